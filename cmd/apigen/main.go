@@ -171,7 +171,8 @@ func loadCustomResourceDefinitions(logger logr.Logger, baseDir string) (map[meta
 			Group:    parts[0],
 			Resource: parts[1],
 		}
-		if gr.Group == apis.GroupName {
+		// the system CRDs of these groups are not exported
+		if gr.Group == apis.GroupName || gr.Group == "apiregistration.k8s.io" {
 			logger.Info(fmt.Sprintf("Skipping CustomResourceDefinition %s from %s", gr.String(), path))
 			return nil
 		}
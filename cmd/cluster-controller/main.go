@@ -29,6 +29,7 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/tools/clientcmd"
 
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
@@ -101,10 +102,12 @@ func main() {
 	apiResource, err := apiresource.NewController(
 		crdClusterClient,
 		kcpClusterClient,
-		options.ApiResourceOptions.AutoPublishAPIs,
+		apiresourcev1alpha1.NegotiationStrategyType(options.ApiResourceOptions.NegotiationStrategy),
+		options.ApiResourceOptions.RequirePublicationApproval,
 		kcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
 		kcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		crdSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		kcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
 	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
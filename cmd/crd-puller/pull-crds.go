@@ -20,19 +20,29 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	apiextensionsv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/yaml"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/cmd/help"
 	"github.com/kcp-dev/kcp/pkg/crdpuller"
 )
 
 func main() {
+	var (
+		pullOptions  crdpuller.PullOptions
+		outputFormat string
+		prefix       string
+		outputDir    string
+	)
+
 	cmd := &cobra.Command{
 		Use:        "pull-crds",
 		Aliases:    []string{},
@@ -42,9 +52,25 @@ func main() {
 					Pull CRDs from a Kubernetes cluster
 					Based on a kubeconfig file, it uses discovery API and the OpenAPI v2
 					model on the cluster to build CRDs for a list of api resource names.
+					CRDs with a conversion webhook are pulled with all their served versions.
+					The pulled resources can be written as CRDs or APIResourceSchemas.
+				`),
+		Example: help.Doc(`
+					# Pull the deployments and services as CRDs, with the preferred version only.
+					pull-crds deployments.apps services
+
+					# Pull all the served versions of the cert-manager certificates, without the status,
+					# as APIResourceSchemas named today.certificates.cert-manager.io.
+					pull-crds certificates.cert-manager.io --all-versions --strip-status --output apiresourceschema --prefix today
 				`),
-		Example: "",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "crd" && outputFormat != "apiresourceschema" {
+				return fmt.Errorf("invalid value %q for --output; valid values are crd, apiresourceschema", outputFormat)
+			}
+			if outputFormat == "apiresourceschema" && prefix == "" {
+				return fmt.Errorf("--prefix is required with --output apiresourceschema")
+			}
+
 			kubeconfigPath := cmd.Flag("kubeconfig").Value.String()
 			config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
 			if err != nil {
@@ -64,16 +90,33 @@ func main() {
 			if err != nil {
 				return err
 			}
-			crds, err := puller.PullCRDs(context.TODO(), args...)
+			crds, err := puller.PullCRDsWithOptions(context.TODO(), pullOptions, args...)
 			if err != nil {
 				return err
 			}
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				return err
+			}
 			for name, crd := range crds {
-				yamlBytes, err := yaml.Marshal(crd)
+				var obj interface{} = crd
+				filename := name.String() + ".yaml"
+				if outputFormat == "apiresourceschema" {
+					apiResourceSchema, err := apisv1alpha1.CRDToAPIResourceSchema(crd, prefix)
+					if err != nil {
+						return fmt.Errorf("error converting CRD %s: %w", crd.Name, err)
+					}
+					apiResourceSchema.TypeMeta = metav1.TypeMeta{
+						APIVersion: apisv1alpha1.SchemeGroupVersion.String(),
+						Kind:       "APIResourceSchema",
+					}
+					obj = apiResourceSchema
+					filename = apiResourceSchema.Name + ".yaml"
+				}
+				yamlBytes, err := yaml.Marshal(obj)
 				if err != nil {
 					return err
 				}
-				if err := os.WriteFile(name.String()+".yaml", yamlBytes, os.ModePerm); err != nil {
+				if err := os.WriteFile(filepath.Join(outputDir, filename), yamlBytes, os.ModePerm); err != nil {
 					return err
 				}
 			}
@@ -82,6 +125,13 @@ func main() {
 	}
 
 	cmd.Flags().String("kubeconfig", ".kubeconfig", "kubeconfig file used to contact the cluster.")
+	cmd.Flags().StringSliceVar(&pullOptions.Versions, "versions", nil, "Versions to pull, e.g. v1beta1. Resources not served at any of these versions are skipped.")
+	cmd.Flags().BoolVar(&pullOptions.AllVersions, "all-versions", false, "Pull all the served versions of the resources, instead of the preferred version only.")
+	cmd.Flags().BoolVar(&pullOptions.StripStatus, "strip-status", false, "Remove the status from the schemas, as well as the status subresource.")
+	cmd.Flags().BoolVar(&pullOptions.StripDefaults, "strip-defaults", false, "Remove the default values from the schemas.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "crd", "Kind of the written objects. Valid values are 'crd' and 'apiresourceschema'.")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Prefix of the APIResourceSchemas' name, before <resource>.<group>. Required with --output apiresourceschema.")
+	cmd.Flags().StringVar(&outputDir, "output-dir", ".", "Directory the pulled resources are written to.")
 
 	help.FitTerminal(cmd.OutOrStdout())
 
@@ -27,9 +27,16 @@ import (
 	"k8s.io/component-base/version"
 	"k8s.io/klog/v2"
 
+	admincmd "github.com/kcp-dev/kcp/pkg/cliplugins/admin/cmd"
+	apibindingcmd "github.com/kcp-dev/kcp/pkg/cliplugins/apibinding/cmd"
+	apiexportcmd "github.com/kcp-dev/kcp/pkg/cliplugins/apiexport/cmd"
+	authcmd "github.com/kcp-dev/kcp/pkg/cliplugins/auth/cmd"
 	bindcmd "github.com/kcp-dev/kcp/pkg/cliplugins/bind/cmd"
 	claimscmd "github.com/kcp-dev/kcp/pkg/cliplugins/claims/cmd"
 	crdcmd "github.com/kcp-dev/kcp/pkg/cliplugins/crd/cmd"
+	getcmd "github.com/kcp-dev/kcp/pkg/cliplugins/get/cmd"
+	logscmd "github.com/kcp-dev/kcp/pkg/cliplugins/logs/cmd"
+	reportcmd "github.com/kcp-dev/kcp/pkg/cliplugins/report/cmd"
 	workloadcmd "github.com/kcp-dev/kcp/pkg/cliplugins/workload/cmd"
 	workspacecmd "github.com/kcp-dev/kcp/pkg/cliplugins/workspace/cmd"
 	"github.com/kcp-dev/kcp/pkg/cmd/help"
@@ -88,5 +95,26 @@ func KubectlKcpCommand() *cobra.Command {
 	claimsCmd := claimscmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
 	root.AddCommand(claimsCmd)
 
+	apiexportCmd := apiexportcmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(apiexportCmd)
+
+	apibindingCmd := apibindingcmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(apibindingCmd)
+
+	getCmd := getcmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(getCmd)
+
+	authCmd := authcmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(authCmd)
+
+	adminCmd := admincmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(adminCmd)
+
+	reportCmd := reportcmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(reportCmd)
+
+	logsCmd := logscmd.New(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	root.AddCommand(logsCmd)
+
 	return root
 }
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/kcp/pkg/cmd/help"
+	"github.com/kcp-dev/kcp/pkg/loadgenerator"
+)
+
+func main() {
+	options := loadgenerator.NewOptions()
+
+	cmd := &cobra.Command{
+		Use:   "load-generator",
+		Short: "Generate workspaces, APIExports, APIBindings and objects against a kcp instance",
+		Long: help.Doc(`
+					Generate workspaces, APIExports, APIBindings and objects against a kcp instance
+					A provider workspace exports resources through APIExports, and consumer workspaces
+					bind them and create objects of the bound resources. The latency of each operation,
+					until the workspaces are ready and the bindings are bound, is reported per phase,
+					together with the memory, CPU and goroutines of the server at the end of each phase.
+				`),
+		Example: help.Doc(`
+					# Create 10k workspaces with 10 bindings each, i.e. 100k bindings, and write the report as JSON.
+					load-generator --workspaces 10000 --exports 100 --bindings-per-workspace 10 --objects-per-workspace 0 --report report.json
+
+					# Run a small load under root:load, and delete the workspaces at the end.
+					load-generator --parent root:load --workspaces 100 --cleanup
+				`),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeconfigPath := cmd.Flag("kubeconfig").Value.String()
+			config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+			if err != nil {
+				return err
+			}
+			// the clients are cluster-aware, strip the workspace the kubeconfig points to, and
+			// default the parent workspace to it.
+			if u, current, err := pluginhelpers.ParseClusterURL(config.Host); err == nil {
+				config.Host = u.String()
+				if !cmd.Flag("parent").Changed {
+					options.Parent = current.String()
+				}
+			}
+
+			if err := utilerrors.NewAggregate(options.Validate()); err != nil {
+				return err
+			}
+
+			generator, err := loadgenerator.New(config, options)
+			if err != nil {
+				return err
+			}
+			_, err = generator.Run(cmd.Context(), cmd.OutOrStdout())
+			return err
+		},
+	}
+
+	cmd.Flags().String("kubeconfig", ".kubeconfig", "kubeconfig file used to contact the kcp instance.")
+	options.AddFlags(cmd.Flags())
+
+	help.FitTerminal(cmd.OutOrStdout())
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		cancel()
+		os.Exit(1) //nolint:gocritic
+	}
+}
@@ -46,4 +46,7 @@ limitations under the License.
 // It will create generic files in .kcp, proxy files in .kcp-front-proxy and shard specific files in .kcp-0, .kcp-1, .kcp-2.
 // The usual .kcp/admin.kubeconfig will direct to the front-proxy. The individual shard .kcp/admin.kubeconfig will direct to
 // the shards.
+//
+// The layout can be described in a YAML file passed with --topology-file, with per-shard flags, front-proxy flags
+// and SNI certificates, and the cache server placement. See the topology type for the format.
 package main
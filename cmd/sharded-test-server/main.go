@@ -31,6 +31,7 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 
 	"github.com/kcp-dev/kcp/cmd/sharded-test-server/third_party/library-go/crypto"
+	"github.com/kcp-dev/kcp/cmd/test-server/helpers"
 	shard "github.com/kcp-dev/kcp/cmd/test-server/kcp"
 	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 )
@@ -39,7 +40,10 @@ func main() {
 	logDirPath := flag.String("log-dir-path", "", "Path to the log files. If empty, log files are stored in the dot directories.")
 	workDirPath := flag.String("work-dir-path", "", "Path to the working directory where the .kcp* dot directories are created. If empty, the working directory is the current directory.")
 	numberOfShards := flag.Int("number-of-shards", 1, "The number of shards to create. The first created is assumed root.")
+	topologyFile := flag.String("topology-file", "", "Path to a YAML file describing the shards with their flags, the front-proxy flags and SNI certificates, and the cache server placement. Overrides --number-of-shards.")
 	quiet := flag.Bool("quiet", false, "Suppress output of the subprocesses")
+	etcdOptions := helpers.NewEtcdOptions()
+	etcdOptions.AddFlags(flag.CommandLine)
 
 	// split flags into --proxy-*, --shard-* and everything else (generic). The former are
 	// passed to the respective components.
@@ -55,13 +59,22 @@ func main() {
 	}
 	flag.CommandLine.Parse(genericFlags) //nolint:errcheck
 
-	if err := start(proxyFlags, shardFlags, *logDirPath, *workDirPath, *numberOfShards, *quiet); err != nil {
+	topo := defaultTopology(*numberOfShards)
+	if *topologyFile != "" {
+		var err error
+		if topo, err = loadTopology(*topologyFile); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if err := start(proxyFlags, shardFlags, etcdOptions, topo, *logDirPath, *workDirPath, *quiet); err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 }
 
-func start(proxyFlags, shardFlags []string, logDirPath, workDirPath string, numberOfShards int, quiet bool) error {
+func start(proxyFlags, shardFlags []string, etcdOptions *helpers.EtcdOptions, topo *topology, logDirPath, workDirPath string, quiet bool) error {
 	ctx, cancelFn := context.WithCancel(genericapiserver.SetupSignalContext())
 	defer cancelFn()
 
@@ -177,15 +190,17 @@ func start(proxyFlags, shardFlags []string, logDirPath, workDirPath string, numb
 
 	cacheServerErrCh := make(chan indexErrTuple)
 	cacheServerConfigPath := ""
-	cacheServerCh, configPath, err := startCacheServer(ctx, logDirPath, workDirPath)
-	if err != nil {
-		return fmt.Errorf("error starting the cache server: %w", err)
+	if topo.CacheServer.Placement == cacheStandalone {
+		cacheServerCh, configPath, err := startCacheServer(ctx, logDirPath, workDirPath)
+		if err != nil {
+			return fmt.Errorf("error starting the cache server: %w", err)
+		}
+		cacheServerConfigPath = configPath
+		go func() {
+			err := <-cacheServerCh
+			cacheServerErrCh <- indexErrTuple{0, err}
+		}()
 	}
-	cacheServerConfigPath = configPath
-	go func() {
-		err := <-cacheServerCh
-		cacheServerErrCh <- indexErrTuple{0, err}
-	}()
 
 	if err := writeLogicalClusterAdminKubeConfig(hostIP.String(), workDirPath); err != nil {
 		return err
@@ -193,8 +208,9 @@ func start(proxyFlags, shardFlags []string, logDirPath, workDirPath string, numb
 
 	// start shards
 	var shards []*shard.Shard
-	for i := 0; i < numberOfShards; i++ {
-		shard, err := newShard(ctx, i, shardFlags, servingCA, hostIP.String(), logDirPath, workDirPath, cacheServerConfigPath)
+	for i, shardTopo := range topo.Shards {
+		args := append(append([]string{}, shardFlags...), shardTopo.Flags...)
+		shard, err := newShard(ctx, i, args, etcdOptions, servingCA, hostIP.String(), logDirPath, workDirPath, cacheServerConfigPath)
 		if err != nil {
 			return err
 		}
@@ -210,7 +226,7 @@ func start(proxyFlags, shardFlags []string, logDirPath, workDirPath string, numb
 		// TODO: support multiple virtual workspace servers (i.e. multiple ports)
 		vwPort = "7444"
 
-		for i := 0; i < numberOfShards; i++ {
+		for i := range topo.Shards {
 			virtualWorkspaceErrCh, err := startVirtual(ctx, i, logDirPath, workDirPath)
 			if err != nil {
 				return fmt.Errorf("error starting virtual workspaces server %d: %w", i, err)
@@ -232,6 +248,11 @@ func start(proxyFlags, shardFlags []string, logDirPath, workDirPath string, numb
 	}
 
 	// start front-proxy
+	sniFlags, err := topo.frontProxySNIFlags(servingCA, filepath.Join(workDirPath, ".kcp-front-proxy"))
+	if err != nil {
+		return err
+	}
+	proxyFlags = append(append(proxyFlags, topo.FrontProxy.Flags...), sniFlags...)
 	if err := startFrontProxy(ctx, proxyFlags, servingCA, hostIP.String(), logDirPath, workDirPath, vwPort, quiet); err != nil {
 		return err
 	}
@@ -25,10 +25,11 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/kcp-dev/kcp/cmd/sharded-test-server/third_party/library-go/crypto"
+	"github.com/kcp-dev/kcp/cmd/test-server/helpers"
 	shard "github.com/kcp-dev/kcp/cmd/test-server/kcp"
 )
 
-func newShard(ctx context.Context, n int, args []string, servingCA *crypto.CA, hostIP string, logDirPath, workDirPath, cacheServerConfigPath string) (*shard.Shard, error) {
+func newShard(ctx context.Context, n int, args []string, etcdOptions *helpers.EtcdOptions, servingCA *crypto.CA, hostIP string, logDirPath, workDirPath, cacheServerConfigPath string) (*shard.Shard, error) {
 	// create serving cert
 	hostnames := sets.NewString("localhost", hostIP)
 	klog.Infof("Creating shard server %d serving cert with hostnames %v", n, hostnames)
@@ -63,10 +64,15 @@ func newShard(ctx context.Context, n int, args []string, servingCA *crypto.CA, h
 		args = append(args,
 			fmt.Sprintf("--shard-name=shard-%d", n),
 			fmt.Sprintf("--root-shard-kubeconfig-file=%s", filepath.Join(workDirPath, ".kcp-0/admin.kubeconfig")),
-			fmt.Sprintf("--embedded-etcd-client-port=%d", embeddedEtcdClientPort(n)),
-			fmt.Sprintf("--embedded-etcd-peer-port=%d", embeddedEtcdPeerPort(n)),
 		)
+		if !etcdOptions.External() {
+			args = append(args,
+				fmt.Sprintf("--embedded-etcd-client-port=%d", embeddedEtcdClientPort(n)),
+				fmt.Sprintf("--embedded-etcd-peer-port=%d", embeddedEtcdPeerPort(n)),
+			)
+		}
 	}
+	args = append(args, etcdOptions.ShardArgs(fmt.Sprintf("kcp-%d", n))...)
 	args = append(args,
 		/*fmt.Sprintf("--cluster-workspace-shard-name=kcp-%d", n),*/
 		fmt.Sprintf("--root-directory=%s", filepath.Join(workDirPath, fmt.Sprintf(".kcp-%d", n))),
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/cmd/sharded-test-server/third_party/library-go/crypto"
+)
+
+// cachePlacement is where the cache server runs.
+type cachePlacement string
+
+const (
+	// cacheStandalone runs a cache server process the shards connect to.
+	cacheStandalone cachePlacement = "standalone"
+	// cacheEmbedded runs no cache server process, each shard uses its own embedded cache.
+	// Objects are not replicated across shards then.
+	cacheEmbedded cachePlacement = "embedded"
+)
+
+// topology is the layout of the servers started by the sharded test server, e.g.:
+//
+//	shards:
+//	- flags: ["--batteries-included=+user"]
+//	- flags: ["--v=6"]
+//	frontProxy:
+//	  sniCertificates:
+//	  - hostnames: ["kcp.example.com"]
+//	cacheServer:
+//	  placement: standalone
+type topology struct {
+	// Shards are the shards to start. The first one is the root shard.
+	Shards []shardTopology `json:"shards"`
+	// FrontProxy configures the front-proxy.
+	FrontProxy frontProxyTopology `json:"frontProxy,omitempty"`
+	// CacheServer configures the cache server.
+	CacheServer cacheServerTopology `json:"cacheServer,omitempty"`
+}
+
+type shardTopology struct {
+	// Flags are passed to this shard only, after the --shard-* flags.
+	Flags []string `json:"flags,omitempty"`
+}
+
+type frontProxyTopology struct {
+	// Flags are passed to the front-proxy, after the --proxy-* flags.
+	Flags []string `json:"flags,omitempty"`
+	// SNICertificates are additional serving certificates of the front-proxy, selected by SNI.
+	SNICertificates []sniCertificate `json:"sniCertificates,omitempty"`
+}
+
+type sniCertificate struct {
+	// Hostnames are the hostnames the certificate is served for. The certificate is signed by
+	// the serving CA, unless CertFile and KeyFile are set.
+	Hostnames []string `json:"hostnames,omitempty"`
+	// CertFile and KeyFile are an existing certificate and key, served for the hostnames they
+	// are valid for.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+}
+
+type cacheServerTopology struct {
+	// Placement is where the cache server runs, standalone or embedded. Defaults to standalone.
+	Placement cachePlacement `json:"placement,omitempty"`
+}
+
+// defaultTopology returns the topology of the given number of shards with the default settings.
+func defaultTopology(numberOfShards int) *topology {
+	return &topology{
+		Shards:      make([]shardTopology, numberOfShards),
+		CacheServer: cacheServerTopology{Placement: cacheStandalone},
+	}
+}
+
+// loadTopology reads and validates the topology file.
+func loadTopology(path string) (*topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t topology
+	if err := yaml.UnmarshalStrict(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse topology file %s: %w", path, err)
+	}
+	if t.CacheServer.Placement == "" {
+		t.CacheServer.Placement = cacheStandalone
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("invalid topology file %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func (t *topology) validate() error {
+	if len(t.Shards) == 0 {
+		return errors.New("at least one shard is required")
+	}
+	if placement := t.CacheServer.Placement; placement != cacheStandalone && placement != cacheEmbedded {
+		return fmt.Errorf("unknown cache server placement %q, must be %s or %s", placement, cacheStandalone, cacheEmbedded)
+	}
+	for i, sni := range t.FrontProxy.SNICertificates {
+		switch {
+		case (sni.CertFile == "") != (sni.KeyFile == ""):
+			return fmt.Errorf("frontProxy.sniCertificates[%d]: certFile and keyFile must be set together", i)
+		case sni.CertFile == "" && len(sni.Hostnames) == 0:
+			return fmt.Errorf("frontProxy.sniCertificates[%d]: hostnames are required to generate a certificate", i)
+		}
+	}
+	return nil
+}
+
+// frontProxySNIFlags returns the --tls-sni-cert-key flags of the front-proxy, generating the
+// certificates without files with the serving CA into dir.
+func (t *topology) frontProxySNIFlags(servingCA *crypto.CA, dir string) ([]string, error) {
+	var flags []string
+	for i, sni := range t.FrontProxy.SNICertificates {
+		certFile, keyFile := sni.CertFile, sni.KeyFile
+		if certFile == "" {
+			certFile, keyFile = filepath.Join(dir, fmt.Sprintf("sni-%d.crt", i)), filepath.Join(dir, fmt.Sprintf("sni-%d.key", i))
+			cert, err := servingCA.MakeServerCert(sets.NewString(sni.Hostnames...), 365)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create SNI cert for %v: %w", sni.Hostnames, err)
+			}
+			if err := cert.WriteCertConfigFile(certFile, keyFile); err != nil {
+				return nil, fmt.Errorf("failed to write SNI cert for %v: %w", sni.Hostnames, err)
+			}
+		}
+		// without explicit domain patterns, the certificate is served for the names it is valid for
+		flags = append(flags, fmt.Sprintf("--tls-sni-cert-key=%s,%s", certFile, keyFile))
+	}
+	return flags, nil
+}
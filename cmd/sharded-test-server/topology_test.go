@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadTopology(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		content       string
+		expected      *topology
+		expectedError string
+	}{
+		{
+			name: "shards with flags and defaulted cache server",
+			content: `
+shards:
+- flags: ["--v=6"]
+- {}
+frontProxy:
+  flags: ["--v=4"]
+  sniCertificates:
+  - hostnames: ["kcp.example.com"]
+`,
+			expected: &topology{
+				Shards: []shardTopology{{Flags: []string{"--v=6"}}, {}},
+				FrontProxy: frontProxyTopology{
+					Flags:           []string{"--v=4"},
+					SNICertificates: []sniCertificate{{Hostnames: []string{"kcp.example.com"}}},
+				},
+				CacheServer: cacheServerTopology{Placement: cacheStandalone},
+			},
+		},
+		{
+			name: "embedded cache server",
+			content: `
+shards:
+- {}
+cacheServer:
+  placement: embedded
+`,
+			expected: &topology{
+				Shards:      []shardTopology{{}},
+				CacheServer: cacheServerTopology{Placement: cacheEmbedded},
+			},
+		},
+		{
+			name:          "no shard",
+			content:       `shards: []`,
+			expectedError: "at least one shard is required",
+		},
+		{
+			name: "unknown cache server placement",
+			content: `
+shards:
+- {}
+cacheServer:
+  placement: root-shard
+`,
+			expectedError: `unknown cache server placement "root-shard"`,
+		},
+		{
+			name: "SNI certificate without key",
+			content: `
+shards:
+- {}
+frontProxy:
+  sniCertificates:
+  - certFile: kcp.crt
+`,
+			expectedError: "certFile and keyFile must be set together",
+		},
+		{
+			name: "unknown field",
+			content: `
+shards:
+- args: ["--v=6"]
+`,
+			expectedError: `unknown field "args"`,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "topology.yaml")
+			if err := os.WriteFile(path, []byte(scenario.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := loadTopology(path)
+			if scenario.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), scenario.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", scenario.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, scenario.expected) {
+				t.Fatalf("unexpected topology %#v, expected %#v", actual, scenario.expected)
+			}
+		})
+	}
+}
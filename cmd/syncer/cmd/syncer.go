@@ -32,6 +32,7 @@ import (
 
 	synceroptions "github.com/kcp-dev/kcp/cmd/syncer/options"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
+	"github.com/kcp-dev/kcp/pkg/spiffe"
 	"github.com/kcp-dev/kcp/pkg/syncer"
 )
 
@@ -95,6 +96,15 @@ func Run(ctx context.Context, options *synceroptions.Options) error {
 	upstreamConfig.QPS = options.QPS
 	upstreamConfig.Burst = options.Burst
 
+	if options.SPIFFESVIDCertFile != "" {
+		source, err := spiffe.NewX509Source(options.SPIFFESVIDCertFile, options.SPIFFESVIDKeyFile, options.SPIFFETrustBundleFile)
+		if err != nil {
+			return err
+		}
+		go source.Run(ctx, spiffe.DefaultReloadInterval)
+		upstreamConfig = source.WrapConfig(upstreamConfig)
+	}
+
 	downstreamConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.ToKubeconfig},
 		&clientcmd.ConfigOverrides{
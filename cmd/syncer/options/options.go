@@ -46,6 +46,10 @@ type Options struct {
 	DNSImage                      string
 	DownstreamNamespaceCleanDelay time.Duration
 
+	SPIFFESVIDCertFile    string
+	SPIFFESVIDKeyFile     string
+	SPIFFETrustBundleFile string
+
 	APIImportPollInterval time.Duration
 }
 
@@ -82,6 +86,9 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 		"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
 	fs.StringVar(&options.DNSImage, "dns-image", options.DNSImage, "kcp DNS server image.")
 	fs.DurationVar(&options.DownstreamNamespaceCleanDelay, "downstream-namespace-clean-delay", options.DownstreamNamespaceCleanDelay, "Time to wait before deleting a downstream namespace, defaults to 30s.")
+	fs.StringVar(&options.SPIFFESVIDCertFile, "spiffe-svid-cert-file", options.SPIFFESVIDCertFile, "File holding the PEM X.509 SVID authenticating the syncer to kcp, reloaded when rotated. It replaces the TLS options of --from-kubeconfig, whose token should be removed for the SVID to be used.")
+	fs.StringVar(&options.SPIFFESVIDKeyFile, "spiffe-svid-key-file", options.SPIFFESVIDKeyFile, "File holding the PEM private key of --spiffe-svid-cert-file.")
+	fs.StringVar(&options.SPIFFETrustBundleFile, "spiffe-trust-bundle-file", options.SPIFFETrustBundleFile, "File holding the PEM trust bundle verifying the kcp server when --spiffe-svid-cert-file is set, reloaded when rotated.")
 
 	options.Logs.AddFlags(fs)
 }
@@ -100,5 +107,11 @@ func (options *Options) Validate() error {
 	if options.SyncTargetUID == "" {
 		return errors.New("--sync-target-uid is required")
 	}
+	if (options.SPIFFESVIDCertFile == "") != (options.SPIFFESVIDKeyFile == "") {
+		return errors.New("--spiffe-svid-cert-file and --spiffe-svid-key-file must be set together")
+	}
+	if options.SPIFFESVIDCertFile != "" && options.SPIFFETrustBundleFile == "" {
+		return errors.New("--spiffe-trust-bundle-file is required if --spiffe-svid-cert-file is set")
+	}
 	return nil
 }
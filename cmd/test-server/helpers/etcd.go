@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"flag"
+	"path"
+)
+
+// EtcdOptions configures the shards to store their data in an external etcd instead of
+// the embedded one.
+type EtcdOptions struct {
+	Servers  string
+	Prefix   string
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// NewEtcdOptions returns EtcdOptions defaulting to the embedded etcd.
+func NewEtcdOptions() *EtcdOptions {
+	return &EtcdOptions{
+		Prefix: "/kcp",
+	}
+}
+
+// AddFlags registers the external etcd flags.
+func (o *EtcdOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Servers, "etcd-servers", o.Servers, "Comma-separated list of external etcd servers the shards connect to. If empty, each shard runs an embedded etcd.")
+	fs.StringVar(&o.Prefix, "etcd-prefix", o.Prefix, "Prefix under which each shard stores its data in the external etcd, followed by the shard name.")
+	fs.StringVar(&o.CAFile, "etcd-cafile", o.CAFile, "SSL Certificate Authority file used to secure the external etcd communication.")
+	fs.StringVar(&o.CertFile, "etcd-certfile", o.CertFile, "SSL certification file used to secure the external etcd communication.")
+	fs.StringVar(&o.KeyFile, "etcd-keyfile", o.KeyFile, "SSL key file used to secure the external etcd communication.")
+}
+
+// External returns whether the shards use an external etcd.
+func (o *EtcdOptions) External() bool {
+	return o.Servers != ""
+}
+
+// ShardArgs returns the kcp flags for the given shard to use the external etcd. Each shard
+// stores its data under its own prefix, such that the shards can share the etcd servers.
+func (o *EtcdOptions) ShardArgs(shardName string) []string {
+	if !o.External() {
+		return nil
+	}
+	args := []string{
+		"--etcd-servers=" + o.Servers,
+		"--etcd-prefix=" + path.Join(o.Prefix, shardName),
+	}
+	if o.CAFile != "" {
+		args = append(args, "--etcd-cafile="+o.CAFile)
+	}
+	if o.CertFile != "" {
+		args = append(args, "--etcd-certfile="+o.CertFile)
+	}
+	if o.KeyFile != "" {
+		args = append(args, "--etcd-keyfile="+o.KeyFile)
+	}
+	return args
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEtcdOptionsShardArgs(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		options  EtcdOptions
+		expected []string
+	}{
+		{
+			name:    "embedded etcd",
+			options: *NewEtcdOptions(),
+		},
+		{
+			name:    "external etcd",
+			options: EtcdOptions{Servers: "https://etcd-0:2379,https://etcd-1:2379", Prefix: "/kcp"},
+			expected: []string{
+				"--etcd-servers=https://etcd-0:2379,https://etcd-1:2379",
+				"--etcd-prefix=/kcp/kcp-1",
+			},
+		},
+		{
+			name:    "external etcd with TLS",
+			options: EtcdOptions{Servers: "https://etcd-0:2379", Prefix: "/perf/", CAFile: "ca.crt", CertFile: "client.crt", KeyFile: "client.key"},
+			expected: []string{
+				"--etcd-servers=https://etcd-0:2379",
+				"--etcd-prefix=/perf/kcp-1",
+				"--etcd-cafile=ca.crt",
+				"--etcd-certfile=client.crt",
+				"--etcd-keyfile=client.key",
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			actual := scenario.options.ShardArgs("kcp-1")
+			if !reflect.DeepEqual(actual, scenario.expected) {
+				t.Fatalf("unexpected shard args %v, expected %v", actual, scenario.expected)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	genericapiserver "k8s.io/apiserver/pkg/server"
 
+	"github.com/kcp-dev/kcp/cmd/test-server/helpers"
 	shard "github.com/kcp-dev/kcp/cmd/test-server/kcp"
 )
 
@@ -45,9 +46,15 @@ import (
 // Run individual tests against a persistent server:
 //
 //	$ go test -v --use-default-kcp-server
+//
+// Store the data in an external etcd instead of the embedded one:
+//
+//	$ ./bin/test-server --etcd-servers=https://127.0.0.1:2379 --etcd-cafile=ca.crt --etcd-certfile=client.crt --etcd-keyfile=client.key
 func main() {
 	flag.String("log-file-path", ".kcp/kcp.log", "Path to the log file")
 	quiet := flag.Bool("quiet", false, "Suppress output of the subprocesses")
+	etcdOptions := helpers.NewEtcdOptions()
+	etcdOptions.AddFlags(flag.CommandLine)
 
 	// split flags into --shard-* and everything else (generic). The former are
 	// passed to the respective components. Everything after "--" is considered a shard flag.
@@ -65,7 +72,7 @@ func main() {
 	}
 	flag.CommandLine.Parse(genericFlags) //nolint:errcheck
 
-	if err := start(shardFlags, *quiet); err != nil {
+	if err := start(shardFlags, etcdOptions, *quiet); err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			os.Exit(exitErr.ExitCode())
@@ -75,7 +82,7 @@ func main() {
 	}
 }
 
-func start(shardFlags []string, quiet bool) error {
+func start(shardFlags []string, etcdOptions *helpers.EtcdOptions, quiet bool) error {
 	ctx, cancelFn := context.WithCancel(genericapiserver.SetupSignalContext())
 	defer cancelFn()
 
@@ -84,7 +91,7 @@ func start(shardFlags []string, quiet bool) error {
 		"kcp",
 		".kcp",
 		logFilePath,
-		append(shardFlags, "--audit-log-path", filepath.Join(filepath.Dir(logFilePath), "audit.log")),
+		append(append(shardFlags, etcdOptions.ShardArgs("kcp")...), "--audit-log-path", filepath.Join(filepath.Dir(logFilePath), "audit.log")),
 	)
 	if err := shard.Start(ctx, quiet); err != nil {
 		return err
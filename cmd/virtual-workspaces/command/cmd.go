@@ -37,6 +37,7 @@ import (
 	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/component-base/config"
+	"k8s.io/component-base/traces"
 	"k8s.io/klog/v2"
 
 	"github.com/kcp-dev/kcp/cmd/virtual-workspaces/options"
@@ -93,6 +94,17 @@ func Run(ctx context.Context, o *options.Options) error {
 	u.Path = ""
 	nonIdentityConfig.Host = u.String()
 
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "", Version: "v1"})
+	codecs := serializer.NewCodecFactory(scheme)
+	recommendedConfig := genericapiserver.NewRecommendedConfig(codecs)
+
+	// the tracer provider is set up first, so that the requests to kcp are traced as children of the virtual workspace spans
+	if err := o.Tracing.ApplyTo(nil, &recommendedConfig.Config); err != nil {
+		return err
+	}
+	nonIdentityConfig.Wrap(traces.WrapperFor(recommendedConfig.TracerProvider))
+
 	// resolve identities for system APIBindings
 	identityConfig, resolveIdentities := bootstrap.NewConfigWithWildcardIdentities(nonIdentityConfig, bootstrap.KcpRootGroupExportNames, bootstrap.KcpRootGroupResourceExportNames, nil)
 	if err := wait.PollImmediateInfiniteWithContext(ctx, time.Millisecond*500, func(ctx context.Context) (bool, error) {
@@ -129,23 +141,20 @@ func Run(ctx context.Context, o *options.Options) error {
 	if err != nil {
 		return err
 	}
-	scheme := runtime.NewScheme()
-	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "", Version: "v1"})
-	codecs := serializer.NewCodecFactory(scheme)
-	recommendedConfig := genericapiserver.NewRecommendedConfig(codecs)
 	if err := o.SecureServing.ApplyTo(&recommendedConfig.Config.SecureServing); err != nil {
 		return err
 	}
 	if err := o.Authentication.ApplyTo(&recommendedConfig.Authentication, recommendedConfig.SecureServing, recommendedConfig.OpenAPIConfig); err != nil {
 		return err
 	}
+	recommendedConfig.Authentication.APIAudiences = o.APIAudiences
 	if err := o.Authorization.ApplyTo(&recommendedConfig.Config, virtualWorkspaces); err != nil {
 		return err
 	}
 	if err := o.Audit.ApplyTo(&recommendedConfig.Config); err != nil {
 		return err
 	}
-	rootAPIServerConfig, err := virtualrootapiserver.NewRootAPIConfig(recommendedConfig, []virtualrootapiserver.InformerStart{
+	rootAPIServerConfig, err := virtualrootapiserver.NewRootAPIConfig(recommendedConfig, o.RootPathPrefix, []virtualrootapiserver.InformerStart{
 		wildcardKubeInformers.Start,
 		wildcardKcpInformers.Start,
 	}, virtualWorkspaces)
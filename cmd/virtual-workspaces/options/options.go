@@ -46,8 +46,10 @@ type Options struct {
 
 	SecureServing  genericapiserveroptions.SecureServingOptions
 	Authentication genericapiserveroptions.DelegatingAuthenticationOptions
+	APIAudiences   []string
 	Authorization  virtualworkspacesoptions.Authorization
 	Audit          genericapiserveroptions.AuditOptions
+	Tracing        *genericapiserveroptions.TracingOptions
 
 	Logs logs.Options
 
@@ -65,6 +67,7 @@ func NewOptions() *Options {
 		Authentication: *genericapiserveroptions.NewDelegatingAuthenticationOptions(),
 		Authorization:  *virtualworkspacesoptions.NewAuthorization(),
 		Audit:          *genericapiserveroptions.NewAuditOptions(),
+		Tracing:        genericapiserveroptions.NewTracingOptions(),
 		Logs:           *logs.NewOptions(),
 
 		VirtualWorkspaces: *virtualworkspacesoptions.NewOptions(),
@@ -81,6 +84,7 @@ func NewOptions() *Options {
 func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	o.SecureServing.AddFlags(flags)
 	o.Authentication.AddFlags(flags)
+	o.Tracing.AddFlags(flags)
 	o.Logs.AddFlags(flags)
 	o.VirtualWorkspaces.AddFlags(flags)
 
@@ -89,6 +93,7 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	_ = cobra.MarkFlagRequired(flags, "kubeconfig")
 
 	flags.StringVar(&o.Context, "context", o.Context, "Name of the context in the kubeconfig file to use")
+	flags.StringSliceVar(&o.APIAudiences, "api-audiences", o.APIAudiences, "The API audiences of the KCP instance. If set, the service account tokens bound to the audience of a virtual workspace, i.e., the path of its URL, are accepted by that virtual workspace, in addition to the tokens bound to these audiences.")
 	flags.StringVar(&o.ProfilerAddress, "profiler-address", "", "[Address]:port to bind the profiler to")
 }
 
@@ -96,6 +101,7 @@ func (o *Options) Validate() error {
 	errs := []error{}
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
+	errs = append(errs, o.Tracing.Validate()...)
 	errs = append(errs, o.VirtualWorkspaces.Validate()...)
 
 	if len(o.KubeconfigFile) == 0 {
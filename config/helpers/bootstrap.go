@@ -20,10 +20,10 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"embed"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"strings"
 	"text/template"
 	"time"
@@ -77,7 +77,7 @@ func ReplaceOption(pairs ...string) Option {
 // Bootstrap creates resources in a package's fs by
 // continuously retrying the list. This is blocking, i.e. it only returns (with error)
 // when the context is closed or with nil when the bootstrapping is successfully completed.
-func Bootstrap(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, batteriesIncluded sets.String, fs embed.FS, opts ...Option) error {
+func Bootstrap(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, batteriesIncluded sets.String, fsys fs.FS, opts ...Option) error {
 	cache := memory.NewMemCacheClient(discoveryClient)
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cache)
 
@@ -87,7 +87,7 @@ func Bootstrap(ctx context.Context, discoveryClient discovery.DiscoveryInterface
 		transformers = append(transformers, opt.TransformFile)
 	}
 	return wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
-		if err := CreateResourcesFromFS(ctx, dynamicClient, mapper, batteriesIncluded, fs, transformers...); err != nil {
+		if err := CreateResourcesFromFS(ctx, dynamicClient, mapper, batteriesIncluded, fsys, transformers...); err != nil {
 			klog.Infof("Failed to bootstrap resources, retrying: %v", err)
 			// invalidate cache if resources not found
 			// xref: https://github.com/kcp-dev/kcp/issues/655
@@ -99,8 +99,8 @@ func Bootstrap(ctx context.Context, discoveryClient discovery.DiscoveryInterface
 }
 
 // CreateResourcesFromFS creates all resources from a filesystem.
-func CreateResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, batteriesIncluded sets.String, fs embed.FS, transformers ...TransformFileFunc) error {
-	files, err := fs.ReadDir(".")
+func CreateResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, batteriesIncluded sets.String, fsys fs.FS, transformers ...TransformFileFunc) error {
+	files, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return err
 	}
@@ -110,7 +110,7 @@ func CreateResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper
 		if f.IsDir() {
 			continue
 		}
-		if err := CreateResourceFromFS(ctx, client, mapper, batteriesIncluded, f.Name(), fs, transformers...); err != nil {
+		if err := CreateResourceFromFS(ctx, client, mapper, batteriesIncluded, f.Name(), fsys, transformers...); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -118,8 +118,8 @@ func CreateResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper
 }
 
 // CreateResourceFromFS creates given resource file.
-func CreateResourceFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, batteriesIncluded sets.String, filename string, fs embed.FS, transformers ...TransformFileFunc) error {
-	raw, err := fs.ReadFile(filename)
+func CreateResourceFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, batteriesIncluded sets.String, filename string, fsys fs.FS, transformers ...TransformFileFunc) error {
+	raw, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return fmt.Errorf("could not read %s: %w", filename, err)
 	}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hierarchyroot
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	confighelpers "github.com/kcp-dev/kcp/config/helpers"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+//go:embed *.yaml
+var fs embed.FS
+
+// Bootstrap creates an additional top-level hierarchy root, next to the root workspace, by continuously
+// retrying the list. The hierarchy root binds the root APIs and is of the root:root type, but none of the
+// RBAC of the root workspace is replicated, i.e. only the kcp admins have access to it until they grant it.
+// WorkspaceTypes created in the hierarchy root are only resolved by the workspaces of its hierarchy.
+//
+// This is blocking, i.e. it only returns (with error) when the context is closed or with nil when
+// the bootstrapping is successfully completed.
+func Bootstrap(ctx context.Context, kcpClient kcpclient.Interface, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, batteriesIncluded sets.String) error {
+	if err := confighelpers.BindRootAPIs(ctx, kcpClient, "shards.core.kcp.io", "tenancy.kcp.io", "scheduling.kcp.io", "workload.kcp.io", "apiresource.kcp.io", "topology.kcp.io"); err != nil {
+		return err
+	}
+	if err := confighelpers.Bootstrap(ctx, discoveryClient, dynamicClient, batteriesIncluded, fs); err != nil {
+		return err
+	}
+
+	// set LogicalCluster to Initializing
+	return wait.PollImmediateUntilWithContext(ctx, time.Millisecond*100, func(ctx context.Context) (done bool, err error) {
+		logger := klog.FromContext(ctx).WithValues("bootstrapping", "hierarchy-root")
+		logicalCluster, err := kcpClient.CoreV1alpha1().LogicalClusters().Get(ctx, corev1alpha1.LogicalClusterName, metav1.GetOptions{})
+		if err != nil {
+			logger.Error(err, "failed to get this workspace in the hierarchy root")
+			return false, nil
+		}
+		if logicalCluster.Status.Phase == corev1alpha1.LogicalClusterPhaseScheduling {
+			logicalCluster.Status.Phase = corev1alpha1.LogicalClusterPhaseInitializing
+			_, err = kcpClient.CoreV1alpha1().LogicalClusters().UpdateStatus(ctx, logicalCluster, metav1.UpdateOptions{})
+			if err != nil {
+				logger.Error(err, "failed to update LogicalCluster of the hierarchy root")
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
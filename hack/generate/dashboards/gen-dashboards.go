@@ -0,0 +1,374 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gen-dashboards generates the Grafana dashboard and the Prometheus alerting rules of the kcp
+// controllers from the workqueue and reconcile metrics registered in the metrics registry.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"sigs.k8s.io/yaml"
+
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+)
+
+const (
+	// sampleController is the controller the metrics are recorded for, so that the vectors of the
+	// registry have series to gather.
+	sampleController = "kcp-dashboard-sample"
+
+	dashboardFile = "kcp-controllers-dashboard.json"
+	alertsFile    = "kcp-controllers-alerts.yaml"
+
+	rateInterval = "5m"
+)
+
+// metric is a metric of the controllers, as gathered from the registry.
+type metric struct {
+	name string
+	help string
+	// kind is the Prometheus metric type, e.g., COUNTER.
+	kind string
+	// controllerLabel is the label holding the controller name.
+	controllerLabel string
+	// labels are the other labels of the metric.
+	labels []string
+}
+
+func main() {
+	outputDir := flag.String("output-dir", filepath.Join("contrib", "observability"), "Directory to write the dashboard and the alerting rules to.")
+	flag.Parse()
+
+	dashboard, alerts, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatalf("Failed to create the output directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outputDir, dashboardFile), dashboard, 0644); err != nil {
+		log.Fatalf("Failed to write the dashboard: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*outputDir, alertsFile), alerts, 0644); err != nil {
+		log.Fatalf("Failed to write the alerting rules: %v", err)
+	}
+}
+
+// generate returns the dashboard and the alerting rules of the controller metrics registered in
+// the registry.
+func generate() (dashboard, alerts []byte, err error) {
+	metrics, err := gatherControllerMetrics()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to gather the controller metrics: %w", err)
+	}
+
+	dashboard, err = json.MarshalIndent(newDashboard(metrics), "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal the dashboard: %w", err)
+	}
+	rules, err := newAlertingRules(metrics)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the alerting rules: %w", err)
+	}
+	alerts, err = yaml.Marshal(rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal the alerting rules: %w", err)
+	}
+	return append(dashboard, '\n'), alerts, nil
+}
+
+// gatherControllerMetrics records samples of the controller metrics, and gathers their families
+// from the registry.
+func gatherControllerMetrics() ([]metric, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), sampleController)
+	queue.AddRateLimited("key")
+	queue.ShutDown()
+	reconcilermetrics.ObserveReconcile(sampleController, time.Now(), nil)
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []metric
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "workqueue_") && !strings.HasPrefix(family.GetName(), "controller_reconcile_") {
+			continue
+		}
+		m := metric{
+			name: family.GetName(),
+			help: family.GetHelp(),
+			kind: family.GetType().String(),
+		}
+		if len(family.GetMetric()) > 0 {
+			for _, label := range family.GetMetric()[0].GetLabel() {
+				switch {
+				case label.GetValue() == sampleController:
+					m.controllerLabel = label.GetName()
+				default:
+					m.labels = append(m.labels, label.GetName())
+				}
+			}
+		}
+		if m.controllerLabel == "" {
+			return nil, fmt.Errorf("metric %s has no controller label", m.name)
+		}
+		metrics = append(metrics, m)
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no controller metrics registered")
+	}
+
+	// reconcile metrics first, then workqueue metrics, by name
+	sort.Slice(metrics, func(i, j int) bool {
+		ri, rj := strings.HasPrefix(metrics[i].name, "controller_"), strings.HasPrefix(metrics[j].name, "controller_")
+		if ri != rj {
+			return ri
+		}
+		return metrics[i].name < metrics[j].name
+	})
+	return metrics, nil
+}
+
+func (m metric) selector() string {
+	return fmt.Sprintf(`%s{%s=~"$controller"}`, m.name, m.controllerLabel)
+}
+
+func (m metric) groupBy(extra ...string) string {
+	return strings.Join(append(append([]string{m.controllerLabel}, m.labels...), extra...), ", ")
+}
+
+func (m metric) legend() string {
+	var legend []string
+	for _, label := range append([]string{m.controllerLabel}, m.labels...) {
+		legend = append(legend, "{{"+label+"}}")
+	}
+	return strings.Join(legend, " ")
+}
+
+// unit returns the Grafana unit of the panels of the metric.
+func (m metric) unit() string {
+	switch {
+	case m.kind == "COUNTER":
+		return "ops"
+	case strings.HasSuffix(m.name, "_seconds"):
+		return "s"
+	default:
+		return "short"
+	}
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// targets returns the queries of the panel of the metric, depending on its type.
+func (m metric) targets() []target {
+	switch m.kind {
+	case "COUNTER":
+		return []target{{
+			Expr:         fmt.Sprintf("sum by (%s) (rate(%s[%s]))", m.groupBy(), m.selector(), rateInterval),
+			LegendFormat: m.legend(),
+			RefID:        "A",
+		}}
+	case "HISTOGRAM":
+		bucket := fmt.Sprintf(`%s_bucket{%s=~"$controller"}`, m.name, m.controllerLabel)
+		var targets []target
+		for i, quantile := range []float64{0.5, 0.99} {
+			targets = append(targets, target{
+				Expr:         fmt.Sprintf("histogram_quantile(%g, sum by (%s) (rate(%s[%s])))", quantile, m.groupBy("le"), bucket, rateInterval),
+				LegendFormat: fmt.Sprintf("p%g %s", quantile*100, m.legend()),
+				RefID:        string(rune('A' + i)),
+			})
+		}
+		return targets
+	default:
+		return []target{{
+			Expr:         fmt.Sprintf("max by (%s) (%s)", m.groupBy(), m.selector()),
+			LegendFormat: m.legend(),
+			RefID:        "A",
+		}}
+	}
+}
+
+func newDashboard(metrics []metric) map[string]interface{} {
+	var reconciles metric
+	for _, m := range metrics {
+		if m.name == reconcilermetrics.ReconcilesMetricName {
+			reconciles = m
+		}
+	}
+
+	panels := make([]map[string]interface{}, 0, len(metrics))
+	for i, m := range metrics {
+		panels = append(panels, map[string]interface{}{
+			"id":          i + 1,
+			"type":        "timeseries",
+			"title":       m.name,
+			"description": m.help,
+			"datasource":  "${datasource}",
+			"gridPos":     map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"targets":     m.targets(),
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": m.unit()},
+				"overrides": []interface{}{},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"title":         "kcp controllers",
+		"uid":           "kcp-controllers",
+		"description":   "Workqueue and reconcile metrics of the kcp controllers. Generated by hack/generate/dashboards, do not edit.",
+		"tags":          []string{"kcp"},
+		"editable":      false,
+		"schemaVersion": 36,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-1h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				{
+					"name":       "controller",
+					"type":       "query",
+					"datasource": "${datasource}",
+					"query":      fmt.Sprintf("label_values(%s, %s)", reconciles.name, reconciles.controllerLabel),
+					"multi":      true,
+					"includeAll": true,
+					"refresh":    2,
+				},
+			},
+		},
+		"panels": panels,
+	}
+}
+
+type ruleGroups struct {
+	Groups []ruleGroup `json:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// newAlertingRules returns the alerting rules of the controllers. It fails if a metric the rules
+// rely on is not registered.
+func newAlertingRules(metrics []metric) (*ruleGroups, error) {
+	byName := map[string]metric{}
+	for _, m := range metrics {
+		byName[m.name] = m
+	}
+	lookup := func(name string) (metric, error) {
+		m, ok := byName[name]
+		if !ok {
+			return metric{}, fmt.Errorf("metric %s is not registered", name)
+		}
+		return m, nil
+	}
+
+	reconciles, err := lookup(reconcilermetrics.ReconcilesMetricName)
+	if err != nil {
+		return nil, err
+	}
+	reconcileDuration, err := lookup(reconcilermetrics.ReconcileDurationMetricName)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := lookup("workqueue_depth")
+	if err != nil {
+		return nil, err
+	}
+	longestRunning, err := lookup("workqueue_longest_running_processor_seconds")
+	if err != nil {
+		return nil, err
+	}
+
+	warning := map[string]string{"severity": "warning"}
+	return &ruleGroups{Groups: []ruleGroup{{
+		Name: "kcp-controllers",
+		Rules: []rule{
+			{
+				Alert: "KcpControllerReconcileErrors",
+				Expr: fmt.Sprintf(`sum by (%[1]s) (rate(%[2]s{%[3]s="%[4]s"}[10m])) / sum by (%[1]s) (rate(%[2]s[10m])) > 0.1`,
+					reconciles.controllerLabel, reconciles.name, reconcilermetrics.ResultLabel, reconcilermetrics.ResultError),
+				For:    "15m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     "A kcp controller fails to reconcile",
+					"description": fmt.Sprintf("More than 10%% of the reconciliations of the {{ $labels.%s }} controller failed over the last 15 minutes.", reconciles.controllerLabel),
+				},
+			},
+			{
+				Alert: "KcpControllerReconcileSlow",
+				Expr: fmt.Sprintf(`histogram_quantile(0.99, sum by (%s, le) (rate(%s_bucket[10m]))) > 10`,
+					reconcileDuration.controllerLabel, reconcileDuration.name),
+				For:    "15m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     "A kcp controller reconciles slowly",
+					"description": fmt.Sprintf("The 99th percentile of the reconciliation duration of the {{ $labels.%s }} controller is above 10 seconds.", reconcileDuration.controllerLabel),
+				},
+			},
+			{
+				Alert:  "KcpControllerQueueBacklog",
+				Expr:   fmt.Sprintf(`max by (%[1]s) (%[2]s{%[1]s=~"kcp-.*"}) > 100`, depth.controllerLabel, depth.name),
+				For:    "15m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     "A kcp controller falls behind",
+					"description": fmt.Sprintf("The queue of the {{ $labels.%s }} controller has held more than 100 keys for 15 minutes.", depth.controllerLabel),
+				},
+			},
+			{
+				Alert:  "KcpControllerStuck",
+				Expr:   fmt.Sprintf(`max by (%[1]s) (%[2]s{%[1]s=~"kcp-.*"}) > 600`, longestRunning.controllerLabel, longestRunning.name),
+				For:    "5m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     "A kcp controller is stuck",
+					"description": fmt.Sprintf("A worker of the {{ $labels.%s }} controller has been processing a key for more than 10 minutes.", longestRunning.controllerLabel),
+				},
+			},
+		},
+	}}}, nil
+}
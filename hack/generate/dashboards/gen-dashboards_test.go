@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "Update the golden files of the dashboard and the alerting rules.")
+
+// TestGenerate compares the generated dashboard and alerting rules to the golden files in testdata.
+// Run it with -update to regenerate the golden files after changing the controller metrics.
+func TestGenerate(t *testing.T) {
+	dashboard, alerts, err := generate()
+	require.NoError(t, err)
+
+	for file, got := range map[string][]byte{
+		dashboardFile: dashboard,
+		alertsFile:    alerts,
+	} {
+		golden := filepath.Join("testdata", file)
+		if *update {
+			require.NoError(t, os.WriteFile(golden, got, 0644))
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), "%s is out of date, run the test with -update to regenerate it", golden)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/kcp-dev/logicalcluster/v3"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,6 +46,7 @@ import (
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/tracing"
 )
 
 const (
@@ -163,6 +165,14 @@ func (o *apiBindingAdmission) Admit(ctx context.Context, a admission.Attributes,
 		)
 	}
 
+	// let the binding controller join the trace of the request that changed the spec of the binding,
+	// and only that one.
+	if a.GetOperation() == admission.Create {
+		tracing.InjectTraceContext(ctx, apiBinding, 1)
+	} else if a.GetOperation() == admission.Update && !equality.Semantic.DeepEqual(apiBinding.Spec, oldAPIBinding.Spec) {
+		tracing.InjectTraceContext(ctx, apiBinding, oldAPIBinding.Generation+1)
+	}
+
 	// write back
 	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(apiBinding)
 	if err != nil {
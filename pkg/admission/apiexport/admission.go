@@ -17,17 +17,34 @@ limitations under the License.
 package apiexport
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/endpoints/request"
 
+	"github.com/kcp-dev/kcp/pkg/admission/initializers"
 	"github.com/kcp-dev/kcp/pkg/apis/apis"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 	builtinapiexport "github.com/kcp-dev/kcp/pkg/virtual/apiexport/schemas/builtin"
 )
 
@@ -47,18 +64,28 @@ type APIExportAdmission struct {
 	*admission.Handler
 
 	isBuiltIn func(apisv1alpha1.GroupResource) bool
+
+	apiResourceSchemaLister apisv1alpha1listers.APIResourceSchemaClusterLister
+	kcpClusterClient        kcpclientset.ClusterInterface
+
+	getAPIResourceSchema func(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
 }
 
 // NewAPIExportAdmission constructs a new APIExportAdmission admission plugin.
 func NewAPIExportAdmission(isBuiltIn func(apisv1alpha1.GroupResource) bool) *APIExportAdmission {
-	return &APIExportAdmission{
+	p := &APIExportAdmission{
 		Handler:   admission.NewHandler(admission.Create, admission.Update),
 		isBuiltIn: isBuiltIn,
 	}
+	p.getAPIResourceSchema = p.readThroughGetAPIResourceSchema
+	return p
 }
 
 // Ensure that the required admission interfaces are implemented.
 var _ = admission.ValidationInterface(&APIExportAdmission{})
+var _ = admission.InitializationValidator(&APIExportAdmission{})
+var _ = initializers.WantsKcpInformers(&APIExportAdmission{})
+var _ = initializers.WantsKcpClusterClient(&APIExportAdmission{})
 
 // Validate ensures that the APIExport is valid.
 func (e *APIExportAdmission) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
@@ -92,5 +119,153 @@ func (e *APIExportAdmission) Validate(ctx context.Context, a admission.Attribute
 		}
 	}
 
+	if errs := e.validateLatestResourceSchemas(ctx, a, ae); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
+	return nil
+}
+
+// validateLatestResourceSchemas verifies that the APIResourceSchemas newly referenced by the
+// APIExport exist and round-trip, and optionally that their bound CRDs are valid, so that a
+// broken APIExport is rejected rather than discovered by its consumers. The schemas that are
+// already referenced are not validated again, not to block unrelated updates.
+func (e *APIExportAdmission) validateLatestResourceSchemas(ctx context.Context, a admission.Attributes, ae *apisv1alpha1.APIExport) field.ErrorList {
+	existing := sets.NewString()
+	if a.GetOperation() == admission.Update {
+		if u, ok := a.GetOldObject().(*unstructured.Unstructured); ok {
+			old := &apisv1alpha1.APIExport{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, old); err == nil {
+				existing.Insert(old.Spec.LatestResourceSchemas...)
+			}
+		}
+	}
+
+	var errs field.ErrorList
+	var clusterName logicalcluster.Name
+	dryRun := ae.Annotations[apisv1alpha1.DryRunBoundCRDsAnnotationKey] == "true"
+	for i, name := range ae.Spec.LatestResourceSchemas {
+		if existing.Has(name) {
+			continue
+		}
+		fldPath := field.NewPath("spec", "latestResourceSchemas").Index(i)
+
+		if clusterName.Empty() {
+			cluster, err := request.ClusterNameFrom(ctx)
+			if err != nil {
+				return append(errs, field.InternalError(fldPath, fmt.Errorf("failed to retrieve cluster from context: %w", err)))
+			}
+			clusterName = logicalcluster.Name(cluster.String())
+		}
+
+		schema, err := e.getAPIResourceSchema(ctx, clusterName, name)
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, field.NotFound(fldPath, name))
+			continue
+		} else if err != nil {
+			errs = append(errs, field.InternalError(fldPath, err))
+			continue
+		}
+
+		valid := true
+		for _, version := range schema.Spec.Versions {
+			if err := roundTrips(version.Schema.Raw); err != nil {
+				errs = append(errs, field.Invalid(fldPath, name, fmt.Sprintf("schema of version %q is invalid: %v", version.Name, err)))
+				valid = false
+			}
+		}
+		if !valid || !dryRun {
+			continue
+		}
+
+		for _, err := range validateBoundCRD(schema) {
+			errs = append(errs, field.Invalid(fldPath, name, fmt.Sprintf("bound CRD is invalid: %v", err)))
+		}
+	}
+	return errs
+}
+
+// roundTrips verifies that a schema strictly decodes into JSONSchemaProps, and that it is
+// decoded the same once encoded again.
+func roundTrips(raw []byte) error {
+	if raw == nil {
+		return fmt.Errorf("schema is missing")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var props apiextensionsv1.JSONSchemaProps
+	if err := decoder.Decode(&props); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(&props)
+	if err != nil {
+		return err
+	}
+	var roundTripped apiextensionsv1.JSONSchemaProps
+	if err := json.Unmarshal(bs, &roundTripped); err != nil {
+		return err
+	}
+	if !equality.Semantic.DeepEqual(props, roundTripped) {
+		return fmt.Errorf("schema does not round-trip")
+	}
+	return nil
+}
+
+// validateBoundCRD generates the bound CRD of the APIResourceSchema, as the APIBinding controller
+// does, and verifies that the schemas of its versions are structural.
+func validateBoundCRD(schema *apisv1alpha1.APIResourceSchema) field.ErrorList {
+	crd, err := apibinding.GenerateCRD(schema)
+	if err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("spec"), err)}
+	}
+
+	var errs field.ErrorList
+	for i, version := range crd.Spec.Versions {
+		fldPath := field.NewPath("spec", "versions").Index(i).Child("schema", "openAPIV3Schema")
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			errs = append(errs, field.Required(fldPath, ""))
+			continue
+		}
+		internal := &apiextensions.JSONSchemaProps{}
+		if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(version.Schema.OpenAPIV3Schema, internal, nil); err != nil {
+			errs = append(errs, field.Invalid(fldPath, "", err.Error()))
+			continue
+		}
+		structural, err := structuralschema.NewStructural(internal)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath, "", err.Error()))
+			continue
+		}
+		errs = append(errs, structuralschema.ValidateStructural(fldPath, structural)...)
+	}
+	return errs
+}
+
+func (e *APIExportAdmission) readThroughGetAPIResourceSchema(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+	schema, err := e.apiResourceSchemaLister.Cluster(clusterName).Get(name)
+	if err == nil {
+		return schema, nil
+	}
+
+	// The schema is commonly created together with the export, and the informer may not have caught up yet.
+	return e.kcpClusterClient.Cluster(clusterName.Path()).ApisV1alpha1().APIResourceSchemas().Get(ctx, name, metav1.GetOptions{})
+}
+
+func (e *APIExportAdmission) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	e.SetReadyFunc(informers.Apis().V1alpha1().APIResourceSchemas().Informer().HasSynced)
+	e.apiResourceSchemaLister = informers.Apis().V1alpha1().APIResourceSchemas().Lister()
+}
+
+func (e *APIExportAdmission) SetKcpClusterClient(kcpClusterClient kcpclientset.ClusterInterface) {
+	e.kcpClusterClient = kcpClusterClient
+}
+
+func (e *APIExportAdmission) ValidateInitialization() error {
+	if e.apiResourceSchemaLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs an APIResourceSchemas lister")
+	}
+	if e.kcpClusterClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a kcp cluster client")
+	}
 	return nil
 }
@@ -20,13 +20,17 @@ import (
 	"context"
 	"testing"
 
+	"github.com/kcp-dev/logicalcluster/v3"
 	"github.com/stretchr/testify/require"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
 
 	"github.com/kcp-dev/kcp/pkg/admission/helpers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
@@ -229,3 +233,119 @@ func TestAdmission(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLatestResourceSchemas(t *testing.T) {
+	validSchema := `{"type":"object","properties":{"spec":{"type":"object","properties":{"replicas":{"type":"integer"}}}}}`
+
+	cases := map[string]struct {
+		schemas   map[string]string
+		latest    []string
+		oldLatest []string
+		dryRun    bool
+		wantErrs  []string
+	}{
+		"valid": {
+			schemas: map[string]string{"today.widgets.example.io": validSchema},
+			latest:  []string{"today.widgets.example.io"},
+		},
+		"missing schema": {
+			latest:   []string{"today.widgets.example.io"},
+			wantErrs: []string{`spec.latestResourceSchemas[0]: Not found: "today.widgets.example.io"`},
+		},
+		"missing schema already referenced": {
+			latest:    []string{"today.widgets.example.io"},
+			oldLatest: []string{"today.widgets.example.io"},
+		},
+		"unknown field": {
+			schemas:  map[string]string{"today.widgets.example.io": `{"type":"object","propertiez":{}}`},
+			latest:   []string{"today.widgets.example.io"},
+			wantErrs: []string{`schema of version "v1" is invalid`, "propertiez"},
+		},
+		"non-structural without dry-run": {
+			schemas: map[string]string{"today.widgets.example.io": `{"type":"object","properties":{"spec":{}}}`},
+			latest:  []string{"today.widgets.example.io"},
+		},
+		"non-structural with dry-run": {
+			schemas:  map[string]string{"today.widgets.example.io": `{"type":"object","properties":{"spec":{}}}`},
+			latest:   []string{"today.widgets.example.io"},
+			dryRun:   true,
+			wantErrs: []string{"bound CRD is invalid", "type: Required value"},
+		},
+		"structural with dry-run": {
+			schemas: map[string]string{"today.widgets.example.io": validSchema},
+			latest:  []string{"today.widgets.example.io"},
+			dryRun:  true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ae := &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cool-something",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec: apisv1alpha1.APIExportSpec{
+					LatestResourceSchemas: tc.latest,
+				},
+			}
+			if tc.dryRun {
+				ae.Annotations[apisv1alpha1.DryRunBoundCRDsAnnotationKey] = "true"
+			}
+			attr := createAttr("cool-something", ae, "APIExport", "apiexports")
+			if tc.oldLatest != nil {
+				old := ae.DeepCopy()
+				old.Spec.LatestResourceSchemas = tc.oldLatest
+				attr = admission.NewAttributesRecord(
+					helpers.ToUnstructuredOrDie(ae),
+					helpers.ToUnstructuredOrDie(old),
+					apisv1alpha1.Kind("APIExport").WithVersion("v1alpha1"),
+					"",
+					"cool-something",
+					apisv1alpha1.Resource("apiexports").WithVersion("v1alpha1"),
+					"",
+					admission.Update,
+					&metav1.UpdateOptions{},
+					false,
+					&user.DefaultInfo{},
+				)
+			}
+
+			plugin := NewAPIExportAdmission(func(apisv1alpha1.GroupResource) bool { return false })
+			plugin.getAPIResourceSchema = func(_ context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+				require.Equal(t, logicalcluster.Name("root:org"), clusterName)
+				schema, found := tc.schemas[name]
+				if !found {
+					return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiresourceschemas"), name)
+				}
+				return &apisv1alpha1.APIResourceSchema{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+					},
+					Spec: apisv1alpha1.APIResourceSchemaSpec{
+						Group: "example.io",
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Singular: "widget", Kind: "Widget", ListKind: "WidgetList"},
+						Scope: apiextensionsv1.NamespaceScoped,
+						Versions: []apisv1alpha1.APIResourceVersion{{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema:  runtime.RawExtension{Raw: []byte(schema)},
+						}},
+					},
+				}, nil
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
+			err := plugin.Validate(ctx, attr, nil)
+			if len(tc.wantErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tc.wantErrs {
+				require.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiservice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"k8s.io/kubernetes/pkg/api/genericcontrolplanescheme"
+	_ "k8s.io/kubernetes/pkg/genericcontrolplane/apis/install"
+)
+
+// PluginName is the name of the admission plugin validating the APIServices.
+const PluginName = "apiregistration.kcp.io/APIService"
+
+// builtInGroups are the groups served by the generic control plane of every workspace.
+var builtInGroups = func() sets.String {
+	groups := sets.NewString(apiextensionsv1.GroupName, apiregistrationv1.GroupName)
+	for _, gv := range genericcontrolplanescheme.Scheme.PrioritizedVersionsAllGroups() {
+		groups.Insert(gv.Group)
+	}
+	return groups
+}()
+
+// Register registers the APIService admission plugin.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &apiService{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type apiService struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&apiService{})
+
+// ReservedGroup returns whether the group cannot be delegated by an APIService, because it is a
+// group of kcp or a group served by the generic control plane.
+func ReservedGroup(group string) bool {
+	return group == "kcp.io" || strings.HasSuffix(group, ".kcp.io") || builtInGroups.Has(group)
+}
+
+// Validate rejects the APIServices delegating a reserved group, or skipping the TLS verification of
+// their extension API server, which is not supported.
+func (o *apiService) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != apiregistrationv1.Resource("apiservices") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	obj := &apiregistrationv1.APIService{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), obj); err != nil {
+		return fmt.Errorf("failed to convert unstructured to APIService: %w", err)
+	}
+
+	// the APIServices without Service do not delegate their group/version
+	if obj.Spec.Service == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if ReservedGroup(obj.Spec.Group) {
+		errs = append(errs, field.Invalid(specPath.Child("group"), obj.Spec.Group, "group is reserved"))
+	}
+	if name := obj.Spec.Version + "." + obj.Spec.Group; obj.Name != name {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), obj.Name, fmt.Sprintf("must be %q", name)))
+	}
+	if obj.Spec.InsecureSkipTLSVerify {
+		errs = append(errs, field.Forbidden(specPath.Child("insecureSkipTLSVerify"), "not supported, the caBundle must be set instead"))
+	}
+	if len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+	return nil
+}
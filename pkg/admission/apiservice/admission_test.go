@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+)
+
+func createAttr(t *testing.T, obj *apiregistrationv1.APIService) admission.Attributes {
+	t.Helper()
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return admission.NewAttributesRecord(
+		&unstructured.Unstructured{Object: u},
+		nil,
+		apiregistrationv1.SchemeGroupVersion.WithKind("APIService"),
+		"",
+		obj.Name,
+		apiregistrationv1.SchemeGroupVersion.WithResource("apiservices"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newAPIService(name, group, version string) *apiregistrationv1.APIService {
+	return &apiregistrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apiregistrationv1.APIServiceSpec{
+			Group:   group,
+			Version: version,
+			Service: &apiregistrationv1.ServiceReference{Namespace: "default", Name: "api"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	insecure := newAPIService("v1.metrics.example.com", "metrics.example.com", "v1")
+	insecure.Spec.InsecureSkipTLSVerify = true
+	local := newAPIService("v1.apis.kcp.io", "apis.kcp.io", "v1")
+	local.Spec.Service = nil
+
+	tests := []struct {
+		name       string
+		apiService *apiregistrationv1.APIService
+		wantErr    bool
+	}{
+		{name: "extension group", apiService: newAPIService("v1.metrics.example.com", "metrics.example.com", "v1")},
+		{name: "kcp group", apiService: newAPIService("v1alpha1.apis.kcp.io", "apis.kcp.io", "v1alpha1"), wantErr: true},
+		{name: "kcp.io group", apiService: newAPIService("v1.kcp.io", "kcp.io", "v1"), wantErr: true},
+		{name: "built-in group", apiService: newAPIService("v1.rbac.authorization.k8s.io", "rbac.authorization.k8s.io", "v1"), wantErr: true},
+		{name: "core group", apiService: newAPIService("v1.", "", "v1"), wantErr: true},
+		{name: "apiextensions group", apiService: newAPIService("v1.apiextensions.k8s.io", "apiextensions.k8s.io", "v1"), wantErr: true},
+		{name: "name mismatch", apiService: newAPIService("v2.metrics.example.com", "metrics.example.com", "v1"), wantErr: true},
+		{name: "insecure", apiService: insecure, wantErr: true},
+		{name: "without Service", apiService: local},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &apiService{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			err := o.Validate(context.Background(), createAttr(t, tt.apiService), nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
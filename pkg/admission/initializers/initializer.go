@@ -23,6 +23,7 @@ import (
 	"k8s.io/apiserver/pkg/admission/initializer"
 	quota "k8s.io/apiserver/pkg/quota/v1"
 
+	"github.com/kcp-dev/kcp/pkg/admission/webhook/resolver"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 )
@@ -142,3 +143,21 @@ func (i *serverShutdownChannelInitializer) Initialize(plugin admission.Interface
 		wants.SetServerShutdownChannel(i.ch)
 	}
 }
+
+// NewWebhookResolverInitializer returns an admission plugin initializer that injects
+// the resolver of the Services referenced by the webhooks of the workspaces into admission plugins.
+func NewWebhookResolverInitializer(webhookResolver *resolver.Resolver) *webhookResolverInitializer {
+	return &webhookResolverInitializer{
+		webhookResolver: webhookResolver,
+	}
+}
+
+type webhookResolverInitializer struct {
+	webhookResolver *resolver.Resolver
+}
+
+func (i *webhookResolverInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsWebhookResolver); ok {
+		wants.SetWebhookResolver(i.webhookResolver)
+	}
+}
@@ -19,6 +19,7 @@ package initializers
 import (
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 
+	"github.com/kcp-dev/kcp/pkg/admission/webhook/resolver"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 )
@@ -53,3 +54,9 @@ type WantsDeepSARClient interface {
 type WantsServerShutdownChannel interface {
 	SetServerShutdownChannel(<-chan struct{})
 }
+
+// WantsWebhookResolver interface should be implemented by admission plugins that want
+// to have the resolver of the Services referenced by the webhooks of the workspaces injected.
+type WantsWebhookResolver interface {
+	SetWebhookResolver(*resolver.Resolver)
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/klog/v2"
 
 	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
 )
 
 const clusterWorkspaceDeletionMonitorControllerName = "kcp-kubequota-cluster-workspace-deletion-monitor"
@@ -73,8 +74,9 @@ func (m *clusterWorkspaceDeletionMonitor) Start(stop <-chan struct{}) {
 	defer runtime.HandleCrash()
 	defer m.queue.ShutDown()
 
-	klog.Infof("Starting %s controller", clusterWorkspaceDeletionMonitorControllerName)
-	defer klog.Infof("Shutting down %s controller", clusterWorkspaceDeletionMonitorControllerName)
+	logger := logging.WithReconciler(klog.Background(), clusterWorkspaceDeletionMonitorControllerName)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
 
 	go wait.Until(m.startWorker, time.Second, stop)
 
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apiserver/pkg/admission"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	certutil "k8s.io/client-go/util/cert"
 
 	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
@@ -151,6 +152,10 @@ func (o *plugin) Validate(ctx context.Context, a admission.Attributes, _ admissi
 			return fmt.Errorf("failed to convert unstructured to LogicalCluster: %w", err)
 		}
 
+		if err := validateTrustBundles(logicalCluster.Spec.TrustBundles); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+
 		oldSpec := toSet(old.Spec.Initializers)
 		newSpec := toSet(logicalCluster.Spec.Initializers)
 		oldStatus := toSet(old.Status.Initializers)
@@ -202,6 +207,16 @@ func (o *plugin) Validate(ctx context.Context, a admission.Attributes, _ admissi
 	return nil
 }
 
+// validateTrustBundles checks that the trust bundles hold PEM encoded certificates only.
+func validateTrustBundles(trustBundles []corev1alpha1.TrustBundle) error {
+	for _, trustBundle := range trustBundles {
+		if _, err := certutil.ParseCertsPEM([]byte(trustBundle.PEM)); err != nil {
+			return fmt.Errorf("spec.trustBundles[%s].pem is invalid: %w", trustBundle.Name, err)
+		}
+	}
+	return nil
+}
+
 func (o *plugin) ValidateInitialization() error {
 	if o.logicalClusterLister == nil {
 		return fmt.Errorf(PluginName + " plugin needs an LogicalCluster lister")
@@ -315,6 +315,23 @@ func TestValidate(t *testing.T) {
 			),
 			wantErr: "cannot transition from",
 		},
+		{
+			name:        "passes with a valid trust bundle",
+			clusterName: "root:org:ws",
+			attr: updateAttr(
+				newLogicalCluster("root:org:ws").withTrustBundle("ca", testCA).LogicalCluster,
+				newLogicalCluster("root:org:ws").LogicalCluster,
+			),
+		},
+		{
+			name:        "fails with an invalid trust bundle",
+			clusterName: "root:org:ws",
+			attr: updateAttr(
+				newLogicalCluster("root:org:ws").withTrustBundle("ca", "not a certificate").LogicalCluster,
+				newLogicalCluster("root:org:ws").LogicalCluster,
+			),
+			wantErr: "spec.trustBundles[ca].pem is invalid",
+		},
 		{
 			name:        "fails deletion as another user",
 			clusterName: "root:org:ws",
@@ -414,6 +431,11 @@ func (b thisWsBuilder) withInitializers(initializers ...corev1alpha1.LogicalClus
 	return b
 }
 
+func (b thisWsBuilder) withTrustBundle(name, pem string) thisWsBuilder {
+	b.Spec.TrustBundles = append(b.Spec.TrustBundles, corev1alpha1.TrustBundle{Name: name, PEM: pem})
+	return b
+}
+
 func (b thisWsBuilder) directlyDeletable() thisWsBuilder {
 	b.Spec.DirectlyDeletable = true
 	return b
@@ -462,3 +484,16 @@ func (l fakeLogicalClusterLister) GetWithContext(ctx context.Context, name strin
 	}
 	return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspace"), name)
 }
+
+// testCA is a self-signed CA certificate.
+const testCA = `-----BEGIN CERTIFICATE-----
+MIIBfDCCASGgAwIBAgIUBj4z2JgHxo1ZSvzq0DIy1Bjc++wwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAgFw0yNjEwMTYwODE5MzVaGA8yMTI2MDkyMjA4
+MTkzNVowEjEQMA4GA1UEAwwHdGVzdC1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABFY2YCehSBtq+GIvDoEboLKG96AeAIy52c43lP3jPQ1pbZ6crW/J4cmhDdWQ
+5yw5+5/FjooRcn1j7N+pKpT+C8+jUzBRMB0GA1UdDgQWBBTxtXyN7VNZXxnsinPr
+FjnQxsXaNDAfBgNVHSMEGDAWgBTxtXyN7VNZXxnsinPrFjnQxsXaNDAPBgNVHRMB
+Af8EBTADAQH/MAoGCCqGSM49BAMCA0kAMEYCIQCvtN5WZ9NAkf/GesbKlbUup0bc
+iNzbdRqBHvjpS3IZ1AIhAJmW17jBVfstxHtabi9bO2Zzkv2gpBdUlHnfSeVaxhv0
+-----END CERTIFICATE-----
+`
@@ -53,6 +53,7 @@ var (
 	_ = admission.ValidationInterface(&Plugin{})
 	_ = admission.InitializationValidator(&Plugin{})
 	_ = kcpinitializers.WantsKcpInformers(&Plugin{})
+	_ = kcpinitializers.WantsWebhookResolver(&Plugin{})
 )
 
 func NewValidatingAdmissionWebhook(configfile io.Reader) (*Plugin, error) {
@@ -69,26 +70,36 @@ func NewValidatingAdmissionWebhook(configfile io.Reader) (*Plugin, error) {
 	if err != nil {
 		return nil, err
 	}
-	cm, err := webhookutil.NewClientManager(
-		[]schema.GroupVersion{
-			admissionv1beta1.SchemeGroupVersion,
-			admissionv1.SchemeGroupVersion,
-		},
-		admissionv1beta1.AddToScheme,
-		admissionv1.AddToScheme,
-	)
+	authInfoResolver, err := webhookutil.NewDefaultAuthenticationInfoResolver(kubeconfigFile)
 	if err != nil {
 		return nil, err
 	}
-	authInfoResolver, err := webhookutil.NewDefaultAuthenticationInfoResolver(kubeconfigFile)
+	newDispatcher := func(serviceResolver webhookutil.ServiceResolver, authInfoResolver webhookutil.AuthenticationInfoResolver) (generic.Dispatcher, error) {
+		cm, err := webhookutil.NewClientManager(
+			[]schema.GroupVersion{
+				admissionv1beta1.SchemeGroupVersion,
+				admissionv1.SchemeGroupVersion,
+			},
+			admissionv1beta1.AddToScheme,
+			admissionv1.AddToScheme,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cm.SetAuthenticationInfoResolver(authInfoResolver)
+		cm.SetServiceResolver(serviceResolver)
+		return dispatcherFactory(&cm), nil
+	}
+
+	// Set defaults which may be overridden later, by the dispatchers of the logical clusters when the
+	// Services of the webhooks are resolved in the workspaces.
+	dispatcher, err := newDispatcher(webhookutil.NewDefaultServiceResolver(), authInfoResolver)
 	if err != nil {
 		return nil, err
 	}
-	// Set defaults which may be overridden later.
-	cm.SetAuthenticationInfoResolver(authInfoResolver)
-	cm.SetServiceResolver(webhookutil.NewDefaultServiceResolver())
+	p.WebhookDispatcher.SetDispatcher(dispatcher)
+	p.WebhookDispatcher.SetDispatcherFactory(newDispatcher, authInfoResolver)
 
-	p.WebhookDispatcher.SetDispatcher(dispatcherFactory(&cm))
 	// Need to do this, to make sure that the underlying objects for the call to ShouldCallHook have the right values
 	p.Plugin.Webhook, err = generic.NewWebhook(p.Handler, configfile, configuration.NewValidatingWebhookConfigurationManager, dispatcherFactory)
 	if err != nil {
@@ -29,11 +29,14 @@ import (
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/rules"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	webhookutil "k8s.io/apiserver/pkg/util/webhook"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/kcp-dev/kcp/pkg/admission/initializers"
+	"github.com/kcp-dev/kcp/pkg/admission/webhook/resolver"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
@@ -79,6 +82,11 @@ func (c *clusterAwareSource) HasSynced() bool {
 }
 
 var _ initializers.WantsKcpInformers = &WebhookDispatcher{}
+var _ initializers.WantsWebhookResolver = &WebhookDispatcher{}
+
+// DispatcherFactory returns a dispatcher whose client manager resolves the Services referenced by the
+// webhooks with the given resolvers.
+type DispatcherFactory func(serviceResolver webhookutil.ServiceResolver, authInfoResolver webhookutil.AuthenticationInfoResolver) (generic.Dispatcher, error)
 
 type WebhookDispatcher struct {
 	*admission.Handler
@@ -86,6 +94,13 @@ type WebhookDispatcher struct {
 	dispatcher generic.Dispatcher
 	hookSource ClusterAwareSource
 
+	// dispatchers are the dispatchers by logical cluster, when a webhook resolver is set.
+	webhookResolver   *resolver.Resolver
+	authInfoResolver  webhookutil.AuthenticationInfoResolver
+	dispatcherFactory DispatcherFactory
+	dispatchersLock   sync.Mutex
+	dispatchers       map[logicalcluster.Name]generic.Dispatcher
+
 	getAPIExport func(path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error)
 
 	apiBindingClusterLister apisv1alpha1listers.APIBindingClusterLister
@@ -96,7 +111,8 @@ type WebhookDispatcher struct {
 
 func NewWebhookDispatcher() *WebhookDispatcher {
 	d := &WebhookDispatcher{
-		Handler: admission.NewHandler(admission.Connect, admission.Create, admission.Delete, admission.Update),
+		Handler:     admission.NewHandler(admission.Connect, admission.Create, admission.Delete, admission.Update),
+		dispatchers: map[logicalcluster.Name]generic.Dispatcher{},
 	}
 
 	d.getAPIExport = func(path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
@@ -114,6 +130,54 @@ func (p *WebhookDispatcher) SetDispatcher(dispatch generic.Dispatcher) {
 	p.dispatcher = dispatch
 }
 
+// SetDispatcherFactory sets the factory of the dispatchers of the logical clusters, used instead of
+// the default dispatcher when a webhook resolver is set. The authentication info of the webhooks is
+// resolved by authInfoResolver.
+func (p *WebhookDispatcher) SetDispatcherFactory(factory DispatcherFactory, authInfoResolver webhookutil.AuthenticationInfoResolver) {
+	p.dispatcherFactory = factory
+	p.authInfoResolver = authInfoResolver
+}
+
+// SetWebhookResolver implements the WantsWebhookResolver interface.
+func (p *WebhookDispatcher) SetWebhookResolver(webhookResolver *resolver.Resolver) {
+	p.webhookResolver = webhookResolver
+}
+
+// dispatcherFor returns the dispatcher of the webhooks of the given logical cluster, resolving the
+// Services referenced by the webhooks in that logical cluster.
+func (p *WebhookDispatcher) dispatcherFor(cluster logicalcluster.Name) (generic.Dispatcher, error) {
+	if p.webhookResolver == nil || p.dispatcherFactory == nil {
+		return p.dispatcher, nil
+	}
+
+	p.dispatchersLock.Lock()
+	defer p.dispatchersLock.Unlock()
+	if dispatcher, found := p.dispatchers[cluster]; found {
+		return dispatcher, nil
+	}
+	dispatcher, err := p.dispatcherFactory(p.webhookResolver.ForCluster(cluster, p.authInfoResolver))
+	if err != nil {
+		return nil, err
+	}
+	p.dispatchers[cluster] = dispatcher
+	return dispatcher, nil
+}
+
+// deleteDispatcher drops the dispatcher of the deleted logical cluster.
+func (p *WebhookDispatcher) deleteDispatcher(obj interface{}) {
+	if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = final.Obj
+	}
+	logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster)
+	if !ok {
+		return
+	}
+
+	p.dispatchersLock.Lock()
+	defer p.dispatchersLock.Unlock()
+	delete(p.dispatchers, logicalcluster.From(logicalCluster))
+}
+
 func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attributes, o admission.ObjectInterfaces) error {
 	// If the object is a Webhook configuration, do not call webhooks
 	// This is because we need some way to recover if a webhook is preventing a cluster resources from being updated
@@ -129,6 +193,7 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 	}
 
 	var whAccessor []webhook.WebhookAccessor
+	hooksCluster := lcluster
 
 	// Determine the type of request, is it api binding or not.
 	if workspace, isAPIBinding, err := p.getAPIExportCluster(attr, lcluster); err != nil {
@@ -136,6 +201,7 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 	} else if isAPIBinding {
 		whAccessor = p.hookSource.Webhooks(workspace)
 		attr.SetCluster(workspace)
+		hooksCluster = workspace
 		klog.V(7).Infof("restricting call to api registration hooks in cluster: %v", workspace)
 	} else {
 		whAccessor = p.hookSource.Webhooks(lcluster)
@@ -143,7 +209,11 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 		klog.V(7).Infof("restricting call to hooks in cluster: %v", lcluster)
 	}
 
-	return p.dispatcher.Dispatch(ctx, attr, o, whAccessor)
+	dispatcher, err := p.dispatcherFor(hooksCluster)
+	if err != nil {
+		return err
+	}
+	return dispatcher.Dispatch(ctx, attr, o, whAccessor)
 }
 
 func (p *WebhookDispatcher) getAPIExportCluster(attr admission.Attributes, clusterName logicalcluster.Name) (logicalcluster.Name, bool, error) {
@@ -191,6 +261,10 @@ func (p *WebhookDispatcher) SetKcpInformers(f kcpinformers.SharedInformerFactory
 	p.SetReadyFunc(synced)
 	p.informersHaveSynced = synced
 
+	f.Core().V1alpha1().LogicalClusters().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: p.deleteDispatcher,
+	})
+
 	indexers.AddIfNotPresentOrDie(f.Apis().V1alpha1().APIExports().Informer().GetIndexer(), cache.Indexers{
 		indexers.ByLogicalClusterPathAndName: indexers.IndexByLogicalClusterPathAndName,
 	})
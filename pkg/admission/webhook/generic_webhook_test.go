@@ -33,14 +33,20 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook"
+	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	webhookutil "k8s.io/apiserver/pkg/util/webhook"
+	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/admission/webhook/resolver"
 	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster/fake"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 )
 
 func attr(gvk schema.GroupVersionKind, name, resource string, op admission.Operation) admission.Attributes {
@@ -275,7 +281,7 @@ func TestDispatch(t *testing.T) {
 				informersHaveSynced:     tc.informersHaveSynced,
 				getAPIExport: func(path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
 					for _, apiExport := range tc.apiExports {
-						if keys, _ := indexers.IndexByLogicalClusterPathAndName(apiExport); sets.NewString(keys...).Has(path.Join(name).String()) {
+						if keys, _ := indexers.IndexByLogicalClusterPathAndName(apiExport); sets.NewString(keys...).Has(indexkeys.NewPathAndName(path, name).String()) {
 							return apiExport, nil
 						}
 					}
@@ -303,6 +309,36 @@ func TestDispatch(t *testing.T) {
 	}
 }
 
+func TestDispatcherForDeletedLogicalCluster(t *testing.T) {
+	created := 0
+	d := NewWebhookDispatcher()
+	d.SetWebhookResolver(&resolver.Resolver{})
+	d.SetDispatcherFactory(func(webhookutil.ServiceResolver, webhookutil.AuthenticationInfoResolver) (generic.Dispatcher, error) {
+		created++
+		return &validatingDispatcher{}, nil
+	}, nil)
+
+	first, err := d.dispatcherFor("org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := d.dispatcherFor("org"); again != first || created != 1 {
+		t.Fatalf("expected the dispatcher of the logical cluster to be reused, created %d", created)
+	}
+
+	logicalCluster := &corev1alpha1.LogicalCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:        corev1alpha1.LogicalClusterName,
+		Annotations: map[string]string{logicalcluster.AnnotationKey: "org"},
+	}}
+	d.deleteDispatcher(cache.DeletedFinalStateUnknown{Key: "org|cluster", Obj: logicalCluster})
+	if _, found := d.dispatchers["org"]; found {
+		t.Fatal("expected the dispatcher of the deleted logical cluster to be dropped")
+	}
+	if _, err := d.dispatcherFor("org"); err != nil || created != 2 {
+		t.Fatalf("expected a new dispatcher, created %d: %v", created, err)
+	}
+}
+
 func toObjects(bindings []*apisv1alpha1.APIBinding) []runtime.Object {
 	objs := make([]runtime.Object, 0, len(bindings))
 	for _, binding := range bindings {
@@ -0,0 +1,321 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/sets"
+	webhookutil "k8s.io/apiserver/pkg/util/webhook"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	// WorkspacePathAnnotationKey is the annotation of a Service referenced by the client configuration
+	// of a webhook, delegating its resolution to the Service with the same namespace and name in the
+	// workspace of the given path. The Services of workspaces of other shards are read through the
+	// front-proxy.
+	WorkspacePathAnnotationKey = "experimental.webhooks.kcp.io/workspace-path"
+
+	// AllowedWorkspacePathsAnnotationKey is the annotation of a Service granting the workspaces of the
+	// given comma-separated paths the delegation of the resolution of their Services to it with the
+	// WorkspacePathAnnotationKey annotation. Services without it cannot be delegated to.
+	AllowedWorkspacePathsAnnotationKey = "experimental.webhooks.kcp.io/allowed-workspace-paths"
+
+	// CABundleAnnotationKey is the annotation of a resolved Service holding the PEM encoded CA bundle
+	// verifying the serving certificate of the webhook, in addition to the caBundle of the client
+	// configuration of the webhook.
+	CABundleAnnotationKey = "experimental.webhooks.kcp.io/ca-bundle"
+
+	// DefaultTTL is the duration the resolved endpoints are cached for.
+	DefaultTTL = 30 * time.Second
+
+	// maxCacheEntries bounds the number of resolved endpoints cached, the least recently used being
+	// evicted first.
+	maxCacheEntries = 10000
+
+	remoteTimeout = 10 * time.Second
+)
+
+// Endpoint is the resolved endpoint of a Service referenced by the client configuration of a webhook.
+type Endpoint struct {
+	// Host is the host:port the webhook is dialed at.
+	Host string
+	// ServerName is the name the serving certificate of the webhook is verified against, or empty
+	// to verify it against the <name>.<namespace>.svc name of the Service.
+	ServerName string
+	// CABundle is the PEM encoded CA bundle verifying the serving certificate of the webhook.
+	CABundle []byte
+}
+
+type cacheKey struct {
+	clusterName logicalcluster.Name
+	namespace   string
+	name        string
+	port        int32
+}
+
+type clockFunc func() time.Time
+
+func (f clockFunc) Now() time.Time {
+	return f()
+}
+
+// Resolver resolves the Services referenced by the client configurations of webhooks in the
+// logical cluster of the webhook configurations, as kcp does not run Service endpoints. A Service
+// must either be of type ExternalName, or have an external IP, or delegate to a Service of another
+// workspace with the WorkspacePathAnnotationKey annotation, the latter granting it with the
+// AllowedWorkspacePathsAnnotationKey annotation.
+type Resolver struct {
+	getService        func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error)
+	getLogicalCluster func(path logicalcluster.Path) (*corev1alpha1.LogicalCluster, error)
+	getPath           func(clusterName logicalcluster.Name) (logicalcluster.Path, error)
+	getRemoteService  func(ctx context.Context, path logicalcluster.Path, namespace, name string) (*corev1.Service, error)
+
+	// proxy is the proxy the webhooks are called through, or nil to route the calls according to
+	// the proxy environment variables.
+	proxy func(*http.Request) (*url.URL, error)
+
+	ttl   time.Duration
+	now   func() time.Time
+	cache *utilcache.LRUExpireCache
+}
+
+// NewResolver returns a Resolver reading the Services of the workspaces of this shard from the
+// informers, and those of the other shards through the front-proxy with frontProxyClient, unless it
+// is nil. The webhooks are called through proxy, unless it is nil.
+func NewResolver(
+	serviceInformer kcpcorev1informers.ServiceClusterInformer,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	frontProxyClient kcpkubernetesclientset.ClusterInterface,
+	proxy func(*http.Request) (*url.URL, error),
+) *Resolver {
+	indexers.AddIfNotPresentOrDie(logicalClusterInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalClusterPathAndName: indexers.IndexByLogicalClusterPathAndName,
+	})
+
+	r := &Resolver{
+		getService: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error) {
+			return serviceInformer.Lister().Cluster(clusterName).Services(namespace).Get(name)
+		},
+		getLogicalCluster: func(path logicalcluster.Path) (*corev1alpha1.LogicalCluster, error) {
+			return indexers.ByPathAndName[*corev1alpha1.LogicalCluster](corev1alpha1.Resource("logicalclusters"), logicalClusterInformer.Informer().GetIndexer(), path, corev1alpha1.LogicalClusterName)
+		},
+		getPath: func(clusterName logicalcluster.Name) (logicalcluster.Path, error) {
+			logicalCluster, err := logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+			if err != nil {
+				return logicalcluster.Path{}, err
+			}
+			if path := logicalcluster.NewPath(logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
+				return path, nil
+			}
+			return clusterName.Path(), nil
+		},
+		proxy: proxy,
+		ttl:   DefaultTTL,
+		now:   time.Now,
+	}
+	r.cache = utilcache.NewLRUExpireCacheWithClock(maxCacheEntries, clockFunc(func() time.Time { return r.now() }))
+	if frontProxyClient != nil {
+		r.getRemoteService = func(ctx context.Context, path logicalcluster.Path, namespace, name string) (*corev1.Service, error) {
+			return frontProxyClient.Cluster(path).CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	return r
+}
+
+// Resolve returns the endpoint of the Service referenced by a webhook of the given logical cluster.
+func (r *Resolver) Resolve(ctx context.Context, clusterName logicalcluster.Name, namespace, name string, port int32) (*Endpoint, error) {
+	key := cacheKey{clusterName: clusterName, namespace: namespace, name: name, port: port}
+	if endpoint, found := r.cache.Get(key); found {
+		return endpoint.(*Endpoint), nil
+	}
+
+	endpoint, err := r.resolve(ctx, clusterName, namespace, name, port)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.Add(key, endpoint, r.ttl)
+	return endpoint, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, clusterName logicalcluster.Name, namespace, name string, port int32) (*Endpoint, error) {
+	service, err := r.getService(clusterName, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Service %s/%s in logical cluster %s: %w", namespace, name, clusterName, err)
+	}
+
+	if value, found := service.Annotations[WorkspacePathAnnotationKey]; found {
+		path := logicalcluster.NewPath(value)
+		if !path.IsValid() {
+			return nil, fmt.Errorf("invalid %s annotation of Service %s/%s in logical cluster %s: %q", WorkspacePathAnnotationKey, namespace, name, clusterName, value)
+		}
+		service, err = r.getDelegateService(ctx, path, namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Service %s/%s in workspace %s: %w", namespace, name, path, err)
+		}
+		if err := r.checkAllowed(clusterName, service); err != nil {
+			return nil, err
+		}
+	}
+
+	return endpointFor(service, port)
+}
+
+func (r *Resolver) getDelegateService(ctx context.Context, path logicalcluster.Path, namespace, name string) (*corev1.Service, error) {
+	logicalCluster, err := r.getLogicalCluster(path)
+	if err == nil {
+		return r.getService(logicalcluster.From(logicalCluster), namespace, name)
+	}
+	if !apierrors.IsNotFound(err) || r.getRemoteService == nil {
+		return nil, err
+	}
+
+	// the workspace is not on this shard
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+	return r.getRemoteService(ctx, path, namespace, name)
+}
+
+// checkAllowed returns an error unless the given delegate Service grants the workspace of the given
+// logical cluster with the AllowedWorkspacePathsAnnotationKey annotation, by its path or by the name
+// of its logical cluster.
+func (r *Resolver) checkAllowed(clusterName logicalcluster.Name, service *corev1.Service) error {
+	path, err := r.getPath(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get the path of logical cluster %s: %w", clusterName, err)
+	}
+
+	allowed := sets.NewString()
+	for _, value := range strings.Split(service.Annotations[AllowedWorkspacePathsAnnotationKey], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			allowed.Insert(value)
+		}
+	}
+	if !allowed.Has(path.String()) && !allowed.Has(clusterName.String()) {
+		return fmt.Errorf("service %s/%s does not allow workspace %s with the %s annotation", service.Namespace, service.Name, path, AllowedWorkspacePathsAnnotationKey)
+	}
+	return nil
+}
+
+func endpointFor(service *corev1.Service, port int32) (*Endpoint, error) {
+	endpoint := &Endpoint{}
+	if caBundle, found := service.Annotations[CABundleAnnotationKey]; found {
+		endpoint.CABundle = []byte(caBundle)
+	}
+
+	portString := strconv.Itoa(int(port))
+	switch {
+	case service.Spec.Type == corev1.ServiceTypeExternalName:
+		endpoint.Host = net.JoinHostPort(service.Spec.ExternalName, portString)
+		endpoint.ServerName = service.Spec.ExternalName
+	case len(service.Spec.ExternalIPs) > 0:
+		endpoint.Host = net.JoinHostPort(service.Spec.ExternalIPs[0], portString)
+	default:
+		return nil, fmt.Errorf("service %s/%s must be of type ExternalName or have an external IP", service.Namespace, service.Name)
+	}
+	return endpoint, nil
+}
+
+// ForCluster returns the resolvers of the Services referenced by the webhooks of the given logical
+// cluster, for a webhook client manager. The authentication info is resolved by delegate, and
+// completed with the server name and CA bundle of the resolved endpoint, and with the proxy of the
+// Resolver.
+func (r *Resolver) ForCluster(clusterName logicalcluster.Name, delegate webhookutil.AuthenticationInfoResolver) (webhookutil.ServiceResolver, webhookutil.AuthenticationInfoResolver) {
+	return &serviceResolver{resolver: r, clusterName: clusterName},
+		&authenticationInfoResolver{resolver: r, clusterName: clusterName, delegate: delegate}
+}
+
+type serviceResolver struct {
+	resolver    *Resolver
+	clusterName logicalcluster.Name
+}
+
+var _ webhookutil.ServiceResolver = &serviceResolver{}
+
+func (s *serviceResolver) ResolveEndpoint(namespace, name string, port int32) (*url.URL, error) {
+	endpoint, err := s.resolver.Resolve(context.Background(), s.clusterName, namespace, name, port)
+	if err != nil {
+		return nil, err
+	}
+	return &url.URL{Scheme: "https", Host: endpoint.Host}, nil
+}
+
+type authenticationInfoResolver struct {
+	resolver    *Resolver
+	clusterName logicalcluster.Name
+	delegate    webhookutil.AuthenticationInfoResolver
+}
+
+var _ webhookutil.AuthenticationInfoResolver = &authenticationInfoResolver{}
+
+func (a *authenticationInfoResolver) ClientConfigFor(hostPort string) (*rest.Config, error) {
+	config, err := a.delegate.ClientConfigFor(hostPort)
+	if err != nil {
+		return nil, err
+	}
+	if a.resolver.proxy != nil {
+		config = rest.CopyConfig(config)
+		config.Proxy = a.resolver.proxy
+	}
+	return config, nil
+}
+
+func (a *authenticationInfoResolver) ClientConfigForService(serviceName, serviceNamespace string, servicePort int) (*rest.Config, error) {
+	config, err := a.delegate.ClientConfigForService(serviceName, serviceNamespace, servicePort)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := a.resolver.Resolve(context.Background(), a.clusterName, serviceNamespace, serviceName, int32(servicePort))
+	if err != nil {
+		return nil, err
+	}
+
+	config = rest.CopyConfig(config)
+	if endpoint.ServerName != "" {
+		config.TLSClientConfig.ServerName = endpoint.ServerName
+	}
+	if len(endpoint.CABundle) > 0 {
+		config.TLSClientConfig.CAData = append(append([]byte{}, config.TLSClientConfig.CAData...), endpoint.CABundle...)
+	}
+	if a.resolver.proxy != nil {
+		config.Proxy = a.resolver.proxy
+	}
+	return config, nil
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/rest"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func newService(clusterName, name string, annotations map[string]string, spec corev1.ServiceSpec) *corev1.Service {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[logicalcluster.AnnotationKey] = clusterName
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "webhooks", Name: name, Annotations: annotations},
+		Spec:       spec,
+	}
+}
+
+func newTestResolver(services []*corev1.Service, remoteServices map[logicalcluster.Path]*corev1.Service) *Resolver {
+	r := &Resolver{
+		getService: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error) {
+			for _, service := range services {
+				if logicalcluster.From(service) == clusterName && service.Namespace == namespace && service.Name == name {
+					return service, nil
+				}
+			}
+			return nil, apierrors.NewNotFound(corev1.Resource("services"), name)
+		},
+		getLogicalCluster: func(path logicalcluster.Path) (*corev1alpha1.LogicalCluster, error) {
+			if path.String() == "root:infra" {
+				return &corev1alpha1.LogicalCluster{ObjectMeta: metav1.ObjectMeta{
+					Name:        corev1alpha1.LogicalClusterName,
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "infra"},
+				}}, nil
+			}
+			return nil, apierrors.NewNotFound(corev1alpha1.Resource("logicalclusters"), corev1alpha1.LogicalClusterName)
+		},
+		getPath: func(clusterName logicalcluster.Name) (logicalcluster.Path, error) {
+			return logicalcluster.NewPath("root").Join(clusterName.String()), nil
+		},
+		ttl: DefaultTTL,
+		now: time.Now,
+	}
+	r.cache = utilcache.NewLRUExpireCacheWithClock(maxCacheEntries, clockFunc(func() time.Time { return r.now() }))
+	if remoteServices != nil {
+		r.getRemoteService = func(ctx context.Context, path logicalcluster.Path, namespace, name string) (*corev1.Service, error) {
+			if service, found := remoteServices[path]; found {
+				return service, nil
+			}
+			return nil, apierrors.NewNotFound(corev1.Resource("services"), name)
+		}
+	}
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	externalName := corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "webhook.example.com"}
+	delegated := map[string]string{WorkspacePathAnnotationKey: "root:infra"}
+	allowed := func(paths string) map[string]string {
+		return map[string]string{AllowedWorkspacePathsAnnotationKey: paths}
+	}
+
+	tests := map[string]struct {
+		services       []*corev1.Service
+		remoteServices map[logicalcluster.Path]*corev1.Service
+		want           *Endpoint
+		wantErr        string
+	}{
+		"external name": {
+			services: []*corev1.Service{newService("org", "hook", nil, externalName)},
+			want:     &Endpoint{Host: "webhook.example.com:8443", ServerName: "webhook.example.com"},
+		},
+		"external IP": {
+			services: []*corev1.Service{newService("org", "hook", map[string]string{CABundleAnnotationKey: "ca"}, corev1.ServiceSpec{ExternalIPs: []string{"10.0.0.1"}})},
+			want:     &Endpoint{Host: "10.0.0.1:8443", CABundle: []byte("ca")},
+		},
+		"no endpoint": {
+			services: []*corev1.Service{newService("org", "hook", nil, corev1.ServiceSpec{})},
+			wantErr:  "must be of type ExternalName or have an external IP",
+		},
+		"not found": {
+			wantErr: "failed to get Service webhooks/hook in logical cluster org",
+		},
+		"delegated to a workspace of this shard": {
+			services: []*corev1.Service{
+				newService("org", "hook", delegated, corev1.ServiceSpec{}),
+				newService("infra", "hook", allowed("root:team, root:org"), externalName),
+			},
+			want: &Endpoint{Host: "webhook.example.com:8443", ServerName: "webhook.example.com"},
+		},
+		"delegated to a workspace allowing the logical cluster": {
+			services: []*corev1.Service{
+				newService("org", "hook", delegated, corev1.ServiceSpec{}),
+				newService("infra", "hook", allowed("org"), externalName),
+			},
+			want: &Endpoint{Host: "webhook.example.com:8443", ServerName: "webhook.example.com"},
+		},
+		"delegated to a workspace not allowing it": {
+			services: []*corev1.Service{
+				newService("org", "hook", delegated, corev1.ServiceSpec{}),
+				newService("infra", "hook", allowed("root:team"), externalName),
+			},
+			wantErr: "service webhooks/hook does not allow workspace root:org",
+		},
+		"delegated to a workspace without grant": {
+			services: []*corev1.Service{
+				newService("org", "hook", delegated, corev1.ServiceSpec{}),
+				newService("infra", "hook", nil, externalName),
+			},
+			wantErr: "service webhooks/hook does not allow workspace root:org",
+		},
+		"delegated to a workspace of another shard": {
+			services: []*corev1.Service{
+				newService("org", "hook", map[string]string{WorkspacePathAnnotationKey: "root:remote"}, corev1.ServiceSpec{}),
+			},
+			remoteServices: map[logicalcluster.Path]*corev1.Service{
+				logicalcluster.NewPath("root:remote"): newService("remote", "hook", allowed("root:org"), externalName),
+			},
+			want: &Endpoint{Host: "webhook.example.com:8443", ServerName: "webhook.example.com"},
+		},
+		"delegated to a workspace of another shard without front-proxy": {
+			services: []*corev1.Service{
+				newService("org", "hook", map[string]string{WorkspacePathAnnotationKey: "root:remote"}, corev1.ServiceSpec{}),
+			},
+			wantErr: "failed to get Service webhooks/hook in workspace root:remote",
+		},
+		"invalid path": {
+			services: []*corev1.Service{newService("org", "hook", map[string]string{WorkspacePathAnnotationKey: "root::"}, corev1.ServiceSpec{})},
+			wantErr:  "invalid " + WorkspacePathAnnotationKey + " annotation",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newTestResolver(tc.services, tc.remoteServices)
+			got, err := r.Resolve(context.Background(), "org", "webhooks", "hook", 8443)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	services := []*corev1.Service{newService("org", "hook", nil, corev1.ServiceSpec{ExternalIPs: []string{"10.0.0.1"}})}
+	r := newTestResolver(services, nil)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	endpoint, err := r.Resolve(context.Background(), "org", "webhooks", "hook", 443)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:443", endpoint.Host)
+
+	services[0].Spec.ExternalIPs = []string{"10.0.0.2"}
+	endpoint, err = r.Resolve(context.Background(), "org", "webhooks", "hook", 443)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:443", endpoint.Host, "expected the cached endpoint")
+
+	now = now.Add(DefaultTTL + time.Second)
+	endpoint, err = r.Resolve(context.Background(), "org", "webhooks", "hook", 443)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2:443", endpoint.Host)
+}
+
+func TestResolveCacheEviction(t *testing.T) {
+	var services []*corev1.Service
+	for i := 0; i <= maxCacheEntries; i++ {
+		services = append(services, newService("org", fmt.Sprintf("hook-%d", i), nil, corev1.ServiceSpec{ExternalIPs: []string{"10.0.0.1"}}))
+	}
+	r := newTestResolver(services, nil)
+
+	for i := range services {
+		_, err := r.Resolve(context.Background(), "org", "webhooks", services[i].Name, 443)
+		require.NoError(t, err)
+	}
+	require.Len(t, r.cache.Keys(), maxCacheEntries)
+
+	services[0].Spec.ExternalIPs = []string{"10.0.0.2"}
+	endpoint, err := r.Resolve(context.Background(), "org", "webhooks", services[0].Name, 443)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2:443", endpoint.Host, "expected the least recently used endpoint to be evicted")
+}
+
+type fakeAuthenticationInfoResolver struct{}
+
+func (fakeAuthenticationInfoResolver) ClientConfigFor(hostPort string) (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+func (fakeAuthenticationInfoResolver) ClientConfigForService(serviceName, serviceNamespace string, servicePort int) (*rest.Config, error) {
+	return &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("default")}}, nil
+}
+
+func TestForCluster(t *testing.T) {
+	services := []*corev1.Service{
+		newService("org", "hook", map[string]string{CABundleAnnotationKey: "-service"}, corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "webhook.example.com"}),
+	}
+	serviceResolver, authInfoResolver := newTestResolver(services, nil).ForCluster("org", fakeAuthenticationInfoResolver{})
+
+	u, err := serviceResolver.ResolveEndpoint("webhooks", "hook", 443)
+	require.NoError(t, err)
+	require.Equal(t, "https://webhook.example.com:443", u.String())
+
+	config, err := authInfoResolver.ClientConfigForService("hook", "webhooks", 443)
+	require.NoError(t, err)
+	require.Equal(t, "webhook.example.com", config.TLSClientConfig.ServerName)
+	require.Equal(t, "default-service", string(config.TLSClientConfig.CAData))
+
+	_, err = serviceResolver.ResolveEndpoint("webhooks", "other", 443)
+	require.Error(t, err)
+}
+
+func TestForClusterWithProxy(t *testing.T) {
+	services := []*corev1.Service{
+		newService("org", "hook", nil, corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "webhook.example.com"}),
+	}
+	proxyURL := &url.URL{Scheme: "http", Host: "proxy.example.com:3128"}
+	resolver := newTestResolver(services, nil)
+	resolver.proxy = http.ProxyURL(proxyURL)
+	_, authInfoResolver := resolver.ForCluster("org", fakeAuthenticationInfoResolver{})
+
+	config, err := authInfoResolver.ClientConfigForService("hook", "webhooks", 443)
+	require.NoError(t, err)
+	require.NotNil(t, config.Proxy)
+	u, err := config.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "webhook.example.com:443"}})
+	require.NoError(t, err)
+	require.Equal(t, proxyURL, u)
+
+	config, err = authInfoResolver.ClientConfigFor("webhook.example.com:443")
+	require.NoError(t, err)
+	require.NotNil(t, config.Proxy)
+}
@@ -22,6 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,6 +38,7 @@ import (
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 
 	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
@@ -51,9 +55,14 @@ const (
 
 func Register(plugins *admission.Plugins) {
 	plugins.Register(PluginName,
-		func(_ io.Reader) (admission.Interface, error) {
+		func(config io.Reader) (admission.Interface, error) {
+			configuration, err := LoadConfiguration(config)
+			if err != nil {
+				return nil, err
+			}
 			return &workspace{
 				Handler: admission.NewHandler(admission.Create, admission.Update),
+				config:  configuration,
 			}, nil
 		})
 }
@@ -62,6 +71,8 @@ type workspace struct {
 	*admission.Handler
 
 	logicalClusterLister corev1alpha1listers.LogicalClusterClusterLister
+
+	config *Configuration
 }
 
 // Ensure that the required admission interfaces are implemented.
@@ -72,7 +83,9 @@ var _ = kcpinitializers.WantsKcpInformers(&workspace{})
 
 // Admit ensures that
 // - the owner user is recorded in annotations on create
-// - the required groups are copied over from the LogicalCluster.
+// - the required groups are copied over from the LogicalCluster
+// - the type, location and required labels are defaulted on create, according to the child
+// workspace defaults of the LogicalCluster.
 func (o *workspace) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
 	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
 	if err != nil {
@@ -122,6 +135,14 @@ func (o *workspace) Admit(ctx context.Context, a admission.Attributes, _ admissi
 				delete(cw.Annotations, authorization.RequiredGroupsAnnotationKey)
 			}
 		}
+
+		defaults, err := o.childWorkspaceDefaults(clusterName)
+		if err != nil {
+			return admission.NewForbidden(a, err)
+		}
+		if defaults != nil {
+			defaults.apply(cw, sets.NewString(a.GetUserInfo().GetGroups()...))
+		}
 	}
 
 	return updateUnstructured(u, cw)
@@ -132,7 +153,8 @@ func (o *workspace) Admit(ctx context.Context, a admission.Attributes, _ admissi
 // - has a valid type and it is not mutated
 // - the cluster is not removed
 // - the user is recorded in annotations on create
-// - the required groups match with the LogicalCluster.
+// - the required groups match with the LogicalCluster
+// - the required labels of the child workspace defaults of the LogicalCluster are set on create.
 func (o *workspace) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
 	if err != nil {
@@ -183,6 +205,16 @@ func (o *workspace) Validate(ctx context.Context, a admission.Attributes, _ admi
 			}
 		}
 	case admission.Create:
+		if o.config != nil {
+			parentPath, err := o.canonicalPath(clusterName)
+			if err != nil {
+				return admission.NewForbidden(a, err)
+			}
+			if err := o.config.validatePath(parentPath.Join(cw.Name)); err != nil {
+				return admission.NewForbidden(a, err)
+			}
+		}
+
 		isSystemPrivileged := sets.NewString(a.GetUserInfo().GetGroups()...).Has(kuser.SystemPrivilegedGroup)
 
 		if !isSystemPrivileged {
@@ -208,12 +240,52 @@ func (o *workspace) Validate(ctx context.Context, a admission.Attributes, _ admi
 			if cw.Annotations[authorization.RequiredGroupsAnnotationKey] != expected {
 				return admission.NewForbidden(a, fmt.Errorf("missing required groups annotation %s=%s", authorization.RequiredGroupsAnnotationKey, expected))
 			}
+
+			defaults, err := o.childWorkspaceDefaults(clusterName)
+			if err != nil {
+				return admission.NewForbidden(a, err)
+			}
+			if defaults != nil {
+				if missing := defaults.missingLabels(cw); len(missing) > 0 {
+					return admission.NewForbidden(a, fmt.Errorf("missing labels required by the parent workspace: %s", strings.Join(missing, ", ")))
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// canonicalPath returns the canonical path of the given logical cluster.
+func (o *workspace) canonicalPath(clusterName logicalcluster.Name) (logicalcluster.Path, error) {
+	logicalCluster, err := o.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+	if apierrors.IsNotFound(err) {
+		return clusterName.Path(), nil
+	} else if err != nil {
+		return logicalcluster.Path{}, err
+	}
+	if path := logicalcluster.NewPath(logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
+		return path, nil
+	}
+	return clusterName.Path(), nil
+}
+
+// childWorkspaceDefaults returns the child workspace defaults of the given logical cluster, or nil if
+// it has none.
+func (o *workspace) childWorkspaceDefaults(clusterName logicalcluster.Name) (*childWorkspaceDefaults, error) {
+	logicalCluster, err := o.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	value, found := logicalCluster.Annotations[tenancyv1alpha1.ExperimentalChildWorkspaceDefaultsAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+	return parseChildWorkspaceDefaults(value)
+}
+
 func (o *workspace) ValidateInitialization() error {
 	if o.logicalClusterLister == nil {
 		return fmt.Errorf(PluginName + " plugin needs an LogicalCluster lister")
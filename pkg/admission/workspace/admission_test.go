@@ -35,6 +35,7 @@ import (
 	"k8s.io/apiserver/pkg/endpoints/request"
 
 	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
@@ -291,6 +292,92 @@ func TestAdmit(t *testing.T) {
 				Spec: tenancyv1beta1.WorkspaceSpec{},
 			},
 		},
+		{
+			name: "defaults type, location and labels from the child workspace defaults",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org:ws")).WithChildWorkspaceDefaults(`{"types":[{"group":"team-b","type":{"name":"other"}},{"group":"team-a","type":{"name":"team","path":"root"}}],"location":{"selector":{"matchLabels":{"region":"eu"}}},"requiredLabels":{"env":"dev","cost-center":""}}`).LogicalCluster,
+			},
+			clusterName: "root:org:ws",
+			a: createAttrWithUser(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"cost-center": "42"},
+				},
+			}, &kuser.DefaultInfo{
+				Name:   "someone",
+				Groups: []string{"team-a"},
+			}),
+			expectedObj: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"cost-center": "42", "env": "dev"},
+					Annotations: map[string]string{
+						"experimental.tenancy.kcp.io/owner": `{"username":"someone","groups":["team-a"]}`,
+					},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					Type: tenancyv1beta1.WorkspaceTypeReference{
+						Name: "team",
+						Path: "root",
+					},
+					Location: &tenancyv1beta1.WorkspaceLocation{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+					},
+				},
+			},
+		},
+		{
+			name: "keeps explicit values over the child workspace defaults",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org:ws")).WithChildWorkspaceDefaults(`{"types":[{"group":"team-b","type":{"name":"other"}},{"group":"team-a","type":{"name":"team","path":"root"}}],"location":{"selector":{"matchLabels":{"region":"eu"}}},"requiredLabels":{"env":"dev","cost-center":""}}`).LogicalCluster,
+			},
+			clusterName: "root:org:ws",
+			a: createAttrWithUser(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"env": "prod"},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					Type: tenancyv1beta1.WorkspaceTypeReference{
+						Name: "universal",
+						Path: "root",
+					},
+					Location: &tenancyv1beta1.WorkspaceLocation{},
+				},
+			}, &kuser.DefaultInfo{
+				Name:   "someone",
+				Groups: []string{"team-a"},
+			}),
+			expectedObj: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"env": "prod"},
+					Annotations: map[string]string{
+						"experimental.tenancy.kcp.io/owner": `{"username":"someone","groups":["team-a"]}`,
+					},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					Type: tenancyv1beta1.WorkspaceTypeReference{
+						Name: "universal",
+						Path: "root",
+					},
+					Location: &tenancyv1beta1.WorkspaceLocation{},
+				},
+			},
+		},
+		{
+			name: "rejects invalid child workspace defaults",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org:ws")).WithChildWorkspaceDefaults(`{"type":"team"}`).LogicalCluster,
+			},
+			clusterName: "root:org:ws",
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			}),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -317,6 +404,7 @@ func TestValidate(t *testing.T) {
 	tests := []struct {
 		name            string
 		logicalClusters []*corev1alpha1.LogicalCluster
+		config          string
 		a               admission.Attributes
 		expectedErrors  []string
 	}{
@@ -644,15 +732,101 @@ func TestValidate(t *testing.T) {
 				Groups: []string{kuser.SystemPrivilegedGroup},
 			}),
 		},
+		{
+			name: "rejects missing labels required by the child workspace defaults",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).WithChildWorkspaceDefaults(`{"requiredLabels":{"env":"","cost-center":""}}`).LogicalCluster,
+			},
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Labels:      map[string]string{"env": "dev"},
+					Annotations: map[string]string{"experimental.tenancy.kcp.io/owner": "{}"},
+				},
+			}),
+			expectedErrors: []string{"missing labels required by the parent workspace: cost-center"},
+		},
+		{
+			name: "accepts labels required by the child workspace defaults",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).WithChildWorkspaceDefaults(`{"requiredLabels":{"env":""}}`).LogicalCluster,
+			},
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Labels:      map[string]string{"env": "dev"},
+					Annotations: map[string]string{"experimental.tenancy.kcp.io/owner": "{}"},
+				},
+			}),
+		},
+		{
+			name: "accepts a workspace within the configured constraints",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).LogicalCluster,
+			},
+			config: "maxDepth: 3\nmaxPathLength: 13\nnamePattern: ^[a-z]+$\n",
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.io/owner": "{}"},
+				},
+			}),
+		},
+		{
+			name: "rejects a workspace deeper than the configured maximum depth",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).LogicalCluster,
+			},
+			config: "maxDepth: 2\n",
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.io/owner": "{}"},
+				},
+			}),
+			expectedErrors: []string{`workspace path "root:org:test" is deeper than the maximum depth of 2`},
+		},
+		{
+			name: "rejects a workspace longer than the configured maximum path length",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).WithPath("root:organization").LogicalCluster,
+			},
+			config: "maxPathLength: 20\n",
+			a: createAttr(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.io/owner": "{}"},
+				},
+			}),
+			expectedErrors: []string{`workspace path "root:organization:test" is longer than the maximum length of 20 characters`},
+		},
+		{
+			name: "rejects a workspace name not matching the configured pattern, even as system:master",
+			logicalClusters: []*corev1alpha1.LogicalCluster{
+				newLogicalCluster(logicalcluster.NewPath("root:org")).LogicalCluster,
+			},
+			config: "namePattern: ^[a-z]+$\n",
+			a: createAttrWithUser(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-1",
+				},
+			}, &kuser.DefaultInfo{
+				Groups: []string{kuser.SystemPrivilegedGroup},
+			}),
+			expectedErrors: []string{`workspace name "test-1" does not match the pattern "^[a-z]+$"`},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfiguration(strings.NewReader(tt.config))
+			require.NoError(t, err)
 			o := &workspace{
 				Handler:              admission.NewHandler(admission.Create, admission.Update),
 				logicalClusterLister: fakeLogicalClusterClusterLister(tt.logicalClusters),
+				config:               config,
 			}
 			ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
-			err := o.Validate(ctx, tt.a, nil)
+			err = o.Validate(ctx, tt.a, nil)
 			t.Logf("%v", err)
 			wantErr := len(tt.expectedErrors) > 0
 			require.Equal(t, wantErr, err != nil, "expected error: %v, got: %v", tt.expectedErrors, err)
@@ -705,6 +879,16 @@ func (b thisBuilder) WithRequiredGroups(groups ...string) thisBuilder {
 	return b
 }
 
+func (b thisBuilder) WithPath(path string) thisBuilder {
+	b.LogicalCluster.Annotations[core.LogicalClusterPathAnnotationKey] = path
+	return b
+}
+
+func (b thisBuilder) WithChildWorkspaceDefaults(value string) thisBuilder {
+	b.LogicalCluster.Annotations[tenancyv1alpha1.ExperimentalChildWorkspaceDefaultsAnnotationKey] = value
+	return b
+}
+
 type fakeLogicalClusterClusterLister []*corev1alpha1.LogicalCluster
 
 func (l fakeLogicalClusterClusterLister) List(selector labels.Selector) (ret []*corev1alpha1.LogicalCluster, err error) {
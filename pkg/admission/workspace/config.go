@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Configuration is the configuration of the Workspace admission plugin, as set in the
+// admission configuration file of the server, e.g.:
+//
+//	plugins:
+//	- name: tenancy.kcp.io/Workspace
+//	  configuration:
+//	    maxDepth: 6
+//	    maxPathLength: 128
+//	    namePattern: ^[a-z][a-z0-9-]*$
+//
+// The constraints apply to the creation of the workspaces, including the workspaces moved to another
+// parent, e.g. when orphaned.
+type Configuration struct {
+	// MaxDepth is the maximum number of segments of the path of a workspace, e.g. 3 for
+	// root:org:team. 0 means unlimited.
+	MaxDepth int `json:"maxDepth,omitempty"`
+
+	// MaxPathLength is the maximum number of characters of the path of a workspace. 0 means
+	// unlimited.
+	MaxPathLength int `json:"maxPathLength,omitempty"`
+
+	// NamePattern is a regular expression the names of the workspaces must match, in addition
+	// to being valid DNS labels.
+	NamePattern string `json:"namePattern,omitempty"`
+
+	namePattern *regexp.Regexp
+}
+
+// LoadConfiguration reads the configuration of the plugin, or returns nil if there is none.
+func LoadConfiguration(config io.Reader) (*Configuration, error) {
+	if config == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	c := &Configuration{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("invalid %s admission configuration: %w", PluginName, err)
+	}
+	if err := c.complete(); err != nil {
+		return nil, fmt.Errorf("invalid %s admission configuration: %w", PluginName, err)
+	}
+	return c, nil
+}
+
+func (c *Configuration) complete() error {
+	if c.MaxDepth < 0 {
+		return fmt.Errorf("maxDepth must not be negative")
+	}
+	if c.MaxPathLength < 0 {
+		return fmt.Errorf("maxPathLength must not be negative")
+	}
+	if c.NamePattern != "" {
+		pattern, err := regexp.Compile(c.NamePattern)
+		if err != nil {
+			return fmt.Errorf("invalid namePattern: %w", err)
+		}
+		c.namePattern = pattern
+	}
+	return nil
+}
+
+// validatePath returns an error if the workspace of the given path violates the constraints.
+func (c *Configuration) validatePath(path logicalcluster.Path) error {
+	if c == nil {
+		return nil
+	}
+	_, name := path.Split()
+	if c.namePattern != nil && !c.namePattern.MatchString(name) {
+		return fmt.Errorf("workspace name %q does not match the pattern %q", name, c.NamePattern)
+	}
+	if depth := strings.Count(path.String(), ":") + 1; c.MaxDepth > 0 && depth > c.MaxDepth {
+		return fmt.Errorf("workspace path %q is deeper than the maximum depth of %d", path, c.MaxDepth)
+	}
+	if c.MaxPathLength > 0 && len(path.String()) > c.MaxPathLength {
+		return fmt.Errorf("workspace path %q is longer than the maximum length of %d characters", path, c.MaxPathLength)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// childWorkspaceDefaults are the defaulting rules of the child workspaces of a workspace, as defined by
+// the tenancyv1alpha1.ExperimentalChildWorkspaceDefaultsAnnotationKey annotation of its LogicalCluster.
+type childWorkspaceDefaults struct {
+	Types          []defaultWorkspaceType            `json:"types,omitempty"`
+	Location       *tenancyv1beta1.WorkspaceLocation `json:"location,omitempty"`
+	RequiredLabels map[string]string                 `json:"requiredLabels,omitempty"`
+}
+
+type defaultWorkspaceType struct {
+	Group string                                `json:"group,omitempty"`
+	Type  tenancyv1beta1.WorkspaceTypeReference `json:"type"`
+}
+
+func parseChildWorkspaceDefaults(value string) (*childWorkspaceDefaults, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	defaults := &childWorkspaceDefaults{}
+	if err := decoder.Decode(defaults); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation of the parent workspace: %w", tenancyv1alpha1.ExperimentalChildWorkspaceDefaultsAnnotationKey, err)
+	}
+	for i, t := range defaults.Types {
+		if t.Type.Name == "" {
+			return nil, fmt.Errorf("invalid %s annotation of the parent workspace: types[%d].type.name is required", tenancyv1alpha1.ExperimentalChildWorkspaceDefaultsAnnotationKey, i)
+		}
+	}
+	return defaults, nil
+}
+
+// apply defaults the type, location and required labels of the workspace that are not set,
+// the type according to the groups of the creator.
+func (d *childWorkspaceDefaults) apply(ws *tenancyv1beta1.Workspace, groups sets.String) {
+	if ws.Spec.Type.Name == "" {
+		for _, t := range d.Types {
+			if t.Group == "" || groups.Has(t.Group) {
+				ws.Spec.Type = t.Type
+				break
+			}
+		}
+	}
+
+	if ws.Spec.Location == nil && d.Location != nil {
+		ws.Spec.Location = d.Location.DeepCopy()
+	}
+
+	for key, value := range d.RequiredLabels {
+		if _, found := ws.Labels[key]; found || value == "" {
+			continue
+		}
+		if ws.Labels == nil {
+			ws.Labels = map[string]string{}
+		}
+		ws.Labels[key] = value
+	}
+}
+
+// missingLabels returns the required labels the workspace does not have, sorted.
+func (d *childWorkspaceDefaults) missingLabels(ws *tenancyv1beta1.Workspace) []string {
+	var missing []string
+	for key := range d.RequiredLabels {
+		if _, found := ws.Labels[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
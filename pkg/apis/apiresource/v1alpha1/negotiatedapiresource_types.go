@@ -53,6 +53,53 @@ type NegotiatedAPIResourceSpec struct {
 	Publish               bool `json:"publish,omitempty"`
 }
 
+// NegotiationStrategyAnnotationKey is the annotation of the LogicalCluster of a workspace
+// selecting the negotiation strategy of the NegotiatedAPIResources of the workspace.
+//
+// The value is a comma-separated list of strategies, optionally prefixed with the
+// <resource>.<group>= they apply to, e.g. "Manual,deployments.apps=Strict,services=LCD".
+// The strategy without a group resource applies to the other resources of the workspace.
+const NegotiationStrategyAnnotationKey = "experimental.apiresource.kcp.io/negotiation-strategy"
+
+// NegotiationStrategyType defines how the APIResourceImports of a resource are negotiated into
+// its NegotiatedAPIResource.
+type NegotiationStrategyType string
+
+const (
+	// NegotiationStrict means that the negotiated schema is the intersection (LCD) of the schemas
+	// of the imports until it is published, and is never narrowed afterwards: an import whose schema
+	// does not include the published one is incompatible. The NegotiatedAPIResource is published
+	// automatically.
+	NegotiationStrict NegotiationStrategyType = "Strict"
+
+	// NegotiationLCD means that every import is taken into account, and the negotiated schema is
+	// narrowed to the LCD of the schemas of the imports even after it is published. The
+	// NegotiatedAPIResource is published automatically.
+	NegotiationLCD NegotiationStrategyType = "LCD"
+
+	// NegotiationManual means that the negotiated schema is updated according to the
+	// SchemaUpdateStrategy of the imports, and that the NegotiatedAPIResource is only published
+	// when its spec.publish field is set manually.
+	NegotiationManual NegotiationStrategyType = "Manual"
+)
+
+// AutoPublish returns whether NegotiatedAPIResources are published automatically with the strategy.
+func (strategy NegotiationStrategyType) AutoPublish() bool {
+	return strategy == NegotiationStrict || strategy == NegotiationLCD
+}
+
+// CanUpdate returns whether the negotiated schema can be narrowed to take an import with the given
+// schema update strategy into account.
+func (strategy NegotiationStrategyType) CanUpdate(importStrategy SchemaUpdateStrategyType, negotiatedAPIResourceIsPublished bool) bool {
+	switch strategy {
+	case NegotiationStrict:
+		return !negotiatedAPIResourceIsPublished
+	case NegotiationLCD:
+		return true
+	}
+	return importStrategy.CanUpdate(negotiatedAPIResourceIsPublished)
+}
+
 // NegotiatedAPIResourceConditionType is a valid value for NegotiatedAPIResourceCondition.Type
 type NegotiatedAPIResourceConditionType string
 
@@ -78,6 +125,28 @@ const (
 	// enforced CRD schema, and flag the API Resource import (and possibly the corresponding cluster location)
 	// accordingly.
 	Enforced NegotiatedAPIResourceConditionType = "Enforced"
+
+	// Approved means that the current spec of this negotiated API Resource has been approved
+	// for publication, when the approval of the publications is required.
+	Approved NegotiatedAPIResourceConditionType = "Approved"
+)
+
+const (
+	// ApprovedSpecAnnotationKey is the annotation of a NegotiatedAPIResource approving the
+	// publication of its spec, when the approval of the publications is required. Its value
+	// is the hash of the approved spec, as reported in the message of the Approved condition.
+	// A change of the spec invalidates the approval.
+	ApprovedSpecAnnotationKey = "experimental.apiresource.kcp.io/approved-spec"
+
+	// AutoApproveAnnotationKey is the annotation of the LogicalCluster of a workspace approving
+	// the publication of the NegotiatedAPIResources of the workspace by policy. Its value is a
+	// comma-separated list of <resource>.<group>, or * for all the resources.
+	AutoApproveAnnotationKey = "experimental.apiresource.kcp.io/auto-approve"
+
+	// RequireApprovalAnnotationKey is the annotation of the LogicalCluster of a workspace
+	// requiring the approval of the publication of the NegotiatedAPIResources of the workspace,
+	// when it is not required by default. Its value must be "true".
+	RequireApprovalAnnotationKey = "experimental.apiresource.kcp.io/require-approval"
 )
 
 // NegotiatedAPIResourceCondition contains details for the current condition of this negotiated api resource.
@@ -171,6 +171,46 @@ type APIBindingStatus struct {
 	// the binding to grant.
 	// +optional
 	ExportPermissionClaims []PermissionClaim `json:"exportPermissionClaims,omitempty"`
+
+	// usage records the usage of the bound resources in the workspace, when the API usage
+	// accounting is enabled. It is readable by the API service provider through the
+	// APIExport virtual workspace.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	Usage []BoundAPIResourceUsage `json:"usage,omitempty"`
+}
+
+// BoundAPIResourceUsage is the usage of a bound resource in the workspace of an APIBinding.
+type BoundAPIResourceUsage struct {
+	// group is the group of the bound resource. Empty string for the core API group.
+	//
+	// +required
+	Group string `json:"group"`
+
+	// resource is the bound resource.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Resource string `json:"resource"`
+
+	// objects is the number of objects of the resource in the workspace.
+	//
+	// +optional
+	Objects int64 `json:"objects,omitempty"`
+
+	// requests is the number of requests to the resource in the workspace since the
+	// accounting started.
+	//
+	// +optional
+	Requests int64 `json:"requests,omitempty"`
+
+	// lastUpdateTime is the last time the usage was updated.
+	//
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // These are valid conditions of APIBinding.
@@ -35,8 +35,25 @@ const (
 	APIExportVirtualWorkspaceURLsReady conditionsv1alpha1.ConditionType = "VirtualWorkspaceURLsReady"
 
 	ErrorGeneratingURLsReason = "ErrorGeneratingURLs"
+
+	// APIExportSchemasLinted is set by the optional schema linter, and is false when the latest
+	// APIResourceSchemas of the export do not follow the best practices.
+	APIExportSchemasLinted conditionsv1alpha1.ConditionType = "SchemasLinted"
+
+	SchemaLintFindingsReason = "LintFindings"
 )
 
+// DryRunBoundCRDsAnnotationKey is the annotation of an APIExport requesting the admission of the
+// APIExport to generate the bound CRDs of its newly referenced APIResourceSchemas, and to reject the
+// APIExport if they are invalid. Its value must be "true".
+const DryRunBoundCRDsAnnotationKey = "experimental.apis.kcp.io/dry-run-bound-crds"
+
+// SharedLabelsAnnotationKey is the annotation of the LogicalCluster of a workspace listing the labels
+// of the LogicalCluster shared with the providers of the APIExports bound in the workspace, through the
+// logicalclusters resource of the APIExport virtual workspace. Its value is a comma-separated list of
+// label keys.
+const SharedLabelsAnnotationKey = "experimental.apis.kcp.io/shared-labels"
+
 // These are for APIExport identity.
 const (
 	// SecretKeyAPIExportIdentity is the key in an identity secret for the identity of an APIExport.
@@ -76,6 +93,12 @@ const (
 	// this APIExport. If the annotation is removed from the APIExport, it will also be removed from
 	// all APIBindings bound to this APIExport.
 	AnnotationAPIExportExtraKeyPrefix = "extra.apis.kcp.io/"
+
+	// AnnotationAPIExportTrustBundleKey is the annotation set by the system on the APIExports of a
+	// logical cluster, holding the trust bundles of the logical cluster. Like the other extra annotations,
+	// it is synced to the APIBindings bound to the APIExports, and projected from there into the
+	// namespaces of the consumers.
+	AnnotationAPIExportTrustBundleKey = AnnotationAPIExportExtraKeyPrefix + "trust-bundle"
 )
 
 func (in *APIExport) GetConditions() conditionsv1alpha1.Conditions {
@@ -127,6 +150,21 @@ type APIExportSpec struct {
 	// +optional
 	Identity *Identity `json:"identity,omitempty"`
 
+	// description is a human-readable description of the APIs of this APIExport, shown
+	// in catalogs.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty"`
+
+	// icon is an image representing this APIExport in catalogs, as an https URL or a
+	// data URI.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=32768
+	// +kubebuilder:validation:Pattern:="^(https://|data:image/)"
+	Icon string `json:"icon,omitempty"`
+
 	// TODO: before beta we should re-evaluate this field name
 
 	// maximalPermissionPolicy will allow for a service provider to set an upper bound on what is allowed
@@ -145,6 +145,13 @@ func (in *APIBindingStatus) DeepCopyInto(out *APIBindingStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = make([]BoundAPIResourceUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -579,6 +586,23 @@ func (in *BoundAPIResource) DeepCopy() *BoundAPIResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BoundAPIResourceUsage) DeepCopyInto(out *BoundAPIResourceUsage) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BoundAPIResourceUsage.
+func (in *BoundAPIResourceUsage) DeepCopy() *BoundAPIResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(BoundAPIResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BoundAPIResourceSchema) DeepCopyInto(out *BoundAPIResourceSchema) {
 	*out = *in
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	topologyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/topology/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// packages are the directories of the API packages covered by the conformance tests, relative to this one.
+var packages = []string{
+	"../apis/v1alpha1",
+	"../core/v1alpha1",
+	"../scheduling/v1alpha1",
+	"../tenancy/v1alpha1",
+	"../tenancy/v1beta1",
+	"../topology/v1alpha1",
+	"../workload/v1alpha1",
+}
+
+var (
+	camelCase = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+	// negativePolarity matches condition types for which True would be an undesired state.
+	negativePolarity = regexp.MustCompile(`^(Not|No|Un)[A-Z]|(Failed|Failure|Error|Errors|Invalid|Pending)$`)
+)
+
+func TestConditionTypes(t *testing.T) {
+	for _, c := range constants(t, func(spec *ast.ValueSpec, _ string) bool {
+		sel, ok := spec.Type.(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == "ConditionType"
+	}) {
+		require.Regexp(t, camelCase, c.value, "condition type %s (%s) must be CamelCase", c.name, c.pos)
+		require.NotRegexp(t, negativePolarity, c.value, "condition type %s (%s) must have a positive polarity, i.e. True must be the desired state", c.name, c.pos)
+	}
+}
+
+func TestConditionReasons(t *testing.T) {
+	for _, c := range constants(t, func(_ *ast.ValueSpec, name string) bool {
+		return strings.HasSuffix(name, "Reason")
+	}) {
+		require.Regexp(t, camelCase, c.value, "reason %s (%s) must be CamelCase", c.name, c.pos)
+	}
+}
+
+func TestConditionsAccessors(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		apisv1alpha1.AddToScheme,
+		corev1alpha1.AddToScheme,
+		schedulingv1alpha1.AddToScheme,
+		tenancyv1alpha1.AddToScheme,
+		tenancyv1beta1.AddToScheme,
+		topologyv1alpha1.AddToScheme,
+		workloadv1alpha1.AddToScheme,
+	} {
+		utilruntime.Must(addToScheme(scheme))
+	}
+
+	conditionsType := reflect.TypeOf(conditionsv1alpha1.Conditions{})
+	setterType := reflect.TypeOf((*conditions.Setter)(nil)).Elem()
+
+	for gvk, typ := range scheme.AllKnownTypes() {
+		status, ok := typ.FieldByName("Status")
+		if !ok || status.Type.Kind() != reflect.Struct {
+			continue
+		}
+		field, ok := status.Type.FieldByName("Conditions")
+		if !ok {
+			continue
+		}
+		require.Equal(t, conditionsType, field.Type, "%s status conditions must be of type %s", gvk, conditionsType)
+		require.Equal(t, "conditions,omitempty", field.Tag.Get("json"), "%s status conditions must be serialized as conditions", gvk)
+		require.True(t, reflect.PtrTo(typ).Implements(setterType), "%s must implement the conditions getter and setter", gvk)
+	}
+}
+
+type constant struct {
+	name, value string
+	pos         token.Position
+}
+
+// constants returns the string constants of the API packages that match.
+func constants(t *testing.T, match func(spec *ast.ValueSpec, name string) bool) []constant {
+	t.Helper()
+
+	var found []constant
+	fset := token.NewFileSet()
+	for _, dir := range packages {
+		pkgs, err := parser.ParseDir(fset, filepath.FromSlash(dir), func(info fs.FileInfo) bool {
+			return !strings.HasSuffix(info.Name(), "_test.go")
+		}, 0)
+		require.NoError(t, err)
+
+		for _, pkg := range pkgs {
+			for _, file := range pkg.Files {
+				for _, decl := range file.Decls {
+					gen, ok := decl.(*ast.GenDecl)
+					if !ok || gen.Tok != token.CONST {
+						continue
+					}
+					for _, s := range gen.Specs {
+						spec := s.(*ast.ValueSpec)
+						for i, name := range spec.Names {
+							if i >= len(spec.Values) || !match(spec, name.Name) {
+								continue
+							}
+							lit, ok := spec.Values[i].(*ast.BasicLit)
+							if !ok || lit.Kind != token.STRING {
+								continue
+							}
+							value, err := strconv.Unquote(lit.Value)
+							require.NoError(t, err)
+							found = append(found, constant{name: name.Name, value: value, pos: fset.Position(name.Pos())})
+						}
+					}
+				}
+			}
+		}
+	}
+	require.NotEmpty(t, found, "no constant found, are the API packages still at the expected location?")
+	return found
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance verifies that the kcp APIs follow common conventions, so that generic
+// tooling, e.g. kstatus, Argo CD health checks or the kubectl plugins, can reason about them
+// uniformly.
+//
+// Conditions of the kcp APIs follow the metav1.Condition semantics:
+//   - condition types are CamelCase and have a positive polarity, i.e. True is the desired state;
+//   - reasons are CamelCase;
+//   - the observedGeneration records the generation of the object a condition was based upon,
+//     which is set by the conditions utilities of third_party/conditions.
+//
+// The legacy apiresource API group, which has its own condition types, is not covered.
+package conformance
@@ -98,8 +98,45 @@ type LogicalClusterSpec struct {
 	//
 	// +optional
 	Initializers []LogicalClusterInitializer `json:"initializers,omitempty"`
+
+	// trustBundles are the CA bundles trusted in this logical cluster, e.g. to verify the serving
+	// certificates of webhooks, aggregated APIs or external services. They are projected into the
+	// kcp-trust-bundle.crt ConfigMap of every namespace of this logical cluster, and of the logical
+	// clusters binding the APIExports of this logical cluster.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	TrustBundles []TrustBundle `json:"trustBundles,omitempty"`
 }
 
+// TrustBundle is a named PEM encoded CA bundle.
+type TrustBundle struct {
+	// name identifies the bundle in the logical cluster.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// pem is the PEM encoded bundle of CA certificates.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	PEM string `json:"pem"`
+}
+
+const (
+	// TrustBundleConfigMapName is the name of the ConfigMap projecting the trust bundles of a
+	// logical cluster, and of the providers of the APIs bound in the logical cluster, into each of its
+	// namespaces, and from there into the downstream namespaces of the syncers.
+	TrustBundleConfigMapName = "kcp-trust-bundle.crt"
+
+	// TrustBundleConfigMapKey is the key of the trust bundles in the TrustBundleConfigMapName ConfigMap.
+	TrustBundleConfigMapKey = "ca.crt"
+)
+
 // LogicalClusterOwner is a reference to a resource controlling the life-cycle of a LogicalCluster.
 type LogicalClusterOwner struct {
 	// apiVersion is the group and API version of the owner.
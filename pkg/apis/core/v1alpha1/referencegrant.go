@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+const (
+	// ReferenceGrantsAnnotationKey is the annotation of a Secret or ConfigMap granting other
+	// workspaces the read access to it. Its value is a JSON list of grantees, each of them being
+	// either a workspace, as {"workspace":"<path>"}, or the workspaces binding an APIExport, as
+	// {"export":"<path>:<name>"}.
+	ReferenceGrantsAnnotationKey = "experimental.core.kcp.io/reference-grants"
+
+	// ReferenceAnnotationKey is the annotation of a Secret or ConfigMap referencing a Secret or
+	// ConfigMap of the same kind in another workspace, as <path>/<namespace>/<name>. The data of
+	// the referenced object are projected into the annotated object as long as the referenced
+	// object grants its workspace with the ReferenceGrantsAnnotationKey annotation, and removed
+	// otherwise.
+	ReferenceAnnotationKey = "experimental.core.kcp.io/reference"
+
+	// ReferenceStatusAnnotationKey is the annotation of a Secret or ConfigMap with the
+	// ReferenceAnnotationKey annotation reporting the resolution of the reference, one of
+	// ReferenceResolved, ReferenceInvalid, ReferenceNotFound or ReferenceNotGranted.
+	ReferenceStatusAnnotationKey = "experimental.core.kcp.io/reference-status"
+)
+
+const (
+	// ReferenceResolved means that the data of the referenced object are projected.
+	ReferenceResolved = "Resolved"
+	// ReferenceInvalid means that the reference cannot be parsed.
+	ReferenceInvalid = "Invalid"
+	// ReferenceNotFound means that the referenced object does not exist.
+	ReferenceNotFound = "NotFound"
+	// ReferenceNotGranted means that the referenced object does not grant the workspace.
+	ReferenceNotGranted = "NotGranted"
+)
@@ -27,6 +27,10 @@ import (
 // RootShard holds a name of the root shard.
 var RootShard = "root"
 
+// ShardUnschedulableAnnotationKey cordons a shard when set to "true": new workspaces are not
+// scheduled onto it anymore, while the logical clusters it already hosts are left untouched.
+const ShardUnschedulableAnnotationKey = "experimental.core.kcp.io/unschedulable"
+
 // Shard describes a kcp instance on which a number of logical clusters will live
 //
 // +crd
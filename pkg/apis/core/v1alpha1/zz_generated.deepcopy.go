@@ -118,6 +118,11 @@ func (in *LogicalClusterSpec) DeepCopyInto(out *LogicalClusterSpec) {
 		*out = make([]LogicalClusterInitializer, len(*in))
 		copy(*out, *in)
 	}
+	if in.TrustBundles != nil {
+		in, out := &in.TrustBundles, &out.TrustBundles
+		*out = make([]TrustBundle, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -265,3 +270,19 @@ func (in *ShardStatus) DeepCopy() *ShardStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustBundle) DeepCopyInto(out *TrustBundle) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustBundle.
+func (in *TrustBundle) DeepCopy() *TrustBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustBundle)
+	in.DeepCopyInto(out)
+	return out
+}
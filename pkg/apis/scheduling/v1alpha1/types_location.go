@@ -71,6 +71,14 @@ type LocationSpec struct {
 	// +optional
 	Description string `json:"description,omitempty"`
 
+	// icon is an image representing the location in catalogs, as an https URL or a
+	// data URI.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=32768
+	// +kubebuilder:validation:Pattern:="^(https://|data:image/)"
+	Icon string `json:"icon,omitempty"`
+
 	// availableSelectorLabels is a list of labels that can be used to select an
 	// instance at this location in a placement object.
 	//
@@ -155,6 +163,34 @@ type LocationStatus struct {
 
 	// available is the number of actual instances that are available at this location.
 	AvailableInstances *uint32 `json:"availableInstances,omitempty"`
+
+	// unavailableInstances is a sample of the instances that are not available at this
+	// location, with the reason why. At most 5 instances are listed.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	UnavailableInstances []UnavailableInstance `json:"unavailableInstances,omitempty"`
+}
+
+// UnavailableInstance is an instance that is not available at a location.
+type UnavailableInstance struct {
+	// name is the name of the instance.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// reason is a brief CamelCase reason why the instance is not available.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// message is a human readable message explaining why the instance is not available.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // LocationList is a list of locations.
@@ -61,6 +61,17 @@ func (in *Placement) GetConditions() conditionsv1alpha1.Conditions {
 var _ conditions.Getter = &Placement{}
 var _ conditions.Setter = &Placement{}
 
+// NamespacePlacementsAnnotationKey is the annotation of a namespace overriding the Placements of
+// its workspace that apply to it. Its value is a comma-separated list of Placement names:
+//
+//   - a name pins the Placement to the namespace, even if its namespace selector does not match
+//     the namespace, and constrains the namespace to the pinned Placements only.
+//   - a name prefixed with "-" excludes the Placement, even if its namespace selector matches
+//     the namespace.
+//
+// An empty value excludes the namespace from scheduling entirely.
+const NamespacePlacementsAnnotationKey = "experimental.scheduling.kcp.io/placements"
+
 type PlacementSpec struct {
 	// locationSelectors represents a slice of label selector to select a location, these label selectors
 	// are logically ORed.
@@ -95,11 +106,87 @@ type PlacementStatus struct {
 	// +optional
 	SelectedLocation *LocationReference `json:"selectedLocation,omitempty"`
 
+	// decisions is the history of the scheduling decisions of the placement, the most recent
+	// last, with the candidates they rejected. At most 10 decisions are kept.
+	//
+	// +optional
+	Decisions []PlacementDecision `json:"decisions,omitempty"`
+
 	// Current processing state of the Placement.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
 }
 
+// PlacementDecisionStage is the stage of the scheduling of a placement a decision is made at.
+type PlacementDecisionStage string
+
+const (
+	// PlacementDecisionStageLocation is the stage selecting the location of a placement.
+	PlacementDecisionStageLocation PlacementDecisionStage = "Location"
+
+	// PlacementDecisionStageSyncTarget is the stage selecting the SyncTarget of a placement in
+	// its selected location.
+	PlacementDecisionStageSyncTarget PlacementDecisionStage = "SyncTarget"
+)
+
+// PlacementDecision is a scheduling decision of a placement.
+type PlacementDecision struct {
+	// time is when the decision was made.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Time metav1.Time `json:"time"`
+
+	// stage is the stage of the scheduling the decision was made at.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Location;SyncTarget
+	Stage PlacementDecisionStage `json:"stage"`
+
+	// selected is the name of the selected location or SyncTarget, if any.
+	//
+	// +optional
+	Selected string `json:"selected,omitempty"`
+
+	// reason is a brief CamelCase reason of the decision.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// message is a human readable message explaining the decision.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// rejected are the candidates rejected by the decision, with the reason why. At most 10
+	// candidates are listed.
+	//
+	// +optional
+	Rejected []PlacementRejection `json:"rejected,omitempty"`
+}
+
+// PlacementRejection is a location or SyncTarget rejected by a scheduling decision.
+type PlacementRejection struct {
+	// name is the name of the rejected location or SyncTarget.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// reason is a brief CamelCase reason why the candidate is rejected.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// message is a human readable message explaining why the candidate is rejected.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // LocationReference describes a location that are provided in the specified Workspace.
 type LocationReference struct {
 	// path is an absolute reference to a workspace, e.g. root:org:ws. The workspace must
@@ -164,6 +251,26 @@ const (
 	ScheduleNoValidTargetReason = "NoValidTarget"
 )
 
+const (
+	// LocationSelectedReason is the reason of a decision selecting a location.
+	LocationSelectedReason = "LocationSelected"
+
+	// SyncTargetSelectedReason is the reason of a decision selecting a SyncTarget.
+	SyncTargetSelectedReason = "SyncTargetSelected"
+
+	// ResourceMismatchReason is the reason of the rejection of a location for another resource
+	// than the location resource of the placement.
+	ResourceMismatchReason = "ResourceMismatch"
+
+	// SelectorMismatchReason is the reason of the rejection of a location whose labels match
+	// none of the location selectors of the placement.
+	SelectorMismatchReason = "SelectorMismatch"
+
+	// APIIncompatibleReason is the reason of the rejection of a SyncTarget that does not support
+	// the APIs bound in the workspace of the placement.
+	APIIncompatibleReason = "APIIncompatible"
+)
+
 // PlacementList is a list of locations.
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
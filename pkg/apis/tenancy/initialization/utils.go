@@ -18,6 +18,7 @@ package initialization
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -92,3 +93,22 @@ func InitializerToLabel(initializer corev1alpha1.LogicalClusterInitializer) (str
 	labelKeyHashLength := validation.LabelValueMaxLength - len(tenancyv1alpha1.WorkspaceInitializerLabelPrefix)
 	return tenancyv1alpha1.WorkspaceInitializerLabelPrefix + hash[0:labelKeyHashLength], hash
 }
+
+// TypeRevision returns the revision of the blueprint of the workspaces of the WorkspaceType, i.e. a hash
+// of the fields determining their initialization. Changes to the extended types are not taken into account.
+func TypeRevision(cwt *tenancyv1alpha1.WorkspaceType) string {
+	bs, err := json.Marshal(struct {
+		Initializer        bool                                   `json:"initializer,omitempty"`
+		Extend             tenancyv1alpha1.WorkspaceTypeExtension `json:"extend,omitempty"`
+		DefaultAPIBindings []tenancyv1alpha1.APIExportReference   `json:"defaultAPIBindings,omitempty"`
+	}{
+		Initializer:        cwt.Spec.Initializer,
+		Extend:             cwt.Spec.Extend,
+		DefaultAPIBindings: cwt.Spec.DefaultAPIBindings,
+	})
+	if err != nil {
+		// should never happen
+		panic(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum224(bs))[:16]
+}
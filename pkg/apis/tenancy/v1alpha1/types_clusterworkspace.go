@@ -170,6 +170,17 @@ type ClusterWorkspaceStatus struct {
 
 const ExperimentalWorkspaceOwnerAnnotationKey string = "experimental.tenancy.kcp.io/owner"
 
+// ExperimentalChildWorkspaceDefaultsAnnotationKey is the annotation of the LogicalCluster of a workspace
+// defining the defaulting rules of its child workspaces, applied on creation when they are created without
+// explicit values. The value is a JSON object with the optional fields:
+//
+//   - types: the default types of the child workspaces by creator group, e.g.
+//     [{"group":"team-a","type":{"name":"team","path":"root"}},{"type":{"name":"universal","path":"root"}}].
+//     The first entry whose group the creator belongs to, or without group, applies.
+//   - location: the default location of the child workspaces, e.g. {"selector":{"matchLabels":{"region":"eu"}}}.
+//   - requiredLabels: the labels the child workspaces must have. A non-empty value is the default value of the label.
+const ExperimentalChildWorkspaceDefaultsAnnotationKey string = "experimental.tenancy.kcp.io/child-workspace-defaults"
+
 // ClusterWorkspaceLocation specifies workspace placement information, including current, desired (target), and
 // historical information.
 type ClusterWorkspaceLocation struct {
@@ -210,6 +221,19 @@ const (
 	// WorkspaceContentDeleted represents the status that all resources in the workspace are deleted.
 	WorkspaceContentDeleted conditionsv1alpha1.ConditionType = "WorkspaceContentDeleted"
 
+	// WorkspaceChildrenDeleted represents the status of the child workspaces of a deleting workspace,
+	// handled according to its deletion policy.
+	WorkspaceChildrenDeleted conditionsv1alpha1.ConditionType = "ChildrenDeleted"
+	// WorkspaceChildrenDeletedDeleting reason in ChildrenDeleted condition means that child workspaces
+	// are being deleted.
+	WorkspaceChildrenDeletedDeleting = "ChildrenDeleting"
+	// WorkspaceChildrenDeletedBlocked reason in ChildrenDeleted condition means that the deletion is
+	// blocked by the existing child workspaces.
+	WorkspaceChildrenDeletedBlocked = "DeletionBlocked"
+	// WorkspaceChildrenDeletedOrphaning reason in ChildrenDeleted condition means that child workspaces
+	// are being moved into the parent workspace.
+	WorkspaceChildrenDeletedOrphaning = "ChildrenOrphaning"
+
 	// WorkspaceInitialized represents the status that initialization has finished.
 	WorkspaceInitialized conditionsv1alpha1.ConditionType = "WorkspaceInitialized"
 	// WorkspaceInitializedInitializerExists reason in WorkspaceInitialized condition means that there is at least
@@ -218,6 +242,10 @@ const (
 	// WorkspaceInitializedWorkspaceDisappeared reason in WorkspaceInitialized condition means that the LogicalCluster
 	// object has disappeared.
 	WorkspaceInitializedWorkspaceDisappeared = "WorkspaceDisappeared"
+	// WorkspaceInitializedInitializerTimedOut reason in WorkspaceInitialized condition means that at least
+	// one initializer has not finished within the timeout of its WorkspaceType, and that the initialization
+	// has failed.
+	WorkspaceInitializedInitializerTimedOut = "InitializerTimedOut"
 
 	// WorkspaceAPIBindingsInitialized represents the status of the initial APIBindings for the workspace.
 	WorkspaceAPIBindingsInitialized conditionsv1alpha1.ConditionType = "APIBindingsInitialized"
@@ -69,6 +69,29 @@ type WorkspaceTypeSpec struct {
 	// +optional
 	Initializer bool `json:"initializer,omitempty"`
 
+	// initializerTimeout is the duration after which the initializer of this WorkspaceType
+	// is considered as failed if it has not finished its work on a workspace, counted from
+	// the creation of the logical cluster of the workspace, or from the last retry.
+	//
+	// If unset, the initializer can take an unlimited time.
+	//
+	// +optional
+	InitializerTimeout *metav1.Duration `json:"initializerTimeout,omitempty"`
+
+	// initializerTimeoutPolicy defines what happens to a workspace when the initializer of
+	// this WorkspaceType has timed out:
+	//
+	// - Fail keeps the workspace initializing, with a failed WorkspaceInitialized condition.
+	//   This is the default.
+	// - Retry signals the initialization again, by setting the
+	//   experimental.tenancy.kcp.io/initialization-retried-at annotation on the LogicalCluster,
+	//   and restarts the timeout.
+	// - Delete deletes the workspace.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Fail;Retry;Delete
+	InitializerTimeoutPolicy InitializerTimeoutPolicy `json:"initializerTimeoutPolicy,omitempty"`
+
 	// extend is a list of other WorkspaceTypes whose initializers and limitAllowedChildren
 	// and limitAllowedParents this WorkspaceType is inheriting. By (transitively) extending
 	// another WorkspaceType, this WorkspaceType will be considered as that
@@ -116,8 +139,81 @@ type WorkspaceTypeSpec struct {
 	//
 	// +optional
 	DefaultAPIBindings []APIExportReference `json:"defaultAPIBindings,omitempty"`
+
+	// description is a human-readable description of the workspaces of this type, shown
+	// in catalogs.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty"`
+
+	// icon is an image representing this WorkspaceType in catalogs, as an https URL or a
+	// data URI.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=32768
+	// +kubebuilder:validation:Pattern:="^(https://|data:image/)"
+	Icon string `json:"icon,omitempty"`
+
+	// migration configures the migration of the existing workspaces of this type to the
+	// current revision of the type, as reported in status.revision. The revision changes with
+	// the initializer, extend and defaultAPIBindings fields.
+	//
+	// A workspace is migrated by re-running the initializers of the type, which puts its
+	// logical cluster back into the Initializing phase until they are done.
+	//
+	// If unset, the existing workspaces are not migrated.
+	//
+	// +optional
+	Migration *WorkspaceTypeMigration `json:"migration,omitempty"`
+}
+
+// WorkspaceTypeMigration controls the rollout of a new revision of a WorkspaceType to its
+// existing workspaces.
+type WorkspaceTypeMigration struct {
+	// canarySelector selects the LogicalClusters, by their labels, of the workspaces that are
+	// migrated to a new revision before it is promoted.
+	//
+	// +optional
+	CanarySelector *metav1.LabelSelector `json:"canarySelector,omitempty"`
+
+	// promotedRevision is the revision to which all the workspaces of this type are migrated.
+	// As long as it differs from the current revision, only the canaries are migrated.
+	//
+	// +optional
+	PromotedRevision string `json:"promotedRevision,omitempty"`
+
+	// maxConcurrent is the maximum number of workspaces of this type migrated at the same
+	// time on each shard.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrent int32 `json:"maxConcurrent,omitempty"`
+
+	// paused stops the migration of further workspaces.
+	//
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
+// InitializerTimeoutPolicy defines what happens to a workspace whose initializer has timed out.
+type InitializerTimeoutPolicy string
+
+const (
+	// InitializerTimeoutPolicyFail marks the initialization of the workspace as failed.
+	InitializerTimeoutPolicyFail InitializerTimeoutPolicy = "Fail"
+	// InitializerTimeoutPolicyRetry signals the initialization of the workspace again.
+	InitializerTimeoutPolicyRetry InitializerTimeoutPolicy = "Retry"
+	// InitializerTimeoutPolicyDelete deletes the workspace.
+	InitializerTimeoutPolicyDelete InitializerTimeoutPolicy = "Delete"
+)
+
+// ExperimentalInitializationRetriedAtAnnotationKey is the annotation of the LogicalCluster of an
+// initializing workspace set when its initialization is retried after an initializer has timed out.
+// The value is the RFC3339 time of the last retry, from which the initializer timeouts are counted.
+const ExperimentalInitializationRetriedAtAnnotationKey = "experimental.tenancy.kcp.io/initialization-retried-at"
+
 // APIExportReference provides the fields necessary to resolve an APIExport.
 type APIExportReference struct {
 	// path is the fully-qualified path to the workspace containing the APIExport. If it is
@@ -181,6 +277,12 @@ type WorkspaceTypeStatus struct {
 	// virtualWorkspaces contains all APIExport virtual workspace URLs.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// revision identifies the current revision of the blueprint of the workspaces of this type,
+	// i.e. of its initializer, extend and defaultAPIBindings fields.
+	//
+	// +optional
+	Revision string `json:"revision,omitempty"`
 }
 
 type VirtualWorkspace struct {
@@ -292,6 +292,27 @@ func (in *WorkspaceTypeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceTypeMigration) DeepCopyInto(out *WorkspaceTypeMigration) {
+	*out = *in
+	if in.CanarySelector != nil {
+		in, out := &in.CanarySelector, &out.CanarySelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceTypeMigration.
+func (in *WorkspaceTypeMigration) DeepCopy() *WorkspaceTypeMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceTypeMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceTypeReference) DeepCopyInto(out *WorkspaceTypeReference) {
 	*out = *in
@@ -332,6 +353,11 @@ func (in *WorkspaceTypeSelector) DeepCopy() *WorkspaceTypeSelector {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceTypeSpec) DeepCopyInto(out *WorkspaceTypeSpec) {
 	*out = *in
+	if in.InitializerTimeout != nil {
+		in, out := &in.InitializerTimeout, &out.InitializerTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	in.Extend.DeepCopyInto(&out.Extend)
 	if in.AdditionalWorkspaceLabels != nil {
 		in, out := &in.AdditionalWorkspaceLabels, &out.AdditionalWorkspaceLabels
@@ -360,6 +386,11 @@ func (in *WorkspaceTypeSpec) DeepCopyInto(out *WorkspaceTypeSpec) {
 		*out = make([]APIExportReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(WorkspaceTypeMigration)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
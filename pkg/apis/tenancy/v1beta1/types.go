@@ -28,6 +28,14 @@ import (
 // the type of the workspace on the corresponding LogicalCluster object. Its format is "root:ws:name".
 const LogicalClusterTypeAnnotationKey = "internal.tenancy.kcp.io/type"
 
+// LogicalClusterTypeRevisionAnnotationKey is the annotation key used to indicate the revision
+// of the type of the workspace the corresponding LogicalCluster has been initialized with.
+const LogicalClusterTypeRevisionAnnotationKey = "internal.tenancy.kcp.io/type-revision"
+
+// LogicalClusterMigratingAnnotationKey is the annotation key set on a LogicalCluster while it
+// is being migrated to a new revision of the type of its workspace.
+const LogicalClusterMigratingAnnotationKey = "internal.tenancy.kcp.io/migrating"
+
 // Workspace defines a generic Kubernetes-cluster-like endpoint, with standard Kubernetes
 // discovery APIs, OpenAPI and resource API endpoints.
 //
@@ -81,8 +89,33 @@ type WorkspaceSpec struct {
 	//
 	// +optional
 	Location *WorkspaceLocation `json:"shard,omitempty"`
+
+	// deletionPolicy defines how the child workspaces are handled when this workspace
+	// is deleted:
+	//
+	// - Cascade deletes the child workspaces, and waits for them to be gone, before
+	//   the content of this workspace is deleted. This is the default.
+	// - Block waits for the child workspaces to be deleted by other means.
+	// - Orphan moves the child workspaces, with their logical clusters, into the
+	//   parent of this workspace.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Cascade;Block;Orphan
+	DeletionPolicy WorkspaceDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+// WorkspaceDeletionPolicy defines how the child workspaces of a deleted workspace are handled.
+type WorkspaceDeletionPolicy string
+
+const (
+	// WorkspaceDeletionPolicyCascade deletes the child workspaces in the foreground.
+	WorkspaceDeletionPolicyCascade WorkspaceDeletionPolicy = "Cascade"
+	// WorkspaceDeletionPolicyBlock blocks the deletion as long as child workspaces exist.
+	WorkspaceDeletionPolicyBlock WorkspaceDeletionPolicy = "Block"
+	// WorkspaceDeletionPolicyOrphan moves the child workspaces into the parent workspace.
+	WorkspaceDeletionPolicyOrphan WorkspaceDeletionPolicy = "Orphan"
+)
+
 // WorkspaceTypeReference is a reference to a workspace type.
 type WorkspaceTypeReference struct {
 	// name is the name of the WorkspaceType
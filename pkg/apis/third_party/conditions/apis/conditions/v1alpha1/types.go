@@ -82,6 +82,12 @@ type Condition struct {
 	// This field may be empty.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation of the object the condition was set based upon.
+	// If it is lower than the current .metadata.generation of the object, the condition is out of date
+	// with respect to the current state of the object.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // ANCHOR_END: Condition
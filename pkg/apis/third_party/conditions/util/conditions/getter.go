@@ -230,6 +230,8 @@ func mirror(from Getter, targetCondition conditionsapi.ConditionType, options ..
 
 	if condition != nil {
 		condition.Type = targetCondition
+		// the generation of the source object is meaningless for the target object
+		condition.ObservedGeneration = 0
 	}
 
 	return condition
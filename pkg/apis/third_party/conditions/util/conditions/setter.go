@@ -38,11 +38,18 @@ type Setter interface {
 //
 // NOTE: If a condition already exists, the LastTransitionTime is updated only if a change is detected
 // in any of the following fields: Status, Reason, Severity and Message.
+//
+// NOTE: If the condition does not record the generation it is based upon, the ObservedGeneration is set to
+// the current generation of the object.
 func Set(to Setter, condition *conditionsapi.Condition) {
 	if to == nil || condition == nil {
 		return
 	}
 
+	if condition.ObservedGeneration == 0 {
+		condition.ObservedGeneration = to.GetGeneration()
+	}
+
 	// Check if the new conditions already exists, and change it only if there is a status
 	// transition (otherwise we should preserve the current last transition time)-
 	conditions := to.GetConditions()
@@ -57,6 +64,7 @@ func Set(to Setter, condition *conditionsapi.Condition) {
 				break
 			}
 			condition.LastTransitionTime = existingCondition.LastTransitionTime
+			conditions[i].ObservedGeneration = condition.ObservedGeneration
 			break
 		}
 	}
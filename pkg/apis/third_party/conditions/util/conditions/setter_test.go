@@ -194,6 +194,37 @@ func TestSetLastTransitionTime(t *testing.T) {
 	}
 }
 
+func TestSetObservedGeneration(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := newConditioned("test")
+	obj.SetGeneration(2)
+
+	// the generation of the object is recorded when the condition is set
+	MarkFalse(obj, "foo", "reason foo", conditionsapi.ConditionSeverityInfo, "message foo")
+	g.Expect(Get(obj, "foo").ObservedGeneration).To(Equal(int64(2)))
+	lastTransitionTime := Get(obj, "foo").LastTransitionTime
+
+	// the generation is updated even if the state of the condition does not change
+	obj.SetGeneration(3)
+	MarkFalse(obj, "foo", "reason foo", conditionsapi.ConditionSeverityInfo, "message foo")
+	g.Expect(Get(obj, "foo").ObservedGeneration).To(Equal(int64(3)))
+	g.Expect(Get(obj, "foo").LastTransitionTime).To(Equal(lastTransitionTime))
+
+	// an explicit observed generation is preserved
+	bar := TrueCondition("bar")
+	bar.ObservedGeneration = 1
+	Set(obj, bar)
+	g.Expect(Get(obj, "bar").ObservedGeneration).To(Equal(int64(1)))
+
+	// mirrored conditions record the generation of the target object
+	source := newConditioned("source")
+	source.SetGeneration(7)
+	MarkTrue(source, conditionsapi.ReadyCondition)
+	SetMirror(obj, "baz", source)
+	g.Expect(Get(obj, "baz").ObservedGeneration).To(Equal(int64(3)))
+}
+
 func TestMarkMethods(t *testing.T) {
 	g := NewWithT(t)
 
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -81,6 +83,81 @@ type SyncTargetSpec struct {
 	// they are in the same physical cluster. Each key/value pair in the cells should be added and updated by service providers
 	// (i.e. a network provider updates one key/value, while the storage provider updates another.)
 	Cells map[string]string `json:"cells,omitempty"`
+
+	// WorkloadRBAC enables the syncing of the ServiceAccounts, Roles and RoleBindings of the namespaces
+	// scheduled to the SyncTarget, so that the synced workloads run with their RBAC downstream, within
+	// the limits of the given policy. By default, they are not synced.
+	//
+	// The syncer must be granted the permissions on these resources downstream, e.g. by re-generating
+	// its manifests once this is set.
+	// +optional
+	WorkloadRBAC *WorkloadRBACPolicy `json:"workloadRBAC,omitempty"`
+
+	// MaintenanceWindows are the planned maintenance periods of the physical cluster. During a maintenance
+	// window, no new workload is scheduled to the SyncTarget, the workloads already scheduled to it stay
+	// scheduled, and missed heartbeats do not make it unhealthy. The SyncTarget returns to service
+	// automatically at the end of the window.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// MaintenanceWindow is a planned maintenance period of the physical cluster of a SyncTarget.
+type MaintenanceWindow struct {
+	// Start is the time the maintenance window starts at.
+	// +required
+	// +kubebuilder:validation:Required
+	Start metav1.Time `json:"start"`
+
+	// Duration is the duration of the maintenance window.
+	// +required
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+
+	// Reason is a human-readable reason of the maintenance.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// ActiveMaintenanceWindow returns the maintenance window of the SyncTarget the given time is in, or nil
+// if the SyncTarget is not in maintenance.
+func (in *SyncTarget) ActiveMaintenanceWindow(now time.Time) *MaintenanceWindow {
+	for i := range in.Spec.MaintenanceWindows {
+		window := &in.Spec.MaintenanceWindows[i]
+		if !now.Before(window.Start.Time) && now.Before(window.Start.Add(window.Duration.Duration)) {
+			return window
+		}
+	}
+	return nil
+}
+
+// NextMaintenanceTransition returns the duration after which the SyncTarget next enters or leaves a
+// maintenance window, or 0 if there is no such transition after the given time.
+func (in *SyncTarget) NextMaintenanceTransition(now time.Time) time.Duration {
+	var next time.Duration
+	for _, window := range in.Spec.MaintenanceWindows {
+		for _, t := range []time.Time{window.Start.Time, window.Start.Add(window.Duration.Duration)} {
+			if d := t.Sub(now); d > 0 && (next == 0 || d < next) {
+				next = d
+			}
+		}
+	}
+	return next
+}
+
+// WorkloadRBACPolicy defines the limits of the RBAC synced downstream for the workloads of a SyncTarget.
+// Only the RoleBindings of ServiceAccounts to Roles are synced, and the subjects other than the ServiceAccounts
+// of the namespace of a RoleBinding are dropped.
+type WorkloadRBACPolicy struct {
+	// AllowedAPIGroups is the list of the API groups the rules of the synced Roles may refer to, "*" allowing
+	// all of them. The rules referring to other API groups are dropped. If empty, only the rules of the core API
+	// group are synced.
+	// +optional
+	AllowedAPIGroups []string `json:"allowedAPIGroups,omitempty"`
+
+	// AllowedVerbs is the list of the verbs the rules of the synced Roles may grant, "*" allowing all of them.
+	// The other verbs are dropped from the rules. If empty, only the get, list and watch verbs are synced.
+	// +optional
+	AllowedVerbs []string `json:"allowedVerbs,omitempty"`
 }
 
 // SyncTargetStatus communicates the observed state of the SyncTarget (from the controller).
@@ -182,8 +259,15 @@ const (
 	// SyncerAuthorized means the syncer is authorized to sync resources to downstream cluster.
 	SyncerAuthorized conditionsv1alpha1.ConditionType = "SyncerAuthorized"
 
+	// InMaintenance means the SyncTarget is in one of its maintenance windows. It does not contribute
+	// to the Ready condition.
+	InMaintenance conditionsv1alpha1.ConditionType = "InMaintenance"
+
 	// ErrorHeartbeatMissedReason indicates that a heartbeat update was not received within the configured threshold.
 	ErrorHeartbeatMissedReason = "ErrorHeartbeat"
+
+	// MaintenanceReason indicates that the SyncTarget is in a maintenance window.
+	MaintenanceReason = "Maintenance"
 )
 
 func (in *SyncTarget) SetConditions(conditions conditionsv1alpha1.Conditions) {
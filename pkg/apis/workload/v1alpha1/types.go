@@ -161,4 +161,29 @@ const (
 	// synced from the APIExport to all the APIBindings bound to this APIExport. The workload scheduler will
 	// check all the APIBindings with this annotation for scheduling purpose.
 	ComputeAPIExportAnnotationKey = "extra.apis.kcp.io/compute.workload.kcp.io"
+
+	// PinnedSyncTargetAnnotationKey is the annotation of a resource pinning it to a single SyncTarget
+	// among those its namespace (or, for cluster-scoped resources, its workspace) is scheduled to. Its
+	// value is the name or the key of the SyncTarget, as generated with the ToSyncTargetKey(..) helper func.
+	//
+	// The resource is not scheduled to any SyncTarget as long as the pinned SyncTarget is not eligible,
+	// so that workloads with data-locality needs are never moved to another SyncTarget.
+	PinnedSyncTargetAnnotationKey = "experimental.workload.kcp.io/pinned-synctarget"
+
+	// PinnedSyncTargetStatusAnnotationKey is the annotation set by the resource scheduler on a resource
+	// with the PinnedSyncTargetAnnotationKey annotation, reporting whether the pinning is honored.
+	// Its value is one of PinnedSyncTargetStatus.
+	PinnedSyncTargetStatusAnnotationKey = "experimental.workload.kcp.io/pinned-synctarget-status"
+)
+
+// PinnedSyncTargetStatus is the status of the pinning of a resource to a SyncTarget.
+type PinnedSyncTargetStatus string
+
+const (
+	// PinnedSyncTargetScheduled means that the resource is scheduled to the pinned SyncTarget only.
+	PinnedSyncTargetScheduled PinnedSyncTargetStatus = "Scheduled"
+
+	// PinnedSyncTargetNotEligible means that the pinned SyncTarget is not among those the resource
+	// can be scheduled to, so that it is not scheduled to any SyncTarget.
+	PinnedSyncTargetNotEligible PinnedSyncTargetStatus = "NotEligible"
 )
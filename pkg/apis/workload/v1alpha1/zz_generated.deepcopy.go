@@ -30,6 +30,24 @@ import (
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceToSync) DeepCopyInto(out *ResourceToSync) {
 	*out = *in
@@ -132,6 +150,18 @@ func (in *SyncTargetSpec) DeepCopyInto(out *SyncTargetSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.WorkloadRBAC != nil {
+		in, out := &in.WorkloadRBAC, &out.WorkloadRBAC
+		*out = new(WorkloadRBACPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -221,3 +251,29 @@ func (in *VirtualWorkspace) DeepCopy() *VirtualWorkspace {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRBACPolicy) DeepCopyInto(out *WorkloadRBACPolicy) {
+	*out = *in
+	if in.AllowedAPIGroups != nil {
+		in, out := &in.AllowedAPIGroups, &out.AllowedAPIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedVerbs != nil {
+		in, out := &in.AllowedVerbs, &out.AllowedVerbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRBACPolicy.
+func (in *WorkloadRBACPolicy) DeepCopy() *WorkloadRBACPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRBACPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/admin/plugin"
+)
+
+var (
+	shardsExample = `
+	# List the shards, whether new workspaces can be scheduled onto them, and how many workspaces they host.
+	%[1]s admin shards list
+
+	# Stop scheduling new workspaces onto the shard "alpha".
+	%[1]s admin shards cordon alpha
+
+	# Allow new workspaces to be scheduled onto the shard "alpha" again.
+	%[1]s admin shards uncordon alpha
+
+	# List the workspaces scheduled onto the shard "alpha".
+	%[1]s admin shards workspaces alpha
+`
+
+	migrateExample = `
+	# Run the preflight checks of the migration of the workspace root:org:team-a to the shard "beta".
+	%[1]s admin migrate workspace root:org:team-a --to-shard beta
+
+	# Also check that the shard "beta" hosts at most 500 workspaces after the migration.
+	%[1]s admin migrate workspace root:org:team-a --to-shard beta --max-workspaces 500
+`
+)
+
+// New provides a cobra command for kcp operators.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	cmd := &cobra.Command{
+		Use:              "admin",
+		Short:            "Operations for kcp operators",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	shardsCmd := &cobra.Command{
+		Use:              "shards",
+		Short:            "Manage the shards of the kcp installation",
+		Example:          fmt.Sprintf(shardsExample, cliName),
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(shardsCmd)
+
+	listOpts := plugin.NewListShardsOptions(streams)
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List the shards with their scheduling status and number of workspaces",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := listOpts.Complete(); err != nil {
+				return err
+			}
+			if err := listOpts.Validate(); err != nil {
+				return err
+			}
+			return listOpts.Run(c.Context())
+		},
+	}
+	listOpts.BindFlags(listCmd)
+	shardsCmd.AddCommand(listCmd)
+
+	shardsCmd.AddCommand(newCordonCommand(streams, "cordon", "Stop scheduling new workspaces onto a shard", true))
+	shardsCmd.AddCommand(newCordonCommand(streams, "uncordon", "Allow new workspaces to be scheduled onto a shard", false))
+
+	workspacesOpts := plugin.NewShardWorkspacesOptions(streams)
+	workspacesCmd := &cobra.Command{
+		Use:          "workspaces <shard>",
+		Short:        "List the workspaces scheduled onto a shard",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := workspacesOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := workspacesOpts.Validate(); err != nil {
+				return err
+			}
+			return workspacesOpts.Run(c.Context())
+		},
+	}
+	workspacesOpts.BindFlags(workspacesCmd)
+	shardsCmd.AddCommand(workspacesCmd)
+
+	migrateCmd := &cobra.Command{
+		Use:              "migrate",
+		Short:            "Migrate workspaces across shards",
+		Example:          fmt.Sprintf(migrateExample, cliName),
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(migrateCmd)
+
+	migrateWorkspaceOpts := plugin.NewMigrateWorkspaceOptions(streams)
+	migrateWorkspaceCmd := &cobra.Command{
+		Use:          "workspace <path> --to-shard <shard>",
+		Short:        "Check whether a workspace can be migrated to another shard",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := migrateWorkspaceOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := migrateWorkspaceOpts.Validate(); err != nil {
+				return err
+			}
+			return migrateWorkspaceOpts.Run(c.Context())
+		},
+	}
+	migrateWorkspaceOpts.BindFlags(migrateWorkspaceCmd)
+	migrateCmd.AddCommand(migrateWorkspaceCmd)
+
+	return cmd
+}
+
+func newCordonCommand(streams genericclioptions.IOStreams, verb, short string, cordon bool) *cobra.Command {
+	opts := plugin.NewCordonShardOptions(streams, cordon)
+	cmd := &cobra.Command{
+		Use:          verb + " <shard>",
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := opts.Complete(args); err != nil {
+				return err
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return opts.Run(c.Context())
+		},
+	}
+	opts.BindFlags(cmd)
+	return cmd
+}
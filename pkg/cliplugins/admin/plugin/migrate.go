@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// errMigrationNotSupported is returned once the preflight checks pass, as the workspace data
+// cannot be moved across shards yet.
+var errMigrationNotSupported = errors.New("preflight checks passed, but moving the workspace data across shards is not supported yet")
+
+// MigrateWorkspaceOptions contains the options for migrating a workspace to another shard.
+type MigrateWorkspaceOptions struct {
+	*base.Options
+
+	// Path is the absolute path of the workspace to migrate.
+	Path string
+	// ToShard is the name of the shard the workspace is migrated to.
+	ToShard string
+	// MaxWorkspaces is the maximum number of workspaces the target shard may host after the migration.
+	// Zero means no limit.
+	MaxWorkspaces int
+
+	// for testing
+	client *shardClient
+}
+
+// NewMigrateWorkspaceOptions returns a new MigrateWorkspaceOptions.
+func NewMigrateWorkspaceOptions(streams genericclioptions.IOStreams) *MigrateWorkspaceOptions {
+	return &MigrateWorkspaceOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *MigrateWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.ToShard, "to-shard", o.ToShard, "Name of the shard to migrate the workspace to")
+	cmd.Flags().IntVar(&o.MaxWorkspaces, "max-workspaces", o.MaxWorkspaces, "Maximum number of workspaces the target shard may host after the migration, 0 for no limit")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *MigrateWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		o.Path = args[0]
+	}
+	if o.client == nil {
+		client, err := newShardClient(o.Options)
+		if err != nil {
+			return err
+		}
+		o.client = client
+	}
+	return nil
+}
+
+// Validate validates the MigrateWorkspaceOptions are complete and usable.
+func (o *MigrateWorkspaceOptions) Validate() error {
+	if path := logicalcluster.NewPath(o.Path); !path.IsValid() || !path.HasPrefix(core.RootCluster.Path()) || path == core.RootCluster.Path() {
+		return fmt.Errorf("an absolute workspace path under root is required, got %q", o.Path)
+	}
+	if o.ToShard == "" {
+		return errors.New("--to-shard is required")
+	}
+	if o.MaxWorkspaces < 0 {
+		return errors.New("--max-workspaces must not be negative")
+	}
+	return o.Options.Validate()
+}
+
+// preflightCheck is the result of a check run before migrating a workspace.
+type preflightCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Run runs the preflight checks of the migration of the workspace to the target shard.
+func (o *MigrateWorkspaceOptions) Run(ctx context.Context) error {
+	checks, err := o.preflightChecks(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := printers.GetNewTabWriter(o.Out)
+	if _, err := fmt.Fprintf(out, "CHECK\tRESULT\tMESSAGE\n"); err != nil {
+		return err
+	}
+	failed := 0
+	for _, check := range checks {
+		result := "Passed"
+		if !check.Passed {
+			result = "Failed"
+			failed++
+		}
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\n", check.Name, result, check.Message); err != nil {
+			return err
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d preflight checks failed, workspace %s is not migrated", failed, o.Path)
+	}
+	return errMigrationNotSupported
+}
+
+func (o *MigrateWorkspaceOptions) preflightChecks(ctx context.Context) ([]preflightCheck, error) {
+	shards, err := o.client.listShards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing shards: %w", err)
+	}
+	shardsByHash := map[string]*corev1alpha1.Shard{}
+	var target *corev1alpha1.Shard
+	for i := range shards {
+		shardsByHash[shardNameHash(shards[i].Name)] = &shards[i]
+		if shards[i].Name == o.ToShard {
+			target = &shards[i]
+		}
+	}
+
+	var checks []preflightCheck
+
+	ws, err := o.client.getWorkspace(ctx, logicalcluster.NewPath(o.Path))
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspace %s: %w", o.Path, err)
+	}
+	source := shardsByHash[ws.Annotations[workspaceShardAnnotationKey]]
+	switch {
+	case ws.Status.Phase != corev1alpha1.LogicalClusterPhaseReady:
+		checks = append(checks, preflightCheck{Name: "workspace", Message: fmt.Sprintf("workspace is %s, not Ready", ws.Status.Phase)})
+	case source == nil:
+		checks = append(checks, preflightCheck{Name: "workspace", Message: "workspace is not scheduled onto a known shard"})
+	default:
+		checks = append(checks, preflightCheck{Name: "workspace", Passed: true, Message: fmt.Sprintf("workspace is Ready on shard %s", source.Name)})
+	}
+
+	switch {
+	case target == nil:
+		checks = append(checks, preflightCheck{Name: "target-shard", Message: fmt.Sprintf("shard %s does not exist", o.ToShard)})
+		return checks, nil
+	case source != nil && source.Name == target.Name:
+		checks = append(checks, preflightCheck{Name: "target-shard", Message: fmt.Sprintf("workspace is already on shard %s", target.Name)})
+	case shardStatus(target) == "Cordoned":
+		checks = append(checks, preflightCheck{Name: "target-shard", Message: fmt.Sprintf("shard %s is cordoned", target.Name)})
+	default:
+		checks = append(checks, preflightCheck{Name: "target-shard", Passed: true, Message: fmt.Sprintf("shard %s is schedulable", target.Name)})
+	}
+
+	if source != nil {
+		checks = append(checks, o.versionSkewCheck(ctx, source, target))
+	}
+
+	workspaces, err := o.client.listWorkspaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workspaces: %w", err)
+	}
+	targetHash := shardNameHash(target.Name)
+	count := 0
+	for _, ws := range workspaces {
+		if ws.Annotations[workspaceShardAnnotationKey] == targetHash {
+			count++
+		}
+	}
+	if o.MaxWorkspaces > 0 && count+1 > o.MaxWorkspaces {
+		checks = append(checks, preflightCheck{Name: "capacity", Message: fmt.Sprintf("shard %s hosts %d workspaces, the limit is %d", target.Name, count, o.MaxWorkspaces)})
+	} else {
+		checks = append(checks, preflightCheck{Name: "capacity", Passed: true, Message: fmt.Sprintf("shard %s hosts %d workspaces", target.Name, count)})
+	}
+
+	return checks, nil
+}
+
+func (o *MigrateWorkspaceOptions) versionSkewCheck(ctx context.Context, source, target *corev1alpha1.Shard) preflightCheck {
+	sourceVersion, err := o.client.serverVersion(ctx, source)
+	if err != nil {
+		return preflightCheck{Name: "version-skew", Message: fmt.Sprintf("failed to get the version of shard %s: %v", source.Name, err)}
+	}
+	targetVersion, err := o.client.serverVersion(ctx, target)
+	if err != nil {
+		return preflightCheck{Name: "version-skew", Message: fmt.Sprintf("failed to get the version of shard %s: %v", target.Name, err)}
+	}
+	if sourceVersion != targetVersion {
+		return preflightCheck{Name: "version-skew", Message: fmt.Sprintf("shard %s runs %s, shard %s runs %s", source.Name, sourceVersion, target.Name, targetVersion)}
+	}
+	return preflightCheck{Name: "version-skew", Passed: true, Message: fmt.Sprintf("both shards run %s", sourceVersion)}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func newMigrateTestShardClient(t *testing.T) *shardClient {
+	versions := map[string]string{"root": "v0.10.0", "alpha": "v0.11.0", "beta": "v0.11.0"}
+	return &shardClient{
+		listShards: func(ctx context.Context) ([]corev1alpha1.Shard, error) {
+			return []corev1alpha1.Shard{newTestShard("root", false), newTestShard("alpha", true), newTestShard("beta", false)}, nil
+		},
+		listWorkspaces: func(ctx context.Context) ([]tenancyv1beta1.Workspace, error) {
+			return []tenancyv1beta1.Workspace{
+				newTestWorkspace("root", "org", "root"),
+				newTestWorkspace("root:org", "team-a", "alpha"),
+				newTestWorkspace("root:org", "team-b", "alpha"),
+				newTestWorkspace("root:org", "ops", "beta"),
+			}, nil
+		},
+		getWorkspace: func(ctx context.Context, path logicalcluster.Path) (*tenancyv1beta1.Workspace, error) {
+			require.Equal(t, "root:org:team-a", path.String())
+			ws := newTestWorkspace("root:org", "team-a", "alpha")
+			return &ws, nil
+		},
+		serverVersion: func(ctx context.Context, shard *corev1alpha1.Shard) (string, error) {
+			return versions[shard.Name], nil
+		},
+	}
+}
+
+func TestMigrateWorkspace(t *testing.T) {
+	tests := []struct {
+		name          string
+		toShard       string
+		maxWorkspaces int
+		wantOut       string
+		wantErr       string
+	}{
+		{
+			name:    "preflight checks pass",
+			toShard: "beta",
+			wantOut: "" +
+				"CHECK          RESULT   MESSAGE\n" +
+				"workspace      Passed   workspace is Ready on shard alpha\n" +
+				"target-shard   Passed   shard beta is schedulable\n" +
+				"version-skew   Passed   both shards run v0.11.0\n" +
+				"capacity       Passed   shard beta hosts 1 workspaces\n",
+			wantErr: errMigrationNotSupported.Error(),
+		},
+		{
+			name:          "version skew and capacity",
+			toShard:       "root",
+			maxWorkspaces: 1,
+			wantOut: "" +
+				"CHECK          RESULT   MESSAGE\n" +
+				"workspace      Passed   workspace is Ready on shard alpha\n" +
+				"target-shard   Passed   shard root is schedulable\n" +
+				"version-skew   Failed   shard alpha runs v0.11.0, shard root runs v0.10.0\n" +
+				"capacity       Failed   shard root hosts 1 workspaces, the limit is 1\n",
+			wantErr: "2 preflight checks failed, workspace root:org:team-a is not migrated",
+		},
+		{
+			name:    "unknown shard",
+			toShard: "gamma",
+			wantOut: "" +
+				"CHECK          RESULT   MESSAGE\n" +
+				"workspace      Passed   workspace is Ready on shard alpha\n" +
+				"target-shard   Failed   shard gamma does not exist\n",
+			wantErr: "1 preflight checks failed, workspace root:org:team-a is not migrated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewMigrateWorkspaceOptions(streams)
+			opts.Path = "root:org:team-a"
+			opts.ToShard = tt.toShard
+			opts.MaxWorkspaces = tt.maxWorkspaces
+			opts.client = newMigrateTestShardClient(t)
+
+			require.NoError(t, opts.Validate())
+			require.EqualError(t, opts.Run(context.Background()), tt.wantErr)
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
+
+func TestMigrateWorkspaceValidate(t *testing.T) {
+	opts := NewMigrateWorkspaceOptions(genericclioptions.NewTestIOStreamsDiscard())
+	opts.ToShard = "beta"
+	for _, path := range []string{"", "root", "team-a", "system:admin"} {
+		opts.Path = path
+		require.Error(t, opts.Validate(), path)
+	}
+	opts.Path = "root:org"
+	require.NoError(t, opts.Validate())
+}
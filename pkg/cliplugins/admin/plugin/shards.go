@@ -0,0 +1,381 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/martinlindhe/base36"
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// workspaceShardAnnotationKey is set by the workspace scheduler to the hash of the name of the
+// shard the workspace is scheduled onto.
+const workspaceShardAnnotationKey = "internal.tenancy.kcp.io/shard"
+
+// shardClient gives access to the shards and the workspaces scheduled onto them.
+type shardClient struct {
+	listShards     func(ctx context.Context) ([]corev1alpha1.Shard, error)
+	listWorkspaces func(ctx context.Context) ([]tenancyv1beta1.Workspace, error)
+	patchShard     func(ctx context.Context, name string, patch []byte) error
+	getWorkspace   func(ctx context.Context, path logicalcluster.Path) (*tenancyv1beta1.Workspace, error)
+	serverVersion  func(ctx context.Context, shard *corev1alpha1.Shard) (string, error)
+}
+
+func newShardClient(o *base.Options) (*shardClient, error) {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	kcpClusterClient, err := newKCPClusterClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating kcp client: %w", err)
+	}
+
+	return &shardClient{
+		listShards: func(ctx context.Context) ([]corev1alpha1.Shard, error) {
+			shards, err := kcpClusterClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return shards.Items, nil
+		},
+		listWorkspaces: func(ctx context.Context) ([]tenancyv1beta1.Workspace, error) {
+			workspaces, err := kcpClusterClient.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return workspaces.Items, nil
+		},
+		patchShard: func(ctx context.Context, name string, patch []byte) error {
+			_, err := kcpClusterClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+			return err
+		},
+		getWorkspace: func(ctx context.Context, path logicalcluster.Path) (*tenancyv1beta1.Workspace, error) {
+			parent, name := path.Split()
+			return kcpClusterClient.Cluster(parent).TenancyV1beta1().Workspaces().Get(ctx, name, metav1.GetOptions{})
+		},
+		serverVersion: func(ctx context.Context, shard *corev1alpha1.Shard) (string, error) {
+			shardConfig := rest.CopyConfig(config)
+			shardConfig.Host = shard.Spec.BaseURL
+			discoveryClient, err := discovery.NewDiscoveryClientForConfig(shardConfig)
+			if err != nil {
+				return "", err
+			}
+			info, err := discoveryClient.ServerVersion()
+			if err != nil {
+				return "", err
+			}
+			return info.GitVersion, nil
+		},
+	}, nil
+}
+
+// ListShardsOptions contains the options for listing the shards.
+type ListShardsOptions struct {
+	*base.Options
+
+	// for testing
+	client *shardClient
+	now    func() time.Time
+}
+
+// NewListShardsOptions returns a new ListShardsOptions.
+func NewListShardsOptions(streams genericclioptions.IOStreams) *ListShardsOptions {
+	return &ListShardsOptions{
+		Options: base.NewOptions(streams),
+		now:     time.Now,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ListShardsOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ListShardsOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+	if o.client == nil {
+		client, err := newShardClient(o.Options)
+		if err != nil {
+			return err
+		}
+		o.client = client
+	}
+	return nil
+}
+
+// Validate validates the ListShardsOptions are complete and usable.
+func (o *ListShardsOptions) Validate() error {
+	return o.Options.Validate()
+}
+
+// Run lists the shards, whether they accept new workspaces, and how many workspaces they host.
+func (o *ListShardsOptions) Run(ctx context.Context) error {
+	shards, err := o.client.listShards(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing shards: %w", err)
+	}
+	workspaces, err := o.client.listWorkspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing workspaces: %w", err)
+	}
+
+	counts := map[string]int{}
+	for _, ws := range workspaces {
+		if hash, found := ws.Annotations[workspaceShardAnnotationKey]; found {
+			counts[hash]++
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool { return shards[i].Name < shards[j].Name })
+
+	out := printers.GetNewTabWriter(o.Out)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "NAME\tSTATUS\tWORKSPACES\tURL\tAGE\n"); err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		age := duration.HumanDuration(o.now().Sub(shard.CreationTimestamp.Time))
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%d\t%s\t%s\n", shard.Name, shardStatus(&shard), counts[shardNameHash(shard.Name)], shard.Spec.ExternalURL, age); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CordonShardOptions contains the options for cordoning or uncordoning a shard.
+type CordonShardOptions struct {
+	*base.Options
+
+	// Name is the name of the shard.
+	Name string
+	// Cordon prevents new workspaces from being scheduled onto the shard if true,
+	// and allows it again if false.
+	Cordon bool
+
+	// for testing
+	client *shardClient
+}
+
+// NewCordonShardOptions returns a new CordonShardOptions.
+func NewCordonShardOptions(streams genericclioptions.IOStreams, cordon bool) *CordonShardOptions {
+	return &CordonShardOptions{
+		Options: base.NewOptions(streams),
+		Cordon:  cordon,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *CordonShardOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *CordonShardOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+	if o.client == nil {
+		client, err := newShardClient(o.Options)
+		if err != nil {
+			return err
+		}
+		o.client = client
+	}
+	return nil
+}
+
+// Validate validates the CordonShardOptions are complete and usable.
+func (o *CordonShardOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("a shard name is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run sets or removes the unschedulable annotation of the shard.
+func (o *CordonShardOptions) Run(ctx context.Context) error {
+	var value interface{}
+	if o.Cordon {
+		value = "true"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				corev1alpha1.ShardUnschedulableAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := o.client.patchShard(ctx, o.Name, patch); err != nil {
+		return fmt.Errorf("error patching shard %q: %w", o.Name, err)
+	}
+
+	verb := "uncordoned"
+	if o.Cordon {
+		verb = "cordoned"
+	}
+	_, err = fmt.Fprintf(o.Out, "shard %s %s\n", o.Name, verb)
+	return err
+}
+
+// ShardWorkspacesOptions contains the options for listing the workspaces scheduled onto a shard.
+type ShardWorkspacesOptions struct {
+	*base.Options
+
+	// Name is the name of the shard.
+	Name string
+
+	// for testing
+	client *shardClient
+}
+
+// NewShardWorkspacesOptions returns a new ShardWorkspacesOptions.
+func NewShardWorkspacesOptions(streams genericclioptions.IOStreams) *ShardWorkspacesOptions {
+	return &ShardWorkspacesOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ShardWorkspacesOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ShardWorkspacesOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+	if o.client == nil {
+		client, err := newShardClient(o.Options)
+		if err != nil {
+			return err
+		}
+		o.client = client
+	}
+	return nil
+}
+
+// Validate validates the ShardWorkspacesOptions are complete and usable.
+func (o *ShardWorkspacesOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("a shard name is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run lists the workspaces scheduled onto the shard.
+func (o *ShardWorkspacesOptions) Run(ctx context.Context) error {
+	workspaces, err := o.client.listWorkspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing workspaces: %w", err)
+	}
+	return printShardWorkspaces(o.Out, workspaces, o.Name)
+}
+
+func printShardWorkspaces(w io.Writer, workspaces []tenancyv1beta1.Workspace, shard string) error {
+	hash := shardNameHash(shard)
+	var paths []string
+	rows := map[string]string{}
+	for _, ws := range workspaces {
+		if ws.Annotations[workspaceShardAnnotationKey] != hash {
+			continue
+		}
+		path := logicalcluster.From(&ws).Path().Join(ws.Name).String()
+		paths = append(paths, path)
+		rows[path] = fmt.Sprintf("%s\t%s\t%s", path, ws.Status.Cluster, ws.Status.Phase)
+	}
+	sort.Strings(paths)
+
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "WORKSPACE\tCLUSTER\tPHASE\n"); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := fmt.Fprintln(out, rows[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shardStatus(shard *corev1alpha1.Shard) string {
+	if shard.Annotations[corev1alpha1.ShardUnschedulableAnnotationKey] == "true" {
+		return "Cordoned"
+	}
+	return "Schedulable"
+}
+
+// shardNameHash returns the hash of the shard name, as set by the workspace scheduler
+// on the workspaces it schedules onto the shard.
+func shardNameHash(name string) string {
+	hash := sha256.Sum224([]byte(name))
+	base36hash := strings.ToLower(base36.EncodeBytes(hash[:]))
+	return base36hash[:8]
+}
+
+func newKCPClusterClient(config *rest.Config) (kcpclientset.ClusterInterface, error) {
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return kcpclientset.NewForConfig(clusterConfig)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+var now = time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestShard(name string, cordoned bool) corev1alpha1.Shard {
+	shard := corev1alpha1.Shard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+		},
+		Spec: corev1alpha1.ShardSpec{ExternalURL: "https://" + name},
+	}
+	if cordoned {
+		shard.Annotations = map[string]string{corev1alpha1.ShardUnschedulableAnnotationKey: "true"}
+	}
+	return shard
+}
+
+func newTestWorkspace(parent, name, shard string) tenancyv1beta1.Workspace {
+	return tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: parent,
+				workspaceShardAnnotationKey:  shardNameHash(shard),
+			},
+		},
+		Status: tenancyv1beta1.WorkspaceStatus{Cluster: name + "-cluster", Phase: corev1alpha1.LogicalClusterPhaseReady},
+	}
+}
+
+func newTestShardClient() *shardClient {
+	return &shardClient{
+		listShards: func(ctx context.Context) ([]corev1alpha1.Shard, error) {
+			return []corev1alpha1.Shard{newTestShard("root", false), newTestShard("alpha", true)}, nil
+		},
+		listWorkspaces: func(ctx context.Context) ([]tenancyv1beta1.Workspace, error) {
+			return []tenancyv1beta1.Workspace{
+				newTestWorkspace("root", "org", "root"),
+				newTestWorkspace("root:org", "team-b", "alpha"),
+				newTestWorkspace("root:org", "team-a", "alpha"),
+			}, nil
+		},
+	}
+}
+
+func TestListShards(t *testing.T) {
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	opts := NewListShardsOptions(streams)
+	opts.client = newTestShardClient()
+	opts.now = func() time.Time { return now }
+
+	require.NoError(t, opts.Run(context.Background()))
+	require.Equal(t, ""+
+		"NAME    STATUS        WORKSPACES   URL             AGE\n"+
+		"alpha   Cordoned      2            https://alpha   60m\n"+
+		"root    Schedulable   1            https://root    60m\n", out.String())
+}
+
+func TestCordonShard(t *testing.T) {
+	for _, cordon := range []bool{true, false} {
+		streams, _, _, _ := genericclioptions.NewTestIOStreams()
+		opts := NewCordonShardOptions(streams, cordon)
+		opts.Name = "alpha"
+		var patched string
+		opts.client = &shardClient{
+			patchShard: func(ctx context.Context, name string, patch []byte) error {
+				require.Equal(t, "alpha", name)
+				patched = string(patch)
+				return nil
+			},
+		}
+
+		require.NoError(t, opts.Run(context.Background()))
+		if cordon {
+			require.Equal(t, `{"metadata":{"annotations":{"experimental.core.kcp.io/unschedulable":"true"}}}`, patched)
+		} else {
+			require.Equal(t, `{"metadata":{"annotations":{"experimental.core.kcp.io/unschedulable":null}}}`, patched)
+		}
+	}
+}
+
+func TestShardWorkspaces(t *testing.T) {
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	opts := NewShardWorkspacesOptions(streams)
+	opts.Name = "alpha"
+	opts.client = newTestShardClient()
+
+	require.NoError(t, opts.Run(context.Background()))
+	require.Equal(t, ""+
+		"WORKSPACE         CLUSTER          PHASE\n"+
+		"root:org:team-a   team-a-cluster   Ready\n"+
+		"root:org:team-b   team-b-cluster   Ready\n", out.String())
+}
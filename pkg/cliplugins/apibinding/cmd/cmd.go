@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/apibinding/plugin"
+)
+
+var (
+	upgradeExample = `
+	# Show the differences between the schemas bound by the APIBinding "widgets" and the latest schemas of its APIExport.
+	%[1]s apibinding upgrade widgets --dry-run
+
+	# Wait for the APIBinding "widgets" to be bound to the latest schemas of its APIExport.
+	%[1]s apibinding upgrade widgets --timeout=1m
+`
+)
+
+// New provides a cobra command for APIBinding operations.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	cmd := &cobra.Command{
+		Use:              "apibinding",
+		Short:            "Manage APIBindings",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	upgradeOpts := plugin.NewUpgradeAPIBindingOptions(streams)
+	upgradeCmd := &cobra.Command{
+		Use:          "upgrade <apibinding-name>",
+		Short:        "Show the differences with the latest schemas of the APIExport and upgrade the APIBinding to them",
+		Example:      fmt.Sprintf(upgradeExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := upgradeOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := upgradeOpts.Validate(); err != nil {
+				return err
+			}
+			return upgradeOpts.Run(c.Context())
+		},
+	}
+	upgradeOpts.BindFlags(upgradeCmd)
+	cmd.AddCommand(upgradeCmd)
+
+	return cmd
+}
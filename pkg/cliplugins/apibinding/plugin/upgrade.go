@@ -0,0 +1,341 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// UpgradeAPIBindingOptions contains the options for upgrading an APIBinding to the latest
+// APIResourceSchemas of its APIExport.
+type UpgradeAPIBindingOptions struct {
+	*base.Options
+
+	// Name is the name of the APIBinding.
+	Name string
+	// DryRun only prints the differences between the bound and the latest schemas.
+	DryRun bool
+	// WaitTimeout is how long to wait for the APIBinding to be bound to the latest schemas.
+	WaitTimeout time.Duration
+
+	// for testing
+	getAPIBinding func(ctx context.Context, name string) (*apisv1alpha1.APIBinding, error)
+	getAPIExport  func(ctx context.Context, path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error)
+	getSchema     func(ctx context.Context, cluster logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
+}
+
+// NewUpgradeAPIBindingOptions returns a new UpgradeAPIBindingOptions.
+func NewUpgradeAPIBindingOptions(streams genericclioptions.IOStreams) *UpgradeAPIBindingOptions {
+	return &UpgradeAPIBindingOptions{
+		Options:     base.NewOptions(streams),
+		WaitTimeout: 30 * time.Second,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *UpgradeAPIBindingOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", o.DryRun, "Only print the differences between the bound and the latest schemas of the APIExport")
+	cmd.Flags().DurationVar(&o.WaitTimeout, "timeout", o.WaitTimeout, "Duration to wait for the APIBinding to be bound to the latest schemas")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *UpgradeAPIBindingOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	kcpClusterClient, err := newKCPClusterClient(config)
+	if err != nil {
+		return err
+	}
+
+	if o.getAPIBinding == nil {
+		o.getAPIBinding = func(ctx context.Context, name string) (*apisv1alpha1.APIBinding, error) {
+			return kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings().Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	if o.getAPIExport == nil {
+		o.getAPIExport = func(ctx context.Context, path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
+			if path.Empty() {
+				path = currentClusterName
+			}
+			return kcpClusterClient.Cluster(path).ApisV1alpha1().APIExports().Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	if o.getSchema == nil {
+		o.getSchema = func(ctx context.Context, cluster logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+			return kcpClusterClient.Cluster(cluster.Path()).ApisV1alpha1().APIResourceSchemas().Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the UpgradeAPIBindingOptions are complete and usable.
+func (o *UpgradeAPIBindingOptions) Validate() error {
+	if o.Name == "" {
+		return errors.New("an APIBinding name is required")
+	}
+	return o.Options.Validate()
+}
+
+// schemaChange is a difference between the schema bound for a resource and the latest schema of the APIExport.
+type schemaChange struct {
+	Resource schema.GroupResource
+	// From is the bound schema, nil if the resource is not bound yet.
+	From *apisv1alpha1.APIResourceSchema
+	// To is the latest schema, nil if the resource is not exported anymore.
+	To *apisv1alpha1.APIResourceSchema
+}
+
+// Run prints the differences between the bound and the latest schemas, and waits for the APIBinding to be
+// bound to the latest schemas unless in dry-run mode.
+func (o *UpgradeAPIBindingOptions) Run(ctx context.Context) error {
+	binding, err := o.getAPIBinding(ctx, o.Name)
+	if err != nil {
+		return err
+	}
+	if binding.Spec.Reference.Export == nil {
+		return fmt.Errorf("APIBinding %q does not reference an APIExport", o.Name)
+	}
+	exportPath := logicalcluster.NewPath(binding.Spec.Reference.Export.Path)
+	export, err := o.getAPIExport(ctx, exportPath, binding.Spec.Reference.Export.Name)
+	if err != nil {
+		return err
+	}
+	exportRef := exportPath.Join(export.Name).String()
+
+	changes, err := o.schemaChanges(ctx, binding, export)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		_, err := fmt.Fprintf(o.Out, "APIBinding %q is up to date with APIExport %q.\n", binding.Name, exportRef)
+		return err
+	}
+	if err := printSchemaChanges(o.Out, changes); err != nil {
+		return err
+	}
+	if o.DryRun {
+		return nil
+	}
+
+	// bindings are upgraded by the APIBinding controller as soon as the APIExport changes, unless the
+	// new schemas conflict with other APIs of the workspace.
+	var message string
+	if err := wait.PollImmediate(time.Millisecond*500, o.WaitTimeout, func() (bool, error) {
+		binding, err := o.getAPIBinding(ctx, o.Name)
+		if err != nil {
+			return false, err
+		}
+		if conditions.IsFalse(binding, apisv1alpha1.BindingUpToDate) {
+			message = conditions.GetMessage(binding, apisv1alpha1.BindingUpToDate)
+			return false, nil
+		}
+		changes, err := o.schemaChanges(ctx, binding, export)
+		if err != nil {
+			return false, err
+		}
+		message = fmt.Sprintf("%d resources are not bound to the latest schemas yet", len(changes))
+		return len(changes) == 0, nil
+	}); err != nil && err.Error() == wait.ErrWaitTimeout.Error() {
+		return fmt.Errorf("APIBinding %q not upgraded: %s", binding.Name, message)
+	} else if err != nil {
+		return fmt.Errorf("APIBinding %q not upgraded: %w", binding.Name, err)
+	}
+
+	_, err = fmt.Fprintf(o.Out, "APIBinding %q is bound to the latest schemas of APIExport %q.\n", binding.Name, exportRef)
+	return err
+}
+
+func (o *UpgradeAPIBindingOptions) schemaChanges(ctx context.Context, binding *apisv1alpha1.APIBinding, export *apisv1alpha1.APIExport) ([]schemaChange, error) {
+	exportCluster := logicalcluster.From(export)
+
+	bound := map[schema.GroupResource]string{}
+	for _, r := range binding.Status.BoundResources {
+		bound[schema.GroupResource{Group: r.Group, Resource: r.Resource}] = r.Schema.Name
+	}
+
+	var changes []schemaChange
+	exported := map[schema.GroupResource]bool{}
+	for _, name := range export.Spec.LatestResourceSchemas {
+		latest, err := o.getSchema(ctx, exportCluster, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get APIResourceSchema %q of the APIExport: %w", name, err)
+		}
+		gr := schema.GroupResource{Group: latest.Spec.Group, Resource: latest.Spec.Names.Plural}
+		exported[gr] = true
+
+		boundName, found := bound[gr]
+		if found && boundName == name {
+			continue
+		}
+		change := schemaChange{Resource: gr, To: latest}
+		if found {
+			// the bound schema may have been deleted by the APIExport owner, the diff is then limited to its name.
+			current, err := o.getSchema(ctx, exportCluster, boundName)
+			if apierrors.IsNotFound(err) {
+				current = &apisv1alpha1.APIResourceSchema{ObjectMeta: metav1.ObjectMeta{Name: boundName}}
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get bound APIResourceSchema %q: %w", boundName, err)
+			}
+			change.From = current
+		}
+		changes = append(changes, change)
+	}
+	for gr, name := range bound {
+		if !exported[gr] {
+			changes = append(changes, schemaChange{Resource: gr, From: &apisv1alpha1.APIResourceSchema{ObjectMeta: metav1.ObjectMeta{Name: name}}})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Resource.String() < changes[j].Resource.String()
+	})
+	return changes, nil
+}
+
+func printSchemaChanges(w io.Writer, changes []schemaChange) error {
+	var buf bytes.Buffer
+	for _, change := range changes {
+		switch {
+		case change.From == nil:
+			fmt.Fprintf(&buf, "%s: not bound -> %s\n", change.Resource, change.To.Name)
+		case change.To == nil:
+			fmt.Fprintf(&buf, "%s: %s -> not exported anymore, the bound resource is kept\n", change.Resource, change.From.Name)
+			continue
+		default:
+			fmt.Fprintf(&buf, "%s: %s -> %s\n", change.Resource, change.From.Name, change.To.Name)
+		}
+		for _, line := range diffSchemaVersions(change.From, change.To) {
+			fmt.Fprintf(&buf, "  %s\n", line)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// diffSchemaVersions returns a human readable summary of the differences between the versions of two schemas.
+func diffSchemaVersions(from, to *apisv1alpha1.APIResourceSchema) []string {
+	var lines []string
+	if from != nil && from.Spec.Scope != "" && from.Spec.Scope != to.Spec.Scope {
+		lines = append(lines, fmt.Sprintf("~ scope: %s -> %s", from.Spec.Scope, to.Spec.Scope))
+	}
+
+	fromVersions := map[string]apisv1alpha1.APIResourceVersion{}
+	if from != nil {
+		for _, v := range from.Spec.Versions {
+			fromVersions[v.Name] = v
+		}
+	}
+	toVersions := map[string]bool{}
+	for _, v := range to.Spec.Versions {
+		toVersions[v.Name] = true
+		old, found := fromVersions[v.Name]
+		if !found {
+			lines = append(lines, fmt.Sprintf("+ version %s%s", v.Name, versionFlags(v)))
+			continue
+		}
+		if old.Served != v.Served || old.Storage != v.Storage || old.Deprecated != v.Deprecated {
+			lines = append(lines, fmt.Sprintf("~ version %s:%s ->%s", v.Name, versionFlags(old), versionFlags(v)))
+		}
+		if !bytes.Equal(old.Schema.Raw, v.Schema.Raw) {
+			lines = append(lines, fmt.Sprintf("~ version %s: schema changed", v.Name))
+		}
+		if !reflect.DeepEqual(old.Subresources, v.Subresources) {
+			lines = append(lines, fmt.Sprintf("~ version %s: subresources changed", v.Name))
+		}
+		if !reflect.DeepEqual(old.AdditionalPrinterColumns, v.AdditionalPrinterColumns) {
+			lines = append(lines, fmt.Sprintf("~ version %s: printer columns changed", v.Name))
+		}
+	}
+	if from != nil {
+		for _, v := range from.Spec.Versions {
+			if !toVersions[v.Name] {
+				lines = append(lines, fmt.Sprintf("- version %s", v.Name))
+			}
+		}
+	}
+	return lines
+}
+
+func versionFlags(v apisv1alpha1.APIResourceVersion) string {
+	var flags string
+	if v.Served {
+		flags += " served"
+	}
+	if v.Storage {
+		flags += " storage"
+	}
+	if v.Deprecated {
+		flags += " deprecated"
+	}
+	if flags == "" {
+		return " not served"
+	}
+	return flags
+}
+
+func newKCPClusterClient(config *rest.Config) (kcpclientset.ClusterInterface, error) {
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return kcpclientset.NewForConfig(clusterConfig)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func newSchema(name, plural string, versions ...apisv1alpha1.APIResourceVersion) *apisv1alpha1.APIResourceSchema {
+	return &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group:    "example.io",
+			Names:    apiextensionsv1.CustomResourceDefinitionNames{Plural: plural},
+			Scope:    apiextensionsv1.NamespaceScoped,
+			Versions: versions,
+		},
+	}
+}
+
+func TestUpgradeAPIBinding(t *testing.T) {
+	schemas := map[string]*apisv1alpha1.APIResourceSchema{
+		"v1.widgets.example.io": newSchema("v1.widgets.example.io", "widgets",
+			apisv1alpha1.APIResourceVersion{Name: "v1", Served: true, Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+		),
+		"v2.widgets.example.io": newSchema("v2.widgets.example.io", "widgets",
+			apisv1alpha1.APIResourceVersion{Name: "v1", Served: true, Deprecated: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+			apisv1alpha1.APIResourceVersion{Name: "v2", Served: true, Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object","properties":{}}`)}},
+		),
+		"v1.gadgets.example.io": newSchema("v1.gadgets.example.io", "gadgets",
+			apisv1alpha1.APIResourceVersion{Name: "v1", Served: true, Storage: true},
+		),
+	}
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "example",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "provider"},
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v2.widgets.example.io", "v1.gadgets.example.io"},
+		},
+	}
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.BindingReference{
+				Export: &apisv1alpha1.ExportBindingReference{Path: "root:provider", Name: "example"},
+			},
+		},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "example.io", Resource: "widgets", Schema: apisv1alpha1.BoundAPIResourceSchema{Name: "v1.widgets.example.io"}},
+				{Group: "example.io", Resource: "sprockets", Schema: apisv1alpha1.BoundAPIResourceSchema{Name: "v1.sprockets.example.io"}},
+			},
+			Conditions: conditionsv1alpha1.Conditions{
+				{
+					Type:    apisv1alpha1.BindingUpToDate,
+					Status:  corev1.ConditionFalse,
+					Reason:  apisv1alpha1.NamingConflictsReason,
+					Message: "naming conflict with gadgets.example.io",
+				},
+			},
+		},
+	}
+
+	newOptions := func(streams genericclioptions.IOStreams) *UpgradeAPIBindingOptions {
+		opts := NewUpgradeAPIBindingOptions(streams)
+		opts.Name = "example"
+		opts.WaitTimeout = 10 * time.Millisecond
+		opts.getAPIBinding = func(ctx context.Context, name string) (*apisv1alpha1.APIBinding, error) {
+			return binding, nil
+		}
+		opts.getAPIExport = func(ctx context.Context, path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
+			require.Equal(t, logicalcluster.NewPath("root:provider"), path)
+			return export, nil
+		}
+		opts.getSchema = func(ctx context.Context, cluster logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+			require.Equal(t, logicalcluster.Name("provider"), cluster)
+			if s, found := schemas[name]; found {
+				return s, nil
+			}
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apis.kcp.io", Resource: "apiresourceschemas"}, name)
+		}
+		return opts
+	}
+
+	wantDiff := "gadgets.example.io: not bound -> v1.gadgets.example.io\n" +
+		"  + version v1 served storage\n" +
+		"sprockets.example.io: v1.sprockets.example.io -> not exported anymore, the bound resource is kept\n" +
+		"widgets.example.io: v1.widgets.example.io -> v2.widgets.example.io\n" +
+		"  ~ version v1: served storage -> served deprecated\n" +
+		"  + version v2 served storage\n"
+
+	t.Run("dry run", func(t *testing.T) {
+		streams, _, out, _ := genericclioptions.NewTestIOStreams()
+		opts := newOptions(streams)
+		opts.DryRun = true
+		require.NoError(t, opts.Validate())
+		require.NoError(t, opts.Run(context.Background()))
+		require.Equal(t, wantDiff, out.String())
+	})
+
+	t.Run("not upgraded", func(t *testing.T) {
+		streams, _, out, _ := genericclioptions.NewTestIOStreams()
+		opts := newOptions(streams)
+		require.EqualError(t, opts.Run(context.Background()), `APIBinding "example" not upgraded: naming conflict with gadgets.example.io`)
+		require.Equal(t, wantDiff, out.String())
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		streams, _, out, _ := genericclioptions.NewTestIOStreams()
+		opts := newOptions(streams)
+		export := export.DeepCopy()
+		export.Spec.LatestResourceSchemas = []string{"v1.widgets.example.io"}
+		opts.getAPIExport = func(ctx context.Context, path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
+			return export, nil
+		}
+		binding := binding.DeepCopy()
+		binding.Status.BoundResources = binding.Status.BoundResources[:1]
+		opts.getAPIBinding = func(ctx context.Context, name string) (*apisv1alpha1.APIBinding, error) {
+			return binding, nil
+		}
+		require.NoError(t, opts.Run(context.Background()))
+		require.Equal(t, "APIBinding \"example\" is up to date with APIExport \"root:provider:example\".\n", out.String())
+	})
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/apiexport/plugin"
+)
+
+var (
+	schemaGenerateExample = `
+	# Print the APIResourceSchemas converted from the CRDs in a yaml file, that do not exist in the current workspace yet.
+	%[1]s apiexport schema generate -f crds.yaml
+
+	# Generate the CRDs from Go types with controller-gen, and create the new APIResourceSchema revisions in the current workspace.
+	%[1]s apiexport schema generate --paths ./api/... --create
+
+	# Create the new APIResourceSchema revisions, and update the latest resource schemas of the widgets APIExport.
+	%[1]s apiexport schema generate -f crds.yaml --create --apiexport widgets
+`
+
+	consumersExample = `
+	# List the workspaces consuming the widgets APIExport, with the state of their bindings and permission claims.
+	%[1]s apiexport consumers widgets
+`
+
+	pushExample = `
+	# Push the widgets APIExport of the current workspace, with its latest APIResourceSchemas, to a registry.
+	%[1]s apiexport push widgets oci://registry.example.com/apis/widgets:v1
+
+	# Push to a registry requiring authentication, reading the password from stdin.
+	echo "$PASSWORD" | %[1]s apiexport push widgets oci://registry.example.com/apis/widgets:v1 --registry-username provider --registry-password-stdin
+`
+
+	pullExample = `
+	# Print the APIExport and APIResourceSchemas of a bundle.
+	%[1]s apiexport pull oci://registry.example.com/apis/widgets:v1
+
+	# Create the APIResourceSchemas and the APIExport of a bundle pinned to a digest in the current workspace.
+	%[1]s apiexport pull oci://registry.example.com/apis/widgets@sha256:<digest> --create
+`
+)
+
+// New provides a command for APIExport operations.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	cmd := &cobra.Command{
+		Use:              "apiexport",
+		Short:            "APIExport related operations",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	schemaCmd := &cobra.Command{
+		Use:              "schema",
+		Short:            "APIResourceSchema related operations",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	generateOptions := plugin.NewGenerateSchemaOptions(streams)
+	generateCmd := &cobra.Command{
+		Use:          "generate (-f FILE | --paths PACKAGES) [--prefix PREFIX] [--create [--apiexport NAME]]",
+		Short:        "Generate APIResourceSchema revisions from CRDs or Go types",
+		Example:      fmt.Sprintf(schemaGenerateExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := generateOptions.Complete(); err != nil {
+				return err
+			}
+			if err := generateOptions.Validate(); err != nil {
+				return err
+			}
+			return generateOptions.Run(c.Context())
+		},
+	}
+	generateOptions.BindFlags(generateCmd)
+
+	schemaCmd.AddCommand(generateCmd)
+	cmd.AddCommand(schemaCmd)
+
+	consumersOptions := plugin.NewListConsumersOptions(streams)
+	consumersCmd := &cobra.Command{
+		Use:          "consumers <apiexport-name>",
+		Short:        "List the APIBindings of the consumers of an APIExport",
+		Example:      fmt.Sprintf(consumersExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := consumersOptions.Complete(args); err != nil {
+				return err
+			}
+			if err := consumersOptions.Validate(); err != nil {
+				return err
+			}
+			return consumersOptions.Run(c.Context())
+		},
+	}
+	consumersOptions.BindFlags(consumersCmd)
+	cmd.AddCommand(consumersCmd)
+
+	pushOptions := plugin.NewPushOptions(streams)
+	pushCmd := &cobra.Command{
+		Use:          "push <apiexport-name> oci://<registry>/<repository>[:<tag>]",
+		Short:        "Push an APIExport and its latest APIResourceSchemas to an OCI registry",
+		Example:      fmt.Sprintf(pushExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return c.Help()
+			}
+			if err := pushOptions.Complete(args); err != nil {
+				return err
+			}
+			if err := pushOptions.Validate(); err != nil {
+				return err
+			}
+			return pushOptions.Run(c.Context())
+		},
+	}
+	pushOptions.BindFlags(pushCmd)
+	cmd.AddCommand(pushCmd)
+
+	pullOptions := plugin.NewPullOptions(streams)
+	pullCmd := &cobra.Command{
+		Use:          "pull oci://<registry>/<repository>[:<tag>|@<digest>] [--create]",
+		Short:        "Pull an APIExport and its APIResourceSchemas from an OCI registry",
+		Example:      fmt.Sprintf(pullExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := pullOptions.Complete(args); err != nil {
+				return err
+			}
+			if err := pullOptions.Validate(); err != nil {
+				return err
+			}
+			return pullOptions.Run(c.Context())
+		},
+	}
+	pullOptions.BindFlags(pullCmd)
+	cmd.AddCommand(pullCmd)
+
+	return cmd
+}
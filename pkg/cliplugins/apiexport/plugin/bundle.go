@@ -0,0 +1,513 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// bundleConfig is the config blob of an APIExport bundle.
+type bundleConfig struct {
+	// APIExport is the name of the bundled APIExport.
+	APIExport string `json:"apiExport"`
+	// ResourceSchemas are the names of the bundled APIResourceSchemas.
+	ResourceSchemas []string `json:"resourceSchemas"`
+}
+
+// bundle is an APIExport with the APIResourceSchemas of its latest resource schemas.
+type bundle struct {
+	APIExport *apisv1alpha1.APIExport
+	Schemas   []*apisv1alpha1.APIResourceSchema
+}
+
+// encode returns the config blob and the layer of the bundle. Only the names and the specs
+// of the objects are kept, and the identity of the APIExport is dropped, as it is specific
+// to an installation, so that pushing identical APIs gives identical digests.
+func (b *bundle) encode() ([]byte, []byte, error) {
+	config := bundleConfig{APIExport: b.APIExport.Name}
+
+	export := &apisv1alpha1.APIExport{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apisv1alpha1.SchemeGroupVersion.String(), Kind: "APIExport"},
+		ObjectMeta: metav1.ObjectMeta{Name: b.APIExport.Name},
+		Spec:       *b.APIExport.Spec.DeepCopy(),
+	}
+	export.Spec.Identity = nil
+
+	var layer bytes.Buffer
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		return nil, nil, err
+	}
+	layer.Write(data)
+
+	for _, s := range b.Schemas {
+		schema := &apisv1alpha1.APIResourceSchema{
+			TypeMeta:   metav1.TypeMeta{APIVersion: apisv1alpha1.SchemeGroupVersion.String(), Kind: "APIResourceSchema"},
+			ObjectMeta: metav1.ObjectMeta{Name: s.Name},
+			Spec:       s.Spec,
+		}
+		data, err := yaml.Marshal(schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		layer.WriteString("---\n")
+		layer.Write(data)
+		config.ResourceSchemas = append(config.ResourceSchemas, s.Name)
+	}
+
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return configData, layer.Bytes(), nil
+}
+
+// decodeBundle decodes the layer of an APIExport bundle.
+func decodeBundle(layer []byte) (*bundle, error) {
+	scheme := runtime.NewScheme()
+	if err := apisv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	b := &bundle{}
+	d := kubeyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(layer)))
+	for {
+		doc, err := d.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		decoded, _, err := codecs.UniversalDecoder(apisv1alpha1.SchemeGroupVersion).Decode(doc, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch obj := decoded.(type) {
+		case *apisv1alpha1.APIExport:
+			if b.APIExport != nil {
+				return nil, fmt.Errorf("bundle has several APIExports")
+			}
+			b.APIExport = obj
+		case *apisv1alpha1.APIResourceSchema:
+			b.Schemas = append(b.Schemas, obj)
+		default:
+			return nil, fmt.Errorf("unexpected type %T in bundle", decoded)
+		}
+	}
+	if b.APIExport == nil {
+		return nil, fmt.Errorf("bundle has no APIExport")
+	}
+	return b, nil
+}
+
+// pushBundle pushes the bundle to the reference, and returns the digest of its manifest.
+func pushBundle(ctx context.Context, client *ociRegistryClient, ref *ociReference, b *bundle) (string, error) {
+	config, layer, err := b.encode()
+	if err != nil {
+		return "", err
+	}
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Config:        descriptorOf(bundleConfigMediaType, config),
+		Layers:        []ociDescriptor{descriptorOf(bundleLayerMediaType, layer)},
+		Annotations:   map[string]string{"org.opencontainers.image.title": b.APIExport.Name},
+	}
+	if err := client.pushBlob(ctx, ref, manifest.Config, config); err != nil {
+		return "", err
+	}
+	if err := client.pushBlob(ctx, ref, manifest.Layers[0], layer); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	return client.pushManifest(ctx, ref, data)
+}
+
+// pullBundle pulls the bundle of the reference, and returns it with the digest of its manifest.
+func pullBundle(ctx context.Context, client *ociRegistryClient, ref *ociReference) (*bundle, string, error) {
+	manifest, digest, err := client.pullManifest(ctx, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	if manifest.Config.MediaType != bundleConfigMediaType {
+		return nil, "", fmt.Errorf("%s is not an APIExport bundle, its config has media type %q", ref, manifest.Config.MediaType)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != bundleLayerMediaType {
+		return nil, "", fmt.Errorf("%s is not an APIExport bundle, it must have a single %s layer", ref, bundleLayerMediaType)
+	}
+
+	layer, err := client.pullBlob(ctx, ref, manifest.Layers[0])
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := decodeBundle(layer)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid APIExport bundle %s: %w", ref, err)
+	}
+	return b, digest, nil
+}
+
+// registryOptions are the options to access an OCI registry.
+type registryOptions struct {
+	// PlainHTTP indicates the registry is accessed over HTTP instead of HTTPS.
+	PlainHTTP bool
+	// Username is the user authenticating to the registry.
+	Username string
+	// PasswordStdin indicates the password of the user is read from stdin.
+	PasswordStdin bool
+}
+
+func (o *registryOptions) bindFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.PlainHTTP, "plain-http", o.PlainHTTP, "Access the registry over HTTP instead of HTTPS")
+	cmd.Flags().StringVar(&o.Username, "registry-username", o.Username, "Username to authenticate to the registry with. The password is read from the KCP_REGISTRY_PASSWORD environment variable, or from stdin with --registry-password-stdin")
+	cmd.Flags().BoolVar(&o.PasswordStdin, "registry-password-stdin", o.PasswordStdin, "Read the registry password from stdin")
+}
+
+func (o *registryOptions) client(in io.Reader) (*ociRegistryClient, error) {
+	c := &ociRegistryClient{
+		client:    http.DefaultClient,
+		plainHTTP: o.PlainHTTP,
+		username:  o.Username,
+		password:  os.Getenv("KCP_REGISTRY_PASSWORD"),
+	}
+	if o.PasswordStdin {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the registry password from stdin: %w", err)
+		}
+		c.password = string(bytes.TrimRight(data, "\r\n"))
+	}
+	return c, nil
+}
+
+// PushOptions contains the options for pushing an APIExport bundle to an OCI registry.
+type PushOptions struct {
+	*base.Options
+	registryOptions
+
+	// APIExportName is the name of the APIExport in the current workspace.
+	APIExportName string
+	// Reference is the oci:// reference the bundle is pushed to.
+	Reference string
+
+	// for testing
+	getBundle func(ctx context.Context, name string) (*bundle, error)
+}
+
+// NewPushOptions returns a new PushOptions.
+func NewPushOptions(streams genericclioptions.IOStreams) *PushOptions {
+	return &PushOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *PushOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	o.registryOptions.bindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *PushOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.APIExportName = args[0]
+	}
+	if len(args) > 1 {
+		o.Reference = args[1]
+	}
+
+	if o.getBundle == nil {
+		config, err := o.ClientConfig.ClientConfig()
+		if err != nil {
+			return err
+		}
+		_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+		if err != nil {
+			return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+		}
+		kcpClusterClient, err := newKCPClusterClient(config)
+		if err != nil {
+			return err
+		}
+		kcpClient := kcpClusterClient.Cluster(currentClusterName)
+
+		o.getBundle = func(ctx context.Context, name string) (*bundle, error) {
+			export, err := kcpClient.ApisV1alpha1().APIExports().Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("error getting APIExport %s: %w", name, err)
+			}
+			b := &bundle{APIExport: export}
+			for _, schemaName := range export.Spec.LatestResourceSchemas {
+				schema, err := kcpClient.ApisV1alpha1().APIResourceSchemas().Get(ctx, schemaName, metav1.GetOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("error getting APIResourceSchema %s: %w", schemaName, err)
+				}
+				b.Schemas = append(b.Schemas, schema)
+			}
+			return b, nil
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the PushOptions are complete and usable.
+func (o *PushOptions) Validate() error {
+	var errs []error
+
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.APIExportName == "" {
+		errs = append(errs, errors.New("an APIExport name is required"))
+	}
+	if ref, err := parseOCIReference(o.Reference); err != nil {
+		errs = append(errs, err)
+	} else if ref.Digest != "" {
+		errs = append(errs, fmt.Errorf("reference %q must be a tag, the digest is computed on push", o.Reference))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run pushes the APIExport of the current workspace, with its latest resource schemas, to the registry.
+func (o *PushOptions) Run(ctx context.Context) error {
+	ref, err := parseOCIReference(o.Reference)
+	if err != nil {
+		return err
+	}
+	client, err := o.registryOptions.client(o.In)
+	if err != nil {
+		return err
+	}
+
+	b, err := o.getBundle(ctx, o.APIExportName)
+	if err != nil {
+		return err
+	}
+	digest, err := pushBundle(ctx, client, ref, b)
+	if err != nil {
+		return err
+	}
+
+	pinned := *ref
+	pinned.Tag = ""
+	pinned.Digest = digest
+	fmt.Fprintf(o.Out, "Pushed APIExport %s with %d APIResourceSchemas to %s.\n", o.APIExportName, len(b.Schemas), &pinned)
+	return nil
+}
+
+// PullOptions contains the options for pulling an APIExport bundle from an OCI registry.
+type PullOptions struct {
+	*base.Options
+	registryOptions
+
+	// Reference is the oci:// reference the bundle is pulled from. It can be pinned to a digest.
+	Reference string
+	// Create indicates the APIResourceSchemas and the APIExport are created in the current workspace, instead of printed.
+	Create bool
+
+	// for testing
+	getSchema    func(ctx context.Context, name string) (*apisv1alpha1.APIResourceSchema, error)
+	createSchema func(ctx context.Context, schema *apisv1alpha1.APIResourceSchema) error
+	applyExport  func(ctx context.Context, export *apisv1alpha1.APIExport) (bool, error)
+}
+
+// NewPullOptions returns a new PullOptions.
+func NewPullOptions(streams genericclioptions.IOStreams) *PullOptions {
+	return &PullOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *PullOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	o.registryOptions.bindFlags(cmd)
+
+	cmd.Flags().BoolVar(&o.Create, "create", o.Create, "Create the APIResourceSchemas and the APIExport in the current workspace instead of printing them")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *PullOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Reference = args[0]
+	}
+
+	if o.Create && o.getSchema == nil {
+		config, err := o.ClientConfig.ClientConfig()
+		if err != nil {
+			return err
+		}
+		_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+		if err != nil {
+			return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+		}
+		kcpClusterClient, err := newKCPClusterClient(config)
+		if err != nil {
+			return err
+		}
+		kcpClient := kcpClusterClient.Cluster(currentClusterName)
+
+		o.getSchema = func(ctx context.Context, name string) (*apisv1alpha1.APIResourceSchema, error) {
+			return kcpClient.ApisV1alpha1().APIResourceSchemas().Get(ctx, name, metav1.GetOptions{})
+		}
+		o.createSchema = func(ctx context.Context, schema *apisv1alpha1.APIResourceSchema) error {
+			_, err := kcpClient.ApisV1alpha1().APIResourceSchemas().Create(ctx, schema, metav1.CreateOptions{})
+			return err
+		}
+		o.applyExport = func(ctx context.Context, export *apisv1alpha1.APIExport) (bool, error) {
+			existing, err := kcpClient.ApisV1alpha1().APIExports().Get(ctx, export.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				_, err = kcpClient.ApisV1alpha1().APIExports().Create(ctx, export, metav1.CreateOptions{})
+				return true, err
+			} else if err != nil {
+				return false, err
+			}
+			// the identity of the existing APIExport is kept
+			existing.Spec.LatestResourceSchemas = export.Spec.LatestResourceSchemas
+			existing.Spec.MaximalPermissionPolicy = export.Spec.MaximalPermissionPolicy
+			existing.Spec.PermissionClaims = export.Spec.PermissionClaims
+			_, err = kcpClient.ApisV1alpha1().APIExports().Update(ctx, existing, metav1.UpdateOptions{})
+			return false, err
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the PullOptions are complete and usable.
+func (o *PullOptions) Validate() error {
+	var errs []error
+
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := parseOCIReference(o.Reference); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run pulls the bundle, and creates or prints its APIResourceSchemas and APIExport.
+func (o *PullOptions) Run(ctx context.Context) error {
+	ref, err := parseOCIReference(o.Reference)
+	if err != nil {
+		return err
+	}
+	client, err := o.registryOptions.client(o.In)
+	if err != nil {
+		return err
+	}
+
+	b, digest, err := pullBundle(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	if ref.Digest == "" {
+		pinned := *ref
+		pinned.Tag = ""
+		pinned.Digest = digest
+		fmt.Fprintf(o.ErrOut, "Pulled %s, use %s to pin this version.\n", ref, &pinned)
+	}
+
+	if !o.Create {
+		_, layer, err := b.encode()
+		if err != nil {
+			return err
+		}
+		_, err = o.Out.Write(layer)
+		return err
+	}
+
+	for _, schema := range b.Schemas {
+		existing, err := o.getSchema(ctx, schema.Name)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err := o.createSchema(ctx, schema); err != nil {
+				return fmt.Errorf("error creating APIResourceSchema %s: %w", schema.Name, err)
+			}
+			fmt.Fprintf(o.Out, "APIResourceSchema %s created.\n", schema.Name)
+		case err != nil:
+			return err
+		default:
+			// APIResourceSchemas are immutable, an existing revision must be identical
+			equal, err := equalSchemaSpecs(&existing.Spec, &schema.Spec)
+			if err != nil {
+				return err
+			}
+			if !equal {
+				return fmt.Errorf("APIResourceSchema %s already exists with a different spec", schema.Name)
+			}
+			fmt.Fprintf(o.Out, "APIResourceSchema %s is up to date.\n", schema.Name)
+		}
+	}
+
+	created, err := o.applyExport(ctx, b.APIExport)
+	if err != nil {
+		return fmt.Errorf("error applying APIExport %s: %w", b.APIExport.Name, err)
+	}
+	if created {
+		fmt.Fprintf(o.Out, "APIExport %s created.\n", b.APIExport.Name)
+	} else {
+		fmt.Fprintf(o.Out, "APIExport %s updated.\n", b.APIExport.Name)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// fakeRegistry is an in-memory OCI registry requiring a bearer token.
+type fakeRegistry struct {
+	lock      sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func newFakeRegistry(t *testing.T) *httptest.Server {
+	r := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		if req.URL.Path == "/token" {
+			if user, password, ok := req.BasicAuth(); !ok || user != "provider" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token":"t0k3n"}`)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:apis/widgets:pull,push"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.TrimPrefix(req.URL.Path, "/v2/apis/widgets/")
+		body, _ := io.ReadAll(req.Body)
+		switch {
+		case req.Method == http.MethodHead && strings.HasPrefix(path, "blobs/"):
+			if _, ok := r.blobs[strings.TrimPrefix(path, "blobs/")]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case req.Method == http.MethodGet && strings.HasPrefix(path, "blobs/"):
+			data, ok := r.blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data) //nolint:errcheck
+		case req.Method == http.MethodPost && path == "blobs/uploads/":
+			w.Header().Set("Location", "/v2/apis/widgets/blobs/uploads/1?state=x")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == http.MethodPut && strings.HasPrefix(path, "blobs/uploads/"):
+			digest := req.URL.Query().Get("digest")
+			require.Equal(t, "x", req.URL.Query().Get("state"))
+			require.Equal(t, digest, digestOf(body))
+			r.blobs[digest] = body
+			w.WriteHeader(http.StatusCreated)
+		case req.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+			r.manifests[strings.TrimPrefix(path, "manifests/")] = body
+			r.manifests[digestOf(body)] = body
+			w.WriteHeader(http.StatusCreated)
+		case req.Method == http.MethodGet && strings.HasPrefix(path, "manifests/"):
+			data, ok := r.manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return server
+}
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := map[string]struct {
+		ref     string
+		want    *ociReference
+		wantErr bool
+	}{
+		"tag":             {ref: "oci://registry.example.com/apis/widgets:v1", want: &ociReference{Registry: "registry.example.com", Repository: "apis/widgets", Tag: "v1"}},
+		"default tag":     {ref: "oci://localhost:5000/widgets", want: &ociReference{Registry: "localhost:5000", Repository: "widgets", Tag: "latest"}},
+		"digest":          {ref: "oci://localhost:5000/widgets@" + digest, want: &ociReference{Registry: "localhost:5000", Repository: "widgets", Digest: digest}},
+		"tag and digest":  {ref: "oci://localhost:5000/widgets:v1@" + digest, want: &ociReference{Registry: "localhost:5000", Repository: "widgets", Tag: "v1", Digest: digest}},
+		"no scheme":       {ref: "registry.example.com/widgets:v1", wantErr: true},
+		"no repository":   {ref: "oci://registry.example.com", wantErr: true},
+		"invalid digest":  {ref: "oci://registry.example.com/widgets@sha256:abc", wantErr: true},
+		"uppercase repo":  {ref: "oci://registry.example.com/Widgets", wantErr: true},
+		"unsupported alg": {ref: "oci://registry.example.com/widgets@sha512:" + strings.Repeat("a", 128), wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseOCIReference(tc.ref)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:apis/widgets:pull,push"`)
+	require.Equal(t, "Bearer", scheme)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:apis/widgets:pull,push",
+	}, params)
+}
+
+func TestPushPullBundle(t *testing.T) {
+	server := newFakeRegistry(t)
+	defer server.Close()
+
+	schema := generateWidgetsSchema(t, "v1", widgetsCRDYaml)
+	schema.UID = "uid"
+	schema.ResourceVersion = "42"
+	b := &bundle{
+		APIExport: &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets", ResourceVersion: "7"},
+			Spec: apisv1alpha1.APIExportSpec{
+				LatestResourceSchemas: []string{schema.Name},
+				Identity:              &apisv1alpha1.Identity{SecretRef: &corev1.SecretReference{Namespace: "kcp-system", Name: "widgets"}},
+			},
+		},
+		Schemas: []*apisv1alpha1.APIResourceSchema{schema},
+	}
+
+	ref, err := parseOCIReference("oci://" + strings.TrimPrefix(server.URL, "http://") + "/apis/widgets:v1")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	anonymous := &ociRegistryClient{client: server.Client(), plainHTTP: true}
+	_, err = pushBundle(ctx, anonymous, ref, b)
+	require.Error(t, err)
+
+	client := &ociRegistryClient{client: server.Client(), plainHTTP: true, username: "provider", password: "secret"}
+	digest, err := pushBundle(ctx, client, ref, b)
+	require.NoError(t, err)
+
+	// pushing the same APIs gives the same digest
+	again, err := pushBundle(ctx, client, ref, b)
+	require.NoError(t, err)
+	require.Equal(t, digest, again)
+
+	pulled, pulledDigest, err := pullBundle(ctx, client, ref)
+	require.NoError(t, err)
+	require.Equal(t, digest, pulledDigest)
+	require.Equal(t, "widgets", pulled.APIExport.Name)
+	require.Empty(t, pulled.APIExport.ResourceVersion)
+	require.Nil(t, pulled.APIExport.Spec.Identity)
+	require.Equal(t, []string{schema.Name}, pulled.APIExport.Spec.LatestResourceSchemas)
+	require.Len(t, pulled.Schemas, 1)
+	require.Equal(t, schema.Name, pulled.Schemas[0].Name)
+	require.Empty(t, pulled.Schemas[0].UID)
+	equal, err := equalSchemaSpecs(&schema.Spec, &pulled.Schemas[0].Spec)
+	require.NoError(t, err)
+	require.True(t, equal)
+
+	pinned := *ref
+	pinned.Tag = ""
+	pinned.Digest = digest
+	_, _, err = pullBundle(ctx, client, &pinned)
+	require.NoError(t, err)
+
+	pinned.Digest = "sha256:" + strings.Repeat("0", 64)
+	_, _, err = pullBundle(ctx, client, &pinned)
+	require.Error(t, err)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// claimOpen is the state of a permission claim requested by the APIExport that the consumer
+// has neither accepted nor rejected.
+const claimOpen apisv1alpha1.AcceptablePermissionClaimState = "Open"
+
+// ListConsumersOptions contains the options for listing the consumers of an APIExport.
+type ListConsumersOptions struct {
+	*base.Options
+
+	// APIExportName is the name of the APIExport in the current workspace.
+	APIExportName string
+
+	// for testing
+	getAPIExport func(ctx context.Context, name string) (*apisv1alpha1.APIExport, error)
+	// listAPIBindings lists the APIBindings of the consumers through the virtual workspace at the given URL.
+	listAPIBindings func(ctx context.Context, url string) ([]apisv1alpha1.APIBinding, error)
+}
+
+// NewListConsumersOptions returns a new ListConsumersOptions.
+func NewListConsumersOptions(streams genericclioptions.IOStreams) *ListConsumersOptions {
+	return &ListConsumersOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ListConsumersOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ListConsumersOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.APIExportName = args[0]
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	kcpClusterClient, err := newKCPClusterClient(config)
+	if err != nil {
+		return err
+	}
+
+	if o.getAPIExport == nil {
+		o.getAPIExport = func(ctx context.Context, name string) (*apisv1alpha1.APIExport, error) {
+			return kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIExports().Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	if o.listAPIBindings == nil {
+		o.listAPIBindings = func(ctx context.Context, url string) ([]apisv1alpha1.APIBinding, error) {
+			vwConfig := rest.CopyConfig(config)
+			vwConfig.Host = url
+			vwClient, err := kcpclientset.NewForConfig(vwConfig)
+			if err != nil {
+				return nil, err
+			}
+			bindings, err := vwClient.ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return bindings.Items, nil
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the ListConsumersOptions are complete and usable.
+func (o *ListConsumersOptions) Validate() error {
+	if o.APIExportName == "" {
+		return errors.New("an APIExport name is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run lists the APIBindings of the APIExport consumers, across all the shards, with their conditions,
+// the bound schemas and the state of the permission claims.
+func (o *ListConsumersOptions) Run(ctx context.Context) error {
+	export, err := o.getAPIExport(ctx, o.APIExportName)
+	if err != nil {
+		return err
+	}
+
+	//nolint:staticcheck // SA1019 VirtualWorkspaces is deprecated but not removed yet
+	urls := export.Status.VirtualWorkspaces
+	if len(urls) == 0 {
+		return fmt.Errorf("APIExport %q has no virtual workspace URL yet", export.Name)
+	}
+
+	// there is one virtual workspace per shard, each serving the APIBindings of the consumers on that shard.
+	var bindings []apisv1alpha1.APIBinding
+	seen := sets.NewString()
+	for _, vw := range urls {
+		shardBindings, err := o.listAPIBindings(ctx, vw.URL)
+		if err != nil {
+			return fmt.Errorf("failed to list the APIBindings from %s: %w", vw.URL, err)
+		}
+		for _, b := range shardBindings {
+			key := logicalcluster.From(&b).String() + "|" + b.Name
+			if seen.Has(key) {
+				continue
+			}
+			seen.Insert(key)
+			bindings = append(bindings, b)
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		ci, cj := logicalcluster.From(&bindings[i]), logicalcluster.From(&bindings[j])
+		if ci != cj {
+			return ci < cj
+		}
+		return bindings[i].Name < bindings[j].Name
+	})
+
+	out := printers.GetNewTabWriter(o.Out)
+	if _, err := fmt.Fprintf(out, "%s\n", strings.Join([]string{"CLUSTER", "APIBINDING", "READY", "UP-TO-DATE", "SCHEMAS", "CLAIMS"}, "\t")); err != nil {
+		return err
+	}
+
+	latest := sets.NewString(export.Spec.LatestResourceSchemas...)
+	var errs []error
+	outdated := 0
+	for i := range bindings {
+		b := &bindings[i]
+
+		schemas := make([]string, 0, len(b.Status.BoundResources))
+		isLatest := true
+		for _, r := range b.Status.BoundResources {
+			schemas = append(schemas, r.Schema.Name)
+			if !latest.Has(r.Schema.Name) {
+				isLatest = false
+			}
+		}
+		if !isLatest {
+			outdated++
+		}
+
+		_, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			logicalcluster.From(b),
+			b.Name,
+			conditionStatus(b, conditionsv1alpha1.ReadyCondition),
+			conditionStatus(b, apisv1alpha1.BindingUpToDate),
+			orNone(strings.Join(schemas, ",")),
+			orNone(consumerClaims(b, export.Spec.PermissionClaims)),
+		)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := fmt.Fprintf(o.ErrOut, "%d consumers, %d not bound to the latest resource schemas.\n", len(bindings), outdated); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// conditionStatus returns the status of the condition, or Unknown if the condition is not set yet.
+func conditionStatus(binding *apisv1alpha1.APIBinding, t conditionsv1alpha1.ConditionType) string {
+	if c := conditions.Get(binding, t); c != nil {
+		return string(c.Status)
+	}
+	return "Unknown"
+}
+
+// consumerClaims returns the state of the permission claims requested by the APIExport in the binding,
+// in the <resource>[.<group>]=<state> format.
+func consumerClaims(binding *apisv1alpha1.APIBinding, claims []apisv1alpha1.PermissionClaim) string {
+	states := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		state := claimOpen
+		for _, c := range binding.Spec.PermissionClaims {
+			if c.Equal(claim) {
+				state = c.State
+				break
+			}
+		}
+		name := claim.Resource
+		if claim.Group != "" {
+			name += "." + claim.Group
+		}
+		states = append(states, name+"="+string(state))
+	}
+	return strings.Join(states, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
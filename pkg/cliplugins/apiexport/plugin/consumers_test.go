@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func newConsumerBinding(cluster, name, schema string, status corev1.ConditionStatus, claims ...apisv1alpha1.AcceptablePermissionClaim) apisv1alpha1.APIBinding {
+	binding := apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			PermissionClaims: claims,
+		},
+	}
+	if schema != "" {
+		binding.Status.BoundResources = []apisv1alpha1.BoundAPIResource{
+			{Group: "example.io", Resource: "widgets", Schema: apisv1alpha1.BoundAPIResourceSchema{Name: schema}},
+		}
+	}
+	if status != "" {
+		binding.Status.Conditions = conditionsv1alpha1.Conditions{
+			{Type: conditionsv1alpha1.ReadyCondition, Status: status},
+			{Type: apisv1alpha1.BindingUpToDate, Status: status},
+		}
+	}
+	return binding
+}
+
+func TestListConsumers(t *testing.T) {
+	configMaps := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}, All: true}
+	secrets := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}, All: true}
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"v2.widgets.example.io"},
+			PermissionClaims:      []apisv1alpha1.PermissionClaim{configMaps, secrets},
+		},
+		Status: apisv1alpha1.APIExportStatus{
+			//nolint:staticcheck // SA1019 VirtualWorkspaces is deprecated but not removed yet
+			VirtualWorkspaces: []apisv1alpha1.VirtualWorkspace{
+				{URL: "https://shard-1/services/apiexport/root/widgets"},
+				{URL: "https://shard-2/services/apiexport/root/widgets"},
+			},
+		},
+	}
+	consumerA := newConsumerBinding("consumer-a", "widgets", "v2.widgets.example.io", corev1.ConditionTrue,
+		apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: configMaps, State: apisv1alpha1.ClaimAccepted})
+	consumerB := newConsumerBinding("consumer-b", "example", "v1.widgets.example.io", corev1.ConditionFalse,
+		apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: configMaps, State: apisv1alpha1.ClaimRejected})
+	consumerC := newConsumerBinding("consumer-c", "widgets", "", "")
+	bindings := map[string][]apisv1alpha1.APIBinding{
+		"https://shard-1/services/apiexport/root/widgets": {consumerC, consumerA},
+		"https://shard-2/services/apiexport/root/widgets": {consumerB, consumerA},
+	}
+
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	opts := NewListConsumersOptions(streams)
+	opts.APIExportName = "widgets"
+	opts.getAPIExport = func(ctx context.Context, name string) (*apisv1alpha1.APIExport, error) {
+		require.Equal(t, "widgets", name)
+		return export, nil
+	}
+	opts.listAPIBindings = func(ctx context.Context, url string) ([]apisv1alpha1.APIBinding, error) {
+		return bindings[url], nil
+	}
+
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+	require.Equal(t, ""+
+		"CLUSTER      APIBINDING   READY     UP-TO-DATE   SCHEMAS                 CLAIMS\n"+
+		"consumer-a   widgets      True      True         v2.widgets.example.io   configmaps=Accepted,secrets=Open\n"+
+		"consumer-b   example      False     False        v1.widgets.example.io   configmaps=Rejected,secrets=Open\n"+
+		"consumer-c   widgets      Unknown   Unknown      <none>                  configmaps=Open,secrets=Open\n",
+		out.String())
+	require.Equal(t, "3 consumers, 1 not bound to the latest resource schemas.\n", errOut.String())
+}
+
+func TestListConsumersNoVirtualWorkspace(t *testing.T) {
+	opts := NewListConsumersOptions(genericclioptions.NewTestIOStreamsDiscard())
+	opts.APIExportName = "widgets"
+	opts.getAPIExport = func(ctx context.Context, name string) (*apisv1alpha1.APIExport, error) {
+		return &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+	}
+	require.EqualError(t, opts.Run(context.Background()), `APIExport "widgets" has no virtual workspace URL yet`)
+}
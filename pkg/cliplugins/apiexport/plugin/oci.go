@@ -0,0 +1,408 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	ociScheme = "oci://"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// bundleConfigMediaType is the media type of the config blob of an APIExport bundle.
+	bundleConfigMediaType = "application/vnd.kcp.apiexport.config.v1+json"
+	// bundleLayerMediaType is the media type of the layer of an APIExport bundle, holding the
+	// APIExport and its APIResourceSchemas as multi-document YAML.
+	bundleLayerMediaType = "application/vnd.kcp.apiexport.bundle.v1+yaml"
+
+	// maxBlobSize bounds the size of the pulled manifests and blobs.
+	maxBlobSize = 32 << 20
+)
+
+var (
+	digestRegexp     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+)
+
+// ociReference is a parsed oci://<registry>/<repository>[:<tag>][@<digest>] reference.
+type ociReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseOCIReference parses an oci:// reference. The tag defaults to latest if there is no digest.
+func parseOCIReference(s string) (*ociReference, error) {
+	if !strings.HasPrefix(s, ociScheme) {
+		return nil, fmt.Errorf("reference %q must start with %s", s, ociScheme)
+	}
+	rest := strings.TrimPrefix(s, ociScheme)
+
+	ref := &ociReference{}
+	if i := strings.Index(rest, "@"); i >= 0 {
+		ref.Digest = rest[i+1:]
+		rest = rest[:i]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid digest %q in reference %q, only sha256 is supported", ref.Digest, s)
+		}
+	}
+
+	i := strings.Index(rest, "/")
+	if i <= 0 {
+		return nil, fmt.Errorf("reference %q has no repository", s)
+	}
+	ref.Registry, rest = rest[:i], rest[i+1:]
+	if j := strings.LastIndex(rest, ":"); j >= 0 {
+		ref.Tag, rest = rest[j+1:], rest[:j]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("invalid tag %q in reference %q", ref.Tag, s)
+		}
+	}
+	if !repositoryRegexp.MatchString(rest) {
+		return nil, fmt.Errorf("invalid repository %q in reference %q", rest, s)
+	}
+	ref.Repository = rest
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String returns the oci:// form of the reference.
+func (r *ociReference) String() string {
+	s := ociScheme + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifestReference returns the digest of the reference if pinned, or else its tag.
+func (r *ociReference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// ociDescriptor describes a content addressable blob.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func descriptorOf(mediaType string, data []byte) ociDescriptor {
+	return ociDescriptor{
+		MediaType: mediaType,
+		Digest:    digestOf(data),
+		Size:      int64(len(data)),
+	}
+}
+
+// ociRegistryClient is a minimal client of the OCI distribution API, supporting anonymous,
+// basic and bearer token authentication.
+type ociRegistryClient struct {
+	client    *http.Client
+	plainHTTP bool
+	username  string
+	password  string
+
+	// token is the bearer token obtained from the token service of the registry.
+	token string
+}
+
+func (c *ociRegistryClient) url(ref *ociReference, format string, args ...interface{}) string {
+	scheme := "https"
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.Registry, ref.Repository, fmt.Sprintf(format, args...))
+}
+
+// do sends the request built by newRequest, authenticating and sending it again if the
+// registry challenges it.
+func (c *ociRegistryClient) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		switch {
+		case c.token != "":
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		case c.username != "":
+			req.SetBasicAuth(c.username, c.password)
+		}
+		return c.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	scheme, params := parseChallenge(challenge)
+	switch {
+	case strings.EqualFold(scheme, "bearer"):
+		if err := c.fetchToken(ctx, params); err != nil {
+			return nil, err
+		}
+	case strings.EqualFold(scheme, "basic") && c.username != "" && c.token == "":
+		// the credentials have already been sent
+		return nil, fmt.Errorf("registry rejected the credentials of %s", c.username)
+	default:
+		return nil, fmt.Errorf("registry requires authentication (%s)", challenge)
+	}
+	return send()
+}
+
+// fetchToken gets a bearer token from the token service of the registry.
+func (c *ociRegistryClient) fetchToken(ctx context.Context, params map[string]string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	q := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v := params[key]; v != "" {
+			q.Set(key, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token service %s responded %s", realm.Host, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBlobSize)).Decode(&token); err != nil {
+		return fmt.Errorf("invalid response from token service %s: %w", realm.Host, err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("token service %s returned no token", realm.Host)
+	}
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header, e.g. Bearer realm="...",service="...".
+func parseChallenge(header string) (string, map[string]string) {
+	params := map[string]string{}
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return scheme, params
+}
+
+// pushBlob uploads the blob unless the repository already has it.
+func (c *ociRegistryClient) pushBlob(ctx context.Context, ref *ociReference, desc ociDescriptor, data []byte) error {
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodHead, c.url(ref, "blobs/%s", desc.Digest), nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, c.url(ref, "blobs/uploads/"), nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to start the upload of blob %s: registry responded %s", desc.Digest, resp.Status)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location %q: %w", resp.Header.Get("Location"), err)
+	}
+	q := location.Query()
+	q.Set("digest", desc.Digest)
+	location.RawQuery = q.Encode()
+
+	resp, err = c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, location.String(), bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to upload blob %s: registry responded %s", desc.Digest, resp.Status)
+	}
+	return nil
+}
+
+// pushManifest uploads the manifest under the tag of the reference, and returns its digest.
+func (c *ociRegistryClient) pushManifest(ctx context.Context, ref *ociReference, manifest []byte) (string, error) {
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, c.url(ref, "manifests/%s", ref.manifestReference()), bytes.NewReader(manifest))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", ociManifestMediaType)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to push manifest: registry responded %s", resp.Status)
+	}
+	return digestOf(manifest), nil
+}
+
+// pullManifest downloads the manifest of the reference, and returns it with its digest. The
+// digest is verified against the one the reference is pinned to, if any.
+func (c *ociRegistryClient) pullManifest(ctx context.Context, ref *ociReference) (*ociManifest, string, error) {
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, c.url(ref, "manifests/%s", ref.manifestReference()), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", ociManifestMediaType)
+		return req, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to pull manifest of %s: registry responded %s", ref, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := digestOf(data)
+	if ref.Digest != "" && digest != ref.Digest {
+		return nil, "", fmt.Errorf("manifest of %s has digest %s", ref, digest)
+	}
+
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest of %s: %w", ref, err)
+	}
+	if manifest.MediaType != "" && manifest.MediaType != ociManifestMediaType {
+		return nil, "", fmt.Errorf("unsupported manifest media type %q of %s", manifest.MediaType, ref)
+	}
+	return manifest, digest, nil
+}
+
+// pullBlob downloads the blob, and verifies its digest.
+func (c *ociRegistryClient) pullBlob(ctx context.Context, ref *ociReference, desc ociDescriptor) ([]byte, error) {
+	if !digestRegexp.MatchString(desc.Digest) {
+		return nil, fmt.Errorf("unsupported digest %q", desc.Digest)
+	}
+	if desc.Size > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is too large (%d bytes)", desc.Digest, desc.Size)
+	}
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, c.url(ref, "blobs/%s", desc.Digest), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to pull blob %s: registry responded %s", desc.Digest, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize))
+	if err != nil {
+		return nil, err
+	}
+	if digest := digestOf(data); digest != desc.Digest {
+		return nil, fmt.Errorf("blob %s has digest %s", desc.Digest, digest)
+	}
+	return data, nil
+}
@@ -0,0 +1,470 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// GenerateSchemaOptions contains options for generating APIResourceSchemas from CRDs.
+type GenerateSchemaOptions struct {
+	*base.Options
+
+	// Filenames are the paths of files containing the CRDs to convert, or - for stdin.
+	Filenames []string
+	// Paths are the Go packages controller-gen generates the CRDs from.
+	Paths []string
+	// ControllerGen is the controller-gen binary used to generate CRDs from Go types.
+	ControllerGen string
+	// Prefix is the prefix of the APIResourceSchema names. It defaults to v<date>-<hash>,
+	// with the hash computed from the schema spec, so that identical specs get identical names.
+	Prefix string
+	// OutputFormat is the format the new APIResourceSchemas are printed with.
+	OutputFormat string
+	// Create indicates the new APIResourceSchemas are created in the current workspace, instead of printed.
+	Create bool
+	// APIExportName is the name of the APIExport whose latest resource schemas are bumped to the generated revisions.
+	APIExportName string
+
+	// for testing
+	now func() time.Time
+}
+
+// NewGenerateSchemaOptions returns a new GenerateSchemaOptions.
+func NewGenerateSchemaOptions(streams genericclioptions.IOStreams) *GenerateSchemaOptions {
+	return &GenerateSchemaOptions{
+		Options:       base.NewOptions(streams),
+		ControllerGen: "controller-gen",
+		OutputFormat:  "yaml",
+		now:           time.Now,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *GenerateSchemaOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringSliceVarP(&o.Filenames, "filename", "f", o.Filenames, "Paths to files containing the CRDs to convert to APIResourceSchemas, or - for stdin")
+	cmd.Flags().StringSliceVar(&o.Paths, "paths", o.Paths, "Go packages to generate the CRDs from with controller-gen, e.g. ./api/...")
+	cmd.Flags().StringVar(&o.ControllerGen, "controller-gen", o.ControllerGen, "Path to the controller-gen binary used with --paths")
+	cmd.Flags().StringVar(&o.Prefix, "prefix", o.Prefix, "Prefix to use for the APIResourceSchemas' name, before <resource>.<group>. Defaults to v<date>-<hash of the schema>")
+	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", o.OutputFormat, "Output format of the new APIResourceSchemas. Valid values are 'json' and 'yaml'")
+	cmd.Flags().BoolVar(&o.Create, "create", o.Create, "Create the new APIResourceSchemas in the current workspace instead of printing them")
+	cmd.Flags().StringVar(&o.APIExportName, "apiexport", o.APIExportName, "Name of the APIExport of the current workspace whose latest resource schemas are updated to the generated revisions. Requires --create")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *GenerateSchemaOptions) Complete() error {
+	return o.Options.Complete()
+}
+
+// Validate validates the GenerateSchemaOptions are complete and usable.
+func (o *GenerateSchemaOptions) Validate() error {
+	var errs []error
+
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(o.Filenames) == 0 && len(o.Paths) == 0 {
+		errs = append(errs, fmt.Errorf("one of --filename or --paths is required"))
+	}
+	if o.OutputFormat != "json" && o.OutputFormat != "yaml" {
+		errs = append(errs, fmt.Errorf("invalid value %q for --output; valid values are json, yaml", o.OutputFormat))
+	}
+	if o.APIExportName != "" && !o.Create {
+		errs = append(errs, fmt.Errorf("--apiexport requires --create"))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run converts the CRDs to APIResourceSchemas, and creates or prints the revisions
+// that do not already exist in the current workspace.
+func (o *GenerateSchemaOptions) Run(ctx context.Context) error {
+	crds, err := o.loadCRDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	generated := make([]*apisv1alpha1.APIResourceSchema, 0, len(crds))
+	for _, crd := range crds {
+		prefix := o.Prefix
+		if prefix == "" {
+			prefix, err = defaultSchemaPrefix(crd, o.now())
+			if err != nil {
+				return err
+			}
+		}
+		schema, err := apisv1alpha1.CRDToAPIResourceSchema(crd, prefix)
+		if err != nil {
+			return fmt.Errorf("error converting CRD %s: %w", crd.Name, err)
+		}
+		generated = append(generated, schema)
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	kcpClusterClient, err := newKCPClusterClient(config)
+	if err != nil {
+		return err
+	}
+	kcpClient := kcpClusterClient.Cluster(currentClusterName)
+
+	existing, err := kcpClient.ApisV1alpha1().APIResourceSchemas().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing APIResourceSchemas in %q workspace: %w", currentClusterName, err)
+	}
+
+	resolved, newSchemas, err := resolveSchemaRevisions(existing.Items, generated)
+	if err != nil {
+		return err
+	}
+	for _, schema := range resolved {
+		if !containsSchema(newSchemas, schema.Name) {
+			fmt.Fprintf(o.ErrOut, "APIResourceSchema %s is up to date, no new revision needed.\n", schema.Name)
+		}
+	}
+
+	if !o.Create {
+		return o.printSchemas(newSchemas)
+	}
+
+	for _, schema := range newSchemas {
+		if _, err := kcpClient.ApisV1alpha1().APIResourceSchemas().Create(ctx, schema, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating APIResourceSchema %s: %w", schema.Name, err)
+		}
+		fmt.Fprintf(o.Out, "APIResourceSchema %s created.\n", schema.Name)
+	}
+
+	if o.APIExportName == "" {
+		return nil
+	}
+
+	export, err := kcpClient.ApisV1alpha1().APIExports().Get(ctx, o.APIExportName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting APIExport %s: %w", o.APIExportName, err)
+	}
+
+	latest := updateLatestResourceSchemas(export.Spec.LatestResourceSchemas, resolved)
+	if equality.Semantic.DeepEqual(latest, export.Spec.LatestResourceSchemas) {
+		fmt.Fprintf(o.Out, "APIExport %s is up to date.\n", export.Name)
+		return nil
+	}
+
+	oldData, err := json.Marshal(apisv1alpha1.APIExport{
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: export.Spec.LatestResourceSchemas,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal old data for APIExport %s: %w", export.Name, err)
+	}
+	newData, err := json.Marshal(apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             export.UID,
+			ResourceVersion: export.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: latest,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal new data for APIExport %s: %w", export.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create merge patch for APIExport %q because: %w", export.Name, err)
+	}
+	if _, err := kcpClient.ApisV1alpha1().APIExports().Patch(ctx, export.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch APIExport %s: %w", export.Name, err)
+	}
+	fmt.Fprintf(o.Out, "APIExport %s updated with latest resource schemas %s.\n", export.Name, strings.Join(latest, ", "))
+
+	return nil
+}
+
+// loadCRDs reads the CRDs from the files, and generates them from the Go packages with controller-gen.
+func (o *GenerateSchemaOptions) loadCRDs(ctx context.Context) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	var crds []*apiextensionsv1.CustomResourceDefinition
+
+	for _, filename := range o.Filenames {
+		var in io.Reader
+		if filename == "-" {
+			in = o.In
+		} else {
+			f, err := os.Open(filename)
+			if err != nil {
+				return nil, fmt.Errorf("error opening %s: %w", filename, err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		decoded, err := decodeCRDs(in)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CRDs from %s: %w", filename, err)
+		}
+		crds = append(crds, decoded...)
+	}
+
+	if len(o.Paths) > 0 {
+		args := []string{"crd", "paths=" + strings.Join(o.Paths, ";"), "output:crd:stdout"}
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, o.ControllerGen, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("error running %s %s: %w: %s", o.ControllerGen, strings.Join(args, " "), err, stderr.String())
+		}
+
+		decoded, err := decodeCRDs(&stdout)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CRDs generated by controller-gen: %w", err)
+		}
+		crds = append(crds, decoded...)
+	}
+
+	if len(crds) == 0 {
+		return nil, errors.New("no CRDs found")
+	}
+
+	return crds, nil
+}
+
+func (o *GenerateSchemaOptions) printSchemas(schemas []*apisv1alpha1.APIResourceSchema) error {
+	scheme := runtime.NewScheme()
+	if err := apisv1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	mediaType := runtime.ContentTypeYAML
+	if o.OutputFormat == "json" {
+		mediaType = runtime.ContentTypeJSON
+	}
+	info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), mediaType)
+	if !ok {
+		return fmt.Errorf("unsupported media type %q", mediaType)
+	}
+	encoder := codecs.EncoderForVersion(info.Serializer, apisv1alpha1.SchemeGroupVersion)
+
+	for _, schema := range schemas {
+		out, err := runtime.Encode(encoder, schema)
+		if err != nil {
+			return fmt.Errorf("error encoding APIResourceSchema %s: %w", schema.Name, err)
+		}
+		fmt.Fprintln(o.Out, string(out))
+		fmt.Fprintln(o.Out, "---")
+	}
+	return nil
+}
+
+func decodeCRDs(in io.Reader) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	d := kubeyaml.NewYAMLReader(bufio.NewReader(in))
+	for {
+		doc, err := d.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		decoded, _, err := codecs.UniversalDecoder(apiextensionsv1.SchemeGroupVersion).Decode(doc, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		crd, ok := decoded.(*apiextensionsv1.CustomResourceDefinition)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for CRD %T", decoded)
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
+
+// defaultSchemaPrefix returns v<date>-<hash>, with the hash computed from the CRD spec.
+func defaultSchemaPrefix(crd *apiextensionsv1.CustomResourceDefinition, now time.Time) (string, error) {
+	data, err := json.Marshal(crd.Spec)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("v%s-%x", now.Format("060102"), hash[:4]), nil
+}
+
+// resolveSchemaRevisions returns, for each generated APIResourceSchema, the existing revision
+// of the same resource with an identical spec if any, or else the generated one. The generated
+// APIResourceSchemas that are needed, i.e., that do not already exist, are also returned.
+func resolveSchemaRevisions(existing []apisv1alpha1.APIResourceSchema, generated []*apisv1alpha1.APIResourceSchema) ([]*apisv1alpha1.APIResourceSchema, []*apisv1alpha1.APIResourceSchema, error) {
+	var resolved, newSchemas []*apisv1alpha1.APIResourceSchema
+	for _, schema := range generated {
+		var match *apisv1alpha1.APIResourceSchema
+		conflict := false
+		for i := range existing {
+			e := &existing[i]
+			if e.Name == schema.Name {
+				conflict = true
+			}
+			if e.Spec.Group != schema.Spec.Group || e.Spec.Names.Plural != schema.Spec.Names.Plural {
+				continue
+			}
+			equal, err := equalSchemaSpecs(&e.Spec, &schema.Spec)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error comparing APIResourceSchemas %s and %s: %w", e.Name, schema.Name, err)
+			}
+			if equal {
+				match = e
+				break
+			}
+		}
+
+		switch {
+		case match != nil:
+			resolved = append(resolved, match)
+		case conflict:
+			return nil, nil, fmt.Errorf("APIResourceSchema %s already exists with a different spec, use a different --prefix", schema.Name)
+		default:
+			resolved = append(resolved, schema)
+			newSchemas = append(newSchemas, schema)
+		}
+	}
+	return resolved, newSchemas, nil
+}
+
+// equalSchemaSpecs compares APIResourceSchema specs, unmarshalling the JSON schemas
+// instead of comparing their raw representations, as those are not semantically meaningful.
+func equalSchemaSpecs(a, b *apisv1alpha1.APIResourceSchemaSpec) (bool, error) {
+	var errs []error
+	equal := cmp.Equal(a, b, cmp.FilterPath(func(path cmp.Path) bool {
+		return path.String() == "Versions.Schema.Raw"
+	}, cmp.Comparer(func(x, y []byte) bool {
+		var X, Y apiextensionsv1.JSONSchemaProps
+		if err := yaml.Unmarshal(x, &X); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		if err := yaml.Unmarshal(y, &Y); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		return cmp.Equal(X, Y)
+	})))
+	return equal, utilerrors.NewAggregate(errs)
+}
+
+// updateLatestResourceSchemas returns the latest resource schemas of an APIExport, with the revisions
+// of the resources of the given schemas replaced, and the schemas of new resources added.
+func updateLatestResourceSchemas(latest []string, schemas []*apisv1alpha1.APIResourceSchema) []string {
+	byResource := map[string]string{}
+	for _, schema := range schemas {
+		byResource[schema.Spec.Names.Plural+"."+schema.Spec.Group] = schema.Name
+	}
+
+	updated := make([]string, 0, len(latest)+len(schemas))
+	for _, name := range latest {
+		parts := strings.SplitN(name, ".", 2)
+		if len(parts) == 2 {
+			if newName, ok := byResource[parts[1]]; ok {
+				updated = append(updated, newName)
+				delete(byResource, parts[1])
+				continue
+			}
+		}
+		updated = append(updated, name)
+	}
+
+	added := make([]string, 0, len(byResource))
+	for _, name := range byResource {
+		added = append(added, name)
+	}
+	sort.Strings(added)
+
+	return append(updated, added...)
+}
+
+func containsSchema(schemas []*apisv1alpha1.APIResourceSchema, name string) bool {
+	for _, schema := range schemas {
+		if schema.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newKCPClusterClient(config *rest.Config) (kcpclientset.ClusterInterface, error) {
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return kcpclientset.NewForConfig(clusterConfig)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+var widgetsCRDYaml = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.io
+spec:
+  group: example.io
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+    served: true
+    storage: true
+`
+
+func generateWidgetsSchema(t *testing.T, prefix, crdYaml string) *apisv1alpha1.APIResourceSchema {
+	t.Helper()
+
+	crds, err := decodeCRDs(strings.NewReader(crdYaml))
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+
+	schema, err := apisv1alpha1.CRDToAPIResourceSchema(crds[0], prefix)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestDefaultSchemaPrefix(t *testing.T) {
+	crds, err := decodeCRDs(strings.NewReader(widgetsCRDYaml))
+	require.NoError(t, err)
+
+	now := time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC)
+	prefix, err := defaultSchemaPrefix(crds[0], now)
+	require.NoError(t, err)
+	require.Regexp(t, `^v221103-[0-9a-f]{8}$`, prefix)
+
+	again, err := defaultSchemaPrefix(crds[0], now)
+	require.NoError(t, err)
+	require.Equal(t, prefix, again, "the prefix should only depend on the CRD spec and date")
+
+	crds[0].Spec.Versions[0].Served = false
+	changed, err := defaultSchemaPrefix(crds[0], now)
+	require.NoError(t, err)
+	require.NotEqual(t, prefix, changed)
+}
+
+func TestResolveSchemaRevisions(t *testing.T) {
+	existing := generateWidgetsSchema(t, "v1", widgetsCRDYaml)
+
+	t.Run("identical spec reuses the existing revision", func(t *testing.T) {
+		generated := generateWidgetsSchema(t, "v2", widgetsCRDYaml)
+		resolved, newSchemas, err := resolveSchemaRevisions([]apisv1alpha1.APIResourceSchema{*existing}, []*apisv1alpha1.APIResourceSchema{generated})
+		require.NoError(t, err)
+		require.Empty(t, newSchemas)
+		require.Len(t, resolved, 1)
+		require.Equal(t, "v1.widgets.example.io", resolved[0].Name)
+	})
+
+	t.Run("changed spec needs a new revision", func(t *testing.T) {
+		generated := generateWidgetsSchema(t, "v2", strings.Replace(widgetsCRDYaml, "type: integer", "type: string", 1))
+		resolved, newSchemas, err := resolveSchemaRevisions([]apisv1alpha1.APIResourceSchema{*existing}, []*apisv1alpha1.APIResourceSchema{generated})
+		require.NoError(t, err)
+		require.Len(t, newSchemas, 1)
+		require.Equal(t, "v2.widgets.example.io", newSchemas[0].Name)
+		require.Equal(t, newSchemas, resolved)
+	})
+
+	t.Run("changed spec with an existing name is a conflict", func(t *testing.T) {
+		generated := generateWidgetsSchema(t, "v1", strings.Replace(widgetsCRDYaml, "type: integer", "type: string", 1))
+		_, _, err := resolveSchemaRevisions([]apisv1alpha1.APIResourceSchema{*existing}, []*apisv1alpha1.APIResourceSchema{generated})
+		require.Error(t, err)
+	})
+}
+
+func TestUpdateLatestResourceSchemas(t *testing.T) {
+	widgets := generateWidgetsSchema(t, "v2", widgetsCRDYaml)
+	gadgets := generateWidgetsSchema(t, "v1", strings.NewReplacer("widget", "gadget", "Widget", "Gadget").Replace(widgetsCRDYaml))
+
+	latest := updateLatestResourceSchemas([]string{"v1.things.example.io", "v1.widgets.example.io"}, []*apisv1alpha1.APIResourceSchema{widgets, gadgets})
+	require.Equal(t, []string{"v1.things.example.io", "v2.widgets.example.io", "v1.gadgets.example.io"}, latest)
+}
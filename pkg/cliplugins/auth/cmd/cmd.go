@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/auth/plugin"
+)
+
+var (
+	canIExample = `
+	# Check whether I can create configmaps in the default namespace of the current workspace.
+	%[1]s auth can-i create configmaps -n default
+
+	# Check whether I can update the status of widgets in the workspace root:org:team.
+	%[1]s auth can-i update widgets.example.io --subresource status --workspace root:org:team
+
+	# Check whether I can access the content of the child workspace "team".
+	%[1]s auth can-i access / --workspace team
+
+	# List all the actions I can perform in the current workspace.
+	%[1]s auth can-i --list
+`
+
+	whoAmIExample = `
+	# Print who I am, and whether I have access to the current workspace.
+	%[1]s auth whoami
+
+	# Print who I am, and whether I have access to the workspace root:org:team.
+	%[1]s auth whoami --workspace root:org:team
+`
+)
+
+// New provides a cobra command for workspace-aware authorization introspection.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	cmd := &cobra.Command{
+		Use:              "auth",
+		Short:            "Inspect authorization in workspaces",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	canIOptions := plugin.NewCanIOptions(streams)
+	canICmd := &cobra.Command{
+		Use:   "can-i VERB [TYPE | TYPE/NAME | NONRESOURCEURL]",
+		Short: "Check whether an action is allowed in a workspace",
+		Long: "Check whether an action is allowed in a workspace. The access review is evaluated by kcp in the target " +
+			"workspace, including the inherited and bootstrap RBAC, the workspace access permission and the maximal " +
+			"permission policy of bound APIs. When the current context points to a virtual workspace, the review is " +
+			"evaluated by the virtual workspace.",
+		Example:      fmt.Sprintf(canIExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := canIOptions.Complete(args); err != nil {
+				return err
+			}
+			if err := canIOptions.Validate(); err != nil {
+				return err
+			}
+			if err := canIOptions.Run(c.Context()); err != nil {
+				return err
+			}
+			if !canIOptions.List && !canIOptions.Allowed {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	canIOptions.BindFlags(canICmd)
+	cmd.AddCommand(canICmd)
+
+	whoAmIOptions := plugin.NewWhoAmIOptions(streams)
+	whoAmICmd := &cobra.Command{
+		Use:          "whoami",
+		Short:        "Print the user and its access to a workspace",
+		Example:      fmt.Sprintf(whoAmIExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := whoAmIOptions.Complete(); err != nil {
+				return err
+			}
+			if err := whoAmIOptions.Validate(); err != nil {
+				return err
+			}
+			return whoAmIOptions.Run(c.Context())
+		},
+	}
+	whoAmIOptions.BindFlags(whoAmICmd)
+	cmd.AddCommand(whoAmICmd)
+
+	return cmd
+}
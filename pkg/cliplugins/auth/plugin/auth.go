@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// resolveWorkspace returns the workspace path the name refers to. The name is either
+// empty or "." for the current workspace, an absolute path like root:org:team, or a
+// path relative to the current workspace.
+func resolveWorkspace(current logicalcluster.Path, name string) (logicalcluster.Path, error) {
+	if name == "" || name == "." {
+		return current, nil
+	}
+
+	workspace := logicalcluster.NewPath(name)
+	if !workspace.IsValid() {
+		return logicalcluster.Path{}, fmt.Errorf("invalid workspace name format: %s", name)
+	}
+	if strings.Contains(name, ":") || name == core.RootCluster.String() {
+		return workspace, nil
+	}
+	return current.Join(name), nil
+}
+
+// workspaceConfig returns a copy of the config pointing to the given workspace, and the
+// resolved workspace path. Any URL prefix in front of the cluster path, e.g. of a virtual
+// workspace, is preserved such that requests are evaluated by the same endpoint.
+func workspaceConfig(config *rest.Config, workspace string) (*rest.Config, logicalcluster.Path, error) {
+	u, current, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return nil, logicalcluster.Path{}, fmt.Errorf("current URL %q does not point to a workspace: %w", config.Host, err)
+	}
+
+	target, err := resolveWorkspace(current, workspace)
+	if err != nil {
+		return nil, logicalcluster.Path{}, err
+	}
+
+	u.Path = path.Join(u.Path, target.RequestPath())
+	workspaceConfig := rest.CopyConfig(config)
+	workspaceConfig.Host = u.String()
+	return workspaceConfig, target, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestWorkspaceConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		workspace string
+		wantHost  string
+		wantErr   bool
+	}{
+		{name: "current", host: "https://test/clusters/root:org", wantHost: "https://test/clusters/root:org"},
+		{name: "relative", host: "https://test/clusters/root:org", workspace: "team", wantHost: "https://test/clusters/root:org:team"},
+		{name: "absolute", host: "https://test/clusters/root:org", workspace: "root:other", wantHost: "https://test/clusters/root:other"},
+		{name: "root", host: "https://test/clusters/root:org", workspace: "root", wantHost: "https://test/clusters/root"},
+		{
+			name:      "virtual workspace",
+			host:      "https://test/services/apiexport/root:org/export/clusters/root:org",
+			workspace: "team",
+			wantHost:  "https://test/services/apiexport/root:org/export/clusters/root:org:team",
+		},
+		{name: "invalid", host: "https://test/clusters/root:org", workspace: "Team", wantErr: true},
+		{name: "not a workspace", host: "https://test", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _, err := workspaceConfig(&rest.Config{Host: tt.host}, tt.workspace)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantHost, config.Host)
+		})
+	}
+}
+
+func TestCanI(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		namespace  string
+		allowed    bool
+		reason     string
+		wantReview authorizationv1.SelfSubjectAccessReviewSpec
+		wantOutput string
+	}{
+		{
+			name:      "resource",
+			args:      []string{"create", "widgets"},
+			namespace: "default",
+			allowed:   true,
+			wantReview: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Namespace: "default", Verb: "create", Group: "example.io", Resource: "widgets"},
+			},
+			wantOutput: "yes\n",
+		},
+		{
+			name:    "resource name",
+			args:    []string{"get", "widgets/foo"},
+			allowed: false,
+			reason:  "no verb=access permission on /",
+			wantReview: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Group: "example.io", Resource: "widgets", Name: "foo"},
+			},
+			wantOutput: "no - no verb=access permission on /\n",
+		},
+		{
+			name:    "non-resource URL",
+			args:    []string{"access", "/"},
+			allowed: true,
+			wantReview: authorizationv1.SelfSubjectAccessReviewSpec{
+				NonResourceAttributes: &authorizationv1.NonResourceAttributes{Verb: "access", Path: "/"},
+			},
+			wantOutput: "yes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewCanIOptions(streams)
+			opts.Workspace = "team"
+			opts.namespaceFromConfig = func() (string, error) { return tt.namespace, nil }
+			opts.resolveResource = func(config *rest.Config, resource string) (schema.GroupResource, error) {
+				return schema.GroupResource{Group: "example.io", Resource: resource}, nil
+			}
+			var gotHost string
+			var gotReview authorizationv1.SelfSubjectAccessReviewSpec
+			opts.createAccessReview = func(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error) {
+				gotHost = config.Host
+				gotReview = review.Spec
+				review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: tt.allowed, Reason: tt.reason}
+				return review, nil
+			}
+
+			require.NoError(t, opts.Complete(tt.args))
+			opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+				CurrentContext: "test",
+				Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+				Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://test/clusters/root:org"}},
+				AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+			}, nil)
+			require.NoError(t, opts.Validate())
+			require.NoError(t, opts.Run(context.Background()))
+
+			require.Equal(t, "https://test/clusters/root:org:team", gotHost)
+			require.Equal(t, tt.wantReview, gotReview)
+			require.Equal(t, tt.allowed, opts.Allowed)
+			require.Equal(t, tt.wantOutput, out.String())
+		})
+	}
+}
+
+func TestIdentityFromJWT(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"system:serviceaccount:default:builder"}`))
+	id, ok := identityFromJWT("header." + payload + ".signature")
+	require.True(t, ok)
+	require.Equal(t, "system:serviceaccount:default:builder", id.Username)
+	require.Equal(t, []string{"system:serviceaccounts", "system:serviceaccounts:default", "system:authenticated"}, id.Groups)
+	require.Equal(t, "service account token", id.Source)
+
+	_, ok = identityFromJWT("opaque-token")
+	require.False(t, ok)
+}
+
+func TestPrintWhoAmI(t *testing.T) {
+	var out bytes.Buffer
+	err := printWhoAmI(&out, "root:org", "https://test/clusters/root:org",
+		identity{Username: "alice", Groups: []string{"team-a", "system:authenticated"}, Source: "client certificate"},
+		authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+		authorizationv1.SubjectAccessReviewStatus{Allowed: false},
+	)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"Workspace:          root:org\n"+
+		"Server:             https://test/clusters/root:org\n"+
+		"Username:           alice\n"+
+		"Groups:             [team-a system:authenticated]\n"+
+		"Credentials:        client certificate\n"+
+		"Workspace access:   yes\n"+
+		"Workspace admin:    no\n", out.String())
+}
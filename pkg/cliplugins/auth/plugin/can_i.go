@@ -0,0 +1,290 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// CanIOptions contains options for checking whether an action is allowed in a workspace.
+type CanIOptions struct {
+	*base.Options
+
+	// Workspace is the workspace to evaluate the permissions in. Defaults to the current workspace.
+	Workspace string
+	// AllNamespaces checks the action in all the namespaces.
+	AllNamespaces bool
+	// Subresource is the subresource of the resource to check, e.g. status.
+	Subresource string
+	// List lists all the actions allowed in the workspace.
+	List bool
+	// Quiet suppresses the output, only the exit code reflects the answer.
+	Quiet bool
+
+	Verb           string
+	Resource       string
+	ResourceName   string
+	NonResourceURL string
+
+	// Allowed is set by Run with the answer to the question.
+	Allowed bool
+
+	// for testing
+	resolveResource     func(config *rest.Config, resource string) (schema.GroupResource, error)
+	createAccessReview  func(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error)
+	createRulesReview   func(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectRulesReview) (*authorizationv1.SelfSubjectRulesReview, error)
+	namespaceFromConfig func() (string, error)
+}
+
+// NewCanIOptions returns a new CanIOptions.
+func NewCanIOptions(streams genericclioptions.IOStreams) *CanIOptions {
+	return &CanIOptions{
+		Options:            base.NewOptions(streams),
+		resolveResource:    resolveResource,
+		createAccessReview: createAccessReview,
+		createRulesReview:  createRulesReview,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *CanIOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Workspace, "workspace", o.Workspace, "Workspace to evaluate the permissions in, absolute or relative to the current workspace. Defaults to the current workspace")
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", o.AllNamespaces, "If true, check the specified action in all namespaces")
+	cmd.Flags().StringVar(&o.Subresource, "subresource", o.Subresource, "Subresource such as pod/log or deployment/scale")
+	cmd.Flags().BoolVar(&o.List, "list", o.List, "If true, prints all allowed actions in the workspace")
+	cmd.Flags().BoolVarP(&o.Quiet, "quiet", "q", o.Quiet, "If true, suppress output and just return the exit code")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *CanIOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if o.namespaceFromConfig == nil {
+		o.namespaceFromConfig = func() (string, error) {
+			namespace, _, err := o.ClientConfig.Namespace()
+			return namespace, err
+		}
+	}
+
+	if o.List {
+		return nil
+	}
+
+	if len(args) > 0 {
+		o.Verb = args[0]
+	}
+	if len(args) > 1 {
+		if strings.HasPrefix(args[1], "/") {
+			o.NonResourceURL = args[1]
+		} else {
+			resource, name, _ := strings.Cut(args[1], "/")
+			o.Resource, o.ResourceName = resource, name
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the CanIOptions are complete and usable.
+func (o *CanIOptions) Validate() error {
+	if o.List {
+		if o.Verb != "" || o.Resource != "" || o.NonResourceURL != "" {
+			return errors.New("--list does not take a verb or resource")
+		}
+		return o.Options.Validate()
+	}
+
+	if o.Verb == "" {
+		return errors.New("a verb is required")
+	}
+	if o.Resource == "" && o.NonResourceURL == "" {
+		return errors.New("a resource or a non-resource URL is required")
+	}
+	if o.NonResourceURL != "" && o.Subresource != "" {
+		return errors.New("--subresource can not be used with a non-resource URL")
+	}
+	if o.NonResourceURL != "" && o.AllNamespaces {
+		return errors.New("--all-namespaces can not be used with a non-resource URL")
+	}
+
+	return o.Options.Validate()
+}
+
+// Run evaluates the access review in the workspace and prints the answer.
+func (o *CanIOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	config, workspace, err := workspaceConfig(config, o.Workspace)
+	if err != nil {
+		return err
+	}
+
+	namespace := ""
+	if !o.AllNamespaces {
+		if namespace, err = o.namespaceFromConfig(); err != nil {
+			return err
+		}
+	}
+
+	if o.List {
+		review, err := o.createRulesReview(ctx, config, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to review the rules in workspace %q: %w", workspace, err)
+		}
+		if review.Status.Incomplete {
+			fmt.Fprintf(o.ErrOut, "Warning: the list may be incomplete: %s\n", review.Status.EvaluationError)
+		}
+		return printRules(o.Out, review.Status)
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{}
+	if o.NonResourceURL != "" {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Verb: o.Verb,
+			Path: o.NonResourceURL,
+		}
+	} else {
+		gr, err := o.resolveResource(config, o.Resource)
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "Warning: the server doesn't have a resource type %q in workspace %q\n", o.Resource, workspace)
+			gr = schema.ParseGroupResource(o.Resource)
+		}
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   namespace,
+			Verb:        o.Verb,
+			Group:       gr.Group,
+			Resource:    gr.Resource,
+			Subresource: o.Subresource,
+			Name:        o.ResourceName,
+		}
+	}
+
+	response, err := o.createAccessReview(ctx, config, review)
+	if err != nil {
+		return fmt.Errorf("failed to review access in workspace %q: %w", workspace, err)
+	}
+	o.Allowed = response.Status.Allowed
+
+	if response.Status.EvaluationError != "" {
+		fmt.Fprintf(o.ErrOut, "Warning: %s\n", response.Status.EvaluationError)
+	}
+	if o.Quiet {
+		return nil
+	}
+	_, err = fmt.Fprintln(o.Out, accessReviewAnswer(response.Status))
+	return err
+}
+
+// accessReviewAnswer returns yes or no, with the reason given by the kcp authorizers if any.
+func accessReviewAnswer(status authorizationv1.SubjectAccessReviewStatus) string {
+	answer := "no"
+	if status.Allowed {
+		answer = "yes"
+	}
+	if status.Reason != "" {
+		answer += " - " + status.Reason
+	}
+	return answer
+}
+
+func printRules(w io.Writer, status authorizationv1.SubjectRulesReviewStatus) error {
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "RESOURCES\tNON-RESOURCE URLS\tRESOURCE NAMES\tVERBS\n"); err != nil {
+		return err
+	}
+	for _, rule := range status.ResourceRules {
+		var resources []string
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				resources = append(resources, schema.GroupResource{Group: group, Resource: resource}.String())
+			}
+		}
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", strings.Join(resources, ","), "[]", formatList(rule.ResourceNames), formatList(rule.Verbs)); err != nil {
+			return err
+		}
+	}
+	for _, rule := range status.NonResourceRules {
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", "", formatList(rule.NonResourceURLs), "[]", formatList(rule.Verbs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatList(values []string) string {
+	return "[" + strings.Join(values, " ") + "]"
+}
+
+// resolveResource maps the resource to its group, using the discovery of the workspace.
+func resolveResource(config *rest.Config, resource string) (schema.GroupResource, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return schema.GroupResource{}, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return schema.GroupResource{}, err
+	}
+	return gvr.GroupResource(), nil
+}
+
+func createAccessReview(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+}
+
+func createRulesReview(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectRulesReview) (*authorizationv1.SelfSubjectRulesReview, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, review, metav1.CreateOptions{})
+}
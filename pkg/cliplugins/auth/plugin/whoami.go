@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// WhoAmIOptions contains options for printing the identity used to access a workspace.
+type WhoAmIOptions struct {
+	*base.Options
+
+	// Workspace is the workspace to check the access to. Defaults to the current workspace.
+	Workspace string
+
+	// for testing
+	createAccessReview func(ctx context.Context, config *rest.Config, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error)
+}
+
+// identity is the user information derived from the client credentials.
+type identity struct {
+	Username string
+	Groups   []string
+	Source   string
+}
+
+// NewWhoAmIOptions returns a new WhoAmIOptions.
+func NewWhoAmIOptions(streams genericclioptions.IOStreams) *WhoAmIOptions {
+	return &WhoAmIOptions{
+		Options:            base.NewOptions(streams),
+		createAccessReview: createAccessReview,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *WhoAmIOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Workspace, "workspace", o.Workspace, "Workspace to check the access to, absolute or relative to the current workspace. Defaults to the current workspace")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *WhoAmIOptions) Complete() error {
+	return o.Options.Complete()
+}
+
+// Validate validates the WhoAmIOptions are complete and usable.
+func (o *WhoAmIOptions) Validate() error {
+	return o.Options.Validate()
+}
+
+// Run prints the identity of the user, and its access to the workspace.
+func (o *WhoAmIOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	config, workspace, err := workspaceConfig(config, o.Workspace)
+	if err != nil {
+		return err
+	}
+
+	id, err := identityFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// workspace content access is granted by the verb=access permission on /, everything
+	// else by the RBAC of the workspace, the bootstrap policy and, for bound resources, the
+	// maximal permission policy of the APIExport.
+	access, err := o.createAccessReview(ctx, config, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{Verb: "access", Path: "/"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to review access to workspace %q: %w", workspace, err)
+	}
+	admin, err := o.createAccessReview(ctx, config, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to review access to workspace %q: %w", workspace, err)
+	}
+
+	return printWhoAmI(o.Out, workspace.String(), config.Host, id, access.Status, admin.Status)
+}
+
+func printWhoAmI(w io.Writer, workspace, server string, id identity, access, admin authorizationv1.SubjectAccessReviewStatus) error {
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	username := id.Username
+	if username == "" {
+		username = "<unknown>"
+	}
+	rows := [][2]string{
+		{"Workspace", workspace},
+		{"Server", server},
+		{"Username", username},
+		{"Groups", formatList(id.Groups)},
+		{"Credentials", id.Source},
+		{"Workspace access", accessReviewAnswer(access)},
+		{"Workspace admin", accessReviewAnswer(admin)},
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(out, "%s:\t%s\n", row[0], row[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identityFromConfig derives the user from the credentials of the config. Only client
+// certificates and JWT bearer tokens carry the user information, for other credentials
+// the user is only known to the server.
+func identityFromConfig(config *rest.Config) (identity, error) {
+	if config.Impersonate.UserName != "" {
+		return identity{
+			Username: config.Impersonate.UserName,
+			Groups:   config.Impersonate.Groups,
+			Source:   "impersonation",
+		}, nil
+	}
+
+	certData := config.TLSClientConfig.CertData
+	if len(certData) == 0 && config.TLSClientConfig.CertFile != "" {
+		data, err := os.ReadFile(config.TLSClientConfig.CertFile)
+		if err != nil {
+			return identity{}, err
+		}
+		certData = data
+	}
+	if len(certData) > 0 {
+		return identityFromCertificate(certData)
+	}
+
+	token := config.BearerToken
+	if token == "" && config.BearerTokenFile != "" {
+		data, err := os.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return identity{}, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		if id, ok := identityFromJWT(token); ok {
+			return id, nil
+		}
+		return identity{Source: "opaque bearer token"}, nil
+	}
+
+	switch {
+	case config.ExecProvider != nil:
+		return identity{Source: fmt.Sprintf("exec plugin %s", config.ExecProvider.Command)}, nil
+	case config.AuthProvider != nil:
+		return identity{Source: fmt.Sprintf("auth provider %s", config.AuthProvider.Name)}, nil
+	case config.Username != "":
+		return identity{Username: config.Username, Groups: []string{"system:authenticated"}, Source: "basic auth"}, nil
+	}
+	return identity{Username: "system:anonymous", Groups: []string{"system:unauthenticated"}, Source: "none"}, nil
+}
+
+func identityFromCertificate(data []byte) (identity, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return identity{}, fmt.Errorf("failed to decode the client certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return identity{}, fmt.Errorf("failed to parse the client certificate: %w", err)
+	}
+	groups := append([]string{}, cert.Subject.Organization...)
+	groups = append(groups, "system:authenticated")
+	return identity{
+		Username: cert.Subject.CommonName,
+		Groups:   groups,
+		Source:   "client certificate",
+	}, nil
+}
+
+func identityFromJWT(token string) (identity, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return identity{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return identity{}, false
+	}
+	var claims struct {
+		Subject string   `json:"sub"`
+		Groups  []string `json:"groups"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return identity{}, false
+	}
+
+	id := identity{Username: claims.Subject, Groups: claims.Groups, Source: "bearer token"}
+	if strings.HasPrefix(claims.Subject, "system:serviceaccount:") {
+		// service accounts are only authorized in the workspace they are declared in.
+		namespace := strings.Split(strings.TrimPrefix(claims.Subject, "system:serviceaccount:"), ":")[0]
+		id.Groups = append(id.Groups, "system:serviceaccounts", "system:serviceaccounts:"+namespace)
+		id.Source = "service account token"
+	}
+	id.Groups = append(id.Groups, "system:authenticated")
+	return id, true
+}
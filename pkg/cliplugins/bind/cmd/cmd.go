@@ -41,6 +41,9 @@ var (
 
     # Create a placement to deploy standard kubernetes workloads to synctargets in the "root:mylocations" location workspace, and select only locations in the us-east region.
     %[1]s bind compute root:mylocations --location-selectors=region=us-east1
+
+    # Show which locations and synctargets in the "root:mylocations" location workspace would be selected, without binding.
+    %[1]s bind compute root:mylocations --location-selectors=region=us-east1 --preview
 	`
 )
 
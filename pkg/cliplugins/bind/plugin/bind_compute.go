@@ -65,6 +65,9 @@ type BindComputeOptions struct {
 
 	// BindWaitTimeout is how long to wait for the placement to be created and successful.
 	BindWaitTimeout time.Duration
+
+	// Preview prints the Locations and SyncTargets the placement would select, without creating anything.
+	Preview bool
 }
 
 func NewBindComputeOptions(streams genericclioptions.IOStreams) *BindComputeOptions {
@@ -91,6 +94,7 @@ func (o *BindComputeOptions) BindFlags(cmd *cobra.Command) {
 		"A list of label selectors to select locations in the location workspace to sync workload.")
 	cmd.Flags().StringVar(&o.PlacementName, "name", o.PlacementName, "Name of the placement to be created.")
 	cmd.Flags().DurationVar(&o.BindWaitTimeout, "timeout", time.Second*30, "Duration to wait for Placement to be created and bound successfully.")
+	cmd.Flags().BoolVar(&o.Preview, "preview", o.Preview, "Print the Locations and SyncTargets in the location workspace that would be selected, without creating the APIBindings and Placement.")
 }
 
 // Complete ensures all dynamically populated fields are initialized.
@@ -142,6 +146,11 @@ func (o *BindComputeOptions) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+
+	if o.Preview {
+		return o.preview(ctx, config)
+	}
+
 	userWorkspaceKcpClient, err := kcpclient.NewForConfig(config)
 	if err != nil {
 		return fmt.Errorf("failed to create kcp client: %w", err)
@@ -339,11 +348,7 @@ func (o *BindComputeOptions) applyPlacement(ctx context.Context, client kcpclien
 			NamespaceSelector: o.namespaceSelector,
 			LocationSelectors: o.locationSelectors,
 			LocationWorkspace: o.LocationWorkspace.String(),
-			LocationResource: schedulingv1alpha1.GroupVersionResource{
-				Group:    "workload.kcp.io",
-				Version:  "v1alpha1",
-				Resource: "synctargets",
-			},
+			LocationResource:  syncTargetsLocationResource,
 		},
 	}
 
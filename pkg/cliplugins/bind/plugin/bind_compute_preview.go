@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// syncTargetsLocationResource is the location resource placements created by bind compute point to.
+var syncTargetsLocationResource = schedulingv1alpha1.GroupVersionResource{
+	Group:    "workload.kcp.io",
+	Version:  "v1alpha1",
+	Resource: "synctargets",
+}
+
+// locationPreview is a Location matched by the location selectors, with the SyncTargets it selects.
+type locationPreview struct {
+	location    *schedulingv1alpha1.Location
+	syncTargets []*workloadv1alpha1.SyncTarget
+}
+
+// preview prints the Locations and SyncTargets in the location workspace that a placement with the
+// given selectors would be scheduled to, without creating any APIBinding or Placement.
+func (o *BindComputeOptions) preview(ctx context.Context, config *rest.Config) error {
+	kcpClusterClient, err := newKCPClusterClient(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kcp client: %w", err)
+	}
+
+	locations, err := kcpClusterClient.Cluster(o.LocationWorkspace).SchedulingV1alpha1().Locations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list Locations in workspace %q: %w", o.LocationWorkspace, err)
+	}
+	syncTargets, err := kcpClusterClient.Cluster(o.LocationWorkspace).WorkloadV1alpha1().SyncTargets().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list SyncTargets in workspace %q: %w", o.LocationWorkspace, err)
+	}
+
+	previews, err := previewLocations(locations.Items, syncTargets.Items, o.locationSelectors)
+	if err != nil {
+		return err
+	}
+	if len(previews) == 0 {
+		return fmt.Errorf("no Location in workspace %q matches the location selectors %q", o.LocationWorkspace, strings.Join(o.LocationSelectorsStrings, ","))
+	}
+
+	if err := printLocationPreviews(o.Out, previews, time.Now()); err != nil {
+		return err
+	}
+
+	if !anySyncTargetHealthy(previews, time.Now()) {
+		_, err = fmt.Fprintf(o.ErrOut, "Warning: none of the selected SyncTargets is ready, workloads will not be synced until one becomes ready.\n")
+	}
+	return err
+}
+
+// previewLocations returns the Locations matching any of the selectors, the same way the placement
+// scheduler selects them, along with the SyncTargets selected by each Location's instance selector.
+func previewLocations(locations []schedulingv1alpha1.Location, syncTargets []workloadv1alpha1.SyncTarget, selectors []metav1.LabelSelector) ([]locationPreview, error) {
+	var previews []locationPreview
+	for i := range locations {
+		location := &locations[i]
+		if location.Spec.Resource != syncTargetsLocationResource {
+			continue
+		}
+
+		matched := false
+		for i := range selectors {
+			selector, err := metav1.LabelSelectorAsSelector(&selectors[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid location selector %v: %w", selectors[i], err)
+			}
+			if selector.Matches(labels.Set(location.Labels)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		instanceSelector, err := metav1.LabelSelectorAsSelector(location.Spec.InstanceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid instance selector in Location %q: %w", location.Name, err)
+		}
+		preview := locationPreview{location: location}
+		for i := range syncTargets {
+			if instanceSelector.Matches(labels.Set(syncTargets[i].Labels)) {
+				preview.syncTargets = append(preview.syncTargets, &syncTargets[i])
+			}
+		}
+		sort.Slice(preview.syncTargets, func(i, j int) bool {
+			return preview.syncTargets[i].Name < preview.syncTargets[j].Name
+		})
+		previews = append(previews, preview)
+	}
+
+	sort.Slice(previews, func(i, j int) bool {
+		return previews[i].location.Name < previews[j].location.Name
+	})
+	return previews, nil
+}
+
+// syncTargetHealth returns whether the SyncTarget can be scheduled to, and a short description of its health.
+func syncTargetHealth(syncTarget *workloadv1alpha1.SyncTarget, now time.Time) (bool, string) {
+	if syncTarget.Spec.EvictAfter != nil && !now.Before(syncTarget.Spec.EvictAfter.Time) {
+		return false, "Evicting"
+	}
+	if syncTarget.Spec.Unschedulable {
+		return false, "Unschedulable"
+	}
+	if !conditions.IsTrue(syncTarget, conditionsv1alpha1.ReadyCondition) {
+		if reason := conditions.GetReason(syncTarget, conditionsv1alpha1.ReadyCondition); reason != "" {
+			return false, fmt.Sprintf("NotReady (%s)", reason)
+		}
+		return false, "NotReady"
+	}
+	return true, "Ready"
+}
+
+func anySyncTargetHealthy(previews []locationPreview, now time.Time) bool {
+	for _, preview := range previews {
+		for _, syncTarget := range preview.syncTargets {
+			if healthy, _ := syncTargetHealth(syncTarget, now); healthy {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func printLocationPreviews(w io.Writer, previews []locationPreview, now time.Time) error {
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "LOCATION\tSYNCTARGET\tHEALTH\n"); err != nil {
+		return err
+	}
+	for _, preview := range previews {
+		if len(preview.syncTargets) == 0 {
+			if _, err := fmt.Fprintf(out, "%s\t<none>\t\n", preview.location.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, syncTarget := range preview.syncTargets {
+			_, health := syncTargetHealth(syncTarget, now)
+			if _, err := fmt.Fprintf(out, "%s\t%s\t%s\n", preview.location.Name, syncTarget.Name, health); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func newLocation(name string, labels map[string]string, instanceSelector map[string]string) schedulingv1alpha1.Location {
+	return schedulingv1alpha1.Location{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec: schedulingv1alpha1.LocationSpec{
+			Resource:         syncTargetsLocationResource,
+			InstanceSelector: &metav1.LabelSelector{MatchLabels: instanceSelector},
+		},
+	}
+}
+
+func newSyncTarget(name string, labels map[string]string, ready bool) workloadv1alpha1.SyncTarget {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: workloadv1alpha1.SyncTargetStatus{
+			Conditions: conditionsv1alpha1.Conditions{
+				{Type: conditionsv1alpha1.ReadyCondition, Status: status, Reason: "HeartbeatStale"},
+			},
+		},
+	}
+}
+
+func TestPreviewLocations(t *testing.T) {
+	locations := []schedulingv1alpha1.Location{
+		newLocation("us-west", map[string]string{"region": "us-west1"}, map[string]string{"region": "us-west1"}),
+		newLocation("us-east", map[string]string{"region": "us-east1"}, map[string]string{"region": "us-east1"}),
+		newLocation("empty", map[string]string{"region": "us-east1"}, map[string]string{"region": "none"}),
+	}
+	other := newLocation("clusters", map[string]string{"region": "us-east1"}, nil)
+	other.Spec.Resource.Resource = "clusters"
+	locations = append(locations, other)
+
+	syncTargets := []workloadv1alpha1.SyncTarget{
+		newSyncTarget("east-2", map[string]string{"region": "us-east1"}, false),
+		newSyncTarget("east-1", map[string]string{"region": "us-east1"}, true),
+		newSyncTarget("west-1", map[string]string{"region": "us-west1"}, true),
+	}
+
+	selectors := []metav1.LabelSelector{{MatchLabels: map[string]string{"region": "us-east1"}}}
+	previews, err := previewLocations(locations, syncTargets, selectors)
+	require.NoError(t, err)
+	require.Len(t, previews, 2)
+
+	now := time.Now()
+	var out bytes.Buffer
+	require.NoError(t, printLocationPreviews(&out, previews, now))
+	require.Equal(t, ""+
+		"LOCATION   SYNCTARGET   HEALTH\n"+
+		"empty      <none>       \n"+
+		"us-east    east-1       Ready\n"+
+		"us-east    east-2       NotReady (HeartbeatStale)\n", out.String())
+	require.True(t, anySyncTargetHealthy(previews, now))
+
+	previews, err = previewLocations(locations, syncTargets, []metav1.LabelSelector{{MatchLabels: map[string]string{"region": "eu-central1"}}})
+	require.NoError(t, err)
+	require.Empty(t, previews)
+}
+
+func TestSyncTargetHealth(t *testing.T) {
+	now := time.Now()
+
+	syncTarget := newSyncTarget("st", nil, true)
+	healthy, health := syncTargetHealth(&syncTarget, now)
+	require.True(t, healthy)
+	require.Equal(t, "Ready", health)
+
+	syncTarget.Spec.Unschedulable = true
+	healthy, health = syncTargetHealth(&syncTarget, now)
+	require.False(t, healthy)
+	require.Equal(t, "Unschedulable", health)
+
+	syncTarget.Spec.EvictAfter = &metav1.Time{Time: now.Add(-time.Minute)}
+	healthy, health = syncTargetHealth(&syncTarget, now)
+	require.False(t, healthy)
+	require.Equal(t, "Evicting", health)
+}
@@ -24,10 +24,10 @@ import (
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
+	apiv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/claims/plugin"
 )
 
-// TODO: Add examples for edit and update claims.
 var (
 	claimsExample = `
 	# Lists the permission claims and their respective status related to a specific APIBinding.
@@ -35,6 +35,15 @@ var (
 
 	# List permission claims and their respective status for all APIBindings in current workspace.
 	%[1]s claims get apibinding
+
+	# List the open, accepted and rejected permission claims of all APIBindings in current workspace, and what they grant.
+	%[1]s claims list
+
+	# Accept the permission claims on configmaps and secrets requested by the APIExport bound by the cert-manager APIBinding.
+	%[1]s claims accept cert-manager configmaps secrets
+
+	# Reject all the permission claims requested by the APIExport bound by the cert-manager APIBinding.
+	%[1]s claims reject cert-manager --all
 	`
 )
 
@@ -84,5 +93,49 @@ func New(streams genericclioptions.IOStreams) *cobra.Command {
 	apibindingGetOpts.BindFlags(apibindingGetCmd)
 	getcmd.AddCommand(apibindingGetCmd)
 	claimsCmd.AddCommand(getcmd)
+
+	listOpts := plugin.NewListClaimsOptions(streams)
+	listCmd := &cobra.Command{
+		Use:          "list [<apibinding_name>]",
+		Short:        "List the permission claims of apibindings, with their state and what they grant",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return cmd.Help()
+			}
+			if err := listOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := listOpts.Validate(); err != nil {
+				return err
+			}
+			return listOpts.Run(cmd.Context())
+		},
+	}
+	listOpts.BindFlags(listCmd)
+	claimsCmd.AddCommand(listCmd)
+
+	claimsCmd.AddCommand(newUpdateClaimsCommand(streams, "accept", "Accept permission claims of an apibinding", apiv1alpha1.ClaimAccepted))
+	claimsCmd.AddCommand(newUpdateClaimsCommand(streams, "reject", "Reject permission claims of an apibinding", apiv1alpha1.ClaimRejected))
 	return claimsCmd
 }
+
+func newUpdateClaimsCommand(streams genericclioptions.IOStreams, verb, short string, state apiv1alpha1.AcceptablePermissionClaimState) *cobra.Command {
+	opts := plugin.NewUpdateClaimsOptions(streams, state)
+	cmd := &cobra.Command{
+		Use:          verb + " <apibinding_name> [<resource>[.<group>]...] [--all]",
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Complete(args); err != nil {
+				return err
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return opts.Run(cmd.Context())
+		},
+	}
+	opts.BindFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	apiv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// ClaimOpen is the state of a permission claim requested by the APIExport
+// that has been neither accepted nor rejected in the APIBinding.
+const ClaimOpen apiv1alpha1.AcceptablePermissionClaimState = "Open"
+
+// ListClaimsOptions contains the options for listing the permission claims
+// requested by the APIExports bound in the current workspace.
+type ListClaimsOptions struct {
+	*base.Options
+
+	// APIBindingName is the name of the APIBinding whose claims are listed.
+	// All the APIBindings of the current workspace are considered if empty.
+	APIBindingName string
+}
+
+// NewListClaimsOptions returns a new ListClaimsOptions.
+func NewListClaimsOptions(streams genericclioptions.IOStreams) *ListClaimsOptions {
+	return &ListClaimsOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *ListClaimsOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *ListClaimsOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.APIBindingName = args[0]
+	}
+	return nil
+}
+
+// Validate validates the ListClaimsOptions are complete and usable.
+func (o *ListClaimsOptions) Validate() error {
+	return o.Options.Validate()
+}
+
+// Run lists the permission claims, with their state and what they grant.
+func (o *ListClaimsOptions) Run(ctx context.Context) error {
+	bindings, err := o.apiBindings(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := printers.GetNewTabWriter(o.Out)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "%s\n", strings.Join([]string{"APIBINDING", "CLAIM", "STATE", "GRANTS"}, "\t")); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, b := range bindings {
+		for _, claim := range b.Status.ExportPermissionClaims {
+			_, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", b.Name, claimName(claim), claimState(&b, claim), describeClaim(&b, claim))
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *ListClaimsOptions) apiBindings(ctx context.Context) ([]apiv1alpha1.APIBinding, error) {
+	cfg, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(cfg.Host)
+	if err != nil {
+		return nil, fmt.Errorf("current URL %q does not point to cluster workspace", cfg.Host)
+	}
+	kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating kcp client %w", err)
+	}
+
+	if o.APIBindingName != "" {
+		binding, err := kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings().Get(ctx, o.APIBindingName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error finding apibinding: %w", err)
+		}
+		return []apiv1alpha1.APIBinding{*binding}, nil
+	}
+
+	bindings, err := kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing apibindings in %q workspace: %w", currentClusterName, err)
+	}
+	return bindings.Items, nil
+}
+
+// UpdateClaimsOptions contains the options for accepting or rejecting
+// the permission claims of an APIBinding.
+type UpdateClaimsOptions struct {
+	*base.Options
+
+	// APIBindingName is the name of the APIBinding whose claims are updated.
+	APIBindingName string
+	// Claims are the claims to update, identified by <resource>[.<group>].
+	Claims []string
+	// All indicates all the claims requested by the APIExport are updated.
+	All bool
+
+	// State is the state the claims are set to, i.e., accepted or rejected.
+	State apiv1alpha1.AcceptablePermissionClaimState
+}
+
+// NewUpdateClaimsOptions returns a new UpdateClaimsOptions setting claims to the given state.
+func NewUpdateClaimsOptions(streams genericclioptions.IOStreams, state apiv1alpha1.AcceptablePermissionClaimState) *UpdateClaimsOptions {
+	return &UpdateClaimsOptions{
+		Options: base.NewOptions(streams),
+		State:   state,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *UpdateClaimsOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	cmd.Flags().BoolVar(&o.All, "all", o.All, "Update all the permission claims requested by the APIExport")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *UpdateClaimsOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.APIBindingName = args[0]
+		o.Claims = args[1:]
+	}
+	return nil
+}
+
+// Validate validates the UpdateClaimsOptions are complete and usable.
+func (o *UpdateClaimsOptions) Validate() error {
+	var errs []error
+
+	if o.APIBindingName == "" {
+		errs = append(errs, fmt.Errorf("an APIBinding name is required"))
+	}
+	if o.All && len(o.Claims) > 0 {
+		errs = append(errs, fmt.Errorf("--all cannot be used with explicit claims"))
+	}
+	if !o.All && len(o.Claims) == 0 {
+		errs = append(errs, fmt.Errorf("at least one claim, or --all, is required"))
+	}
+	if o.State != apiv1alpha1.ClaimAccepted && o.State != apiv1alpha1.ClaimRejected {
+		errs = append(errs, fmt.Errorf("invalid claim state %q", o.State))
+	}
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run patches the permission claims of the APIBinding with the new state.
+func (o *UpdateClaimsOptions) Run(ctx context.Context) error {
+	cfg, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(cfg.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", cfg.Host)
+	}
+	kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+	if err != nil {
+		return fmt.Errorf("error while creating kcp client %w", err)
+	}
+
+	binding, err := kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings().Get(ctx, o.APIBindingName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error finding apibinding: %w", err)
+	}
+
+	claims, updated, err := setClaimsState(binding, o.Claims, o.All, o.State)
+	if err != nil {
+		return err
+	}
+
+	oldData, err := json.Marshal(apiv1alpha1.APIBinding{
+		Spec: apiv1alpha1.APIBindingSpec{
+			PermissionClaims: binding.Spec.PermissionClaims,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal old data for apibinding %s: %w", binding.Name, err)
+	}
+	newData, err := json.Marshal(apiv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             binding.UID,
+			ResourceVersion: binding.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Spec: apiv1alpha1.APIBindingSpec{
+			PermissionClaims: claims,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal new data for apibinding %s: %w", binding.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create merge patch for apibinding %q because: %w", binding.Name, err)
+	}
+
+	if _, err := kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings().Patch(ctx, binding.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch apibinding %s: %w", binding.Name, err)
+	}
+
+	for _, claim := range updated {
+		if _, err := fmt.Fprintf(o.Out, "Permission claim %s of apibinding %q %s.\n", claim, binding.Name, strings.ToLower(string(o.State))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setClaimsState returns the permission claims of the binding spec, with the named claims, or all the
+// claims requested by the APIExport, set to the given state. The names of the updated claims are returned.
+func setClaimsState(binding *apiv1alpha1.APIBinding, names []string, all bool, state apiv1alpha1.AcceptablePermissionClaimState) ([]apiv1alpha1.AcceptablePermissionClaim, []string, error) {
+	var selected []apiv1alpha1.PermissionClaim
+	if all {
+		selected = binding.Status.ExportPermissionClaims
+		if len(selected) == 0 {
+			return nil, nil, fmt.Errorf("apibinding %q has no permission claims", binding.Name)
+		}
+	} else {
+		var errs []error
+		for _, name := range names {
+			found := false
+			for _, claim := range binding.Status.ExportPermissionClaims {
+				if claimName(claim) == name {
+					selected = append(selected, claim)
+					found = true
+				}
+			}
+			if !found {
+				errs = append(errs, fmt.Errorf("permission claim %q is not requested by the APIExport of apibinding %q", name, binding.Name))
+			}
+		}
+		if err := utilerrors.NewAggregate(errs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	claims := make([]apiv1alpha1.AcceptablePermissionClaim, 0, len(binding.Spec.PermissionClaims)+len(selected))
+	for _, claim := range binding.Spec.PermissionClaims {
+		isSelected := false
+		for _, s := range selected {
+			if claim.Equal(s) {
+				isSelected = true
+				break
+			}
+		}
+		if !isSelected {
+			claims = append(claims, claim)
+		}
+	}
+
+	updated := make([]string, 0, len(selected))
+	for _, claim := range selected {
+		claims = append(claims, apiv1alpha1.AcceptablePermissionClaim{
+			PermissionClaim: claim,
+			State:           state,
+		})
+		updated = append(updated, claimName(claim))
+	}
+
+	return claims, updated, nil
+}
+
+// claimName returns the name a claim is identified with on the command line, i.e., <resource>[.<group>].
+func claimName(claim apiv1alpha1.PermissionClaim) string {
+	if claim.Group == "" {
+		return claim.Resource
+	}
+	return claim.Resource + "." + claim.Group
+}
+
+// claimState returns whether the claim requested by the APIExport is accepted, rejected or still open in the binding.
+func claimState(binding *apiv1alpha1.APIBinding, claim apiv1alpha1.PermissionClaim) apiv1alpha1.AcceptablePermissionClaimState {
+	for _, c := range binding.Spec.PermissionClaims {
+		if c.Equal(claim) {
+			return c.State
+		}
+	}
+	return ClaimOpen
+}
+
+// describeClaim explains the access granted to the API service provider by accepting the claim.
+func describeClaim(binding *apiv1alpha1.APIBinding, claim apiv1alpha1.PermissionClaim) string {
+	provider := "the API provider"
+	if binding.Spec.Reference.Export != nil {
+		provider = fmt.Sprintf("APIExport %q", binding.Spec.Reference.Export.Name)
+	}
+
+	if claim.All {
+		return fmt.Sprintf("%s access to all %s in this workspace", provider, claimName(claim))
+	}
+
+	selectors := make([]string, 0, len(claim.ResourceSelector))
+	for _, s := range claim.ResourceSelector {
+		switch {
+		case s.Name != "" && s.Namespace != "":
+			selectors = append(selectors, fmt.Sprintf("%s/%s", s.Namespace, s.Name))
+		case s.Name != "":
+			selectors = append(selectors, fmt.Sprintf("named %s", s.Name))
+		default:
+			selectors = append(selectors, fmt.Sprintf("in namespace %s", s.Namespace))
+		}
+	}
+	return fmt.Sprintf("%s access to %s %s", provider, claimName(claim), strings.Join(selectors, ", "))
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+var (
+	configMapsClaim = apiv1alpha1.PermissionClaim{
+		GroupResource: apiv1alpha1.GroupResource{Resource: "configmaps"},
+		All:           true,
+	}
+	secretsClaim = apiv1alpha1.PermissionClaim{
+		GroupResource: apiv1alpha1.GroupResource{Resource: "secrets"},
+		ResourceSelector: []apiv1alpha1.ResourceSelector{
+			{Namespace: "default", Name: "tls"},
+			{Namespace: "kube-system"},
+		},
+	}
+	widgetsClaim = apiv1alpha1.PermissionClaim{
+		GroupResource: apiv1alpha1.GroupResource{Group: "example.io", Resource: "widgets"},
+		All:           true,
+		IdentityHash:  "abc",
+	}
+)
+
+func newBinding() *apiv1alpha1.APIBinding {
+	return &apiv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-manager"},
+		Spec: apiv1alpha1.APIBindingSpec{
+			Reference: apiv1alpha1.BindingReference{
+				Export: &apiv1alpha1.ExportBindingReference{Name: "cert-manager"},
+			},
+			PermissionClaims: []apiv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configMapsClaim, State: apiv1alpha1.ClaimRejected},
+			},
+		},
+		Status: apiv1alpha1.APIBindingStatus{
+			ExportPermissionClaims: []apiv1alpha1.PermissionClaim{configMapsClaim, secretsClaim, widgetsClaim},
+		},
+	}
+}
+
+func TestClaimState(t *testing.T) {
+	binding := newBinding()
+	require.Equal(t, apiv1alpha1.ClaimRejected, claimState(binding, configMapsClaim))
+	require.Equal(t, ClaimOpen, claimState(binding, secretsClaim))
+}
+
+func TestDescribeClaim(t *testing.T) {
+	binding := newBinding()
+	require.Equal(t, `APIExport "cert-manager" access to all configmaps in this workspace`, describeClaim(binding, configMapsClaim))
+	require.Equal(t, `APIExport "cert-manager" access to secrets default/tls, in namespace kube-system`, describeClaim(binding, secretsClaim))
+	require.Equal(t, `APIExport "cert-manager" access to all widgets.example.io in this workspace`, describeClaim(binding, widgetsClaim))
+}
+
+func TestSetClaimsState(t *testing.T) {
+	tests := []struct {
+		name        string
+		claims      []string
+		all         bool
+		state       apiv1alpha1.AcceptablePermissionClaimState
+		want        []apiv1alpha1.AcceptablePermissionClaim
+		wantUpdated []string
+		wantErr     bool
+	}{
+		{
+			name:   "accept an open claim",
+			claims: []string{"widgets.example.io"},
+			state:  apiv1alpha1.ClaimAccepted,
+			want: []apiv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configMapsClaim, State: apiv1alpha1.ClaimRejected},
+				{PermissionClaim: widgetsClaim, State: apiv1alpha1.ClaimAccepted},
+			},
+			wantUpdated: []string{"widgets.example.io"},
+		},
+		{
+			name:   "accept a rejected claim",
+			claims: []string{"configmaps"},
+			state:  apiv1alpha1.ClaimAccepted,
+			want: []apiv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configMapsClaim, State: apiv1alpha1.ClaimAccepted},
+			},
+			wantUpdated: []string{"configmaps"},
+		},
+		{
+			name:  "reject all claims",
+			all:   true,
+			state: apiv1alpha1.ClaimRejected,
+			want: []apiv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configMapsClaim, State: apiv1alpha1.ClaimRejected},
+				{PermissionClaim: secretsClaim, State: apiv1alpha1.ClaimRejected},
+				{PermissionClaim: widgetsClaim, State: apiv1alpha1.ClaimRejected},
+			},
+			wantUpdated: []string{"configmaps", "secrets", "widgets.example.io"},
+		},
+		{
+			name:    "unknown claim",
+			claims:  []string{"pods"},
+			state:   apiv1alpha1.ClaimAccepted,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, updated, err := setClaimsState(newBinding(), tt.claims, tt.all, tt.state)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantUpdated, updated)
+		})
+	}
+}
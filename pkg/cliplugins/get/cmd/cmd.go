@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/get/plugin"
+)
+
+var (
+	getExample = `
+	# List the configmaps of the current workspace and all its descendants.
+	%[1]s get configmaps --all-workspaces
+
+	# List the widgets of the default namespace of the current workspace and all its descendants.
+	%[1]s get widgets.example.io --all-workspaces -n default
+`
+)
+
+// New provides a command for listing resources across workspaces.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	getOptions := plugin.NewGetOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "get <resource> [--all-workspaces] [-n NAMESPACE]",
+		Short:        "List resources, with the workspace they belong to",
+		Example:      fmt.Sprintf(getExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			if err := getOptions.Complete(args); err != nil {
+				return err
+			}
+			if err := getOptions.Validate(); err != nil {
+				return err
+			}
+			return getOptions.Run(c.Context())
+		},
+	}
+	getOptions.BindFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// GetOptions contains options for listing resources across workspaces.
+type GetOptions struct {
+	*base.Options
+
+	// Resource is the resource to list, e.g. configmaps or widgets.example.io.
+	Resource string
+	// Namespace restricts the listing to a namespace. All the namespaces are listed if empty.
+	Namespace string
+	// AllWorkspaces lists the resources in the current workspace and all its descendants.
+	AllWorkspaces bool
+
+	// for testing
+	listWorkspaces func(ctx context.Context, path logicalcluster.Path) ([]workspaceInfo, error)
+	getClusterName func(ctx context.Context, path logicalcluster.Path) (logicalcluster.Name, error)
+	listWildcard   func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	listInCluster  func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error)
+	now            func() time.Time
+}
+
+// workspaceInfo identifies a workspace by path and logical cluster name.
+type workspaceInfo struct {
+	Path    logicalcluster.Path
+	Cluster logicalcluster.Name
+}
+
+// NewGetOptions returns a new GetOptions.
+func NewGetOptions(streams genericclioptions.IOStreams) *GetOptions {
+	return &GetOptions{
+		Options: base.NewOptions(streams),
+		now:     time.Now,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *GetOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "Only list the resources of this namespace. Defaults to all namespaces")
+	cmd.Flags().BoolVar(&o.AllWorkspaces, "all-workspaces", o.AllWorkspaces, "List the resources of the current workspace and all its descendants readable by the user")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *GetOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Resource = args[0]
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	clusterConfig, err := clusterConfigFor(config)
+	if err != nil {
+		return err
+	}
+	kcpClusterClient, err := kcpclientset.NewForConfig(clusterConfig)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(clusterConfig)
+	if err != nil {
+		return err
+	}
+
+	o.listWorkspaces = func(ctx context.Context, path logicalcluster.Path) ([]workspaceInfo, error) {
+		workspaces, err := kcpClusterClient.Cluster(path).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var children []workspaceInfo
+		for _, ws := range workspaces.Items {
+			if ws.Status.Phase != corev1alpha1.LogicalClusterPhaseReady {
+				continue
+			}
+			children = append(children, workspaceInfo{Path: path.Join(ws.Name), Cluster: logicalcluster.Name(ws.Status.Cluster)})
+		}
+		return children, nil
+	}
+	o.getClusterName = func(ctx context.Context, path logicalcluster.Path) (logicalcluster.Name, error) {
+		cluster, err := kcpClusterClient.Cluster(path).CoreV1alpha1().LogicalClusters().Get(ctx, corev1alpha1.LogicalClusterName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return logicalcluster.From(cluster), nil
+	}
+	o.listWildcard = func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+		list, err := dynamicClusterClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	o.listInCluster = func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+		list, err := dynamicClusterClient.Cluster(path).Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	return nil
+}
+
+// Validate validates the GetOptions are complete and usable.
+func (o *GetOptions) Validate() error {
+	if o.Resource == "" {
+		return fmt.Errorf("a resource is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run lists the resources in the current workspace, and in its descendants if requested.
+func (o *GetOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	mapping, err := resolveResource(config, o.Resource)
+	if err != nil {
+		return err
+	}
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+
+	workspaces := []workspaceInfo{{Path: currentClusterName}}
+	if o.AllWorkspaces {
+		descendants, err := o.walkWorkspaces(ctx, currentClusterName)
+		if err != nil {
+			return err
+		}
+		workspaces = append(workspaces, descendants...)
+	}
+
+	rows, err := o.listResources(ctx, mapping.Resource, workspaces)
+	if err != nil {
+		return err
+	}
+
+	return printRows(o.Out, rows, namespaced, o.now())
+}
+
+// walkWorkspaces returns the descendants of the workspace, skipping the subtrees the user is not allowed to read.
+func (o *GetOptions) walkWorkspaces(ctx context.Context, path logicalcluster.Path) ([]workspaceInfo, error) {
+	var descendants []workspaceInfo
+	queue := []logicalcluster.Path{path}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		children, err := o.listWorkspaces(ctx, current)
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+			fmt.Fprintf(o.ErrOut, "Skipping the workspaces under %s: %v\n", current, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(children, func(i, j int) bool {
+			return children[i].Path.String() < children[j].Path.String()
+		})
+		for _, child := range children {
+			descendants = append(descendants, child)
+			queue = append(queue, child.Path)
+		}
+	}
+	return descendants, nil
+}
+
+type resourceRow struct {
+	Workspace logicalcluster.Path
+	Object    unstructured.Unstructured
+}
+
+// listResources lists the resource in the workspaces, with a single wildcard request across
+// all logical clusters when the user is allowed to, or else by requesting each workspace.
+func (o *GetOptions) listResources(ctx context.Context, gvr schema.GroupVersionResource, workspaces []workspaceInfo) ([]resourceRow, error) {
+	if len(workspaces) > 1 {
+		rows, err := o.listResourcesWithWildcard(ctx, gvr, workspaces)
+		if err == nil {
+			return rows, nil
+		}
+		if !apierrors.IsForbidden(err) && !apierrors.IsNotFound(err) && !apierrors.IsMethodNotSupported(err) {
+			return nil, err
+		}
+	}
+
+	var rows []resourceRow
+	var errs []error
+	for _, ws := range workspaces {
+		objects, err := o.listInCluster(ctx, ws.Path, gvr, o.Namespace)
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+			// the resource is not readable or not available in this workspace
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error listing %s in %s: %w", gvr.GroupResource(), ws.Path, err))
+			continue
+		}
+		for _, obj := range objects {
+			rows = append(rows, resourceRow{Workspace: ws.Path, Object: obj})
+		}
+	}
+	return rows, utilerrors.NewAggregate(errs)
+}
+
+func (o *GetOptions) listResourcesWithWildcard(ctx context.Context, gvr schema.GroupVersionResource, workspaces []workspaceInfo) ([]resourceRow, error) {
+	paths := make(map[logicalcluster.Name]logicalcluster.Path, len(workspaces))
+	for _, ws := range workspaces {
+		cluster := ws.Cluster
+		if cluster.Empty() {
+			var err error
+			if cluster, err = o.getClusterName(ctx, ws.Path); err != nil {
+				return nil, err
+			}
+		}
+		paths[cluster] = ws.Path
+	}
+
+	objects, err := o.listWildcard(ctx, gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []resourceRow
+	for _, obj := range objects {
+		path, ok := paths[logicalcluster.From(&obj)]
+		if !ok {
+			continue
+		}
+		if o.Namespace != "" && obj.GetNamespace() != o.Namespace {
+			continue
+		}
+		rows = append(rows, resourceRow{Workspace: path, Object: obj})
+	}
+	return rows, nil
+}
+
+func printRows(w io.Writer, rows []resourceRow, namespaced bool, now time.Time) error {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if a, b := rows[i].Workspace.String(), rows[j].Workspace.String(); a != b {
+			return a < b
+		}
+		if a, b := rows[i].Object.GetNamespace(), rows[j].Object.GetNamespace(); a != b {
+			return a < b
+		}
+		return rows[i].Object.GetName() < rows[j].Object.GetName()
+	})
+
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	columns := []string{"WORKSPACE", "NAME", "AGE"}
+	if namespaced {
+		columns = []string{"WORKSPACE", "NAMESPACE", "NAME", "AGE"}
+	}
+	if _, err := fmt.Fprintln(out, strings.Join(columns, "\t")); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		age := "<unknown>"
+		if created := row.Object.GetCreationTimestamp(); !created.IsZero() {
+			age = duration.HumanDuration(now.Sub(created.Time))
+		}
+		values := []string{row.Workspace.String(), row.Object.GetName(), age}
+		if namespaced {
+			values = []string{row.Workspace.String(), row.Object.GetNamespace(), row.Object.GetName(), age}
+		}
+		if _, err := fmt.Fprintln(out, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveResource maps the resource to its preferred version, using the discovery of the current workspace.
+func resolveResource(config *rest.Config, resource string) (*meta.RESTMapping, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find resource %q in the current workspace: %w", resource, err)
+	}
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// clusterConfigFor returns a copy of the config pointing to the kcp server base URL, for cluster-aware clients.
+func clusterConfigFor(config *rest.Config) (*rest.Config, error) {
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return clusterConfig, nil
+}
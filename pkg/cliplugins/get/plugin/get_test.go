@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func newConfigMap(cluster logicalcluster.Name, namespace, name string, created time.Time) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetCreationTimestamp(metav1.NewTime(created))
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: cluster.String()})
+	return obj
+}
+
+func newTestOptions(t *testing.T) (*GetOptions, *bytes.Buffer) {
+	t.Helper()
+
+	streams, _, _, errOut := genericclioptions.NewTestIOStreams()
+	o := NewGetOptions(streams)
+
+	tree := map[string][]workspaceInfo{
+		"root:org": {
+			{Path: logicalcluster.NewPath("root:org:team-b"), Cluster: "cluster-b"},
+			{Path: logicalcluster.NewPath("root:org:team-a"), Cluster: "cluster-a"},
+		},
+		"root:org:team-a": {
+			{Path: logicalcluster.NewPath("root:org:team-a:private"), Cluster: "cluster-private"},
+		},
+	}
+	o.listWorkspaces = func(ctx context.Context, path logicalcluster.Path) ([]workspaceInfo, error) {
+		if path.String() == "root:org:team-a:private" {
+			return nil, apierrors.NewForbidden(schema.GroupResource{Group: "tenancy.kcp.io", Resource: "workspaces"}, "", nil)
+		}
+		return tree[path.String()], nil
+	}
+	o.getClusterName = func(ctx context.Context, path logicalcluster.Path) (logicalcluster.Name, error) {
+		return "cluster-org", nil
+	}
+	return o, errOut
+}
+
+func TestWalkWorkspaces(t *testing.T) {
+	o, errOut := newTestOptions(t)
+
+	descendants, err := o.walkWorkspaces(context.Background(), logicalcluster.NewPath("root:org"))
+	require.NoError(t, err)
+
+	var paths []string
+	for _, ws := range descendants {
+		paths = append(paths, ws.Path.String())
+	}
+	require.Equal(t, []string{"root:org:team-a", "root:org:team-b", "root:org:team-a:private"}, paths)
+	require.Contains(t, errOut.String(), "Skipping the workspaces under root:org:team-a:private")
+}
+
+func TestListResources(t *testing.T) {
+	created := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	workspaces := []workspaceInfo{
+		{Path: logicalcluster.NewPath("root:org")},
+		{Path: logicalcluster.NewPath("root:org:team-a"), Cluster: "cluster-a"},
+		{Path: logicalcluster.NewPath("root:org:team-b"), Cluster: "cluster-b"},
+	}
+	objects := map[logicalcluster.Name][]unstructured.Unstructured{
+		"cluster-org":   {newConfigMap("cluster-org", "default", "settings", created)},
+		"cluster-a":     {newConfigMap("cluster-a", "default", "settings", created), newConfigMap("cluster-a", "kube-system", "ca", created)},
+		"cluster-b":     {newConfigMap("cluster-b", "default", "settings", created)},
+		"cluster-other": {newConfigMap("cluster-other", "default", "settings", created)},
+	}
+	clusters := map[string]logicalcluster.Name{
+		"root:org":        "cluster-org",
+		"root:org:team-a": "cluster-a",
+		"root:org:team-b": "cluster-b",
+	}
+
+	wildcard := func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+		var all []unstructured.Unstructured
+		for _, objs := range objects {
+			all = append(all, objs...)
+		}
+		return all, nil
+	}
+	forbiddenWildcard := func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+		return nil, apierrors.NewForbidden(gvr.GroupResource(), "", nil)
+	}
+	inCluster := func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+		if path.String() == "root:org:team-b" {
+			return nil, apierrors.NewForbidden(gvr.GroupResource(), "", nil)
+		}
+		var objs []unstructured.Unstructured
+		for _, obj := range objects[clusters[path.String()]] {
+			if namespace == "" || obj.GetNamespace() == namespace {
+				objs = append(objs, obj)
+			}
+		}
+		return objs, nil
+	}
+
+	tests := []struct {
+		name      string
+		wildcard  func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+		namespace string
+		want      string
+	}{
+		{
+			name:     "wildcard",
+			wildcard: wildcard,
+			want: `WORKSPACE         NAMESPACE     NAME       AGE
+root:org          default       settings   2d
+root:org:team-a   default       settings   2d
+root:org:team-a   kube-system   ca         2d
+root:org:team-b   default       settings   2d
+`,
+		},
+		{
+			name:      "wildcard in a namespace",
+			wildcard:  wildcard,
+			namespace: "kube-system",
+			want: `WORKSPACE         NAMESPACE     NAME   AGE
+root:org:team-a   kube-system   ca     2d
+`,
+		},
+		{
+			name:     "fan-out when wildcard is forbidden, skipping the forbidden workspaces",
+			wildcard: forbiddenWildcard,
+			want: `WORKSPACE         NAMESPACE     NAME       AGE
+root:org          default       settings   2d
+root:org:team-a   default       settings   2d
+root:org:team-a   kube-system   ca         2d
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _ := newTestOptions(t)
+			o.listWildcard = tt.wildcard
+			o.listInCluster = inCluster
+			o.Namespace = tt.namespace
+
+			rows, err := o.listResources(context.Background(), configMapsGVR, workspaces)
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+			require.NoError(t, printRows(&out, rows, true, created.Add(48*time.Hour)))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestPrintRowsClusterScoped(t *testing.T) {
+	obj := unstructured.Unstructured{}
+	obj.SetName("widgets")
+
+	var out bytes.Buffer
+	require.NoError(t, printRows(&out, []resourceRow{{Workspace: logicalcluster.NewPath("root"), Object: obj}}, false, time.Now()))
+	require.Equal(t, []string{"WORKSPACE   NAME      AGE", "root        widgets   <unknown>"}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/logs/plugin"
+)
+
+var (
+	logsExample = `
+	# Print the events of the current workspace of the last hour.
+	%[1]s logs
+
+	# Print the warnings of the current workspace of the last 10 minutes.
+	%[1]s logs --warnings --since 10m
+`
+)
+
+// New provides a command for printing the recent events of the current workspace.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	logsOptions := plugin.NewLogsOptions(streams)
+	cmd := &cobra.Command{
+		Use:          "logs [--since <duration>] [--warnings]",
+		Short:        "Print the recent events recorded in the current workspace",
+		Example:      fmt.Sprintf(logsExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := logsOptions.Complete(); err != nil {
+				return err
+			}
+			if err := logsOptions.Validate(); err != nil {
+				return err
+			}
+			return logsOptions.Run(c.Context())
+		},
+	}
+	logsOptions.BindFlags(cmd)
+
+	return cmd
+}
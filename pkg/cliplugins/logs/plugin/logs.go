@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// LogsOptions contains the options for printing the recent events of the current workspace.
+type LogsOptions struct {
+	*base.Options
+
+	// Since is how far back the events are printed.
+	Since time.Duration
+	// WarningsOnly only prints the events of type Warning.
+	WarningsOnly bool
+
+	// for testing
+	listEvents func(ctx context.Context) ([]corev1.Event, error)
+	now        func() time.Time
+}
+
+// NewLogsOptions returns a new LogsOptions.
+func NewLogsOptions(streams genericclioptions.IOStreams) *LogsOptions {
+	return &LogsOptions{
+		Options: base.NewOptions(streams),
+		Since:   time.Hour,
+		now:     time.Now,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *LogsOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().DurationVar(&o.Since, "since", o.Since, "Only print the events more recent than the duration, e.g. 5m or 2h")
+	cmd.Flags().BoolVar(&o.WarningsOnly, "warnings", o.WarningsOnly, "Only print the events of type Warning")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *LogsOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if o.listEvents == nil {
+		config, err := o.ClientConfig.ClientConfig()
+		if err != nil {
+			return err
+		}
+		kubeClient, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return err
+		}
+		o.listEvents = func(ctx context.Context) ([]corev1.Event, error) {
+			events, err := kubeClient.CoreV1().Events(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return events.Items, nil
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the LogsOptions are complete and usable.
+func (o *LogsOptions) Validate() error {
+	if o.Since <= 0 {
+		return errors.New("--since must be positive")
+	}
+	return o.Options.Validate()
+}
+
+// Run prints the recent events of the current workspace, oldest first.
+func (o *LogsOptions) Run(ctx context.Context) error {
+	events, err := o.listEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing the events of the current workspace: %w", err)
+	}
+
+	now := o.now()
+	var recent []corev1.Event
+	for _, event := range events {
+		if o.WarningsOnly && event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		if now.Sub(lastSeen(&event)) > o.Since {
+			continue
+		}
+		recent = append(recent, event)
+	}
+	if len(recent) == 0 {
+		_, err := fmt.Fprintf(o.ErrOut, "No events in the last %s.\n", o.Since)
+		return err
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return lastSeen(&recent[i]).Before(lastSeen(&recent[j]))
+	})
+
+	out := printers.GetNewTabWriter(o.Out)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE\n"); err != nil {
+		return err
+	}
+	for _, event := range recent {
+		object := fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name)
+		if event.InvolvedObject.Namespace != "" {
+			object = event.InvolvedObject.Namespace + "/" + object
+		}
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\n", duration.HumanDuration(now.Sub(lastSeen(&event))), event.Type, event.Reason, object, event.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastSeen returns when the event was last observed, falling back to when it was first recorded.
+func lastSeen(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
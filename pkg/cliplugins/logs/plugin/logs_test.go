@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestLogs(t *testing.T) {
+	now := time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+	events := []corev1.Event{
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			LastTimestamp:  metav1.NewTime(now.Add(-5 * time.Minute)),
+		},
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "team.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Namespace", Name: "team"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Created",
+			Message:        "Created namespace team",
+			EventTime:      metav1.NewMicroTime(now.Add(-30 * time.Minute)),
+		},
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.0", Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedScheduling",
+			Message:        "no nodes available",
+		},
+	}
+
+	tests := map[string]struct {
+		warningsOnly bool
+		want         string
+	}{
+		"all events": {
+			want: "" +
+				"LAST SEEN   TYPE      REASON    OBJECT            MESSAGE\n" +
+				"30m         Normal    Created   Namespace/team    Created namespace team\n" +
+				"5m          Warning   BackOff   default/Pod/web   Back-off restarting failed container\n",
+		},
+		"warnings only": {
+			warningsOnly: true,
+			want: "" +
+				"LAST SEEN   TYPE      REASON    OBJECT            MESSAGE\n" +
+				"5m          Warning   BackOff   default/Pod/web   Back-off restarting failed container\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewLogsOptions(streams)
+			opts.WarningsOnly = tt.warningsOnly
+			opts.now = func() time.Time { return now }
+			opts.listEvents = func(ctx context.Context) ([]corev1.Event, error) {
+				return events, nil
+			}
+
+			require.NoError(t, opts.Validate())
+			require.NoError(t, opts.Run(context.Background()))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestLogsNoEvents(t *testing.T) {
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	opts := NewLogsOptions(streams)
+	opts.Since = 10 * time.Minute
+	opts.listEvents = func(ctx context.Context) ([]corev1.Event, error) {
+		return nil, nil
+	}
+
+	require.NoError(t, opts.Run(context.Background()))
+	require.Empty(t, out.String())
+	require.Equal(t, "No events in the last 10m0s.\n", errOut.String())
+}
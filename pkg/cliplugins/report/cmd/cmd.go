@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/report/plugin"
+)
+
+var (
+	usageExample = `
+	# Report the number of objects, the estimated storage and the bound APIs of the current workspace.
+	%[1]s report usage
+
+	# Report the usage of the current workspace and of all its descendants as CSV.
+	%[1]s report usage --recursive -o csv
+`
+)
+
+// New provides a command for reporting on workspaces.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cliName := "kubectl"
+	if pflag.CommandLine.Name() == "kubectl-kcp" {
+		cliName = "kubectl kcp"
+	}
+
+	cmd := &cobra.Command{
+		Use:              "report",
+		Short:            "Report on workspaces",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	usageOptions := plugin.NewUsageOptions(streams)
+	usageCmd := &cobra.Command{
+		Use:          "usage [--recursive] [-o table|json|csv]",
+		Short:        "Report the object count, estimated storage and bound APIs of workspaces",
+		Example:      fmt.Sprintf(usageExample, cliName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := usageOptions.Complete(); err != nil {
+				return err
+			}
+			if err := usageOptions.Validate(); err != nil {
+				return err
+			}
+			return usageOptions.Run(c.Context())
+		},
+	}
+	usageOptions.BindFlags(usageCmd)
+	cmd.AddCommand(usageCmd)
+
+	return cmd
+}
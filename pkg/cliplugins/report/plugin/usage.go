@@ -0,0 +1,366 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// UsageOptions contains options for reporting the usage of workspaces.
+type UsageOptions struct {
+	*base.Options
+
+	// Recursive reports the usage of the descendants of the current workspace too.
+	Recursive bool
+	// Output is the output format, one of table, json or csv.
+	Output string
+
+	// for testing
+	listChildWorkspaces func(ctx context.Context, path logicalcluster.Path) ([]string, error)
+	listResources       func(ctx context.Context, path logicalcluster.Path) ([]schema.GroupVersionResource, error)
+	listObjects         func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	listAPIBindings     func(ctx context.Context, path logicalcluster.Path) ([]apisv1alpha1.APIBinding, error)
+}
+
+// NewUsageOptions returns a new UsageOptions.
+func NewUsageOptions(streams genericclioptions.IOStreams) *UsageOptions {
+	return &UsageOptions{
+		Options: base.NewOptions(streams),
+		Output:  "table",
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *UsageOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "r", o.Recursive, "Report the usage of the descendants of the current workspace readable by the user too")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "Output format, one of table, json or csv")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *UsageOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(clusterConfig)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(clusterConfig)
+	if err != nil {
+		return err
+	}
+
+	if o.listChildWorkspaces == nil {
+		o.listChildWorkspaces = func(ctx context.Context, path logicalcluster.Path) ([]string, error) {
+			workspaces, err := kcpClusterClient.Cluster(path).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var children []string
+			for _, ws := range workspaces.Items {
+				if ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady {
+					children = append(children, ws.Name)
+				}
+			}
+			return children, nil
+		}
+	}
+	if o.listResources == nil {
+		o.listResources = func(ctx context.Context, path logicalcluster.Path) ([]schema.GroupVersionResource, error) {
+			resources, err := discovery.ServerPreferredResources(kcpClusterClient.Cluster(path).Discovery())
+			if err != nil && len(resources) == 0 {
+				return nil, err
+			}
+			resources = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, resources)
+			gvrs, err := discovery.GroupVersionResources(resources)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]schema.GroupVersionResource, 0, len(gvrs))
+			for gvr := range gvrs {
+				ret = append(ret, gvr)
+			}
+			return ret, nil
+		}
+	}
+	if o.listObjects == nil {
+		o.listObjects = func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+			list, err := dynamicClusterClient.Cluster(path).Resource(gvr).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		}
+	}
+	if o.listAPIBindings == nil {
+		o.listAPIBindings = func(ctx context.Context, path logicalcluster.Path) ([]apisv1alpha1.APIBinding, error) {
+			bindings, err := kcpClusterClient.Cluster(path).ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return bindings.Items, nil
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the UsageOptions are complete and usable.
+func (o *UsageOptions) Validate() error {
+	switch o.Output {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("invalid value %q for --output; valid values are table, json, csv", o.Output)
+	}
+	return o.Options.Validate()
+}
+
+// workspaceUsage is the usage of a workspace.
+type workspaceUsage struct {
+	Workspace string `json:"workspace"`
+	// Objects is the number of objects in the workspace.
+	Objects int `json:"objects"`
+	// StorageBytes is an estimate of the storage used by the objects, i.e., the size of their JSON serialization.
+	StorageBytes int64 `json:"storageBytes"`
+	// Resources is the number of objects per resource.
+	Resources map[string]int `json:"resources,omitempty"`
+	// APIBindings are the APIs bound in the workspace.
+	APIBindings []boundAPI `json:"apiBindings,omitempty"`
+}
+
+// boundAPI is an APIBinding of a workspace.
+type boundAPI struct {
+	Name      string   `json:"name"`
+	Export    string   `json:"export"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Run reports the usage of the current workspace, and of its descendants if requested.
+func (o *UsageOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	paths := []logicalcluster.Path{currentClusterName}
+	if o.Recursive {
+		descendants, err := o.walkWorkspaces(ctx, currentClusterName)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, descendants...)
+	}
+
+	usages := make([]workspaceUsage, 0, len(paths))
+	for _, path := range paths {
+		usage, err := o.workspaceUsage(ctx, path)
+		if err != nil {
+			return err
+		}
+		usages = append(usages, *usage)
+	}
+
+	switch o.Output {
+	case "json":
+		data, err := json.MarshalIndent(usages, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	case "csv":
+		return printUsageCSV(o.Out, usages)
+	default:
+		return printUsageTable(o.Out, usages)
+	}
+}
+
+// walkWorkspaces returns the descendants of the workspace, skipping the subtrees the user is not allowed to read.
+func (o *UsageOptions) walkWorkspaces(ctx context.Context, path logicalcluster.Path) ([]logicalcluster.Path, error) {
+	var descendants []logicalcluster.Path
+	children, err := o.listChildWorkspaces(ctx, path)
+	if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) {
+		fmt.Fprintf(o.ErrOut, "Skipping the workspaces under %s: %v\n", path, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(children)
+	for _, child := range children {
+		descendants = append(descendants, path.Join(child))
+		grandChildren, err := o.walkWorkspaces(ctx, path.Join(child))
+		if err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, grandChildren...)
+	}
+	return descendants, nil
+}
+
+func (o *UsageOptions) workspaceUsage(ctx context.Context, path logicalcluster.Path) (*workspaceUsage, error) {
+	usage := &workspaceUsage{Workspace: path.String(), Resources: map[string]int{}}
+
+	gvrs, err := o.listResources(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("error discovering the resources of %s: %w", path, err)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+
+	// the same objects can be served by several resources, e.g. events and events.events.k8s.io.
+	seen := map[types.UID]bool{}
+	for _, gvr := range gvrs {
+		objects, err := o.listObjects(ctx, path, gvr)
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s in %s: %w", gvr.GroupResource(), path, err)
+		}
+		for i := range objects {
+			obj := &objects[i]
+			if uid := obj.GetUID(); uid != "" {
+				if seen[uid] {
+					continue
+				}
+				seen[uid] = true
+			}
+			data, err := obj.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			usage.Objects++
+			usage.StorageBytes += int64(len(data))
+			usage.Resources[gvr.GroupResource().String()]++
+		}
+	}
+
+	bindings, err := o.listAPIBindings(ctx, path)
+	if err != nil && !apierrors.IsForbidden(err) && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error listing the APIBindings of %s: %w", path, err)
+	}
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].Name < bindings[j].Name })
+	for _, binding := range bindings {
+		api := boundAPI{Name: binding.Name}
+		if ref := binding.Spec.Reference.Export; ref != nil {
+			api.Export = ref.Name
+			if ref.Path != "" {
+				api.Export = logicalcluster.NewPath(ref.Path).Join(ref.Name).String()
+			}
+		}
+		for _, r := range binding.Status.BoundResources {
+			api.Resources = append(api.Resources, schema.GroupResource{Group: r.Group, Resource: r.Resource}.String())
+		}
+		usage.APIBindings = append(usage.APIBindings, api)
+	}
+
+	return usage, nil
+}
+
+func apiBindingsColumn(apis []boundAPI) string {
+	if len(apis) == 0 {
+		return "<none>"
+	}
+	names := make([]string, 0, len(apis))
+	for _, api := range apis {
+		names = append(names, api.Export)
+	}
+	return strings.Join(names, ",")
+}
+
+func printUsageTable(w io.Writer, usages []workspaceUsage) error {
+	out := printers.GetNewTabWriter(w)
+	defer out.Flush()
+
+	if _, err := fmt.Fprintf(out, "WORKSPACE\tOBJECTS\tSTORAGE\tAPIBINDINGS\n"); err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		storage := resource.NewQuantity(usage.StorageBytes, resource.BinarySI)
+		if _, err := fmt.Fprintf(out, "%s\t%d\t%s\t%s\n", usage.Workspace, usage.Objects, storage, apiBindingsColumn(usage.APIBindings)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printUsageCSV(w io.Writer, usages []workspaceUsage) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"workspace", "objects", "storageBytes", "apiBindings"}); err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		exports := make([]string, 0, len(usage.APIBindings))
+		for _, api := range usage.APIBindings {
+			exports = append(exports, api.Export)
+		}
+		if err := out.Write([]string{usage.Workspace, strconv.Itoa(usage.Objects), strconv.FormatInt(usage.StorageBytes, 10), strings.Join(exports, ";")}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func newObject(apiVersion, kind, name, uid string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetUID(types.UID(uid))
+	return obj
+}
+
+func objectSize(t *testing.T, obj unstructured.Unstructured) int64 {
+	data, err := obj.MarshalJSON()
+	require.NoError(t, err)
+	return int64(len(data))
+}
+
+func newTestUsageOptions(streams genericclioptions.IOStreams) *UsageOptions {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	events := schema.GroupVersionResource{Version: "v1", Resource: "events"}
+	eventsV1 := schema.GroupVersionResource{Group: "events.k8s.io", Version: "v1", Resource: "events"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	objects := map[logicalcluster.Path]map[schema.GroupVersionResource][]unstructured.Unstructured{
+		logicalcluster.NewPath("root:org"): {
+			configMaps: {newObject("v1", "ConfigMap", "settings", "cm-1")},
+			events:     {newObject("v1", "Event", "started", "ev-1")},
+			eventsV1:   {newObject("events.k8s.io/v1", "Event", "started", "ev-1")},
+		},
+		logicalcluster.NewPath("root:org:team"): {
+			configMaps: {newObject("v1", "ConfigMap", "a", "cm-2"), newObject("v1", "ConfigMap", "b", "cm-3")},
+		},
+	}
+
+	opts := NewUsageOptions(streams)
+	opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		CurrentContext: "test",
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://front-proxy/clusters/root:org"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+	}, nil)
+	opts.listChildWorkspaces = func(ctx context.Context, path logicalcluster.Path) ([]string, error) {
+		switch path.String() {
+		case "root:org":
+			return []string{"team", "private"}, nil
+		case "root:org:private":
+			return nil, apierrors.NewForbidden(schema.GroupResource{Group: "tenancy.kcp.dev", Resource: "workspaces"}, "", fmt.Errorf("access denied"))
+		}
+		return nil, nil
+	}
+	opts.listResources = func(ctx context.Context, path logicalcluster.Path) ([]schema.GroupVersionResource, error) {
+		return []schema.GroupVersionResource{secrets, configMaps, events, eventsV1}, nil
+	}
+	opts.listObjects = func(ctx context.Context, path logicalcluster.Path, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+		if gvr == secrets {
+			return nil, apierrors.NewForbidden(gvr.GroupResource(), "", fmt.Errorf("access denied"))
+		}
+		return objects[path][gvr], nil
+	}
+	opts.listAPIBindings = func(ctx context.Context, path logicalcluster.Path) ([]apisv1alpha1.APIBinding, error) {
+		if path.String() != "root:org" {
+			return nil, nil
+		}
+		return []apisv1alpha1.APIBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.BindingReference{Export: &apisv1alpha1.ExportBindingReference{Path: "root:providers", Name: "widgets"}},
+				},
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "example.io", Resource: "widgets"}},
+				},
+			},
+		}, nil
+	}
+	return opts
+}
+
+func TestUsageJSON(t *testing.T) {
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	opts := newTestUsageOptions(streams)
+	opts.Output = "json"
+
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+
+	var usages []workspaceUsage
+	require.NoError(t, json.Unmarshal(out.Bytes(), &usages))
+	require.Equal(t, []workspaceUsage{
+		{
+			Workspace:    "root:org",
+			Objects:      2,
+			StorageBytes: objectSize(t, newObject("v1", "ConfigMap", "settings", "cm-1")) + objectSize(t, newObject("v1", "Event", "started", "ev-1")),
+			Resources:    map[string]int{"configmaps": 1, "events": 1},
+			APIBindings:  []boundAPI{{Name: "widgets", Export: "root:providers:widgets", Resources: []string{"widgets.example.io"}}},
+		},
+	}, usages)
+}
+
+func TestUsageRecursiveCSV(t *testing.T) {
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	opts := newTestUsageOptions(streams)
+	opts.Recursive = true
+	opts.Output = "csv"
+
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+
+	orgStorage := objectSize(t, newObject("v1", "ConfigMap", "settings", "cm-1")) + objectSize(t, newObject("v1", "Event", "started", "ev-1"))
+	teamStorage := objectSize(t, newObject("v1", "ConfigMap", "a", "cm-2")) + objectSize(t, newObject("v1", "ConfigMap", "b", "cm-3"))
+	require.Equal(t, ""+
+		"workspace,objects,storageBytes,apiBindings\n"+
+		fmt.Sprintf("root:org,2,%d,root:providers:widgets\n", orgStorage)+
+		"root:org:private,0,0,\n"+
+		fmt.Sprintf("root:org:team,2,%d,\n", teamStorage),
+		out.String())
+	require.Contains(t, errOut.String(), "Skipping the workspaces under root:org:private")
+}
+
+func TestUsageValidate(t *testing.T) {
+	opts := NewUsageOptions(genericclioptions.NewTestIOStreamsDiscard())
+	opts.Output = "yaml"
+	require.EqualError(t, opts.Validate(), `invalid value "yaml" for --output; valid values are table, json, csv`)
+}
@@ -34,6 +34,16 @@ var (
 
 	# Directly apply the manifest
 	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> -o - | KUBECONFIG=<pcluster-config> kubectl apply -f -
+
+	# Check the physical cluster before writing the manifest
+	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> -o syncer.yaml --preflight --downstream-kubeconfig <pcluster-config>
+`
+	checkExample = `
+	# Check that a physical cluster is ready to run a syncer for the current workspace.
+	%[1]s workload check --downstream-kubeconfig <pcluster-config>
+
+	# Check that a physical cluster serves the resources to sync.
+	%[1]s workload check --downstream-kubeconfig <pcluster-config> --resources=deployments.apps,services
 `
 	cordonExample = `
 	# Mark a sync target as unschedulable.
@@ -44,8 +54,14 @@ var (
 	%[1]s workload uncordon <sync-target-name>
 `
 	drainExample = `
-	# Start draining a sync target in preparation for maintenance.
+	# Drain a sync target in preparation for maintenance, and wait for its resources to be evicted.
 	%[1]s workload drain <sync-target-name>
+
+	# Drain a sync target, and give up waiting for its resources to be evicted after 10 minutes.
+	%[1]s workload drain <sync-target-name> --timeout=10m
+
+	# Start draining a sync target whose syncer is not ready, without waiting for the eviction.
+	%[1]s workload drain <sync-target-name> --force --timeout=-1s
 `
 )
 
@@ -90,6 +106,34 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	syncOptions.BindFlags(enableSyncerCmd)
 	cmd.AddCommand(enableSyncerCmd)
 
+	// Check command
+	checkOptions := plugin.NewPreflightOptions(streams)
+
+	checkCmd := &cobra.Command{
+		Use:          "check --downstream-kubeconfig <pcluster-config> [--resources=<resource1>,<resource2>..]",
+		Short:        "Check that a physical cluster is ready to run a syncer, and print a report of the checks.",
+		Example:      fmt.Sprintf(checkExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+
+			if err := checkOptions.Complete(); err != nil {
+				return err
+			}
+
+			if err := checkOptions.Validate(); err != nil {
+				return err
+			}
+
+			return checkOptions.Run(c.Context())
+		},
+	}
+
+	checkOptions.BindFlags(checkCmd)
+	cmd.AddCommand(checkCmd)
+
 	// Cordon command
 	cordonOpts := plugin.NewCordonOptions(streams)
 	cordonOpts.Cordon = true
@@ -153,7 +197,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 	drainCmd := &cobra.Command{
 		Use:          "drain <sync-target-name>",
-		Short:        "Start draining sync target in preparation for maintenance",
+		Short:        "Drain sync target in preparation for maintenance, and report the eviction progress",
 		Example:      fmt.Sprintf(drainExample, "kubectl kcp"),
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
@@ -0,0 +1,341 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// MinimumDownstreamVersion is the minimum Kubernetes version of the physical clusters a syncer can run in.
+const MinimumDownstreamVersion = "v1.24.0"
+
+// PreflightStatus is the status of a preflight check.
+type PreflightStatus string
+
+const (
+	PreflightPass    PreflightStatus = "PASS"
+	PreflightWarning PreflightStatus = "WARN"
+	PreflightFail    PreflightStatus = "FAIL"
+)
+
+// PreflightCheck is the result of a preflight check of a physical cluster.
+type PreflightCheck struct {
+	Name    string
+	Status  PreflightStatus
+	Message string
+}
+
+// PreflightOptions contains options for checking that a physical cluster is ready to run a syncer.
+type PreflightOptions struct {
+	*base.Options
+
+	// DownstreamKubeconfig is the path to the kubeconfig of the physical cluster.
+	DownstreamKubeconfig string
+	// DownstreamContext is the context of the kubeconfig of the physical cluster. The current context
+	// is used if empty.
+	DownstreamContext string
+	// ResourcesToSync is a list of fully-qualified resource names that should be synced by the syncer.
+	ResourcesToSync []string
+	// ConnectivityTimeout is the timeout of the connection to kcp.
+	ConnectivityTimeout time.Duration
+}
+
+// NewPreflightOptions returns a new PreflightOptions.
+func NewPreflightOptions(streams genericclioptions.IOStreams) *PreflightOptions {
+	return &PreflightOptions{
+		Options: base.NewOptions(streams),
+
+		ConnectivityTimeout: 5 * time.Second,
+	}
+}
+
+// BindFlags binds fields PreflightOptions as command line flags to cmd's flagset.
+func (o *PreflightOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	o.bindDownstreamFlags(cmd)
+	cmd.Flags().StringSliceVar(&o.ResourcesToSync, "resources", o.ResourcesToSync, "Resources to synchronize with kcp.")
+}
+
+func (o *PreflightOptions) bindDownstreamFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.DownstreamKubeconfig, "downstream-kubeconfig", o.DownstreamKubeconfig, "Path to the kubeconfig of the physical cluster to check.")
+	cmd.Flags().StringVar(&o.DownstreamContext, "downstream-context", o.DownstreamContext, "The context of the kubeconfig of the physical cluster to check. Defaults to the current context.")
+	cmd.Flags().DurationVar(&o.ConnectivityTimeout, "connectivity-timeout", o.ConnectivityTimeout, "Timeout of the connection to kcp.")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *PreflightOptions) Complete() error {
+	return o.Options.Complete()
+}
+
+// Validate validates the PreflightOptions are complete and usable.
+func (o *PreflightOptions) Validate() error {
+	var errs []error
+
+	if err := o.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.DownstreamKubeconfig == "" {
+		errs = append(errs, errors.New("--downstream-kubeconfig is required"))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run checks the physical cluster and prints a report of the checks.
+func (o *PreflightOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	kcpURL, _, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	resources := sets.NewString(o.ResourcesToSync...)
+	// secrets and configmaps are always needed.
+	resources.Insert("secrets", "configmaps")
+
+	return o.check(ctx, kcpURL, resources.List())
+}
+
+// check runs the checks of the physical cluster, prints their report and returns an error if any of them failed.
+func (o *PreflightOptions) check(ctx context.Context, kcpURL *url.URL, resources []string) error {
+	downstreamConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: o.DownstreamKubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: o.DownstreamContext},
+	).ClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig of the physical cluster: %w", err)
+	}
+
+	checker, err := newPreflightChecker(downstreamConfig, o.ConnectivityTimeout)
+	if err != nil {
+		return err
+	}
+
+	checks := checker.run(ctx, kcpURL, resources)
+	if failed := printPreflightReport(o.ErrOut, checks); failed {
+		return errors.New("the physical cluster failed the preflight checks")
+	}
+	return nil
+}
+
+type preflightChecker struct {
+	discovery  discovery.DiscoveryInterface
+	createSSAR func(ctx context.Context, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error)
+	dial       func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+func newPreflightChecker(downstreamConfig *rest.Config, connectivityTimeout time.Duration) (*preflightChecker, error) {
+	kubeClient, err := kubernetes.NewForConfig(downstreamConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client of the physical cluster: %w", err)
+	}
+
+	dialer := &net.Dialer{Timeout: connectivityTimeout}
+	return &preflightChecker{
+		discovery: kubeClient.Discovery(),
+		createSSAR: func(ctx context.Context, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error) {
+			return kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		},
+		dial: dialer.DialContext,
+	}, nil
+}
+
+func (c *preflightChecker) run(ctx context.Context, kcpURL *url.URL, resources []string) []PreflightCheck {
+	return []PreflightCheck{
+		c.checkVersion(),
+		c.checkAPIs(resources),
+		c.checkPermissions(ctx, resources),
+		c.checkConnectivity(ctx, kcpURL),
+	}
+}
+
+// checkVersion checks that the version of the physical cluster is supported by the syncer.
+func (c *preflightChecker) checkVersion() PreflightCheck {
+	check := PreflightCheck{Name: "Version"}
+
+	info, err := c.discovery.ServerVersion()
+	if err != nil {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("failed to get the version: %v", err)
+		return check
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("failed to parse the version %q: %v", info.GitVersion, err)
+		return check
+	}
+	if serverVersion.LessThan(version.MustParseGeneric(MinimumDownstreamVersion)) {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("%s is older than the minimum supported version %s", info.GitVersion, MinimumDownstreamVersion)
+		return check
+	}
+
+	check.Status, check.Message = PreflightPass, info.GitVersion
+	return check
+}
+
+// checkAPIs checks that the APIs required by the syncer, and the ones of the resources to sync, are served
+// by the physical cluster.
+func (c *preflightChecker) checkAPIs(resources []string) PreflightCheck {
+	check := PreflightCheck{Name: "APIs"}
+
+	// Failures to discover some of the groups are reported as missing APIs below.
+	_, resourceLists, err := c.discovery.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("failed to discover the APIs: %v", err)
+		return check
+	}
+
+	served := sets.NewString()
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range resourceList.APIResources {
+			served.Insert(qualifiedResource(resource.Name, gv.Group))
+		}
+	}
+
+	required := sets.NewString(resources...).Insert("namespaces", "customresourcedefinitions.apiextensions.k8s.io")
+	if missing := required.Difference(served); missing.Len() > 0 {
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("missing APIs: %s", strings.Join(missing.List(), ", "))
+		return check
+	}
+
+	check.Status, check.Message = PreflightPass, fmt.Sprintf("%d required APIs are served", required.Len())
+	return check
+}
+
+// checkPermissions checks that the current user of the physical cluster can apply the syncer manifests,
+// including the RBAC of the syncer service account, which requires to hold the granted permissions.
+func (c *preflightChecker) checkPermissions(ctx context.Context, resources []string) PreflightCheck {
+	check := PreflightCheck{Name: "RBAC"}
+
+	attributes := []authorizationv1.ResourceAttributes{
+		{Verb: "create", Resource: "namespaces"},
+		{Verb: "create", Resource: "serviceaccounts"},
+		{Verb: "create", Resource: "secrets"},
+		{Verb: "create", Group: "apps", Resource: "deployments"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "roles"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "rolebindings"},
+		{Verb: "get", Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+	}
+	for _, resource := range resources {
+		name, group, _ := strings.Cut(resource, ".")
+		attributes = append(attributes, authorizationv1.ResourceAttributes{Verb: "*", Group: group, Resource: name})
+	}
+
+	var denied []string
+	for i := range attributes {
+		review, err := c.createSSAR(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes[i]},
+		})
+		if err != nil {
+			check.Status, check.Message = PreflightFail, fmt.Sprintf("failed to review the access: %v", err)
+			return check
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, fmt.Sprintf("%s %s", attributes[i].Verb, qualifiedResource(attributes[i].Resource, attributes[i].Group)))
+		}
+	}
+	if len(denied) > 0 {
+		sort.Strings(denied)
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("the syncer manifests cannot be applied, missing permissions: %s", strings.Join(denied, ", "))
+		return check
+	}
+
+	check.Status, check.Message = PreflightPass, "the syncer manifests can be applied"
+	return check
+}
+
+// checkConnectivity checks that kcp can be reached. The connection is established from this machine, which
+// does not guarantee that the syncer can reach kcp from the physical cluster.
+func (c *preflightChecker) checkConnectivity(ctx context.Context, kcpURL *url.URL) PreflightCheck {
+	check := PreflightCheck{Name: "Connectivity"}
+
+	host := kcpURL.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		if kcpURL.Scheme == "http" {
+			host = net.JoinHostPort(host, "80")
+		} else {
+			host = net.JoinHostPort(host, "443")
+		}
+	}
+
+	conn, err := c.dial(ctx, "tcp", host)
+	if err != nil {
+		check.Status, check.Message = PreflightWarning, fmt.Sprintf("kcp cannot be reached at %s from this machine: %v", host, err)
+		return check
+	}
+	conn.Close()
+
+	check.Status, check.Message = PreflightPass, fmt.Sprintf("kcp can be reached at %s from this machine, make sure it can also be reached from the physical cluster", host)
+	return check
+}
+
+// printPreflightReport prints the report of the preflight checks, and returns whether any of them failed.
+func printPreflightReport(out io.Writer, checks []PreflightCheck) bool {
+	failed := false
+	fmt.Fprintln(out, "Preflight checks of the physical cluster:")
+	for _, check := range checks {
+		fmt.Fprintf(out, "  [%s] %s: %s\n", check.Status, check.Name, check.Message)
+		if check.Status == PreflightFail {
+			failed = true
+		}
+	}
+	return failed
+}
+
+// qualifiedResource returns the <resource>.<group> name of a resource, or <resource> for the core group.
+func qualifiedResource(resource, group string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newFakePreflightChecker(gitVersion string, resources []*metav1.APIResourceList, denied map[string]bool, reachable bool) *preflightChecker {
+	discovery := kubefake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &kubeversion.Info{GitVersion: gitVersion}
+	discovery.Resources = resources
+
+	return &preflightChecker{
+		discovery: discovery,
+		createSSAR: func(ctx context.Context, review *authorizationv1.SelfSubjectAccessReview) (*authorizationv1.SelfSubjectAccessReview, error) {
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = !denied[attributes.Verb+" "+qualifiedResource(attributes.Resource, attributes.Group)]
+			return review, nil
+		},
+		dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if !reachable {
+				return nil, errors.New("connection refused")
+			}
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		},
+	}
+}
+
+func TestPreflightChecks(t *testing.T) {
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "namespaces"}, {Name: "secrets"}, {Name: "configmaps"}}},
+		{GroupVersion: "apiextensions.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "customresourcedefinitions"}}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments"}}},
+	}
+	kcpURL, err := url.Parse("https://kcp.example.com")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		gitVersion string
+		resources  []string
+		denied     map[string]bool
+		reachable  bool
+		want       map[string]PreflightStatus
+		wantFailed bool
+	}{
+		"ready": {
+			gitVersion: "v1.25.3",
+			resources:  []string{"configmaps", "deployments.apps", "secrets"},
+			reachable:  true,
+			want:       map[string]PreflightStatus{"Version": PreflightPass, "APIs": PreflightPass, "RBAC": PreflightPass, "Connectivity": PreflightPass},
+		},
+		"old version": {
+			gitVersion: "v1.21.1",
+			resources:  []string{"configmaps", "secrets"},
+			reachable:  true,
+			want:       map[string]PreflightStatus{"Version": PreflightFail, "APIs": PreflightPass, "RBAC": PreflightPass, "Connectivity": PreflightPass},
+			wantFailed: true,
+		},
+		"missing API": {
+			gitVersion: "v1.25.3",
+			resources:  []string{"configmaps", "ingresses.networking.k8s.io", "secrets"},
+			reachable:  true,
+			want:       map[string]PreflightStatus{"Version": PreflightPass, "APIs": PreflightFail, "RBAC": PreflightPass, "Connectivity": PreflightPass},
+			wantFailed: true,
+		},
+		"missing permission": {
+			gitVersion: "v1.25.3",
+			resources:  []string{"configmaps", "deployments.apps", "secrets"},
+			denied:     map[string]bool{"* deployments.apps": true},
+			reachable:  true,
+			want:       map[string]PreflightStatus{"Version": PreflightPass, "APIs": PreflightPass, "RBAC": PreflightFail, "Connectivity": PreflightPass},
+			wantFailed: true,
+		},
+		"unreachable kcp": {
+			gitVersion: "v1.25.3",
+			resources:  []string{"configmaps", "secrets"},
+			want:       map[string]PreflightStatus{"Version": PreflightPass, "APIs": PreflightPass, "RBAC": PreflightPass, "Connectivity": PreflightWarning},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := newFakePreflightChecker(tc.gitVersion, resources, tc.denied, tc.reachable)
+			checks := checker.run(context.Background(), kcpURL, tc.resources)
+
+			got := map[string]PreflightStatus{}
+			for _, check := range checks {
+				got[check.Name] = check.Status
+			}
+			require.Equal(t, tc.want, got)
+
+			var out bytes.Buffer
+			require.Equal(t, tc.wantFailed, printPreflightReport(&out, checks))
+			require.Contains(t, out.String(), "Preflight checks of the physical cluster:")
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"sort"
 	"strings"
@@ -102,12 +103,29 @@ type SyncOptions struct {
 	FeatureGates string
 	// DownstreamNamespaceCleanDelay is the time to wait before deleting of a downstream namespace.
 	DownstreamNamespaceCleanDelay time.Duration
+	// Preflight indicates that the physical cluster is checked before the manifest for the syncer is written.
+	Preflight bool
+	// PreflightOptions contains the options of the preflight checks of the physical cluster.
+	PreflightOptions *PreflightOptions
+	// HTTPProxy is the proxy used by the syncer for the HTTP requests.
+	HTTPProxy string
+	// HTTPSProxy is the proxy used by the syncer for the HTTPS requests, including those to kcp and of the tunnel.
+	HTTPSProxy string
+	// NoProxy is a list of hosts, domains and CIDRs that the syncer reaches without proxy.
+	NoProxy []string
+	// CABundleFile is the path to a file with additional PEM-encoded CA certificates the syncer trusts
+	// for its connections to kcp, e.g. those of a TLS-intercepting proxy.
+	CABundleFile string
+	// ImageRegistry is the registry mirroring the registry of the syncer image.
+	ImageRegistry string
 }
 
 // NewSyncOptions returns a new SyncOptions.
 func NewSyncOptions(streams genericclioptions.IOStreams) *SyncOptions {
+	preflightOptions := NewPreflightOptions(streams)
 	return &SyncOptions{
-		Options: base.NewOptions(streams),
+		Options:          preflightOptions.Options,
+		PreflightOptions: preflightOptions,
 
 		Replicas:                      1,
 		KCPNamespace:                  "default",
@@ -139,6 +157,13 @@ func (o *SyncOptions) BindFlags(cmd *cobra.Command) {
 			"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
 	cmd.Flags().DurationVar(&o.APIImportPollInterval, "api-import-poll-interval", o.APIImportPollInterval, "Polling interval for API import.")
 	cmd.Flags().DurationVar(&o.DownstreamNamespaceCleanDelay, "downstream-namespace-clean-delay", o.DownstreamNamespaceCleanDelay, "Time to wait before deleting a downstream namespaces.")
+	cmd.Flags().BoolVar(&o.Preflight, "preflight", o.Preflight, "Check the physical cluster, and print a report of the checks, before writing the manifest. Requires --downstream-kubeconfig.")
+	cmd.Flags().StringVar(&o.HTTPProxy, "http-proxy", o.HTTPProxy, "The proxy used by the syncer for HTTP requests.")
+	cmd.Flags().StringVar(&o.HTTPSProxy, "https-proxy", o.HTTPSProxy, "The proxy used by the syncer for HTTPS requests, including those to kcp.")
+	cmd.Flags().StringSliceVar(&o.NoProxy, "no-proxy", o.NoProxy, "Hosts, domains and CIDRs the syncer reaches without proxy. The API server of the physical cluster is always reached without proxy.")
+	cmd.Flags().StringVar(&o.CABundleFile, "ca-bundle", o.CABundleFile, "A file with additional PEM-encoded CA certificates the syncer trusts for its connections to kcp, e.g. those of a TLS-intercepting proxy.")
+	cmd.Flags().StringVar(&o.ImageRegistry, "image-registry", o.ImageRegistry, "A registry mirroring the registry of the syncer image, e.g. in air-gapped environments.")
+	o.PreflightOptions.bindDownstreamFlags(cmd)
 }
 
 // Complete ensures all dynamically populated fields are initialized.
@@ -180,6 +205,21 @@ func (o *SyncOptions) Validate() error {
 		errs = append(errs, errors.New("--output-file is required"))
 	}
 
+	if o.HTTPProxy != "" {
+		if _, err := url.Parse(o.HTTPProxy); err != nil {
+			errs = append(errs, fmt.Errorf("invalid --http-proxy: %w", err))
+		}
+	}
+	if o.HTTPSProxy != "" {
+		if _, err := url.Parse(o.HTTPSProxy); err != nil {
+			errs = append(errs, fmt.Errorf("invalid --https-proxy: %w", err))
+		}
+	}
+
+	if o.Preflight && o.PreflightOptions.DownstreamKubeconfig == "" {
+		errs = append(errs, errors.New("--downstream-kubeconfig is required with --preflight"))
+	}
+
 	// see pkg/syncer/shared/GetDNSID
 	if len(o.SyncTargetName)+len(DNSIDPrefix)+8+8+2 > 254 {
 		errs = append(errs, fmt.Errorf("the maximum length of the sync-target-name is %d", MaxSyncTargetNameLength))
@@ -196,17 +236,6 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		return err
 	}
 
-	var outputFile *os.File
-	if o.OutputFile == "-" {
-		outputFile = os.Stdout
-	} else {
-		outputFile, err = os.Create(o.OutputFile)
-		if err != nil {
-			return err
-		}
-		defer outputFile.Close()
-	}
-
 	token, syncerID, syncTarget, err := o.enableSyncerForWorkspace(ctx, config, o.SyncTargetName, o.KCPNamespace)
 	if err != nil {
 		return err
@@ -237,6 +266,12 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		}
 	}
 
+	if o.Preflight {
+		if err := o.PreflightOptions.check(ctx, configURL, expectedResourcesForPermission.List()); err != nil {
+			return err
+		}
+	}
+
 	if o.DownstreamNamespace == "" {
 		o.DownstreamNamespace = syncerID
 	}
@@ -247,9 +282,24 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 	// TODO(marun) It's probably preferable that the syncer and importer are provided a
 	// cluster configuration since they only operate against a single workspace.
 	serverURL := configURL.Scheme + "://" + configURL.Host
-	input := templateInput{
+
+	caData := config.CAData
+	if o.CABundleFile != "" {
+		caBundle, err := os.ReadFile(o.CABundleFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the CA bundle: %w", err)
+		}
+		caData = appendCABundle(caData, caBundle)
+	}
+
+	image := o.SyncerImage
+	if o.ImageRegistry != "" {
+		image = mirrorImage(image, o.ImageRegistry)
+	}
+
+	input := TemplateInput{
 		ServerURL:    serverURL,
-		CAData:       base64.StdEncoding.EncodeToString(config.CAData),
+		CAData:       base64.StdEncoding.EncodeToString(caData),
 		Token:        token,
 		KCPNamespace: o.KCPNamespace,
 		Namespace:    o.DownstreamNamespace,
@@ -258,7 +308,7 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		SyncTarget:     o.SyncTargetName,
 		SyncTargetUID:  string(syncTarget.UID),
 
-		Image:                               o.SyncerImage,
+		Image:                               image,
 		Replicas:                            o.Replicas,
 		ResourcesToSync:                     o.ResourcesToSync,
 		QPS:                                 o.QPS,
@@ -266,13 +316,28 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		FeatureGatesString:                  o.FeatureGates,
 		APIImportPollIntervalString:         o.APIImportPollInterval.String(),
 		DownstreamNamespaceCleanDelayString: o.DownstreamNamespaceCleanDelay.String(),
+
+		HTTPProxy:  o.HTTPProxy,
+		HTTPSProxy: o.HTTPSProxy,
+		NoProxy:    strings.Join(o.NoProxy, ","),
 	}
 
-	resources, err := renderSyncerResources(input, syncerID, expectedResourcesForPermission.List())
+	resources, err := RenderSyncerResources(input, syncerID, expectedResourcesForPermission.List())
 	if err != nil {
 		return err
 	}
 
+	var outputFile *os.File
+	if o.OutputFile == "-" {
+		outputFile = os.Stdout
+	} else {
+		outputFile, err = os.Create(o.OutputFile)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+	}
+
 	_, err = outputFile.Write(resources)
 	if o.OutputFile != "-" {
 		fmt.Fprintf(o.ErrOut, "\nWrote physical cluster manifest to %s for namespace %q. Use\n\n  KUBECONFIG=<pcluster-config> kubectl apply -f %q\n\nto apply it. "+
@@ -281,9 +346,9 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 	return err
 }
 
-// getSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
+// GetSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
 // a valid DNS segment and can be used as namespace or object names.
-func getSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
+func GetSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
 	syncerHash := sha256.Sum224([]byte(syncTarget.UID))
 	base36hash := strings.ToLower(base36.EncodeBytes(syncerHash[:]))
 	return fmt.Sprintf("kcp-syncer-%s-%s", syncTarget.Name, base36hash[:8])
@@ -392,6 +457,11 @@ func (o *SyncOptions) getResourcesForPermission(ctx context.Context, config *res
 			return false, nil //nolint:nilerr
 		}
 
+		// the ServiceAccounts and their RBAC are synced when enabled on the SyncTarget.
+		if syncTarget.Spec.WorkloadRBAC != nil {
+			expectedResourcesForPermission.Insert("serviceaccounts", "roles.rbac.authorization.k8s.io", "rolebindings.rbac.authorization.k8s.io")
+		}
+
 		// skip if there is only the local kubernetes APIExport in the synctarget workspace, since we may not get syncedResources yet.
 		clusterName := logicalcluster.From(syncTarget)
 
@@ -436,7 +506,7 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 		return "", "", nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	syncerID = getSyncerID(syncTarget)
+	syncerID = GetSyncerID(syncTarget)
 
 	syncTargetOwnerReferences := []metav1.OwnerReference{{
 		APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
@@ -494,31 +564,7 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 	// Create a cluster role that provides the syncer the minimal permissions
 	// required by KCP to manage the sync target, and by the syncer virtual
 	// workspace to sync.
-	rules := []rbacv1.PolicyRule{
-		{
-			Verbs:         []string{"sync"},
-			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			ResourceNames: []string{syncTargetName},
-			Resources:     []string{"synctargets"},
-		},
-		{
-			Verbs:         []string{"get", "list", "watch"},
-			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			Resources:     []string{"synctargets"},
-			ResourceNames: []string{syncTargetName},
-		},
-		{
-			Verbs:         []string{"update", "patch"},
-			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			ResourceNames: []string{syncTargetName},
-			Resources:     []string{"synctargets/status"},
-		},
-		{
-			Verbs:     []string{"get", "create", "update", "delete", "list", "watch"},
-			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
-			Resources: []string{"apiresourceimports"},
-		},
-	}
+	rules := SyncerPolicyRules(syncTargetName)
 
 	cr, err := kubeClient.RbacV1().ClusterRoles().Get(ctx,
 		syncerID,
@@ -638,6 +684,36 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 	return string(saTokenBytes), syncerID, syncTarget, nil
 }
 
+// SyncerPolicyRules returns the rules of the cluster role granting the syncer of the
+// given sync target its permissions in the sync target workspace.
+func SyncerPolicyRules(syncTargetName string) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			Verbs:         []string{"sync"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: []string{syncTargetName},
+			Resources:     []string{"synctargets"},
+		},
+		{
+			Verbs:         []string{"get", "list", "watch"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			Resources:     []string{"synctargets"},
+			ResourceNames: []string{syncTargetName},
+		},
+		{
+			Verbs:         []string{"update", "patch"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: []string{syncTargetName},
+			Resources:     []string{"synctargets/status"},
+		},
+		{
+			Verbs:     []string{"get", "create", "update", "delete", "list", "watch"},
+			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"apiresourceimports"},
+		},
+	}
+}
+
 // mergeOwnerReference: merge a slice of ownerReference with a given ownerReferences.
 func mergeOwnerReference(ownerReferences, newOwnerReferences []metav1.OwnerReference) []metav1.OwnerReference {
 	var merged []metav1.OwnerReference
@@ -660,9 +736,9 @@ func mergeOwnerReference(ownerReferences, newOwnerReferences []metav1.OwnerRefer
 	return merged
 }
 
-// templateInput represents the external input required to render the resources to
+// TemplateInput represents the external input required to render the resources to
 // deploy the syncer to a pcluster.
-type templateInput struct {
+type TemplateInput struct {
 	// ServerURL is the logical cluster url the syncer configuration will use
 	ServerURL string
 	// CAData holds the PEM-encoded bytes of the ca certificate(s) a syncer will use to validate
@@ -702,12 +778,19 @@ type templateInput struct {
 	APIImportPollIntervalString string
 	// DownstreamNamespaceCleanDelay is the time to delay before cleaning the downstream namespace as a string.
 	DownstreamNamespaceCleanDelayString string
+	// HTTPProxy is the proxy the syncer uses for HTTP requests.
+	HTTPProxy string
+	// HTTPSProxy is the proxy the syncer uses for HTTPS requests.
+	HTTPSProxy string
+	// NoProxy is the comma-separated list of hosts the syncer reaches without proxy, in addition
+	// to the API server of the pcluster.
+	NoProxy string
 }
 
 // templateArgs represents the full set of arguments required to render the resources
 // required to deploy the syncer.
 type templateArgs struct {
-	templateInput
+	TemplateInput
 	// ServiceAccount is the name of the service account to create in the syncer
 	// namespace on the pcluster.
 	ServiceAccount string
@@ -740,16 +823,16 @@ type templateArgs struct {
 	DeploymentApp string
 }
 
-// renderSyncerResources renders the resources required to deploy a syncer to a pcluster.
+// RenderSyncerResources renders the resources required to deploy a syncer to a pcluster.
 //
 // TODO(marun) Is it possible to set owner references in a set of applied resources? Ideally the
 // cluster role and role binding would be owned by the namespace to ensure cleanup on deletion
 // of the namespace.
-func renderSyncerResources(input templateInput, syncerID string, resourceForPermission []string) ([]byte, error) {
+func RenderSyncerResources(input TemplateInput, syncerID string, resourceForPermission []string) ([]byte, error) {
 	dnsSyncerID := strings.Replace(syncerID, "syncer", "dns", 1)
 
 	tmplArgs := templateArgs{
-		templateInput:      input,
+		TemplateInput:      input,
 		ServiceAccount:     syncerID,
 		ClusterRole:        syncerID,
 		ClusterRoleBinding: syncerID,
@@ -824,3 +907,28 @@ func sortGroupMappings(groupMappings []groupMapping) {
 		return groupMappings[i].APIGroup < groupMappings[j].APIGroup
 	})
 }
+
+// appendCABundle appends the PEM-encoded certificates of a CA bundle to the CA data of the
+// kubeconfig of the syncer.
+func appendCABundle(caData, caBundle []byte) []byte {
+	if len(caData) == 0 {
+		return caBundle
+	}
+	bundle := append([]byte{}, caData...)
+	if !bytes.HasSuffix(bundle, []byte("\n")) {
+		bundle = append(bundle, '\n')
+	}
+	return append(bundle, caBundle...)
+}
+
+// mirrorImage replaces the registry of the image with the given mirror. An image without
+// registry, i.e. from Docker Hub, is prefixed with the mirror.
+func mirrorImage(image, registry string) string {
+	registry = strings.TrimSuffix(registry, "/")
+	if i := strings.Index(image, "/"); i >= 0 {
+		if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			return registry + image[i:]
+		}
+	}
+	return registry + "/" + image
+}
@@ -232,7 +232,7 @@ spec:
             optional: false
 `
 
-	actualYAML, err := renderSyncerResources(templateInput{
+	actualYAML, err := RenderSyncerResources(TemplateInput{
 		ServerURL:                           "server-url",
 		Token:                               "token",
 		CAData:                              "ca-data",
@@ -462,7 +462,7 @@ spec:
             secretName: kcp-syncer-sync-target-name-34b23c4k
             optional: false
 `
-	actualYAML, err := renderSyncerResources(templateInput{
+	actualYAML, err := RenderSyncerResources(TemplateInput{
 		ServerURL:                           "server-url",
 		Token:                               "token",
 		CAData:                              "ca-data",
@@ -556,3 +556,56 @@ func TestGetGroupMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSyncerYAMLWithProxy(t *testing.T) {
+	actualYAML, err := RenderSyncerResources(TemplateInput{
+		ServerURL:                           "server-url",
+		Token:                               "token",
+		CAData:                              "ca-data",
+		KCPNamespace:                        "kcp-namespace",
+		Namespace:                           "kcp-syncer-sync-target-name-34b23c4k",
+		SyncTargetPath:                      "root:default:foo",
+		SyncTarget:                          "sync-target-name",
+		SyncTargetUID:                       "sync-target-uid",
+		Image:                               "image",
+		Replicas:                            1,
+		ResourcesToSync:                     []string{"resource1", "resource2"},
+		QPS:                                 123.4,
+		Burst:                               456,
+		APIImportPollIntervalString:         "1m",
+		DownstreamNamespaceCleanDelayString: "2s",
+		HTTPSProxy:                          "http://proxy.example.com:3128",
+		NoProxy:                             "10.0.0.0/8,.internal",
+	}, "kcp-syncer-sync-target-name-34b23c4k", []string{"resource1", "resource2"})
+	require.NoError(t, err)
+	require.Contains(t, string(actualYAML), `
+        - name: HTTPS_PROXY
+          value: http://proxy.example.com:3128
+        - name: NO_PROXY
+          value: 10.0.0.0/8,.internal,$(KUBERNETES_SERVICE_HOST),.svc,.cluster.local
+        image: image
+`)
+	require.NotContains(t, string(actualYAML), "HTTP_PROXY")
+}
+
+func TestMirrorImage(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		expected string
+	}{
+		{image: "ghcr.io/kcp-dev/kcp/syncer:v0.10.0", expected: "mirror.example.com:5000/kcp-dev/kcp/syncer:v0.10.0"},
+		{image: "localhost/syncer", expected: "mirror.example.com:5000/syncer"},
+		{image: "kcp-dev/syncer:latest", expected: "mirror.example.com:5000/kcp-dev/syncer:latest"},
+		{image: "syncer", expected: "mirror.example.com:5000/syncer"},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			require.Equal(t, tc.expected, mirrorImage(tc.image, "mirror.example.com:5000/"))
+		})
+	}
+}
+
+func TestAppendCABundle(t *testing.T) {
+	require.Equal(t, "bundle\n", string(appendCABundle(nil, []byte("bundle\n"))))
+	require.Equal(t, "kcp\nbundle\n", string(appendCABundle([]byte("kcp"), []byte("bundle\n"))))
+	require.Equal(t, "kcp\nbundle\n", string(appendCABundle([]byte("kcp\n"), []byte("bundle\n"))))
+}
@@ -22,10 +22,22 @@ import (
 	"fmt"
 	"time"
 
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
 
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
 )
@@ -127,15 +139,37 @@ type DrainOptions struct {
 
 	// SyncTarget is the name of the SyncTarget to drain.
 	SyncTarget string
+	// Timeout is how long to wait for the resources to be evicted from the SyncTarget.
+	// Zero means waiting indefinitely, a negative value does not wait.
+	Timeout time.Duration
+	// Force starts draining the SyncTarget even if its syncer is not ready to evict the resources.
+	Force bool
+
+	// for testing
+	getSyncTarget     func(ctx context.Context, name string) (*workloadv1alpha1.SyncTarget, error)
+	patchSyncTarget   func(ctx context.Context, name string, patch []byte) error
+	listSyncedObjects func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) ([]unstructured.Unstructured, error)
+	now               func() time.Time
+	pollInterval      time.Duration
 }
 
 // NewDrainOptions returns a new DrainOptions.
 func NewDrainOptions(streams genericclioptions.IOStreams) *DrainOptions {
 	return &DrainOptions{
-		Options: base.NewOptions(streams),
+		Options:      base.NewOptions(streams),
+		now:          time.Now,
+		pollInterval: 2 * time.Second,
 	}
 }
 
+// BindFlags binds fields to cmd's flagset.
+func (o *DrainOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "Duration to wait for the resources to be evicted from the sync target. Zero means waiting indefinitely, a negative value does not wait")
+	cmd.Flags().BoolVar(&o.Force, "force", o.Force, "Drain the sync target even if its syncer is not ready. The resources are only removed from the physical cluster once the syncer is back")
+}
+
 // Complete ensures all dynamically populated fields are initialized.
 func (o *DrainOptions) Complete(args []string) error {
 	if err := o.Options.Complete(); err != nil {
@@ -146,6 +180,32 @@ func (o *DrainOptions) Complete(args []string) error {
 		o.SyncTarget = args[0]
 	}
 
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	kcpClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kcp client: %w", err)
+	}
+
+	if o.getSyncTarget == nil {
+		o.getSyncTarget = func(ctx context.Context, name string) (*workloadv1alpha1.SyncTarget, error) {
+			return kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	if o.patchSyncTarget == nil {
+		o.patchSyncTarget = func(ctx context.Context, name string, patch []byte) error {
+			_, err := kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{})
+			return err
+		}
+	}
+	if o.listSyncedObjects == nil {
+		o.listSyncedObjects = func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) ([]unstructured.Unstructured, error) {
+			return listSyncedObjects(ctx, config, syncTarget)
+		}
+	}
+
 	return nil
 }
 
@@ -158,19 +218,10 @@ func (o *DrainOptions) Validate() error {
 	return nil
 }
 
-// Run drains the sync target and marks it as unschedulable.
+// Run drains the sync target and marks it as unschedulable, then waits for the resources
+// to be evicted from it, reporting the progress of the eviction.
 func (o *DrainOptions) Run(ctx context.Context) error {
-	config, err := o.ClientConfig.ClientConfig()
-	if err != nil {
-		return err
-	}
-
-	kcpClient, err := kcpclient.NewForConfig(config)
-	if err != nil {
-		return fmt.Errorf("failed to create kcp client: %w", err)
-	}
-
-	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, o.SyncTarget, metav1.GetOptions{})
+	syncTarget, err := o.getSyncTarget(ctx, o.SyncTarget)
 	if err != nil {
 		return fmt.Errorf("failed to get synctarget %s: %w", o.SyncTarget, err)
 	}
@@ -178,19 +229,97 @@ func (o *DrainOptions) Run(ctx context.Context) error {
 	// See if there is nothing to do
 	if syncTarget.Spec.EvictAfter != nil && syncTarget.Spec.Unschedulable {
 		fmt.Fprintln(o.Out, o.SyncTarget, "already draining")
-		return nil
+	} else {
+		if !conditions.IsTrue(syncTarget, conditionsv1alpha1.ReadyCondition) {
+			if !o.Force {
+				return fmt.Errorf("SyncTarget %s is not ready, its syncer cannot evict the resources: use --force to drain it anyway", o.SyncTarget)
+			}
+			fmt.Fprintf(o.ErrOut, "Warning: SyncTarget %s is not ready, the resources are only removed from it once its syncer is back.\n", o.SyncTarget)
+		}
+
+		// the placement controllers re-place the namespaces bound to the sync target onto other
+		// sync targets as soon as the evictAfter time is reached, and the syncer then deletes the
+		// resources downstream.
+		nowTime := o.now().UTC()
+		var patchBytes = []byte(`[{"op":"replace","path":"/spec/unschedulable","value":true},{"op":"replace","path":"/spec/evictAfter","value":"` + nowTime.Format(time.RFC3339) + `"}]`)
+		if err := o.patchSyncTarget(ctx, o.SyncTarget, patchBytes); err != nil {
+			return fmt.Errorf("failed to update SyncTarget %s: %w", o.SyncTarget, err)
+		}
+
+		fmt.Fprintln(o.Out, o.SyncTarget, "draining")
 	}
 
-	nowTime := time.Now().UTC()
-	var patchBytes = []byte(`[{"op":"replace","path":"/spec/unschedulable","value":true},{"op":"replace","path":"/spec/evictAfter","value":"` + nowTime.Format(time.RFC3339) + `"}]`)
+	if o.Timeout < 0 {
+		return nil
+	}
 
-	_, err = kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, o.SyncTarget, types.JSONPatchType, patchBytes, metav1.PatchOptions{})
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to update SyncTarget %s: %w", o.SyncTarget, err)
+	lastResources, lastNamespaces := -1, -1
+	err = wait.PollImmediateUntilWithContext(ctx, o.pollInterval, func(ctx context.Context) (bool, error) {
+		objects, err := o.listSyncedObjects(ctx, syncTarget)
+		if err != nil {
+			return false, err
+		}
+		resources, namespaces := countEvictionProgress(objects)
+		if resources == 0 {
+			return true, nil
+		}
+		if resources != lastResources || namespaces != lastNamespaces {
+			fmt.Fprintf(o.Out, "%s: %d resources remaining, %d namespaces pending re-placement\n", o.SyncTarget, resources, namespaces)
+			lastResources, lastNamespaces = resources, namespaces
+		}
+		return false, nil
+	})
+	if err != nil && errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out waiting for SyncTarget %s to be drained: %d resources remaining, %d namespaces pending re-placement", o.SyncTarget, lastResources, lastNamespaces)
+	} else if err != nil {
+		return fmt.Errorf("failed to wait for SyncTarget %s to be drained: %w", o.SyncTarget, err)
 	}
 
-	fmt.Fprintln(o.Out, o.SyncTarget, "draining")
+	fmt.Fprintln(o.Out, o.SyncTarget, "drained")
 
 	return nil
 }
+
+// countEvictionProgress returns the number of resources still synced to the sync target, and the
+// number of namespaces they belong to, i.e., the namespaces still placed onto the sync target.
+func countEvictionProgress(objects []unstructured.Unstructured) (int, int) {
+	namespaces := sets.NewString()
+	for _, obj := range objects {
+		if obj.GetNamespace() != "" {
+			namespaces.Insert(logicalcluster.From(&obj).String() + "|" + obj.GetNamespace())
+		}
+	}
+	return len(objects), namespaces.Len()
+}
+
+// listSyncedObjects lists the objects of the resources synced to the sync target, across all the workspaces,
+// through the syncer virtual workspace, which only serves the objects assigned to the sync target.
+func listSyncedObjects(ctx context.Context, config *rest.Config, syncTarget *workloadv1alpha1.SyncTarget) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+	for _, vw := range syncTarget.Status.VirtualWorkspaces {
+		vwConfig := rest.CopyConfig(config)
+		vwConfig.Host = vw.URL
+		dynamicClient, err := kcpdynamic.NewForConfig(vwConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range syncTarget.Status.SyncedResources {
+			if r.State != workloadv1alpha1.ResourceSchemaAcceptedState || len(r.Versions) == 0 {
+				continue
+			}
+			gvr := schema.GroupVersionResource{Group: r.Group, Version: r.Versions[0], Resource: r.Resource}
+			list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s synced to SyncTarget %s: %w", gvr.GroupResource(), syncTarget.Name, err)
+			}
+			objects = append(objects, list.Items...)
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func newSyncedObject(cluster, namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: cluster})
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func newDrainOptions(syncTarget *workloadv1alpha1.SyncTarget, progress ...[]unstructured.Unstructured) (*DrainOptions, *bytes.Buffer, *[]string) {
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	opts := NewDrainOptions(streams)
+	opts.SyncTarget = syncTarget.Name
+	opts.pollInterval = time.Millisecond
+	opts.now = func() time.Time { return time.Date(2022, 12, 1, 14, 0, 0, 0, time.UTC) }
+	opts.getSyncTarget = func(ctx context.Context, name string) (*workloadv1alpha1.SyncTarget, error) {
+		return syncTarget, nil
+	}
+	var patches []string
+	opts.patchSyncTarget = func(ctx context.Context, name string, patch []byte) error {
+		patches = append(patches, string(patch))
+		return nil
+	}
+	opts.listSyncedObjects = func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) ([]unstructured.Unstructured, error) {
+		objects := progress[0]
+		if len(progress) > 1 {
+			progress = progress[1:]
+		}
+		return objects, nil
+	}
+	return opts, out, &patches
+}
+
+func TestDrain(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+		Status: workloadv1alpha1.SyncTargetStatus{
+			Conditions: conditionsv1alpha1.Conditions{{Type: conditionsv1alpha1.ReadyCondition, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	opts, out, patches := newDrainOptions(syncTarget,
+		[]unstructured.Unstructured{newSyncedObject("team-a", "default", "web"), newSyncedObject("team-a", "default", "db"), newSyncedObject("team-b", "default", "web")},
+		[]unstructured.Unstructured{newSyncedObject("team-a", "default", "web"), newSyncedObject("team-a", "default", "db"), newSyncedObject("team-b", "default", "web")},
+		[]unstructured.Unstructured{newSyncedObject("team-b", "default", "web")},
+		nil,
+	)
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+	require.Equal(t, []string{`[{"op":"replace","path":"/spec/unschedulable","value":true},{"op":"replace","path":"/spec/evictAfter","value":"2022-12-01T14:00:00Z"}]`}, *patches)
+	require.Equal(t, ""+
+		"cluster-1 draining\n"+
+		"cluster-1: 3 resources remaining, 2 namespaces pending re-placement\n"+
+		"cluster-1: 1 resources remaining, 1 namespaces pending re-placement\n"+
+		"cluster-1 drained\n",
+		out.String())
+}
+
+func TestDrainNotReady(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"}}
+
+	opts, _, patches := newDrainOptions(syncTarget, []unstructured.Unstructured{newSyncedObject("team-a", "default", "web")})
+	require.EqualError(t, opts.Run(context.Background()), "SyncTarget cluster-1 is not ready, its syncer cannot evict the resources: use --force to drain it anyway")
+	require.Empty(t, *patches)
+
+	opts, _, patches = newDrainOptions(syncTarget, []unstructured.Unstructured{newSyncedObject("team-a", "default", "web")})
+	opts.Force = true
+	opts.Timeout = 10 * time.Millisecond
+	require.EqualError(t, opts.Run(context.Background()), "timed out waiting for SyncTarget cluster-1 to be drained: 1 resources remaining, 1 namespaces pending re-placement")
+	require.Len(t, *patches, 1)
+}
+
+func TestDrainAlreadyDraining(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"},
+		Spec: workloadv1alpha1.SyncTargetSpec{
+			Unschedulable: true,
+			EvictAfter:    &metav1.Time{Time: time.Date(2022, 12, 1, 13, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	opts, out, patches := newDrainOptions(syncTarget, nil)
+	opts.Timeout = -1
+	require.NoError(t, opts.Run(context.Background()))
+	require.Empty(t, *patches)
+	require.Equal(t, "cluster-1 already draining\n", out.String())
+}
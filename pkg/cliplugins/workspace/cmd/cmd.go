@@ -56,11 +56,35 @@ var (
 	# create a workspace and immediately enter it
 	%[1]s workspace create my-workspace --enter
 
+	# enter a child workspace, creating it first if it does not exist
+	%[1]s workspace my-workspace --create
+
 	# create a context with the current workspace, e.g. root:default:my-workspace
 	%[1]s workspace create-context
 
 	# create a context with the current workspace, named context-name
 	%[1]s workspace create-context context-name
+
+	# pick a workspace interactively among the favorite, recent, parent and child workspaces
+	%[1]s workspace -i
+
+	# add the current workspace to the favorites of the interactive picker
+	%[1]s workspace favorite
+
+	# write a kubeconfig for the service account ci/deployer of the current workspace, creating it if needed
+	%[1]s workspace create-kubeconfig --serviceaccount ci/deployer --duration 24h -o deployer.kubeconfig
+
+	# back up the objects of the current workspace to a file
+	%[1]s workspace backup -o backup.yaml
+
+	# back up the configmaps and secrets of the current workspace to a pre-signed object storage URL
+	%[1]s workspace backup --include configmaps,secrets -o "https://bucket.example.com/backup.yaml?X-Amz-Signature=..."
+
+	# restore a backup into the current workspace
+	%[1]s workspace restore -f backup.yaml
+
+	# enable the completion of workspace paths in bash, e.g. kubectl-kcp workspace root:org:<TAB>
+	source <(kubectl-kcp completion bash)
 `
 )
 
@@ -75,7 +99,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 	cmd := &cobra.Command{
 		Aliases:          []string{"ws", "workspaces"},
-		Use:              "workspace [create|create-context|use|current|<workspace>|..|.|-|~|<root:absolute:workspace>]",
+		Use:              "workspace [create|create-context|create-kubeconfig|use|current|favorite|backup|restore|<workspace>|..|.|-|~|<root:absolute:workspace>|-i]",
 		Short:            "Manages KCP workspaces",
 		Example:          fmt.Sprintf(workspaceExample, cliName),
 		SilenceUsage:     true,
@@ -92,6 +116,12 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 			}
 			return cmdOpts.Run(cmd.Context())
 		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return cmdOpts.CompleteWorkspaces(cmd.Context(), toComplete)
+		},
 	}
 	cmdOpts.BindFlags(cmd)
 
@@ -112,6 +142,12 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 			}
 			return useWorkspaceOpts.Run(c.Context())
 		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return useWorkspaceOpts.CompleteWorkspaces(c.Context(), toComplete)
+		},
 	}
 	useWorkspaceOpts.BindFlags(useCmd)
 
@@ -140,7 +176,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	createCmd := &cobra.Command{
 		Use:          "create",
 		Short:        "Creates a new workspace",
-		Example:      "kcp workspace create <workspace name> [--type=<type>] [--enter [--ignore-not-ready]] --ignore-existing",
+		Example:      "kcp workspace create <workspace name> [--type=<type>] [--enter [--ignore-not-ready]] --ignore-existing [--wait=false] [--wait-timeout=<duration>]",
 		SilenceUsage: true,
 		Args:         cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -183,7 +219,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	treeCmd := &cobra.Command{
 		Use:          "tree",
 		Short:        "Print the current workspace tree.",
-		Example:      "kcp workspace tree",
+		Example:      "kcp workspace tree\n\n# show the APIBindings and APIExports of each workspace\nkcp workspace tree --show-bindings",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
 			if len(args) != 0 {
@@ -200,10 +236,98 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	treeCmdOpts.BindFlags(treeCmd)
 
+	favoriteOpts := plugin.NewFavoriteWorkspaceOptions(streams)
+	favoriteCmd := &cobra.Command{
+		Use:          "favorite [<root:absolute:workspace>] [--remove] [--list]",
+		Short:        "Manage the favorite workspaces proposed by the interactive mode. Defaults to the current workspace.",
+		Example:      "kcp workspace favorite root:org:team",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return c.Help()
+			}
+			if err := favoriteOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := favoriteOpts.Validate(); err != nil {
+				return err
+			}
+			return favoriteOpts.Run(c.Context())
+		},
+	}
+	favoriteOpts.BindFlags(favoriteCmd)
+
+	createKubeconfigOpts := plugin.NewCreateKubeconfigOptions(streams)
+	createKubeconfigCmd := &cobra.Command{
+		Use:          "create-kubeconfig --serviceaccount <namespace>/<name> [--audience <audience>] [--duration <duration>] [-o <file>]",
+		Short:        "Create a kubeconfig for a service account of the current workspace, creating the service account if needed.",
+		Example:      "kcp workspace create-kubeconfig --serviceaccount ci/deployer -o deployer.kubeconfig",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := createKubeconfigOpts.Complete(); err != nil {
+				return err
+			}
+			if err := createKubeconfigOpts.Validate(); err != nil {
+				return err
+			}
+			return createKubeconfigOpts.Run(c.Context())
+		},
+	}
+	createKubeconfigOpts.BindFlags(createKubeconfigCmd)
+
+	backupOpts := plugin.NewBackupWorkspaceOptions(streams)
+	backupCmd := &cobra.Command{
+		Use:          "backup [-o <file>|<url>] [--include <resources>] [--exclude <resources>]",
+		Short:        "Back up the objects of the current workspace as a YAML stream.",
+		Example:      "kcp workspace backup -o backup.yaml",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := backupOpts.Complete(); err != nil {
+				return err
+			}
+			if err := backupOpts.Validate(); err != nil {
+				return err
+			}
+			return backupOpts.Run(c.Context())
+		},
+	}
+	backupOpts.BindFlags(backupCmd)
+
+	restoreOpts := plugin.NewRestoreWorkspaceOptions(streams)
+	restoreCmd := &cobra.Command{
+		Use:          "restore [-f <file>|<url>] [--include <resources>] [--exclude <resources>]",
+		Short:        "Restore a backup into the current workspace. Existing objects are left untouched.",
+		Example:      "kcp workspace restore -f backup.yaml",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return c.Help()
+			}
+			if err := restoreOpts.Complete(); err != nil {
+				return err
+			}
+			if err := restoreOpts.Validate(); err != nil {
+				return err
+			}
+			return restoreOpts.Run(c.Context())
+		},
+	}
+	restoreOpts.BindFlags(restoreCmd)
+
 	cmd.AddCommand(useCmd)
 	cmd.AddCommand(treeCmd)
 	cmd.AddCommand(currentCmd)
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(createContextCmd)
+	cmd.AddCommand(createKubeconfigCmd)
+	cmd.AddCommand(favoriteCmd)
+	cmd.AddCommand(backupCmd)
+	cmd.AddCommand(restoreCmd)
 	return cmd, nil
 }
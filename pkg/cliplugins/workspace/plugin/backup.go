@@ -0,0 +1,537 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// defaultExcludedResources are never backed up, their objects are owned by the system.
+var defaultExcludedResources = sets.NewString(
+	"events",
+	"events.events.k8s.io",
+	"leases.coordination.k8s.io",
+	"logicalclusters.core.kcp.io",
+)
+
+// restoreOrder are the resources restored before all others, such that the objects
+// depending on them can be created.
+var restoreOrder = []schema.GroupResource{
+	{Resource: "namespaces"},
+	{Group: "apis.kcp.io", Resource: "apibindings"},
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+}
+
+// resourceFilter selects resources by their group resource, e.g. configmaps or widgets.example.io.
+type resourceFilter struct {
+	include sets.String
+	exclude sets.String
+}
+
+func newResourceFilter(include, exclude []string) resourceFilter {
+	return resourceFilter{include: sets.NewString(include...), exclude: sets.NewString(exclude...)}
+}
+
+func (f resourceFilter) matches(gr schema.GroupResource) bool {
+	if f.include.Len() > 0 {
+		return f.include.Has(gr.String())
+	}
+	return !f.exclude.Has(gr.String()) && !defaultExcludedResources.Has(gr.String())
+}
+
+// BackupWorkspaceOptions contains options for backing up the objects of the current workspace.
+type BackupWorkspaceOptions struct {
+	*base.Options
+
+	// Output is the file to write the backup to, "-" for the standard output, or an
+	// http(s) URL, e.g. a pre-signed object storage URL, the backup is uploaded to.
+	Output string
+	// Include restricts the backup to these resources.
+	Include []string
+	// Exclude removes these resources from the backup.
+	Exclude []string
+
+	// for testing
+	listResources func() ([]schema.GroupVersionResource, error)
+	listObjects   func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	openWriter    func(ctx context.Context, target string) (io.WriteCloser, error)
+}
+
+// NewBackupWorkspaceOptions returns a new BackupWorkspaceOptions.
+func NewBackupWorkspaceOptions(streams genericclioptions.IOStreams) *BackupWorkspaceOptions {
+	return &BackupWorkspaceOptions{
+		Options: base.NewOptions(streams),
+		Output:  "-",
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *BackupWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "File or http(s) URL to write the backup to, - for the standard output")
+	cmd.Flags().StringSliceVar(&o.Include, "include", o.Include, "Only back up these resources, e.g. configmaps,widgets.example.io")
+	cmd.Flags().StringSliceVar(&o.Exclude, "exclude", o.Exclude, "Do not back up these resources, e.g. secrets")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *BackupWorkspaceOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if o.listResources == nil {
+		o.listResources = func() ([]schema.GroupVersionResource, error) {
+			resources, err := discoveryClient.ServerPreferredResources()
+			if err != nil && len(resources) == 0 {
+				return nil, err
+			}
+			resources = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "create"}}, resources)
+			gvrs, err := discovery.GroupVersionResources(resources)
+			if err != nil {
+				return nil, err
+			}
+			ret := make([]schema.GroupVersionResource, 0, len(gvrs))
+			for gvr := range gvrs {
+				ret = append(ret, gvr)
+			}
+			return ret, nil
+		}
+	}
+	if o.listObjects == nil {
+		o.listObjects = func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+			list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		}
+	}
+	if o.openWriter == nil {
+		o.openWriter = func(ctx context.Context, target string) (io.WriteCloser, error) {
+			return openBackupWriter(ctx, target, o.Out)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the BackupWorkspaceOptions are complete and usable.
+func (o *BackupWorkspaceOptions) Validate() error {
+	if len(o.Include) > 0 && len(o.Exclude) > 0 {
+		return errors.New("--include and --exclude are mutually exclusive")
+	}
+	return o.Options.Validate()
+}
+
+// Run writes the objects of the workspace as a YAML stream.
+func (o *BackupWorkspaceOptions) Run(ctx context.Context) error {
+	gvrs, err := o.listResources()
+	if err != nil {
+		return fmt.Errorf("failed to discover the resources of the workspace: %w", err)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].GroupResource().String() < gvrs[j].GroupResource().String()
+	})
+
+	w, err := o.openWriter(ctx, o.Output)
+	if err != nil {
+		return err
+	}
+
+	filter := newResourceFilter(o.Include, o.Exclude)
+	total := 0
+	var errs []error
+	for _, gvr := range gvrs {
+		if !filter.matches(gvr.GroupResource()) {
+			continue
+		}
+
+		objs, err := o.listObjects(ctx, gvr)
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			fmt.Fprintf(o.ErrOut, "Skipping %s: %v\n", gvr.GroupResource(), err)
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", gvr.GroupResource(), err))
+			continue
+		}
+
+		count := 0
+		for i := range objs {
+			if !isBackedUp(gvr.GroupResource(), &objs[i]) {
+				continue
+			}
+			if err := writeBackupObject(w, cleanBackupObject(&objs[i])); err != nil {
+				errs = append(errs, err)
+				break
+			}
+			count++
+		}
+		if count > 0 {
+			fmt.Fprintf(o.ErrOut, "Backed up %d %s\n", count, gvr.GroupResource())
+		}
+		total += count
+	}
+
+	if len(errs) > 0 {
+		// do not upload an incomplete backup, possibly replacing a complete one.
+		if _, upload := w.(*httpUploader); !upload {
+			w.Close()
+		}
+		return utilerrors.NewAggregate(errs)
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.ErrOut, "Backed up %d objects.\n", total)
+	return nil
+}
+
+// isBackedUp returns false for the objects created by kcp in every workspace.
+func isBackedUp(gr schema.GroupResource, obj *unstructured.Unstructured) bool {
+	switch gr.String() {
+	case "configmaps":
+		return obj.GetName() != "kube-root-ca.crt"
+	case "serviceaccounts":
+		return obj.GetName() != "default"
+	case "secrets":
+		t, _, _ := unstructured.NestedString(obj.Object, "type")
+		return t != "kubernetes.io/service-account-token"
+	}
+	return true
+}
+
+// cleanBackupObject drops the status and the metadata set by the server, which cannot be restored.
+func cleanBackupObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds", "managedFields", "selfLink", "ownerReferences"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	annotations := obj.GetAnnotations()
+	delete(annotations, "kcp.io/cluster")
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func writeBackupObject(w io.Writer, obj *unstructured.Unstructured) error {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreWorkspaceOptions contains options for restoring a backup into the current workspace.
+type RestoreWorkspaceOptions struct {
+	*base.Options
+
+	// Input is the file to read the backup from, "-" for the standard input, or an
+	// http(s) URL, e.g. a pre-signed object storage URL, the backup is downloaded from.
+	Input string
+	// Include restricts the restore to these resources.
+	Include []string
+	// Exclude does not restore these resources.
+	Exclude []string
+
+	// for testing
+	openReader   func(ctx context.Context, source string) (io.ReadCloser, error)
+	resourceFor  func(gvk schema.GroupVersionKind) (*meta.RESTMapping, error)
+	createObject func(ctx context.Context, mapping *meta.RESTMapping, obj *unstructured.Unstructured) error
+}
+
+// NewRestoreWorkspaceOptions returns a new RestoreWorkspaceOptions.
+func NewRestoreWorkspaceOptions(streams genericclioptions.IOStreams) *RestoreWorkspaceOptions {
+	return &RestoreWorkspaceOptions{
+		Options: base.NewOptions(streams),
+		Input:   "-",
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *RestoreWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Input, "filename", "f", o.Input, "File or http(s) URL to read the backup from, - for the standard input")
+	cmd.Flags().StringSliceVar(&o.Include, "include", o.Include, "Only restore these resources, e.g. configmaps,widgets.example.io")
+	cmd.Flags().StringSliceVar(&o.Exclude, "exclude", o.Exclude, "Do not restore these resources, e.g. secrets")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *RestoreWorkspaceOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if o.openReader == nil {
+		o.openReader = func(ctx context.Context, source string) (io.ReadCloser, error) {
+			return openBackupReader(ctx, source, o.In)
+		}
+	}
+	if o.resourceFor == nil {
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+		o.resourceFor = func(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if meta.IsNoMatchError(err) {
+				// the resource might have been added by a restored APIBinding or CRD.
+				mapper.Reset()
+				mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			}
+			return mapping, err
+		}
+	}
+	if o.createObject == nil {
+		o.createObject = func(ctx context.Context, mapping *meta.RESTMapping, obj *unstructured.Unstructured) error {
+			var err error
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				_, err = dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+			} else {
+				_, err = dynamicClient.Resource(mapping.Resource).Create(ctx, obj, metav1.CreateOptions{})
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the RestoreWorkspaceOptions are complete and usable.
+func (o *RestoreWorkspaceOptions) Validate() error {
+	if len(o.Include) > 0 && len(o.Exclude) > 0 {
+		return errors.New("--include and --exclude are mutually exclusive")
+	}
+	return o.Options.Validate()
+}
+
+// Run creates the objects of the backup in the current workspace. Existing objects are left untouched.
+func (o *RestoreWorkspaceOptions) Run(ctx context.Context) error {
+	r, err := o.openReader(ctx, o.Input)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	objs, err := readBackupObjects(r)
+	if err != nil {
+		return fmt.Errorf("failed to read the backup: %w", err)
+	}
+	sortForRestore(objs)
+
+	filter := newResourceFilter(o.Include, o.Exclude)
+	var restored, existing int
+	var errs []error
+	for i, obj := range objs {
+		mapping, err := o.resourceFor(obj.GroupVersionKind())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s %s: %w", obj.GetKind(), namespacedName(obj), err))
+			continue
+		}
+		if !filter.matches(mapping.Resource.GroupResource()) {
+			continue
+		}
+
+		err = o.createObject(ctx, mapping, obj)
+		switch {
+		case apierrors.IsAlreadyExists(err):
+			existing++
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to restore %s %s: %w", mapping.Resource.GroupResource(), namespacedName(obj), err))
+		default:
+			restored++
+		}
+		if (i+1)%100 == 0 {
+			fmt.Fprintf(o.ErrOut, "Processed %d/%d objects\n", i+1, len(objs))
+		}
+	}
+
+	fmt.Fprintf(o.ErrOut, "Restored %d objects, %d already existed, %d failed.\n", restored, existing, len(errs))
+	return utilerrors.NewAggregate(errs)
+}
+
+func readBackupObjects(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	d := kubeyaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := d.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// sortForRestore moves the objects that others depend on to the front, keeping the order of the backup otherwise.
+func sortForRestore(objs []*unstructured.Unstructured) {
+	priority := func(obj *unstructured.Unstructured) int {
+		gk := obj.GroupVersionKind().GroupKind()
+		for i, gr := range restoreOrder {
+			// the kinds of the resources restored first are their singular resource names.
+			if gk.Group == gr.Group && strings.ToLower(gk.Kind)+"s" == gr.Resource {
+				return i
+			}
+		}
+		return len(restoreOrder)
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return priority(objs[i]) < priority(objs[j])
+	})
+}
+
+func namespacedName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// openBackupWriter opens the target for writing. Backups uploaded to an http(s) URL are buffered,
+// such that the request has a content length as required by object storage pre-signed URLs.
+func openBackupWriter(ctx context.Context, target string, stdout io.Writer) (io.WriteCloser, error) {
+	switch {
+	case target == "-":
+		return nopWriteCloser{stdout}, nil
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		return &httpUploader{ctx: ctx, url: target}, nil
+	case strings.Contains(target, "://"):
+		return nil, fmt.Errorf("unsupported backup URL %q, only http(s) URLs are supported", target)
+	}
+	return os.Create(target)
+}
+
+func openBackupReader(ctx context.Context, source string, stdin io.Reader) (io.ReadCloser, error) {
+	switch {
+	case source == "-":
+		return io.NopCloser(stdin), nil
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to download the backup: %s", resp.Status)
+		}
+		return resp.Body, nil
+	case strings.Contains(source, "://"):
+		return nil, fmt.Errorf("unsupported backup URL %q, only http(s) URLs are supported", source)
+	}
+	return os.Open(source)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+type httpUploader struct {
+	bytes.Buffer
+	ctx context.Context
+	url string
+}
+
+func (u *httpUploader) Close() error {
+	req, err := http.NewRequestWithContext(u.ctx, http.MethodPut, u.url, bytes.NewReader(u.Bytes()))
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload the backup: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newUnstructured(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestBackupWorkspace(t *testing.T) {
+	configMap := newUnstructured("v1", "ConfigMap", "default", "settings")
+	configMap.SetUID("uid")
+	configMap.SetResourceVersion("42")
+	configMap.SetAnnotations(map[string]string{"kcp.io/cluster": "abc"})
+	configMap.Object["data"] = map[string]interface{}{"key": "value"}
+	rootCA := newUnstructured("v1", "ConfigMap", "default", "kube-root-ca.crt")
+	widget := newUnstructured("example.io/v1", "Widget", "default", "foo")
+	widget.Object["status"] = map[string]interface{}{"ready": true}
+
+	objects := map[schema.GroupVersionResource][]unstructured.Unstructured{
+		{Version: "v1", Resource: "configmaps"}:                   {configMap, rootCA},
+		{Group: "example.io", Version: "v1", Resource: "widgets"}: {widget},
+		{Version: "v1", Resource: "events"}:                       {newUnstructured("v1", "Event", "default", "event")},
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{
+			name: "all resources",
+			want: "---\napiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: default\n" +
+				"---\napiVersion: example.io/v1\nkind: Widget\nmetadata:\n  name: foo\n  namespace: default\n",
+		},
+		{
+			name:    "included resources",
+			include: []string{"widgets.example.io"},
+			want:    "---\napiVersion: example.io/v1\nkind: Widget\nmetadata:\n  name: foo\n  namespace: default\n",
+		},
+		{
+			name:    "excluded resources",
+			exclude: []string{"widgets.example.io"},
+			want:    "---\napiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: default\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewBackupWorkspaceOptions(streams)
+			opts.Include = tt.include
+			opts.Exclude = tt.exclude
+			opts.listResources = func() ([]schema.GroupVersionResource, error) {
+				var gvrs []schema.GroupVersionResource
+				for gvr := range objects {
+					gvrs = append(gvrs, gvr)
+				}
+				return gvrs, nil
+			}
+			opts.listObjects = func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+				return objects[gvr], nil
+			}
+			opts.openWriter = func(ctx context.Context, target string) (io.WriteCloser, error) {
+				return nopWriteCloser{out}, nil
+			}
+
+			require.NoError(t, opts.Validate())
+			require.NoError(t, opts.Run(context.Background()))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestRestoreWorkspace(t *testing.T) {
+	backup := "---\napiVersion: example.io/v1\nkind: Widget\nmetadata:\n  name: foo\n  namespace: team\n" +
+		"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: team\n" +
+		"---\napiVersion: apis.kcp.io/v1alpha1\nkind: APIBinding\nmetadata:\n  name: widgets\n" +
+		"---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: team\n"
+
+	mappings := map[string]*meta.RESTMapping{
+		"Widget":     {Resource: schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}, Scope: meta.RESTScopeNamespace},
+		"ConfigMap":  {Resource: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, Scope: meta.RESTScopeNamespace},
+		"APIBinding": {Resource: schema.GroupVersionResource{Group: "apis.kcp.io", Version: "v1alpha1", Resource: "apibindings"}, Scope: meta.RESTScopeRoot},
+		"Namespace":  {Resource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, Scope: meta.RESTScopeRoot},
+	}
+
+	streams, in, _, errOut := genericclioptions.NewTestIOStreams()
+	in.WriteString(backup)
+	opts := NewRestoreWorkspaceOptions(streams)
+	opts.Exclude = []string{"configmaps"}
+	opts.openReader = func(ctx context.Context, source string) (io.ReadCloser, error) {
+		return openBackupReader(ctx, source, in)
+	}
+	opts.resourceFor = func(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+		return mappings[gvk.Kind], nil
+	}
+	var created []string
+	opts.createObject = func(ctx context.Context, mapping *meta.RESTMapping, obj *unstructured.Unstructured) error {
+		created = append(created, mapping.Resource.GroupResource().String()+" "+namespacedName(obj))
+		if obj.GetKind() == "Namespace" {
+			return apierrors.NewAlreadyExists(mapping.Resource.GroupResource(), obj.GetName())
+		}
+		return nil
+	}
+
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+	require.Equal(t, []string{"namespaces team", "apibindings.apis.kcp.io widgets", "widgets.example.io team/foo"}, created)
+	require.Equal(t, "Restored 2 objects, 1 already existed, 0 failed.\n", errOut.String())
+}
+
+func TestOpenBackupWriter(t *testing.T) {
+	var stdout bytes.Buffer
+	w, err := openBackupWriter(context.Background(), "-", &stdout)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, "data", stdout.String())
+
+	_, err = openBackupWriter(context.Background(), "s3://bucket/backup.yaml", &stdout)
+	require.Error(t, err)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/homedir"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// completionCacheTTL is how long the child workspaces listed for shell completion are cached.
+const completionCacheTTL = time.Minute
+
+// defaultCompletionCacheDir returns the directory where the child workspaces listed for shell completion are cached.
+func defaultCompletionCacheDir() string {
+	return filepath.Join(homedir.HomeDir(), ".kcp", "cache", "completion")
+}
+
+// completionCacheEntry is the cached list of the child workspaces of a workspace.
+type completionCacheEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Children  []string  `json:"children"`
+}
+
+// CompleteWorkspaces returns the shell completions for a workspace argument. An absolute path
+// is completed with the ready child workspaces of its parent, e.g. root:org:<TAB>, otherwise
+// the ready child workspaces of the current workspace are proposed.
+func (o *UseWorkspaceOptions) CompleteWorkspaces(ctx context.Context, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// the options are not completed yet when the completion is requested by the shell.
+	if o.ClientConfig == nil {
+		if err := o.Options.Complete(); err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	u, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		cobra.CompErrorln(fmt.Sprintf("current URL %q does not point to cluster workspace", config.Host))
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	parent, prefix := currentClusterName, toComplete
+	absolute := strings.Contains(toComplete, ":")
+	if absolute {
+		i := strings.LastIndex(toComplete, ":")
+		parent, prefix = logicalcluster.NewPath(toComplete[:i]), toComplete[i+1:]
+		if !parent.IsValid() {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	if o.listChildWorkspaces == nil {
+		kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		o.listChildWorkspaces = func(ctx context.Context, parent logicalcluster.Path) ([]string, error) {
+			workspaces, err := kcpClusterClient.Cluster(parent).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			var children []string
+			for _, ws := range workspaces.Items {
+				if ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady {
+					children = append(children, ws.Name)
+				}
+			}
+			return children, nil
+		}
+	}
+
+	// the cache is keyed by the server and the user, such that identities with different
+	// permissions do not share completions, without storing anything about the credentials.
+	u.Path = ""
+	rawConfig, err := o.ClientConfig.RawConfig()
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var user string
+	if c, found := rawConfig.Contexts[rawConfig.CurrentContext]; found {
+		user = c.AuthInfo
+	}
+	children, err := o.cachedChildWorkspaces(ctx, u, user, parent)
+	if err != nil {
+		// the user may not be allowed to list the child workspaces, in which case there is nothing to complete.
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, child := range children {
+		if !strings.HasPrefix(child, prefix) {
+			continue
+		}
+		if absolute {
+			completions = append(completions, parent.Join(child).String())
+		} else {
+			completions = append(completions, child)
+		}
+	}
+	if !absolute && strings.HasPrefix(core.RootCluster.String(), toComplete) {
+		completions = append(completions, core.RootCluster.String())
+	}
+	sort.Strings(completions)
+
+	// no space is added after a completion, so that the path can be further completed with ':'.
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func (o *UseWorkspaceOptions) cachedChildWorkspaces(ctx context.Context, server *url.URL, user string, parent logicalcluster.Path) ([]string, error) {
+	hash := sha256.Sum256([]byte(server.String() + "|" + user + "|" + parent.String()))
+	cacheFile := filepath.Join(o.completionCacheDir, fmt.Sprintf("%x.json", hash[:16]))
+
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		var entry completionCacheEntry
+		if err := json.Unmarshal(data, &entry); err == nil && o.now().Sub(entry.Timestamp) < completionCacheTTL {
+			return entry.Children, nil
+		}
+	}
+
+	children, err := o.listChildWorkspaces(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	// failing to cache is not fatal, the child workspaces are then listed again next time.
+	if data, err := json.Marshal(completionCacheEntry{Timestamp: o.now(), Children: children}); err == nil {
+		if err := os.MkdirAll(o.completionCacheDir, 0o700); err == nil {
+			_ = os.WriteFile(cacheFile, data, 0o600)
+		}
+	}
+
+	return children, nil
+}
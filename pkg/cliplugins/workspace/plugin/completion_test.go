@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCompleteWorkspaces(t *testing.T) {
+	children := map[string][]string{
+		"root:org":      {"team-a", "team-b", "ops"},
+		"root:other":    {"team-c"},
+		"root:org:ops":  {"monitoring"},
+		"root:unlisted": nil,
+	}
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{name: "children of the current workspace", toComplete: "", want: []string{"ops", "root", "team-a", "team-b"}},
+		{name: "children of the current workspace with prefix", toComplete: "team", want: []string{"team-a", "team-b"}},
+		{name: "root", toComplete: "ro", want: []string{"root"}},
+		{name: "absolute path", toComplete: "root:org:", want: []string{"root:org:ops", "root:org:team-a", "root:org:team-b"}},
+		{name: "absolute path with prefix", toComplete: "root:org:t", want: []string{"root:org:team-a", "root:org:team-b"}},
+		{name: "nested absolute path", toComplete: "root:org:ops:", want: []string{"root:org:ops:monitoring"}},
+		{name: "other absolute path", toComplete: "root:other:", want: []string{"root:other:team-c"}},
+		{name: "no children", toComplete: "root:unlisted:", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := NewUseWorkspaceOptions(genericclioptions.NewTestIOStreamsDiscard())
+			opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+				CurrentContext: "test",
+				Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+				Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://test/clusters/root:org"}},
+				AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+			}, nil)
+			opts.completionCacheDir = t.TempDir()
+			opts.listChildWorkspaces = func(ctx context.Context, parent logicalcluster.Path) ([]string, error) {
+				return children[parent.String()], nil
+			}
+
+			got, directive := opts.CompleteWorkspaces(context.Background(), tt.toComplete)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, directive)
+		})
+	}
+}
+
+func TestCompleteWorkspacesCache(t *testing.T) {
+	opts := NewUseWorkspaceOptions(genericclioptions.NewTestIOStreamsDiscard())
+	opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		CurrentContext: "test",
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://test/clusters/root:org"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+	}, nil)
+	opts.completionCacheDir = t.TempDir()
+	now := time.Date(2022, 12, 1, 14, 0, 0, 0, time.UTC)
+	opts.now = func() time.Time { return now }
+	calls := 0
+	opts.listChildWorkspaces = func(ctx context.Context, parent logicalcluster.Path) ([]string, error) {
+		calls++
+		return []string{"team-a"}, nil
+	}
+
+	got, _ := opts.CompleteWorkspaces(context.Background(), "root:org:")
+	require.Equal(t, []string{"root:org:team-a"}, got)
+	got, _ = opts.CompleteWorkspaces(context.Background(), "root:org:team")
+	require.Equal(t, []string{"root:org:team-a"}, got)
+	require.Equal(t, 1, calls, "the child workspaces should have been cached")
+
+	now = now.Add(completionCacheTTL)
+	_, _ = opts.CompleteWorkspaces(context.Background(), "root:org:")
+	require.Equal(t, 2, calls, "the cache should have expired")
+}
+
+func TestCompleteWorkspacesClientConfig(t *testing.T) {
+	config := func(server string) clientcmdapi.Config {
+		return clientcmdapi.Config{
+			CurrentContext: "test",
+			Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+			Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: server}},
+			AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+		}
+	}
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, clientcmd.WriteToFile(config("https://test/clusters/root:other"), kubeconfig))
+
+	children := map[string][]string{
+		"root:org":   {"team-a"},
+		"root:other": {"team-c"},
+	}
+	newOptions := func() *UseWorkspaceOptions {
+		opts := NewUseWorkspaceOptions(genericclioptions.NewTestIOStreamsDiscard())
+		opts.Kubeconfig = kubeconfig
+		opts.completionCacheDir = t.TempDir()
+		opts.listChildWorkspaces = func(ctx context.Context, parent logicalcluster.Path) ([]string, error) {
+			return children[parent.String()], nil
+		}
+		return opts
+	}
+
+	t.Run("resolved client config is kept", func(t *testing.T) {
+		opts := newOptions()
+		clientConfig := clientcmd.NewDefaultClientConfig(config("https://test/clusters/root:org"), nil)
+		opts.ClientConfig = clientConfig
+
+		got, _ := opts.CompleteWorkspaces(context.Background(), "team")
+		require.Equal(t, []string{"team-a"}, got)
+		require.Same(t, clientConfig, opts.ClientConfig, "the resolved client config should not be replaced")
+	})
+
+	t.Run("client config is resolved from the kubeconfig", func(t *testing.T) {
+		opts := newOptions()
+
+		got, _ := opts.CompleteWorkspaces(context.Background(), "team")
+		require.Equal(t, []string{"team-c"}, got)
+		require.NotNil(t, opts.ClientConfig)
+	})
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// CreateKubeconfigOptions contains options for creating a kubeconfig for a service account of the current workspace.
+type CreateKubeconfigOptions struct {
+	*base.Options
+
+	// ServiceAccount is the service account, in the <namespace>/<name> format.
+	ServiceAccount string
+	// Audiences are the intended audiences of the token. Defaults to the audiences of the API server.
+	Audiences []string
+	// Duration is the requested validity of the token.
+	Duration time.Duration
+	// Output is the file the kubeconfig is written to, "-" for the standard output.
+	Output string
+
+	namespace, name string
+
+	// for testing
+	ensureServiceAccount func(ctx context.Context, namespace, name string) (bool, error)
+	createToken          func(ctx context.Context, namespace, name string, request *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error)
+}
+
+// NewCreateKubeconfigOptions returns a new CreateKubeconfigOptions.
+func NewCreateKubeconfigOptions(streams genericclioptions.IOStreams) *CreateKubeconfigOptions {
+	return &CreateKubeconfigOptions{
+		Options:  base.NewOptions(streams),
+		Duration: time.Hour,
+		Output:   "-",
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *CreateKubeconfigOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.ServiceAccount, "serviceaccount", o.ServiceAccount, "Service account to create the kubeconfig for, in the <namespace>/<name> format. It is created if it does not exist")
+	cmd.Flags().StringSliceVar(&o.Audiences, "audience", o.Audiences, "Audiences of the requested token. Defaults to the API server audiences")
+	cmd.Flags().DurationVar(&o.Duration, "duration", o.Duration, "Requested validity of the token. The server may return a token with a different validity")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "File to write the kubeconfig to, - for the standard output")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *CreateKubeconfigOptions) Complete() error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	o.namespace, o.name, _ = strings.Cut(o.ServiceAccount, "/")
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if o.ensureServiceAccount == nil {
+		o.ensureServiceAccount = func(ctx context.Context, namespace, name string) (bool, error) {
+			_, err := kubeClient.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+			return err == nil, err
+		}
+	}
+	if o.createToken == nil {
+		o.createToken = func(ctx context.Context, namespace, name string, request *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+			return kubeClient.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, request, metav1.CreateOptions{})
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the CreateKubeconfigOptions are complete and usable.
+func (o *CreateKubeconfigOptions) Validate() error {
+	if o.namespace == "" || o.name == "" {
+		return errors.New("--serviceaccount is required, in the <namespace>/<name> format")
+	}
+	if o.Duration < 10*time.Minute {
+		return errors.New("--duration must be at least 10m")
+	}
+	return o.Options.Validate()
+}
+
+// Run creates the service account if needed, requests a token for it, and writes a kubeconfig
+// using that token for the current workspace, through the same server URL as the current context.
+func (o *CreateKubeconfigOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.RawConfig()
+	if err != nil {
+		return err
+	}
+	currentContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return fmt.Errorf("current context %q is not found in kubeconfig", config.CurrentContext)
+	}
+	currentCluster, ok := config.Clusters[currentContext.Cluster]
+	if !ok {
+		return fmt.Errorf("current cluster %q is not found in kubeconfig", currentContext.Cluster)
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(currentCluster.Server)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", currentCluster.Server)
+	}
+
+	created, err := o.ensureServiceAccount(ctx, o.namespace, o.name)
+	if err != nil {
+		return fmt.Errorf("failed to create service account %s: %w", o.ServiceAccount, err)
+	}
+	if created {
+		fmt.Fprintf(o.ErrOut, "Created service account %q in workspace %q.\n", o.ServiceAccount, currentClusterName)
+	}
+
+	expirationSeconds := int64(o.Duration / time.Second)
+	token, err := o.createToken(ctx, o.namespace, o.name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         o.Audiences,
+			ExpirationSeconds: &expirationSeconds,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request a token for service account %s: %w", o.ServiceAccount, err)
+	}
+
+	cluster := currentCluster.DeepCopy()
+	if cluster.CertificateAuthority != "" && len(cluster.CertificateAuthorityData) == 0 {
+		// embed the CA such that the kubeconfig is self-contained.
+		data, err := os.ReadFile(cluster.CertificateAuthority)
+		if err != nil {
+			return err
+		}
+		cluster.CertificateAuthorityData = data
+		cluster.CertificateAuthority = ""
+	}
+	cluster.LocationOfOrigin = ""
+
+	name := fmt.Sprintf("%s-%s-%s", currentClusterName, o.namespace, o.name)
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = cluster
+	kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: token.Status.Token}
+	kubeconfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	kubeconfig.CurrentContext = name
+
+	if o.Output == "-" {
+		data, err := clientcmd.Write(*kubeconfig)
+		if err != nil {
+			return err
+		}
+		_, err = o.Out.Write(data)
+		return err
+	}
+	if err := clientcmd.WriteToFile(*kubeconfig, o.Output); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.ErrOut, "Wrote kubeconfig for service account %q to %s, the token expires at %s.\n", o.ServiceAccount, o.Output, token.Status.ExpirationTimestamp.Format(time.RFC3339))
+	return err
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCreateKubeconfig(t *testing.T) {
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	opts := NewCreateKubeconfigOptions(streams)
+	opts.ServiceAccount = "ci/deployer"
+	opts.Audiences = []string{"https://kcp.example.com"}
+	opts.Duration = 2 * time.Hour
+	opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		CurrentContext: "test",
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://front-proxy/clusters/root:org", CertificateAuthorityData: []byte("ca")}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "admin"}},
+	}, nil)
+
+	opts.ensureServiceAccount = func(ctx context.Context, namespace, name string) (bool, error) {
+		require.Equal(t, "ci", namespace)
+		require.Equal(t, "deployer", name)
+		return true, nil
+	}
+	opts.createToken = func(ctx context.Context, namespace, name string, request *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+		require.Equal(t, []string{"https://kcp.example.com"}, request.Spec.Audiences)
+		require.Equal(t, int64(7200), *request.Spec.ExpirationSeconds)
+		request.Status = authenticationv1.TokenRequestStatus{
+			Token:               "sa-token",
+			ExpirationTimestamp: metav1.NewTime(time.Date(2022, 12, 1, 14, 0, 0, 0, time.UTC)),
+		}
+		return request, nil
+	}
+
+	opts.namespace, opts.name = "ci", "deployer"
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.Run(context.Background()))
+
+	kubeconfig, err := clientcmd.Load(out.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "root:org-ci-deployer", kubeconfig.CurrentContext)
+	cluster := kubeconfig.Clusters["root:org-ci-deployer"]
+	require.Equal(t, "https://front-proxy/clusters/root:org", cluster.Server)
+	require.Equal(t, []byte("ca"), cluster.CertificateAuthorityData)
+	require.Equal(t, "sa-token", kubeconfig.AuthInfos["root:org-ci-deployer"].Token)
+	require.Equal(t, "Created service account \"ci/deployer\" in workspace \"root:org\".\n", errOut.String())
+}
+
+func TestCreateKubeconfigValidate(t *testing.T) {
+	opts := NewCreateKubeconfigOptions(genericclioptions.NewTestIOStreamsDiscard())
+	require.Error(t, opts.Validate(), "a service account is required")
+
+	opts.namespace, opts.name = "ci", "deployer"
+	require.NoError(t, opts.Validate())
+
+	opts.Duration = time.Minute
+	require.Error(t, opts.Validate(), "the duration is below the minimum accepted by the TokenRequest API")
+}
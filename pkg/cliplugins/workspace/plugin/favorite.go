@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// FavoriteWorkspaceOptions contains options for managing the favorite workspaces proposed by the interactive picker.
+type FavoriteWorkspaceOptions struct {
+	*base.Options
+
+	// Name is the absolute path of the workspace. Defaults to the current workspace.
+	Name string
+	// Remove indicates the workspace should be removed from the favorites.
+	Remove bool
+	// List indicates the favorite workspaces should be listed.
+	List bool
+
+	// for testing
+	loadHistory func() (*workspaceHistory, error)
+	saveHistory func(history *workspaceHistory) error
+}
+
+// NewFavoriteWorkspaceOptions returns a new FavoriteWorkspaceOptions.
+func NewFavoriteWorkspaceOptions(streams genericclioptions.IOStreams) *FavoriteWorkspaceOptions {
+	return &FavoriteWorkspaceOptions{
+		Options: base.NewOptions(streams),
+
+		loadHistory: func() (*workspaceHistory, error) {
+			return loadWorkspaceHistory(defaultWorkspaceHistoryPath())
+		},
+		saveHistory: func(history *workspaceHistory) error {
+			return history.save(defaultWorkspaceHistoryPath())
+		},
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *FavoriteWorkspaceOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+	cmd.Flags().BoolVar(&o.Remove, "remove", o.Remove, "Remove the workspace from the favorites")
+	cmd.Flags().BoolVar(&o.List, "list", o.List, "List the favorite workspaces")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *FavoriteWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	return nil
+}
+
+// Validate validates the FavoriteWorkspaceOptions are complete and usable.
+func (o *FavoriteWorkspaceOptions) Validate() error {
+	if o.List && (o.Remove || o.Name != "") {
+		return fmt.Errorf("--list cannot be used with --remove or a workspace")
+	}
+	if o.Name != "" && !logicalcluster.NewPath(o.Name).IsValid() {
+		return fmt.Errorf("invalid workspace name format: %s", o.Name)
+	}
+	return o.Options.Validate()
+}
+
+// Run adds or removes a favorite workspace, or lists them.
+func (o *FavoriteWorkspaceOptions) Run(ctx context.Context) error {
+	history, err := o.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	if o.List {
+		for _, ws := range history.Favorites {
+			fmt.Fprintln(o.Out, ws)
+		}
+		return nil
+	}
+
+	name := o.Name
+	if name == "" {
+		config, err := o.ClientConfig.ClientConfig()
+		if err != nil {
+			return err
+		}
+		_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+		if err != nil {
+			return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+		}
+		name = currentClusterName.String()
+	}
+
+	if o.Remove {
+		if !history.removeFavorite(name) {
+			return fmt.Errorf("workspace %q is not a favorite", name)
+		}
+		if err := o.saveHistory(history); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.Out, "Workspace %q removed from favorites.\n", name)
+		return err
+	}
+
+	if !history.addFavorite(name) {
+		_, err = fmt.Fprintf(o.Out, "Workspace %q is already a favorite.\n", name)
+		return err
+	}
+	if err := o.saveHistory(history); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.Out, "Workspace %q added to favorites.\n", name)
+	return err
+}
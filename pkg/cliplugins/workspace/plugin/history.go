@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
+)
+
+// maxRecentWorkspaces is the number of recently used workspaces remembered for the interactive picker.
+const maxRecentWorkspaces = 10
+
+// workspaceHistory holds the recently used and favorite workspaces, by absolute path.
+type workspaceHistory struct {
+	Recent    []string `json:"recent,omitempty"`
+	Favorites []string `json:"favorites,omitempty"`
+}
+
+// defaultWorkspaceHistoryPath returns the path of the file where the workspace history is stored.
+func defaultWorkspaceHistoryPath() string {
+	return filepath.Join(homedir.HomeDir(), ".kcp", "workspaces.yaml")
+}
+
+func loadWorkspaceHistory(path string) (*workspaceHistory, error) {
+	history := &workspaceHistory{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (h *workspaceHistory) save(path string) error {
+	data, err := yaml.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// addRecent moves the workspace at the front of the recent workspaces.
+func (h *workspaceHistory) addRecent(workspace string) {
+	recent := []string{workspace}
+	for _, ws := range h.Recent {
+		if ws != workspace && len(recent) < maxRecentWorkspaces {
+			recent = append(recent, ws)
+		}
+	}
+	h.Recent = recent
+}
+
+// addFavorite adds the workspace to the favorites, and returns false if it was already a favorite.
+func (h *workspaceHistory) addFavorite(workspace string) bool {
+	for _, ws := range h.Favorites {
+		if ws == workspace {
+			return false
+		}
+	}
+	h.Favorites = append(h.Favorites, workspace)
+	return true
+}
+
+// removeFavorite removes the workspace from the favorites, and returns false if it was not a favorite.
+func (h *workspaceHistory) removeFavorite(workspace string) bool {
+	for i, ws := range h.Favorites {
+		if ws == workspace {
+			h.Favorites = append(h.Favorites[:i], h.Favorites[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
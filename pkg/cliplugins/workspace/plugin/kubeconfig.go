@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/xlab/treeprint"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -43,6 +45,8 @@ import (
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
 	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
@@ -61,6 +65,10 @@ type UseWorkspaceOptions struct {
 	Name string
 	// ShortWorkspaceOutput indicates only the workspace name should be printed.
 	ShortWorkspaceOutput bool
+	// Interactive indicates the workspace should be picked interactively.
+	Interactive bool
+	// Create indicates the workspace should be created, and waited for, if it does not exist.
+	Create bool
 
 	kcpClusterClient kcpclientset.ClusterInterface
 	startingConfig   *clientcmdapi.Config
@@ -68,6 +76,12 @@ type UseWorkspaceOptions struct {
 	// for testing
 	modifyConfig   func(configAccess clientcmd.ConfigAccess, newConfig *clientcmdapi.Config) error
 	getAPIBindings func(ctx context.Context, kcpClusterClient kcpclientset.ClusterInterface, host string) ([]apisv1alpha1.APIBinding, error)
+	loadHistory    func() (*workspaceHistory, error)
+	saveHistory    func(history *workspaceHistory) error
+
+	listChildWorkspaces func(ctx context.Context, parent logicalcluster.Path) ([]string, error)
+	completionCacheDir  string
+	now                 func() time.Time
 }
 
 // NewUseWorkspaceOptions returns a new UseWorkspaceOptions.
@@ -79,6 +93,14 @@ func NewUseWorkspaceOptions(streams genericclioptions.IOStreams) *UseWorkspaceOp
 			return clientcmd.ModifyConfig(configAccess, *newConfig, true)
 		},
 		getAPIBindings: getAPIBindings,
+		loadHistory: func() (*workspaceHistory, error) {
+			return loadWorkspaceHistory(defaultWorkspaceHistoryPath())
+		},
+		saveHistory: func(history *workspaceHistory) error {
+			return history.save(defaultWorkspaceHistoryPath())
+		},
+		completionCacheDir: defaultCompletionCacheDir(),
+		now:                time.Now,
 	}
 }
 
@@ -109,6 +131,12 @@ func (o *UseWorkspaceOptions) Complete(args []string) error {
 
 // Validate validates the UseWorkspaceOptions are complete and usable.
 func (o *UseWorkspaceOptions) Validate() error {
+	if o.Interactive && o.Name != "" {
+		return errors.New("a workspace cannot be specified in interactive mode")
+	}
+	if o.Create && (o.Name == "" || strings.Contains(o.Name, ":")) {
+		return errors.New("--create requires the name of a child workspace of the current workspace")
+	}
 	return o.Options.Validate()
 }
 
@@ -116,6 +144,8 @@ func (o *UseWorkspaceOptions) Validate() error {
 func (o *UseWorkspaceOptions) BindFlags(cmd *cobra.Command) {
 	o.Options.BindFlags(cmd)
 	cmd.Flags().BoolVar(&o.ShortWorkspaceOutput, "short", o.ShortWorkspaceOutput, "Print only the name of the workspace, e.g. for integration into the shell prompt")
+	cmd.Flags().BoolVarP(&o.Interactive, "interactive", "i", o.Interactive, "Pick the workspace interactively among the favorite, recent, parent and child workspaces")
+	cmd.Flags().BoolVar(&o.Create, "create", o.Create, "Create the child workspace if it does not exist, reporting the progress of its initializers, before entering it")
 }
 
 // Run executes the "use workspace" logic based on the supplied options.
@@ -131,6 +161,26 @@ func (o *UseWorkspaceOptions) Run(ctx context.Context) error {
 		return fmt.Errorf("current %q context not found", rawConfig.CurrentContext)
 	}
 
+	if o.Interactive {
+		history, err := o.loadHistory()
+		if err != nil {
+			return fmt.Errorf("error loading workspace history: %w", err)
+		}
+		candidates, err := o.workspaceCandidates(ctx, history)
+		if err != nil {
+			return err
+		}
+		name, err := pickWorkspace(o.In, o.Out, candidates)
+		if errors.Is(err, errPickerCanceled) {
+			fmt.Fprintln(o.ErrOut, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		o.Name = name
+	}
+
 	var newServerHost string
 	var workspaceType *tenancyv1beta1.WorkspaceTypeReference
 	switch o.Name {
@@ -169,6 +219,7 @@ func (o *UseWorkspaceOptions) Run(ctx context.Context) error {
 		}
 
 		newServerHost = newKubeConfig.Clusters[newKubeConfig.Contexts[kcpCurrentWorkspaceContextKey].Cluster].Server
+		o.recordRecentWorkspace(newServerHost)
 
 		bindings, err := o.getAPIBindings(ctx, o.kcpClusterClient, newServerHost)
 		if err != nil {
@@ -275,6 +326,9 @@ func (o *UseWorkspaceOptions) Run(ctx context.Context) error {
 		} else {
 			// relative logical cluster, get URL from workspace object in current context
 			ws, err := o.kcpClusterClient.Cluster(currentClusterName).TenancyV1beta1().Workspaces().Get(ctx, o.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) && o.Create {
+				return o.createWorkspace(ctx)
+			}
 			if err != nil {
 				return err
 			}
@@ -323,6 +377,7 @@ func (o *UseWorkspaceOptions) Run(ctx context.Context) error {
 	if err := o.modifyConfig(o.ClientConfig.ConfigAccess(), newKubeConfig); err != nil {
 		return err
 	}
+	o.recordRecentWorkspace(newServerHost)
 
 	bindings, err := o.getAPIBindings(ctx, o.kcpClusterClient, newServerHost)
 	if err != nil {
@@ -337,6 +392,23 @@ func (o *UseWorkspaceOptions) Run(ctx context.Context) error {
 	return currentWorkspace(o.Out, newServerHost, shortWorkspaceOutput(o.ShortWorkspaceOutput), workspaceType)
 }
 
+// recordRecentWorkspace adds the workspace served at the given host to the recent workspaces.
+// Failures are reported, but do not fail the command.
+func (o *UseWorkspaceOptions) recordRecentWorkspace(host string) {
+	_, clusterName, err := pluginhelpers.ParseClusterURL(host)
+	if err != nil {
+		return
+	}
+	history, err := o.loadHistory()
+	if err == nil {
+		history.addRecent(clusterName.String())
+		err = o.saveHistory(history)
+	}
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "error recording recent workspace: %v\n", err)
+	}
+}
+
 // getAPIBindings retrieves APIBindings within the workspace.
 func getAPIBindings(ctx context.Context, kcpClusterClient kcpclientset.ClusterInterface, host string) ([]apisv1alpha1.APIBinding, error) {
 	_, clusterName, err := pluginhelpers.ParseClusterURL(host)
@@ -447,6 +519,8 @@ type CreateWorkspaceOptions struct {
 	EnterAfterCreate bool
 	// IgnoreExisting ignores errors if the workspace already exists.
 	IgnoreExisting bool
+	// Wait indicates to wait for the workspace to be ready, reporting the initialization progress.
+	Wait bool
 	// ReadyWaitTimeout is how long to wait for the workspace to be ready before returning control to the user.
 	ReadyWaitTimeout time.Duration
 
@@ -454,6 +528,8 @@ type CreateWorkspaceOptions struct {
 
 	// for testing - passed to UseWorkspaceOptions
 	modifyConfig func(configAccess clientcmd.ConfigAccess, newConfig *clientcmdapi.Config) error
+	loadHistory  func() (*workspaceHistory, error)
+	saveHistory  func(history *workspaceHistory) error
 }
 
 // NewCreateWorkspaceOptions returns a new CreateWorkspaceOptions.
@@ -461,6 +537,7 @@ func NewCreateWorkspaceOptions(streams genericclioptions.IOStreams) *CreateWorks
 	return &CreateWorkspaceOptions{
 		Options: base.NewOptions(streams),
 
+		Wait:             true,
 		ReadyWaitTimeout: time.Minute,
 	}
 }
@@ -486,6 +563,9 @@ func (o *CreateWorkspaceOptions) Complete(args []string) error {
 
 // Validate validates the CreateWorkspaceOptions are complete and usable.
 func (o *CreateWorkspaceOptions) Validate() error {
+	if !o.Wait && o.EnterAfterCreate {
+		return errors.New("--enter cannot be used with --wait=false")
+	}
 	return o.Options.Validate()
 }
 
@@ -495,6 +575,8 @@ func (o *CreateWorkspaceOptions) BindFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Type, "type", o.Type, "A workspace type. The default type depends on where this child workspace is created.")
 	cmd.Flags().BoolVar(&o.EnterAfterCreate, "enter", o.EnterAfterCreate, "Immediately enter the created workspace")
 	cmd.Flags().BoolVar(&o.IgnoreExisting, "ignore-existing", o.IgnoreExisting, "Ignore if the workspace already exists. Requires none or absolute type path.")
+	cmd.Flags().BoolVar(&o.Wait, "wait", o.Wait, "Wait for the workspace to be ready, reporting the progress of its initializers")
+	cmd.Flags().DurationVar(&o.ReadyWaitTimeout, "wait-timeout", o.ReadyWaitTimeout, "How long to wait for the workspace to be ready")
 }
 
 // Run creates a workspace.
@@ -554,6 +636,14 @@ func (o *CreateWorkspaceOptions) Run(ctx context.Context) error {
 	}
 
 	workspaceReference := fmt.Sprintf("Workspace %q (type %s)", o.Name, ws.Spec.Type)
+	if !o.Wait && ws.Status.Phase != corev1alpha1.LogicalClusterPhaseReady {
+		if preExisting {
+			_, err = fmt.Fprintf(o.Out, "%s already exists.\n", workspaceReference)
+		} else {
+			_, err = fmt.Fprintf(o.Out, "%s created.\n", workspaceReference)
+		}
+		return err
+	}
 	if preExisting {
 		if ws.Spec.Type.Name != "" && ws.Spec.Type.Name != structuredWorkspaceType.Name || ws.Spec.Type.Path != structuredWorkspaceType.Path {
 			wsTypeString := logicalcluster.NewPath(ws.Spec.Type.Path).Join(string(ws.Spec.Type.Name)).String()
@@ -590,8 +680,9 @@ func (o *CreateWorkspaceOptions) Run(ctx context.Context) error {
 		return err
 	}
 
-	// wait for being ready
+	// wait for being ready, reporting the progress whenever it changes
 	if ws.Status.Phase != corev1alpha1.LogicalClusterPhaseReady {
+		var lastProgress string
 		if err := wait.PollImmediate(time.Millisecond*500, o.ReadyWaitTimeout, func() (bool, error) {
 			ws, err = o.kcpClusterClient.Cluster(currentClusterName).TenancyV1beta1().Workspaces().Get(ctx, ws.Name, metav1.GetOptions{})
 			if err != nil {
@@ -600,8 +691,16 @@ func (o *CreateWorkspaceOptions) Run(ctx context.Context) error {
 			if ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady {
 				return true, nil
 			}
+			if progress := workspaceProgress(ws); progress != lastProgress {
+				lastProgress = progress
+				if _, err := fmt.Fprintf(o.Out, "  %s\n", progress); err != nil {
+					return false, err
+				}
+			}
 			return false, nil
-		}); err != nil {
+		}); errors.Is(err, wait.ErrWaitTimeout) {
+			return fmt.Errorf("%s is not ready after %s: %s", workspaceReference, o.ReadyWaitTimeout, workspaceProgress(ws))
+		} else if err != nil {
 			return err
 		}
 	}
@@ -616,6 +715,12 @@ func (o *CreateWorkspaceOptions) Run(ctx context.Context) error {
 		if o.modifyConfig != nil {
 			useOptions.modifyConfig = o.modifyConfig
 		}
+		if o.loadHistory != nil {
+			useOptions.loadHistory = o.loadHistory
+		}
+		if o.saveHistory != nil {
+			useOptions.saveHistory = o.saveHistory
+		}
 		if err := useOptions.Complete(nil); err != nil {
 			return err
 		}
@@ -628,6 +733,60 @@ func (o *CreateWorkspaceOptions) Run(ctx context.Context) error {
 	return nil
 }
 
+// createWorkspace creates the workspace as a child of the current workspace, waits for it to be ready, and enters it.
+func (o *UseWorkspaceOptions) createWorkspace(ctx context.Context) error {
+	createOptions := NewCreateWorkspaceOptions(o.IOStreams)
+	createOptions.Options = o.Options
+	createOptions.Name = o.Name
+	createOptions.EnterAfterCreate = true
+	createOptions.kcpClusterClient = o.kcpClusterClient
+	createOptions.modifyConfig = o.modifyConfig
+	createOptions.loadHistory = o.loadHistory
+	createOptions.saveHistory = o.saveHistory
+	if err := createOptions.Validate(); err != nil {
+		return err
+	}
+	return createOptions.Run(ctx)
+}
+
+// workspaceProgress describes why a workspace is not ready yet, i.e., its phase, its pending
+// initializers, and the conditions that are not true, with their reason.
+func workspaceProgress(ws *tenancyv1beta1.Workspace) string {
+	phase := string(ws.Status.Phase)
+	if phase == "" {
+		phase = "Scheduling"
+	}
+	progress := []string{phase}
+
+	if len(ws.Status.Initializers) > 0 {
+		initializers := make([]string, 0, len(ws.Status.Initializers))
+		for _, initializer := range ws.Status.Initializers {
+			initializers = append(initializers, string(initializer))
+		}
+		progress = append(progress, fmt.Sprintf("pending initializers: %s", strings.Join(initializers, ", ")))
+	}
+
+	for _, c := range ws.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			continue
+		}
+		state := "not ready"
+		if c.Severity == conditionsv1alpha1.ConditionSeverityError {
+			state = "failed"
+		}
+		condition := fmt.Sprintf("%s %s", c.Type, state)
+		if c.Reason != "" {
+			condition += fmt.Sprintf(" (%s)", c.Reason)
+		}
+		if c.Message != "" {
+			condition += ": " + c.Message
+		}
+		progress = append(progress, condition)
+	}
+
+	return strings.Join(progress, "; ")
+}
+
 // CreateContextOptions contains options for creating or updating a kubeconfig context.
 type CreateContextOptions struct {
 	*base.Options
@@ -758,6 +917,8 @@ type TreeOptions struct {
 	*base.Options
 
 	Full bool
+	// ShowBindings annotates each workspace with its APIBindings and the APIExports it publishes.
+	ShowBindings bool
 
 	kcpClusterClient kcpclientset.ClusterInterface
 }
@@ -773,6 +934,7 @@ func NewTreeOptions(streams genericclioptions.IOStreams) *TreeOptions {
 func (o *TreeOptions) BindFlags(cmd *cobra.Command) {
 	o.Options.BindFlags(cmd)
 	cmd.Flags().BoolVarP(&o.Full, "full", "f", o.Full, "Show full workspaces names")
+	cmd.Flags().BoolVar(&o.ShowBindings, "show-bindings", o.ShowBindings, "Show the APIBindings of each workspace, with the APIExport they bind and whether they are ready, and the APIExports it publishes")
 }
 
 // Complete ensures all dynamically populated fields are initialized.
@@ -807,8 +969,8 @@ func (o *TreeOptions) Run(ctx context.Context) error {
 		return err
 	}
 
-	fmt.Println(tree.String())
-	return nil
+	_, err = fmt.Fprintln(o.Out, tree.String())
+	return err
 }
 
 func (o *TreeOptions) populateBranch(ctx context.Context, tree treeprint.Tree, name logicalcluster.Path) error {
@@ -819,6 +981,12 @@ func (o *TreeOptions) populateBranch(ctx context.Context, tree treeprint.Tree, n
 		b = tree.AddBranch(name.Base())
 	}
 
+	if o.ShowBindings {
+		if err := o.addAPINodes(ctx, b, name); err != nil {
+			return err
+		}
+	}
+
 	results, err := o.kcpClusterClient.Cluster(name).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -839,3 +1007,42 @@ func (o *TreeOptions) populateBranch(ctx context.Context, tree treeprint.Tree, n
 	}
 	return nil
 }
+
+// addAPINodes adds the APIBindings of the workspace, and the APIExports it publishes, to its branch.
+// They are omitted if the user is not allowed to list them.
+func (o *TreeOptions) addAPINodes(ctx context.Context, branch treeprint.Tree, name logicalcluster.Path) error {
+	bindings, err := o.kcpClusterClient.Cluster(name).ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsForbidden(err) && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		sort.Slice(bindings.Items, func(i, j int) bool { return bindings.Items[i].Name < bindings.Items[j].Name })
+		for _, binding := range bindings.Items {
+			export := "<unknown>"
+			if ref := binding.Spec.Reference.Export; ref != nil {
+				export = ref.Name
+				if ref.Path != "" {
+					export = logicalcluster.NewPath(ref.Path).Join(ref.Name).String()
+				}
+			}
+			ready := "NotReady"
+			if conditions.IsTrue(&binding, conditionsv1alpha1.ReadyCondition) {
+				ready = "Ready"
+			}
+			branch.AddNode(fmt.Sprintf("binding %s -> %s (%s)", binding.Name, export, ready))
+		}
+	}
+
+	exports, err := o.kcpClusterClient.Cluster(name).ApisV1alpha1().APIExports().List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsForbidden(err) && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		sort.Slice(exports.Items, func(i, j int) bool { return exports.Items[i].Name < exports.Items[j].Name })
+		for _, export := range exports.Items {
+			branch.AddNode(fmt.Sprintf("export %s", export.Name))
+		}
+	}
+
+	return nil
+}
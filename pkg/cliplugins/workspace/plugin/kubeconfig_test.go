@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	kcptesting "github.com/kcp-dev/client-go/third_party/k8s.io/client-go/testing"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -42,7 +43,9 @@ import (
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster/fake"
@@ -247,6 +250,12 @@ func TestCreate(t *testing.T) {
 				got = config
 				return nil
 			}
+			opts.loadHistory = func() (*workspaceHistory, error) {
+				return &workspaceHistory{}, nil
+			}
+			opts.saveHistory = func(*workspaceHistory) error {
+				return nil
+			}
 			opts.kcpClusterClient = client
 			opts.ClientConfig = clientcmd.NewDefaultClientConfig(*tt.config.DeepCopy(), nil)
 			err := opts.Run(context.Background())
@@ -267,6 +276,22 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestWorkspaceProgress(t *testing.T) {
+	ws := &tenancyv1beta1.Workspace{}
+	require.Equal(t, "Scheduling", workspaceProgress(ws))
+
+	ws.Status.Phase = corev1alpha1.LogicalClusterPhaseInitializing
+	ws.Status.Initializers = []corev1alpha1.LogicalClusterInitializer{"root:universal", "system:apibindings"}
+	ws.Status.Conditions = conditionsv1alpha1.Conditions{
+		{Type: tenancyv1alpha1.WorkspaceScheduled, Status: corev1.ConditionTrue},
+		{Type: tenancyv1alpha1.WorkspaceInitialized, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityInfo, Reason: tenancyv1alpha1.WorkspaceInitializedInitializerExists},
+		{Type: tenancyv1alpha1.WorkspaceAPIBindingsInitialized, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityError, Reason: tenancyv1alpha1.WorkspaceInitializedAPIBindingErrors, Message: "APIExport root:compute:kubernetes not found"},
+	}
+	require.Equal(t, "Initializing; pending initializers: root:universal, system:apibindings; "+
+		"WorkspaceInitialized not ready (InitializerExists); "+
+		"APIBindingsInitialized failed (APIBindingErrors): APIExport root:compute:kubernetes not found", workspaceProgress(ws))
+}
+
 func TestUse(t *testing.T) {
 	homeWorkspaceLogicalCluster := logicalcluster.NewPath("root:users:ab:cd:user-name")
 	tests := []struct {
@@ -1269,6 +1294,12 @@ func TestUse(t *testing.T) {
 				return nil
 			}
 			opts.getAPIBindings = getAPIBindings
+			opts.loadHistory = func() (*workspaceHistory, error) {
+				return &workspaceHistory{}, nil
+			}
+			opts.saveHistory = func(*workspaceHistory) error {
+				return nil
+			}
 			opts.kcpClusterClient = fakeClusterClientWithDiscoveryErrors{
 				ClusterClientset: client,
 				discoveryErrs:    tt.discoveryErrors,
@@ -1617,3 +1648,49 @@ func (b *bindingBuilder) WithExportClaim(group, resource, identityHash string) *
 func (b *bindingBuilder) Build() apisv1alpha1.APIBinding {
 	return b.APIBinding
 }
+
+func TestTreeShowBindings(t *testing.T) {
+	client := kcpfakeclient.NewSimpleClientset(
+		&tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"}},
+			Status:     tenancyv1beta1.WorkspaceStatus{Phase: corev1alpha1.LogicalClusterPhaseReady, URL: "https://test/clusters/root:org:team-a"},
+		},
+		&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets", Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"}},
+		},
+		&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets", Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:team-a"}},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.BindingReference{Export: &apisv1alpha1.ExportBindingReference{Path: "root:org", Name: "widgets"}},
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				Conditions: conditionsv1alpha1.Conditions{{Type: conditionsv1alpha1.ReadyCondition, Status: corev1.ConditionTrue}},
+			},
+		},
+		&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadgets", Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:team-a"}},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.BindingReference{Export: &apisv1alpha1.ExportBindingReference{Name: "gadgets"}},
+			},
+		},
+	)
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	opts := NewTreeOptions(streams)
+	opts.ShowBindings = true
+	opts.kcpClusterClient = client
+	opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		CurrentContext: "test",
+		Contexts:       map[string]*clientcmdapi.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		Clusters:       map[string]*clientcmdapi.Cluster{"test": {Server: "https://test/clusters/root:org"}},
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+	}, nil)
+
+	require.NoError(t, opts.Run(context.Background()))
+	lines := strings.Split(out.String(), "\n")
+	require.Contains(t, lines, "└── org")
+	require.Contains(t, lines, "    ├── export widgets")
+	require.Contains(t, lines, "    └── team-a")
+	require.Contains(t, lines, "        ├── binding gadgets -> gadgets (NotReady)")
+	require.Contains(t, lines, "        └── binding widgets -> root:org:widgets (Ready)")
+}
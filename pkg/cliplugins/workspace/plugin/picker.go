@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// errPickerCanceled is returned when no workspace is picked.
+var errPickerCanceled = errors.New("no workspace selected")
+
+// workspaceCandidate is a workspace proposed by the interactive picker.
+type workspaceCandidate struct {
+	// Path is the absolute path of the workspace.
+	Path string
+	// Source explains why the workspace is proposed, i.e., favorite, recent, child or parent.
+	Source string
+}
+
+// workspaceCandidates returns the workspaces proposed by the interactive picker, i.e., the favorite
+// and recent workspaces, and the parent and the ready children of the current workspace.
+func (o *UseWorkspaceOptions) workspaceCandidates(ctx context.Context, history *workspaceHistory) ([]workspaceCandidate, error) {
+	var candidates []workspaceCandidate
+	seen := map[string]bool{}
+	add := func(path, source string) {
+		if path == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+		candidates = append(candidates, workspaceCandidate{Path: path, Source: source})
+	}
+
+	for _, ws := range history.Favorites {
+		add(ws, "favorite")
+	}
+	for _, ws := range history.Recent {
+		add(ws, "recent")
+	}
+
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	if parent, hasParent := currentClusterName.Parent(); hasParent {
+		add(parent.String(), "parent")
+	}
+
+	workspaces, err := o.kcpClusterClient.Cluster(currentClusterName).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsForbidden(err) {
+		return nil, err
+	}
+	if err == nil {
+		children := make([]string, 0, len(workspaces.Items))
+		for _, ws := range workspaces.Items {
+			if ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady {
+				children = append(children, currentClusterName.Join(ws.Name).String())
+			}
+		}
+		sort.Strings(children)
+		for _, child := range children {
+			add(child, "child")
+		}
+	}
+
+	if len(candidates) == 0 {
+		add(core.RootCluster.String(), "root")
+	}
+
+	return candidates, nil
+}
+
+// fuzzyMatch returns whether all the characters of the pattern appear in order in the value,
+// and a score that is lower the closer the matched characters are to each other.
+func fuzzyMatch(pattern, value string) (bool, int) {
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+	if pattern == "" {
+		return true, 0
+	}
+
+	score, last := 0, -1
+	patternRunes := []rune(pattern)
+	i := 0
+	for j, r := range []rune(value) {
+		if i < len(patternRunes) && r == patternRunes[i] {
+			if last >= 0 {
+				score += j - last - 1
+			}
+			last = j
+			i++
+		}
+	}
+	return i == len(patternRunes), score
+}
+
+// filterCandidates returns the candidates matching the pattern, the best matches first.
+func filterCandidates(candidates []workspaceCandidate, pattern string) []workspaceCandidate {
+	type scored struct {
+		workspaceCandidate
+		score int
+	}
+	var matches []scored
+	for _, c := range candidates {
+		if ok, score := fuzzyMatch(pattern, c.Path); ok {
+			matches = append(matches, scored{workspaceCandidate: c, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	filtered := make([]workspaceCandidate, 0, len(matches))
+	for _, m := range matches {
+		filtered = append(filtered, m.workspaceCandidate)
+	}
+	return filtered
+}
+
+// pickWorkspace prompts for a workspace among the candidates. The input is either a number
+// selecting a listed workspace, or a pattern to narrow down the list with fuzzy matching.
+// An empty input cancels the selection.
+func pickWorkspace(in io.Reader, out io.Writer, candidates []workspaceCandidate) (string, error) {
+	reader := bufio.NewReader(in)
+	filtered := candidates
+	for {
+		if len(filtered) == 0 {
+			fmt.Fprintln(out, "No matching workspace.")
+			filtered = candidates
+		}
+		for i, c := range filtered {
+			fmt.Fprintf(out, "%3d) %s (%s)\n", i+1, c.Path, c.Source)
+		}
+		fmt.Fprint(out, "Select a workspace by number, or type to filter (empty to cancel): ")
+
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		input := strings.TrimSpace(line)
+		if input == "" {
+			return "", errPickerCanceled
+		}
+
+		if n, convErr := strconv.Atoi(input); convErr == nil {
+			if n < 1 || n > len(filtered) {
+				fmt.Fprintf(out, "Invalid selection %d.\n", n)
+				continue
+			}
+			return filtered[n-1].Path, nil
+		}
+
+		filtered = filterCandidates(candidates, input)
+		if len(filtered) == 1 {
+			return filtered[0].Path, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return "", errPickerCanceled
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		wantMatch      bool
+		wantScore      int
+	}{
+		{pattern: "", value: "root:foo", wantMatch: true, wantScore: 0},
+		{pattern: "foo", value: "root:foo", wantMatch: true, wantScore: 0},
+		{pattern: "FOO", value: "root:foo", wantMatch: true, wantScore: 0},
+		{pattern: "rf", value: "root:foo", wantMatch: true, wantScore: 4},
+		{pattern: "bar", value: "root:foo", wantMatch: false},
+		{pattern: "fr", value: "root:foo", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.value, func(t *testing.T) {
+			match, score := fuzzyMatch(tt.pattern, tt.value)
+			require.Equal(t, tt.wantMatch, match)
+			if tt.wantMatch {
+				require.Equal(t, tt.wantScore, score)
+			}
+		})
+	}
+}
+
+func TestPickWorkspace(t *testing.T) {
+	candidates := []workspaceCandidate{
+		{Path: "root:org:team-a", Source: "favorite"},
+		{Path: "root:org", Source: "parent"},
+		{Path: "root:org:team-b", Source: "child"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "select by number", input: "2\n", want: "root:org"},
+		{name: "invalid number then valid one", input: "7\n3\n", want: "root:org:team-b"},
+		{name: "filter to a single match", input: "tb\n", want: "root:org:team-b"},
+		{name: "filter then select", input: "team\n2\n", want: "root:org:team-b"},
+		{name: "no input cancels", input: "", wantErr: errPickerCanceled},
+		{name: "empty line cancels", input: "\n", wantErr: errPickerCanceled},
+		{name: "ambiguous filter at end of input cancels", input: "team", wantErr: errPickerCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickWorkspace(strings.NewReader(tt.input), &bytes.Buffer{}, candidates)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWorkspaceHistory(t *testing.T) {
+	history := &workspaceHistory{}
+	for i := 0; i < maxRecentWorkspaces+2; i++ {
+		history.addRecent("root:" + strings.Repeat("a", i+1))
+	}
+	history.addRecent("root:aaa")
+	require.Len(t, history.Recent, maxRecentWorkspaces)
+	require.Equal(t, "root:aaa", history.Recent[0])
+	require.Equal(t, "root:"+strings.Repeat("a", maxRecentWorkspaces+2), history.Recent[1])
+
+	require.True(t, history.addFavorite("root:org"))
+	require.False(t, history.addFavorite("root:org"))
+	require.True(t, history.removeFavorite("root:org"))
+	require.False(t, history.removeFavorite("root:org"))
+	require.Empty(t, history.Favorites)
+
+	path := t.TempDir() + "/workspaces.yaml"
+	require.NoError(t, history.save(path))
+	loaded, err := loadWorkspaceHistory(path)
+	require.NoError(t, err)
+	require.Equal(t, history.Recent, loaded.Recent)
+}
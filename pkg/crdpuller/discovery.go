@@ -95,10 +95,30 @@ func NewSchemaPuller(
 	}, nil
 }
 
+// PullOptions customizes how the API resources are pulled as CRDs.
+type PullOptions struct {
+	// Versions restricts the pulled versions of each resource to the given ones, e.g. v1beta1.
+	// Resources that are not served at any of these versions are skipped.
+	Versions []string
+	// AllVersions pulls all the served versions of each resource, instead of the preferred version only.
+	// All the served versions of CRDs with a webhook conversion strategy are always pulled.
+	AllVersions bool
+	// StripStatus removes the status from the pulled schemas, as well as the status subresource.
+	StripStatus bool
+	// StripDefaults removes the default values from the pulled schemas.
+	StripDefaults bool
+}
+
 // PullCRDs allows pulling the resources named by their plural names
 // and make them available as CRDs in the output map.
 // If the list of resources is empty, it will try pulling all the resources it finds.
 func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
+	return sp.PullCRDsWithOptions(ctx, PullOptions{}, resourceNames...)
+}
+
+// PullCRDsWithOptions is like PullCRDs, with the pulled versions and the content of the schemas
+// customized by the options.
+func (sp *schemaPuller) PullCRDsWithOptions(ctx context.Context, options PullOptions, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
 	logger := klog.FromContext(ctx)
 
 	pullAllResources := len(resourceNames) == 0
@@ -118,6 +138,8 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 		return nil, err
 	}
 	apiResourceNames := map[schema.GroupVersion]sets.String{}
+	// servedVersions records the versions each resource is served at, by order of priority.
+	servedVersions := map[schema.GroupResource][]string{}
 	for _, apiResourcesList := range apiResourcesLists {
 		gv, err := schema.ParseGroupVersion(apiResourcesList.GroupVersion)
 		if err != nil {
@@ -127,6 +149,10 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 		apiResourceNames[gv] = sets.NewString()
 		for _, apiResource := range apiResourcesList.APIResources {
 			apiResourceNames[gv].Insert(apiResource.Name)
+			if !strings.Contains(apiResource.Name, "/") {
+				gr := gv.WithResource(apiResource.Name).GroupResource()
+				servedVersions[gr] = append(servedVersions[gr], gv.Version)
+			}
 		}
 	}
 
@@ -184,80 +210,43 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 
 			logger = logger.WithValues("crd", crdName)
 			logger.Info("processing discovery")
-			var schemaProps apiextensionsv1.JSONSchemaProps
-			var additionalPrinterColumns []apiextensionsv1.CustomResourceColumnDefinition
 			crd, err := sp.getCRD(ctx, crdName)
-			if err == nil {
-				if apihelpers.IsCRDConditionTrue(crd, apiextensionsv1.NonStructuralSchema) {
-					logger.Info("non-structural schema: the resources will not be validated")
-					schemaProps = apiextensionsv1.JSONSchemaProps{
-						Type:                   "object",
-						XPreserveUnknownFields: boolPtr(true),
-					}
-				} else {
-					var versionFound bool
-					for _, version := range crd.Spec.Versions {
-						if version.Name == gv.Version {
-							schemaProps = *version.Schema.OpenAPIV3Schema
-							additionalPrinterColumns = version.AdditionalPrinterColumns
-							versionFound = true
-							break
-						}
-					}
-					if !versionFound {
-						logger.Error(nil, "expected version not found in CRD")
-						schemaProps = apiextensionsv1.JSONSchemaProps{
-							Type:                   "object",
-							XPreserveUnknownFields: boolPtr(true),
-						}
-					}
-				}
-			} else {
+			if err != nil {
 				if !errors.IsNotFound(err) {
 					logger.Error(err, "error looking up CRD")
 					return nil, err
 				}
-				protoSchema := sp.models[gvk]
-				if protoSchema == nil {
-					logger.Info("ignoring a resource that has no OpenAPI Schema")
-					continue
-				}
-				swaggerSpecDefinitionName := protoSchema.GetPath().String()
-
-				var errors []error
-				converter := &SchemaConverter{
-					schemaProps: &schemaProps,
-					schemaName:  swaggerSpecDefinitionName,
-					visited:     sets.NewString(),
-					errors:      &errors,
-				}
-				protoSchema.Accept(converter)
-				if len(*converter.errors) > 0 {
-					logger.Error(kerrors.NewAggregate(*converter.errors), "error during the OpenAPI schema import of resource")
-					continue
-				}
+				crd = nil
 			}
 
-			hasSubResource := func(subResource string) bool {
-				groupResourceNames := apiResourceNames[gv]
-				if groupResourceNames != nil {
-					return groupResourceNames.Has(apiResource.Name + "/" + subResource)
-				}
-				return false
+			versionsToPull := selectVersions(options, crd, gv.Version, servedVersions[groupResource])
+			if len(versionsToPull) == 0 {
+				logger.Info("ignoring a resource that is not served at any of the selected versions")
+				continue
 			}
 
-			statusSubResource := &apiextensionsv1.CustomResourceSubresourceStatus{}
-			if !hasSubResource("status") {
-				statusSubResource = nil
-			}
+			var versions []apiextensionsv1.CustomResourceDefinitionVersion
+			for _, version := range versionsToPull {
+				logger := logger.WithValues("version", version)
+				versionGV := schema.GroupVersion{Group: gv.Group, Version: version}
 
-			scaleSubResource := &apiextensionsv1.CustomResourceSubresourceScale{
-				SpecReplicasPath:   ".spec.replicas",
-				StatusReplicasPath: ".status.replicas",
+				crdVersion, ok := sp.pullVersion(logger, crd, versionGV.WithKind(apiResource.Kind), apiResource.Name, apiResourceNames[versionGV])
+				if !ok {
+					continue
+				}
+				if options.StripStatus {
+					delete(crdVersion.Schema.OpenAPIV3Schema.Properties, "status")
+					crdVersion.Subresources.Status = nil
+				}
+				if options.StripDefaults {
+					stripDefaults(crdVersion.Schema.OpenAPIV3Schema)
+				}
+				versions = append(versions, crdVersion)
 			}
-			if !hasSubResource("scale") {
-				scaleSubResource = nil
+			if len(versions) == 0 {
+				continue
 			}
+			setStorageVersion(versions, crd, gv.Version)
 
 			publishedCRD := &apiextensionsv1.CustomResourceDefinition{
 				TypeMeta: metav1.TypeMeta{
@@ -270,22 +259,9 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 					Annotations: map[string]string{},
 				},
 				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
-					Group: gv.Group,
-					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
-						{
-							Name: gv.Version,
-							Schema: &apiextensionsv1.CustomResourceValidation{
-								OpenAPIV3Schema: &schemaProps,
-							},
-							Subresources: &apiextensionsv1.CustomResourceSubresources{
-								Status: statusSubResource,
-								Scale:  scaleSubResource,
-							},
-							Served:  true,
-							Storage: true,
-						},
-					},
-					Scope: resourceScope,
+					Group:    gv.Group,
+					Versions: versions,
+					Scope:    resourceScope,
 					Names: apiextensionsv1.CustomResourceDefinitionNames{
 						Plural:     apiResource.Name,
 						Kind:       apiResource.Kind,
@@ -295,8 +271,10 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 					},
 				},
 			}
-			if len(additionalPrinterColumns) != 0 {
-				publishedCRD.Spec.Versions[0].AdditionalPrinterColumns = additionalPrinterColumns
+			// The conversion webhook of the physical cluster cannot be reached from kcp, so the pulled versions
+			// are converted by only changing the apiVersion, which works for schema-compatible versions.
+			if crd != nil && crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter && len(versions) > 1 {
+				logger.Info("the conversion webhook is replaced with the None conversion strategy")
 			}
 			apiextensionsv1.SetDefaults_CustomResourceDefinition(publishedCRD)
 
@@ -324,6 +302,175 @@ func (sp *schemaPuller) PullCRDs(ctx context.Context, resourceNames ...string) (
 	return crds, nil
 }
 
+// selectVersions returns the versions of a resource to pull, among the versions it is served at.
+func selectVersions(options PullOptions, crd *apiextensionsv1.CustomResourceDefinition, preferredVersion string, servedVersions []string) []string {
+	if len(servedVersions) == 0 {
+		servedVersions = []string{preferredVersion}
+	}
+
+	if len(options.Versions) > 0 {
+		selected := sets.NewString(options.Versions...)
+		var versions []string
+		for _, version := range servedVersions {
+			if selected.Has(version) {
+				versions = append(versions, version)
+			}
+		}
+		return versions
+	}
+
+	hasConversionWebhook := crd != nil && crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter
+	if options.AllVersions || hasConversionWebhook {
+		return servedVersions
+	}
+
+	return []string{preferredVersion}
+}
+
+// pullVersion returns the CRD version of a resource, from its CRD in the physical cluster if any,
+// or else from its OpenAPI schema. It returns false if the version cannot be pulled.
+func (sp *schemaPuller) pullVersion(logger klog.Logger, crd *apiextensionsv1.CustomResourceDefinition, gvk schema.GroupVersionKind, resourceName string, groupVersionResourceNames sets.String) (apiextensionsv1.CustomResourceDefinitionVersion, bool) {
+	var schemaProps apiextensionsv1.JSONSchemaProps
+	var additionalPrinterColumns []apiextensionsv1.CustomResourceColumnDefinition
+	if crd != nil {
+		if apihelpers.IsCRDConditionTrue(crd, apiextensionsv1.NonStructuralSchema) {
+			logger.Info("non-structural schema: the resources will not be validated")
+			schemaProps = apiextensionsv1.JSONSchemaProps{
+				Type:                   "object",
+				XPreserveUnknownFields: boolPtr(true),
+			}
+		} else {
+			var versionFound bool
+			for _, version := range crd.Spec.Versions {
+				if version.Name == gvk.Version && version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+					schemaProps = *version.Schema.OpenAPIV3Schema.DeepCopy()
+					additionalPrinterColumns = version.AdditionalPrinterColumns
+					versionFound = true
+					break
+				}
+			}
+			if !versionFound {
+				logger.Error(nil, "expected version not found in CRD")
+				schemaProps = apiextensionsv1.JSONSchemaProps{
+					Type:                   "object",
+					XPreserveUnknownFields: boolPtr(true),
+				}
+			}
+		}
+	} else {
+		protoSchema := sp.models[gvk]
+		if protoSchema == nil {
+			logger.Info("ignoring a resource that has no OpenAPI Schema")
+			return apiextensionsv1.CustomResourceDefinitionVersion{}, false
+		}
+		swaggerSpecDefinitionName := protoSchema.GetPath().String()
+
+		var errors []error
+		converter := &SchemaConverter{
+			schemaProps: &schemaProps,
+			schemaName:  swaggerSpecDefinitionName,
+			visited:     sets.NewString(),
+			errors:      &errors,
+		}
+		protoSchema.Accept(converter)
+		if len(*converter.errors) > 0 {
+			logger.Error(kerrors.NewAggregate(*converter.errors), "error during the OpenAPI schema import of resource")
+			return apiextensionsv1.CustomResourceDefinitionVersion{}, false
+		}
+	}
+
+	hasSubResource := func(subResource string) bool {
+		if groupVersionResourceNames != nil {
+			return groupVersionResourceNames.Has(resourceName + "/" + subResource)
+		}
+		return false
+	}
+
+	statusSubResource := &apiextensionsv1.CustomResourceSubresourceStatus{}
+	if !hasSubResource("status") {
+		statusSubResource = nil
+	}
+
+	scaleSubResource := &apiextensionsv1.CustomResourceSubresourceScale{
+		SpecReplicasPath:   ".spec.replicas",
+		StatusReplicasPath: ".status.replicas",
+	}
+	if !hasSubResource("scale") {
+		scaleSubResource = nil
+	}
+
+	version := apiextensionsv1.CustomResourceDefinitionVersion{
+		Name: gvk.Version,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &schemaProps,
+		},
+		Subresources: &apiextensionsv1.CustomResourceSubresources{
+			Status: statusSubResource,
+			Scale:  scaleSubResource,
+		},
+		Served: true,
+	}
+	if len(additionalPrinterColumns) != 0 {
+		version.AdditionalPrinterColumns = additionalPrinterColumns
+	}
+	return version, true
+}
+
+// setStorageVersion marks the storage version of the physical cluster CRD as the storage version
+// if it has been pulled, or else the preferred version, or else the first pulled version.
+func setStorageVersion(versions []apiextensionsv1.CustomResourceDefinitionVersion, crd *apiextensionsv1.CustomResourceDefinition, preferredVersion string) {
+	storage := -1
+	if crd != nil {
+		for _, v := range crd.Spec.Versions {
+			if !v.Storage {
+				continue
+			}
+			for i := range versions {
+				if versions[i].Name == v.Name {
+					storage = i
+				}
+			}
+		}
+	}
+	if storage < 0 {
+		for i := range versions {
+			if versions[i].Name == preferredVersion {
+				storage = i
+			}
+		}
+	}
+	if storage < 0 {
+		storage = 0
+	}
+	versions[storage].Storage = true
+}
+
+// stripDefaults removes the default values from the schema and its sub-schemas.
+func stripDefaults(schemaProps *apiextensionsv1.JSONSchemaProps) {
+	if schemaProps == nil {
+		return
+	}
+	schemaProps.Default = nil
+	for name, property := range schemaProps.Properties {
+		stripDefaults(&property)
+		schemaProps.Properties[name] = property
+	}
+	if schemaProps.Items != nil {
+		stripDefaults(schemaProps.Items.Schema)
+		for i := range schemaProps.Items.JSONSchemas {
+			stripDefaults(&schemaProps.Items.JSONSchemas[i])
+		}
+	}
+	if schemaProps.AdditionalProperties != nil {
+		stripDefaults(schemaProps.AdditionalProperties.Schema)
+	}
+	for _, schemas := range [][]apiextensionsv1.JSONSchemaProps{schemaProps.AllOf, schemaProps.AnyOf, schemaProps.OneOf} {
+		for i := range schemas {
+			stripDefaults(&schemas[i])
+		}
+	}
+}
+
 type SchemaConverter struct {
 	schemaProps *apiextensionsv1.JSONSchemaProps
 	schemaName  string
@@ -113,3 +113,114 @@ func TestPuller(t *testing.T) {
 	require.Equal(t, 1, getCRDCount)
 	require.Equal(t, "pods.core", getCRDName)
 }
+
+func TestPullerWithOptions(t *testing.T) {
+	widgetSchema := func(description string) *apiextensionsv1.CustomResourceValidation {
+		return &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:        "object",
+				Description: description,
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"spec": {
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"size": {Type: "integer", Default: &apiextensionsv1.JSON{Raw: []byte(`1`)}},
+						},
+					},
+					"status": {Type: "object"},
+				},
+			},
+		}
+	}
+
+	newPuller := func(conversion apiextensionsv1.ConversionStrategyType) *schemaPuller {
+		return &schemaPuller{
+			serverGroupsAndResources: func() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+				return nil, []*metav1.APIResourceList{
+					{
+						GroupVersion: "example.io/v1",
+						APIResources: []metav1.APIResource{
+							{Name: "widgets", Namespaced: true, Kind: "Widget"},
+							{Name: "widgets/status", Namespaced: true, Kind: "Widget"},
+						},
+					},
+					{
+						GroupVersion: "example.io/v1beta1",
+						APIResources: []metav1.APIResource{
+							{Name: "widgets", Namespaced: true, Kind: "Widget"},
+						},
+					},
+				}, nil
+			},
+			serverPreferredResources: func() ([]*metav1.APIResourceList, error) {
+				return []*metav1.APIResourceList{
+					{
+						GroupVersion: "example.io/v1",
+						APIResources: []metav1.APIResource{
+							{Name: "widgets", Namespaced: true, Kind: "Widget"},
+						},
+					},
+				}, nil
+			},
+			getCRD: func(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+				return &apiextensionsv1.CustomResourceDefinition{
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Group: "example.io",
+						Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+							{Name: "v1", Served: true, Schema: widgetSchema("v1")},
+							{Name: "v1beta1", Served: true, Storage: true, Schema: widgetSchema("v1beta1")},
+						},
+						Conversion: &apiextensionsv1.CustomResourceConversion{Strategy: conversion},
+					},
+				}, nil
+			},
+			resourceFor: func(groupResource schema.GroupResource) (schema.GroupResource, error) {
+				return groupResource, nil
+			},
+		}
+	}
+
+	widgets := schema.GroupResource{Group: "example.io", Resource: "widgets"}
+
+	t.Run("preferred version only", func(t *testing.T) {
+		crds, err := newPuller(apiextensionsv1.NoneConverter).PullCRDsWithOptions(context.Background(), PullOptions{}, "widgets.example.io")
+		require.NoError(t, err)
+		require.Len(t, crds[widgets].Spec.Versions, 1)
+		require.Equal(t, "v1", crds[widgets].Spec.Versions[0].Name)
+		require.True(t, crds[widgets].Spec.Versions[0].Storage)
+		require.NotNil(t, crds[widgets].Spec.Versions[0].Subresources.Status)
+	})
+
+	t.Run("all served versions of CRDs with a conversion webhook", func(t *testing.T) {
+		crds, err := newPuller(apiextensionsv1.WebhookConverter).PullCRDsWithOptions(context.Background(), PullOptions{}, "widgets.example.io")
+		require.NoError(t, err)
+		versions := crds[widgets].Spec.Versions
+		require.Len(t, versions, 2)
+		require.Equal(t, "v1", versions[0].Name)
+		require.False(t, versions[0].Storage)
+		require.Equal(t, "v1beta1", versions[1].Name)
+		require.True(t, versions[1].Storage, "the storage version of the physical cluster should be kept")
+		require.Equal(t, "v1beta1", versions[1].Schema.OpenAPIV3Schema.Description)
+		require.Equal(t, apiextensionsv1.NoneConverter, crds[widgets].Spec.Conversion.Strategy)
+	})
+
+	t.Run("selected versions", func(t *testing.T) {
+		crds, err := newPuller(apiextensionsv1.WebhookConverter).PullCRDsWithOptions(context.Background(), PullOptions{Versions: []string{"v1"}}, "widgets.example.io")
+		require.NoError(t, err)
+		require.Len(t, crds[widgets].Spec.Versions, 1)
+		require.True(t, crds[widgets].Spec.Versions[0].Storage)
+
+		crds, err = newPuller(apiextensionsv1.NoneConverter).PullCRDsWithOptions(context.Background(), PullOptions{Versions: []string{"v2"}}, "widgets.example.io")
+		require.NoError(t, err)
+		require.Empty(t, crds)
+	})
+
+	t.Run("strip status and defaults", func(t *testing.T) {
+		crds, err := newPuller(apiextensionsv1.NoneConverter).PullCRDsWithOptions(context.Background(), PullOptions{StripStatus: true, StripDefaults: true}, "widgets.example.io")
+		require.NoError(t, err)
+		version := crds[widgets].Spec.Versions[0]
+		require.Nil(t, version.Subresources.Status)
+		require.NotContains(t, version.Schema.OpenAPIV3Schema.Properties, "status")
+		require.Nil(t, version.Schema.OpenAPIV3Schema.Properties["spec"].Properties["size"].Default)
+	})
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func newLogicalCluster(clusterName string) *corev1alpha1.LogicalCluster {
+	return &corev1alpha1.LogicalCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        corev1alpha1.LogicalClusterName,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName},
+		},
+	}
+}
+
+func newWorkspace(parent, name, cluster string) *tenancyv1beta1.Workspace {
+	return &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: parent},
+		},
+		Status: tenancyv1beta1.WorkspaceStatus{
+			Phase:   corev1alpha1.LogicalClusterPhaseReady,
+			Cluster: cluster,
+		},
+	}
+}
+
+func TestLookupHierarchyRoots(t *testing.T) {
+	tests := map[string]struct {
+		hierarchyRoots []string
+		path           string
+		wantShard      string
+		wantCluster    logicalcluster.Name
+		wantFound      bool
+	}{
+		"root workspace without hierarchy roots": {
+			path:        "root:org",
+			wantShard:   "beta",
+			wantCluster: "org-cluster",
+			wantFound:   true,
+		},
+		"hierarchy root without hierarchy roots": {
+			path: "acme",
+		},
+		"workspace of a hierarchy root without hierarchy roots": {
+			path: "acme:team",
+		},
+		"hierarchy root": {
+			hierarchyRoots: []string{"acme"},
+			path:           "acme",
+			wantShard:      "root",
+			wantCluster:    "acme",
+			wantFound:      true,
+		},
+		"workspace of a hierarchy root": {
+			hierarchyRoots: []string{"acme"},
+			path:           "acme:team",
+			wantShard:      "beta",
+			wantCluster:    "team-cluster",
+			wantFound:      true,
+		},
+		"workspace of the root workspace under a hierarchy root": {
+			hierarchyRoots: []string{"acme"},
+			path:           "acme:org",
+		},
+		"workspace of a hierarchy root under the root workspace": {
+			hierarchyRoots: []string{"acme"},
+			path:           "root:team",
+		},
+		"workspace of another hierarchy root": {
+			hierarchyRoots: []string{"acme", "globex"},
+			path:           "globex:team",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := New(nil)
+			state.UpsertShard("root", "https://root.kcp.test")
+			state.UpsertShard("beta", "https://beta.kcp.test")
+
+			state.UpsertLogicalCluster("root", newLogicalCluster("root"))
+			state.UpsertWorkspace("root", newWorkspace("root", "org", "org-cluster"))
+			state.UpsertLogicalCluster("beta", newLogicalCluster("org-cluster"))
+
+			for _, hierarchyRoot := range tc.hierarchyRoots {
+				state.UpsertLogicalCluster("root", newLogicalCluster(hierarchyRoot))
+			}
+			if len(tc.hierarchyRoots) > 0 {
+				state.UpsertWorkspace("root", newWorkspace(tc.hierarchyRoots[0], "team", "team-cluster"))
+				state.UpsertLogicalCluster("beta", newLogicalCluster("team-cluster"))
+			}
+
+			shard, cluster, found := state.Lookup(logicalcluster.NewPath(tc.path))
+			require.Equal(t, tc.wantFound, found)
+			require.Equal(t, tc.wantShard, shard)
+			require.Equal(t, tc.wantCluster, cluster)
+		})
+	}
+}
+
+func TestLookupURLHierarchyRoot(t *testing.T) {
+	state := New(nil)
+	state.UpsertShard("root", "https://root.kcp.test/")
+	state.UpsertLogicalCluster("root", newLogicalCluster("acme"))
+
+	url, found := state.LookupURL(logicalcluster.NewPath("acme"))
+	require.True(t, found)
+	require.Equal(t, "https://root.kcp.test/clusters/acme", url)
+
+	state.DeleteLogicalCluster("root", newLogicalCluster("acme"))
+	_, found = state.LookupURL(logicalcluster.NewPath("acme"))
+	require.False(t, found)
+}
@@ -24,12 +24,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 )
 
 // ClusterAndGroupResourceValue returns the index value for use with
 // IndexAPIBindingByClusterAndAcceptedClaimedGroupResources from clusterName and groupResource.
 func ClusterAndGroupResourceValue(clusterName logicalcluster.Name, groupResource schema.GroupResource) string {
-	return fmt.Sprintf("%s|%s", clusterName, groupResource)
+	return indexkeys.NewClusterAndGroupResource(clusterName, groupResource).String()
 }
 
 // IndexAPIBindingByClusterAndAcceptedClaimedGroupResources is an index function that indexes an APIBinding by its
@@ -88,7 +89,7 @@ func IndexAPIBindingByBoundResources(obj interface{}) ([]string, error) {
 }
 
 func APIBindingBoundResourceValue(clusterName logicalcluster.Name, group, resource string) string {
-	return fmt.Sprintf("%s|%s.%s", clusterName, resource, group)
+	return indexkeys.NewClusterAndGroupResource(clusterName, schema.GroupResource{Group: group, Resource: resource}).String()
 }
 
 const APIBindingsByAPIExport = "APIBindingByAPIExport"
@@ -105,5 +106,5 @@ func IndexAPIBindingByAPIExport(obj interface{}) ([]string, error) {
 		path = logicalcluster.From(apiBinding).Path()
 	}
 
-	return []string{path.Join(apiBinding.Spec.Reference.Export.Name).String()}, nil
+	return []string{indexkeys.NewPathAndName(path, apiBinding.Spec.Reference.Export.Name).String()}, nil
 }
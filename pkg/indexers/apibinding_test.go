@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 )
 
 func TestIndexAPIBindingByAPIExport(t *testing.T) {
@@ -55,7 +56,7 @@ func TestIndexAPIBindingByAPIExport(t *testing.T) {
 					},
 				},
 			},
-			want:    []string{logicalcluster.NewPath("root:workspace1").Join("export1").String()},
+			want:    []string{indexkeys.NewPathAndName(logicalcluster.NewPath("root:workspace1"), "export1").String()},
 			wantErr: false,
 		},
 		"has a local export reference": {
@@ -74,7 +75,7 @@ func TestIndexAPIBindingByAPIExport(t *testing.T) {
 					},
 				},
 			},
-			want:    []string{logicalcluster.NewPath("root:default").Join("export1").String()},
+			want:    []string{indexkeys.NewPathAndName(logicalcluster.NewPath("root:default"), "export1").String()},
 			wantErr: false,
 		},
 	}
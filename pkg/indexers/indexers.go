@@ -30,6 +30,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/core"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 	syncershared "github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
@@ -104,12 +105,12 @@ func IndexByLogicalClusterPathAndName(obj interface{}) ([]string, error) {
 	}
 	if path, found := metaObj.GetAnnotations()[core.LogicalClusterPathAnnotationKey]; found {
 		return []string{
-			logicalcluster.NewPath(path).Join(metaObj.GetName()).String(),
-			logicalcluster.From(metaObj).Path().Join(metaObj.GetName()).String(),
+			indexkeys.NewPathAndName(logicalcluster.NewPath(path), metaObj.GetName()).String(),
+			indexkeys.NewPathAndName(logicalcluster.From(metaObj).Path(), metaObj.GetName()).String(),
 		}, nil
 	}
 
-	return []string{logicalcluster.From(metaObj).String()}, nil
+	return []string{indexkeys.NewPathAndName(logicalcluster.From(metaObj).Path(), metaObj.GetName()).String()}, nil
 }
 
 // ByIndex returns all instances of T that match indexValue in indexName in indexer.
@@ -130,7 +131,7 @@ func ByIndex[T runtime.Object](indexer cache.Indexer, indexName, indexValue stri
 // ByPathAndName returns the instance of T from the indexer with the matching path and name. Path may be a canonical path
 // or a cluster name. Note: this depends on the presence of the optional "kcp.io/path" annotation.
 func ByPathAndName[T runtime.Object](groupResource schema.GroupResource, indexer cache.Indexer, path logicalcluster.Path, name string) (ret T, err error) {
-	objs, err := indexer.ByIndex(ByLogicalClusterPathAndName, path.Join(name).String())
+	objs, err := indexer.ByIndex(ByLogicalClusterPathAndName, indexkeys.NewPathAndName(path, name).String())
 	if err != nil {
 		return ret, err
 	}
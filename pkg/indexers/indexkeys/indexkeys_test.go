@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexkeys
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPathAndName(t *testing.T) {
+	tests := map[string]struct {
+		key  PathAndName
+		want string
+	}{
+		"path": {
+			key:  NewPathAndName(logicalcluster.NewPath("root:org:ws"), "export"),
+			want: "root:org:ws|export",
+		},
+		"cluster name": {
+			key:  NewPathAndName(logicalcluster.Name("2v0k2vbjsxw3lbs4").Path(), "export"),
+			want: "2v0k2vbjsxw3lbs4|export",
+		},
+		"dotted name": {
+			key:  NewPathAndName(logicalcluster.NewPath("root"), "kubernetes.kcp.io"),
+			want: "root|kubernetes.kcp.io",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.key.String())
+
+			parsed, err := ParsePathAndName(tt.key.String())
+			require.NoError(t, err)
+			require.Equal(t, tt.key, parsed)
+		})
+	}
+}
+
+func TestPathAndNameDoNotCollide(t *testing.T) {
+	// with the path separator, "root:org" + "ws" and "root" + "org:ws" rendered to the same value
+	require.NotEqual(t,
+		NewPathAndName(logicalcluster.NewPath("root:org"), "ws").String(),
+		NewPathAndName(logicalcluster.NewPath("root"), "org:ws").String(),
+	)
+}
+
+func TestParsePathAndNameInvalid(t *testing.T) {
+	for _, value := range []string{"", "root:org", "|export", "root|"} {
+		_, err := ParsePathAndName(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestClusterAndGroupResource(t *testing.T) {
+	tests := map[string]struct {
+		key  ClusterAndGroupResource
+		want string
+	}{
+		"core group": {
+			key:  NewClusterAndGroupResource("root", schema.GroupResource{Resource: "configmaps"}),
+			want: "root|configmaps",
+		},
+		"named group": {
+			key:  NewClusterAndGroupResource("root", schema.GroupResource{Group: "apps", Resource: "deployments"}),
+			want: "root|deployments.apps",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.key.String())
+			require.Equal(t, tt.key.GroupResource.String(), tt.want[len("root|"):])
+		})
+	}
+}
+
+func BenchmarkPathAndName(b *testing.B) {
+	path := logicalcluster.NewPath("root:org:team:ws")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewPathAndName(path, "kubernetes").String()
+	}
+}
+
+func BenchmarkClusterAndGroupResource(b *testing.B) {
+	clusterName := logicalcluster.Name("2v0k2vbjsxw3lbs4")
+	groupResource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewClusterAndGroupResource(clusterName, groupResource).String()
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Registry records the resources informed by the shared informer factories of a server, per
+// scope. A scope is the set of objects an informer caches, e.g., the wildcard objects of the
+// shard, or the ones of the cache server. A resource informed by more than one factory of the
+// same scope is cached more than once, which is a waste of memory on large shards.
+type Registry struct {
+	lock      sync.Mutex
+	informers map[string]map[schema.GroupVersionResource]sets.String
+}
+
+// Duplicate is a resource informed by more than one factory of the same scope.
+type Duplicate struct {
+	Scope     string
+	Resource  schema.GroupVersionResource
+	Factories []string
+}
+
+func (d Duplicate) String() string {
+	return fmt.Sprintf("%s in scope %q is informed by %v", d.Resource, d.Scope, d.Factories)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		informers: map[string]map[schema.GroupVersionResource]sets.String{},
+	}
+}
+
+// Add records the resources informed by a factory in a scope.
+func (r *Registry) Add(scope, factory string, resources ...schema.GroupVersionResource) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.informers[scope] == nil {
+		r.informers[scope] = map[schema.GroupVersionResource]sets.String{}
+	}
+	for _, gvr := range resources {
+		if r.informers[scope][gvr] == nil {
+			r.informers[scope][gvr] = sets.NewString()
+		}
+		r.informers[scope][gvr].Insert(factory)
+	}
+}
+
+// AddTyped records the resources informed by a typed factory in a scope, from the object types
+// of its started informers, as returned by WaitForCacheSync.
+func (r *Registry) AddTyped(scope, factory string, scheme *runtime.Scheme, started map[reflect.Type]bool) error {
+	resources := make([]schema.GroupVersionResource, 0, len(started))
+	for t := range started {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		obj, ok := reflect.New(t).Interface().(runtime.Object)
+		if !ok {
+			return fmt.Errorf("informer type %s of factory %q is not a runtime.Object", t, factory)
+		}
+		gvks, _, err := scheme.ObjectKinds(obj)
+		if err != nil {
+			return fmt.Errorf("failed to get the kind of the informer type %s of factory %q: %w", t, factory, err)
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gvks[0])
+		resources = append(resources, gvr)
+	}
+	r.Add(scope, factory, resources...)
+	return nil
+}
+
+// Duplicates returns the resources informed by more than one factory of the same scope, sorted
+// by scope and resource.
+func (r *Registry) Duplicates() []Duplicate {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var duplicates []Duplicate
+	for scope, resources := range r.informers {
+		for gvr, factories := range resources {
+			if factories.Len() > 1 {
+				duplicates = append(duplicates, Duplicate{Scope: scope, Resource: gvr, Factories: factories.List()})
+			}
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Scope != duplicates[j].Scope {
+			return duplicates[i].Scope < duplicates[j].Scope
+		}
+		return duplicates[i].Resource.String() < duplicates[j].Resource.String()
+	})
+	return duplicates
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func TestRegistry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1alpha1.AddToScheme(scheme))
+	require.NoError(t, apisv1alpha1.AddToScheme(scheme))
+
+	r := NewRegistry()
+	require.NoError(t, r.AddTyped("shard", "kcp", scheme, map[reflect.Type]bool{
+		reflect.TypeOf(&corev1alpha1.LogicalCluster{}): true,
+		reflect.TypeOf(&apisv1alpha1.APIBinding{}):     true,
+	}))
+	require.NoError(t, r.AddTyped("cache", "cache", scheme, map[reflect.Type]bool{
+		reflect.TypeOf(&apisv1alpha1.APIBinding{}): true,
+	}))
+	require.Empty(t, r.Duplicates(), "the same resource in different scopes is not a duplicate")
+
+	t.Log("A resource informed twice in the same scope is a duplicate")
+	r.Add("shard", "initializer", corev1alpha1.SchemeGroupVersion.WithResource("logicalclusters"))
+	require.Equal(t, []Duplicate{{
+		Scope:     "shard",
+		Resource:  corev1alpha1.SchemeGroupVersion.WithResource("logicalclusters"),
+		Factories: []string{"initializer", "kcp"},
+	}}, r.Duplicates())
+
+	t.Log("Types unknown to the scheme are errors")
+	require.Error(t, r.AddTyped("shard", "kcp", runtime.NewScheme(), map[reflect.Type]bool{
+		reflect.TypeOf(&corev1alpha1.Shard{}): true,
+	}))
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package loadgenerator creates configurable numbers of workspaces, APIExports, APIBindings
+// and objects against a kcp instance, and reports the latencies of their creation together
+// with the resource usage of the server as it grows, so that scalability regressions can be
+// measured from release to release.
+package loadgenerator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+)
+
+const (
+	resource = "widgets"
+	kind     = "Widget"
+	version  = "v1"
+)
+
+var widgetSchema = []byte(`{"type":"object","properties":{"spec":{"type":"object","properties":{"size":{"type":"integer"}}}}}`)
+
+// LoadGenerator generates the load of Options against a kcp instance.
+type LoadGenerator struct {
+	options *Options
+
+	kcpClusterClient     kcpclientset.ClusterInterface
+	dynamicClusterClient kcpdynamic.ClusterInterface
+	kubeClusterClient    kcpkubernetesclientset.ClusterInterface
+	parent, provider     logicalcluster.Path
+	metricsUnavailable   bool
+}
+
+// New returns a LoadGenerator for the kcp instance of the given config, whose host must not
+// point to a workspace.
+func New(config *rest.Config, options *Options) (*LoadGenerator, error) {
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := logicalcluster.NewPath(options.Parent)
+	return &LoadGenerator{
+		options:              options,
+		kcpClusterClient:     kcpClusterClient,
+		dynamicClusterClient: dynamicClusterClient,
+		kubeClusterClient:    kubeClusterClient,
+		parent:               parent,
+		provider:             parent.Join(options.Prefix + "-provider"),
+	}, nil
+}
+
+// Run generates the load phase by phase, and writes the report to out. A phase whose
+// operations fail is reported with its errors, and the run continues, so that the failures
+// under load are part of the measurements. Failures to set up the provider workspace abort
+// the run.
+func (g *LoadGenerator) Run(ctx context.Context, out io.Writer) (*Report, error) {
+	report := &Report{Options: *g.options}
+	phase := func(name string, n int, op func(ctx context.Context, i int) error) {
+		rec := &recorder{}
+		start := time.Now()
+		workqueue.ParallelizeUntil(ctx, g.options.Concurrency, n, func(i int) {
+			opStart := time.Now()
+			err := op(ctx, i)
+			if err != nil {
+				klog.FromContext(ctx).Error(err, "operation failed", "phase", name, "index", i)
+			}
+			rec.observe(time.Since(opStart), err)
+		})
+		phaseReport := rec.report(name, time.Since(start))
+		phaseReport.Server = g.sampleServer(ctx)
+		report.Phases = append(report.Phases, phaseReport)
+		fmt.Fprintf(out, "%s: %d operations, %d errors in %s\n", name, phaseReport.Count, phaseReport.Errors, phaseReport.Duration.Round(time.Millisecond))
+	}
+
+	phase("provider", 1, func(ctx context.Context, _ int) error {
+		return g.createWorkspace(ctx, g.provider)
+	})
+	if report.Phases[0].Errors > 0 {
+		return report, fmt.Errorf("failed to create the provider workspace %s", g.provider)
+	}
+	phase("exports", g.options.Exports, g.createExport)
+	if report.Phases[1].Errors > 0 {
+		return report, fmt.Errorf("failed to create the APIExports in %s", g.provider)
+	}
+	phase("workspaces", g.options.Workspaces, func(ctx context.Context, i int) error {
+		return g.createWorkspace(ctx, g.consumer(i))
+	})
+	if g.options.BindingsPerWorkspace > 0 {
+		phase("bindings", g.options.Workspaces*g.options.BindingsPerWorkspace, g.createBinding)
+	}
+	if g.options.ObjectsPerWorkspace > 0 {
+		phase("objects", g.options.Workspaces*g.options.ObjectsPerWorkspace, g.createObject)
+	}
+	if g.options.Cleanup {
+		phase("cleanup", g.options.Workspaces, func(ctx context.Context, i int) error {
+			return g.deleteWorkspace(ctx, g.consumer(i))
+		})
+		if err := g.deleteWorkspace(ctx, g.provider); err != nil {
+			klog.FromContext(ctx).Error(err, "failed to delete the provider workspace", "workspace", g.provider)
+		}
+	}
+
+	if g.options.ReportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return report, err
+		}
+		if err := os.WriteFile(g.options.ReportFile, data, 0644); err != nil {
+			return report, err
+		}
+	}
+	return report, report.Print(out)
+}
+
+func (g *LoadGenerator) consumer(i int) logicalcluster.Path {
+	return g.parent.Join(fmt.Sprintf("%s-%d", g.options.Prefix, i))
+}
+
+func (g *LoadGenerator) exportName(i int) string {
+	return fmt.Sprintf("%s-%d", g.options.Prefix, i)
+}
+
+func (g *LoadGenerator) exportGroup(i int) string {
+	return fmt.Sprintf("%s-%d.loadgenerator.kcp.io", g.options.Prefix, i)
+}
+
+// createWorkspace creates the workspace, and waits for it to be ready. Workspaces left over by
+// a previous run are reused.
+func (g *LoadGenerator) createWorkspace(ctx context.Context, path logicalcluster.Path) error {
+	parent, name := path.Split()
+	ws := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			Type: tenancyv1alpha1.WorkspaceTypeReference{
+				Name: "universal",
+				Path: "root",
+			},
+		},
+	}
+	client := g.kcpClusterClient.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces()
+	if _, err := client.Create(ctx, ws, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return g.poll(func() (bool, error) {
+		ws, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady, nil
+	})
+}
+
+func (g *LoadGenerator) deleteWorkspace(ctx context.Context, path logicalcluster.Path) error {
+	parent, name := path.Split()
+	err := g.kcpClusterClient.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// createExport creates the i-th APIExport of the provider workspace, exporting a single
+// resource in its own group, and waits for its identity.
+func (g *LoadGenerator) createExport(ctx context.Context, i int) error {
+	group := g.exportGroup(i)
+	schemaName := fmt.Sprintf("%s.%s.%s", version, resource, group)
+	apiResourceSchema := &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: schemaName},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   resource,
+				Singular: "widget",
+				Kind:     kind,
+				ListKind: kind + "List",
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:    version,
+				Served:  true,
+				Storage: true,
+				Schema:  runtime.RawExtension{Raw: widgetSchema},
+			}},
+		},
+	}
+	client := g.kcpClusterClient.Cluster(g.provider).ApisV1alpha1()
+	if _, err := client.APIResourceSchemas().Create(ctx, apiResourceSchema, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: g.exportName(i)},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{schemaName},
+		},
+	}
+	if _, err := client.APIExports().Create(ctx, export, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return g.poll(func() (bool, error) {
+		export, err := client.APIExports().Get(ctx, g.exportName(i), metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return export.Status.IdentityHash != "", nil
+	})
+}
+
+// createBinding creates the j-th APIBinding of the w-th consumer workspace, for i = w*bindings+j,
+// and waits for it to be bound. The bindings of a workspace are spread over distinct APIExports.
+func (g *LoadGenerator) createBinding(ctx context.Context, i int) error {
+	w, j := i/g.options.BindingsPerWorkspace, i%g.options.BindingsPerWorkspace
+	exportName := g.exportName((w + j) % g.options.Exports)
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: exportName},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.BindingReference{
+				Export: &apisv1alpha1.ExportBindingReference{
+					Path: g.provider.String(),
+					Name: exportName,
+				},
+			},
+		},
+	}
+	client := g.kcpClusterClient.Cluster(g.consumer(w)).ApisV1alpha1().APIBindings()
+	if _, err := client.Create(ctx, binding, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return g.poll(func() (bool, error) {
+		binding, err := client.Get(ctx, exportName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound, nil
+	})
+}
+
+// createObject creates the j-th object of the w-th consumer workspace, for i = w*objects+j, of
+// the resource of its first binding.
+func (g *LoadGenerator) createObject(ctx context.Context, i int) error {
+	w, j := i/g.options.ObjectsPerWorkspace, i%g.options.ObjectsPerWorkspace
+	gvr := schema.GroupVersionResource{Group: g.exportGroup(w % g.options.Exports), Version: version, Resource: resource}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": fmt.Sprintf("%s-%d", g.options.Prefix, j),
+		},
+		"spec": map[string]interface{}{
+			"size": int64(j),
+		},
+	}}
+	_, err := g.dynamicClusterClient.Cluster(g.consumer(w)).Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func (g *LoadGenerator) poll(condition wait.ConditionFunc) error {
+	return wait.PollImmediate(g.options.PollInterval, g.options.Timeout, func() (bool, error) {
+		done, err := condition()
+		if apierrors.IsNotFound(err) {
+			// the object may not be visible yet, e.g., through the workspace index
+			return false, nil
+		}
+		return done, err
+	})
+}
+
+// sampleServer reads the sampled metrics of the server. With several shards, the metrics are
+// the ones of the shard serving the parent workspace. Missing metrics, e.g., for lack of
+// permission, are logged once.
+func (g *LoadGenerator) sampleServer(ctx context.Context) map[string]float64 {
+	data, err := g.kubeClusterClient.Cluster(g.parent).Discovery().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+	if err == nil {
+		var values map[string]float64
+		if values, err = parseMetrics(bytes.NewReader(data), sampledMetrics); err == nil {
+			return values
+		}
+	}
+	if !g.metricsUnavailable {
+		g.metricsUnavailable = true
+		klog.FromContext(ctx).Error(err, "failed to read the server metrics, the resource usage is not reported")
+	}
+	return nil
+}
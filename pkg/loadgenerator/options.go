@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadgenerator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/pflag"
+)
+
+// Options are the sizes of the load generated against a kcp instance.
+type Options struct {
+	// Parent is the workspace the provider and the consumer workspaces are created in.
+	Parent string
+	// Prefix prefixes the names of the created workspaces and the groups of the exported APIs,
+	// so that several runs can be told apart.
+	Prefix string
+
+	Workspaces           int
+	Exports              int
+	BindingsPerWorkspace int
+	ObjectsPerWorkspace  int
+
+	Concurrency  int
+	PollInterval time.Duration
+	Timeout      time.Duration
+
+	// ReportFile is the file the report is written to as JSON, in addition to the summary
+	// printed to the output.
+	ReportFile string
+	// Cleanup deletes the created workspaces at the end of the run.
+	Cleanup bool
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Parent:               "root",
+		Prefix:               "load",
+		Workspaces:           100,
+		Exports:              10,
+		BindingsPerWorkspace: 1,
+		ObjectsPerWorkspace:  10,
+		Concurrency:          20,
+		PollInterval:         time.Second,
+		Timeout:              5 * time.Minute,
+	}
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Parent, "parent", o.Parent, "Workspace the provider and the consumer workspaces are created in.")
+	fs.StringVar(&o.Prefix, "prefix", o.Prefix, "Prefix of the names of the created workspaces and of the groups of the exported APIs.")
+	fs.IntVar(&o.Workspaces, "workspaces", o.Workspaces, "Number of consumer workspaces.")
+	fs.IntVar(&o.Exports, "exports", o.Exports, "Number of APIExports of the provider workspace, each exporting one resource.")
+	fs.IntVar(&o.BindingsPerWorkspace, "bindings-per-workspace", o.BindingsPerWorkspace, "Number of APIBindings per consumer workspace, to distinct APIExports. Cannot exceed --exports.")
+	fs.IntVar(&o.ObjectsPerWorkspace, "objects-per-workspace", o.ObjectsPerWorkspace, "Number of objects of the bound resources created per consumer workspace.")
+	fs.IntVar(&o.Concurrency, "concurrency", o.Concurrency, "Number of concurrent requests.")
+	fs.DurationVar(&o.PollInterval, "poll-interval", o.PollInterval, "Interval between the checks of the readiness of the workspaces and of the bindings.")
+	fs.DurationVar(&o.Timeout, "timeout", o.Timeout, "Maximum time for a workspace or a binding to become ready.")
+	fs.StringVar(&o.ReportFile, "report", o.ReportFile, "File the report is written to as JSON.")
+	fs.BoolVar(&o.Cleanup, "cleanup", o.Cleanup, "Delete the created workspaces at the end of the run.")
+}
+
+func (o *Options) Validate() []error {
+	var errs []error
+
+	if !logicalcluster.NewPath(o.Parent).IsValid() {
+		errs = append(errs, fmt.Errorf("--parent %q is not a valid workspace path", o.Parent))
+	}
+	if o.Prefix == "" {
+		errs = append(errs, fmt.Errorf("--prefix cannot be empty"))
+	}
+	if o.Workspaces < 1 {
+		errs = append(errs, fmt.Errorf("--workspaces must be positive"))
+	}
+	if o.Exports < 1 {
+		errs = append(errs, fmt.Errorf("--exports must be positive"))
+	}
+	if o.BindingsPerWorkspace < 0 || o.BindingsPerWorkspace > o.Exports {
+		errs = append(errs, fmt.Errorf("--bindings-per-workspace must be between 0 and --exports"))
+	}
+	if o.ObjectsPerWorkspace < 0 {
+		errs = append(errs, fmt.Errorf("--objects-per-workspace cannot be negative"))
+	}
+	if o.ObjectsPerWorkspace > 0 && o.BindingsPerWorkspace == 0 {
+		errs = append(errs, fmt.Errorf("--objects-per-workspace requires at least one binding per workspace"))
+	}
+	if o.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("--concurrency must be positive"))
+	}
+	if o.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--poll-interval must be positive"))
+	}
+	if o.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("--timeout must be positive"))
+	}
+
+	return errs
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadgenerator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the outcome of a run, phase by phase.
+type Report struct {
+	Options Options       `json:"options"`
+	Phases  []PhaseReport `json:"phases"`
+}
+
+// PhaseReport are the latencies of the operations of a phase, e.g., the creation of the
+// workspaces, and a sample of the server resources at the end of the phase. As the phases
+// grow the number of objects of the instance, the successive samples draw the resource curves.
+type PhaseReport struct {
+	Name     string         `json:"name"`
+	Count    int            `json:"count"`
+	Errors   int            `json:"errors"`
+	Duration time.Duration  `json:"duration"`
+	Latency  LatencySummary `json:"latency"`
+	// Server is the sample of the server metrics at the end of the phase, if they could be read.
+	Server map[string]float64 `json:"server,omitempty"`
+}
+
+// LatencySummary summarizes the latency distribution of the operations of a phase.
+type LatencySummary struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// sampledMetrics are the server metrics sampled at the end of each phase.
+var sampledMetrics = []string{
+	"process_resident_memory_bytes",
+	"process_cpu_seconds_total",
+	"go_goroutines",
+	"go_memstats_heap_inuse_bytes",
+}
+
+// recorder collects the latencies and the errors of the operations of a phase.
+type recorder struct {
+	lock      sync.Mutex
+	latencies []time.Duration
+	errors    int
+}
+
+func (r *recorder) observe(latency time.Duration, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err != nil {
+		r.errors++
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+func (r *recorder) report(name string, duration time.Duration) PhaseReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return PhaseReport{
+		Name:     name,
+		Count:    len(r.latencies) + r.errors,
+		Errors:   r.errors,
+		Duration: duration,
+		Latency:  summarize(r.latencies),
+	}
+}
+
+// summarize returns the percentiles of the latencies, using the nearest-rank method.
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p int) time.Duration {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+	return LatencySummary{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// parseMetrics returns the values of the given unlabeled metrics, read from the Prometheus
+// text exposition format.
+func parseMetrics(r io.Reader, names []string) (map[string]float64, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	values := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || !wanted[fields[0]] {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of metric %s: %w", fields[0], err)
+		}
+		values[fields[0]] = value
+	}
+	return values, scanner.Err()
+}
+
+// Print writes the summary of the report as a table.
+func (r *Report) Print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	columns := []string{"PHASE", "COUNT", "ERRORS", "DURATION", "P50", "P90", "P99", "MAX"}
+	columns = append(columns, sampledMetrics...)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, phase := range r.Phases {
+		row := []string{
+			phase.Name,
+			strconv.Itoa(phase.Count),
+			strconv.Itoa(phase.Errors),
+			phase.Duration.Round(time.Millisecond).String(),
+			phase.Latency.P50.Round(time.Millisecond).String(),
+			phase.Latency.P90.Round(time.Millisecond).String(),
+			phase.Latency.P99.Round(time.Millisecond).String(),
+			phase.Latency.Max.Round(time.Millisecond).String(),
+		}
+		for _, name := range sampledMetrics {
+			value, ok := phase.Server[name]
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, strconv.FormatFloat(value, 'g', 6, 64))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
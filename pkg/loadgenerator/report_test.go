@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadgenerator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	require.Equal(t, LatencySummary{}, summarize(nil))
+
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, LatencySummary{
+		P50: 50 * time.Millisecond,
+		P90: 90 * time.Millisecond,
+		P99: 99 * time.Millisecond,
+		Max: 100 * time.Millisecond,
+	}, summarize(latencies))
+	require.Equal(t, 100*time.Millisecond, latencies[0], "the latencies must not be sorted in place")
+
+	require.Equal(t, LatencySummary{P50: time.Second, P90: time.Second, P99: time.Second, Max: time.Second}, summarize([]time.Duration{time.Second}))
+}
+
+func TestRecorder(t *testing.T) {
+	r := &recorder{}
+	r.observe(time.Second, nil)
+	r.observe(3*time.Second, nil)
+	r.observe(time.Hour, errors.New("failed"))
+
+	report := r.report("workspaces", time.Minute)
+	require.Equal(t, "workspaces", report.Name)
+	require.Equal(t, 3, report.Count)
+	require.Equal(t, 1, report.Errors)
+	require.Equal(t, 3*time.Second, report.Latency.Max, "failed operations must not count in the latencies")
+}
+
+func TestParseMetrics(t *testing.T) {
+	metrics := `# HELP go_goroutines Number of goroutines that currently exist.
+# TYPE go_goroutines gauge
+go_goroutines 1234
+go_gc_duration_seconds{quantile="0.5"} 0.001
+process_resident_memory_bytes 1.2345e+09
+`
+	values, err := parseMetrics(strings.NewReader(metrics), []string{"go_goroutines", "process_resident_memory_bytes", "process_cpu_seconds_total"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{
+		"go_goroutines":                 1234,
+		"process_resident_memory_bytes": 1.2345e+09,
+	}, values)
+
+	_, err = parseMetrics(strings.NewReader("go_goroutines NaNa\n"), []string{"go_goroutines"})
+	require.Error(t, err)
+}
@@ -22,12 +22,15 @@ import (
 	"strings"
 
 	"github.com/go-logr/logr"
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
 )
 
 const (
@@ -37,8 +40,14 @@ const (
 	// QueueKeyKey is used to expose the workqueue key being processed.
 	QueueKeyKey = "key"
 
+	// LogicalClusterKey is used to specify the logical cluster of the workqueue key being processed.
+	LogicalClusterKey = "logicalcluster"
+
 	// WorkspaceKey is used to specify a workspace when a log is related to an object.
 	WorkspaceKey = "workspace"
+	// PathKey is used to specify the canonical path of the workspace when a log is related to an object
+	// that records it, e.g. an APIExport.
+	PathKey = "path"
 	// NamespaceKey is used to specify a namespace when a log is related to an object.
 	NamespaceKey = "namespace"
 	// NameKey is used to specify a name when a log is related to an object.
@@ -52,8 +61,12 @@ func WithReconciler(logger logr.Logger, reconciler string) logr.Logger {
 	return logger.WithValues(ReconcilerKey, reconciler)
 }
 
-// WithQueueKey adds the queue key to the logger.
+// WithQueueKey adds the queue key to the logger, and the logical cluster it belongs to if it is
+// a cluster-aware key.
 func WithQueueKey(logger logr.Logger, key string) logr.Logger {
+	if clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key); err == nil && !clusterName.Empty() {
+		return logger.WithValues(QueueKeyKey, key, LogicalClusterKey, clusterName.String())
+	}
 	return logger.WithValues(QueueKeyKey, key)
 }
 
@@ -83,7 +96,7 @@ func From(obj Object) []interface{} {
 // FromPrefix provides the structured logging fields that identify an object, allowing any prefix.
 func FromPrefix(prefix string, obj Object) []interface{} {
 	gvk := obj.GetObjectKind().GroupVersionKind()
-	return []interface{}{
+	fields := []interface{}{
 		prefix + "." + string(WorkspaceKey),
 		logicalcluster.From(obj).String(),
 		prefix + "." + string(NamespaceKey),
@@ -93,6 +106,10 @@ func FromPrefix(prefix string, obj Object) []interface{} {
 		prefix + "." + string(APIVersionKey),
 		gvk.GroupVersion(),
 	}
+	if path, ok := obj.GetAnnotations()[core.LogicalClusterPathAnnotationKey]; ok {
+		fields = append(fields, prefix+"."+PathKey, path)
+	}
+	return fields
 }
 
 // WithUser adds user identifiers to the logger.
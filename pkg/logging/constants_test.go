@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+)
+
+func TestWithQueueKey(t *testing.T) {
+	tests := map[string]struct {
+		key     string
+		cluster string
+	}{
+		"cluster-aware key":     {key: "root:org|ns/name", cluster: "root:org"},
+		"cluster-scoped object": {key: "root:org|name", cluster: "root:org"},
+		"plain key":             {key: "ns/name"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			logger := funcr.New(func(_, args string) { got = args }, funcr.Options{})
+			WithQueueKey(logger, tc.key).Info("")
+			require.Contains(t, got, `"key"="`+tc.key+`"`)
+			if tc.cluster == "" {
+				require.NotContains(t, got, `"logicalcluster"`)
+			} else {
+				require.Contains(t, got, `"logicalcluster"="`+tc.cluster+`"`)
+			}
+		})
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "export",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:         "abc123",
+				core.LogicalClusterPathAnnotationKey: "root:org",
+			},
+		},
+	}
+	fields := From(export)
+	require.Contains(t, fields, "apiexport.path")
+	require.Equal(t, "root:org", fields[len(fields)-1])
+
+	delete(export.Annotations, core.LogicalClusterPathAnnotationKey)
+	require.NotContains(t, From(export), "apiexport.path")
+}
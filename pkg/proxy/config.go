@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"go.opentelemetry.io/otel/trace"
+
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -50,6 +52,9 @@ type ExtraConfig struct {
 	RootShardConfig   *rest.Config
 	ShardsConfig      *rest.Config
 
+	// TracerProvider traces the proxied requests, and propagates the trace context to the shards.
+	TracerProvider *trace.TracerProvider
+
 	AuthenticationInfo    genericapiserver.AuthenticationInfo
 	ServingInfo           *genericapiserver.SecureServingInfo
 	AdditionalAuthEnabled bool
@@ -105,5 +110,13 @@ func NewConfig(opts *proxyoptions.Options) (*Config, error) {
 
 	c.AdditionalAuthEnabled = c.Options.Authentication.AdditionalAuthEnabled()
 
+	// the tracing options only apply to a generic API server config, from which the tracer provider is picked.
+	tracerProvider := trace.NewNoopTracerProvider()
+	tracingConfig := &genericapiserver.Config{TracerProvider: &tracerProvider}
+	if err := c.Options.Tracing.ApplyTo(nil, tracingConfig); err != nil {
+		return nil, err
+	}
+	c.TracerProvider = tracingConfig.TracerProvider
+
 	return c, nil
 }
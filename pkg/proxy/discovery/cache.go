@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+)
+
+// maxAuthorizations is the maximum number of the authorization decisions cached.
+const maxAuthorizations = 10000
+
+// Cache caches the discovery and OpenAPI responses of the shards, per logical cluster. The
+// responses of a logical cluster are invalidated on the changes of its APIs, and expire after
+// a TTL, in case a change has been missed.
+//
+// The responses are shared by the users, and the least recently used ones are evicted when
+// their total size exceeds maxBytes. Whether a user is allowed to get the responses of a
+// logical cluster is cached separately, for the same TTL.
+type Cache struct {
+	ttl      time.Duration
+	maxBytes int64
+	now      func() time.Time
+
+	lock sync.Mutex
+	// lru holds the entries, the most recently used first.
+	lru     *list.List
+	entries map[logicalcluster.Name]map[string]*list.Element
+	size    int64
+	// generations are incremented on the invalidations of the logical clusters, so that
+	// the responses requested before an invalidation are not cached after it.
+	generations map[logicalcluster.Name]uint64
+
+	authorizations *utilcache.LRUExpireCache
+}
+
+type entry struct {
+	clusterName logicalcluster.Name
+	key         string
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// bytes returns the approximate size of the entry.
+func (e *entry) bytes() int64 {
+	size := len(e.key) + len(e.body)
+	for k, vs := range e.header {
+		size += len(k)
+		for _, v := range vs {
+			size += len(v)
+		}
+	}
+	return int64(size)
+}
+
+type authorizationKey struct {
+	clusterName logicalcluster.Name
+	user        string
+}
+
+type clockFunc func() time.Time
+
+func (f clockFunc) Now() time.Time {
+	return f()
+}
+
+// NewCache returns a Cache whose responses expire after ttl, holding at most maxBytes of responses.
+func NewCache(ttl time.Duration, maxBytes int64) *Cache {
+	c := &Cache{
+		ttl:         ttl,
+		maxBytes:    maxBytes,
+		now:         time.Now,
+		lru:         list.New(),
+		entries:     map[logicalcluster.Name]map[string]*list.Element{},
+		generations: map[logicalcluster.Name]uint64{},
+	}
+	c.authorizations = utilcache.NewLRUExpireCacheWithClock(maxAuthorizations, clockFunc(func() time.Time { return c.now() }))
+	return c
+}
+
+func (c *Cache) get(clusterName logicalcluster.Name, key string) (*entry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, found := c.entries[clusterName][key]
+	if !found {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return e, true
+}
+
+func (c *Cache) generation(clusterName logicalcluster.Name) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.generations[clusterName]
+}
+
+// set caches the response, unless the logical cluster has been invalidated since the given generation.
+func (c *Cache) set(clusterName logicalcluster.Name, generation uint64, key string, header http.Header, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.generations[clusterName] != generation {
+		return
+	}
+	e := &entry{clusterName: clusterName, key: key, header: header, body: body, expires: c.now().Add(c.ttl)}
+	if e.bytes() > c.maxBytes {
+		return
+	}
+	if elem, found := c.entries[clusterName][key]; found {
+		c.remove(elem)
+	}
+	for c.size+e.bytes() > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+	entries, found := c.entries[clusterName]
+	if !found {
+		entries = map[string]*list.Element{}
+		c.entries[clusterName] = entries
+	}
+	entries[key] = c.lru.PushFront(e)
+	c.size += e.bytes()
+}
+
+// remove removes the entry. The lock must be held.
+func (c *Cache) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*entry)
+	c.size -= e.bytes()
+	entries := c.entries[e.clusterName]
+	delete(entries, e.key)
+	if len(entries) == 0 {
+		delete(c.entries, e.clusterName)
+	}
+}
+
+// authorized returns whether the user has been allowed to get the responses of the logical cluster.
+func (c *Cache) authorized(clusterName logicalcluster.Name, user string) bool {
+	_, found := c.authorizations.Get(authorizationKey{clusterName: clusterName, user: user})
+	return found
+}
+
+// setAuthorized records that the user has been allowed to get the responses of the logical cluster.
+func (c *Cache) setAuthorized(clusterName logicalcluster.Name, user string) {
+	c.authorizations.Add(authorizationKey{clusterName: clusterName, user: user}, true, c.ttl)
+}
+
+// Invalidate removes the cached responses of the logical cluster.
+func (c *Cache) Invalidate(clusterName logicalcluster.Name) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generations[clusterName]++
+	for _, elem := range c.entries[clusterName] {
+		c.remove(elem)
+	}
+}
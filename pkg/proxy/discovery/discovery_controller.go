@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kcpapiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/kcp/clientset/versioned"
+	kcpapiextensionsv1informers "k8s.io/apiextensions-apiserver/pkg/client/kcp/informers/externalversions/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	controllerName = "kcp-front-proxy-discovery-cache"
+
+	resyncPeriod = 2 * time.Hour
+)
+
+// ShardClientGetter returns the clients of a shard.
+type ShardClientGetter func(shard *corev1alpha1.Shard) (kcpclientset.ClusterInterface, kcpapiextensionsclientset.ClusterInterface, error)
+
+// NewController returns a controller invalidating the cached responses of the logical clusters
+// whose APIBindings or CRDs change on the shards.
+func NewController(
+	ctx context.Context,
+	shardInformer corev1alpha1informers.ShardInformer,
+	clientGetter ShardClientGetter,
+	discoveryCache *Cache,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue: queue,
+
+		clientGetter: clientGetter,
+		cache:        discoveryCache,
+
+		shardLister: shardInformer.Lister(),
+
+		shardStopCh: map[string]chan struct{}{},
+	}
+
+	shardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueShard(ctx, obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			shard := obj.(*corev1alpha1.Shard)
+			oldShard := old.(*corev1alpha1.Shard)
+			if oldShard.Spec.BaseURL == shard.Spec.BaseURL {
+				return
+			}
+			c.stopShard(oldShard.Name)
+			c.enqueueShard(ctx, shard)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = final.Obj
+			}
+			shard := obj.(*corev1alpha1.Shard)
+			c.stopShard(shard.Name)
+		},
+	})
+
+	return c
+}
+
+// Controller watches Shards on the root shard, and then starts informers for every Shard,
+// watching the APIBindings and CRDs on them, to invalidate the cached discovery and OpenAPI
+// responses of their logical clusters.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	clientGetter ShardClientGetter
+	cache        *Cache
+
+	shardLister corev1alpha1listers.ShardLister
+
+	lock        sync.Mutex
+	shardStopCh map[string]chan struct{}
+}
+
+// Start the controller. It does not do anything until stopped, but starting the informers of the Shards.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+	defer func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		for _, stopCh := range c.shardStopCh {
+			close(stopCh)
+		}
+		c.shardStopCh = map[string]chan struct{}{}
+	}()
+
+	logger := klog.FromContext(ctx).WithValues("controller", controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) enqueueShard(ctx context.Context, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := klog.FromContext(ctx)
+	logger.WithValues("key", key).V(2).Info("enqueueing Shard")
+
+	c.queue.Add(key)
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+	shard, err := c.shardLister.Get(name)
+	if errors.IsNotFound(err) {
+		logger.V(2).Info("Shard not found, stopping informers")
+		c.stopShard(name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, found := c.shardStopCh[shard.Name]; found {
+		return nil
+	}
+
+	logger.V(1).Info("Starting informers for Shard")
+
+	kcpClient, crdClient, err := c.clientGetter(shard)
+	if err != nil {
+		return err
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: c.invalidate,
+		UpdateFunc: func(old, obj interface{}) {
+			c.invalidate(obj)
+		},
+		DeleteFunc: c.invalidate,
+	}
+
+	apiBindingInformer := apisv1alpha1informers.NewAPIBindingClusterInformer(kcpClient, resyncPeriod, nil)
+	apiBindingInformer.AddEventHandler(handler)
+	crdInformer := kcpapiextensionsv1informers.NewCustomResourceDefinitionClusterInformer(crdClient, resyncPeriod, nil)
+	crdInformer.AddEventHandler(handler)
+
+	stopCh := make(chan struct{})
+	c.shardStopCh[shard.Name] = stopCh
+
+	go apiBindingInformer.Run(stopCh)
+	go crdInformer.Run(stopCh)
+
+	// no need to wait, the cached responses expire anyway.
+
+	return nil
+}
+
+func (c *Controller) stopShard(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if stopCh, found := c.shardStopCh[name]; found {
+		close(stopCh)
+	}
+	delete(c.shardStopCh, name)
+}
+
+func (c *Controller) invalidate(obj interface{}) {
+	if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = final.Obj
+	}
+	switch obj := obj.(type) {
+	case *apisv1alpha1.APIBinding:
+		c.cache.Invalidate(logicalcluster.From(obj))
+	case *apiextensionsv1.CustomResourceDefinition:
+		c.cache.Invalidate(logicalcluster.From(obj))
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// maxBodySize is the maximum size of the cached responses. The larger responses are not cached.
+const maxBodySize = 16 << 20
+
+// cachedHeaders are the response headers cached along with the body. The other headers, e.g.,
+// Audit-Id or the warnings of the delegate, are specific to a response and are not shared.
+// Content-Encoding is cached as the responses are cached by Accept-Encoding, and their bodies
+// may be compressed.
+var cachedHeaders = []string{"Cache-Control", "Content-Encoding", "Content-Type", "ETag"}
+
+// ClusterResolver resolves the logical cluster of a workspace path.
+type ClusterResolver interface {
+	Lookup(path logicalcluster.Path) (shard string, cluster logicalcluster.Name, found bool)
+}
+
+// WithCaching serves the GET requests of the discovery and OpenAPI endpoints of the /clusters/<path>
+// workspaces from the cache, and caches the successful responses of the delegate to the other ones.
+//
+// The responses are shared by the users of a logical cluster. As the delegate authorizes the
+// users, a cached response is only served to the users that the delegate has served a response
+// of the same logical cluster to, within the TTL.
+func WithCaching(delegate http.Handler, resolver ClusterResolver, cache *Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+		cs := strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 3)
+		if len(cs) < 3 || cs[0] != "clusters" || !isDiscoveryPath(cs[2]) {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+		user, ok := request.UserFrom(req.Context())
+		if !ok {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+		path := logicalcluster.NewPath(cs[1])
+		if !path.IsValid() {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+		_, clusterName, found := resolver.Lookup(path)
+		if !found {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		key := cacheKey(req)
+		userKey := userKey(user.GetName(), user.GetGroups(), user.GetExtra())
+		if cache.authorized(clusterName, userKey) {
+			if e, found := cache.get(clusterName, key); found {
+				cacheRequests.WithLabelValues("hit").Inc()
+				for k, v := range e.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write(e.body) //nolint:errcheck
+				return
+			}
+		}
+		cacheRequests.WithLabelValues("miss").Inc()
+
+		generation := cache.generation(clusterName)
+		rw := &recordingResponseWriter{ResponseWriter: w}
+		delegate.ServeHTTP(rw, req)
+		if rw.status != http.StatusOK {
+			return
+		}
+		cache.setAuthorized(clusterName, userKey)
+		if !rw.overflow {
+			header := http.Header{}
+			for _, h := range cachedHeaders {
+				if values := w.Header().Values(h); len(values) > 0 {
+					header[http.CanonicalHeaderKey(h)] = append([]string(nil), values...)
+				}
+			}
+			cache.set(clusterName, generation, key, header, rw.body.Bytes())
+		}
+	}
+}
+
+// isDiscoveryPath returns whether the path, relative to a workspace, is the one of a discovery
+// or an OpenAPI endpoint.
+func isDiscoveryPath(path string) bool {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	switch segments[0] {
+	case "api":
+		return len(segments) <= 2
+	case "apis":
+		return len(segments) <= 3
+	case "openapi":
+		return len(segments) >= 2 && (segments[1] == "v2" && len(segments) == 2 || segments[1] == "v3")
+	}
+	return false
+}
+
+// cacheKey returns the key of the response to the request.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	for _, s := range []string{req.URL.Path, req.URL.RawQuery, req.Header.Get("Accept"), req.Header.Get("Accept-Encoding")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// userKey returns the key of the authorization decisions of the user.
+func userKey(name string, groups []string, extra map[string][]string) string {
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	groups = append([]string(nil), groups...)
+	sort.Strings(groups)
+	for _, group := range groups {
+		h.Write([]byte(group))
+		h.Write([]byte{0})
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		for _, v := range extra[k] {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingResponseWriter records the status and the body of a response, up to maxBodySize.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxBodySize {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
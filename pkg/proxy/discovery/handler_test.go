@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type resolver map[logicalcluster.Path]logicalcluster.Name
+
+func (r resolver) Lookup(path logicalcluster.Path) (string, logicalcluster.Name, bool) {
+	cluster, found := r[path]
+	return "root", cluster, found
+}
+
+func TestWithCaching(t *testing.T) {
+	calls := 0
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Audit-Id", "foo")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "public")
+		w.Header().Set("Warning", `299 - "deprecated"`)
+		w.Header().Set("Set-Cookie", "session=alice")
+		if req.URL.Path == "/clusters/root:missing/apis" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if user, _ := request.UserFrom(req.Context()); user.GetName() == "mallory" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"kind":"APIGroupList"}`)) //nolint:errcheck
+	})
+
+	now := time.Now()
+	c := NewCache(time.Minute, 1<<20)
+	c.now = func() time.Time { return now }
+	handler := WithCaching(delegate, resolver{
+		logicalcluster.NewPath("root:org"):     "abc",
+		logicalcluster.NewPath("root:missing"): "def",
+	}, c)
+
+	serve := func(method, path, userName string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: userName}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/clusters/root:org/apis", "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, calls)
+
+	t.Log("The response is served from the cache")
+	w = serve(http.MethodGet, "/clusters/root:org/apis", "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `{"kind":"APIGroupList"}`, w.Body.String())
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.Equal(t, `"v1"`, w.Header().Get("ETag"))
+	require.Equal(t, "public", w.Header().Get("Cache-Control"))
+	require.Empty(t, w.Header().Get("Audit-Id"))
+	require.Empty(t, w.Header().Get("Warning"))
+	require.Empty(t, w.Header().Get("Set-Cookie"))
+	require.Equal(t, 1, calls)
+
+	t.Log("The responses are only served from the cache to the users authorized by the delegate")
+	w = serve(http.MethodGet, "/clusters/root:org/apis", "mallory")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, 2, calls)
+	w = serve(http.MethodGet, "/clusters/root:org/apis", "mallory")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, 3, calls)
+
+	t.Log("The responses are shared by the authorized users")
+	serve(http.MethodGet, "/clusters/root:org/apis", "bob")
+	require.Equal(t, 4, calls)
+	serve(http.MethodGet, "/clusters/root:org/api", "bob")
+	require.Equal(t, 5, calls)
+	serve(http.MethodGet, "/clusters/root:org/api", "alice")
+	require.Equal(t, 5, calls)
+
+	t.Log("The other requests are not cached")
+	serve(http.MethodGet, "/clusters/root:org/apis/apps/v1/deployments", "alice")
+	serve(http.MethodGet, "/clusters/root:org/apis/apps/v1/deployments", "alice")
+	serve(http.MethodPost, "/clusters/root:org/apis", "alice")
+	require.Equal(t, 8, calls)
+
+	t.Log("The failed responses are not cached")
+	serve(http.MethodGet, "/clusters/root:missing/apis", "alice")
+	serve(http.MethodGet, "/clusters/root:missing/apis", "alice")
+	require.Equal(t, 10, calls)
+
+	t.Log("The invalidated responses are not served")
+	c.Invalidate("abc")
+	serve(http.MethodGet, "/clusters/root:org/apis", "alice")
+	require.Equal(t, 11, calls)
+
+	t.Log("The expired responses are not served")
+	now = now.Add(time.Minute)
+	serve(http.MethodGet, "/clusters/root:org/apis", "alice")
+	require.Equal(t, 12, calls)
+}
+
+func TestIsDiscoveryPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"api":                         true,
+		"api/v1":                      true,
+		"api/v1/pods":                 false,
+		"apis":                        true,
+		"apis/apps":                   true,
+		"apis/apps/v1":                true,
+		"apis/apps/v1/deployments":    false,
+		"openapi/v2":                  true,
+		"openapi/v3":                  true,
+		"openapi/v3/apis/apps/v1":     true,
+		"openapi":                     false,
+		"openapi/v2/foo":              false,
+		"version":                     false,
+		"apis/apps/v1/namespaces/foo": false,
+	} {
+		require.Equal(t, want, isDiscoveryPath(path), path)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	now := time.Now()
+	body := make([]byte, 100)
+	c := NewCache(time.Minute, 250)
+	c.now = func() time.Time { return now }
+
+	c.set("abc", 0, "a", nil, body)
+	c.set("def", 0, "b", nil, body)
+	_, found := c.get("abc", "a")
+	require.True(t, found)
+	c.set("abc", 0, "c", nil, body)
+	_, found = c.get("def", "b")
+	require.False(t, found, "the least recently used response is evicted when the cache is full")
+	_, found = c.get("abc", "a")
+	require.True(t, found)
+	_, found = c.get("abc", "c")
+	require.True(t, found)
+	require.LessOrEqual(t, c.size, c.maxBytes)
+
+	c.set("abc", 0, "d", nil, make([]byte, 300))
+	_, found = c.get("abc", "d")
+	require.False(t, found, "the responses larger than the cache are not cached")
+
+	now = now.Add(time.Minute)
+	_, found = c.get("abc", "a")
+	require.False(t, found, "the expired responses are not served")
+
+	c.set("abc", 0, "e", nil, body)
+	c.Invalidate("abc")
+	require.Zero(t, c.size)
+	c.set("abc", 0, "e", nil, body)
+	_, found = c.get("abc", "e")
+	require.False(t, found, "the responses requested before the invalidation are not cached")
+}
@@ -50,6 +50,7 @@ const (
 
 type Index interface {
 	LookupURL(path logicalcluster.Path) (url string, found bool)
+	Lookup(path logicalcluster.Path) (shard string, cluster logicalcluster.Name, found bool)
 }
 
 type ClusterWorkspaceClientGetter func(shard *corev1alpha1.Shard) (kcpclientset.ClusterInterface, error)
@@ -289,3 +290,7 @@ func (c *Controller) stopShard(shard *corev1alpha1.Shard) {
 func (c *Controller) LookupURL(path logicalcluster.Path) (url string, found bool) {
 	return c.state.LookupURL(path)
 }
+
+func (c *Controller) Lookup(path logicalcluster.Path) (shard string, cluster logicalcluster.Name, found bool) {
+	return c.state.Lookup(path)
+}
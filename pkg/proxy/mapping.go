@@ -24,10 +24,14 @@ import (
 	"net/url"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/traces"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 
+	"github.com/kcp-dev/kcp/pkg/proxy/discovery"
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
 )
@@ -46,7 +50,9 @@ type PathMapping struct {
 	ExtraHeaderPrefix string `json:"extra_header_prefix"`
 }
 
-func NewHandler(ctx context.Context, o *proxyoptions.Options, index index.Index) (http.Handler, error) {
+// NewHandler returns the handler of the front-proxy. The discovery and OpenAPI responses of the
+// workspaces are cached in discoveryCache, unless it is nil.
+func NewHandler(ctx context.Context, o *proxyoptions.Options, index index.Index, discoveryCache *discovery.Cache, tracerProvider *trace.TracerProvider) (http.Handler, error) {
 	mappingData, err := os.ReadFile(o.MappingFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping file %q: %w", o.MappingFile, err)
@@ -82,21 +88,26 @@ func NewHandler(ctx context.Context, o *proxyoptions.Options, index index.Index)
 			return nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
 		}
 
-		transport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA)
+		baseTransport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create path mapping for path %q: %w", m.Path, err)
 		}
+		// propagate the trace context to the shards and virtual workspaces
+		transport := traces.WrapperFor(tracerProvider)(baseTransport)
 
 		var handler http.Handler
 		if m.Path == "/clusters/" {
 			clusterProxy := newShardReverseProxy()
 			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
+			handler = shardHandler(index, withUpgradeHandling(clusterProxy, newUpgradeHandler(baseTransport.TLSClientConfig, shardLocation)))
+			if discoveryCache != nil {
+				handler = discovery.WithCaching(handler, index, discoveryCache)
+			}
 		} else {
 			// TODO: handle virtual workspace apiservers per shard
 			proxy := httputil.NewSingleHostReverseProxy(u)
 			proxy.Transport = transport
-			handler = proxy
+			handler = withUpgradeHandling(proxy, newUpgradeHandler(baseTransport.TLSClientConfig, backendLocation(u)))
 		}
 
 		userHeader := "X-Remote-User"
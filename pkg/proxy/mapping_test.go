@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/client-go/util/cert"
+	"sigs.k8s.io/yaml"
+
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+func TestHandlerPropagatesTraceContext(t *testing.T) {
+	traceParents := make(chan string, 1)
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceParents <- req.Header.Get("traceparent")
+	}))
+	defer backend.Close()
+
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	clientCert, clientKey, err := cert.GenerateSelfSignedCertKey("front-proxy", nil, nil)
+	require.NoError(t, err)
+	mapping, err := yaml.Marshal([]PathMapping{{
+		Path:            "/services/",
+		Backend:         backend.URL,
+		BackendServerCA: writeFile("ca.crt", pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: backend.Certificate().Raw})),
+		ProxyClientCert: writeFile("client.crt", clientCert),
+		ProxyClientKey:  writeFile("client.key", clientKey),
+	}})
+	require.NoError(t, err)
+
+	var tracerProvider trace.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	handler, err := NewHandler(context.Background(), &proxyoptions.Options{MappingFile: writeFile("mapping.yaml", mapping)}, nil, nil, &tracerProvider)
+	require.NoError(t, err)
+	serverSpans := make(chan trace.SpanContext, 1)
+	front := httptest.NewServer(genericapifilters.WithTracing(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		serverSpans <- trace.SpanContextFromContext(req.Context())
+		handler.ServeHTTP(w, req)
+	}), &tracerProvider))
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/services/apiexport/root/export/clusters/*/api/v1/configmaps")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	serverSpan, traceParent := <-serverSpans, <-traceParents
+	parts := strings.Split(traceParent, "-")
+	require.Len(t, parts, 4, "expected a traceparent header, got %q", traceParent)
+	require.True(t, serverSpan.IsValid())
+	require.Equal(t, serverSpan.TraceID().String(), parts[1], "expected the trace of the front-proxy to be propagated to the backend")
+	require.NotEqual(t, serverSpan.SpanID().String(), parts[2], "expected the span of the proxied request to be propagated to the backend")
+}
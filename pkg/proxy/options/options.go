@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/pflag"
 
@@ -28,20 +29,28 @@ import (
 
 type Options struct {
 	SecureServing    apiserveroptions.SecureServingOptionsWithLoopback
+	Tracing          *apiserveroptions.TracingOptions
 	Authentication   Authentication
 	MappingFile      string
 	RootDirectory    string
 	RootKubeconfig   string
 	ShardsKubeconfig string
 	ProfilerAddress  string
+
+	DiscoveryCacheTTL      time.Duration
+	DiscoveryCacheMaxBytes int64
 }
 
 func NewOptions() *Options {
 	o := &Options{
 		SecureServing:  *apiserveroptions.NewSecureServingOptions().WithLoopback(),
 		Authentication: *NewAuthentication(),
+		Tracing:        apiserveroptions.NewTracingOptions(),
 		RootKubeconfig: "",
 		RootDirectory:  ".kcp",
+
+		DiscoveryCacheTTL:      time.Minute,
+		DiscoveryCacheMaxBytes: 256 << 20,
 	}
 
 	// override all the things
@@ -54,11 +63,14 @@ func NewOptions() *Options {
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.SecureServing.AddFlags(fs)
 	o.Authentication.AddFlags(fs)
+	o.Tracing.AddFlags(fs)
 	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
 	fs.StringVar(&o.RootDirectory, "root-directory", o.RootDirectory, "Root directory.")
 	fs.StringVar(&o.RootKubeconfig, "root-kubeconfig", o.RootKubeconfig, "The path to the kubeconfig of the root shard.")
 	fs.StringVar(&o.ShardsKubeconfig, "shards-kubeconfig", o.ShardsKubeconfig, "The path to the kubeconfig used for communication with all shards. The server name if provided is replaced with a shard's hostname.")
 	fs.StringVar(&o.ProfilerAddress, "profiler-address", "", "[Address]:port to bind the profiler to")
+	fs.DurationVar(&o.DiscoveryCacheTTL, "discovery-cache-ttl", o.DiscoveryCacheTTL, "The duration the discovery and OpenAPI responses of the workspaces are cached for, in case the changes of their APIs are missed. 0 disables the caching.")
+	fs.Int64Var(&o.DiscoveryCacheMaxBytes, "discovery-cache-max-bytes", o.DiscoveryCacheMaxBytes, "The maximum total size in bytes of the discovery and OpenAPI responses cached. The least recently used responses are evicted first.")
 }
 
 func (o *Options) Complete() error {
@@ -89,9 +101,16 @@ func (o *Options) Validate() []error {
 	if len(o.ShardsKubeconfig) == 0 {
 		errs = append(errs, fmt.Errorf("--shards-kubeconfig is required"))
 	}
+	if o.DiscoveryCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("--discovery-cache-ttl must not be negative"))
+	}
+	if o.DiscoveryCacheMaxBytes <= 0 {
+		errs = append(errs, fmt.Errorf("--discovery-cache-max-bytes must be positive"))
+	}
 
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
+	errs = append(errs, o.Tracing.Validate()...)
 
 	return errs
 }
@@ -22,28 +22,34 @@ import (
 	"net/http"
 	"time"
 
+	kcpapiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/kcp/clientset/versioned"
 	"k8s.io/apimachinery/pkg/util/wait"
+	kauthenticator "k8s.io/apiserver/pkg/authentication/authenticator"
 	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
 	restclient "k8s.io/client-go/rest"
 	_ "k8s.io/component-base/metrics/prometheus/workqueue"
 	"k8s.io/klog/v2"
 
+	virtualworkspacesoptions "github.com/kcp-dev/kcp/cmd/virtual-workspaces/options"
 	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/proxy/discovery"
 	frontproxyfilters "github.com/kcp-dev/kcp/pkg/proxy/filters"
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 	"github.com/kcp-dev/kcp/pkg/proxy/metrics"
 	"github.com/kcp-dev/kcp/pkg/server"
 	"github.com/kcp-dev/kcp/pkg/server/requestinfo"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/audiences"
 )
 
 type Server struct {
 	CompletedConfig
 	Handler                  http.Handler
 	IndexController          *index.Controller
+	DiscoveryController      *discovery.Controller
 	KcpSharedInformerFactory kcpinformers.SharedScopedInformerFactory
 }
 
@@ -70,7 +76,30 @@ func NewServer(ctx context.Context, c CompletedConfig) (*Server, error) {
 		},
 	)
 
-	s.Handler, err = NewHandler(ctx, s.CompletedConfig.Options, s.IndexController)
+	var discoveryCache *discovery.Cache
+	if s.CompletedConfig.Options.DiscoveryCacheTTL > 0 {
+		discoveryCache = discovery.NewCache(s.CompletedConfig.Options.DiscoveryCacheTTL, s.CompletedConfig.Options.DiscoveryCacheMaxBytes)
+		s.DiscoveryController = discovery.NewController(
+			ctx,
+			s.KcpSharedInformerFactory.Core().V1alpha1().Shards(),
+			func(shard *corev1alpha1.Shard) (kcpclientset.ClusterInterface, kcpapiextensionsclientset.ClusterInterface, error) {
+				shardConfig := restclient.CopyConfig(s.CompletedConfig.ShardsConfig)
+				shardConfig.Host = shard.Spec.BaseURL
+				shardClient, err := kcpclientset.NewForConfig(shardConfig)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to create shard %q client: %w", shard.Name, err)
+				}
+				crdClient, err := kcpapiextensionsclientset.NewForConfig(shardConfig)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to create shard %q CRD client: %w", shard.Name, err)
+				}
+				return shardClient, crdClient, nil
+			},
+			discoveryCache,
+		)
+	}
+
+	s.Handler, err = NewHandler(ctx, s.CompletedConfig.Options, s.IndexController, discoveryCache, s.CompletedConfig.TracerProvider)
 
 	if err != nil {
 		return s, err
@@ -103,21 +132,33 @@ func (s preparedServer) Run(ctx context.Context) error {
 
 	// start index
 	go s.IndexController.Start(ctx, 2)
+	if s.DiscoveryController != nil {
+		go s.DiscoveryController.Start(ctx, 2)
+	}
 
 	s.KcpSharedInformerFactory.Start(ctx.Done())
 	s.KcpSharedInformerFactory.WaitForCacheSync(ctx.Done())
 
 	// start the server
 	failedHandler := frontproxyfilters.NewUnauthorizedHandler()
+	// the tokens bound to the audiences of a virtual workspace are only accepted for the requests to it
+	authenticator := audiences.WithAudiences(
+		s.CompletedConfig.AuthenticationInfo.Authenticator,
+		s.CompletedConfig.AuthenticationInfo.APIAudiences,
+		func(req *http.Request) kauthenticator.Audiences {
+			return audiences.ForPath(virtualworkspacesoptions.DefaultRootPathPrefix, req.URL.Path)
+		},
+	)
 	s.Handler = frontproxyfilters.WithOptionalAuthentication(
 		s.Handler,
 		failedHandler,
-		s.CompletedConfig.AuthenticationInfo.Authenticator,
+		authenticator,
 		s.CompletedConfig.AdditionalAuthEnabled)
 
 	requestInfoFactory := requestinfo.NewFactory()
 	s.Handler = server.WithInClusterServiceAccountRequestRewrite(s.Handler)
 	s.Handler = genericapifilters.WithRequestInfo(s.Handler, requestInfoFactory)
+	s.Handler = genericapifilters.WithTracing(s.Handler, s.CompletedConfig.TracerProvider)
 	s.Handler = genericfilters.WithHTTPLogging(s.Handler)
 	s.Handler = metrics.WithLatencyTracking(s.Handler)
 	s.Handler = genericfilters.WithPanicRecovery(s.Handler, requestInfoFactory)
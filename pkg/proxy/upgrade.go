@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// upgradeDialTimeout is the timeout to connect to the backend of an upgrade request.
+	upgradeDialTimeout = 30 * time.Second
+	// upgradeResponseTimeout is the timeout to receive the response of the backend to an upgrade request.
+	upgradeResponseTimeout = 30 * time.Second
+)
+
+// withUpgradeHandling serves the requests upgrading the connection, e.g., the SPDY and WebSocket
+// requests of exec, attach and port-forward, with upgrade, and delegates the other ones.
+func withUpgradeHandling(delegate http.Handler, upgrade *upgradeHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if httpstream.IsUpgradeRequest(req) {
+			upgrade.ServeHTTP(w, req)
+			return
+		}
+		delegate.ServeHTTP(w, req)
+	}
+}
+
+// upgradeHandler proxies the requests upgrading the connection to their backend, over a
+// HTTP/1.1 connection, as the upgrades are not supported by HTTP/2. Once upgraded, the
+// streams are copied in both directions, until both are closed, so that the half-closes
+// are propagated end-to-end.
+type upgradeHandler struct {
+	tlsConfig *tls.Config
+	// location returns the URL of the backend of the request.
+	location func(req *http.Request) (*url.URL, error)
+}
+
+func newUpgradeHandler(tlsConfig *tls.Config, location func(req *http.Request) (*url.URL, error)) *upgradeHandler {
+	return &upgradeHandler{
+		tlsConfig: tlsConfig,
+		location:  location,
+	}
+}
+
+func (h *upgradeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := klog.FromContext(req.Context())
+
+	location, err := h.location(req)
+	if err != nil {
+		responsewriters.InternalError(w, req, err)
+		return
+	}
+
+	backendConn, err := h.dial(req.Context(), location)
+	if err != nil {
+		logger.Error(err, "failed to connect to the backend of the upgrade request", "location", location.Host)
+		http.Error(w, fmt.Sprintf("failed to connect to the backend: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer backendConn.Close()
+
+	outReq := req.Clone(req.Context())
+	outReq.URL = location
+	outReq.Host = location.Host
+	outReq.RequestURI = ""
+	if err := backendConn.SetDeadline(time.Now().Add(upgradeResponseTimeout)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err := outReq.Write(backendConn); err != nil {
+		logger.Error(err, "failed to send the upgrade request to the backend", "location", location.Host)
+		http.Error(w, fmt.Sprintf("failed to send the request to the backend: %v", err), http.StatusBadGateway)
+		return
+	}
+	backendReader := bufio.NewReader(backendConn)
+	resp, err := http.ReadResponse(backendReader, outReq)
+	if err != nil {
+		logger.Error(err, "failed to read the response of the backend to the upgrade request", "location", location.Host)
+		http.Error(w, fmt.Sprintf("failed to read the response of the backend: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if err := backendConn.SetDeadline(time.Time{}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// the upgrade has been refused, e.g., because of the authorization, pass the response through
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body) //nolint:errcheck
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "the connection cannot be upgraded", http.StatusInternalServerError)
+		return
+	}
+	clientConn, clientBuffer, err := hijacker.Hijack()
+	if err != nil {
+		logger.Error(err, "failed to hijack the connection of the upgrade request")
+		return
+	}
+	defer clientConn.Close()
+
+	if err := resp.Write(clientConn); err != nil {
+		logger.V(4).Info("failed to send the upgrade response", "err", err)
+		return
+	}
+
+	// the data read ahead from the client are sent before the rest of the stream
+	if buffered := clientBuffer.Reader.Buffered(); buffered > 0 {
+		data, err := clientBuffer.Reader.Peek(buffered)
+		if err == nil {
+			_, err = backendConn.Write(data)
+		}
+		if err != nil {
+			logger.V(4).Info("failed to proxy the upgraded stream", "err", err)
+			return
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyAndCloseWrite(backendConn, clientConn, clientConn)
+	}()
+	go func() {
+		defer wg.Done()
+		// the backend reader holds the data read ahead from the backend
+		copyAndCloseWrite(clientConn, backendReader, backendConn)
+	}()
+	wg.Wait()
+}
+
+// dial connects to the backend over HTTP/1.1.
+func (h *upgradeHandler) dial(ctx context.Context, location *url.URL) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: upgradeDialTimeout}
+	host := location.Host
+	if location.Port() == "" {
+		switch location.Scheme {
+		case "https":
+			host = net.JoinHostPort(location.Hostname(), "443")
+		case "http":
+			host = net.JoinHostPort(location.Hostname(), "80")
+		default:
+			return nil, fmt.Errorf("unsupported scheme %q", location.Scheme)
+		}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if location.Scheme != "https" {
+		return conn, nil
+	}
+
+	var tlsConfig *tls.Config
+	if h.tlsConfig != nil {
+		tlsConfig = h.tlsConfig.Clone()
+	} else {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = location.Hostname()
+	}
+	tlsConfig.NextProtos = []string{"http/1.1"}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	handshakeCtx, cancel := context.WithTimeout(ctx, upgradeDialTimeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// copyAndCloseWrite copies the stream from src to dst, and then closes the writes of dst, so that
+// the other direction keeps flowing until it is closed too. If the copy fails, both the connections
+// are closed.
+func copyAndCloseWrite(dst net.Conn, src io.Reader, srcConn net.Conn) {
+	if _, err := io.Copy(dst, src); err != nil && !errors.Is(err, net.ErrClosed) && !utilnet.IsProbableEOF(err) {
+		klog.Background().V(4).Info("failed to proxy the upgraded stream", "err", err)
+		srcConn.Close()
+		dst.Close()
+		return
+	}
+	if closeWriter, ok := dst.(interface{ CloseWrite() error }); ok {
+		if err := closeWriter.CloseWrite(); err == nil {
+			return
+		}
+	}
+	dst.Close()
+}
+
+// backendLocation returns the location of the backend of a request, for a static backend URL.
+func backendLocation(backend *url.URL) func(req *http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		location := *backend
+		location.Path = strings.TrimSuffix(backend.Path, "/") + "/" + strings.TrimPrefix(req.URL.Path, "/")
+		location.RawPath = ""
+		location.RawQuery = req.URL.RawQuery
+		return &location, nil
+	}
+}
+
+// shardLocation returns the location of the backend of a request, for the shard of the request.
+func shardLocation(req *http.Request) (*url.URL, error) {
+	shardURL := ShardURLFrom(req.Context())
+	if shardURL == nil {
+		return nil, fmt.Errorf("no shard URL found in request context")
+	}
+	location := *shardURL
+	location.RawQuery = req.URL.RawQuery
+	return &location, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeHandler(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/clusters/root/api/v1/namespaces/default/pods/foo/exec" || req.URL.Query().Get("command") != "sh" {
+			http.NotFound(w, req)
+			return
+		}
+		conn, buffer, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: SPDY/3.1\r\n\r\n") //nolint:errcheck
+		buffer.Flush()                                                                                             //nolint:errcheck
+
+		// echo the stream, until the client closes its writes, and then say goodbye
+		_, err = io.Copy(conn, buffer)
+		require.NoError(t, err)
+		conn.Write([]byte("bye")) //nolint:errcheck
+	}))
+	defer backend.Close()
+
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	handler := newUpgradeHandler(backend.Client().Transport.(*http.Transport).TLSClientConfig, backendLocation(backendURL))
+	front := httptest.NewServer(withUpgradeHandling(http.NotFoundHandler(), handler))
+	defer front.Close()
+
+	t.Log("Refused upgrades are passed through")
+	req, err := http.NewRequest(http.MethodPost, front.URL+"/clusters/root/api/v1/namespaces/default/pods/bar/exec", nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "SPDY/3.1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	t.Log("The upgraded streams are proxied, with half-close")
+	conn, err := net.Dial("tcp", front.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	req, err = http.NewRequest(http.MethodPost, front.URL+"/clusters/root/api/v1/namespaces/default/pods/foo/exec?command=sh", nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "SPDY/3.1")
+	require.NoError(t, req.Write(conn))
+
+	reader := bufio.NewReader(conn)
+	resp, err = http.ReadResponse(reader, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	received, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "hellobye", string(received))
+}
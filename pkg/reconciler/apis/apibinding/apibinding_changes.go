@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+)
+
+// bindingChange is how much of an APIBinding must be reconciled.
+type bindingChange int
+
+const (
+	// bindingChangeNone is a change of an APIExport or an APIResourceSchema which does not
+	// affect the bindings, e.g., of its status conditions or of its virtual workspace URLs.
+	bindingChangeNone bindingChange = iota
+	// bindingChangeClaims is a change of the permission claims of an APIExport only. The bound
+	// resources of the bindings do not need to be checked again.
+	bindingChangeClaims
+	// bindingChangeFull requires a full reconciliation of the bindings.
+	bindingChangeFull
+)
+
+// apiExportChange returns how much of the bindings of an APIExport must be reconciled on an
+// update of the APIExport. Resyncs, and changes of fields not read by the reconciliation, do not
+// enqueue the bindings, so that the updates of popular APIExports do not trigger a
+// reconciliation of all their bindings on the shard.
+func apiExportChange(old, obj *apisv1alpha1.APIExport) bindingChange {
+	if old.UID != obj.UID ||
+		old.Annotations[core.LogicalClusterPathAnnotationKey] != obj.Annotations[core.LogicalClusterPathAnnotationKey] ||
+		old.DeletionTimestamp.IsZero() != obj.DeletionTimestamp.IsZero() ||
+		old.Status.IdentityHash != obj.Status.IdentityHash ||
+		!equality.Semantic.DeepEqual(old.Spec.LatestResourceSchemas, obj.Spec.LatestResourceSchemas) {
+		return bindingChangeFull
+	}
+	if !equality.Semantic.DeepEqual(old.Spec.PermissionClaims, obj.Spec.PermissionClaims) {
+		return bindingChangeClaims
+	}
+	return bindingChangeNone
+}
+
+// apiResourceSchemaChange returns how much of the bindings of an APIResourceSchema must be
+// reconciled on an update of the APIResourceSchema. The spec of APIResourceSchemas is
+// immutable, hence updates are usually changes of their metadata, which do not affect the
+// bindings.
+func apiResourceSchemaChange(old, obj *apisv1alpha1.APIResourceSchema) bindingChange {
+	if old.UID != obj.UID ||
+		old.DeletionTimestamp.IsZero() != obj.DeletionTimestamp.IsZero() ||
+		!equality.Semantic.DeepEqual(old.Spec, obj.Spec) {
+		return bindingChangeFull
+	}
+	return bindingChangeNone
+}
+
+// pendingChanges records how much of the queued APIBindings must be reconciled. A key missing
+// from the record, e.g., requeued after an error, is reconciled fully.
+type pendingChanges struct {
+	lock    sync.Mutex
+	changes map[string]bindingChange
+}
+
+func newPendingChanges() *pendingChanges {
+	return &pendingChanges{
+		changes: map[string]bindingChange{},
+	}
+}
+
+// add records a change of the APIBinding with the given key. Changes only widen until the key
+// is processed.
+func (p *pendingChanges) add(key string, change bindingChange) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if change > p.changes[key] {
+		p.changes[key] = change
+	}
+}
+
+// pop returns the change to reconcile for the key, and forgets it.
+func (p *pendingChanges) pop(key string) bindingChange {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	change, ok := p.changes[key]
+	delete(p.changes, key)
+	if !ok {
+		return bindingChangeFull
+	}
+	return change
+}
+
+type bindingChangeContextKeyType int
+
+const bindingChangeContextKey bindingChangeContextKeyType = iota
+
+func withBindingChange(ctx context.Context, change bindingChange) context.Context {
+	return context.WithValue(ctx, bindingChangeContextKey, change)
+}
+
+// bindingChangeFrom returns the change to reconcile, bindingChangeFull by default.
+func bindingChangeFrom(ctx context.Context) bindingChange {
+	if change, ok := ctx.Value(bindingChangeContextKey).(bindingChange); ok {
+		return change
+	}
+	return bindingChangeFull
+}
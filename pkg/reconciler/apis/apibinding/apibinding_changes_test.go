@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func TestAPIExportChange(t *testing.T) {
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "export", ResourceVersion: "1"},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"today.widgets.example.io"},
+		},
+		Status: apisv1alpha1.APIExportStatus{IdentityHash: "hash"},
+	}
+
+	tests := map[string]struct {
+		mutate func(export *apisv1alpha1.APIExport)
+		want   bindingChange
+	}{
+		"resync": {
+			mutate: func(export *apisv1alpha1.APIExport) {},
+			want:   bindingChangeNone,
+		},
+		"conditions": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				export.ResourceVersion = "2"
+				export.Status.Conditions = conditionsv1alpha1.Conditions{{Type: "Ready", Status: "True"}}
+			},
+			want: bindingChangeNone,
+		},
+		"permission claims": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				export.Spec.PermissionClaims = []apisv1alpha1.PermissionClaim{{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}}
+			},
+			want: bindingChangeClaims,
+		},
+		"schemas": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				export.Spec.LatestResourceSchemas = append(export.Spec.LatestResourceSchemas, "today.gadgets.example.io")
+			},
+			want: bindingChangeFull,
+		},
+		"schemas and permission claims": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				export.Spec.LatestResourceSchemas = nil
+				export.Spec.PermissionClaims = []apisv1alpha1.PermissionClaim{{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}}
+			},
+			want: bindingChangeFull,
+		},
+		"identity": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				export.Status.IdentityHash = "other"
+			},
+			want: bindingChangeFull,
+		},
+		"deletion": {
+			mutate: func(export *apisv1alpha1.APIExport) {
+				now := metav1.Now()
+				export.DeletionTimestamp = &now
+			},
+			want: bindingChangeFull,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj := export.DeepCopy()
+			tc.mutate(obj)
+			require.Equal(t, tc.want, apiExportChange(export, obj))
+		})
+	}
+}
+
+func TestPendingChanges(t *testing.T) {
+	p := newPendingChanges()
+
+	t.Log("Keys without recorded change are reconciled fully")
+	require.Equal(t, bindingChangeFull, p.pop("a"))
+
+	t.Log("Changes widen until the key is processed")
+	p.add("a", bindingChangeClaims)
+	require.Equal(t, bindingChangeClaims, p.pop("a"))
+	p.add("a", bindingChangeClaims)
+	p.add("a", bindingChangeFull)
+	p.add("a", bindingChangeClaims)
+	require.Equal(t, bindingChangeFull, p.pop("a"))
+	require.Equal(t, bindingChangeFull, p.pop("a"))
+
+	t.Log("The change is passed to the reconciliation through the context")
+	require.Equal(t, bindingChangeFull, bindingChangeFrom(context.Background()))
+	require.Equal(t, bindingChangeClaims, bindingChangeFrom(withBindingChange(context.Background(), bindingChangeClaims)))
+}
@@ -24,7 +24,10 @@ import (
 	"github.com/go-logr/logr"
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/kcp-dev/logicalcluster/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"k8s.io/apiextensions-apiserver/pkg/apihelpers"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -39,7 +42,6 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
@@ -49,9 +51,15 @@ import (
 	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/priorityqueue"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	"github.com/kcp-dev/kcp/pkg/tracing"
 )
 
 const (
@@ -74,11 +82,16 @@ func NewController(
 	temporaryRemoteShardApiExportInformer apisv1alpha1informers.APIExportClusterInformer, /*TODO(p0lyn0mial): replace with multi-shard informers*/
 	temporaryRemoteShardApiResourceSchemaInformer apisv1alpha1informers.APIResourceSchemaClusterInformer, /*TODO(p0lyn0mial): replace with multi-shard informers*/
 	crdInformer kcpapiextensionsv1informers.CustomResourceDefinitionClusterInformer,
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	tracerProvider trace.TracerProvider,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := priorityqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue:                queue,
+		pendingChanges:       newPendingChanges(),
+		tracer:               tracing.Tracer(tracerProvider),
+		recorder:             events.NewRecorder(kubeClusterClient, ControllerName),
 		crdClusterClient:     crdClusterClient,
 		kcpClusterClient:     kcpClusterClient,
 		dynamicClusterClient: dynamicClusterClient,
@@ -161,9 +174,11 @@ func NewController(
 	})
 
 	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIBinding(obj, logger, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIBinding(obj, logger, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj, logger, "") },
+		AddFunc: func(obj interface{}) { c.enqueueAPIBinding(obj, logger, bindingChangeFull, priorityqueue.Normal, "") },
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueueAPIBinding(obj, logger, bindingChangeFull, priorityqueue.UpdatePriority(old, obj), "")
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj, logger, bindingChangeFull, priorityqueue.Normal, "") },
 	})
 
 	crdInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
@@ -196,25 +211,43 @@ func NewController(
 	})
 
 	apiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
+		AddFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Normal, "") },
+		UpdateFunc: func(old, obj interface{}) {
+			if apiResourceSchemaChange(old.(*apisv1alpha1.APIResourceSchema), obj.(*apisv1alpha1.APIResourceSchema)) != bindingChangeNone {
+				c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Normal, "")
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Normal, "") },
 	})
 
 	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
+		AddFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, bindingChangeFull, priorityqueue.Normal, "") },
+		UpdateFunc: func(old, obj interface{}) {
+			if change := apiExportChange(old.(*apisv1alpha1.APIExport), obj.(*apisv1alpha1.APIExport)); change != bindingChangeNone {
+				c.enqueueAPIExport(obj, logger, change, priorityqueue.Normal, "")
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, bindingChangeFull, priorityqueue.Normal, "") },
 	})
+	// objects replicated from other shards are not user-facing changes in this shard, and
+	// are processed behind them.
 	temporaryRemoteShardApiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
+		AddFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, bindingChangeFull, priorityqueue.Low, "") },
+		UpdateFunc: func(old, obj interface{}) {
+			if change := apiExportChange(old.(*apisv1alpha1.APIExport), obj.(*apisv1alpha1.APIExport)); change != bindingChangeNone {
+				c.enqueueAPIExport(obj, logger, change, priorityqueue.Low, "")
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, bindingChangeFull, priorityqueue.Low, "") },
 	})
 	temporaryRemoteShardApiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
+		AddFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Low, "") },
+		UpdateFunc: func(old, obj interface{}) {
+			if apiResourceSchemaChange(old.(*apisv1alpha1.APIResourceSchema), obj.(*apisv1alpha1.APIResourceSchema)) != bindingChangeNone {
+				c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Low, "")
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, priorityqueue.Low, "") },
 	})
 
 	return c, nil
@@ -231,7 +264,12 @@ type CommitFunc = func(context.Context, *Resource, *Resource) error
 // referenced from APIBindings. It also watches CRDs, APIResourceSchemas, and APIExports to ensure whenever
 // objects related to an APIBinding are updated, the APIBinding is reconciled.
 type controller struct {
-	queue workqueue.RateLimitingInterface
+	queue    priorityqueue.Interface
+	tracer   trace.Tracer
+	recorder *events.Recorder
+
+	// pendingChanges are how much of the queued APIBindings must be reconciled.
+	pendingChanges *pendingChanges
 
 	crdClusterClient     kcpapiextensionsclientset.ClusterInterface
 	kcpClusterClient     kcpclientset.ClusterInterface
@@ -257,7 +295,7 @@ type controller struct {
 }
 
 // enqueueAPIBinding enqueues an APIBinding .
-func (c *controller) enqueueAPIBinding(obj interface{}, logger logr.Logger, logSuffix string) {
+func (c *controller) enqueueAPIBinding(obj interface{}, logger logr.Logger, change bindingChange, priority priorityqueue.Priority, logSuffix string) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
@@ -265,11 +303,12 @@ func (c *controller) enqueueAPIBinding(obj interface{}, logger logr.Logger, logS
 	}
 
 	logging.WithQueueKey(logger, key).V(2).Info(fmt.Sprintf("queueing APIBinding%s", logSuffix))
-	c.queue.Add(key)
+	c.pendingChanges.add(key, change)
+	c.queue.AddWithPriority(key, priority)
 }
 
 // enqueueAPIExport enqueues maps an APIExport to APIBindings for enqueuing.
-func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSuffix string) {
+func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, change bindingChange, priority priorityqueue.Priority, logSuffix string) {
 	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
 		obj = d.Obj
 	}
@@ -283,7 +322,7 @@ func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSu
 	// binding keys by full path
 	keys := sets.NewString()
 	if path := logicalcluster.NewPath(export.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
-		pathKeys, err := c.apiBindingsIndexer.IndexKeys(indexers.APIBindingsByAPIExport, path.Join(export.Name).String())
+		pathKeys, err := c.apiBindingsIndexer.IndexKeys(indexers.APIBindingsByAPIExport, indexkeys.NewPathAndName(path, export.Name).String())
 		if err != nil {
 			runtime.HandleError(err)
 			return
@@ -291,7 +330,7 @@ func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSu
 		keys.Insert(pathKeys...)
 	}
 
-	clusterKeys, err := c.apiBindingsIndexer.IndexKeys(indexers.APIBindingsByAPIExport, logicalcluster.From(export).Path().Join(export.Name).String())
+	clusterKeys, err := c.apiBindingsIndexer.IndexKeys(indexers.APIBindingsByAPIExport, indexkeys.NewPathAndName(logicalcluster.From(export).Path(), export.Name).String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -307,7 +346,7 @@ func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSu
 			runtime.HandleError(fmt.Errorf("APIBinding %q does not exist", key))
 			continue
 		}
-		c.enqueueAPIBinding(binding, logging.WithObject(logger, obj.(*apisv1alpha1.APIExport)), fmt.Sprintf(" because of APIExport%s", logSuffix))
+		c.enqueueAPIBinding(binding, logging.WithObject(logger, obj.(*apisv1alpha1.APIExport)), change, priority, fmt.Sprintf(" because of APIExport%s", logSuffix))
 	}
 }
 
@@ -339,11 +378,11 @@ func (c *controller) enqueueCRD(obj interface{}, logger logr.Logger) {
 	// and hence stale APIBindings. So this might help to undersand what's going on.
 	logger.V(4).Info("queueing APIResourceSchema because of CRD", "key", kcpcache.ToClusterAwareKey(clusterName.String(), "", apiResourceSchema.Name))
 
-	c.enqueueAPIResourceSchema(apiResourceSchema, logger, " because of CRD")
+	c.enqueueAPIResourceSchema(apiResourceSchema, logger, priorityqueue.Normal, " because of CRD")
 }
 
 // enqueueAPIResourceSchema maps an APIResourceSchema to APIExports for enqueuing.
-func (c *controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logger, logSuffix string) {
+func (c *controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logger, priority priorityqueue.Priority, logSuffix string) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
@@ -364,7 +403,7 @@ func (c *controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logge
 	}
 
 	for _, export := range apiExports {
-		c.enqueueAPIExport(export, logging.WithObject(logger, obj.(*apisv1alpha1.APIResourceSchema)), fmt.Sprintf(" because of APIResourceSchema%s", logSuffix))
+		c.enqueueAPIExport(export, logging.WithObject(logger, obj.(*apisv1alpha1.APIResourceSchema)), bindingChangeFull, priority, fmt.Sprintf(" because of APIResourceSchema%s", logSuffix))
 	}
 }
 
@@ -378,6 +417,8 @@ func (c *controller) Start(ctx context.Context, numThreads int) {
 	logger.Info("Starting controller")
 	defer logger.Info("Shutting down controller")
 
+	c.recorder.Start(ctx)
+
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
 	}
@@ -406,7 +447,10 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if requeue, err := c.process(ctx, key); err != nil {
+	start := time.Now()
+	requeue, err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -427,6 +471,8 @@ func (c *controller) process(ctx context.Context, key string) (bool, error) {
 		return false, nil
 	}
 
+	ctx = withBindingChange(ctx, c.pendingChanges.pop(key))
+
 	obj, err := c.apiBindingsLister.Cluster(clusterName).Get(name)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -436,6 +482,10 @@ func (c *controller) process(ctx context.Context, key string) (bool, error) {
 		return false, nil // nothing we can do here
 	}
 
+	// the reconciliation, and the CRDs it creates, are traced as part of the request that changed the binding
+	ctx, span := tracing.StartReconcileSpan(ctx, c.tracer, ControllerName, key, obj)
+	defer span.End()
+
 	old := obj
 	obj = obj.DeepCopy()
 
@@ -453,7 +503,14 @@ func (c *controller) process(ctx context.Context, key string) (bool, error) {
 	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
 	if err := c.commit(ctx, oldResource, newResource); err != nil {
 		errs = append(errs, err)
+	} else {
+		c.recordEvents(old, obj)
 	}
 
-	return requeue, utilerrors.NewAggregate(errs)
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return requeue, err
+	}
+	return requeue, nil
 }
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+const (
+	// BindingStartedReason is the reason of the event recorded when the binding of a new APIBinding starts.
+	BindingStartedReason = "BindingStarted"
+	// BoundReason is the reason of the event recorded when an APIBinding becomes bound.
+	BoundReason = "Bound"
+	// SchemaUpdatedReason is the reason of the event recorded when a bound resource is updated to a new schema.
+	SchemaUpdatedReason = "SchemaUpdated"
+	// PermissionClaimsPendingReason is the reason of the event recorded when the APIExport makes permission
+	// claims that are neither accepted nor rejected by the APIBinding.
+	PermissionClaimsPendingReason = "PermissionClaimsPending"
+)
+
+// recordEvents records the events of the transitions of the APIBinding from old to new.
+func (c *controller) recordEvents(old, new *apisv1alpha1.APIBinding) {
+	if old.Status.Phase != new.Status.Phase {
+		switch new.Status.Phase {
+		case apisv1alpha1.APIBindingPhaseBinding:
+			c.recorder.Eventf(new, corev1.EventTypeNormal, BindingStartedReason, "Binding to APIExport %s", exportReference(new))
+		case apisv1alpha1.APIBindingPhaseBound:
+			c.recorder.Eventf(new, corev1.EventTypeNormal, BoundReason, "Bound %d resource(s) of APIExport %s", len(new.Status.BoundResources), exportReference(new))
+		}
+	}
+
+	c.recorder.ConditionTransitions(new, old.Status.Conditions, new.Status.Conditions)
+
+	for _, updated := range updatedSchemas(old.Status.BoundResources, new.Status.BoundResources) {
+		c.recorder.Eventf(new, corev1.EventTypeNormal, SchemaUpdatedReason, "Updated %s.%s to APIResourceSchema %s", updated.Resource, updated.Group, updated.Schema.Name)
+	}
+
+	if pending := pendingClaims(new); len(pending) > 0 && !reflect.DeepEqual(pending, pendingClaims(old)) {
+		c.recorder.Eventf(new, corev1.EventTypeWarning, PermissionClaimsPendingReason, "APIExport %s claims permissions that are neither accepted nor rejected: %s", exportReference(new), strings.Join(pending, ", "))
+	}
+}
+
+func exportReference(binding *apisv1alpha1.APIBinding) string {
+	if binding.Spec.Reference.Export == nil {
+		return ""
+	}
+	if binding.Spec.Reference.Export.Path == "" {
+		return binding.Spec.Reference.Export.Name
+	}
+	return binding.Spec.Reference.Export.Path + ":" + binding.Spec.Reference.Export.Name
+}
+
+// updatedSchemas returns the bound resources which schema changed.
+func updatedSchemas(old, new []apisv1alpha1.BoundAPIResource) []apisv1alpha1.BoundAPIResource {
+	var updated []apisv1alpha1.BoundAPIResource
+	for _, n := range new {
+		for _, o := range old {
+			if o.Group == n.Group && o.Resource == n.Resource && o.Schema.UID != n.Schema.UID {
+				updated = append(updated, n)
+			}
+		}
+	}
+	return updated
+}
+
+// pendingClaims returns the sorted permission claims of the APIExport the APIBinding does not acknowledge.
+func pendingClaims(binding *apisv1alpha1.APIBinding) []string {
+	var pending []string
+	for _, claim := range binding.Status.ExportPermissionClaims {
+		acknowledged := false
+		for _, acceptable := range binding.Spec.PermissionClaims {
+			if reflect.DeepEqual(acceptable.PermissionClaim, claim) {
+				acknowledged = true
+				break
+			}
+		}
+		if !acknowledged {
+			pending = append(pending, schema.GroupResource{Group: claim.Group, Resource: claim.Resource}.String())
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
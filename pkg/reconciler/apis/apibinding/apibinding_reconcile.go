@@ -204,6 +204,16 @@ func (r *bindingReconciler) reconcile(ctx context.Context, apiBinding *apisv1alp
 		return reconcileStatusContinue, nil
 	}
 
+	// Only the permission claims of the APIExport changed, which are recorded above: the bound
+	// resources of a binding that is up to date do not need to be checked again.
+	if bindingChangeFrom(ctx) == bindingChangeClaims &&
+		apiBinding.Status.Phase == apisv1alpha1.APIBindingPhaseBound &&
+		conditions.IsTrue(apiBinding, apisv1alpha1.APIExportValid) &&
+		conditions.IsTrue(apiBinding, apisv1alpha1.BindingUpToDate) {
+		logger.V(4).Info("skipping the bound resources, only the permission claims of the APIExport changed")
+		return reconcileStatusContinue, nil
+	}
+
 	var needToWaitForRequeueWhenEstablished []string
 
 	// Process all APIResourceSchemas
@@ -299,7 +309,7 @@ func (r *bindingReconciler) reconcile(ctx context.Context, apiBinding *apisv1alp
 			}
 		} else {
 			// Need to create bound CRD
-			crd, err := generateCRD(schema)
+			crd, err := GenerateCRD(schema)
 			if err != nil {
 				logger.Error(err, "error generating CRD")
 
@@ -455,11 +465,16 @@ func (r *bindingReconciler) reconcile(ctx context.Context, apiBinding *apisv1alp
 	return reconcileStatusContinue, nil
 }
 
+// boundCRDName returns the name of the CRD of an APIResourceSchema in the system:bound-crds
+// logical cluster. The bound CRD is shared by all the APIBindings to the APIExports of the
+// schema, whatever the number of consumer logical clusters: their status only references it
+// by the UID of the schema, and it is decorated with the identity of the APIExport when served.
 func boundCRDName(schema *apisv1alpha1.APIResourceSchema) string {
 	return string(schema.UID)
 }
 
-func generateCRD(schema *apisv1alpha1.APIResourceSchema) (*apiextensionsv1.CustomResourceDefinition, error) {
+// GenerateCRD returns the bound CRD of an APIResourceSchema.
+func GenerateCRD(schema *apisv1alpha1.APIResourceSchema) (*apiextensionsv1.CustomResourceDefinition, error) {
 	crd := &apiextensionsv1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: boundCRDName(schema),
@@ -46,6 +46,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
 	"github.com/kcp-dev/kcp/pkg/reconciler/core/logicalclusterdeletion/deletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -73,7 +74,7 @@ func NewController(
 	kcpClusterClient kcpclientset.ClusterInterface,
 	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
 ) *Controller {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &Controller{
 		queue: queue,
@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingusage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	apisv1alpha1client "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/apis/v1alpha1"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-apibinding-usage"
+)
+
+// NewController returns a new controller recording the usage of the bound resources in the
+// status of the APIBindings, at the given interval.
+func NewController(
+	kcpClusterClient kcpclientset.ClusterInterface,
+	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
+	dynamicDiscoverySharedInformerFactory *informer.DiscoveringDynamicSharedInformerFactory,
+	requests *RequestCounter,
+	interval time.Duration,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &controller{
+		queue:            queue,
+		interval:         interval,
+		requests:         requests,
+		apiBindingLister: apiBindingInformer.Lister(),
+		countObjects: func(clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (int64, bool, error) {
+			lister, known, synced := dynamicDiscoverySharedInformerFactory.Lister(gvr)
+			if !known || !synced {
+				return 0, false, nil
+			}
+			objs, err := lister.ByCluster(clusterName).List(labels.Everything())
+			if err != nil {
+				return 0, false, err
+			}
+			return int64(len(objs)), true, nil
+		},
+		now:    time.Now,
+		commit: committer.NewCommitter[*APIBinding, Patcher, *APIBindingSpec, *APIBindingStatus](kcpClusterClient.ApisV1alpha1().APIBindings()),
+	}
+
+	return c, nil
+}
+
+type APIBinding = apisv1alpha1.APIBinding
+type APIBindingSpec = apisv1alpha1.APIBindingSpec
+type APIBindingStatus = apisv1alpha1.APIBindingStatus
+type Patcher = apisv1alpha1client.APIBindingInterface
+type Resource = committer.Resource[*APIBindingSpec, *APIBindingStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller records the numbers of objects and requests of the bound resources in the status
+// of the APIBindings. All the APIBindings are reconciled at every interval, rather than on
+// every change, so that the status updates are bounded.
+type controller struct {
+	queue    workqueue.RateLimitingInterface
+	interval time.Duration
+	requests *RequestCounter
+
+	apiBindingLister apisv1alpha1listers.APIBindingClusterLister
+	// countObjects returns the number of objects of a resource in a logical cluster, and false
+	// if they are not known yet.
+	countObjects func(clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (int64, bool, error)
+	now          func() time.Time
+
+	commit CommitFunc
+}
+
+// enqueueAll enqueues the APIBindings, and drops the requests counted for the logical clusters
+// without APIBindings.
+func (c *controller) enqueueAll(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+
+	apiBindings, err := c.apiBindingLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	clusters := map[logicalcluster.Name]bool{}
+	for _, apiBinding := range apiBindings {
+		clusters[logicalcluster.From(apiBinding)] = true
+
+		key, err := kcpcache.MetaClusterNamespaceKeyFunc(apiBinding)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		logging.WithQueueKey(logger, key).V(4).Info("queueing APIBinding")
+		c.queue.Add(key)
+	}
+
+	c.requests.Prune(func(cluster logicalcluster.Name) bool {
+		return clusters[cluster]
+	})
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	c.requests.started.Store(true)
+	defer c.requests.started.Store(false)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	go wait.UntilWithContext(ctx, c.enqueueAll, c.interval)
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	cluster, _, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
+	obj, err := c.apiBindingLister.Cluster(cluster).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	recorded, err := c.reconcile(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		// the requests are recorded with the next update
+		return err
+	}
+	c.requests.Recorded(cluster, recorded)
+
+	return nil
+}
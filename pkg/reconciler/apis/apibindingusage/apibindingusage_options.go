@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingusage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.Interval, "apibinding-usage-accounting-interval", o.Interval, "The interval at which the numbers of objects and requests of the bound resources are recorded in the status of the APIBindings, readable by the API service providers through the APIExport virtual workspace. The accounting is disabled if zero.")
+	return o
+}
+
+type Options struct {
+	Interval time.Duration
+}
+
+// Enabled returns whether the API usage accounting is enabled.
+func (o *Options) Enabled() bool {
+	return o.Interval > 0
+}
+
+func (o *Options) Validate() error {
+	if o.Interval < 0 {
+		return fmt.Errorf("--apibinding-usage-accounting-interval must not be negative")
+	}
+	return nil
+}
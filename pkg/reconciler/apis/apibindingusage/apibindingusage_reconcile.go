@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingusage
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// reconcile updates the usage of the bound resources in the status of the APIBinding, and
+// returns the numbers of requests it adds, to be subtracted from the pending ones once the
+// status is updated.
+func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) (map[schema.GroupResource]int64, error) {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(apiBinding)
+
+	// the requests to the resources bound by none of the APIBindings of the workspace are dropped
+	apiBindings, err := c.apiBindingLister.Cluster(clusterName).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	bound := map[schema.GroupResource]bool{}
+	for _, b := range apiBindings {
+		for _, r := range b.Status.BoundResources {
+			bound[schema.GroupResource{Group: r.Group, Resource: r.Resource}] = true
+		}
+	}
+	pending := c.requests.Pending(clusterName, bound)
+
+	existing := map[schema.GroupResource]apisv1alpha1.BoundAPIResourceUsage{}
+	for _, usage := range apiBinding.Status.Usage {
+		existing[schema.GroupResource{Group: usage.Group, Resource: usage.Resource}] = usage
+	}
+
+	now := metav1.NewTime(c.now())
+	recorded := map[schema.GroupResource]int64{}
+	usages := make([]apisv1alpha1.BoundAPIResourceUsage, 0, len(apiBinding.Status.BoundResources))
+	for _, r := range apiBinding.Status.BoundResources {
+		gr := schema.GroupResource{Group: r.Group, Resource: r.Resource}
+		usage, found := existing[gr]
+		if !found {
+			usage = apisv1alpha1.BoundAPIResourceUsage{Group: r.Group, Resource: r.Resource}
+		}
+		updated := !found
+
+		if len(r.StorageVersions) > 0 {
+			gvr := gr.WithVersion(r.StorageVersions[len(r.StorageVersions)-1])
+			objects, known, err := c.countObjects(clusterName, gvr)
+			if err != nil {
+				return nil, err
+			}
+			if !known {
+				logger.V(4).Info("objects not known yet", "resource", gvr)
+			} else if objects != usage.Objects {
+				usage.Objects = objects
+				updated = true
+			}
+		}
+
+		if requests := pending[gr]; requests > 0 {
+			usage.Requests += requests
+			recorded[gr] = requests
+			updated = true
+		}
+
+		if updated {
+			usage.LastUpdateTime = now
+		}
+		usages = append(usages, usage)
+	}
+
+	if len(usages) == 0 {
+		usages = nil
+	}
+	apiBinding.Status.Usage = usages
+
+	return recorded, nil
+}
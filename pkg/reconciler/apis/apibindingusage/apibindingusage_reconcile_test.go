@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingusage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	widgets := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+	gadgets := schema.GroupResource{Group: "example.com", Resource: "gadgets"}
+	configmaps := schema.GroupResource{Resource: "configmaps"}
+	before := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	newAPIBinding := func(name string, grs ...schema.GroupResource) *apisv1alpha1.APIBinding {
+		apiBinding := &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+			},
+		}
+		for _, gr := range grs {
+			apiBinding.Status.BoundResources = append(apiBinding.Status.BoundResources, apisv1alpha1.BoundAPIResource{
+				Group:           gr.Group,
+				Resource:        gr.Resource,
+				StorageVersions: []string{"v1"},
+			})
+		}
+		return apiBinding
+	}
+
+	tests := map[string]struct {
+		existing     []apisv1alpha1.BoundAPIResourceUsage
+		requests     map[schema.GroupResource]int
+		objects      map[schema.GroupResource]int64
+		want         []apisv1alpha1.BoundAPIResourceUsage
+		wantRecorded map[schema.GroupResource]int64
+		wantPending  map[schema.GroupResource]int64
+	}{
+		"first accounting": {
+			requests: map[schema.GroupResource]int{widgets: 3, configmaps: 2, gadgets: 1},
+			objects:  map[schema.GroupResource]int64{widgets: 2},
+			want: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 3, LastUpdateTime: metav1.NewTime(now)},
+			},
+			wantRecorded: map[schema.GroupResource]int64{widgets: 3},
+			// the requests to the resources of the other binding are kept, the other ones are dropped
+			wantPending: map[schema.GroupResource]int64{widgets: 3, gadgets: 1},
+		},
+		"requests are added": {
+			existing: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 10, LastUpdateTime: before},
+			},
+			requests: map[schema.GroupResource]int{widgets: 1},
+			objects:  map[schema.GroupResource]int64{widgets: 2},
+			want: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 11, LastUpdateTime: metav1.NewTime(now)},
+			},
+			wantRecorded: map[schema.GroupResource]int64{widgets: 1},
+			wantPending:  map[schema.GroupResource]int64{widgets: 1},
+		},
+		"unchanged": {
+			existing: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 10, LastUpdateTime: before},
+			},
+			objects: map[schema.GroupResource]int64{widgets: 2},
+			want: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 10, LastUpdateTime: before},
+			},
+			wantRecorded: map[schema.GroupResource]int64{},
+			wantPending:  map[schema.GroupResource]int64{},
+		},
+		"objects not known yet": {
+			existing: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 10, LastUpdateTime: before},
+			},
+			want: []apisv1alpha1.BoundAPIResourceUsage{
+				{Group: "example.com", Resource: "widgets", Objects: 2, Requests: 10, LastUpdateTime: before},
+			},
+			wantRecorded: map[schema.GroupResource]int64{},
+			wantPending:  map[schema.GroupResource]int64{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+			apiBinding := newAPIBinding("widgets", widgets)
+			apiBinding.Status.Usage = tc.existing
+			require.NoError(t, indexer.Add(apiBinding))
+			require.NoError(t, indexer.Add(newAPIBinding("gadgets", gadgets)))
+
+			requests := NewRequestCounter()
+			requests.started.Store(true)
+			for gr, n := range tc.requests {
+				for i := 0; i < n; i++ {
+					requests.RecordRequest("root:consumer", gr)
+				}
+			}
+
+			c := &controller{
+				requests:         requests,
+				apiBindingLister: apisv1alpha1listers.NewAPIBindingClusterLister(indexer),
+				countObjects: func(clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (int64, bool, error) {
+					require.Equal(t, logicalcluster.Name("root:consumer"), clusterName)
+					objects, known := tc.objects[gvr.GroupResource()]
+					return objects, known, nil
+				},
+				now: func() time.Time { return now },
+			}
+
+			apiBinding = apiBinding.DeepCopy()
+			recorded, err := c.reconcile(context.Background(), apiBinding)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, apiBinding.Status.Usage)
+			require.Equal(t, tc.wantRecorded, recorded)
+
+			require.Equal(t, tc.wantPending, requests.Pending("root:consumer", map[schema.GroupResource]bool{widgets: true, gadgets: true}))
+			requests.Recorded("root:consumer", recorded)
+			delete(tc.wantPending, widgets)
+			require.Equal(t, tc.wantPending, requests.Pending("root:consumer", map[schema.GroupResource]bool{widgets: true, gadgets: true}))
+		})
+	}
+}
+
+func TestRequestCounter(t *testing.T) {
+	widgets := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+	bound := map[schema.GroupResource]bool{widgets: true}
+
+	requests := NewRequestCounter()
+	requests.RecordRequest("root:a", widgets)
+	require.Empty(t, requests.Pending("root:a", bound), "requests are not counted before the controller starts")
+
+	requests.started.Store(true)
+	requests.RecordRequest("root:a", widgets)
+	requests.RecordRequest("root:b", widgets)
+
+	t.Log("Requests counted while the status is updated are kept")
+	pending := requests.Pending("root:a", bound)
+	require.Equal(t, map[schema.GroupResource]int64{widgets: 1}, pending)
+	requests.RecordRequest("root:a", widgets)
+	requests.Recorded("root:a", pending)
+	require.Equal(t, map[schema.GroupResource]int64{widgets: 1}, requests.Pending("root:a", bound))
+
+	t.Log("The requests of the logical clusters without APIBindings are pruned")
+	requests.Prune(func(cluster logicalcluster.Name) bool { return cluster == "root:a" })
+	require.Empty(t, requests.Pending("root:b", bound))
+	require.Equal(t, map[schema.GroupResource]int64{widgets: 1}, requests.Pending("root:a", bound))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingusage
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RequestCounter counts the requests to the resources of logical clusters, until they are
+// recorded in the status of the APIBindings. The requests to resources that are not bound
+// are dropped when the APIBindings of the logical cluster are reconciled. Requests are only
+// counted once the controller recording them has started.
+type RequestCounter struct {
+	started atomic.Bool
+
+	lock   sync.Mutex
+	counts map[logicalcluster.Name]map[schema.GroupResource]int64
+}
+
+// NewRequestCounter returns a new RequestCounter.
+func NewRequestCounter() *RequestCounter {
+	return &RequestCounter{counts: map[logicalcluster.Name]map[schema.GroupResource]int64{}}
+}
+
+// RecordRequest counts a request to a resource of a logical cluster.
+func (c *RequestCounter) RecordRequest(cluster logicalcluster.Name, gr schema.GroupResource) {
+	if !c.started.Load() {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts, ok := c.counts[cluster]
+	if !ok {
+		counts = map[schema.GroupResource]int64{}
+		c.counts[cluster] = counts
+	}
+	counts[gr]++
+}
+
+// Pending returns the numbers of requests to the given resources of a logical cluster that
+// have not been recorded yet, and drops the counts of the other resources of the logical
+// cluster.
+func (c *RequestCounter) Pending(cluster logicalcluster.Name, grs map[schema.GroupResource]bool) map[schema.GroupResource]int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	pending := map[schema.GroupResource]int64{}
+	for gr, count := range c.counts[cluster] {
+		if !grs[gr] {
+			delete(c.counts[cluster], gr)
+			continue
+		}
+		pending[gr] = count
+	}
+	if len(c.counts[cluster]) == 0 {
+		delete(c.counts, cluster)
+	}
+	return pending
+}
+
+// Recorded subtracts the numbers of requests that have been recorded in the status of an
+// APIBinding, keeping the requests counted in the meantime.
+func (c *RequestCounter) Recorded(cluster logicalcluster.Name, recorded map[schema.GroupResource]int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := c.counts[cluster]
+	for gr, count := range recorded {
+		if counts[gr] <= count {
+			delete(counts, gr)
+			continue
+		}
+		counts[gr] -= count
+	}
+	if len(counts) == 0 {
+		delete(c.counts, cluster)
+	}
+}
+
+// Prune drops the counts of the logical clusters for which bound is false, i.e. the ones
+// without APIBindings.
+func (c *RequestCounter) Prune(bound func(cluster logicalcluster.Name) bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for cluster := range c.counts {
+		if !bound(cluster) {
+			delete(c.counts, cluster)
+		}
+	}
+}
@@ -48,6 +48,9 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -65,7 +68,7 @@ func NewController(
 	namespaceInformer kcpcorev1informers.NamespaceClusterInformer,
 	secretInformer kcpcorev1informers.SecretClusterInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue:             queue,
@@ -73,6 +76,7 @@ func NewController(
 		apiExportLister:   apiExportInformer.Lister(),
 		apiExportIndexer:  apiExportInformer.Informer().GetIndexer(),
 		kubeClusterClient: kubeClusterClient,
+		recorder:          events.NewRecorder(kubeClusterClient, ControllerName),
 		getNamespace: func(clusterName logicalcluster.Name, name string) (*corev1.Namespace, error) {
 			return namespaceInformer.Lister().Cluster(clusterName).Get(name)
 		},
@@ -152,7 +156,8 @@ type CommitFunc = func(context.Context, *Resource, *Resource) error
 
 // controller reconciles APIExports. It ensures an export's identity secret exists and is valid.
 type controller struct {
-	queue workqueue.RateLimitingInterface
+	queue    workqueue.RateLimitingInterface
+	recorder *events.Recorder
 
 	kcpClusterClient kcpclientset.ClusterInterface
 	apiExportLister  apisv1alpha1listers.APIExportClusterLister
@@ -241,6 +246,8 @@ func (c *controller) Start(ctx context.Context, numThreads int) {
 	logger.Info("Starting controller")
 	defer logger.Info("Shutting down controller")
 
+	c.recorder.Start(ctx)
+
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
 	}
@@ -269,7 +276,10 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -312,6 +322,8 @@ func (c *controller) process(ctx context.Context, key string) error {
 	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
 	if err := c.commit(ctx, oldResource, newResource); err != nil {
 		errs = append(errs, err)
+	} else {
+		c.recorder.ConditionTransitions(obj, old.Status.Conditions, obj.Status.Conditions)
 	}
 
 	return utilerrors.NewAggregate(errs)
@@ -22,6 +22,7 @@ import (
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 )
 
 const indexAPIExportEndpointSliceByAPIExport = "indexAPIExportEndpointSliceByAPIExport"
@@ -37,5 +38,5 @@ func indexAPIExportEndpointSliceByAPIExportFunc(obj interface{}) ([]string, erro
 	if path.Empty() {
 		path = logicalcluster.From(apiExportEndpointSlice).Path()
 	}
-	return []string{path.Join(apiExportEndpointSlice.Spec.APIExport.Name).String()}, nil
+	return []string{indexkeys.NewPathAndName(path, apiExportEndpointSlice.Spec.APIExport.Name).String()}, nil
 }
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+// SpecHash returns the hash of the spec of a NegotiatedAPIResource, as the value of the
+// apiresourcev1alpha1.ApprovedSpecAnnotationKey annotation approving its publication.
+func SpecHash(negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource) (string, error) {
+	bs, err := json.Marshal(negotiatedAPIResource.Spec.CommonAPIResourceSpec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// publicationApproval returns the Approved condition of the NegotiatedAPIResource, or nil if the
+// approval of its publication is not required.
+func (c *Controller) publicationApproval(clusterName logicalcluster.Name, negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource) (*apiresourcev1alpha1.NegotiatedAPIResourceCondition, error) {
+	var annotations map[string]string
+	logicalCluster, err := c.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		annotations = logicalCluster.Annotations
+	}
+
+	if !c.requirePublicationApproval && annotations[apiresourcev1alpha1.RequireApprovalAnnotationKey] != "true" {
+		return nil, nil
+	}
+
+	hash, err := SpecHash(negotiatedAPIResource)
+	if err != nil {
+		return nil, err
+	}
+	if negotiatedAPIResource.Annotations[apiresourcev1alpha1.ApprovedSpecAnnotationKey] == hash {
+		return &apiresourcev1alpha1.NegotiatedAPIResourceCondition{
+			Type:   apiresourcev1alpha1.Approved,
+			Status: metav1.ConditionTrue,
+			Reason: "Approved",
+		}, nil
+	}
+	gvr := negotiatedAPIResource.GVR()
+	if autoApproved(annotations[apiresourcev1alpha1.AutoApproveAnnotationKey], schema.GroupResource{Group: gvr.Group, Resource: gvr.Resource}) {
+		return &apiresourcev1alpha1.NegotiatedAPIResourceCondition{
+			Type:   apiresourcev1alpha1.Approved,
+			Status: metav1.ConditionTrue,
+			Reason: "AutoApproved",
+		}, nil
+	}
+	return &apiresourcev1alpha1.NegotiatedAPIResourceCondition{
+		Type:    apiresourcev1alpha1.Approved,
+		Status:  metav1.ConditionFalse,
+		Reason:  "PendingApproval",
+		Message: fmt.Sprintf("The publication must be approved with the %s=%s annotation", apiresourcev1alpha1.ApprovedSpecAnnotationKey, hash),
+	}, nil
+}
+
+// ensurePublicationApproval updates the Approved condition of the NegotiatedAPIResource, and returns
+// whether its publication is approved.
+func (c *Controller) ensurePublicationApproval(ctx context.Context, clusterName logicalcluster.Name, negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource) (bool, error) {
+	logger := klog.FromContext(ctx)
+	condition, err := c.publicationApproval(clusterName, negotiatedAPIResource)
+	if err != nil {
+		return false, err
+	}
+
+	existing := negotiatedAPIResource.FindCondition(apiresourcev1alpha1.Approved)
+	if !apiresourcev1alpha1.IsNegotiatedAPIResourceConditionEquivalent(existing, condition) {
+		updated := negotiatedAPIResource.DeepCopy()
+		if condition == nil {
+			updated.RemoveCondition(apiresourcev1alpha1.Approved)
+		} else {
+			updated.SetCondition(*condition)
+		}
+		if _, err := c.kcpClusterClient.Cluster(logicalcluster.From(updated).Path()).ApiresourceV1alpha1().NegotiatedAPIResources().UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			logger.Error(err, "error updating NegotiatedAPIResource status")
+			return false, err
+		}
+	}
+
+	return condition == nil || condition.Status == metav1.ConditionTrue, nil
+}
+
+func autoApproved(value string, gr schema.GroupResource) bool {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" || (entry != "" && schema.ParseGroupResource(entry) == gr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+)
+
+func TestPublicationApproval(t *testing.T) {
+	negotiatedAPIResource := &apiresourcev1alpha1.NegotiatedAPIResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployments.v1.apps"},
+		Spec: apiresourcev1alpha1.NegotiatedAPIResourceSpec{
+			CommonAPIResourceSpec: apiresourcev1alpha1.CommonAPIResourceSpec{
+				GroupVersion: apiresourcev1alpha1.GroupVersion{Group: "apps", Version: "v1"},
+			},
+			Publish: true,
+		},
+	}
+	negotiatedAPIResource.Spec.Plural = "deployments"
+	hash, err := SpecHash(negotiatedAPIResource)
+	require.NoError(t, err)
+
+	changed := negotiatedAPIResource.DeepCopy()
+	changed.Spec.Kind = "Deployment"
+	changedHash, err := SpecHash(changed)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, changedHash)
+
+	tests := map[string]struct {
+		requireApproval           bool
+		logicalClusterAnnotations map[string]string
+		approvedSpec              string
+		wantNil                   bool
+		wantStatus                metav1.ConditionStatus
+		wantReason                string
+	}{
+		"not required": {
+			wantNil: true,
+		},
+		"required and pending": {
+			requireApproval: true,
+			wantStatus:      metav1.ConditionFalse,
+			wantReason:      "PendingApproval",
+		},
+		"required by the workspace": {
+			logicalClusterAnnotations: map[string]string{apiresourcev1alpha1.RequireApprovalAnnotationKey: "true"},
+			wantStatus:                metav1.ConditionFalse,
+			wantReason:                "PendingApproval",
+		},
+		"approved": {
+			requireApproval: true,
+			approvedSpec:    hash,
+			wantStatus:      metav1.ConditionTrue,
+			wantReason:      "Approved",
+		},
+		"approved another spec": {
+			requireApproval: true,
+			approvedSpec:    changedHash,
+			wantStatus:      metav1.ConditionFalse,
+			wantReason:      "PendingApproval",
+		},
+		"auto-approved resource": {
+			requireApproval:           true,
+			logicalClusterAnnotations: map[string]string{apiresourcev1alpha1.AutoApproveAnnotationKey: "services, deployments.apps"},
+			wantStatus:                metav1.ConditionTrue,
+			wantReason:                "AutoApproved",
+		},
+		"auto-approved workspace": {
+			requireApproval:           true,
+			logicalClusterAnnotations: map[string]string{apiresourcev1alpha1.AutoApproveAnnotationKey: "*"},
+			wantStatus:                metav1.ConditionTrue,
+			wantReason:                "AutoApproved",
+		},
+		"other resource auto-approved": {
+			requireApproval:           true,
+			logicalClusterAnnotations: map[string]string{apiresourcev1alpha1.AutoApproveAnnotationKey: "deployments"},
+			wantStatus:                metav1.ConditionFalse,
+			wantReason:                "PendingApproval",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+			annotations := map[string]string{logicalcluster.AnnotationKey: "root:org"}
+			for k, v := range tc.logicalClusterAnnotations {
+				annotations[k] = v
+			}
+			require.NoError(t, indexer.Add(&corev1alpha1.LogicalCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: corev1alpha1.LogicalClusterName, Annotations: annotations},
+			}))
+			c := &Controller{
+				logicalClusterLister:       corev1alpha1listers.NewLogicalClusterClusterLister(indexer),
+				requirePublicationApproval: tc.requireApproval,
+			}
+
+			negotiatedAPIResource := negotiatedAPIResource.DeepCopy()
+			if tc.approvedSpec != "" {
+				negotiatedAPIResource.Annotations = map[string]string{apiresourcev1alpha1.ApprovedSpecAnnotationKey: tc.approvedSpec}
+			}
+			condition, err := c.publicationApproval("root:org", negotiatedAPIResource)
+			require.NoError(t, err)
+			if tc.wantNil {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, tc.wantStatus, condition.Status)
+			require.Equal(t, tc.wantReason, condition.Reason)
+			if tc.wantStatus == metav1.ConditionFalse {
+				require.Contains(t, condition.Message, hash)
+			}
+		})
+	}
+}
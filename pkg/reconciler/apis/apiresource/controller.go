@@ -30,6 +30,7 @@ import (
 	kcpapiextensionsv1listers "k8s.io/apiextensions-apiserver/pkg/client/kcp/listers/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -37,10 +38,14 @@ import (
 	"k8s.io/klog/v2"
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
 	apiresourcev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apiresource/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const clusterNameAndGVRIndexName = "clusterNameAndGVR"
@@ -53,24 +58,28 @@ func GetClusterNameAndGVRIndexKey(clusterName logicalcluster.Name, gvr metav1.Gr
 func NewController(
 	crdClusterClient kcpapiextensionsclientset.ClusterInterface,
 	kcpClusterClient kcpclientset.ClusterInterface,
-	autoPublishNegotiatedAPIResource bool,
+	defaultNegotiationStrategy apiresourcev1alpha1.NegotiationStrategyType,
+	requirePublicationApproval bool,
 	negotiatedAPIResourceInformer apiresourceinformer.NegotiatedAPIResourceClusterInformer,
 	apiResourceImportInformer apiresourceinformer.APIResourceImportClusterInformer,
 	crdInformer kcpapiextensionsv1informers.CustomResourceDefinitionClusterInformer,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-apiresource")
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For("kcp-apiresource"), "kcp-apiresource")
 
 	c := &Controller{
-		queue:                            queue,
-		crdClusterClient:                 crdClusterClient,
-		kcpClusterClient:                 kcpClusterClient,
-		AutoPublishNegotiatedAPIResource: autoPublishNegotiatedAPIResource,
-		negotiatedApiResourceIndexer:     negotiatedAPIResourceInformer.Informer().GetIndexer(),
-		negotiatedApiResourceLister:      negotiatedAPIResourceInformer.Lister(),
-		apiResourceImportIndexer:         apiResourceImportInformer.Informer().GetIndexer(),
-		apiResourceImportLister:          apiResourceImportInformer.Lister(),
-		crdIndexer:                       crdInformer.Informer().GetIndexer(),
-		crdLister:                        crdInformer.Lister(),
+		queue:                        queue,
+		crdClusterClient:             crdClusterClient,
+		kcpClusterClient:             kcpClusterClient,
+		defaultNegotiationStrategy:   defaultNegotiationStrategy,
+		requirePublicationApproval:   requirePublicationApproval,
+		negotiatedApiResourceIndexer: negotiatedAPIResourceInformer.Informer().GetIndexer(),
+		negotiatedApiResourceLister:  negotiatedAPIResourceInformer.Lister(),
+		apiResourceImportIndexer:     apiResourceImportInformer.Informer().GetIndexer(),
+		apiResourceImportLister:      apiResourceImportInformer.Lister(),
+		crdIndexer:                   crdInformer.Informer().GetIndexer(),
+		crdLister:                    crdInformer.Lister(),
+		logicalClusterLister:         logicalClusterInformer.Lister(),
 	}
 
 	negotiatedAPIResourceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -124,6 +133,44 @@ func NewController(
 		return nil, fmt.Errorf("failed to add indexer for CustomResourceDefinition: %w", err)
 	}
 
+	// renegotiate the imports of a workspace when its negotiation strategies change,
+	// and reconsider the publication of its negotiated resources when its approval policy changes
+	logicalClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, obj interface{}) {
+			oldLogicalCluster, ok := oldObj.(*corev1alpha1.LogicalCluster)
+			if !ok {
+				return
+			}
+			logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster)
+			if !ok {
+				return
+			}
+			changed := func(key string) bool {
+				return oldLogicalCluster.Annotations[key] != logicalCluster.Annotations[key]
+			}
+			if changed(apiresourcev1alpha1.NegotiationStrategyAnnotationKey) {
+				imports, err := c.apiResourceImportLister.Cluster(logicalcluster.From(logicalCluster)).List(labels.Everything())
+				if err != nil {
+					runtime.HandleError(err)
+					return
+				}
+				for _, apiResourceImport := range imports {
+					c.enqueue(addHandlerAction, nil, apiResourceImport)
+				}
+			}
+			if changed(apiresourcev1alpha1.RequireApprovalAnnotationKey) || changed(apiresourcev1alpha1.AutoApproveAnnotationKey) {
+				negotiatedAPIResources, err := c.negotiatedApiResourceLister.Cluster(logicalcluster.From(logicalCluster)).List(labels.Everything())
+				if err != nil {
+					runtime.HandleError(err)
+					return
+				}
+				for _, negotiatedAPIResource := range negotiatedAPIResources {
+					c.enqueue(addHandlerAction, nil, negotiatedAPIResource)
+				}
+			}
+		},
+	})
+
 	return c, nil
 }
 
@@ -141,7 +188,10 @@ type Controller struct {
 	crdIndexer cache.Indexer
 	crdLister  kcpapiextensionsv1listers.CustomResourceDefinitionClusterLister
 
-	AutoPublishNegotiatedAPIResource bool
+	logicalClusterLister corev1alpha1listers.LogicalClusterClusterLister
+
+	defaultNegotiationStrategy apiresourcev1alpha1.NegotiationStrategyType
+	requirePublicationApproval bool
 }
 
 type queueElementType string
@@ -226,7 +276,7 @@ func toQueueElementType(oldObj, obj interface{}) (theType queueElementType, gvr
 		tombstone := typedObj
 		theType, gvr, oldMeta, newMeta, oldStatus, newStatus = toQueueElementType(nil, tombstone.Obj)
 		if theType == "" {
-			klog.Errorf("Tombstone contained object that is not expected %#v", obj)
+			runtime.HandleError(fmt.Errorf("tombstone contained object that is not expected %#v", obj))
 		}
 	}
 	return
@@ -148,7 +148,7 @@ func (c *Controller) process(ctx context.Context, key queueElement) error {
 			}
 		}
 		switch key.theAction {
-		case createdAction, specChangedAction:
+		case createdAction, specChangedAction, annotationOrLabelsOnlyChanged:
 			// if status.Enforced
 			// => Check the schema of all APIResourceImports for this GVR against the schema of the NegotiatedAPIResource, and update the
 			//    status of each one with the right Compatible condition.
@@ -166,11 +166,18 @@ func (c *Controller) process(ctx context.Context, key queueElement) error {
 			//    If the CRD for the corresponding GVR exists and has a NegotiatedAPIResource owner
 			//    => update the CRD version of the existing CRD with the NegotiatedAPIResource spec content (schema included),
 			//       and add the current NegotiatedAPIResource as owner of the CRD
+			//    unless the publication of the current spec must be approved and is not.
 
 			if negotiatedApiResource.Spec.Publish && !negotiatedApiResource.IsConditionTrue(apiresourcev1alpha1.Enforced) {
-				if err := c.publishNegotiatedResource(ctx, logicalcluster.From(negotiatedApiResource), key.gvr, negotiatedApiResource); err != nil {
+				approved, err := c.ensurePublicationApproval(ctx, logicalcluster.From(negotiatedApiResource), negotiatedApiResource)
+				if err != nil {
 					return err
 				}
+				if approved {
+					if err := c.publishNegotiatedResource(ctx, logicalcluster.From(negotiatedApiResource), key.gvr, negotiatedApiResource); err != nil {
+						return err
+					}
+				}
 			}
 			fallthrough
 
@@ -392,6 +399,11 @@ func (c *Controller) ensureAPIResourceCompatibility(ctx context.Context, cluster
 		return nil
 	}
 
+	strategy, err := c.negotiationStrategy(logger, clusterName, gvr)
+	if err != nil {
+		return err
+	}
+
 	negotiatedAPIResourceName := gvr.Resource + "." + gvr.Version + "."
 	if gvr.Group == "" {
 		negotiatedAPIResourceName += "core"
@@ -482,7 +494,7 @@ func (c *Controller) ensureAPIResourceCompatibility(ctx context.Context, cluster
 				},
 				Spec: apiresourcev1alpha1.NegotiatedAPIResourceSpec{
 					CommonAPIResourceSpec: apiResourceImport.Spec.CommonAPIResourceSpec,
-					Publish:               c.AutoPublishNegotiatedAPIResource,
+					Publish:               strategy.AutoPublish(),
 				},
 			}
 			if negotiatedAPIResource != nil {
@@ -501,7 +513,7 @@ func (c *Controller) ensureAPIResourceCompatibility(ctx context.Context, cluster
 			}
 		} else {
 			allowUpdateNegotiatedSchema := !newNegotiatedAPIResource.IsConditionTrue(apiresourcev1alpha1.Enforced) &&
-				apiResourceImport.Spec.SchemaUpdateStrategy.CanUpdate(newNegotiatedAPIResource.IsConditionTrue(apiresourcev1alpha1.Published))
+				strategy.CanUpdate(apiResourceImport.Spec.SchemaUpdateStrategy, newNegotiatedAPIResource.IsConditionTrue(apiresourcev1alpha1.Published))
 
 			// TODO Also check compatibility of non-schema things like group, names, short names, category, resourcescope, subresources, columns etc...
 
@@ -563,6 +575,11 @@ func (c *Controller) ensureAPIResourceCompatibility(ctx context.Context, cluster
 			return nil
 		})
 	}
+	if strategy.AutoPublish() && !newNegotiatedAPIResource.Spec.Publish && !newNegotiatedAPIResource.IsConditionTrue(apiresourcev1alpha1.Enforced) {
+		// the strategy may have changed since the NegotiatedAPIResource was created
+		newNegotiatedAPIResource.Spec.Publish = true
+		updatedNegotiatedSchema = true
+	}
 	if negotiatedAPIResource == nil {
 		existing, err := c.kcpClusterClient.Cluster(logicalcluster.From(newNegotiatedAPIResource).Path()).ApiresourceV1alpha1().NegotiatedAPIResources().Create(ctx, newNegotiatedAPIResource, metav1.CreateOptions{})
 		if k8serrors.IsAlreadyExists(err) {
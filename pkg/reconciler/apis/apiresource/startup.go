@@ -17,18 +17,22 @@ limitations under the License.
 package apiresource
 
 import (
+	"fmt"
 	"runtime"
 
 	"github.com/spf13/pflag"
 
 	"k8s.io/klog/v2"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 )
 
 // DefaultOptions are the default options for the apiresource controller.
 func DefaultOptions() *Options {
 	return &Options{
 		// Consumed by server instantiation
-		NumThreads: runtime.NumCPU(),
+		NumThreads:          runtime.NumCPU(),
+		NegotiationStrategy: string(apiresourcev1alpha1.NegotiationManual),
 	}
 }
 
@@ -37,20 +41,28 @@ func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
 	fs.BoolVar(&o.AutoPublishAPIs, "auto-publish-apis", o.AutoPublishAPIs, "If true, the APIs imported from physical clusters will be published automatically as CRDs")
 	fs.MarkDeprecated("auto-publish-apis", "This flag is deprecated and ignored. It will be removed in a future release.") //nolint:errcheck
 	fs.IntVar(&o.NumThreads, "apiresource-controller-threads", o.NumThreads, "Number of threads to use for the cluster controller.")
+	fs.BoolVar(&o.RequirePublicationApproval, "apiresource-require-publication-approval", o.RequirePublicationApproval, fmt.Sprintf("If true, the NegotiatedAPIResources are only published as CRDs once their spec is approved with the %s annotation, or by the %s annotation of the LogicalCluster. Workspaces can require the approval with the %s annotation of their LogicalCluster.", apiresourcev1alpha1.ApprovedSpecAnnotationKey, apiresourcev1alpha1.AutoApproveAnnotationKey, apiresourcev1alpha1.RequireApprovalAnnotationKey))
+	fs.StringVar(&o.NegotiationStrategy, "apiresource-negotiation-strategy", o.NegotiationStrategy, fmt.Sprintf("Default strategy negotiating the APIs imported from physical clusters, one of %s, %s or %s. It is overridden per workspace and per resource with the %s annotation of the LogicalCluster.", apiresourcev1alpha1.NegotiationStrict, apiresourcev1alpha1.NegotiationLCD, apiresourcev1alpha1.NegotiationManual, apiresourcev1alpha1.NegotiationStrategyAnnotationKey))
 	return o
 }
 
 // Options are the options for the cluster controller.
 type Options struct {
-	AutoPublishAPIs bool
-	NumThreads      int
+	AutoPublishAPIs            bool
+	NumThreads                 int
+	NegotiationStrategy        string
+	RequirePublicationApproval bool
 }
 
 func (o *Options) Validate() error {
 	if o.AutoPublishAPIs {
-		klog.Warningf("--auto-publish-apis is deprecated and ignored. Please remove it from the command line.")
+		klog.Background().Info("--auto-publish-apis is deprecated and ignored. Please remove it from the command line.")
 		o.AutoPublishAPIs = false
 	}
 
+	if _, err := ParseNegotiationStrategy(o.NegotiationStrategy); err != nil {
+		return fmt.Errorf("--apiresource-negotiation-strategy: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+// ParseNegotiationStrategy parses a negotiation strategy.
+func ParseNegotiationStrategy(value string) (apiresourcev1alpha1.NegotiationStrategyType, error) {
+	switch strategy := apiresourcev1alpha1.NegotiationStrategyType(value); strategy {
+	case apiresourcev1alpha1.NegotiationStrict, apiresourcev1alpha1.NegotiationLCD, apiresourcev1alpha1.NegotiationManual:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("invalid negotiation strategy %q, must be one of %s, %s or %s", value,
+		apiresourcev1alpha1.NegotiationStrict, apiresourcev1alpha1.NegotiationLCD, apiresourcev1alpha1.NegotiationManual)
+}
+
+// negotiationStrategies are the strategies of a workspace, as set in the
+// apiresourcev1alpha1.NegotiationStrategyAnnotationKey annotation.
+type negotiationStrategies struct {
+	workspace      apiresourcev1alpha1.NegotiationStrategyType
+	groupResources map[schema.GroupResource]apiresourcev1alpha1.NegotiationStrategyType
+}
+
+func parseNegotiationStrategies(value string) (*negotiationStrategies, error) {
+	strategies := &negotiationStrategies{groupResources: map[schema.GroupResource]apiresourcev1alpha1.NegotiationStrategyType{}}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		gr, value, found := strings.Cut(entry, "=")
+		if !found {
+			value = gr
+		}
+		strategy, err := ParseNegotiationStrategy(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if strategies.workspace != "" {
+				return nil, fmt.Errorf("more than one negotiation strategy for the workspace")
+			}
+			strategies.workspace = strategy
+			continue
+		}
+		strategies.groupResources[schema.ParseGroupResource(strings.TrimSpace(gr))] = strategy
+	}
+	return strategies, nil
+}
+
+// negotiationStrategy returns the negotiation strategy of the given resource in the given workspace,
+// falling back to the default strategy of the controller.
+func (c *Controller) negotiationStrategy(logger klog.Logger, clusterName logicalcluster.Name, gvr metav1.GroupVersionResource) (apiresourcev1alpha1.NegotiationStrategyType, error) {
+	logicalCluster, err := c.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+	if k8serrors.IsNotFound(err) {
+		return c.defaultNegotiationStrategy, nil
+	} else if err != nil {
+		return "", err
+	}
+	value, found := logicalCluster.Annotations[apiresourcev1alpha1.NegotiationStrategyAnnotationKey]
+	if !found {
+		return c.defaultNegotiationStrategy, nil
+	}
+	strategies, err := parseNegotiationStrategies(value)
+	if err != nil {
+		// an invalid annotation must not break the negotiation
+		logger.Error(err, "ignoring invalid negotiation strategy annotation", "annotation", apiresourcev1alpha1.NegotiationStrategyAnnotationKey)
+		return c.defaultNegotiationStrategy, nil
+	}
+	if strategy, found := strategies.groupResources[schema.GroupResource{Group: gvr.Group, Resource: gvr.Resource}]; found {
+		return strategy, nil
+	}
+	if strategies.workspace != "" {
+		return strategies.workspace, nil
+	}
+	return c.defaultNegotiationStrategy, nil
+}
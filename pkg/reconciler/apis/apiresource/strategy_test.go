@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+)
+
+func TestNegotiationStrategy(t *testing.T) {
+	deployments := metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	services := metav1.GroupVersionResource{Version: "v1", Resource: "services"}
+	ingresses := metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+
+	tests := map[string]struct {
+		annotation *string
+		want       map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType
+	}{
+		"no annotation": {
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				deployments: apiresourcev1alpha1.NegotiationManual,
+				services:    apiresourcev1alpha1.NegotiationManual,
+			},
+		},
+		"workspace": {
+			annotation: stringPtr("LCD"),
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				deployments: apiresourcev1alpha1.NegotiationLCD,
+				services:    apiresourcev1alpha1.NegotiationLCD,
+			},
+		},
+		"group resources": {
+			annotation: stringPtr("deployments.apps=Strict, services=LCD"),
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				deployments: apiresourcev1alpha1.NegotiationStrict,
+				services:    apiresourcev1alpha1.NegotiationLCD,
+				ingresses:   apiresourcev1alpha1.NegotiationManual,
+			},
+		},
+		"workspace and group resource": {
+			annotation: stringPtr("LCD,deployments.apps=Strict"),
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				deployments: apiresourcev1alpha1.NegotiationStrict,
+				services:    apiresourcev1alpha1.NegotiationLCD,
+			},
+		},
+		"invalid": {
+			annotation: stringPtr("LCD,deployments.apps=Loose"),
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				deployments: apiresourcev1alpha1.NegotiationManual,
+				services:    apiresourcev1alpha1.NegotiationManual,
+			},
+		},
+		"two workspace strategies": {
+			annotation: stringPtr("LCD,Strict"),
+			want: map[metav1.GroupVersionResource]apiresourcev1alpha1.NegotiationStrategyType{
+				services: apiresourcev1alpha1.NegotiationManual,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+			if tc.annotation != nil {
+				require.NoError(t, indexer.Add(&corev1alpha1.LogicalCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: corev1alpha1.LogicalClusterName,
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey:                         "root:org",
+							apiresourcev1alpha1.NegotiationStrategyAnnotationKey: *tc.annotation,
+						},
+					},
+				}))
+			}
+			c := &Controller{
+				logicalClusterLister:       corev1alpha1listers.NewLogicalClusterClusterLister(indexer),
+				defaultNegotiationStrategy: apiresourcev1alpha1.NegotiationManual,
+			}
+			for gvr, want := range tc.want {
+				got, err := c.negotiationStrategy(klog.Background(), "root:org", gvr)
+				require.NoError(t, err)
+				require.Equal(t, want, got, gvr.String())
+			}
+		})
+	}
+}
+
+func TestNegotiationStrategyCanUpdate(t *testing.T) {
+	require.False(t, apiresourcev1alpha1.NegotiationStrict.CanUpdate(apiresourcev1alpha1.UpdatePublished, true))
+	require.True(t, apiresourcev1alpha1.NegotiationStrict.CanUpdate(apiresourcev1alpha1.UpdateNever, false))
+	require.True(t, apiresourcev1alpha1.NegotiationLCD.CanUpdate(apiresourcev1alpha1.UpdateNever, true))
+	require.False(t, apiresourcev1alpha1.NegotiationManual.CanUpdate(apiresourcev1alpha1.UpdateUnpublished, true))
+	require.True(t, apiresourcev1alpha1.NegotiationManual.CanUpdate(apiresourcev1alpha1.UpdateUnpublished, false))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -44,7 +44,9 @@ import (
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -57,7 +59,7 @@ func NewController(
 	apiExportInformer apisinformers.APIExportClusterInformer,
 	apiBindingInformer apisinformers.APIBindingClusterInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue: queue,
@@ -71,7 +73,7 @@ func NewController(
 		apiBindingIndexer: apiBindingInformer.Informer().GetIndexer(),
 
 		getAPIBindingsByAPIExport: func(path logicalcluster.Path, exportName string) ([]*apisv1alpha1.APIBinding, error) {
-			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingsByAPIExport, path.Join(exportName).String())
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingsByAPIExport, indexkeys.NewPathAndName(path, exportName).String())
 		},
 		getAPIExport: func(path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
 			return indexers.ByPathAndName[*apisv1alpha1.APIExport](apisv1alpha1.Resource("apiexports"), apiExportInformer.Informer().GetIndexer(), path, name)
@@ -147,7 +149,7 @@ func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger) {
 	// APIBinding keys by full path
 	keys := sets.NewString()
 	if path := logicalcluster.NewPath(export.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
-		pathKeys, err := c.apiBindingIndexer.IndexKeys(indexers.APIBindingsByAPIExport, path.Join(export.Name).String())
+		pathKeys, err := c.apiBindingIndexer.IndexKeys(indexers.APIBindingsByAPIExport, indexkeys.NewPathAndName(path, export.Name).String())
 		if err != nil {
 			runtime.HandleError(err)
 			return
@@ -155,7 +157,7 @@ func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger) {
 		keys.Insert(pathKeys...)
 	}
 
-	clusterKeys, err := c.apiBindingIndexer.IndexKeys(indexers.APIBindingsByAPIExport, logicalcluster.From(export).Path().Join(export.Name).String())
+	clusterKeys, err := c.apiBindingIndexer.IndexKeys(indexers.APIBindingsByAPIExport, indexkeys.NewPathAndName(logicalcluster.From(export).Path(), export.Name).String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -26,6 +26,7 @@ import (
 	"github.com/go-logr/logr"
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -46,6 +47,8 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -57,16 +60,18 @@ const (
 func NewController(
 	kcpClusterClient kcpclientset.ClusterInterface,
 	dynamicClusterClient kcpdynamic.ClusterInterface,
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
 	dynamicDiscoverySharedInformerFactory *informer.DiscoveringDynamicSharedInformerFactory,
 	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
 	apiExportInformer apisv1alpha1informers.APIExportClusterInformer,
 ) (*controller, error) {
 	logger := logging.WithReconciler(klog.Background(), ControllerName)
 
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue:                queue,
+		recorder:             events.NewRecorder(kubeClusterClient, ControllerName),
 		kcpClusterClient:     kcpClusterClient,
 		dynamicClusterClient: dynamicClusterClient,
 		ddsif:                dynamicDiscoverySharedInformerFactory,
@@ -98,7 +103,8 @@ func NewController(
 // owner. It labels resources in the intersection of `APIBinding.status.permissionClaims` and
 // `APIBinding.spec.acceptedPermissionClaims`.
 type controller struct {
-	queue workqueue.RateLimitingInterface
+	queue    workqueue.RateLimitingInterface
+	recorder *events.Recorder
 
 	kcpClusterClient     kcpclientset.ClusterInterface
 	apiBindingsIndexer   cache.Indexer
@@ -131,6 +137,8 @@ func (c *controller) Start(ctx context.Context, numThreads int) {
 	logger.Info("starting controller")
 	defer logger.Info("shutting down controller")
 
+	c.recorder.Start(ctx)
+
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
 	}
@@ -223,6 +231,8 @@ func (c *controller) process(ctx context.Context, key string) error {
 		logger.V(2).Info("patching APIBinding", "patch", string(patchBytes))
 		if _, err := c.kcpClusterClient.Cluster(clusterName.Path()).ApisV1alpha1().APIBindings().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
 			errs = append(errs, err)
+		} else {
+			c.recorder.ConditionTransitions(obj, old.Status.Conditions, obj.Status.Conditions)
 		}
 	}
 
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemalint
+
+import (
+	"fmt"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// Rule identifies a best practice an APIResourceSchema is linted against.
+type Rule string
+
+const (
+	// MissingStatusSubresource means that a version has a status, but no status subresource,
+	// so that clients can update the status together with the spec.
+	MissingStatusSubresource Rule = "MissingStatusSubresource"
+
+	// FloatField means that a field is of type number, which does not round-trip reliably
+	// across languages. Integers or quantity strings should be used instead.
+	FloatField Rule = "FloatField"
+
+	// UnboundedMap means that a map has no maxProperties.
+	UnboundedMap Rule = "UnboundedMap"
+
+	// UnboundedCELCost means that a CEL validation rule applies to a collection or string
+	// without maxItems, maxProperties or maxLength, so that its estimated cost is likely to
+	// exceed the limits and the schema to be rejected or the rule to time out.
+	UnboundedCELCost Rule = "UnboundedCELCost"
+)
+
+// Finding is a violation of a Rule in an APIResourceSchema.
+type Finding struct {
+	Schema  string
+	Version string
+	Path    string
+	Rule    Rule
+}
+
+func (f Finding) String() string {
+	if f.Path == "" {
+		return fmt.Sprintf("%s %s: %s", f.Schema, f.Version, f.Rule)
+	}
+	return fmt.Sprintf("%s %s %s: %s", f.Schema, f.Version, f.Path, f.Rule)
+}
+
+// Lint returns the findings of an APIResourceSchema, in a stable order.
+func Lint(schema *apisv1alpha1.APIResourceSchema) ([]Finding, error) {
+	var findings []Finding
+	for i := range schema.Spec.Versions {
+		version := &schema.Spec.Versions[i]
+		props, err := version.GetSchema()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode schema of version %q of APIResourceSchema %s: %w", version.Name, schema.Name, err)
+		}
+		if props == nil {
+			continue
+		}
+
+		l := &linter{schema: schema.Name, version: version.Name}
+		if _, found := props.Properties["status"]; found && version.Subresources.Status == nil {
+			l.report("", MissingStatusSubresource)
+		}
+		for _, name := range sortedKeys(props.Properties) {
+			if name == "metadata" || name == "apiVersion" || name == "kind" {
+				continue
+			}
+			prop := props.Properties[name]
+			l.lint("."+name, &prop)
+		}
+		findings = append(findings, l.findings...)
+	}
+	return findings, nil
+}
+
+type linter struct {
+	schema   string
+	version  string
+	findings []Finding
+}
+
+func (l *linter) report(path string, rule Rule) {
+	l.findings = append(l.findings, Finding{Schema: l.schema, Version: l.version, Path: path, Rule: rule})
+}
+
+func (l *linter) lint(path string, props *apiextensionsv1.JSONSchemaProps) {
+	if props.Type == "number" {
+		l.report(path, FloatField)
+	}
+	if isMap(props) && props.MaxProperties == nil {
+		l.report(path, UnboundedMap)
+	}
+	if len(props.XValidations) > 0 && unbounded(props) {
+		l.report(path, UnboundedCELCost)
+	}
+
+	for _, name := range sortedKeys(props.Properties) {
+		prop := props.Properties[name]
+		l.lint(path+"."+name, &prop)
+	}
+	if props.Items != nil && props.Items.Schema != nil {
+		l.lint(path+"[*]", props.Items.Schema)
+	}
+	if props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil {
+		l.lint(path+"[*]", props.AdditionalProperties.Schema)
+	}
+}
+
+func isMap(props *apiextensionsv1.JSONSchemaProps) bool {
+	return props.AdditionalProperties != nil && (props.AdditionalProperties.Allows || props.AdditionalProperties.Schema != nil)
+}
+
+// unbounded returns whether the schema, or one of its descendants a CEL rule can iterate over,
+// has no size limit.
+func unbounded(props *apiextensionsv1.JSONSchemaProps) bool {
+	switch {
+	case props.Type == "array" && props.MaxItems == nil:
+		return true
+	case isMap(props) && props.MaxProperties == nil:
+		return true
+	case props.Type == "string" && props.MaxLength == nil && props.Enum == nil && props.Format == "":
+		return true
+	}
+
+	for name := range props.Properties {
+		prop := props.Properties[name]
+		if unbounded(&prop) {
+			return true
+		}
+	}
+	if props.Items != nil && props.Items.Schema != nil && unbounded(props.Items.Schema) {
+		return true
+	}
+	if props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil && unbounded(props.AdditionalProperties.Schema) {
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]apiextensionsv1.JSONSchemaProps) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
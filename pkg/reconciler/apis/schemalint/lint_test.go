@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemalint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestLint(t *testing.T) {
+	tests := map[string]struct {
+		schema       string
+		subresources apiextensionsv1.CustomResourceSubresources
+		want         []Finding
+	}{
+		"no findings": {
+			schema: `{"type":"object","properties":{
+				"spec":{"type":"object","properties":{
+					"replicas":{"type":"integer"},
+					"labels":{"type":"object","maxProperties":16,"additionalProperties":{"type":"string","maxLength":63}}
+				}},
+				"status":{"type":"object"}
+			}}`,
+			subresources: apiextensionsv1.CustomResourceSubresources{Status: &apiextensionsv1.CustomResourceSubresourceStatus{}},
+		},
+		"missing status subresource": {
+			schema: `{"type":"object","properties":{"status":{"type":"object"}}}`,
+			want: []Finding{
+				{Schema: "today.widgets.example.io", Version: "v1", Rule: MissingStatusSubresource},
+			},
+		},
+		"float and unbounded map": {
+			schema: `{"type":"object","properties":{"spec":{"type":"object","properties":{
+				"ratio":{"type":"number"},
+				"labels":{"type":"object","additionalProperties":{"type":"string"}}
+			}}}}`,
+			want: []Finding{
+				{Schema: "today.widgets.example.io", Version: "v1", Path: ".spec.labels", Rule: UnboundedMap},
+				{Schema: "today.widgets.example.io", Version: "v1", Path: ".spec.ratio", Rule: FloatField},
+			},
+		},
+		"unbounded CEL cost": {
+			schema: `{"type":"object","properties":{"spec":{"type":"object",
+				"x-kubernetes-validations":[{"rule":"self.names.all(n, n.size() < 10)"}],
+				"properties":{"names":{"type":"array","maxItems":10,"items":{"type":"string"}}}
+			}}}`,
+			want: []Finding{
+				{Schema: "today.widgets.example.io", Version: "v1", Path: ".spec", Rule: UnboundedCELCost},
+			},
+		},
+		"bounded CEL cost": {
+			schema: `{"type":"object","properties":{"spec":{"type":"object",
+				"x-kubernetes-validations":[{"rule":"self.names.all(n, n.size() < 10)"}],
+				"properties":{"names":{"type":"array","maxItems":10,"items":{"type":"string","maxLength":10}}}
+			}}}`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema := &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{Name: "today.widgets.example.io"},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Versions: []apisv1alpha1.APIResourceVersion{{
+						Name:         "v1",
+						Served:       true,
+						Storage:      true,
+						Schema:       runtime.RawExtension{Raw: []byte(tc.schema)},
+						Subresources: tc.subresources,
+					}},
+				},
+			}
+			got, err := Lint(schema)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFindingsMessage(t *testing.T) {
+	var findings []Finding
+	for i := 0; i < maxReportedFindings+2; i++ {
+		findings = append(findings, Finding{Schema: "today.widgets.example.io", Version: "v1", Path: ".spec.ratio", Rule: FloatField})
+	}
+	message := findingsMessage(findings)
+	require.Contains(t, message, "12 finding(s): today.widgets.example.io v1 .spec.ratio: FloatField; ")
+	require.Contains(t, message, "; and 2 more")
+}
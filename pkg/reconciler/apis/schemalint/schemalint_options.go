@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemalint
+
+import (
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.BoolVar(&o.Lint, "apiexport-schema-linter", o.Lint, "If true, the APIResourceSchemas referenced by the APIExports are linted against best practices, and the findings are reported with the SchemasLinted condition and events on the APIExports.")
+	return o
+}
+
+type Options struct {
+	Lint bool
+}
+
+// Enabled returns whether the schema linter controller is enabled.
+func (o *Options) Enabled() bool {
+	return o.Lint
+}
+
+func (o *Options) Validate() error {
+	return nil
+}
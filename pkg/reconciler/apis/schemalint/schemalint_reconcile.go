@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemalint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// maxReportedFindings is the number of findings listed in the message of the condition.
+const maxReportedFindings = 10
+
+func (c *controller) reconcile(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(apiExport)
+
+	var findings []Finding
+	for _, name := range apiExport.Spec.LatestResourceSchemas {
+		schema, err := c.getAPIResourceSchema(clusterName, name)
+		if errors.IsNotFound(err) {
+			// reported by the consumers of the export, and linted once created
+			logger.V(4).Info("APIResourceSchema not found", "schema", name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		schemaFindings, err := Lint(schema)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, schemaFindings...)
+	}
+
+	if len(findings) == 0 {
+		conditions.MarkTrue(apiExport, apisv1alpha1.APIExportSchemasLinted)
+		return nil
+	}
+
+	conditions.MarkFalse(
+		apiExport,
+		apisv1alpha1.APIExportSchemasLinted,
+		apisv1alpha1.SchemaLintFindingsReason,
+		conditionsv1alpha1.ConditionSeverityWarning,
+		"%s",
+		findingsMessage(findings),
+	)
+	return nil
+}
+
+func findingsMessage(findings []Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d finding(s): ", len(findings))
+	for i, finding := range findings {
+		if i == maxReportedFindings {
+			fmt.Fprintf(&b, "; and %d more", len(findings)-maxReportedFindings)
+			break
+		}
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(finding.String())
+	}
+	return b.String()
+}
@@ -24,6 +24,7 @@ import (
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -40,11 +41,18 @@ import (
 	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
 	// ControllerName hold this controller name.
 	ControllerName = "kcp-replication-controller"
+
+	// batchDelay is how long the changes of an object are collected before it is replicated, so that
+	// bursts of changes, e.g., when APIExports and Shards churn during upgrades, are written to the cache
+	// server once.
+	batchDelay = 500 * time.Millisecond
 )
 
 // NewController returns a new replication controller.
@@ -61,7 +69,7 @@ func NewController(
 ) (*controller, error) {
 	c := &controller{
 		shardName:                      shardName,
-		queue:                          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		queue:                          workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName),
 		dynamicCacheClient:             dynamicCacheClient,
 		dynamicLocalClient:             dynamicLocalClient,
 		localAPIExportLister:           localKcpInformers.Apis().V1alpha1().APIExports().Lister(),
@@ -103,26 +111,28 @@ func NewController(
 	return c, nil
 }
 
-func (c *controller) enqueueAPIExport(obj interface{}) {
-	c.enqueueObject(obj, apisv1alpha1.SchemeGroupVersion.WithResource("apiexports"))
+func (c *controller) enqueueAPIExport(obj interface{}, delay time.Duration) {
+	c.enqueueObject(obj, apisv1alpha1.SchemeGroupVersion.WithResource("apiexports"), delay)
 }
 
-func (c *controller) enqueueAPIResourceSchema(obj interface{}) {
-	c.enqueueObject(obj, apisv1alpha1.SchemeGroupVersion.WithResource("apiresourceschemas"))
+func (c *controller) enqueueAPIResourceSchema(obj interface{}, delay time.Duration) {
+	c.enqueueObject(obj, apisv1alpha1.SchemeGroupVersion.WithResource("apiresourceschemas"), delay)
 }
 
-func (c *controller) enqueueShard(obj interface{}) {
-	c.enqueueObject(obj, corev1alpha1.SchemeGroupVersion.WithResource("shards"))
+func (c *controller) enqueueShard(obj interface{}, delay time.Duration) {
+	c.enqueueObject(obj, corev1alpha1.SchemeGroupVersion.WithResource("shards"), delay)
 }
 
-func (c *controller) enqueueObject(obj interface{}, gvr schema.GroupVersionResource) {
+// enqueueObject enqueues the object after the given delay. Until the key is processed, later enqueues
+// of the same object do not postpone it, hence the changes during the delay are replicated at once.
+func (c *controller) enqueueObject(obj interface{}, gvr schema.GroupVersionResource, delay time.Duration) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 	gvrKey := fmt.Sprintf("%v::%v", gvr.String(), key)
-	c.queue.Add(gvrKey)
+	c.queue.AddAfter(gvrKey, delay)
 }
 
 // Start starts the controller, which stops when ctx.Done() is closed.
@@ -155,7 +165,9 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 
 	logger := logging.WithQueueKey(klog.FromContext(ctx), grKey.(string))
 	ctx = klog.NewContext(ctx, logger)
+	start := time.Now()
 	err := c.reconcile(ctx, grKey.(string))
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
 	if err == nil {
 		c.queue.Forget(grKey)
 		return true
@@ -179,12 +191,31 @@ func (c *controller) shardInformerEventHandler() cache.ResourceEventHandler {
 	return objectInformerEventHandler(c.enqueueShard)
 }
 
-func objectInformerEventHandler(enqueueObject func(obj interface{})) cache.ResourceEventHandler {
+// objectInformerEventHandler enqueues the added and the deleted objects immediately, and the updated
+// ones after batchDelay. Resyncs are skipped, as the objects did not change since they were replicated.
+func objectInformerEventHandler(enqueueObject func(obj interface{}, delay time.Duration)) cache.ResourceEventHandler {
 	return cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { enqueueObject(obj) },
-		UpdateFunc: func(_, obj interface{}) { enqueueObject(obj) },
-		DeleteFunc: func(obj interface{}) { enqueueObject(obj) },
+		AddFunc: func(obj interface{}) { enqueueObject(obj, 0) },
+		UpdateFunc: func(oldObj, obj interface{}) {
+			if isResync(oldObj, obj) {
+				return
+			}
+			enqueueObject(obj, batchDelay)
+		},
+		DeleteFunc: func(obj interface{}) { enqueueObject(obj, 0) },
+	}
+}
+
+func isResync(oldObj, obj interface{}) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return false
 	}
+	return oldMeta.GetResourceVersion() == newMeta.GetResourceVersion()
 }
 
 type controller struct {
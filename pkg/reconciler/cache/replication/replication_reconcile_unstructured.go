@@ -56,6 +56,8 @@ func (c *controller) reconcileUnstructuredObjects(ctx context.Context, cluster l
 		// TODO: in the future the original RV will have to be stored in an annotation (?)
 		// so that the clients that need to modify the original/local object can do it
 		localObject.SetResourceVersion("")
+		// the managed fields are not needed on the replicated objects, and often are the largest part of their metadata
+		localObject.SetManagedFields(nil)
 		annotations := localObject.GetAnnotations()
 		if annotations == nil {
 			annotations = map[string]string{}
@@ -95,7 +97,8 @@ func (c *controller) handleObjectDeletion(ctx context.Context, cluster logicalcl
 	return nil
 }
 
-// ensureMeta changes unstructuredCacheObject's metadata to match unstructuredLocalObject's metadata except the ResourceVersion and the shard annotation fields.
+// ensureMeta changes unstructuredCacheObject's metadata to match unstructuredLocalObject's metadata except the ResourceVersion, the ManagedFields and the shard annotation fields.
+// The managed fields are not replicated, to keep the payloads to the cache server small, and the ones of the cache object are only about the replication.
 func ensureMeta(cacheObject *unstructured.Unstructured, localObject *unstructured.Unstructured) (changed bool, err error) {
 	cacheObjMetaRaw, hasCacheObjMetaRaw, err := unstructured.NestedFieldNoCopy(cacheObject.Object, "metadata")
 	if err != nil {
@@ -131,6 +134,14 @@ func ensureMeta(cacheObject *unstructured.Unstructured, localObject *unstructure
 			}
 		}()
 	}
+	if cacheObjManagedFields, found := cacheObjMeta["managedFields"]; found {
+		unstructured.RemoveNestedField(cacheObjMeta, "managedFields")
+		defer func() {
+			if err == nil {
+				err = unstructured.SetNestedField(cacheObject.Object, cacheObjManagedFields, "metadata", "managedFields")
+			}
+		}()
+	}
 	if cacheObjAnnotationsRaw, found := cacheObjMeta["annotations"]; found {
 		cacheObjAnnotations, ok := cacheObjAnnotationsRaw.(map[string]interface{})
 		if !ok {
@@ -148,14 +159,17 @@ func ensureMeta(cacheObject *unstructured.Unstructured, localObject *unstructure
 	}
 
 	// before we can compare with the local object we need to
-	// store, remove and then bring back the ResourceVersion on the local object
-	if localObjRV, found := localObjMeta["resourceVersion"]; found {
-		unstructured.RemoveNestedField(localObjMeta, "resourceVersion")
-		defer func() {
-			if err == nil {
-				localObjMeta["resourceVersion"] = localObjRV
-			}
-		}()
+	// store, remove and then bring back the ResourceVersion and the ManagedFields on the local object
+	for _, field := range []string{"resourceVersion", "managedFields"} {
+		if value, found := localObjMeta[field]; found {
+			field := field
+			unstructured.RemoveNestedField(localObjMeta, field)
+			defer func() {
+				if err == nil {
+					localObjMeta[field] = value
+				}
+			}()
+		}
 	}
 
 	changed = !reflect.DeepEqual(cacheObjMeta, localObjMeta)
@@ -363,6 +363,38 @@ func TestEnsureUnstructuredMeta(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:            "no-op: managed fields are not replicated",
+			cacheObjectMeta: metav1.ObjectMeta{ResourceVersion: "1", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kcp-replication-controller"}}},
+			localObjectMeta: metav1.ObjectMeta{ResourceVersion: "2", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}},
+			validateCacheObjectMeta: func(t *testing.T, cacheObjectMeta, localObjectMeta metav1.ObjectMeta) {
+				t.Helper()
+
+				expectedCacheObjectMeta := metav1.ObjectMeta{ResourceVersion: "1", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kcp-replication-controller"}}}
+				if !reflect.DeepEqual(cacheObjectMeta, expectedCacheObjectMeta) {
+					t.Errorf("received metadata differs from the expected one :\n%s", cmp.Diff(cacheObjectMeta, expectedCacheObjectMeta))
+				}
+
+				expectedLocalObjectMeta := metav1.ObjectMeta{ResourceVersion: "2", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}}
+				if !reflect.DeepEqual(localObjectMeta, expectedLocalObjectMeta) {
+					t.Errorf("local object's metadata mustn't be changed, diff :\n%s", cmp.Diff(localObjectMeta, expectedLocalObjectMeta))
+				}
+			},
+		},
+		{
+			name:                    "managed fields are preserved on cached when local changes",
+			cacheObjectMeta:         metav1.ObjectMeta{ResourceVersion: "1", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kcp-replication-controller"}}},
+			localObjectMeta:         metav1.ObjectMeta{ResourceVersion: "2", Labels: map[string]string{"a": "b"}, ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}},
+			expectObjectMetaChanged: true,
+			validateCacheObjectMeta: func(t *testing.T, cacheObjectMeta, localObjectMeta metav1.ObjectMeta) {
+				t.Helper()
+
+				expectedCacheObjectMeta := metav1.ObjectMeta{ResourceVersion: "1", Labels: map[string]string{"a": "b"}, ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kcp-replication-controller"}}}
+				if !reflect.DeepEqual(cacheObjectMeta, expectedCacheObjectMeta) {
+					t.Errorf("received metadata differs from the expected one :\n%s", cmp.Diff(cacheObjectMeta, expectedCacheObjectMeta))
+				}
+			},
+		},
 		{
 			name:                    "an arbitrary field on meta",
 			cacheObjectMeta:         metav1.ObjectMeta{ResourceVersion: "1", Annotations: map[string]string{"kcp.io/shard": "amber"}},
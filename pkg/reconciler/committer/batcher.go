@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package committer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// StatusBatcher coalesces the status updates of an object over an interval, so that an object
+// reconciled repeatedly, e.g., on a busy shard, has its status patched at most once per interval,
+// with its latest status. Other updates are committed immediately, and supersede any pending
+// status update of the object.
+type StatusBatcher[Sp any, St any] struct {
+	commit   func(context.Context, *Resource[Sp, St], *Resource[Sp, St]) error
+	interval time.Duration
+	requeue  func(key string)
+	clock    clock.WithDelayedExecution
+
+	lock    sync.Mutex
+	pending map[string]*pendingStatus[Sp, St]
+}
+
+type pendingStatus[Sp any, St any] struct {
+	ctx      context.Context
+	old, obj *Resource[Sp, St]
+}
+
+// NewStatusBatcher returns a StatusBatcher committing with commit, e.g., a function returned by
+// NewCommitter or NewStatusApplier. The cluster-aware key of an object is passed to requeue when the commit of its
+// status fails, so that the controller can reconcile it again.
+func NewStatusBatcher[Sp any, St any](commit func(context.Context, *Resource[Sp, St], *Resource[Sp, St]) error, interval time.Duration, requeue func(key string)) *StatusBatcher[Sp, St] {
+	return &StatusBatcher[Sp, St]{
+		commit:   commit,
+		interval: interval,
+		requeue:  requeue,
+		clock:    clock.RealClock{},
+		pending:  map[string]*pendingStatus[Sp, St]{},
+	}
+}
+
+// Commit has the signature of the functions returned by NewCommitter. Status-only updates are
+// deferred to the end of the interval started by the first pending status update of the object.
+func (b *StatusBatcher[Sp, St]) Commit(ctx context.Context, old, obj *Resource[Sp, St]) error {
+	key := kcpcache.ToClusterAwareKey(logicalcluster.From(old).String(), old.Namespace, old.Name)
+
+	statusOnly := equality.Semantic.DeepEqual(old.ObjectMeta, obj.ObjectMeta) && equality.Semantic.DeepEqual(old.Spec, obj.Spec)
+	if !statusOnly || b.interval <= 0 {
+		b.lock.Lock()
+		delete(b.pending, key)
+		b.lock.Unlock()
+		return b.commit(ctx, old, obj)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		// the latest observed object is up-to-date, nothing left to commit
+		delete(b.pending, key)
+		return nil
+	}
+	if p, ok := b.pending[key]; ok {
+		// the patch is computed against the latest observed object, which carries the latest resource version
+		p.ctx, p.old, p.obj = ctx, old, obj
+		return nil
+	}
+	b.pending[key] = &pendingStatus[Sp, St]{ctx: ctx, old: old, obj: obj}
+	b.clock.AfterFunc(b.interval, func() {
+		b.flush(key)
+	})
+	return nil
+}
+
+func (b *StatusBatcher[Sp, St]) flush(key string) {
+	b.lock.Lock()
+	p, ok := b.pending[key]
+	delete(b.pending, key)
+	b.lock.Unlock()
+
+	if !ok {
+		return // superseded by an immediate commit
+	}
+	if err := b.commit(p.ctx, p.old, p.obj); err != nil {
+		klog.FromContext(p.ctx).V(2).Info("failed to commit batched status, requeueing", "key", key, "err", err)
+		utilruntime.HandleError(err)
+		b.requeue(key)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package committer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type testSpec struct {
+	Replicas int `json:"replicas"`
+}
+
+type testStatus struct {
+	Ready int `json:"ready"`
+}
+
+func testResource(rv string, replicas, ready int) *Resource[*testSpec, *testStatus] {
+	return &Resource[*testSpec, *testStatus]{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "obj",
+			ResourceVersion: rv,
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: "root"},
+		},
+		Spec:   &testSpec{Replicas: replicas},
+		Status: &testStatus{Ready: ready},
+	}
+}
+
+func TestStatusBatcher(t *testing.T) {
+	type commit struct {
+		rv              string
+		replicas, ready int
+	}
+	var commits []commit
+	var commitErr error
+	var requeued []string
+
+	b := NewStatusBatcher(func(_ context.Context, old, obj *Resource[*testSpec, *testStatus]) error {
+		commits = append(commits, commit{rv: old.ResourceVersion, replicas: obj.Spec.Replicas, ready: obj.Status.Ready})
+		return commitErr
+	}, time.Second, func(key string) { requeued = append(requeued, key) })
+	clock := clocktesting.NewFakeClock(time.Now())
+	b.clock = clock
+	ctx := context.Background()
+
+	t.Log("Status updates within the interval are coalesced")
+	require.NoError(t, b.Commit(ctx, testResource("1", 1, 0), testResource("1", 1, 1)))
+	require.NoError(t, b.Commit(ctx, testResource("2", 1, 0), testResource("2", 1, 2)))
+	require.Empty(t, commits)
+	clock.Step(time.Second)
+	require.Equal(t, []commit{{rv: "2", replicas: 1, ready: 2}}, commits)
+
+	t.Log("Spec updates are committed immediately, and supersede the pending status update")
+	commits = nil
+	require.NoError(t, b.Commit(ctx, testResource("3", 1, 2), testResource("3", 1, 3)))
+	require.NoError(t, b.Commit(ctx, testResource("3", 1, 2), testResource("3", 2, 2)))
+	require.Equal(t, []commit{{rv: "3", replicas: 2, ready: 2}}, commits)
+	clock.Step(time.Second)
+	require.Len(t, commits, 1)
+
+	t.Log("Pending status updates are dropped once the observed status is up-to-date")
+	commits = nil
+	require.NoError(t, b.Commit(ctx, testResource("4", 2, 2), testResource("4", 2, 3)))
+	require.NoError(t, b.Commit(ctx, testResource("5", 2, 3), testResource("5", 2, 3)))
+	clock.Step(time.Second)
+	require.Empty(t, commits)
+
+	t.Log("Objects are requeued when their batched status fails to be committed")
+	commits = nil
+	commitErr = errors.New("conflict")
+	require.NoError(t, b.Commit(ctx, testResource("6", 2, 2), testResource("6", 2, 3)))
+	clock.Step(time.Second)
+	require.Len(t, commits, 1)
+	require.Equal(t, []string{"root|obj"}, requeued)
+}
@@ -165,3 +165,37 @@ func generatePatchAndSubResources[Sp any, St any](old, obj *Resource[Sp, St]) ([
 
 	return patchBytes, subresources, nil
 }
+
+// NewStatusApplier returns a function that commits status changes of instances of R with a
+// server-side apply of the apply configuration returned by applyConfiguration, using a
+// cluster-aware patcher. The apply configuration must only set the status fields owned by
+// fieldManager, so that the status fields written by other actors are left untouched, and
+// no longer need to be part of the request. Meta and spec changes are not supported.
+func NewStatusApplier[R runtime.Object, P Patcher[R], Sp any, St any](patcher ClusterPatcher[R, P], fieldManager string, applyConfiguration func(*Resource[Sp, St]) interface{}) func(context.Context, *Resource[Sp, St], *Resource[Sp, St]) error {
+	focusType := fmt.Sprintf("%T", new(R))
+	force := true
+	return func(ctx context.Context, old, obj *Resource[Sp, St]) error {
+		logger := klog.FromContext(ctx)
+		clusterName := logicalcluster.From(old)
+
+		if !equality.Semantic.DeepEqual(old.ObjectMeta, obj.ObjectMeta) || !equality.Semantic.DeepEqual(old.Spec, obj.Spec) {
+			return fmt.Errorf("programmer error: only status changes can be applied for %s %s|%s", focusType, clusterName, old.Name)
+		}
+		if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+			return nil
+		}
+
+		applyBytes, err := json.Marshal(applyConfiguration(obj))
+		if err != nil {
+			return fmt.Errorf("failed to create apply configuration for %s %s|%s: %w", focusType, clusterName, obj.Name, err)
+		}
+
+		logger.V(2).Info(fmt.Sprintf("applying %s status", focusType), "applyConfiguration", string(applyBytes))
+		_, err = patcher.Cluster(clusterName.Path()).Patch(ctx, obj.Name, types.ApplyPatchType, applyBytes, metav1.PatchOptions{FieldManager: fieldManager, Force: &force}, "status")
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s|%s: %w", focusType, clusterName, old.Name, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package committer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type patch struct {
+	cluster      logicalcluster.Path
+	name         string
+	pt           types.PatchType
+	data         string
+	opts         metav1.PatchOptions
+	subresources []string
+}
+
+type fakeClusterPatcher struct {
+	patches []patch
+}
+
+func (f *fakeClusterPatcher) Cluster(cluster logicalcluster.Path) *fakePatcher {
+	return &fakePatcher{cluster: cluster, parent: f}
+}
+
+type fakePatcher struct {
+	cluster logicalcluster.Path
+	parent  *fakeClusterPatcher
+}
+
+func (f *fakePatcher) Patch(_ context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*metav1.PartialObjectMetadata, error) {
+	f.parent.patches = append(f.parent.patches, patch{cluster: f.cluster, name: name, pt: pt, data: string(data), opts: opts, subresources: subresources})
+	return &metav1.PartialObjectMetadata{}, nil
+}
+
+func TestStatusApplier(t *testing.T) {
+	patcher := &fakeClusterPatcher{}
+	commit := NewStatusApplier[*metav1.PartialObjectMetadata, *fakePatcher, *testSpec, *testStatus](patcher, "test-controller", func(r *Resource[*testSpec, *testStatus]) interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"name": r.Name},
+			"status":   map[string]interface{}{"ready": r.Status.Ready},
+		}
+	})
+	ctx := context.Background()
+
+	t.Log("Unchanged status is not applied")
+	require.NoError(t, commit(ctx, testResource("1", 1, 1), testResource("1", 1, 1)))
+	require.Empty(t, patcher.patches)
+
+	t.Log("Status changes are applied to the status subresource")
+	require.NoError(t, commit(ctx, testResource("1", 1, 1), testResource("1", 1, 2)))
+	force := true
+	require.Equal(t, []patch{{
+		cluster:      logicalcluster.NewPath("root"),
+		name:         "obj",
+		pt:           types.ApplyPatchType,
+		data:         `{"metadata":{"name":"obj"},"status":{"ready":2}}`,
+		opts:         metav1.PatchOptions{FieldManager: "test-controller", Force: &force},
+		subresources: []string{"status"},
+	}}, patcher.patches)
+
+	t.Log("Spec changes are rejected")
+	require.Error(t, commit(ctx, testResource("1", 1, 1), testResource("1", 2, 1)))
+	require.Len(t, patcher.patches, 1)
+}
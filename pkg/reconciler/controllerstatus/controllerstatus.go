@@ -0,0 +1,224 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controllerstatus supplies the introspection of the controllers running on a shard.
+// The controllers are discovered from the metrics of their named workqueues, so that every
+// controller is covered without having to register with the monitor.
+package controllerstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+)
+
+const (
+	// DefaultStallTimeout is the duration after which a controller with queued keys that has not
+	// completed any is considered stalled.
+	DefaultStallTimeout = 10 * time.Minute
+
+	// the workqueue metrics, see k8s.io/component-base/metrics/prometheus/workqueue.
+	depthMetricName          = "workqueue_depth"
+	workDurationMetricName   = "workqueue_work_duration_seconds"
+	longestRunningMetricName = "workqueue_longest_running_processor_seconds"
+	queueNameLabel           = "name"
+)
+
+// Status is the status of a controller running on the shard.
+type Status struct {
+	// Name is the name of the controller, i.e. of its queue.
+	Name string `json:"name"`
+	// Leader is whether the controller is leading. kcp controllers split their work across the
+	// shards by logical cluster and do not use leader election, so every running controller leads.
+	Leader bool `json:"leader"`
+	// QueueDepth is the number of keys waiting to be processed.
+	QueueDepth int64 `json:"queueDepth"`
+	// LongestRunningProcessorSeconds is for how long the longest running worker has been processing its key.
+	LongestRunningProcessorSeconds float64 `json:"longestRunningProcessorSeconds"`
+	// LastProgress is the last time the controller was seen completing a key, or having nothing to do.
+	LastProgress time.Time `json:"lastProgress"`
+	// LastSuccessfulSync is the time of the last successful reconciliation, for the controllers
+	// recording their reconciliations with the reconciler metrics.
+	LastSuccessfulSync *time.Time `json:"lastSuccessfulSync,omitempty"`
+	// Stalled is whether the controller has not made any progress for longer than the stall timeout.
+	Stalled bool `json:"stalled"`
+}
+
+type queueState struct {
+	depth          int64
+	longestRunning float64
+	completed      uint64
+	lastProgress   time.Time
+}
+
+// Monitor samples the workqueue metrics of the controllers to track their progress. It serves
+// the controller statuses as JSON, and is a readyz check failing with the names of the stalled
+// controllers.
+type Monitor struct {
+	gatherer     compbasemetrics.Gatherer
+	clock        clock.PassiveClock
+	stallTimeout time.Duration
+
+	lock   sync.RWMutex
+	queues map[string]*queueState
+}
+
+// NewMonitor returns a monitor of the controllers exposing their workqueue metrics to the gatherer.
+func NewMonitor(gatherer compbasemetrics.Gatherer, stallTimeout time.Duration) *Monitor {
+	return &Monitor{
+		gatherer:     gatherer,
+		clock:        clock.RealClock{},
+		stallTimeout: stallTimeout,
+		queues:       map[string]*queueState{},
+	}
+}
+
+// Start samples the controllers at the interval until the context is done.
+func (m *Monitor) Start(ctx context.Context, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.sample(); err != nil {
+			klog.FromContext(ctx).Error(err, "failed to sample the controller statuses")
+		}
+	}, interval)
+}
+
+func (m *Monitor) sample() error {
+	families, err := m.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	depths := map[string]int64{}
+	completed := map[string]uint64{}
+	longestRunning := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == queueNameLabel {
+					name = label.GetValue()
+				}
+			}
+			if name == "" {
+				continue
+			}
+			switch family.GetName() {
+			case depthMetricName:
+				depths[name] = int64(metric.GetGauge().GetValue())
+			case workDurationMetricName:
+				completed[name] = metric.GetHistogram().GetSampleCount()
+			case longestRunningMetricName:
+				longestRunning[name] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	now := m.clock.Now()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for name, depth := range depths {
+		state, ok := m.queues[name]
+		if !ok {
+			state = &queueState{lastProgress: now}
+			m.queues[name] = state
+		}
+		if depth == 0 || completed[name] != state.completed {
+			state.lastProgress = now
+		}
+		state.depth = depth
+		state.completed = completed[name]
+		state.longestRunning = longestRunning[name]
+	}
+
+	return nil
+}
+
+func (m *Monitor) stalled(state *queueState, now time.Time) bool {
+	if state.longestRunning > m.stallTimeout.Seconds() {
+		return true
+	}
+	return state.depth > 0 && now.Sub(state.lastProgress) > m.stallTimeout
+}
+
+// Statuses returns the statuses of the controllers, sorted by name.
+func (m *Monitor) Statuses() []Status {
+	now := m.clock.Now()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	statuses := make([]Status, 0, len(m.queues))
+	for name, state := range m.queues {
+		status := Status{
+			Name:                           name,
+			Leader:                         true,
+			QueueDepth:                     state.depth,
+			LongestRunningProcessorSeconds: state.longestRunning,
+			LastProgress:                   state.lastProgress,
+			Stalled:                        m.stalled(state, now),
+		}
+		if t, ok := reconcilermetrics.LastSuccessfulReconcile(name); ok {
+			status.LastSuccessfulSync = &t
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Name implements healthz.HealthChecker.
+func (m *Monitor) Name() string {
+	return "controllers"
+}
+
+// Check implements healthz.HealthChecker, failing with the names of the stalled controllers.
+func (m *Monitor) Check(_ *http.Request) error {
+	var stalled []string
+	for _, status := range m.Statuses() {
+		if status.Stalled {
+			stalled = append(stalled, status.Name)
+		}
+	}
+	if len(stalled) > 0 {
+		return fmt.Errorf("controllers stalled for more than %s: %s", m.stallTimeout, strings.Join(stalled, ", "))
+	}
+	return nil
+}
+
+// ServeHTTP serves the controller statuses as JSON.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Statuses()); err != nil {
+		utilruntime.HandleError(err)
+	}
+}
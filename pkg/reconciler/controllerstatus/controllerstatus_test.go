@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerstatus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestMonitor(t *testing.T) {
+	registry := compbasemetrics.NewKubeRegistry()
+	depth := compbasemetrics.NewGaugeVec(&compbasemetrics.GaugeOpts{Name: depthMetricName}, []string{queueNameLabel})
+	work := compbasemetrics.NewHistogramVec(&compbasemetrics.HistogramOpts{Name: workDurationMetricName}, []string{queueNameLabel})
+	longestRunning := compbasemetrics.NewGaugeVec(&compbasemetrics.GaugeOpts{Name: longestRunningMetricName}, []string{queueNameLabel})
+	registry.MustRegister(depth, work, longestRunning)
+
+	clock := clocktesting.NewFakeClock(time.Now())
+	m := NewMonitor(registry, time.Minute)
+	m.clock = clock
+
+	t.Log("Controllers are discovered from their queues")
+	depth.WithLabelValues("idle").Set(0)
+	depth.WithLabelValues("busy").Set(3)
+	depth.WithLabelValues("stuck").Set(0)
+	longestRunning.WithLabelValues("stuck").Set(0)
+	require.NoError(t, m.sample())
+	statuses := m.Statuses()
+	require.Len(t, statuses, 3)
+	require.Equal(t, "busy", statuses[0].Name)
+	require.Equal(t, int64(3), statuses[0].QueueDepth)
+	require.True(t, statuses[0].Leader)
+	require.NoError(t, m.Check(nil))
+
+	t.Log("A controller completing keys makes progress")
+	clock.Step(50 * time.Second)
+	work.WithLabelValues("busy").Observe(1)
+	require.NoError(t, m.sample())
+	clock.Step(50 * time.Second)
+	require.NoError(t, m.sample())
+	require.NoError(t, m.Check(nil))
+
+	t.Log("A controller not completing its queued keys stalls")
+	clock.Step(time.Minute)
+	require.NoError(t, m.sample())
+	require.EqualError(t, m.Check(nil), "controllers stalled for more than 1m0s: busy")
+
+	t.Log("A controller stuck processing a key stalls")
+	work.WithLabelValues("busy").Observe(1)
+	longestRunning.WithLabelValues("stuck").Set(120)
+	require.NoError(t, m.sample())
+	require.EqualError(t, m.Check(nil), "controllers stalled for more than 1m0s: stuck")
+}
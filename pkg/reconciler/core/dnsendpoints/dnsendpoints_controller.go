@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsendpoints
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-dnsendpoints"
+
+	// TargetsAnnotationKey is the annotation of the Shards holding the comma-separated targets of
+	// the DNS records of their hostnames, either IP addresses or a single hostname, e.g. of the
+	// load balancer in front of the shard. Shards without it are not published.
+	TargetsAnnotationKey = "experimental.dns.kcp.io/targets"
+
+	// dnsEndpointName is the name of the DNSEndpoint holding the records of all the shards, so
+	// that the shards sharing a hostname, e.g. the front-proxy one, are published in one record.
+	dnsEndpointName = "kcp-shards"
+
+	// queueKey is the single key of the queue, as all the shards are published together.
+	queueKey = "shards"
+)
+
+// dnsEndpointsGVR is the resource of the DNSEndpoints of the CRD source of external-dns.
+var dnsEndpointsGVR = schema.GroupVersionResource{Group: "externaldns.k8s.io", Version: "v1alpha1", Resource: "dnsendpoints"}
+
+// NewController returns a controller that publishes the hostnames of the shards, and hence of
+// their workspaces and virtual workspaces, as a DNSEndpoint for the CRD source of external-dns.
+func NewController(
+	dynamicClusterClient kcpdynamic.ClusterInterface,
+	shardInformer corev1alpha1informers.ShardClusterInformer,
+	options Options,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &Controller{
+		queue:                queue,
+		options:              options,
+		dynamicClusterClient: dynamicClusterClient,
+		shardLister:          shardInformer.Lister(),
+	}
+
+	shardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.enqueue() },
+		UpdateFunc: func(_, _ interface{}) { c.enqueue() },
+		DeleteFunc: func(interface{}) { c.enqueue() },
+	})
+
+	return c, nil
+}
+
+// Controller publishes the DNS records of the shard hostnames.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	options Options
+
+	dynamicClusterClient kcpdynamic.ClusterInterface
+
+	shardLister corev1alpha1listers.ShardClusterLister
+}
+
+func (c *Controller) enqueue() {
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), queueKey)
+	logger.V(2).Info("queueing Shards")
+	c.queue.Add(queueKey)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
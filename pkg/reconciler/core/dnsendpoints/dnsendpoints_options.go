@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsendpoints
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		Namespace: "default",
+		TTL:       300,
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.Workspace, "dns-endpoints-workspace", o.Workspace, "Path of the workspace the DNSEndpoint of the shard hostnames is published into, for the CRD source of external-dns. The DNSEndpoint CRD must be installed in the workspace. The DNS endpoints controller is disabled if empty.")
+	fs.StringVar(&o.Namespace, "dns-endpoints-namespace", o.Namespace, "Namespace of the DNS endpoints workspace the DNSEndpoint is published into.")
+	fs.Int64Var(&o.TTL, "dns-endpoints-ttl", o.TTL, "TTL in seconds of the published DNS records.")
+	return o
+}
+
+type Options struct {
+	Workspace string
+	Namespace string
+	TTL       int64
+}
+
+// Enabled returns whether a DNS endpoints workspace is configured.
+func (o *Options) Enabled() bool {
+	return o.Workspace != ""
+}
+
+func (o *Options) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	if !logicalcluster.NewPath(o.Workspace).IsValid() {
+		return fmt.Errorf("--dns-endpoints-workspace must be a valid workspace path (%s)", o.Workspace)
+	}
+	if o.Namespace == "" {
+		return fmt.Errorf("--dns-endpoints-namespace must be set with --dns-endpoints-workspace")
+	}
+	if o.TTL < 0 {
+		return fmt.Errorf("--dns-endpoints-ttl cannot be negative (%d)", o.TTL)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsendpoints
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+// endpoint is a DNS record of a DNSEndpoint, see sigs.k8s.io/external-dns/endpoint.
+type endpoint struct {
+	DNSName    string   `json:"dnsName"`
+	Targets    []string `json:"targets"`
+	RecordType string   `json:"recordType"`
+	RecordTTL  int64    `json:"recordTTL,omitempty"`
+}
+
+func (c *Controller) process(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+
+	shards, err := c.shardLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	endpoints, errs := endpointsFor(shards, c.options.TTL)
+	for _, err := range errs {
+		logger.Error(err, "skipping hostname")
+	}
+
+	desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&struct {
+		Endpoints []endpoint `json:"endpoints"`
+	}{Endpoints: endpoints})
+	if err != nil {
+		return err
+	}
+
+	client := c.dynamicClusterClient.Cluster(logicalcluster.NewPath(c.options.Workspace)).Resource(dnsEndpointsGVR).Namespace(c.options.Namespace)
+
+	existing, err := client.Get(ctx, dnsEndpointName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		dnsEndpoint := &unstructured.Unstructured{Object: map[string]interface{}{"spec": desired}}
+		dnsEndpoint.SetAPIVersion(dnsEndpointsGVR.GroupVersion().String())
+		dnsEndpoint.SetKind("DNSEndpoint")
+		dnsEndpoint.SetName(dnsEndpointName)
+		logger.V(2).Info("creating DNSEndpoint", "endpoints", len(endpoints))
+		_, err = client.Create(ctx, dnsEndpoint, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if spec, _, _ := unstructured.NestedMap(existing.Object, "spec"); equality.Semantic.DeepEqual(spec, desired) {
+		return nil
+	}
+
+	existing.Object["spec"] = desired
+	logger.V(2).Info("updating DNSEndpoint", "endpoints", len(endpoints))
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// endpointsFor returns the DNS records of the hostnames of the given shards, sorted by name. A
+// hostname shared by several shards, e.g. the front-proxy one, gets the targets of all of them.
+// Hostnames with targets that cannot form a single record are returned as errors.
+func endpointsFor(shards []*corev1alpha1.Shard, ttl int64) ([]endpoint, []error) {
+	targets := map[string]sets.String{}
+	for _, shard := range shards {
+		if !shard.DeletionTimestamp.IsZero() {
+			continue
+		}
+		shardTargets := sets.NewString()
+		for _, target := range strings.Split(shard.Annotations[TargetsAnnotationKey], ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				shardTargets.Insert(target)
+			}
+		}
+		if shardTargets.Len() == 0 {
+			continue
+		}
+		for _, u := range []string{shard.Spec.BaseURL, shard.Spec.ExternalURL, shard.Spec.VirtualWorkspaceURL} {
+			host := hostname(u)
+			if host == "" {
+				continue
+			}
+			if _, ok := targets[host]; !ok {
+				targets[host] = sets.NewString()
+			}
+			targets[host].Insert(shardTargets.UnsortedList()...)
+		}
+	}
+
+	hosts := make([]string, 0, len(targets))
+	for host := range targets {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var endpoints []endpoint
+	var errs []error
+	for _, host := range hosts {
+		recordType, err := recordTypeFor(targets[host])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hostname %s: %w", host, err))
+			continue
+		}
+		endpoints = append(endpoints, endpoint{
+			DNSName:    host,
+			Targets:    targets[host].List(),
+			RecordType: recordType,
+			RecordTTL:  ttl,
+		})
+	}
+	return endpoints, errs
+}
+
+// hostname returns the hostname of the URL, or an empty string if it is invalid or an IP address.
+func hostname(u string) string {
+	if u == "" {
+		return ""
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	host := parsed.Hostname()
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
+
+// recordTypeFor returns A for IPv4 targets, AAAA for IPv6 targets, and CNAME for a single hostname.
+func recordTypeFor(targets sets.String) (string, error) {
+	var ipv4, ipv6, names int
+	for target := range targets {
+		switch ip := net.ParseIP(target); {
+		case ip == nil:
+			names++
+		case ip.To4() != nil:
+			ipv4++
+		default:
+			ipv6++
+		}
+	}
+	switch {
+	case ipv4 == targets.Len():
+		return "A", nil
+	case ipv6 == targets.Len():
+		return "AAAA", nil
+	case names == 1 && targets.Len() == 1:
+		return "CNAME", nil
+	default:
+		return "", fmt.Errorf("targets %v must all be IPv4 addresses, all be IPv6 addresses, or be a single hostname", targets.List())
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsendpoints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func TestEndpointsFor(t *testing.T) {
+	shard := func(name, targets, baseURL, externalURL, virtualWorkspaceURL string) *corev1alpha1.Shard {
+		s := &corev1alpha1.Shard{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1alpha1.ShardSpec{
+				BaseURL:             baseURL,
+				ExternalURL:         externalURL,
+				VirtualWorkspaceURL: virtualWorkspaceURL,
+			},
+		}
+		if targets != "" {
+			s.Annotations = map[string]string{TargetsAnnotationKey: targets}
+		}
+		return s
+	}
+
+	tests := map[string]struct {
+		shards  []*corev1alpha1.Shard
+		want    []endpoint
+		wantErr bool
+	}{
+		"shards without targets are skipped": {
+			shards: []*corev1alpha1.Shard{shard("root", "", "https://root.kcp.io:6443", "", "")},
+		},
+		"hostnames of a shard": {
+			shards: []*corev1alpha1.Shard{shard("root", "10.0.0.1", "https://root.kcp.io:6443", "https://kcp.io", "https://vw.root.kcp.io")},
+			want: []endpoint{
+				{DNSName: "kcp.io", Targets: []string{"10.0.0.1"}, RecordType: "A", RecordTTL: 300},
+				{DNSName: "root.kcp.io", Targets: []string{"10.0.0.1"}, RecordType: "A", RecordTTL: 300},
+				{DNSName: "vw.root.kcp.io", Targets: []string{"10.0.0.1"}, RecordType: "A", RecordTTL: 300},
+			},
+		},
+		"shared hostname gets the targets of all shards": {
+			shards: []*corev1alpha1.Shard{
+				shard("root", "10.0.0.1", "https://root.kcp.io:6443", "https://kcp.io", ""),
+				shard("alpha", "10.0.0.2, 10.0.0.3", "https://alpha.kcp.io:6443", "https://kcp.io", ""),
+			},
+			want: []endpoint{
+				{DNSName: "alpha.kcp.io", Targets: []string{"10.0.0.2", "10.0.0.3"}, RecordType: "A", RecordTTL: 300},
+				{DNSName: "kcp.io", Targets: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, RecordType: "A", RecordTTL: 300},
+				{DNSName: "root.kcp.io", Targets: []string{"10.0.0.1"}, RecordType: "A", RecordTTL: 300},
+			},
+		},
+		"hostname target and IP hosts": {
+			shards: []*corev1alpha1.Shard{shard("root", "lb.cloud.example", "https://10.0.0.1:6443", "https://kcp.io", "")},
+			want: []endpoint{
+				{DNSName: "kcp.io", Targets: []string{"lb.cloud.example"}, RecordType: "CNAME", RecordTTL: 300},
+			},
+		},
+		"IPv6 targets": {
+			shards: []*corev1alpha1.Shard{shard("root", "fd00::1", "https://root.kcp.io:6443", "", "")},
+			want: []endpoint{
+				{DNSName: "root.kcp.io", Targets: []string{"fd00::1"}, RecordType: "AAAA", RecordTTL: 300},
+			},
+		},
+		"mixed targets are skipped": {
+			shards: []*corev1alpha1.Shard{
+				shard("root", "lb.cloud.example", "https://root.kcp.io:6443", "https://kcp.io", ""),
+				shard("alpha", "10.0.0.2", "https://alpha.kcp.io:6443", "https://kcp.io", ""),
+			},
+			want: []endpoint{
+				{DNSName: "alpha.kcp.io", Targets: []string{"10.0.0.2"}, RecordType: "A", RecordTTL: 300},
+				{DNSName: "root.kcp.io", Targets: []string{"lb.cloud.example"}, RecordType: "CNAME", RecordTTL: 300},
+			},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, errs := endpointsFor(tt.shards, 300)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantErr, len(errs) > 0)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	corev1listers "github.com/kcp-dev/client-go/listers/core/v1"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	tenancyv1beta1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1beta1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-notifications"
+)
+
+// bindingFailureConditions are the APIBinding conditions whose turning false is notified.
+var bindingFailureConditions = []conditionsv1alpha1.ConditionType{
+	apisv1alpha1.APIExportValid,
+	apisv1alpha1.InitialBindingCompleted,
+	apisv1alpha1.BindingUpToDate,
+	apisv1alpha1.PermissionClaimsValid,
+}
+
+// NewController returns a controller that posts the lifecycle events of the APIExports,
+// Workspaces and APIBindings of the shard to the notification sinks, i.e. the labelled Secrets
+// of the notification sinks workspace.
+func NewController(
+	apiExportInformer apisv1alpha1informers.APIExportClusterInformer,
+	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
+	workspaceInformer tenancyv1beta1informers.WorkspaceClusterInformer,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	secretInformer kcpcorev1informers.SecretClusterInformer,
+	options Options,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &Controller{
+		queue:                 queue,
+		options:               options,
+		httpClient:            &http.Client{Timeout: options.Timeout},
+		startTime:             time.Now(),
+		now:                   time.Now,
+		deliveries:            map[string]*delivery{},
+		logicalClusterLister:  logicalClusterInformer.Lister(),
+		logicalClusterIndexer: logicalClusterInformer.Informer().GetIndexer(),
+		secretLister:          secretInformer.Lister(),
+	}
+
+	indexers.AddIfNotPresentOrDie(logicalClusterInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalClusterPath: indexers.IndexByLogicalClusterPath,
+	})
+
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if export, ok := obj.(*apisv1alpha1.APIExport); ok && c.isNew(export) {
+				c.notify(APIExportPublished, export, apisv1alpha1.SchemeGroupVersion.String(), "APIExport", "", "")
+			}
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			old, ok := oldObj.(*apisv1alpha1.APIExport)
+			if !ok {
+				return
+			}
+			if export, ok := obj.(*apisv1alpha1.APIExport); ok && export.Generation != old.Generation {
+				c.notify(APIExportUpdated, export, apisv1alpha1.SchemeGroupVersion.String(), "APIExport", "", "")
+			}
+		},
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, obj interface{}) {
+			old, ok := oldObj.(*apisv1alpha1.APIBinding)
+			if !ok {
+				return
+			}
+			binding, ok := obj.(*apisv1alpha1.APIBinding)
+			if !ok {
+				return
+			}
+			for _, t := range bindingFailureConditions {
+				if conditions.IsFalse(binding, t) && !conditions.IsFalse(old, t) {
+					c.notify(APIBindingFailed, binding, apisv1alpha1.SchemeGroupVersion.String(), "APIBinding", conditions.GetReason(binding, t), conditions.GetMessage(binding, t))
+				}
+			}
+		},
+	})
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if workspace, ok := obj.(*tenancyv1beta1.Workspace); ok && c.isNew(workspace) {
+				c.notify(WorkspaceCreated, workspace, tenancyv1beta1.SchemeGroupVersion.String(), "Workspace", "", "")
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if workspace, ok := obj.(*tenancyv1beta1.Workspace); ok {
+				c.notify(WorkspaceDeleted, workspace, tenancyv1beta1.SchemeGroupVersion.String(), "Workspace", "", "")
+			}
+		},
+	})
+
+	return c, nil
+}
+
+// delivery is the notification of an event to a sink.
+type delivery struct {
+	event *Event
+	// sinkKey is the cluster aware key of the sink Secret, so that a rotated key or a changed
+	// URL is used by the retries, and a deleted sink is not retried.
+	sinkKey string
+}
+
+// Controller posts notifications to the notification sinks.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	options    Options
+	httpClient *http.Client
+	startTime  time.Time
+	now        func() time.Time
+
+	lock       sync.Mutex
+	deliveries map[string]*delivery
+
+	logicalClusterLister  corev1alpha1listers.LogicalClusterClusterLister
+	logicalClusterIndexer cache.Indexer
+	secretLister          corev1listers.SecretClusterLister
+}
+
+// isNew returns whether the object was created after the controller started, to not notify
+// the creation of the existing objects on restart.
+func (c *Controller) isNew(obj metav1.Object) bool {
+	return !obj.GetCreationTimestamp().Time.Before(c.startTime.Truncate(time.Second))
+}
+
+// notify queues the notification of an event to every sink receiving its type.
+func (c *Controller) notify(t EventType, obj logging.Object, apiVersion, kind, reason, message string) {
+	clusterName := logicalcluster.From(obj)
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), ControllerName), obj).WithValues("event", t)
+
+	sinksCluster, err := c.sinksCluster()
+	if err != nil {
+		logger.V(2).Info("not notifying event", "reason", err)
+		return
+	}
+	secrets, err := c.secretLister.Cluster(sinksCluster).Secrets(c.options.Namespace).List(labels.SelectorFromSet(labels.Set{SinkLabelKey: "true"}))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	event := &Event{
+		Type:    t,
+		Time:    metav1.NewTime(c.now()),
+		Cluster: clusterName.String(),
+		Path:    c.path(clusterName).String(),
+		Object: ObjectReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       obj.GetName(),
+		},
+		Reason:  reason,
+		Message: message,
+	}
+
+	for _, secret := range secrets {
+		s, err := sinkFromSecret(secret)
+		if err != nil {
+			logger.Error(err, "invalid notification sink")
+			continue
+		}
+		if !s.wants(t) {
+			continue
+		}
+		sinkKey, err := kcpcache.MetaClusterNamespaceKeyFunc(secret)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+
+		// every sink gets its own delivery ID, identical across retries
+		e := *event
+		e.ID = uuid.New().String()
+		c.lock.Lock()
+		c.deliveries[e.ID] = &delivery{event: &e, sinkKey: sinkKey}
+		c.lock.Unlock()
+
+		logger.V(4).Info("queueing notification", "sink", sinkKey, "delivery", e.ID)
+		c.queue.Add(e.ID)
+	}
+}
+
+// sinksCluster returns the logical cluster of the notification sinks workspace.
+func (c *Controller) sinksCluster() (logicalcluster.Name, error) {
+	logicalClusters, err := indexers.ByIndex[*corev1alpha1.LogicalCluster](c.logicalClusterIndexer, indexers.ByLogicalClusterPath, c.options.Workspace)
+	if err != nil {
+		return "", err
+	}
+	if len(logicalClusters) == 0 {
+		return "", fmt.Errorf("notification sinks workspace %s not found", c.options.Workspace)
+	}
+	return logicalcluster.From(logicalClusters[0]), nil
+}
+
+// path returns the workspace path of a logical cluster, or an empty path if unknown.
+func (c *Controller) path(clusterName logicalcluster.Name) logicalcluster.Path {
+	logicalCluster, err := c.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+	if err != nil {
+		return logicalcluster.Path{}
+	}
+	return logicalcluster.NewPath(logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey])
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	retry, err := c.process(ctx, key)
+	if err != nil && retry && c.queue.NumRequeues(key) < c.options.MaxRetries {
+		runtime.HandleError(fmt.Errorf("%q controller failed to deliver %q, retrying, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to deliver %q, dropping, err: %w", ControllerName, key, err))
+	}
+
+	c.queue.Forget(key)
+	c.lock.Lock()
+	delete(c.deliveries, key)
+	c.lock.Unlock()
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) (bool, error) {
+	c.lock.Lock()
+	d, found := c.deliveries[key]
+	c.lock.Unlock()
+	if !found {
+		return false, nil
+	}
+
+	logger := klog.FromContext(ctx).WithValues("sink", d.sinkKey, "event", d.event.Type)
+
+	clusterName, namespace, name, err := kcpcache.SplitMetaClusterNamespaceKey(d.sinkKey)
+	if err != nil {
+		return false, err
+	}
+	secret, err := c.secretLister.Cluster(clusterName).Secrets(namespace).Get(name)
+	if apierrors.IsNotFound(err) || (err == nil && secret.Labels[SinkLabelKey] != "true") {
+		logger.V(2).Info("notification sink is gone, dropping notification")
+		return false, nil
+	} else if err != nil {
+		return true, err
+	}
+	s, err := sinkFromSecret(secret)
+	if err != nil {
+		return false, err
+	}
+
+	if retry, err := deliver(ctx, c.httpClient, s, d.event, c.now()); err != nil {
+		return retry, err
+	}
+	logger.V(2).Info("delivered notification")
+	return false, nil
+}
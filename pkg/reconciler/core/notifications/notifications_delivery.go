@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// EventType is the type of a notification.
+type EventType string
+
+const (
+	// APIExportPublished is sent when an APIExport is created.
+	APIExportPublished EventType = "APIExportPublished"
+	// APIExportUpdated is sent when the spec of an APIExport changes.
+	APIExportUpdated EventType = "APIExportUpdated"
+	// WorkspaceCreated is sent when a Workspace is created.
+	WorkspaceCreated EventType = "WorkspaceCreated"
+	// WorkspaceDeleted is sent when a Workspace is deleted.
+	WorkspaceDeleted EventType = "WorkspaceDeleted"
+	// APIBindingFailed is sent when a condition of an APIBinding turns false.
+	APIBindingFailed EventType = "APIBindingFailed"
+)
+
+const (
+	// SinkLabelKey is the label of the Secrets of the notification sinks workspace that are sinks.
+	SinkLabelKey = "notifications.kcp.io/sink"
+	// SinkEventsAnnotationKey is the annotation of a sink Secret restricting the notifications
+	// it receives to a comma separated list of event types. It receives all of them if absent.
+	SinkEventsAnnotationKey = "notifications.kcp.io/events"
+
+	// SinkURLKey is the key of a sink Secret holding the URL notifications are posted to.
+	SinkURLKey = "url"
+	// SinkHMACKey is the key of a sink Secret holding the optional key notifications are signed with.
+	SinkHMACKey = "hmacKey"
+
+	// EventTypeHeader is the header holding the type of the notification.
+	EventTypeHeader = "X-Kcp-Event"
+	// DeliveryHeader is the header holding the ID of the notification, identical across retries.
+	DeliveryHeader = "X-Kcp-Delivery"
+	// TimestampHeader is the header holding the Unix time the notification was signed at.
+	TimestampHeader = "X-Kcp-Timestamp"
+	// SignatureHeader is the header holding the signature of the notification, as
+	// sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">.
+	SignatureHeader = "X-Kcp-Signature"
+)
+
+// Event is the body of a notification.
+type Event struct {
+	// ID identifies the notification, and is identical across retries.
+	ID string `json:"id"`
+	// Type is the type of the notification.
+	Type EventType `json:"type"`
+	// Time is when the event happened.
+	Time metav1.Time `json:"time"`
+	// Cluster is the logical cluster of the object.
+	Cluster string `json:"cluster"`
+	// Path is the workspace path of the logical cluster of the object, if known.
+	Path string `json:"path,omitempty"`
+	// Object is the object of the event.
+	Object ObjectReference `json:"object"`
+	// Reason is a machine readable reason of the event, e.g. of the failed APIBinding condition.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the event.
+	Message string `json:"message,omitempty"`
+}
+
+// ObjectReference references the object of a notification.
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// sink is a notification endpoint, read from a sink Secret.
+type sink struct {
+	url     string
+	hmacKey []byte
+	events  sets.String
+}
+
+func sinkFromSecret(secret *corev1.Secret) (*sink, error) {
+	url := string(secret.Data[SinkURLKey])
+	if url == "" {
+		return nil, fmt.Errorf("sink Secret %s/%s has no %q key", secret.Namespace, secret.Name, SinkURLKey)
+	}
+	s := &sink{
+		url:     url,
+		hmacKey: secret.Data[SinkHMACKey],
+	}
+	if v, ok := secret.Annotations[SinkEventsAnnotationKey]; ok {
+		s.events = sets.NewString()
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				s.events.Insert(t)
+			}
+		}
+	}
+	return s, nil
+}
+
+// wants returns whether the sink receives the notifications of the event type.
+func (s *sink) wants(t EventType) bool {
+	return s.events == nil || s.events.Has(string(t))
+}
+
+// sign returns the signature of body at the given time.
+func sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts the event to the sink. It returns whether a failed delivery
+// can be retried, i.e. unless the sink rejected it with a client error.
+func deliver(ctx context.Context, client *http.Client, s *sink, event *Event, now time.Time) (bool, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, event.ID)
+	if len(s.hmacKey) > 0 {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, sign(s.hmacKey, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("sink responded %s", resp.Status)
+	default:
+		return false, fmt.Errorf("sink rejected the notification with %s", resp.Status)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSinkFromSecret(t *testing.T) {
+	_, err := sinkFromSecret(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sink"}})
+	require.Error(t, err)
+
+	s, err := sinkFromSecret(&corev1.Secret{Data: map[string][]byte{SinkURLKey: []byte("https://example.com")}})
+	require.NoError(t, err)
+	require.True(t, s.wants(WorkspaceCreated))
+	require.True(t, s.wants(APIBindingFailed))
+
+	s, err = sinkFromSecret(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SinkEventsAnnotationKey: "WorkspaceCreated, WorkspaceDeleted"}},
+		Data:       map[string][]byte{SinkURLKey: []byte("https://example.com")},
+	})
+	require.NoError(t, err)
+	require.True(t, s.wants(WorkspaceCreated))
+	require.True(t, s.wants(WorkspaceDeleted))
+	require.False(t, s.wants(APIExportPublished))
+}
+
+func TestDeliver(t *testing.T) {
+	now := time.Unix(1670000000, 0)
+	event := &Event{
+		ID:      "e6f3a1d2",
+		Type:    WorkspaceCreated,
+		Time:    metav1.NewTime(now),
+		Cluster: "2v1ys0zunjasnkqx",
+		Path:    "root:org",
+		Object:  ObjectReference{APIVersion: "tenancy.kcp.io/v1beta1", Kind: "Workspace", Name: "team"},
+	}
+
+	tests := map[string]struct {
+		status    int
+		hmacKey   []byte
+		wantRetry bool
+		wantErr   bool
+	}{
+		"delivered":         {status: http.StatusNoContent},
+		"delivered, signed": {status: http.StatusOK, hmacKey: []byte("secret")},
+		"server error":      {status: http.StatusBadGateway, wantRetry: true, wantErr: true},
+		"throttled":         {status: http.StatusTooManyRequests, wantRetry: true, wantErr: true},
+		"rejected":          {status: http.StatusBadRequest, wantErr: true},
+		"rejected, signed":  {status: http.StatusUnauthorized, hmacKey: []byte("secret"), wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				var got Event
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Equal(t, event.ID, got.ID)
+				require.Equal(t, "root:org", got.Path)
+				require.Equal(t, string(WorkspaceCreated), r.Header.Get(EventTypeHeader))
+				require.Equal(t, event.ID, r.Header.Get(DeliveryHeader))
+
+				if tc.hmacKey == nil {
+					require.Empty(t, r.Header.Get(SignatureHeader))
+				} else {
+					require.Equal(t, "1670000000", r.Header.Get(TimestampHeader))
+					require.Equal(t, sign(tc.hmacKey, "1670000000", body), r.Header.Get(SignatureHeader))
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			retry, err := deliver(context.Background(), server.Client(), &sink{url: server.URL, hmacKey: tc.hmacKey}, event, now)
+			require.Equal(t, tc.wantRetry, retry)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1670000000.{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "sha256=1108cf9aa8410b79a084cc40d4947d4bf4f5f931e0a1de6bc4b640499911faa1", sign([]byte("secret"), "1670000000", []byte("{}")))
+	require.NotEqual(t, sign([]byte("secret"), "1670000000", []byte("{}")), sign([]byte("secret"), "1670000001", []byte("{}")))
+}
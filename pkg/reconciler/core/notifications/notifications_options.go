@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		Namespace:  "kcp-notifications",
+		MaxRetries: 5,
+		Timeout:    10 * time.Second,
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.Workspace, "notification-sinks-workspace", o.Workspace, "Path of the workspace holding the notification sinks, as Secrets labelled "+SinkLabelKey+"=true. The notifications controller is disabled if empty.")
+	fs.StringVar(&o.Namespace, "notification-sinks-namespace", o.Namespace, "Namespace of the notification sinks workspace holding the sink Secrets.")
+	fs.IntVar(&o.MaxRetries, "notification-max-retries", o.MaxRetries, "Number of times the delivery of a notification is retried with an exponential backoff before it is dropped.")
+	fs.DurationVar(&o.Timeout, "notification-timeout", o.Timeout, "Timeout of the delivery of a notification to a sink.")
+	return o
+}
+
+type Options struct {
+	Workspace  string
+	Namespace  string
+	MaxRetries int
+	Timeout    time.Duration
+}
+
+// Enabled returns whether a notification sinks workspace is configured.
+func (o *Options) Enabled() bool {
+	return o.Workspace != ""
+}
+
+func (o *Options) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	if !logicalcluster.NewPath(o.Workspace).IsValid() {
+		return fmt.Errorf("--notification-sinks-workspace must be a valid workspace path (%s)", o.Workspace)
+	}
+	if o.Namespace == "" {
+		return fmt.Errorf("--notification-sinks-namespace must be set with --notification-sinks-workspace")
+	}
+	if o.MaxRetries < 0 {
+		return fmt.Errorf("--notification-max-retries must be positive (%d)", o.MaxRetries)
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("--notification-timeout must be positive (%s)", o.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referencegrant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	"github.com/kcp-dev/kcp/pkg/referencegrant"
+)
+
+const (
+	ControllerName = "kcp-reference-grant"
+
+	// byReference indexes the Secrets and ConfigMaps by the value of their
+	// corev1alpha1.ReferenceAnnotationKey annotation.
+	byReference = "byReference"
+
+	secretsResource    = "secrets"
+	configMapsResource = "configmaps"
+)
+
+// NewController returns a new controller projecting the data of the Secrets and ConfigMaps granting
+// other workspaces the read access to them into the Secrets and ConfigMaps of these workspaces
+// referencing them.
+func NewController(
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
+	secretInformer kcpcorev1informers.SecretClusterInformer,
+	configMapInformer kcpcorev1informers.ConfigMapClusterInformer,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &controller{
+		queue: queue,
+
+		getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+			return logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+		},
+		getLogicalClustersByPath: func(path logicalcluster.Path) ([]*corev1alpha1.LogicalCluster, error) {
+			return indexers.ByIndex[*corev1alpha1.LogicalCluster](logicalClusterInformer.Informer().GetIndexer(), indexers.ByLogicalClusterPath, path.String())
+		},
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			return apiBindingInformer.Lister().Cluster(clusterName).List(labels.Everything())
+		},
+		getSecret: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+			return secretInformer.Lister().Cluster(clusterName).Secrets(namespace).Get(name)
+		},
+		getConfigMap: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.ConfigMap, error) {
+			return configMapInformer.Lister().Cluster(clusterName).ConfigMaps(namespace).Get(name)
+		},
+		listReferrers: func(resource string, indexName, indexValue string) ([]metav1.Object, error) {
+			indexer := secretInformer.Informer().GetIndexer()
+			if resource == configMapsResource {
+				indexer = configMapInformer.Informer().GetIndexer()
+			}
+			objs, err := indexer.ByIndex(indexName, indexValue)
+			if err != nil {
+				return nil, err
+			}
+			referrers := make([]metav1.Object, 0, len(objs))
+			for _, obj := range objs {
+				if obj, ok := obj.(metav1.Object); ok && obj.GetAnnotations()[corev1alpha1.ReferenceAnnotationKey] != "" {
+					referrers = append(referrers, obj)
+				}
+			}
+			return referrers, nil
+		},
+		updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+			_, err := kubeClusterClient.Cluster(clusterName.Path()).CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		},
+		updateConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+			_, err := kubeClusterClient.Cluster(clusterName.Path()).CoreV1().ConfigMaps(configMap.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+			return err
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(logicalClusterInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalClusterPath: indexers.IndexByLogicalClusterPath,
+	})
+	indexers.AddIfNotPresentOrDie(secretInformer.Informer().GetIndexer(), cache.Indexers{
+		byReference: indexByReference,
+	})
+	indexers.AddIfNotPresentOrDie(configMapInformer.Informer().GetIndexer(), cache.Indexers{
+		byReference: indexByReference,
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueCluster(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueCluster(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueCluster(obj) },
+	})
+
+	for resource, informer := range map[string]cache.SharedIndexInformer{
+		secretsResource:    secretInformer.Informer(),
+		configMapsResource: configMapInformer.Informer(),
+	} {
+		resource := resource
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(resource, obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(resource, obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueue(resource, obj) },
+		})
+	}
+
+	return c
+}
+
+// controller resolves the Secrets and ConfigMaps with the corev1alpha1.ReferenceAnnotationKey
+// annotation. The data of a referenced object are projected into the referencing object as long
+// as the referenced object grants the workspace of the referencing object, either directly or
+// through one of the APIExports bound in it, with the corev1alpha1.ReferenceGrantsAnnotationKey
+// annotation. They are removed when the grant is revoked or the referenced object deleted.
+//
+// The referenced objects are only resolved among the logical clusters of the shard.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getLogicalCluster        func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error)
+	getLogicalClustersByPath func(path logicalcluster.Path) ([]*corev1alpha1.LogicalCluster, error)
+	listAPIBindings          func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+	getSecret                func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error)
+	getConfigMap             func(clusterName logicalcluster.Name, namespace, name string) (*corev1.ConfigMap, error)
+	listReferrers            func(resource string, indexName, indexValue string) ([]metav1.Object, error)
+
+	updateSecret    func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error
+	updateConfigMap func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error
+}
+
+// indexByReference indexes the objects with the corev1alpha1.ReferenceAnnotationKey annotation by
+// the object they reference.
+func indexByReference(obj interface{}) ([]string, error) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
+	}
+	value, found := metaObj.GetAnnotations()[corev1alpha1.ReferenceAnnotationKey]
+	if !found {
+		return []string{}, nil
+	}
+	reference, err := referencegrant.ParseReference(value)
+	if err != nil {
+		return []string{}, nil // reported by the reconciliation
+	}
+	return []string{reference.String()}, nil
+}
+
+// enqueue enqueues the given Secret or ConfigMap if it references another object, and the objects
+// referencing it.
+func (c *controller) enqueue(resource string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj))
+		return
+	}
+
+	if _, found := metaObj.GetAnnotations()[corev1alpha1.ReferenceAnnotationKey]; found {
+		c.add(resource, metaObj, "referencing")
+	}
+
+	clusterName := logicalcluster.From(metaObj)
+	paths := []logicalcluster.Path{clusterName.Path()}
+	if logicalCluster, err := c.getLogicalCluster(clusterName); err == nil {
+		if path, found := logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]; found {
+			paths = append(paths, logicalcluster.NewPath(path))
+		}
+	}
+	for _, path := range paths {
+		reference := referencegrant.Reference{Path: path, Namespace: metaObj.GetNamespace(), Name: metaObj.GetName()}
+		referrers, err := c.listReferrers(resource, byReference, reference.String())
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		for _, referrer := range referrers {
+			c.add(resource, referrer, "referenced")
+		}
+	}
+}
+
+// enqueueCluster enqueues the Secrets and ConfigMaps referencing other objects in the logical cluster
+// of the given APIBinding.
+func (c *controller) enqueueCluster(obj interface{}) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, resource := range []string{secretsResource, configMapsResource} {
+		referrers, err := c.listReferrers(resource, kcpcache.ClusterIndexName, clusterName.String())
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		for _, referrer := range referrers {
+			c.add(resource, referrer, "APIBinding")
+		}
+	}
+}
+
+func (c *controller) add(resource string, obj metav1.Object, reason string) {
+	key, err := kcpcache.MetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	queueKey := resource + "::" + key
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), queueKey)
+	logger.V(4).Info(fmt.Sprintf("queueing %s because of %s", resource, reason))
+	c.queue.Add(queueKey)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, queueKey string) error {
+	resource, key, found := strings.Cut(queueKey, "::")
+	if !found {
+		runtime.HandleError(fmt.Errorf("incorrect key: %v, expected resource::key", queueKey))
+		return nil
+	}
+	clusterName, namespace, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
+	switch resource {
+	case secretsResource:
+		secret, err := c.getSecret(clusterName, namespace, name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil // object deleted before we handled it
+			}
+			return err
+		}
+		ctx = klog.NewContext(ctx, logging.WithObject(klog.FromContext(ctx), secret))
+		return c.reconcileSecret(ctx, secret)
+	case configMapsResource:
+		configMap, err := c.getConfigMap(clusterName, namespace, name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil // object deleted before we handled it
+			}
+			return err
+		}
+		ctx = klog.NewContext(ctx, logging.WithObject(klog.FromContext(ctx), configMap))
+		return c.reconcileConfigMap(ctx, configMap)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referencegrant
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/referencegrant"
+)
+
+func (c *controller) reconcileSecret(ctx context.Context, secret *corev1.Secret) error {
+	clusterName := logicalcluster.From(secret)
+	status, source, err := c.resolve(ctx, clusterName, secret.Annotations[corev1alpha1.ReferenceAnnotationKey], func(clusterName logicalcluster.Name, namespace, name string) (metav1.Object, error) {
+		return c.getSecret(clusterName, namespace, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	updated := secret.DeepCopy()
+	updated.Data = nil
+	if source != nil {
+		updated.Data = source.(*corev1.Secret).DeepCopy().Data
+	}
+	updated.Annotations[corev1alpha1.ReferenceStatusAnnotationKey] = status
+	if equality.Semantic.DeepEqual(secret, updated) {
+		return nil
+	}
+	return c.updateSecret(ctx, clusterName, updated)
+}
+
+func (c *controller) reconcileConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error {
+	clusterName := logicalcluster.From(configMap)
+	status, source, err := c.resolve(ctx, clusterName, configMap.Annotations[corev1alpha1.ReferenceAnnotationKey], func(clusterName logicalcluster.Name, namespace, name string) (metav1.Object, error) {
+		return c.getConfigMap(clusterName, namespace, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	updated := configMap.DeepCopy()
+	updated.Data = nil
+	updated.BinaryData = nil
+	if source != nil {
+		sourceConfigMap := source.(*corev1.ConfigMap).DeepCopy()
+		updated.Data = sourceConfigMap.Data
+		updated.BinaryData = sourceConfigMap.BinaryData
+	}
+	updated.Annotations[corev1alpha1.ReferenceStatusAnnotationKey] = status
+	if equality.Semantic.DeepEqual(configMap, updated) {
+		return nil
+	}
+	return c.updateConfigMap(ctx, clusterName, updated)
+}
+
+// resolve returns the object referenced from the given logical cluster, if it grants the logical
+// cluster the read access to it, and the status of the reference.
+func (c *controller) resolve(ctx context.Context, clusterName logicalcluster.Name, value string, get func(clusterName logicalcluster.Name, namespace, name string) (metav1.Object, error)) (string, metav1.Object, error) {
+	logger := klog.FromContext(ctx)
+
+	reference, err := referencegrant.ParseReference(value)
+	if err != nil {
+		logger.V(2).Info("invalid reference", "err", err)
+		return corev1alpha1.ReferenceInvalid, nil, nil
+	}
+
+	sourceClusterName, err := c.resolvePath(reference.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	if sourceClusterName.Empty() {
+		return corev1alpha1.ReferenceNotFound, nil, nil
+	}
+	source, err := get(sourceClusterName, reference.Namespace, reference.Name)
+	if errors.IsNotFound(err) {
+		return corev1alpha1.ReferenceNotFound, nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	referrer, err := c.referrer(clusterName)
+	if err != nil {
+		return "", nil, err
+	}
+	var resolveErr error
+	granted, err := referencegrant.Granted(source, referrer, func(path logicalcluster.Path) logicalcluster.Name {
+		name, err := c.resolvePath(path)
+		if err != nil {
+			resolveErr = err
+		}
+		return name
+	})
+	if resolveErr != nil {
+		return "", nil, resolveErr
+	}
+	if err != nil {
+		logger.V(2).Info("invalid grants of the referenced object", "reference", reference.String(), "err", err)
+		return corev1alpha1.ReferenceNotGranted, nil, nil
+	}
+	if !granted {
+		return corev1alpha1.ReferenceNotGranted, nil, nil
+	}
+	return corev1alpha1.ReferenceResolved, source, nil
+}
+
+// referrer returns the canonical path of the given logical cluster, and the APIExports bound in it.
+func (c *controller) referrer(clusterName logicalcluster.Name) (referencegrant.Referrer, error) {
+	referrer := referencegrant.Referrer{ClusterName: clusterName}
+
+	logicalCluster, err := c.getLogicalCluster(clusterName)
+	if err != nil && !errors.IsNotFound(err) {
+		return referrer, err
+	} else if err == nil {
+		referrer.Path = logicalcluster.NewPath(logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey])
+	}
+
+	bindings, err := c.listAPIBindings(clusterName)
+	if err != nil {
+		return referrer, err
+	}
+	for _, binding := range bindings {
+		if binding.Spec.Reference.Export == nil {
+			continue
+		}
+		path := logicalcluster.NewPath(binding.Spec.Reference.Export.Path)
+		if path.Empty() {
+			path = clusterName.Path()
+		}
+		exportClusterName, err := c.resolvePath(path)
+		if err != nil {
+			return referrer, err
+		}
+		if exportClusterName.Empty() {
+			continue
+		}
+		referrer.Exports = append(referrer.Exports, referencegrant.Export{ClusterName: exportClusterName, Name: binding.Spec.Reference.Export.Name})
+	}
+	return referrer, nil
+}
+
+// resolvePath returns the name of the logical cluster with the given path, or an empty name if
+// it is not found on the shard.
+func (c *controller) resolvePath(path logicalcluster.Path) (logicalcluster.Name, error) {
+	logicalClusters, err := c.getLogicalClustersByPath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(logicalClusters) != 1 {
+		return "", nil
+	}
+	return logicalcluster.From(logicalClusters[0]), nil
+}
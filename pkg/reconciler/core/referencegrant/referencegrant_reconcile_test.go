@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referencegrant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func TestReconcileSecret(t *testing.T) {
+	logicalClusters := map[logicalcluster.Path]*corev1alpha1.LogicalCluster{}
+	for name, path := range map[string]string{"provider": "root:provider", "consumer": "root:consumer", "exporter": "root:exporter"} {
+		logicalCluster := &corev1alpha1.LogicalCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: corev1alpha1.LogicalClusterName,
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey:         name,
+					core.LogicalClusterPathAnnotationKey: path,
+				},
+			},
+		}
+		logicalClusters[logicalcluster.NewPath(path)] = logicalCluster
+		logicalClusters[logicalcluster.NewPath(name)] = logicalCluster
+	}
+
+	tests := map[string]struct {
+		reference  string
+		grants     string
+		bindings   []*apisv1alpha1.APIBinding
+		noSource   bool
+		wantStatus string
+		wantData   map[string][]byte
+	}{
+		"granted to the workspace": {
+			reference:  "root:provider/ns/credentials",
+			grants:     `[{"workspace":"root:consumer"}]`,
+			wantStatus: corev1alpha1.ReferenceResolved,
+			wantData:   map[string][]byte{"token": []byte("secret")},
+		},
+		"granted to the logical cluster, referenced by cluster name": {
+			reference:  "provider/ns/credentials",
+			grants:     `[{"workspace":"consumer"}]`,
+			wantStatus: corev1alpha1.ReferenceResolved,
+			wantData:   map[string][]byte{"token": []byte("secret")},
+		},
+		"granted to the consumers of a bound export": {
+			reference: "root:provider/ns/credentials",
+			grants:    `[{"export":"root:exporter:kubernetes"}]`,
+			bindings: []*apisv1alpha1.APIBinding{{
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.BindingReference{Export: &apisv1alpha1.ExportBindingReference{Path: "root:exporter", Name: "kubernetes"}},
+				},
+			}},
+			wantStatus: corev1alpha1.ReferenceResolved,
+			wantData:   map[string][]byte{"token": []byte("secret")},
+		},
+		"granted to the consumers of an unbound export": {
+			reference:  "root:provider/ns/credentials",
+			grants:     `[{"export":"root:exporter:kubernetes"}]`,
+			wantStatus: corev1alpha1.ReferenceNotGranted,
+		},
+		"granted to another workspace": {
+			reference:  "root:provider/ns/credentials",
+			grants:     `[{"workspace":"root:other"}]`,
+			wantStatus: corev1alpha1.ReferenceNotGranted,
+		},
+		"not granted": {
+			reference:  "root:provider/ns/credentials",
+			wantStatus: corev1alpha1.ReferenceNotGranted,
+		},
+		"invalid grants": {
+			reference:  "root:provider/ns/credentials",
+			grants:     `[{"workspace":"root:consumer","export":"root:exporter:kubernetes"}]`,
+			wantStatus: corev1alpha1.ReferenceNotGranted,
+		},
+		"not found": {
+			reference:  "root:provider/ns/credentials",
+			noSource:   true,
+			wantStatus: corev1alpha1.ReferenceNotFound,
+		},
+		"unknown workspace": {
+			reference:  "root:unknown/ns/credentials",
+			wantStatus: corev1alpha1.ReferenceNotFound,
+		},
+		"invalid reference": {
+			reference:  "root:provider/credentials",
+			wantStatus: corev1alpha1.ReferenceInvalid,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "ns",
+					Name:        "credentials",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "provider"},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+			if tc.grants != "" {
+				source.Annotations[corev1alpha1.ReferenceGrantsAnnotationKey] = tc.grants
+			}
+			referrer := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "credentials",
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey:        "consumer",
+						corev1alpha1.ReferenceAnnotationKey: tc.reference,
+					},
+				},
+				Data: map[string][]byte{"token": []byte("stale")},
+			}
+
+			var updated *corev1.Secret
+			c := &controller{
+				getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+					if logicalCluster, found := logicalClusters[clusterName.Path()]; found {
+						return logicalCluster, nil
+					}
+					return nil, errors.NewNotFound(corev1alpha1.Resource("logicalclusters"), corev1alpha1.LogicalClusterName)
+				},
+				getLogicalClustersByPath: func(path logicalcluster.Path) ([]*corev1alpha1.LogicalCluster, error) {
+					if logicalCluster, found := logicalClusters[path]; found {
+						return []*corev1alpha1.LogicalCluster{logicalCluster}, nil
+					}
+					return nil, nil
+				},
+				listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, logicalcluster.Name("consumer"), clusterName)
+					return tc.bindings, nil
+				},
+				getSecret: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+					if tc.noSource || clusterName != "provider" || namespace != "ns" || name != "credentials" {
+						return nil, errors.NewNotFound(corev1.Resource("secrets"), name)
+					}
+					return source, nil
+				},
+				updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+					require.Equal(t, logicalcluster.Name("consumer"), clusterName)
+					updated = secret
+					return nil
+				},
+			}
+
+			require.NoError(t, c.reconcileSecret(context.Background(), referrer))
+			require.NotNil(t, updated)
+			require.Equal(t, tc.wantStatus, updated.Annotations[corev1alpha1.ReferenceStatusAnnotationKey])
+			require.Equal(t, tc.wantData, updated.Data)
+		})
+	}
+}
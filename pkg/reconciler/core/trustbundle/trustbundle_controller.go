@@ -0,0 +1,248 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-trust-bundle"
+)
+
+// NewController returns a new controller projecting the trust bundles of the logical clusters
+// into their namespaces, and publishing them to the consumers of their APIExports.
+func NewController(
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	kcpClusterClient kcpclientset.ClusterInterface,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	apiExportInformer apisv1alpha1informers.APIExportClusterInformer,
+	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
+	namespaceInformer kcpcorev1informers.NamespaceClusterInformer,
+	configMapInformer kcpcorev1informers.ConfigMapClusterInformer,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &controller{
+		queue: queue,
+
+		getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+			return logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+		},
+		listAPIExports: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIExport, error) {
+			return apiExportInformer.Lister().Cluster(clusterName).List(labels.Everything())
+		},
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			return apiBindingInformer.Lister().Cluster(clusterName).List(labels.Everything())
+		},
+		listNamespaces: func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+			return namespaceInformer.Lister().Cluster(clusterName).List(labels.Everything())
+		},
+		getConfigMap: func(clusterName logicalcluster.Name, namespace string) (*corev1.ConfigMap, error) {
+			return configMapInformer.Lister().Cluster(clusterName).ConfigMaps(namespace).Get(corev1alpha1.TrustBundleConfigMapName)
+		},
+		patchAPIExport: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+			_, err := kcpClusterClient.Cluster(clusterName.Path()).ApisV1alpha1().APIExports().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+			return err
+		},
+		createConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+			_, err := kubeClusterClient.Cluster(clusterName.Path()).CoreV1().ConfigMaps(configMap.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
+			return err
+		},
+		updateConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+			_, err := kubeClusterClient.Cluster(clusterName.Path()).CoreV1().ConfigMaps(configMap.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+			return err
+		},
+		deleteConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, namespace string) error {
+			return kubeClusterClient.Cluster(clusterName.Path()).CoreV1().ConfigMaps(namespace).Delete(ctx, corev1alpha1.TrustBundleConfigMapName, metav1.DeleteOptions{})
+		},
+	}
+
+	logicalClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj, "LogicalCluster") },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj, "LogicalCluster") },
+	})
+
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj, "APIExport") },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj, "APIExport") },
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj, "APIBinding") },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj, "APIBinding") },
+		DeleteFunc: func(obj interface{}) { c.enqueue(obj, "APIBinding") },
+	})
+
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueue(obj, "Namespace") },
+	})
+
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			configMap, ok := obj.(*corev1.ConfigMap)
+			return ok && configMap.Name == corev1alpha1.TrustBundleConfigMapName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj, "ConfigMap") },
+			DeleteFunc: func(obj interface{}) { c.enqueue(obj, "ConfigMap") },
+		},
+	})
+
+	return c
+}
+
+// controller reconciles the trust bundles of the logical clusters. The trust bundles of a logical
+// cluster are published on its APIExports with the apisv1alpha1.AnnotationAPIExportTrustBundleKey
+// annotation, which is synced to the APIBindings of the consumers. The trust bundles of a logical
+// cluster, and those of the providers of the APIs bound in it, are projected into the
+// corev1alpha1.TrustBundleConfigMapName ConfigMap of each of its namespaces, from where the syncers
+// also sync them downstream.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getLogicalCluster func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error)
+	listAPIExports    func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIExport, error)
+	listAPIBindings   func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+	listNamespaces    func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error)
+	getConfigMap      func(clusterName logicalcluster.Name, namespace string) (*corev1.ConfigMap, error)
+
+	patchAPIExport  func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error
+	createConfigMap func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error
+	updateConfigMap func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error
+	deleteConfigMap func(ctx context.Context, clusterName logicalcluster.Name, namespace string) error
+}
+
+// enqueue enqueues the logical cluster of the given object.
+func (c *controller) enqueue(obj interface{}, kind string) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	key = kcpcache.ToClusterAwareKey(clusterName.String(), "", corev1alpha1.LogicalClusterName)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(4).Info(fmt.Sprintf("queueing LogicalCluster because of %s", kind))
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
+	logicalCluster, err := c.getLogicalCluster(clusterName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), logicalCluster)
+	ctx = klog.NewContext(ctx, logger)
+
+	return c.reconcile(ctx, logicalCluster)
+}
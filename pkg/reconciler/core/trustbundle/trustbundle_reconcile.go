@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func (c *controller) reconcile(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) error {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(logicalCluster)
+
+	pems := make([]string, 0, len(logicalCluster.Spec.TrustBundles))
+	for _, trustBundle := range logicalCluster.Spec.TrustBundles {
+		pems = append(pems, trustBundle.PEM)
+	}
+	own := joinBundles(pems...)
+
+	// publish the trust bundles to the consumers of the APIExports
+	apiExports, err := c.listAPIExports(clusterName)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, apiExport := range apiExports {
+		if apiExport.Annotations[apisv1alpha1.AnnotationAPIExportTrustBundleKey] == own {
+			continue
+		}
+		var value interface{} // nil removes the annotation
+		if own != "" {
+			value = own
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					apisv1alpha1.AnnotationAPIExportTrustBundleKey: value,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		logger.V(2).Info("publishing trust bundle", "apiexport", apiExport.Name)
+		if err := c.patchAPIExport(ctx, clusterName, apiExport.Name, patch); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	// add the trust bundles of the providers of the bound APIs
+	apiBindings, err := c.listAPIBindings(clusterName)
+	if err != nil {
+		return err
+	}
+	sort.Slice(apiBindings, func(i, j int) bool {
+		return apiBindings[i].Name < apiBindings[j].Name
+	})
+	for _, apiBinding := range apiBindings {
+		if value, found := apiBinding.Annotations[apisv1alpha1.AnnotationAPIExportTrustBundleKey]; found {
+			pems = append(pems, value)
+		}
+	}
+	bundle := joinBundles(pems...)
+
+	// project the trust bundles into the namespaces
+	namespaces, err := c.listNamespaces(clusterName)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		if err := c.ensureConfigMap(ctx, clusterName, namespace.Name, bundle); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// ensureConfigMap ensures that the trust bundle ConfigMap of the namespace holds the given bundle,
+// or that it does not exist if the bundle is empty.
+func (c *controller) ensureConfigMap(ctx context.Context, clusterName logicalcluster.Name, namespace string, bundle string) error {
+	logger := klog.FromContext(ctx).WithValues("namespace", namespace)
+
+	configMap, err := c.getConfigMap(clusterName, namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	switch {
+	case bundle == "" && !found:
+		return nil
+	case bundle == "":
+		logger.V(2).Info("deleting trust bundle ConfigMap")
+		if err := c.deleteConfigMap(ctx, clusterName, namespace); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	case !found:
+		logger.V(2).Info("creating trust bundle ConfigMap")
+		err := c.createConfigMap(ctx, clusterName, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      corev1alpha1.TrustBundleConfigMapName,
+				Namespace: namespace,
+			},
+			Data: map[string]string{corev1alpha1.TrustBundleConfigMapKey: bundle},
+		})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	case configMap.Data[corev1alpha1.TrustBundleConfigMapKey] == bundle:
+		return nil
+	}
+
+	logger.V(2).Info("updating trust bundle ConfigMap")
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[corev1alpha1.TrustBundleConfigMapKey] = bundle
+	return c.updateConfigMap(ctx, clusterName, configMap)
+}
+
+// joinBundles concatenates the PEM blocks of the given bundles, skipping the duplicates.
+func joinBundles(bundles ...string) string {
+	seen := sets.NewString()
+	var b strings.Builder
+	for _, bundle := range bundles {
+		rest := []byte(bundle)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			encoded := string(pem.EncodeToMemory(block))
+			if seen.Has(encoded) {
+				continue
+			}
+			seen.Insert(encoded)
+			b.WriteString(encoded)
+		}
+	}
+	return b.String()
+}
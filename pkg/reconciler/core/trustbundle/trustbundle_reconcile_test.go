@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		trustBundles     []corev1alpha1.TrustBundle
+		exportAnnotation *string
+		bindingBundle    string
+		configMapData    *string
+
+		wantPatch     string
+		wantConfigMap *string
+		wantDeleted   bool
+	}{
+		"no trust bundles": {},
+		"own trust bundle": {
+			trustBundles:  []corev1alpha1.TrustBundle{{Name: "ca", PEM: testCA}},
+			wantPatch:     `{"metadata":{"annotations":{"extra.apis.kcp.io/trust-bundle":` + jsonString(testCA) + `}}}`,
+			wantConfigMap: stringPtr(testCA),
+		},
+		"own trust bundle already published and projected": {
+			trustBundles:     []corev1alpha1.TrustBundle{{Name: "ca", PEM: testCA}},
+			exportAnnotation: stringPtr(testCA),
+			configMapData:    stringPtr(testCA),
+		},
+		"trust bundle of a provider": {
+			bindingBundle: testProviderCA,
+			wantConfigMap: stringPtr(testProviderCA),
+		},
+		"duplicated trust bundles": {
+			trustBundles:     []corev1alpha1.TrustBundle{{Name: "ca", PEM: testCA}, {Name: "provider", PEM: testProviderCA}},
+			exportAnnotation: stringPtr(testCA + testProviderCA),
+			bindingBundle:    testProviderCA,
+			wantConfigMap:    stringPtr(testCA + testProviderCA),
+		},
+		"trust bundle removed": {
+			exportAnnotation: stringPtr(testCA),
+			configMapData:    stringPtr(testCA),
+			wantPatch:        `{"metadata":{"annotations":{"extra.apis.kcp.io/trust-bundle":null}}}`,
+			wantDeleted:      true,
+		},
+		"trust bundle changed": {
+			trustBundles:     []corev1alpha1.TrustBundle{{Name: "ca", PEM: testProviderCA}},
+			exportAnnotation: stringPtr(testProviderCA),
+			configMapData:    stringPtr(testCA),
+			wantConfigMap:    stringPtr(testProviderCA),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			apiExport := &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "export"}}
+			if tc.exportAnnotation != nil {
+				apiExport.Annotations = map[string]string{apisv1alpha1.AnnotationAPIExportTrustBundleKey: *tc.exportAnnotation}
+			}
+			apiBinding := &apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{Name: "binding"}}
+			if tc.bindingBundle != "" {
+				apiBinding.Annotations = map[string]string{apisv1alpha1.AnnotationAPIExportTrustBundleKey: tc.bindingBundle}
+			}
+
+			var gotPatch string
+			var gotConfigMap *string
+			var gotDeleted bool
+			c := &controller{
+				listAPIExports: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIExport, error) {
+					return []*apisv1alpha1.APIExport{apiExport}, nil
+				},
+				listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+					return []*apisv1alpha1.APIBinding{apiBinding}, nil
+				},
+				listNamespaces: func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+					return []*corev1.Namespace{
+						{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "terminating"}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}},
+					}, nil
+				},
+				getConfigMap: func(clusterName logicalcluster.Name, namespace string) (*corev1.ConfigMap, error) {
+					require.Equal(t, "default", namespace)
+					if tc.configMapData == nil {
+						return nil, errors.NewNotFound(corev1.Resource("configmaps"), corev1alpha1.TrustBundleConfigMapName)
+					}
+					return &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: corev1alpha1.TrustBundleConfigMapName, Namespace: namespace},
+						Data:       map[string]string{corev1alpha1.TrustBundleConfigMapKey: *tc.configMapData},
+					}, nil
+				},
+				patchAPIExport: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+					gotPatch = string(patch)
+					return nil
+				},
+				createConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+					require.Nil(t, tc.configMapData, "unexpected creation")
+					data := configMap.Data[corev1alpha1.TrustBundleConfigMapKey]
+					gotConfigMap = &data
+					return nil
+				},
+				updateConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, configMap *corev1.ConfigMap) error {
+					require.NotNil(t, tc.configMapData, "unexpected update")
+					data := configMap.Data[corev1alpha1.TrustBundleConfigMapKey]
+					gotConfigMap = &data
+					return nil
+				},
+				deleteConfigMap: func(ctx context.Context, clusterName logicalcluster.Name, namespace string) error {
+					gotDeleted = true
+					return nil
+				},
+			}
+
+			logicalCluster := &corev1alpha1.LogicalCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        corev1alpha1.LogicalClusterName,
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+				},
+				Spec: corev1alpha1.LogicalClusterSpec{TrustBundles: tc.trustBundles},
+			}
+			require.NoError(t, c.reconcile(context.Background(), logicalCluster))
+			require.Equal(t, tc.wantPatch, gotPatch)
+			require.Equal(t, tc.wantConfigMap, gotConfigMap)
+			require.Equal(t, tc.wantDeleted, gotDeleted)
+		})
+	}
+}
+
+func TestJoinBundles(t *testing.T) {
+	require.Equal(t, "", joinBundles())
+	require.Equal(t, "", joinBundles("not a certificate"))
+	require.Equal(t, testCA+testProviderCA, joinBundles(testCA, "\n"+testProviderCA, testCA))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func jsonString(s string) string {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	return string(bs)
+}
+
+// testCA and testProviderCA are self-signed CA certificates.
+const testCA = `-----BEGIN CERTIFICATE-----
+MIIBfDCCASGgAwIBAgIUBj4z2JgHxo1ZSvzq0DIy1Bjc++wwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UEAwwHdGVzdC1jYTAgFw0yNjEwMTYwODE5MzVaGA8yMTI2MDkyMjA4
+MTkzNVowEjEQMA4GA1UEAwwHdGVzdC1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEH
+A0IABFY2YCehSBtq+GIvDoEboLKG96AeAIy52c43lP3jPQ1pbZ6crW/J4cmhDdWQ
+5yw5+5/FjooRcn1j7N+pKpT+C8+jUzBRMB0GA1UdDgQWBBTxtXyN7VNZXxnsinPr
+FjnQxsXaNDAfBgNVHSMEGDAWgBTxtXyN7VNZXxnsinPrFjnQxsXaNDAPBgNVHRMB
+Af8EBTADAQH/MAoGCCqGSM49BAMCA0kAMEYCIQCvtN5WZ9NAkf/GesbKlbUup0bc
+iNzbdRqBHvjpS3IZ1AIhAJmW17jBVfstxHtabi9bO2Zzkv2gpBdUlHnfSeVaxhv0
+-----END CERTIFICATE-----
+`
+
+const testProviderCA = `-----BEGIN CERTIFICATE-----
+MIIBgzCCASmgAwIBAgIULjM6cECUjEmyQHJ53d+UDxtmelUwCgYIKoZIzj0EAwIw
+FjEUMBIGA1UEAwwLcHJvdmlkZXItY2EwIBcNMjYxMDE2MDgyMDM0WhgPMjEyNjA5
+MjIwODIwMzRaMBYxFDASBgNVBAMMC3Byb3ZpZGVyLWNhMFkwEwYHKoZIzj0CAQYI
+KoZIzj0DAQcDQgAEO2i5JzfbsfUHhfg6BG+gcN8iXm715QN2YtC5y3gFvIsimfCE
+R2m0zZd3MBxd0SyGbobCxFCSrMv7xMgsSvuhGaNTMFEwHQYDVR0OBBYEFCjnvfaH
+mIOUtUh4oPp6bDruNe37MB8GA1UdIwQYMBaAFCjnvfaHmIOUtUh4oPp6bDruNe37
+MA8GA1UdEwEB/wQFMAMBAf8wCgYIKoZIzj0EAwIDSAAwRQIgDMMbzSp/nXzAlT+j
+knNSeaXisdKqFHbUPMV/VobZB5MCIQD/lg7QqfU/ie90lG0HODzZsdK8sMr2kxtO
+YZgB3VyS9Q==
+-----END CERTIFICATE-----
+`
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events supplies an event recorder for controllers, which writes the events to the
+// logical cluster of the objects they are about, so that `kubectl describe` shows them in the
+// workspace of the object.
+package events
+
+import (
+	"context"
+
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kcpscheme.AddToScheme(scheme))
+}
+
+// Object is an object events are recorded about.
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Recorder records events about objects in their logical cluster.
+type Recorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	sink        record.EventSink
+}
+
+// NewRecorder returns a recorder of the events of the component. Events are only written once
+// the recorder is started.
+func NewRecorder(kubeClusterClient kcpkubernetesclientset.ClusterInterface, component string) *Recorder {
+	broadcaster := record.NewBroadcaster()
+	return &Recorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme, corev1.EventSource{Component: component}),
+		sink:        &clusterEventSink{kubeClusterClient: kubeClusterClient},
+	}
+}
+
+// Start writes the recorded events until the context is done.
+func (r *Recorder) Start(ctx context.Context) {
+	r.broadcaster.StartRecordingToSink(r.sink)
+	go func() {
+		<-ctx.Done()
+		r.broadcaster.Shutdown()
+	}()
+}
+
+// Eventf records an event about the object, in its logical cluster. Events about cluster-scoped
+// objects are written to the default namespace.
+func (r *Recorder) Eventf(obj Object, eventType, reason, messageFmt string, args ...interface{}) {
+	annotations := map[string]string{
+		logicalcluster.AnnotationKey: logicalcluster.From(obj).String(),
+	}
+	r.recorder.AnnotatedEventf(obj, annotations, eventType, reason, messageFmt, args...)
+}
+
+// ConditionTransitions records an event for each condition of the object that changed status or
+// reason: a Normal event named after the condition type when it becomes true, and an event with
+// the reason and message of the condition when it becomes false, which is a Warning unless the
+// severity of the condition is Info.
+func (r *Recorder) ConditionTransitions(obj Object, old, new conditionsv1alpha1.Conditions) {
+	previous := make(map[conditionsv1alpha1.ConditionType]conditionsv1alpha1.Condition, len(old))
+	for _, c := range old {
+		previous[c.Type] = c
+	}
+
+	for _, c := range new {
+		if p, ok := previous[c.Type]; ok && p.Status == c.Status && p.Reason == c.Reason {
+			continue
+		}
+		switch c.Status {
+		case corev1.ConditionTrue:
+			r.Eventf(obj, corev1.EventTypeNormal, string(c.Type), "%s", conditionMessage(c))
+		case corev1.ConditionFalse:
+			eventType := corev1.EventTypeWarning
+			if c.Severity == conditionsv1alpha1.ConditionSeverityInfo {
+				eventType = corev1.EventTypeNormal
+			}
+			reason := c.Reason
+			if reason == "" {
+				reason = string(c.Type)
+			}
+			r.Eventf(obj, eventType, reason, "%s", conditionMessage(c))
+		}
+	}
+}
+
+func conditionMessage(c conditionsv1alpha1.Condition) string {
+	if c.Message != "" {
+		return string(c.Type) + ": " + c.Message
+	}
+	return string(c.Type) + " is " + string(c.Status)
+}
+
+// clusterEventSink writes events to the logical cluster recorded in their annotations.
+type clusterEventSink struct {
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface
+}
+
+var _ record.EventSink = &clusterEventSink{}
+
+func (s *clusterEventSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	clusterName, event := clusterOf(event)
+	return s.kubeClusterClient.Cluster(clusterName.Path()).CoreV1().Events(event.Namespace).CreateWithEventNamespace(event)
+}
+
+func (s *clusterEventSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	clusterName, event := clusterOf(event)
+	return s.kubeClusterClient.Cluster(clusterName.Path()).CoreV1().Events(event.Namespace).UpdateWithEventNamespace(event)
+}
+
+func (s *clusterEventSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	clusterName, event := clusterOf(event)
+	return s.kubeClusterClient.Cluster(clusterName.Path()).CoreV1().Events(event.Namespace).PatchWithEventNamespace(event, data)
+}
+
+// clusterOf returns the logical cluster of the event, and a copy of the event without the
+// annotation it is recorded in.
+func clusterOf(event *corev1.Event) (logicalcluster.Name, *corev1.Event) {
+	clusterName := logicalcluster.From(event)
+	event = event.DeepCopy()
+	delete(event.Annotations, logicalcluster.AnnotationKey)
+	if len(event.Annotations) == 0 {
+		event.Annotations = nil
+	}
+	return clusterName, event
+}
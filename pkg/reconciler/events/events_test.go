@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func TestConditionTransitions(t *testing.T) {
+	tests := map[string]struct {
+		old, new conditionsv1alpha1.Conditions
+		want     []string
+	}{
+		"unchanged": {
+			old: conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionTrue}},
+			new: conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionTrue}},
+		},
+		"message change only": {
+			old: conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionFalse, Reason: "Waiting", Message: "a"}},
+			new: conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionFalse, Reason: "Waiting", Message: "b"}},
+		},
+		"becomes true": {
+			old:  conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionFalse, Reason: "Waiting"}},
+			new:  conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionTrue}},
+			want: []string{"Normal Ready Ready is True"},
+		},
+		"new condition false with error severity": {
+			new: conditionsv1alpha1.Conditions{{
+				Type:     "BindingUpToDate",
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsv1alpha1.ConditionSeverityError,
+				Reason:   "NamingConflicts",
+				Message:  "conflict",
+			}},
+			want: []string{"Warning NamingConflicts BindingUpToDate: conflict"},
+		},
+		"reason change with info severity": {
+			old: conditionsv1alpha1.Conditions{{Type: "Ready", Status: corev1.ConditionFalse, Reason: "A"}},
+			new: conditionsv1alpha1.Conditions{{
+				Type:     "Ready",
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsv1alpha1.ConditionSeverityInfo,
+				Reason:   "B",
+				Message:  "waiting",
+			}},
+			want: []string{"Normal B Ready: waiting"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := record.NewFakeRecorder(10)
+			r := &Recorder{recorder: fake}
+
+			obj := &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "binding",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+			}
+			r.ConditionTransitions(obj, tc.old, tc.new)
+			close(fake.Events)
+
+			var got []string
+			for e := range fake.Events {
+				got = append(got, e)
+			}
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestClusterOf(t *testing.T) {
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "binding.123",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+		},
+	}
+
+	clusterName, copied := clusterOf(event)
+	require.Equal(t, logicalcluster.Name("root:org"), clusterName)
+	require.Nil(t, copied.Annotations)
+	require.Equal(t, "root:org", event.Annotations[logicalcluster.AnnotationKey], "the original event must not be mutated")
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubequota
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+	"k8s.io/klog/v2"
+)
+
+// boundResourceEvaluator is the object count evaluator of a resource bound in a logical cluster
+// through an APIBinding. It only counts the objects of the identity of the bound APIExport, so
+// that quotas like count/widgets.example.com cap the objects of the provider, and not the objects
+// left behind by another binding of the same group resource.
+type boundResourceEvaluator struct {
+	quota.Evaluator
+
+	identityHash string
+}
+
+// listBoundResourceFunc lists the objects of the given resource and identity in a namespace of
+// the logical cluster.
+type listBoundResourceFunc func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, identityHash, namespace string) ([]runtime.Object, error)
+
+// syncBoundResourceEvaluators adds to the registry of the quota controller of the logical cluster
+// the object count evaluators of the resources bound in the logical cluster, and removes the ones
+// of the resources not bound anymore.
+func (c *Controller) syncBoundResourceEvaluators(ctx context.Context, clusterName logicalcluster.Name, registry quota.Registry) error {
+	logger := klog.FromContext(ctx)
+
+	apiBindings, err := c.apiBindingLister.Cluster(clusterName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	bound := map[schema.GroupResource]*boundResourceEvaluator{}
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Schema.IdentityHash == "" || len(boundResource.StorageVersions) == 0 {
+				continue
+			}
+			gvr := schema.GroupVersionResource{
+				Group:    boundResource.Group,
+				Version:  boundResource.StorageVersions[len(boundResource.StorageVersions)-1],
+				Resource: boundResource.Resource,
+			}
+			identityHash := boundResource.Schema.IdentityHash
+			bound[gvr.GroupResource()] = &boundResourceEvaluator{
+				Evaluator: generic.NewObjectCountEvaluator(gvr.GroupResource(), func(namespace string) ([]runtime.Object, error) {
+					return c.listBoundResource(ctx, clusterName, gvr, identityHash, namespace)
+				}, ""),
+				identityHash: identityHash,
+			}
+		}
+	}
+
+	for _, evaluator := range registry.List() {
+		existing, ok := evaluator.(*boundResourceEvaluator)
+		if !ok {
+			continue
+		}
+		if desired, found := bound[existing.GroupResource()]; found && desired.identityHash == existing.identityHash {
+			delete(bound, existing.GroupResource())
+			continue
+		}
+		logger.V(2).Info("removing bound resource quota evaluator", "groupResource", existing.GroupResource(), "identity", existing.identityHash)
+		registry.Remove(existing)
+	}
+
+	for gr, evaluator := range bound {
+		logger.V(2).Info("adding bound resource quota evaluator", "groupResource", gr, "identity", evaluator.identityHash)
+		registry.Add(evaluator)
+	}
+
+	return nil
+}
+
+// listBoundResourceMetadata lists the partial metadata of the objects of the given resource and
+// identity, so that the objects of another identity are not counted.
+func (c *Controller) listBoundResourceMetadata(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, identityHash, namespace string) ([]runtime.Object, error) {
+	gvr.Resource += ":" + identityHash
+	list, err := c.metadataClusterClient.Cluster(clusterName.Path()).Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]runtime.Object, 0, len(list.Items))
+	for i := range list.Items {
+		objs = append(objs, &list.Items[i])
+	}
+	return objs, nil
+}
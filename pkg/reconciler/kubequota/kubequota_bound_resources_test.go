@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubequota
+
+import (
+	"context"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+)
+
+func TestSyncBoundResourceEvaluators(t *testing.T) {
+	widgets := schema.GroupResource{Group: "example.com", Resource: "widgets"}
+
+	newAPIBinding := func(identityHash string) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "widgets",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				BoundResources: []apisv1alpha1.BoundAPIResource{{
+					Group:           widgets.Group,
+					Resource:        widgets.Resource,
+					Schema:          apisv1alpha1.BoundAPIResourceSchema{Name: "v1.widgets.example.com", IdentityHash: identityHash},
+					StorageVersions: []string{"v1alpha1", "v1"},
+				}},
+			},
+		}
+	}
+
+	indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+	require.NoError(t, indexer.Add(newAPIBinding("provider")))
+
+	var listed []string
+	c := &Controller{
+		apiBindingLister: apisv1alpha1listers.NewAPIBindingClusterLister(indexer),
+		listBoundResource: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, identityHash, namespace string) ([]runtime.Object, error) {
+			require.Equal(t, logicalcluster.Name("root:consumer"), clusterName)
+			require.Equal(t, "v1", gvr.Version)
+			listed = append(listed, identityHash)
+			return []runtime.Object{
+				&metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: namespace}},
+				&metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: namespace}},
+			}, nil
+		},
+	}
+
+	// the generic evaluator registered by the monitors is replaced by the identity-aware one
+	registry := generic.NewRegistry([]quota.Evaluator{generic.NewObjectCountEvaluator(widgets, nil, "")})
+	ctx := context.Background()
+	require.NoError(t, c.syncBoundResourceEvaluators(ctx, "root:consumer", registry))
+
+	evaluator, ok := registry.Get(widgets).(*boundResourceEvaluator)
+	require.True(t, ok, "expected a bound resource evaluator")
+	require.Equal(t, "provider", evaluator.identityHash)
+
+	count := corev1.ResourceName("count/widgets.example.com")
+	stats, err := evaluator.UsageStats(quota.UsageStatsOptions{Namespace: "default", Resources: []corev1.ResourceName{count}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Used.Name(count, "").Value())
+	require.Equal(t, []string{"provider"}, listed)
+
+	// a binding to another export replaces the evaluator
+	require.NoError(t, indexer.Update(newAPIBinding("other")))
+	require.NoError(t, c.syncBoundResourceEvaluators(ctx, "root:consumer", registry))
+	evaluator, ok = registry.Get(widgets).(*boundResourceEvaluator)
+	require.True(t, ok, "expected a bound resource evaluator")
+	require.Equal(t, "other", evaluator.identityHash)
+
+	// unbound resources are not evaluated anymore
+	require.NoError(t, indexer.Delete(newAPIBinding("other")))
+	require.NoError(t, c.syncBoundResourceEvaluators(ctx, "root:consumer", registry))
+	require.Nil(t, registry.Get(widgets))
+}
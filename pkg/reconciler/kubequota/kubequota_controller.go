@@ -23,6 +23,7 @@ import (
 	"time"
 
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
 	kcpkubernetesinformers "github.com/kcp-dev/client-go/informers"
 	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
@@ -31,6 +32,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	quota "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/apiserver/pkg/quota/v1/generic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -40,10 +42,14 @@ import (
 	"k8s.io/kubernetes/pkg/controller/resourcequota"
 	"k8s.io/kubernetes/pkg/quota/v1/install"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpratelimiter "github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -60,6 +66,7 @@ type Controller struct {
 
 	dynamicDiscoverySharedInformerFactory *informer.DiscoveringDynamicSharedInformerFactory
 	kubeClusterClient                     kcpkubernetesclientset.ClusterInterface
+	metadataClusterClient                 kcpdynamic.ClusterInterface
 	informersStarted                      <-chan struct{}
 
 	// quotaRecalculationPeriod controls how often a full quota recalculation is performed
@@ -72,18 +79,23 @@ type Controller struct {
 	// lock guards the fields in this group
 	lock        sync.RWMutex
 	cancelFuncs map[logicalcluster.Name]func()
+	registries  map[logicalcluster.Name]quota.Registry
 
 	resourceQuotaClusterInformer        kcpcorev1informers.ResourceQuotaClusterInformer
 	scopingGenericSharedInformerFactory scopeableInformerFactory
+	apiBindingLister                    apisv1alpha1listers.APIBindingClusterLister
 
 	// For better testability
 	getLogicalCluster func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error)
+	listBoundResource listBoundResourceFunc
 }
 
 // NewController creates a new Controller.
 func NewController(
 	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	apiBindingInformer apisv1alpha1informers.APIBindingClusterInformer,
 	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	metadataClusterClient kcpdynamic.ClusterInterface,
 	kubeInformerFactory kcpkubernetesinformers.SharedInformerFactory,
 	dynamicDiscoverySharedInformerFactory *informer.DiscoveringDynamicSharedInformerFactory,
 	quotaRecalculationPeriod time.Duration,
@@ -92,10 +104,11 @@ func NewController(
 	informersStarted <-chan struct{},
 ) (*Controller, error) {
 	c := &Controller{
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		queue: workqueue.NewNamedRateLimitingQueue(kcpratelimiter.For(ControllerName), ControllerName),
 
 		dynamicDiscoverySharedInformerFactory: dynamicDiscoverySharedInformerFactory,
 		kubeClusterClient:                     kubeClusterClient,
+		metadataClusterClient:                 metadataClusterClient,
 		informersStarted:                      informersStarted,
 
 		quotaRecalculationPeriod: quotaRecalculationPeriod,
@@ -104,14 +117,17 @@ func NewController(
 		workersPerLogicalCluster: workersPerLogicalCluster,
 
 		cancelFuncs: map[logicalcluster.Name]func(){},
+		registries:  map[logicalcluster.Name]quota.Registry{},
 
 		scopingGenericSharedInformerFactory: dynamicDiscoverySharedInformerFactory,
 		resourceQuotaClusterInformer:        kubeInformerFactory.Core().V1().ResourceQuotas(),
+		apiBindingLister:                    apiBindingInformer.Lister(),
 
 		getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
 			return logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
 		},
 	}
+	c.listBoundResource = c.listBoundResourceMetadata
 
 	logicalClusterInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
@@ -123,9 +139,37 @@ func NewController(
 		},
 	)
 
+	// Bound resources are evaluated by the quota controller of the logical cluster of the APIBinding.
+	apiBindingInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueAPIBinding,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.enqueueAPIBinding(newObj)
+			},
+			DeleteFunc: c.enqueueAPIBinding,
+		},
+	)
+
 	return c, nil
 }
 
+// enqueueAPIBinding adds the key of the LogicalCluster of an APIBinding to the queue.
+func (c *Controller) enqueueAPIBinding(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+
+	key := kcpcache.ToClusterAwareKey(logicalcluster.From(apiBinding).String(), "", corev1alpha1.LogicalClusterName)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(2).Info("queueing LogicalCluster because of APIBinding", "APIBinding", apiBinding.Name)
+	c.queue.Add(key)
+}
+
 // enqueue adds the key for a ClusterWorkspace to the queue.
 func (c *Controller) enqueue(obj interface{}) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
@@ -213,6 +257,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 				cancel()
 				delete(c.cancelFuncs, clusterName)
 			}
+			delete(c.registries, clusterName)
 			c.lock.Unlock()
 
 			c.dynamicDiscoverySharedInformerFactory.Unsubscribe("quota-" + clusterName.String())
@@ -230,7 +275,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	_, found := c.cancelFuncs[clusterName]
 	if found {
 		logger.V(4).Info("quota controller already exists")
-		return nil
+		return c.syncBoundResourceEvaluators(ctx, clusterName, c.registries[clusterName])
 	}
 
 	logger.V(2).Info("starting quota controller")
@@ -241,6 +286,8 @@ func (c *Controller) process(ctx context.Context, key string) error {
 
 	if err := c.startQuotaForClusterWorkspace(ctx, clusterName); err != nil {
 		cancel()
+		delete(c.cancelFuncs, clusterName)
+		delete(c.registries, clusterName)
 		return fmt.Errorf("error starting quota controller for cluster %q: %w", clusterName, err)
 	}
 
@@ -257,6 +304,15 @@ func (c *Controller) startQuotaForClusterWorkspace(ctx context.Context, clusterN
 	// quotaConfiguration := install.NewQuotaConfigurationForControllers(listerFuncForResource)
 	quotaConfiguration := generic.NewConfiguration(nil, install.DefaultIgnoredResources())
 
+	// The registry is shared with the monitors of the resource quota controller, which register
+	// generic evaluators for the resources without one, so that the evaluators of the bound
+	// resources take precedence as soon as they are added.
+	registry := generic.NewRegistry(quotaConfiguration.Evaluators())
+	c.registries[clusterName] = registry
+	if err := c.syncBoundResourceEvaluators(ctx, clusterName, registry); err != nil {
+		return err
+	}
+
 	resourceQuotaControllerOptions := &resourcequota.ControllerOptions{
 		QuotaClient:           resourceQuotaControllerClient.CoreV1(),
 		ResourceQuotaInformer: c.resourceQuotaClusterInformer.Cluster(clusterName),
@@ -268,7 +324,7 @@ func (c *Controller) startQuotaForClusterWorkspace(ctx context.Context, clusterN
 		DiscoveryFunc:        c.dynamicDiscoverySharedInformerFactory.ServerPreferredResources,
 		IgnoredResourcesFunc: quotaConfiguration.IgnoredResources,
 		InformersStarted:     c.informersStarted,
-		Registry:             generic.NewRegistry(quotaConfiguration.Evaluators()),
+		Registry:             registry,
 		ClusterName:          clusterName,
 	}
 	if resourceQuotaControllerClient.CoreV1().RESTClient().GetRateLimiter() != nil {
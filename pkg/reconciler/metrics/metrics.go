@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics supplies the metrics common to all the controllers. Next to the reconcile
+// metrics recorded with ObserveReconcile, importing this package registers the workqueue
+// metrics provider, so that the named queues of the controllers expose their depth, adds,
+// retries, and queue and work durations, labeled by controller name.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	_ "k8s.io/component-base/metrics/prometheus/workqueue" // register the workqueue metrics provider
+)
+
+const (
+	// ReconcilesMetricName is the name of the counter of reconciliations.
+	ReconcilesMetricName = "controller_reconcile_total"
+	// ReconcileDurationMetricName is the name of the histogram of reconciliation durations.
+	ReconcileDurationMetricName = "controller_reconcile_duration_seconds"
+	// LastSuccessfulReconcileMetricName is the name of the gauge of the time of the last successful reconciliation.
+	LastSuccessfulReconcileMetricName = "controller_last_successful_reconcile_timestamp_seconds"
+
+	// ControllerLabel is the label identifying the controller, with the same value as the
+	// name label of the workqueue metrics.
+	ControllerLabel = "controller"
+	// ResultLabel is the label of the result of a reconciliation.
+	ResultLabel = "result"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+var (
+	reconciles = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           ReconcilesMetricName,
+			Help:           "Number of reconciliations of queue keys per controller and result.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{ControllerLabel, ResultLabel},
+	)
+
+	reconcileLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Name: ReconcileDurationMetricName,
+			Help: "Duration distribution in seconds of the reconciliations of queue keys per controller.",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5,
+				10, 30, 60},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{ControllerLabel},
+	)
+
+	lastSuccessfulReconcile = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           LastSuccessfulReconcileMetricName,
+			Help:           "Unix time in seconds of the last successful reconciliation of a queue key per controller.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{ControllerLabel},
+	)
+)
+
+var (
+	lock                     sync.RWMutex
+	lastSuccessfulReconciles = map[string]time.Time{}
+)
+
+var registerMetrics sync.Once
+
+// Register metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(reconciles)
+		legacyregistry.MustRegister(reconcileLatencies)
+		legacyregistry.MustRegister(lastSuccessfulReconcile)
+	})
+}
+
+func init() {
+	Register()
+}
+
+// ObserveReconcile records the reconciliation of a queue key by the controller, started at start
+// and completed with err. The controller name must be the name of its queue.
+func ObserveReconcile(controller string, start time.Time, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	now := time.Now()
+	reconciles.WithLabelValues(controller, result).Inc()
+	reconcileLatencies.WithLabelValues(controller).Observe(now.Sub(start).Seconds())
+
+	if err == nil {
+		lastSuccessfulReconcile.WithLabelValues(controller).Set(float64(now.Unix()))
+
+		lock.Lock()
+		defer lock.Unlock()
+		lastSuccessfulReconciles[controller] = now
+	}
+}
+
+// LastSuccessfulReconcile returns the time of the last successful reconciliation observed for the
+// controller, if any.
+func LastSuccessfulReconcile(controller string) (time.Time, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	t, ok := lastSuccessfulReconciles[controller]
+	return t, ok
+}
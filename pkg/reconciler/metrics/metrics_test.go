@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func TestRegisteredMetrics(t *testing.T) {
+	const controller = "kcp-test-metrics"
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controller)
+	queue.AddRateLimited("key")
+	queue.ShutDown()
+
+	start := time.Now()
+	ObserveReconcile(controller, start, nil)
+	ObserveReconcile(controller, start, errors.New("failed"))
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	// labels returns the label sets of the series of the family for the controller.
+	labels := func(family *dto.MetricFamily, controllerLabel string) []map[string]string {
+		var series []map[string]string
+		for _, m := range family.GetMetric() {
+			set := map[string]string{}
+			for _, label := range m.GetLabel() {
+				set[label.GetName()] = label.GetValue()
+			}
+			if set[controllerLabel] == controller {
+				series = append(series, set)
+			}
+		}
+		return series
+	}
+
+	got := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		got[family.GetName()] = family
+	}
+
+	tests := map[string]struct {
+		kind   dto.MetricType
+		series []map[string]string
+		label  string
+	}{
+		ReconcilesMetricName: {
+			kind:  dto.MetricType_COUNTER,
+			label: ControllerLabel,
+			series: []map[string]string{
+				{ControllerLabel: controller, ResultLabel: ResultError},
+				{ControllerLabel: controller, ResultLabel: ResultSuccess},
+			},
+		},
+		ReconcileDurationMetricName: {
+			kind:   dto.MetricType_HISTOGRAM,
+			label:  ControllerLabel,
+			series: []map[string]string{{ControllerLabel: controller}},
+		},
+		LastSuccessfulReconcileMetricName: {
+			kind:   dto.MetricType_GAUGE,
+			label:  ControllerLabel,
+			series: []map[string]string{{ControllerLabel: controller}},
+		},
+		"workqueue_adds_total": {
+			kind:   dto.MetricType_COUNTER,
+			label:  "name",
+			series: []map[string]string{{"name": controller}},
+		},
+		"workqueue_depth": {
+			kind:   dto.MetricType_GAUGE,
+			label:  "name",
+			series: []map[string]string{{"name": controller}},
+		},
+		"workqueue_retries_total": {
+			kind:   dto.MetricType_COUNTER,
+			label:  "name",
+			series: []map[string]string{{"name": controller}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			family, ok := got[name]
+			require.True(t, ok, "metric %s is not registered", name)
+			require.Equal(t, tc.kind, family.GetType())
+			require.Equal(t, tc.series, labels(family, tc.label))
+		})
+	}
+
+	last, ok := LastSuccessfulReconcile(controller)
+	require.True(t, ok)
+	require.False(t, last.Before(start))
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package priorityqueue supplies a workqueue with two priorities, so that controllers process
+// the keys of user-facing changes ahead of the keys of periodic resyncs or of objects replicated
+// from other shards.
+package priorityqueue
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+)
+
+// Priority is the priority of a key added to the queue.
+type Priority int
+
+const (
+	// Normal is the priority of the keys of user-facing changes. It is the priority of Add.
+	Normal Priority = iota
+	// Low is the priority of the keys of resyncs and of replicated objects. They are only
+	// handed to the workers when no key of normal priority is waiting.
+	Low
+)
+
+// Interface is a rate limiting workqueue with two priorities.
+type Interface interface {
+	workqueue.RateLimitingInterface
+	// AddWithPriority adds an item with the given priority. Adding an item of low priority with
+	// normal priority promotes it.
+	AddWithPriority(item interface{}, priority Priority)
+}
+
+// queue stages the items of low priority in front of a workqueue, and moves them into the
+// workqueue for the workers waiting for items, once no item of normal priority is left. The
+// workqueue is kept as the single source of the items handed to the workers, so that an item
+// is never processed concurrently, and so that the named workqueue metrics cover both
+// priorities.
+type queue struct {
+	workqueue.RateLimitingInterface
+
+	rateLimiter workqueue.RateLimiter
+	clock       clock.WithDelayedExecution
+
+	lock sync.Mutex
+	// low are the items of low priority, in order, which are not in the workqueue yet.
+	low    *list.List
+	staged map[interface{}]*list.Element
+	// fed are the items of low priority moved into the workqueue, and not handed to a worker yet.
+	fed map[interface{}]bool
+	// processing are the priorities of the items handed to the workers, so that they are kept
+	// when the items are added again with AddRateLimited or AddAfter.
+	processing map[interface{}]Priority
+	// idle is the number of workers waiting for an item.
+	idle int
+}
+
+// NewNamedRateLimitingQueue returns a workqueue with two priorities, whose metrics are reported
+// under name.
+func NewNamedRateLimitingQueue(rateLimiter workqueue.RateLimiter, name string) Interface {
+	return &queue{
+		RateLimitingInterface: workqueue.NewNamedRateLimitingQueue(rateLimiter, name),
+		rateLimiter:           rateLimiter,
+		clock:                 clock.RealClock{},
+		low:                   list.New(),
+		staged:                map[interface{}]*list.Element{},
+		fed:                   map[interface{}]bool{},
+		processing:            map[interface{}]Priority{},
+	}
+}
+
+// Add adds an item with normal priority.
+func (q *queue) Add(item interface{}) {
+	q.AddWithPriority(item, Normal)
+}
+
+func (q *queue) AddWithPriority(item interface{}, priority Priority) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.RateLimitingInterface.ShuttingDown() {
+		return
+	}
+
+	if priority == Normal {
+		if e, ok := q.staged[item]; ok {
+			q.low.Remove(e)
+			delete(q.staged, item)
+		}
+		delete(q.fed, item)
+		q.RateLimitingInterface.Add(item)
+		return
+	}
+
+	if _, ok := q.staged[item]; ok {
+		return
+	}
+	q.staged[item] = q.low.PushBack(item)
+	q.feedLocked()
+}
+
+// AddAfter adds an item after the given duration, with the priority it was handed to the worker
+// with, or it is staged with, and normal priority otherwise.
+func (q *queue) AddAfter(item interface{}, duration time.Duration) {
+	if q.priority(item) == Normal {
+		q.RateLimitingInterface.AddAfter(item, duration)
+		return
+	}
+	if duration <= 0 {
+		q.AddWithPriority(item, Low)
+		return
+	}
+	q.clock.AfterFunc(duration, func() {
+		q.AddWithPriority(item, Low)
+	})
+}
+
+// AddRateLimited adds an item after the rate limiter says it is ok, with the priority it was
+// handed to the worker with, or it is staged with, and normal priority otherwise.
+func (q *queue) AddRateLimited(item interface{}) {
+	if q.priority(item) == Normal {
+		q.RateLimitingInterface.AddRateLimited(item)
+		return
+	}
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *queue) priority(item interface{}) Priority {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.staged[item]; ok || q.fed[item] {
+		return Low
+	}
+	if priority, ok := q.processing[item]; ok {
+		return priority
+	}
+	return Normal
+}
+
+// Get hands the next item to a worker. The workqueue is fed with items of low priority while
+// there are more workers waiting than items in the workqueue.
+func (q *queue) Get() (interface{}, bool) {
+	q.lock.Lock()
+	q.idle++
+	q.feedLocked()
+	q.lock.Unlock()
+
+	item, shutdown := q.RateLimitingInterface.Get()
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.idle--
+	if !shutdown {
+		priority := Normal
+		if q.fed[item] {
+			priority = Low
+			delete(q.fed, item)
+		}
+		q.processing[item] = priority
+	}
+	return item, shutdown
+}
+
+// Done marks the item as processed, and feeds the workqueue with items of low priority for the
+// workers waiting for items.
+func (q *queue) Done(item interface{}) {
+	q.RateLimitingInterface.Done(item)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	delete(q.processing, item)
+	q.feedLocked()
+}
+
+// Len returns the number of items waiting, of both priorities.
+func (q *queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.RateLimitingInterface.Len() + q.low.Len()
+}
+
+func (q *queue) ShutDown() {
+	q.lock.Lock()
+	q.dropStagedLocked()
+	q.lock.Unlock()
+
+	q.RateLimitingInterface.ShutDown()
+}
+
+func (q *queue) ShutDownWithDrain() {
+	q.lock.Lock()
+	q.dropStagedLocked()
+	q.lock.Unlock()
+
+	q.RateLimitingInterface.ShutDownWithDrain()
+}
+
+func (q *queue) dropStagedLocked() {
+	q.low.Init()
+	q.staged = map[interface{}]*list.Element{}
+}
+
+// feedLocked moves items of low priority into the workqueue, up to the number of workers
+// waiting for items. Adding to the workqueue wakes the waiting workers.
+func (q *queue) feedLocked() {
+	if q.RateLimitingInterface.ShuttingDown() {
+		return
+	}
+	for q.low.Len() > 0 && q.RateLimitingInterface.Len() < q.idle {
+		item := q.low.Remove(q.low.Front())
+		delete(q.staged, item)
+		q.fed[item] = true
+		q.RateLimitingInterface.Add(item)
+	}
+}
+
+// UpdatePriority returns the priority of an update event: Low for the periodic resyncs of an
+// informer, which deliver an object with an unchanged resource version, Normal otherwise.
+func UpdatePriority(old, obj interface{}) Priority {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	oldMeta, err := meta.Accessor(old)
+	if err != nil {
+		return Normal
+	}
+	newMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return Normal
+	}
+	if oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+		return Low
+	}
+	return Normal
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorityqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "")
+	defer q.ShutDown()
+
+	get := func() interface{} {
+		item, shutdown := q.Get()
+		require.False(t, shutdown)
+		q.Done(item)
+		return item
+	}
+
+	q.AddWithPriority("resync-a", Low)
+	q.AddWithPriority("resync-b", Low)
+	q.AddWithPriority("resync-c", Low)
+	q.AddWithPriority("resync-b", Low)
+	q.Add("change-a")
+	require.Equal(t, 4, q.Len())
+
+	require.Equal(t, "change-a", get())
+	require.Equal(t, "resync-a", get())
+
+	// adding an item with normal priority promotes it.
+	q.Add("change-b")
+	q.Add("resync-c")
+	require.Equal(t, "change-b", get())
+	require.Equal(t, "resync-c", get())
+	require.Equal(t, "resync-b", get())
+	require.Equal(t, 0, q.Len())
+
+	// an item is not processed twice concurrently.
+	q.Add("change-c")
+	item, _ := q.Get()
+	require.Equal(t, "change-c", item)
+	q.AddWithPriority("change-c", Low)
+	require.Equal(t, 1, q.Len())
+	q.Done(item)
+	require.Equal(t, "change-c", get())
+	require.Equal(t, 0, q.Len())
+}
+
+func TestQueueWakesWaitingWorkers(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "")
+	defer q.ShutDown()
+
+	const workers = 3
+	items := make(chan interface{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, shutdown := q.Get()
+			if !shutdown {
+				items <- item
+			}
+		}()
+	}
+	require.Eventually(t, func() bool {
+		q.(*queue).lock.Lock()
+		defer q.(*queue).lock.Unlock()
+		return q.(*queue).idle == workers
+	}, wait.ForeverTestTimeout, time.Millisecond)
+
+	// every waiting worker gets an item of low priority.
+	for i := 0; i < workers; i++ {
+		q.AddWithPriority(fmt.Sprintf("resync-%d", i), Low)
+	}
+	wg.Wait()
+	close(items)
+	var got []interface{}
+	for item := range items {
+		got = append(got, item)
+	}
+	require.ElementsMatch(t, []interface{}{"resync-0", "resync-1", "resync-2"}, got)
+}
+
+func TestQueueRequeueKeepsPriority(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.NewItemFastSlowRateLimiter(0, 0, 0), "").(*queue)
+	defer q.ShutDown()
+	clock := clocktesting.NewFakeClock(time.Now())
+	q.clock = clock
+
+	q.AddWithPriority("resync", Low)
+	item, _ := q.Get()
+	require.Equal(t, "resync", item)
+	q.AddRateLimited(item)
+	q.Done(item)
+	q.Add("change")
+
+	// the requeued item is handed over after the items of normal priority.
+	require.Equal(t, 2, q.Len())
+	item, _ = q.Get()
+	require.Equal(t, "change", item)
+	q.Done(item)
+	item, _ = q.Get()
+	require.Equal(t, "resync", item)
+
+	// delayed items of low priority are staged once the delay is over.
+	q.AddAfter(item, time.Second)
+	q.Done(item)
+	require.Equal(t, 0, q.Len())
+	clock.Step(time.Second)
+	require.Equal(t, 1, q.Len())
+	require.Len(t, q.staged, 1)
+}
+
+func TestUpdatePriority(t *testing.T) {
+	obj := func(rv string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", ResourceVersion: rv}}
+	}
+
+	require.Equal(t, Low, UpdatePriority(obj("1"), obj("1")))
+	require.Equal(t, Normal, UpdatePriority(obj("1"), obj("2")))
+	require.Equal(t, Normal, UpdatePriority("invalid", obj("2")))
+}
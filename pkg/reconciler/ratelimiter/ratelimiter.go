@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimiter supplies the rate limiters of the workqueues of the controllers, whose
+// overall rate can be configured per controller.
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Limit is the overall rate limit of the workqueue of a controller, applying on top of the
+// per-item exponential backoff of the failed keys.
+type Limit struct {
+	QPS   float64
+	Burst int
+}
+
+var (
+	lock   sync.RWMutex
+	limits = map[string]Limit{}
+)
+
+// SetLimits overrides the overall rate limits of the workqueues of the given controllers, by
+// controller name. It must be called before the controllers are created.
+func SetLimits(l map[string]Limit) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	limits = make(map[string]Limit, len(l))
+	for name, limit := range l {
+		limits[name] = limit
+	}
+}
+
+// For returns the rate limiter of the workqueue of the named controller. It is the default
+// controller rate limiter, i.e. an exponential backoff per item from 5ms to 1000s, and an overall
+// limit of 10 qps with a burst of 100, unless the overall limit is overridden by SetLimits.
+func For(controllerName string) workqueue.RateLimiter {
+	lock.RLock()
+	limit, ok := limits[controllerName]
+	lock.RUnlock()
+
+	if !ok {
+		return workqueue.DefaultControllerRateLimiter()
+	}
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(limit.QPS), limit.Burst)},
+	)
+}
+
+// ParseLimit parses a limit of the form <qps>:<burst>, e.g. 50:500.
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <qps>:<burst>", s)
+	}
+	qps, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || qps <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, the qps must be a positive number", s)
+	}
+	burst, err := strconv.Atoi(parts[1])
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, the burst must be a positive integer", s)
+	}
+	return Limit{QPS: qps, Burst: burst}, nil
+}
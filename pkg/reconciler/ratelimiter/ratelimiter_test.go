@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("50:500")
+	require.NoError(t, err)
+	require.Equal(t, Limit{QPS: 50, Burst: 500}, limit)
+
+	limit, err = ParseLimit("0.5:1")
+	require.NoError(t, err)
+	require.Equal(t, Limit{QPS: 0.5, Burst: 1}, limit)
+
+	for _, s := range []string{"", "50", "50:", ":500", "a:500", "50:b", "0:500", "50:0", "50:500:1"} {
+		_, err := ParseLimit(s)
+		require.Error(t, err, "expected an error for %q", s)
+	}
+}
+
+func TestFor(t *testing.T) {
+	t.Cleanup(func() { SetLimits(nil) })
+	SetLimits(map[string]Limit{"limited": {QPS: 1, Burst: 1}})
+
+	t.Log("The overall limit applies to the overridden controllers")
+	limited := For("limited")
+	require.Equal(t, 5*time.Millisecond, limited.When("a"), "the first item only waits the initial backoff")
+	require.InDelta(t, float64(time.Second), float64(limited.When("b")), float64(100*time.Millisecond))
+
+	t.Log("The other controllers keep the default limit, with a burst of 100")
+	other := For("other")
+	for i := 0; i < 100; i++ {
+		require.Equal(t, 5*time.Millisecond, other.When(i))
+	}
+}
@@ -32,6 +32,10 @@ import (
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
+// maxUnavailableInstances is the maximum number of unavailable instances sampled in the
+// status of a Location.
+const maxUnavailableInstances = 5
+
 type reconcileStatus int
 
 const (
@@ -48,6 +52,7 @@ type statusReconciler struct {
 	listSyncTargets func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
 	updateLocation  func(ctx context.Context, clusterName logicalcluster.Path, location *schedulingv1alpha1.Location) (*schedulingv1alpha1.Location, error)
 	enqueueAfter    func(*schedulingv1alpha1.Location, time.Duration)
+	now             func() time.Time
 }
 
 func (r *statusReconciler) reconcile(ctx context.Context, location *schedulingv1alpha1.Location) (reconcileStatus, error) {
@@ -87,9 +92,44 @@ func (r *statusReconciler) reconcile(ctx context.Context, location *schedulingv1
 	if err != nil {
 		return reconcileStatusStop, err
 	}
-	available := len(FilterReady(locationClusters))
+	sort.Slice(locationClusters, func(i, j int) bool {
+		return locationClusters[i].Name < locationClusters[j].Name
+	})
+	now := r.now()
+	var available uint32
+	var unavailable []schedulingv1alpha1.UnavailableInstance
+	var requeueAfter time.Duration
+	for _, syncTarget := range locationClusters {
+		// requeue to account for the maintenance windows of the sync target
+		if d := syncTarget.NextMaintenanceTransition(now); d > 0 && (requeueAfter == 0 || d < requeueAfter) {
+			requeueAfter = d
+		}
+		reason, message := Unavailability(syncTarget, now)
+		if reason == "" {
+			available++
+			// requeue to account for the eviction of the sync target
+			if evictAfter := syncTarget.Spec.EvictAfter; evictAfter != nil {
+				if d := evictAfter.Time.Sub(now); requeueAfter == 0 || d < requeueAfter {
+					requeueAfter = d
+				}
+			}
+			continue
+		}
+		if len(unavailable) < maxUnavailableInstances {
+			unavailable = append(unavailable, schedulingv1alpha1.UnavailableInstance{
+				Name:    syncTarget.Name,
+				Reason:  reason,
+				Message: message,
+			})
+		}
+	}
 	location.Status.Instances = uint32Ptr(uint32(len(locationClusters)))
-	location.Status.AvailableInstances = uint32Ptr(uint32(available))
+	location.Status.AvailableInstances = uint32Ptr(available)
+	location.Status.UnavailableInstances = unavailable
+
+	if requeueAfter > 0 {
+		r.enqueueAfter(location, requeueAfter)
+	}
 
 	return reconcileStatusContinue, nil
 }
@@ -104,6 +144,7 @@ func (c *controller) reconcile(ctx context.Context, location *schedulingv1alpha1
 			listSyncTargets: c.listSyncTarget,
 			updateLocation:  c.updateLocation,
 			enqueueAfter:    c.enqueueAfter,
+			now:             time.Now,
 		},
 	}
 
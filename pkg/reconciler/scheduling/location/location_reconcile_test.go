@@ -50,6 +50,13 @@ func instances(expected uint32) func(t *testing.T, l *schedulingv1alpha1.Locatio
 	}
 }
 
+func unavailableInstances(expected ...schedulingv1alpha1.UnavailableInstance) func(t *testing.T, l *schedulingv1alpha1.Location) {
+	return func(t *testing.T, got *schedulingv1alpha1.Location) {
+		t.Helper()
+		require.Equal(t, expected, got.Status.UnavailableInstances)
+	}
+}
+
 func labelString(expected string) func(t *testing.T, l *schedulingv1alpha1.Location) {
 	return func(t *testing.T, got *schedulingv1alpha1.Location) {
 		t.Helper()
@@ -93,6 +100,8 @@ func TestLocationStatusReconciler(t *testing.T) {
 			InstanceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}},
 		},
 	}
+	now := time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
 	usEast1WithoutLabelString := usEast1.DeepCopy()
 	usEast1WithoutLabelString.Annotations = nil
 
@@ -139,8 +148,42 @@ func TestLocationStatusReconciler(t *testing.T) {
 					cluster("us-east1-2"),
 				},
 			},
-			wantLocation:        and(availableInstances(1), instances(4)),
+			wantLocation: and(availableInstances(1), instances(4), unavailableInstances(
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-1", Reason: "NotReady", Message: "The SyncTarget has not reported its readiness yet"},
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-2", Reason: "NotReady"},
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-4", Reason: "Unschedulable", Message: "The SyncTarget is marked unschedulable"},
+			)),
+			wantReconcileStatus: reconcileStatusContinue,
+		},
+		"with sync targets, not ready, evicted and to be evicted": {
+			location: usEast1,
+			syncTargets: map[logicalcluster.Path][]*workloadv1alpha1.SyncTarget{
+				logicalcluster.NewPath("root:org:negotiation-workspace"): {
+					withLabels(withConditions(cluster("us-east1-1"), conditionsv1alpha1.Condition{Type: "Ready", Status: "False", Reason: "ErrorHeartbeat", Message: "No heartbeat since 2022-12-01 00:00:00"}), map[string]string{"region": "us-east1"}),
+					withLabels(evictAfter(withConditions(cluster("us-east1-2"), conditionsv1alpha1.Condition{Type: "Ready", Status: "True"}), now.Add(-time.Minute)), map[string]string{"region": "us-east1"}),
+					withLabels(evictAfter(withConditions(cluster("us-east1-3"), conditionsv1alpha1.Condition{Type: "Ready", Status: "True"}), now.Add(time.Minute)), map[string]string{"region": "us-east1"}),
+				},
+			},
+			wantLocation: and(availableInstances(1), instances(3), unavailableInstances(
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-1", Reason: "ErrorHeartbeat", Message: "No heartbeat since 2022-12-01 00:00:00"},
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-2", Reason: "Evicting", Message: "The SyncTarget is evicting since " + now.Add(-time.Minute).Format(time.RFC3339)},
+			)),
 			wantReconcileStatus: reconcileStatusContinue,
+			wantRequeue:         time.Minute,
+		},
+		"with sync targets in and before maintenance": {
+			location: usEast1,
+			syncTargets: map[logicalcluster.Path][]*workloadv1alpha1.SyncTarget{
+				logicalcluster.NewPath("root:org:negotiation-workspace"): {
+					withLabels(maintenance(withConditions(cluster("us-east1-1"), conditionsv1alpha1.Condition{Type: "Ready", Status: "False"}), now.Add(-time.Minute), time.Hour, "kernel upgrade"), map[string]string{"region": "us-east1"}),
+					withLabels(maintenance(withConditions(cluster("us-east1-2"), conditionsv1alpha1.Condition{Type: "Ready", Status: "True"}), now.Add(30*time.Minute), time.Hour, ""), map[string]string{"region": "us-east1"}),
+				},
+			},
+			wantLocation: and(availableInstances(1), instances(2), unavailableInstances(
+				schedulingv1alpha1.UnavailableInstance{Name: "us-east1-1", Reason: "Maintenance", Message: "The SyncTarget is in maintenance until " + now.Add(59*time.Minute).Format(time.RFC3339) + ": kernel upgrade"},
+			)),
+			wantReconcileStatus: reconcileStatusContinue,
+			wantRequeue:         30 * time.Minute,
 		},
 	}
 
@@ -165,6 +208,7 @@ func TestLocationStatusReconciler(t *testing.T) {
 				enqueueAfter: func(domain *schedulingv1alpha1.Location, duration time.Duration) {
 					requeuedAfter = duration
 				},
+				now: func() time.Time { return now },
 			}
 
 			location := tc.location.DeepCopy()
@@ -218,6 +262,20 @@ func unschedulable(cluster *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncT
 	return cluster
 }
 
+func evictAfter(cluster *workloadv1alpha1.SyncTarget, t time.Time) *workloadv1alpha1.SyncTarget {
+	cluster.Spec.EvictAfter = &metav1.Time{Time: t}
+	return cluster
+}
+
+func maintenance(cluster *workloadv1alpha1.SyncTarget, start time.Time, duration time.Duration, reason string) *workloadv1alpha1.SyncTarget {
+	cluster.Spec.MaintenanceWindows = append(cluster.Spec.MaintenanceWindows, workloadv1alpha1.MaintenanceWindow{
+		Start:    metav1.Time{Time: start},
+		Duration: metav1.Duration{Duration: duration},
+		Reason:   reason,
+	})
+	return cluster
+}
+
 func toYaml(obj interface{}) string {
 	bytes, err := yaml.Marshal(obj)
 	if err != nil {
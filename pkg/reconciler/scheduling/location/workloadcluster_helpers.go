@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -57,6 +58,37 @@ func FilterReady(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1
 	return ready
 }
 
+// Unavailability returns the reason and the message why the sync target is not available for
+// scheduling at the given time, or an empty reason if it is available. A sync target in a maintenance
+// window, and not evicting, is unavailable with the workloadv1alpha1.MaintenanceReason reason, whatever
+// its readiness.
+func Unavailability(syncTarget *workloadv1alpha1.SyncTarget, now time.Time) (reason, message string) {
+	evicting := syncTarget.Spec.EvictAfter != nil && !now.Before(syncTarget.Spec.EvictAfter.Time)
+	if window := syncTarget.ActiveMaintenanceWindow(now); window != nil && !evicting {
+		message = fmt.Sprintf("The SyncTarget is in maintenance until %s", window.Start.Add(window.Duration.Duration).Format(time.RFC3339))
+		if window.Reason != "" {
+			message += ": " + window.Reason
+		}
+		return workloadv1alpha1.MaintenanceReason, message
+	}
+	if ready := conditions.Get(syncTarget, conditionsv1alpha1.ReadyCondition); ready == nil {
+		return "NotReady", "The SyncTarget has not reported its readiness yet"
+	} else if ready.Status != corev1.ConditionTrue {
+		reason = ready.Reason
+		if reason == "" {
+			reason = "NotReady"
+		}
+		return reason, ready.Message
+	}
+	if syncTarget.Spec.Unschedulable {
+		return "Unschedulable", "The SyncTarget is marked unschedulable"
+	}
+	if evicting {
+		return "Evicting", fmt.Sprintf("The SyncTarget is evicting since %s", syncTarget.Spec.EvictAfter.Time.Format(time.RFC3339))
+	}
+	return "", ""
+}
+
 // FilterNonEvicting filters out the evicting sync targets.
 func FilterNonEvicting(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1.SyncTarget {
 	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
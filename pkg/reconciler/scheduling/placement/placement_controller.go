@@ -26,6 +26,7 @@ import (
 	jsonpatch "github.com/evanphx/json-patch"
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	corev1listers "github.com/kcp-dev/client-go/listers/core/v1"
 
 	corev1 "k8s.io/api/core/v1"
@@ -47,6 +48,9 @@ import (
 	schedulingv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -57,12 +61,13 @@ const (
 // NewController returns a new controller placing namespaces onto locations by create
 // a placement annotation..
 func NewController(
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
 	kcpClusterClient kcpclientset.ClusterInterface,
 	namespaceInformer kcpcorev1informers.NamespaceClusterInformer,
 	locationInformer schedulingv1alpha1informers.LocationClusterInformer,
 	placementInformer schedulingv1alpha1informers.PlacementClusterInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue: queue,
@@ -75,6 +80,7 @@ func NewController(
 			queue.AddAfter(key, duration)
 		},
 		kcpClusterClient: kcpClusterClient,
+		recorder:         events.NewRecorder(kubeClusterClient, ControllerName),
 
 		namespaceLister: namespaceInformer.Lister(),
 
@@ -151,6 +157,7 @@ type controller struct {
 	enqueueAfter func(*corev1.Namespace, time.Duration)
 
 	kcpClusterClient kcpclientset.ClusterInterface
+	recorder         *events.Recorder
 
 	namespaceLister corev1listers.NamespaceClusterLister
 
@@ -247,6 +254,8 @@ func (c *controller) Start(ctx context.Context, numThreads int) {
 	logger.Info("Starting controller")
 	defer logger.Info("Shutting down controller")
 
+	c.recorder.Start(ctx)
+
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
 	}
@@ -275,7 +284,10 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -333,6 +345,9 @@ func (c *controller) process(ctx context.Context, key string) error {
 		}
 		logger.V(2).Info("patching placement", "patch", string(patchBytes))
 		_, uerr := c.kcpClusterClient.Cluster(clusterName.Path()).SchedulingV1alpha1().Placements().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		if uerr == nil {
+			RecordDecisionEvents(c.recorder, obj, old.Status.Decisions, obj.Status.Decisions)
+		}
 		return uerr
 	}
 
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+)
+
+const (
+	// maxDecisions is the maximum number of decisions kept in the status of a Placement.
+	maxDecisions = 10
+
+	// maxRejections is the maximum number of rejected candidates listed in a decision.
+	maxRejections = 10
+)
+
+// RecordDecision appends the decision to the history of the decisions of the placement, unless
+// it is the same as the last decision made at the same stage. The rejected candidates of the
+// decision and the history are truncated to their maximum lengths.
+func RecordDecision(placement *schedulingv1alpha1.Placement, decision schedulingv1alpha1.PlacementDecision) {
+	if len(decision.Rejected) > maxRejections {
+		decision.Rejected = decision.Rejected[:maxRejections]
+	}
+
+	for i := len(placement.Status.Decisions) - 1; i >= 0; i-- {
+		last := placement.Status.Decisions[i]
+		if last.Stage != decision.Stage {
+			continue
+		}
+		last.Time = decision.Time
+		if equality.Semantic.DeepEqual(last, decision) {
+			return
+		}
+		break
+	}
+
+	if decision.Time.IsZero() {
+		decision.Time = metav1.Now()
+	}
+	placement.Status.Decisions = append(placement.Status.Decisions, decision)
+	if len(placement.Status.Decisions) > maxDecisions {
+		placement.Status.Decisions = placement.Status.Decisions[len(placement.Status.Decisions)-maxDecisions:]
+	}
+}
+
+// RecordDecisionEvents records an event for each decision in new that is not in old, i.e. the
+// decisions recorded since old: a Normal event when a candidate is selected, and a Warning event
+// otherwise.
+func RecordDecisionEvents(recorder *events.Recorder, placement *schedulingv1alpha1.Placement, old, new []schedulingv1alpha1.PlacementDecision) {
+	for _, decision := range newDecisions(old, new) {
+		eventType := corev1.EventTypeWarning
+		if decision.Selected != "" {
+			eventType = corev1.EventTypeNormal
+		}
+		recorder.Eventf(placement, eventType, decision.Reason, "%s", decisionMessage(decision))
+	}
+}
+
+// newDecisions returns the decisions of new recorded after the last decision of old.
+func newDecisions(old, new []schedulingv1alpha1.PlacementDecision) []schedulingv1alpha1.PlacementDecision {
+	if len(old) == 0 {
+		return new
+	}
+	last := old[len(old)-1]
+	for i := len(new) - 1; i >= 0; i-- {
+		if equality.Semantic.DeepEqual(new[i], last) {
+			return new[i+1:]
+		}
+	}
+	return new
+}
+
+func decisionMessage(decision schedulingv1alpha1.PlacementDecision) string {
+	var b strings.Builder
+	b.WriteString(string(decision.Stage))
+	if decision.Selected != "" {
+		fmt.Fprintf(&b, " %s selected", decision.Selected)
+	}
+	if decision.Message != "" {
+		fmt.Fprintf(&b, ": %s", decision.Message)
+	}
+	if len(decision.Rejected) > 0 {
+		rejected := make([]string, 0, len(decision.Rejected))
+		for _, r := range decision.Rejected {
+			rejected = append(rejected, fmt.Sprintf("%s (%s)", r.Name, r.Reason))
+		}
+		fmt.Fprintf(&b, "; rejected: %s", strings.Join(rejected, ", "))
+	}
+	return b.String()
+}
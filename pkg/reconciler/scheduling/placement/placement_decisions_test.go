@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+func TestRecordDecision(t *testing.T) {
+	placement := &schedulingv1alpha1.Placement{}
+
+	noMatch := schedulingv1alpha1.PlacementDecision{
+		Stage:  schedulingv1alpha1.PlacementDecisionStageLocation,
+		Reason: schedulingv1alpha1.LocationNotMatchReason,
+		Rejected: []schedulingv1alpha1.PlacementRejection{
+			{Name: "gcp", Reason: schedulingv1alpha1.SelectorMismatchReason},
+		},
+	}
+	RecordDecision(placement, noMatch)
+	require.Len(t, placement.Status.Decisions, 1)
+	require.False(t, placement.Status.Decisions[0].Time.IsZero())
+
+	// the same decision is not recorded again
+	RecordDecision(placement, noMatch)
+	require.Len(t, placement.Status.Decisions, 1)
+
+	// nor when a decision is made at another stage in between
+	RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+		Stage:  schedulingv1alpha1.PlacementDecisionStageSyncTarget,
+		Reason: schedulingv1alpha1.ScheduleLocationNotFound,
+	})
+	RecordDecision(placement, noMatch)
+	require.Len(t, placement.Status.Decisions, 2)
+
+	old := placement.Status.DeepCopy().Decisions
+	RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+		Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+		Selected: "root:org:aws",
+		Reason:   schedulingv1alpha1.LocationSelectedReason,
+	})
+	require.Len(t, placement.Status.Decisions, 3)
+	require.Equal(t, placement.Status.Decisions[2:], newDecisions(old, placement.Status.Decisions))
+
+	// the history is bounded
+	for i := 0; i < 2*maxDecisions; i++ {
+		RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+			Stage:    schedulingv1alpha1.PlacementDecisionStageSyncTarget,
+			Selected: fmt.Sprintf("cluster-%d", i),
+			Reason:   schedulingv1alpha1.SyncTargetSelectedReason,
+		})
+	}
+	require.Len(t, placement.Status.Decisions, maxDecisions)
+	require.Equal(t, fmt.Sprintf("cluster-%d", 2*maxDecisions-1), placement.Status.Decisions[maxDecisions-1].Selected)
+
+	// as well as the rejected candidates
+	rejected := make([]schedulingv1alpha1.PlacementRejection, 2*maxRejections)
+	for i := range rejected {
+		rejected[i] = schedulingv1alpha1.PlacementRejection{Name: fmt.Sprintf("location-%d", i), Reason: schedulingv1alpha1.SelectorMismatchReason}
+	}
+	RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+		Time:     metav1.Now(),
+		Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+		Reason:   schedulingv1alpha1.LocationNotMatchReason,
+		Rejected: rejected,
+	})
+	require.Len(t, placement.Status.Decisions[maxDecisions-1].Rejected, maxRejections)
+}
+
+func TestDecisionMessage(t *testing.T) {
+	require.Equal(t, "Location root:org:aws selected: Selected among 1 valid locations; rejected: gcp (SelectorMismatch)", decisionMessage(schedulingv1alpha1.PlacementDecision{
+		Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+		Selected: "root:org:aws",
+		Reason:   schedulingv1alpha1.LocationSelectedReason,
+		Message:  "Selected among 1 valid locations",
+		Rejected: []schedulingv1alpha1.PlacementRejection{
+			{Name: "gcp", Reason: schedulingv1alpha1.SelectorMismatchReason},
+		},
+	}))
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+// IsNamespaceSelected returns whether the placement applies to the namespace, i.e. whether its
+// namespace selector matches the namespace, subject to the overrides of the
+// schedulingv1alpha1.NamespacePlacementsAnnotationKey annotation of the namespace.
+func IsNamespaceSelected(ns *corev1.Namespace, placement *schedulingv1alpha1.Placement) bool {
+	if value, found := ns.Annotations[schedulingv1alpha1.NamespacePlacementsAnnotationKey]; found {
+		pinned, excluded := parseNamespacePlacements(value)
+		switch {
+		case pinned.Len() == 0 && excluded.Len() == 0:
+			// the namespace is excluded from scheduling
+			return false
+		case excluded.Has(placement.Name):
+			return false
+		case pinned.Has(placement.Name):
+			return true
+		case pinned.Len() > 0:
+			return false
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(placement.Spec.NamespaceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(ns.Labels))
+}
+
+// parseNamespacePlacements returns the names of the pinned and excluded Placements of the
+// value of the schedulingv1alpha1.NamespacePlacementsAnnotationKey annotation.
+func parseNamespacePlacements(value string) (pinned, excluded sets.String) {
+	pinned, excluded = sets.NewString(), sets.NewString()
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(name, "-") {
+			if name = strings.TrimSpace(strings.TrimPrefix(name, "-")); name != "" {
+				excluded.Insert(name)
+			}
+		} else if name != "" {
+			pinned.Insert(name)
+		}
+	}
+	return pinned, excluded
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+func TestIsNamespaceSelected(t *testing.T) {
+	tests := map[string]struct {
+		labels      map[string]string
+		annotations map[string]string
+		want        bool
+	}{
+		"selector matches": {
+			labels: map[string]string{"team": "a"},
+			want:   true,
+		},
+		"selector does not match": {
+			labels: map[string]string{"team": "b"},
+		},
+		"pinned without selector match": {
+			labels:      map[string]string{"team": "b"},
+			annotations: map[string]string{schedulingv1alpha1.NamespacePlacementsAnnotationKey: "other, test"},
+			want:        true,
+		},
+		"pinned to another placement": {
+			labels:      map[string]string{"team": "a"},
+			annotations: map[string]string{schedulingv1alpha1.NamespacePlacementsAnnotationKey: "other"},
+		},
+		"excluded": {
+			labels:      map[string]string{"team": "a"},
+			annotations: map[string]string{schedulingv1alpha1.NamespacePlacementsAnnotationKey: "-test"},
+		},
+		"another placement excluded": {
+			labels:      map[string]string{"team": "a"},
+			annotations: map[string]string{schedulingv1alpha1.NamespacePlacementsAnnotationKey: "-other"},
+			want:        true,
+		},
+		"excluded from scheduling": {
+			labels:      map[string]string{"team": "a"},
+			annotations: map[string]string{schedulingv1alpha1.NamespacePlacementsAnnotationKey: ""},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: tc.labels, Annotations: tc.annotations}}
+			placement := &schedulingv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: schedulingv1alpha1.PlacementSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+			require.Equal(t, tc.want, IsNamespaceSelected(ns, placement))
+		})
+	}
+}
@@ -22,9 +22,6 @@ import (
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	utilserrors "k8s.io/apimachinery/pkg/util/errors"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 )
@@ -68,20 +65,14 @@ func (r *placementNamespaceReconciler) selectNamespaces(placement *schedulingv1a
 		return nil, err
 	}
 
-	selector, err := metav1.LabelSelectorAsSelector(placement.Spec.NamespaceSelector)
-	if err != nil {
-		return nil, err
-	}
-
 	candidates := []*corev1.Namespace{}
-	var errs []error
 	for _, ns := range nss {
-		if !selector.Matches(labels.Set(ns.Labels)) {
+		if !IsNamespaceSelected(ns, placement) {
 			continue
 		}
 
 		candidates = append(candidates, ns)
 	}
 
-	return candidates, utilserrors.NewAggregate(errs)
+	return candidates, nil
 }
@@ -18,7 +18,10 @@ package placement
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v3"
 
@@ -46,7 +49,7 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 		locationWorkspace = logicalcluster.From(placement).Path()
 	}
 
-	validLocationNames, err := r.validLocationNames(placement, locationWorkspace)
+	validLocationNames, rejected, err := r.validLocationNames(placement, locationWorkspace)
 	if err != nil {
 		conditions.MarkFalse(placement, schedulingv1alpha1.PlacementReady, schedulingv1alpha1.LocationNotFoundReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
 		return reconcileStatusContinue, placement, err
@@ -64,6 +67,12 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 				conditionsv1alpha1.ConditionSeverityError,
 				"Selected location is invalid for current placement",
 			)
+			RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+				Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+				Reason:   schedulingv1alpha1.LocationInvalidReason,
+				Message:  "Selected location is invalid for current placement",
+				Rejected: rejected,
+			})
 			return reconcileStatusContinue, placement, nil
 		}
 
@@ -87,6 +96,12 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 			schedulingv1alpha1.LocationNotMatchReason,
 			conditionsv1alpha1.ConditionSeverityError,
 			"No valid location is found")
+		RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+			Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+			Reason:   schedulingv1alpha1.LocationNotMatchReason,
+			Message:  "No valid location is found",
+			Rejected: rejected,
+		})
 		return reconcileStatusContinue, placement, nil
 	}
 
@@ -104,20 +119,35 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 	}
 	placement.Status.Phase = schedulingv1alpha1.PlacementUnbound
 	conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
+	RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+		Stage:    schedulingv1alpha1.PlacementDecisionStageLocation,
+		Selected: locationWorkspace.Join(chosenLocation).String(),
+		Reason:   schedulingv1alpha1.LocationSelectedReason,
+		Message:  fmt.Sprintf("Selected among %d valid locations", len(candidates)),
+		Rejected: rejected,
+	})
 
 	return reconcileStatusContinue, placement, nil
 }
 
-func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Path) (sets.String, error) {
+// validLocationNames returns the names of the locations of the location workspace matching the
+// placement, and the locations rejected, with the reason why.
+func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Path) (sets.String, []schedulingv1alpha1.PlacementRejection, error) {
 	selectedLocations := sets.NewString()
+	var rejected []schedulingv1alpha1.PlacementRejection
 
 	locations, err := r.listLocationsByPath(locationWorkspace)
 	if err != nil {
-		return selectedLocations, err
+		return selectedLocations, nil, err
 	}
 
 	for _, loc := range locations {
 		if loc.Spec.Resource != placement.Spec.LocationResource {
+			rejected = append(rejected, schedulingv1alpha1.PlacementRejection{
+				Name:    loc.Name,
+				Reason:  schedulingv1alpha1.ResourceMismatchReason,
+				Message: fmt.Sprintf("Location is for %s, not %s", resourceString(loc.Spec.Resource), resourceString(placement.Spec.LocationResource)),
+			})
 			continue
 		}
 
@@ -133,9 +163,23 @@ func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.P
 				selectedLocations.Insert(loc.Name)
 			}
 		}
+		if !selectedLocations.Has(loc.Name) {
+			rejected = append(rejected, schedulingv1alpha1.PlacementRejection{
+				Name:    loc.Name,
+				Reason:  schedulingv1alpha1.SelectorMismatchReason,
+				Message: "Location labels do not match any location selector",
+			})
+		}
 	}
 
-	return selectedLocations, nil
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Name < rejected[j].Name
+	})
+	return selectedLocations, rejected, nil
+}
+
+func resourceString(gvr schedulingv1alpha1.GroupVersionResource) string {
+	return strings.TrimSuffix(gvr.Resource+"."+gvr.Version+"."+gvr.Group, ".")
 }
 
 func isValidLocationSelected(placement *schedulingv1alpha1.Placement, cluster logicalcluster.Path, validLocationNames sets.String) bool {
@@ -46,6 +46,7 @@ import (
 	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
 	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -61,7 +62,7 @@ func NewController(
 	batteriesIncluded sets.String,
 ) (*controller, error) {
 	controllerName := fmt.Sprintf("%s-%s", ControllerNameBase, workspaceType)
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(controllerName), controllerName)
 
 	c := &controller{
 		controllerName:       controllerName,
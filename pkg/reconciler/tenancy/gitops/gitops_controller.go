@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	corev1listers "github.com/kcp-dev/client-go/listers/core/v1"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	tenancyv1beta1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1beta1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+	tenancyv1beta1listers "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-gitops-cluster-secrets"
+
+	// WorkspaceClusterLabelKey is the label of the rendered Secrets holding the logical cluster
+	// of the Workspace they give access to.
+	WorkspaceClusterLabelKey = "gitops.tenancy.kcp.io/workspace-cluster"
+	// WorkspaceNameLabelKey is the label of the rendered Secrets holding the name of the
+	// Workspace they give access to.
+	WorkspaceNameLabelKey = "gitops.tenancy.kcp.io/workspace-name"
+)
+
+// NewController returns a controller that renders a GitOps cluster Secret for every ready
+// Workspace of the shard into the namespace of the GitOps workspace, and deletes it when the
+// Workspace is deleted.
+func NewController(
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	workspaceInformer tenancyv1beta1informers.WorkspaceClusterInformer,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	secretInformer kcpcorev1informers.SecretClusterInformer,
+	options Options,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	c := &Controller{
+		queue:                 queue,
+		options:               options,
+		kubeClusterClient:     kubeClusterClient,
+		workspaceLister:       workspaceInformer.Lister(),
+		logicalClusterLister:  logicalClusterInformer.Lister(),
+		logicalClusterIndexer: logicalClusterInformer.Informer().GetIndexer(),
+		secretLister:          secretInformer.Lister(),
+	}
+
+	indexers.AddIfNotPresentOrDie(logicalClusterInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalClusterPath: indexers.IndexByLogicalClusterPath,
+	})
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspace(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+	})
+
+	secretInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			secret, ok := obj.(*corev1.Secret)
+			return ok && secret.Namespace == options.Namespace
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueSecret(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueSecret(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueSecret(obj) },
+		},
+	})
+
+	return c, nil
+}
+
+// Controller renders the GitOps cluster Secrets of the Workspaces.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	options Options
+
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface
+
+	workspaceLister       tenancyv1beta1listers.WorkspaceClusterLister
+	logicalClusterLister  corev1alpha1listers.LogicalClusterClusterLister
+	logicalClusterIndexer cache.Indexer
+	secretLister          corev1listers.SecretClusterLister
+}
+
+func (c *Controller) enqueueWorkspace(obj interface{}) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(2).Info("queueing Workspace")
+	c.queue.Add(key)
+}
+
+// enqueueSecret enqueues the Workspace of a rendered Secret, so that it is rendered again when
+// changed or deleted, or deleted when its Workspace is gone, and all the Workspaces when the
+// credentials change.
+func (c *Controller) enqueueSecret(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a Secret, but is %T", obj))
+		return
+	}
+
+	gitOpsCluster, err := c.gitOpsCluster()
+	if err != nil || logicalcluster.From(secret) != gitOpsCluster {
+		return
+	}
+
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), ControllerName), secret)
+
+	if secret.Name == c.options.CredentialsSecret {
+		workspaces, err := c.workspaceLister.List(labels.Everything())
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		logger.V(2).Info("queueing all Workspaces because of credentials Secret")
+		for _, workspace := range workspaces {
+			c.enqueueWorkspace(workspace)
+		}
+		return
+	}
+
+	clusterName, name := secret.Labels[WorkspaceClusterLabelKey], secret.Labels[WorkspaceNameLabelKey]
+	if clusterName == "" || name == "" {
+		return
+	}
+	key := kcpcache.ToClusterAwareKey(clusterName, "", name)
+	logging.WithQueueKey(logger, key).V(2).Info("queueing Workspace because of rendered Secret")
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+// gitOpsCluster returns the logical cluster of the GitOps workspace.
+func (c *Controller) gitOpsCluster() (logicalcluster.Name, error) {
+	logicalClusters, err := indexers.ByIndex[*corev1alpha1.LogicalCluster](c.logicalClusterIndexer, indexers.ByLogicalClusterPath, c.options.Workspace)
+	if err != nil {
+		return "", err
+	}
+	if len(logicalClusters) == 0 {
+		return "", fmt.Errorf("GitOps workspace %s not found", c.options.Workspace)
+	}
+	return logicalcluster.From(logicalClusters[0]), nil
+}
+
+// workspacePath returns the path of the Workspace, falling back to its logical cluster if the
+// path of its parent is unknown.
+func (c *Controller) workspacePath(workspace *tenancyv1beta1.Workspace) logicalcluster.Path {
+	parent, err := c.logicalClusterLister.Cluster(logicalcluster.From(workspace)).Get(corev1alpha1.LogicalClusterName)
+	if err == nil {
+		if path := logicalcluster.NewPath(parent.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
+			return path.Join(workspace.Name)
+		}
+	}
+	return logicalcluster.Name(workspace.Status.Cluster).Path()
+}
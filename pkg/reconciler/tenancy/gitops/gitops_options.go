@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/pflag"
+)
+
+// Format is the format of the rendered Secrets.
+type Format string
+
+const (
+	// FormatArgoCD renders ArgoCD cluster Secrets.
+	FormatArgoCD Format = "argocd"
+	// FormatFlux renders Secrets holding a kubeconfig under the "value" key, to be referenced
+	// by the spec.kubeConfig.secretRef of Flux Kustomizations and HelmReleases.
+	FormatFlux Format = "flux"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		Namespace:         "argocd",
+		Format:            string(FormatArgoCD),
+		CredentialsSecret: "kcp-credentials",
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.Workspace, "gitops-workspace", o.Workspace, "Path of the workspace the GitOps cluster Secrets of the workspaces of this shard are rendered into. The GitOps controller is disabled if empty.")
+	fs.StringVar(&o.Namespace, "gitops-namespace", o.Namespace, "Namespace of the GitOps workspace the cluster Secrets are rendered into, e.g. flux-system for Flux.")
+	fs.StringVar(&o.Format, "gitops-format", o.Format, "Format of the GitOps cluster Secrets, either argocd or flux.")
+	fs.StringVar(&o.CredentialsSecret, "gitops-credentials-secret", o.CredentialsSecret, "Name of the Secret in the GitOps namespace holding the token, and optionally the ca.crt, to access the workspaces with.")
+	return o
+}
+
+type Options struct {
+	Workspace         string
+	Namespace         string
+	Format            string
+	CredentialsSecret string
+}
+
+// Enabled returns whether a GitOps workspace is configured.
+func (o *Options) Enabled() bool {
+	return o.Workspace != ""
+}
+
+func (o *Options) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	if !logicalcluster.NewPath(o.Workspace).IsValid() {
+		return fmt.Errorf("--gitops-workspace must be a valid workspace path (%s)", o.Workspace)
+	}
+	if o.Namespace == "" {
+		return fmt.Errorf("--gitops-namespace must be set with --gitops-workspace")
+	}
+	if o.CredentialsSecret == "" {
+		return fmt.Errorf("--gitops-credentials-secret must be set with --gitops-workspace")
+	}
+	switch Format(o.Format) {
+	case FormatArgoCD, FormatFlux:
+	default:
+		return fmt.Errorf("--gitops-format must be %s or %s (%s)", FormatArgoCD, FormatFlux, o.Format)
+	}
+	return nil
+}
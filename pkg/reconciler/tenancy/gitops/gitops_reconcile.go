@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	// argoCDSecretTypeLabelKey is the label ArgoCD discovers its cluster Secrets by.
+	argoCDSecretTypeLabelKey = "argocd.argoproj.io/secret-type"
+	// fluxKubeconfigKey is the default key Flux reads the kubeconfig of a Secret from.
+	fluxKubeconfigKey = "value"
+)
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+
+	clusterName, _, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		logger.Error(err, "unable to decode key")
+		return nil
+	}
+
+	gitOpsCluster, err := c.gitOpsCluster()
+	if err != nil {
+		return err
+	}
+	secrets := c.kubeClusterClient.Cluster(gitOpsCluster.Path()).CoreV1().Secrets(c.options.Namespace)
+	secretName := secretName(clusterName, name)
+
+	existing, err := c.secretLister.Cluster(gitOpsCluster).Secrets(c.options.Namespace).Get(secretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	}
+
+	workspace, err := c.workspaceLister.Cluster(clusterName).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if apierrors.IsNotFound(err) || !workspace.DeletionTimestamp.IsZero() || workspace.Status.Phase != corev1alpha1.LogicalClusterPhaseReady || workspace.Status.URL == "" {
+		if existing == nil {
+			return nil
+		}
+		logger.V(2).Info("deleting GitOps cluster Secret", "secret", secretName)
+		if err := secrets.Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	logger = logging.WithObject(logger, workspace)
+
+	credentials, err := c.secretLister.Cluster(gitOpsCluster).Secrets(c.options.Namespace).Get(c.options.CredentialsSecret)
+	if apierrors.IsNotFound(err) {
+		// the Workspaces are queued again when the credentials Secret is created
+		logger.V(2).Info("credentials Secret not found, skipping", "secret", c.options.CredentialsSecret)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	desired, err := renderSecret(Format(c.options.Format), secretName, c.workspacePath(workspace), workspace, credentials)
+	if err != nil {
+		logger.Error(err, "failed to render GitOps cluster Secret")
+		return nil // nothing we can do until the credentials change
+	}
+
+	if existing == nil {
+		logger.V(2).Info("creating GitOps cluster Secret", "secret", secretName)
+		desired.Namespace = c.options.Namespace
+		_, err := secrets.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Labels, desired.Labels) && equality.Semantic.DeepEqual(existing.Data, desired.Data) && existing.Type == desired.Type {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels = desired.Labels
+	updated.Data = desired.Data
+	updated.Type = desired.Type
+	logger.V(2).Info("updating GitOps cluster Secret", "secret", secretName)
+	_, err = secrets.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// secretName returns the name of the GitOps cluster Secret of the Workspace with the given name
+// in the given logical cluster.
+func secretName(clusterName logicalcluster.Name, name string) string {
+	return fmt.Sprintf("kcp-%s-%s", clusterName, name)
+}
+
+// renderSecret renders the GitOps cluster Secret of the Workspace with the given path in the
+// given format, from the token, and the optional CA bundle, of the credentials Secret.
+func renderSecret(format Format, name string, path logicalcluster.Path, workspace *tenancyv1beta1.Workspace, credentials *corev1.Secret) (*corev1.Secret, error) {
+	token := credentials.Data[corev1.ServiceAccountTokenKey]
+	if len(token) == 0 {
+		return nil, fmt.Errorf("credentials Secret %s has no %s key", credentials.Name, corev1.ServiceAccountTokenKey)
+	}
+	caData := credentials.Data[corev1.ServiceAccountRootCAKey]
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				WorkspaceClusterLabelKey: logicalcluster.From(workspace).String(),
+				WorkspaceNameLabelKey:    workspace.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	switch format {
+	case FormatArgoCD:
+		config, err := json.Marshal(argoCDClusterConfig{
+			BearerToken: string(token),
+			TLSClientConfig: argoCDTLSClientConfig{
+				CAData: caData,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		secret.Labels[argoCDSecretTypeLabelKey] = "cluster"
+		secret.Data = map[string][]byte{
+			"name":   []byte(path.String()),
+			"server": []byte(workspace.Status.URL),
+			"config": config,
+		}
+	case FormatFlux:
+		kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+			Clusters: map[string]*clientcmdapi.Cluster{
+				"workspace": {
+					Server:                   workspace.Status.URL,
+					CertificateAuthorityData: caData,
+				},
+			},
+			AuthInfos: map[string]*clientcmdapi.AuthInfo{
+				"gitops": {
+					Token: string(token),
+				},
+			},
+			Contexts: map[string]*clientcmdapi.Context{
+				path.String(): {
+					Cluster:  "workspace",
+					AuthInfo: "gitops",
+				},
+			},
+			CurrentContext: path.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		secret.Data = map[string][]byte{
+			fluxKubeconfigKey: kubeconfig,
+		}
+	default:
+		return nil, fmt.Errorf("unknown GitOps format %q", format)
+	}
+
+	return secret, nil
+}
+
+// argoCDClusterConfig is the config of an ArgoCD cluster Secret.
+type argoCDClusterConfig struct {
+	BearerToken     string                `json:"bearerToken"`
+	TLSClientConfig argoCDTLSClientConfig `json:"tlsClientConfig"`
+}
+
+type argoCDTLSClientConfig struct {
+	Insecure bool   `json:"insecure"`
+	CAData   []byte `json:"caData,omitempty"`
+}
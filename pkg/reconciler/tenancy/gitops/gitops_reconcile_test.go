@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func TestRenderSecret(t *testing.T) {
+	workspace := &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: "parent",
+			},
+		},
+		Status: tenancyv1beta1.WorkspaceStatus{
+			URL:     "https://kcp.example.com/clusters/root:org:team",
+			Cluster: "child",
+		},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kcp-credentials"},
+		Data: map[string][]byte{
+			corev1.ServiceAccountTokenKey:  []byte("token"),
+			corev1.ServiceAccountRootCAKey: []byte("ca"),
+		},
+	}
+	path := logicalcluster.NewPath("root:org:team")
+
+	t.Run("argocd", func(t *testing.T) {
+		secret, err := renderSecret(FormatArgoCD, "kcp-parent-team", path, workspace, credentials)
+		require.NoError(t, err)
+		require.Equal(t, "kcp-parent-team", secret.Name)
+		require.Equal(t, map[string]string{
+			argoCDSecretTypeLabelKey: "cluster",
+			WorkspaceClusterLabelKey: "parent",
+			WorkspaceNameLabelKey:    "team",
+		}, secret.Labels)
+		require.Equal(t, "root:org:team", string(secret.Data["name"]))
+		require.Equal(t, "https://kcp.example.com/clusters/root:org:team", string(secret.Data["server"]))
+		require.JSONEq(t, `{"bearerToken":"token","tlsClientConfig":{"insecure":false,"caData":"Y2E="}}`, string(secret.Data["config"]))
+	})
+
+	t.Run("flux", func(t *testing.T) {
+		secret, err := renderSecret(FormatFlux, "kcp-parent-team", path, workspace, credentials)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			WorkspaceClusterLabelKey: "parent",
+			WorkspaceNameLabelKey:    "team",
+		}, secret.Labels)
+
+		config, err := clientcmd.Load(secret.Data[fluxKubeconfigKey])
+		require.NoError(t, err)
+		require.Equal(t, "root:org:team", config.CurrentContext)
+		cluster := config.Clusters[config.Contexts[config.CurrentContext].Cluster]
+		require.Equal(t, "https://kcp.example.com/clusters/root:org:team", cluster.Server)
+		require.Equal(t, []byte("ca"), cluster.CertificateAuthorityData)
+		require.Equal(t, "token", config.AuthInfos[config.Contexts[config.CurrentContext].AuthInfo].Token)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := renderSecret(FormatArgoCD, "kcp-parent-team", path, workspace, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kcp-credentials"}})
+		require.Error(t, err)
+	})
+}
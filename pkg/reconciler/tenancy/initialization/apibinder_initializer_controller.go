@@ -38,6 +38,7 @@ import (
 	admission "github.com/kcp-dev/kcp/pkg/admission/workspacetypeexists"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	corev1alpha1client "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/core/v1alpha1"
@@ -47,6 +48,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -54,7 +56,9 @@ const (
 )
 
 // NewAPIBinder returns a new controller which instantiates APIBindings and waits for them to be fully bound
-// in new ClusterWorkspaces.
+// in new ClusterWorkspaces. The LogicalCluster informer is the shared wildcard one, only the LogicalClusters
+// initializing with the APIBindings initializer are considered. The client must be the one of the initializing
+// workspaces virtual workspace of the APIBindings initializer.
 func NewAPIBinder(
 	kcpClusterClient kcpclientset.ClusterInterface,
 	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
@@ -63,16 +67,33 @@ func NewAPIBinder(
 	apiExportsInformer apisv1alpha1informers.APIExportClusterInformer,
 ) (*APIBinder, error) {
 	c := &APIBinder{
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		queue: workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName),
 
 		getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
-			return logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+			logicalCluster, err := logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+			if err != nil {
+				return nil, err
+			}
+			if !isInitializing(logicalCluster) {
+				return nil, apierrors.NewNotFound(corev1alpha1.Resource("logicalclusters"), corev1alpha1.LogicalClusterName)
+			}
+			return logicalCluster, nil
 		},
 		getWorkspaceType: func(path logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error) {
 			return indexers.ByPathAndName[*tenancyv1alpha1.WorkspaceType](tenancyv1alpha1.Resource("workspacetypes"), workspaceTypeInformer.Informer().GetIndexer(), path, name)
 		},
 		listLogicalClusters: func() ([]*corev1alpha1.LogicalCluster, error) {
-			return logicalClusterInformer.Lister().List(labels.Everything())
+			logicalClusters, err := logicalClusterInformer.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			var initializing []*corev1alpha1.LogicalCluster
+			for _, logicalCluster := range logicalClusters {
+				if isInitializing(logicalCluster) {
+					initializing = append(initializing, logicalCluster)
+				}
+			}
+			return initializing, nil
 		},
 
 		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
@@ -100,12 +121,22 @@ func NewAPIBinder(
 		indexers.ByLogicalClusterPathAndName: indexers.IndexByLogicalClusterPathAndName,
 	})
 
-	logicalClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			c.enqueueLogicalCluster(obj, logger)
+	logicalClusterInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster)
+			return ok && isInitializing(logicalCluster)
 		},
-		DeleteFunc: func(obj interface{}) {
-			c.enqueueLogicalCluster(obj, logger)
+		// a LogicalCluster starting, respectively finishing, its initialization with the initializer is added, respectively deleted
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.enqueueLogicalCluster(obj, logger)
+			},
+			DeleteFunc: func(obj interface{}) {
+				c.enqueueLogicalCluster(obj, logger)
+			},
 		},
 	})
 
@@ -130,6 +161,13 @@ func NewAPIBinder(
 	return c, nil
 }
 
+// isInitializing returns whether the LogicalCluster is initializing with the APIBindings initializer, i.e.
+// whether it is served by the initializing workspaces virtual workspace of the initializer.
+func isInitializing(logicalCluster *corev1alpha1.LogicalCluster) bool {
+	return logicalCluster.Status.Phase == corev1alpha1.LogicalClusterPhaseInitializing &&
+		initialization.InitializerPresent(tenancyv1alpha1.WorkspaceAPIBindingsInitializer, logicalCluster.Status.Initializers)
+}
+
 type logicalClusterResource = committer.Resource[*corev1alpha1.LogicalClusterSpec, *corev1alpha1.LogicalClusterStatus]
 
 // APIBinder is a controller which instantiates APIBindings and waits for them to be fully bound
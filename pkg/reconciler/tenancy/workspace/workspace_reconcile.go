@@ -106,6 +106,35 @@ func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1beta1.Workspace
 			deleteLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path) error {
 				return c.kcpExternalClient.Cluster(cluster).CoreV1alpha1().LogicalClusters().Delete(ctx, corev1alpha1.LogicalClusterName, metav1.DeleteOptions{})
 			},
+			updateLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error {
+				_, err := c.kcpExternalClient.Cluster(cluster).CoreV1alpha1().LogicalClusters().Update(ctx, logicalCluster, metav1.UpdateOptions{})
+				return err
+			},
+			listWorkspaces: func(ctx context.Context, cluster logicalcluster.Path) ([]tenancyv1beta1.Workspace, error) {
+				list, err := c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return list.Items, nil
+			},
+			getWorkspace: func(ctx context.Context, cluster logicalcluster.Path, name string) (*tenancyv1beta1.Workspace, error) {
+				return c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().Get(ctx, name, metav1.GetOptions{})
+			},
+			createWorkspace: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+				return c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().Create(ctx, workspace, metav1.CreateOptions{})
+			},
+			updateWorkspace: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+				return c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().Update(ctx, workspace, metav1.UpdateOptions{})
+			},
+			updateWorkspaceStatus: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+				return c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().UpdateStatus(ctx, workspace, metav1.UpdateOptions{})
+			},
+			deleteWorkspace: func(ctx context.Context, cluster logicalcluster.Path, name string) error {
+				return c.kcpExternalClient.Cluster(cluster).TenancyV1beta1().Workspaces().Delete(ctx, name, metav1.DeleteOptions{})
+			},
+			requeueAfter: func(workspace *tenancyv1beta1.Workspace, after time.Duration) {
+				c.queue.AddAfter(kcpcache.ToClusterAwareKey(logicalcluster.From(workspace).String(), "", workspace.Name), after)
+			},
 		},
 		&schedulingReconciler{
 			generateClusterName: randomClusterName,
@@ -126,6 +155,14 @@ func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1beta1.Workspace
 			getLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error) {
 				return c.kcpExternalClient.Cluster(cluster).CoreV1alpha1().LogicalClusters().Get(ctx, corev1alpha1.LogicalClusterName, metav1.GetOptions{})
 			},
+			updateLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error {
+				_, err := c.kcpExternalClient.Cluster(cluster).CoreV1alpha1().LogicalClusters().Update(ctx, logicalCluster, metav1.UpdateOptions{})
+				return err
+			},
+			getWorkspaceType: getType,
+			deleteWorkspace: func(ctx context.Context, workspace *tenancyv1beta1.Workspace) error {
+				return c.kcpClusterClient.Cluster(logicalcluster.From(workspace).Path()).TenancyV1beta1().Workspaces().Delete(ctx, workspace.Name, metav1.DeleteOptions{})
+			},
 			requeueAfter: func(workspace *tenancyv1beta1.Workspace, after time.Duration) {
 				c.queue.AddAfter(kcpcache.ToClusterAwareKey(logicalcluster.From(workspace).String(), "", workspace.Name), after)
 			},
@@ -19,20 +19,48 @@ package workspace
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
+	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
+// workspaceAdoptedFromAnnotationKey is set on the copy of a child workspace created in the parent
+// of a workspace deleted with the Orphan deletion policy, while the logical cluster of the child
+// workspace is moved to it. The value is the UID of the original child workspace.
+const workspaceAdoptedFromAnnotationKey = "internal.tenancy.kcp.io/adopted-from"
+
+// childrenDeletionRequeueAfter is the delay after which a deleting workspace is checked again
+// while its child workspaces are handled according to its deletion policy.
+const childrenDeletionRequeueAfter = 5 * time.Second
+
 type deletionReconciler struct {
 	getLogicalCluster    func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error)
 	deleteLogicalCluster func(ctx context.Context, cluster logicalcluster.Path) error
+	updateLogicalCluster func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error
+
+	listWorkspaces        func(ctx context.Context, cluster logicalcluster.Path) ([]tenancyv1beta1.Workspace, error)
+	getWorkspace          func(ctx context.Context, cluster logicalcluster.Path, name string) (*tenancyv1beta1.Workspace, error)
+	createWorkspace       func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error)
+	updateWorkspace       func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error)
+	updateWorkspaceStatus func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error)
+	deleteWorkspace       func(ctx context.Context, cluster logicalcluster.Path, name string) error
+
+	requeueAfter func(workspace *tenancyv1beta1.Workspace, after time.Duration)
 }
 
 func (r *deletionReconciler) reconcile(ctx context.Context, workspace *tenancyv1beta1.Workspace) (reconcileStatus, error) {
@@ -62,7 +90,8 @@ func (r *deletionReconciler) reconcile(ctx context.Context, workspace *tenancyv1
 		clusterName = logicalcluster.Name(a)
 	}
 
-	if _, err := r.getLogicalCluster(ctx, clusterName.Path()); err != nil && !apierrors.IsNotFound(err) {
+	logicalCluster, err := r.getLogicalCluster(ctx, clusterName.Path())
+	if err != nil && !apierrors.IsNotFound(err) {
 		return reconcileStatusStopAndRequeue, err
 	} else if apierrors.IsNotFound(err) {
 		finalizers := sets.NewString(workspace.Finalizers...)
@@ -74,6 +103,26 @@ func (r *deletionReconciler) reconcile(ctx context.Context, workspace *tenancyv1
 		return reconcileStatusContinue, nil
 	}
 
+	// The LogicalCluster may have been moved to another workspace, e.g. when the parent
+	// workspace has been deleted with the Orphan deletion policy. It must not be deleted then.
+	if owner := logicalCluster.Spec.Owner; owner != nil && owner.UID != workspace.UID {
+		logger.Info(fmt.Sprintf("LogicalCluster is owned by another workspace, removing finalizer %s", corev1alpha1.LogicalClusterFinalizer), "owner", owner.Cluster+"|"+owner.Name)
+		workspace.Finalizers = sets.NewString(workspace.Finalizers...).Delete(corev1alpha1.LogicalClusterFinalizer).List()
+		return reconcileStatusStopAndRequeue, nil // spec change
+	}
+
+	if !logicalCluster.DeletionTimestamp.IsZero() {
+		// here we are waiting for the other shard to remove the finalizer of the Workspace
+		return reconcileStatusContinue, nil
+	}
+
+	if done, err := r.reconcileChildren(ctx, workspace, clusterName, logicalCluster); err != nil {
+		return reconcileStatusStopAndRequeue, err
+	} else if !done {
+		r.requeueAfter(workspace, childrenDeletionRequeueAfter)
+		return reconcileStatusContinue, nil
+	}
+
 	logger.Info("Deleting LogicalCluster")
 	if err := r.deleteLogicalCluster(ctx, clusterName.Path()); err != nil {
 		return reconcileStatusStopAndRequeue, err
@@ -83,3 +132,161 @@ func (r *deletionReconciler) reconcile(ctx context.Context, workspace *tenancyv1
 
 	return reconcileStatusContinue, nil
 }
+
+// reconcileChildren handles the child workspaces of a deleting workspace according to its
+// deletion policy, and returns whether they are all gone.
+func (r *deletionReconciler) reconcileChildren(ctx context.Context, workspace *tenancyv1beta1.Workspace, clusterName logicalcluster.Name, logicalCluster *corev1alpha1.LogicalCluster) (bool, error) {
+	logger := klog.FromContext(ctx).WithValues("reconciler", "deletion", "cluster", clusterName)
+
+	children, err := r.listWorkspaces(ctx, clusterName.Path())
+	if err != nil {
+		return false, err
+	}
+	if len(children) == 0 {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted)
+		return true, nil
+	}
+
+	names := make([]string, 0, len(children))
+	for _, child := range children {
+		names = append(names, child.Name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	switch workspace.Spec.DeletionPolicy {
+	case tenancyv1beta1.WorkspaceDeletionPolicyBlock:
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted, tenancyv1alpha1.WorkspaceChildrenDeletedBlocked, conditionsv1alpha1.ConditionSeverityWarning,
+			"Deletion is blocked by %d child workspaces: %s", len(names), strings.Join(names, ", "))
+	case tenancyv1beta1.WorkspaceDeletionPolicyOrphan:
+		parent := logicalcluster.From(workspace).Path()
+		parentCanonicalPath := parent
+		if path, found := logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]; found {
+			if p, ok := logicalcluster.NewPath(path).Parent(); ok {
+				parentCanonicalPath = p
+			}
+		}
+		for i := range children {
+			child := &children[i]
+			if !child.DeletionTimestamp.IsZero() || child.Status.Cluster == "" {
+				// wait for the child workspace to be gone or to be scheduled
+				continue
+			}
+			logger.V(2).Info("Moving child workspace into the parent workspace", "workspace", child.Name, "parent", parent)
+			if err := r.orphan(ctx, parent, parentCanonicalPath, child); err != nil {
+				errs = append(errs, fmt.Errorf("failed to move workspace %q into the parent workspace: %w", child.Name, err))
+			}
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted, tenancyv1alpha1.WorkspaceChildrenDeletedOrphaning, conditionsv1alpha1.ConditionSeverityInfo,
+			"Moving %d child workspaces into the parent workspace: %s", len(names), strings.Join(names, ", "))
+	default:
+		for _, child := range children {
+			if !child.DeletionTimestamp.IsZero() {
+				continue
+			}
+			logger.V(2).Info("Deleting child workspace", "workspace", child.Name)
+			if err := r.deleteWorkspace(ctx, clusterName.Path(), child.Name); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted, tenancyv1alpha1.WorkspaceChildrenDeletedDeleting, conditionsv1alpha1.ConditionSeverityInfo,
+			"Waiting for %d child workspaces to be deleted: %s", len(names), strings.Join(names, ", "))
+	}
+
+	if len(errs) > 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted), conditionsv1alpha1.ConditionSeverityError,
+			"%v", utilerrors.NewAggregate(errs))
+	}
+
+	return false, utilerrors.NewAggregate(errs)
+}
+
+// orphan moves the given child workspace, with its logical cluster, into the parent workspace.
+// A copy of the child workspace is created in the parent workspace, marked as being adopted so
+// that it is not scheduled, then the LogicalCluster is re-owned by the copy. The child workspace
+// is eventually deleted with the content of the deleted workspace, without its logical cluster.
+func (r *deletionReconciler) orphan(ctx context.Context, parent, parentCanonicalPath logicalcluster.Path, child *tenancyv1beta1.Workspace) error {
+	clusterPath := logicalcluster.NewPath(child.Status.Cluster)
+	logicalCluster, err := r.getLogicalCluster(ctx, clusterPath)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	ownedByChild := logicalCluster.Spec.Owner != nil && logicalCluster.Spec.Owner.UID == child.UID
+
+	adopted, err := r.getWorkspace(ctx, parent, child.Name)
+	if apierrors.IsNotFound(err) {
+		if !ownedByChild {
+			return nil
+		}
+		adopted, err = r.createWorkspace(ctx, parent, adoptedWorkspace(child))
+	}
+	if err != nil {
+		return err
+	}
+
+	adoptedFrom, adopting := adopted.Annotations[workspaceAdoptedFromAnnotationKey]
+	if !adopting {
+		if logicalCluster.Spec.Owner != nil && logicalCluster.Spec.Owner.UID == adopted.UID {
+			return nil // already moved
+		}
+		return fmt.Errorf("a workspace with the same name already exists")
+	} else if adoptedFrom != string(child.UID) {
+		return fmt.Errorf("a workspace with the same name is being moved from another workspace")
+	}
+
+	if adopted.Status.Cluster == "" {
+		adopted = adopted.DeepCopy()
+		adopted.Status = *child.Status.DeepCopy()
+		if adopted, err = r.updateWorkspaceStatus(ctx, parent, adopted); err != nil {
+			return err
+		}
+	}
+
+	if logicalCluster.Spec.Owner == nil || logicalCluster.Spec.Owner.UID != adopted.UID {
+		logicalCluster = logicalCluster.DeepCopy()
+		logicalCluster.Spec.Owner = &corev1alpha1.LogicalClusterOwner{
+			APIVersion: tenancyv1beta1.SchemeGroupVersion.String(),
+			Resource:   "workspaces",
+			Name:       adopted.Name,
+			Cluster:    logicalcluster.From(adopted).String(),
+			UID:        adopted.UID,
+		}
+		if logicalCluster.Annotations == nil {
+			logicalCluster.Annotations = map[string]string{}
+		}
+		logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey] = parentCanonicalPath.Join(child.Name).String()
+		if err := r.updateLogicalCluster(ctx, clusterPath, logicalCluster); err != nil {
+			return err
+		}
+	}
+
+	adopted = adopted.DeepCopy()
+	delete(adopted.Annotations, workspaceAdoptedFromAnnotationKey)
+	_, err = r.updateWorkspace(ctx, parent, adopted)
+	return err
+}
+
+// adoptedWorkspace returns the copy of the given child workspace to be created in the parent workspace.
+func adoptedWorkspace(child *tenancyv1beta1.Workspace) *tenancyv1beta1.Workspace {
+	adopted := &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        child.Name,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+			Finalizers:  []string{corev1alpha1.LogicalClusterFinalizer},
+		},
+		Spec: *child.Spec.DeepCopy(),
+	}
+	for k, v := range child.Labels {
+		adopted.Labels[k] = v
+	}
+	for k, v := range child.Annotations {
+		adopted.Annotations[k] = v
+	}
+	delete(adopted.Annotations, logicalcluster.AnnotationKey)
+	adopted.Annotations[workspaceClusterAnnotationKey] = child.Status.Cluster
+	adopted.Annotations[workspaceAdoptedFromAnnotationKey] = string(child.UID)
+	return adopted
+}
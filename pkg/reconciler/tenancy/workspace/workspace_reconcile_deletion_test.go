@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcileDeletion(t *testing.T) {
+	newLogicalCluster := func(path string, owner types.UID) *corev1alpha1.LogicalCluster {
+		return &corev1alpha1.LogicalCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        corev1alpha1.LogicalClusterName,
+				Annotations: map[string]string{core.LogicalClusterPathAnnotationKey: path},
+			},
+			Spec: corev1alpha1.LogicalClusterSpec{Owner: &corev1alpha1.LogicalClusterOwner{UID: owner}},
+		}
+	}
+	child := tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "child",
+			UID:         "child-uid",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "cluster-ws"},
+		},
+		Status: tenancyv1beta1.WorkspaceStatus{
+			Cluster: "cluster-child",
+			URL:     "https://shard/clusters/cluster-child",
+			Phase:   corev1alpha1.LogicalClusterPhaseReady,
+		},
+	}
+
+	tests := map[string]struct {
+		policy         tenancyv1beta1.WorkspaceDeletionPolicy
+		owner          types.UID
+		children       []tenancyv1beta1.Workspace
+		wantFinalizer  bool
+		wantDeleted    bool
+		wantDeletedWS  []string
+		wantReason     string
+		wantChildOwner types.UID
+		wantChildPath  string
+	}{
+		"no children": {
+			wantFinalizer: true,
+			wantDeleted:   true,
+		},
+		"cascade": {
+			children:      []tenancyv1beta1.Workspace{child},
+			wantFinalizer: true,
+			wantDeletedWS: []string{"child"},
+			wantReason:    tenancyv1alpha1.WorkspaceChildrenDeletedDeleting,
+		},
+		"block": {
+			policy:        tenancyv1beta1.WorkspaceDeletionPolicyBlock,
+			children:      []tenancyv1beta1.Workspace{child},
+			wantFinalizer: true,
+			wantReason:    tenancyv1alpha1.WorkspaceChildrenDeletedBlocked,
+		},
+		"orphan": {
+			policy:         tenancyv1beta1.WorkspaceDeletionPolicyOrphan,
+			children:       []tenancyv1beta1.Workspace{child},
+			wantFinalizer:  true,
+			wantReason:     tenancyv1alpha1.WorkspaceChildrenDeletedOrphaning,
+			wantChildOwner: "adopted-uid",
+			wantChildPath:  "root:org:child",
+		},
+		"owned by another workspace": {
+			owner: "other-uid",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			owner := tc.owner
+			if owner == "" {
+				owner = "ws-uid"
+			}
+			logicalClusters := map[logicalcluster.Path]*corev1alpha1.LogicalCluster{
+				logicalcluster.NewPath("cluster-ws"):    newLogicalCluster("root:org:ws", owner),
+				logicalcluster.NewPath("cluster-child"): newLogicalCluster("root:org:ws:child", "child-uid"),
+			}
+			workspaces := map[string]*tenancyv1beta1.Workspace{}
+			var deleted bool
+			var deletedWorkspaces []string
+			var requeued bool
+
+			r := &deletionReconciler{
+				getLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error) {
+					if logicalCluster, found := logicalClusters[cluster]; found {
+						return logicalCluster, nil
+					}
+					return nil, apierrors.NewNotFound(corev1alpha1.Resource("logicalclusters"), corev1alpha1.LogicalClusterName)
+				},
+				deleteLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path) error {
+					require.Equal(t, "cluster-ws", cluster.String())
+					deleted = true
+					return nil
+				},
+				updateLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error {
+					logicalClusters[cluster] = logicalCluster
+					return nil
+				},
+				listWorkspaces: func(ctx context.Context, cluster logicalcluster.Path) ([]tenancyv1beta1.Workspace, error) {
+					require.Equal(t, "cluster-ws", cluster.String())
+					return tc.children, nil
+				},
+				getWorkspace: func(ctx context.Context, cluster logicalcluster.Path, name string) (*tenancyv1beta1.Workspace, error) {
+					if ws, found := workspaces[cluster.Join(name).String()]; found {
+						return ws, nil
+					}
+					return nil, apierrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+				},
+				createWorkspace: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+					workspace = workspace.DeepCopy()
+					workspace.UID = "adopted-uid"
+					workspace.Annotations[logicalcluster.AnnotationKey] = cluster.String()
+					workspaces[cluster.Join(workspace.Name).String()] = workspace
+					return workspace, nil
+				},
+				updateWorkspace: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+					workspaces[cluster.Join(workspace.Name).String()] = workspace
+					return workspace, nil
+				},
+				updateWorkspaceStatus: func(ctx context.Context, cluster logicalcluster.Path, workspace *tenancyv1beta1.Workspace) (*tenancyv1beta1.Workspace, error) {
+					workspaces[cluster.Join(workspace.Name).String()] = workspace
+					return workspace, nil
+				},
+				deleteWorkspace: func(ctx context.Context, cluster logicalcluster.Path, name string) error {
+					deletedWorkspaces = append(deletedWorkspaces, name)
+					return nil
+				},
+				requeueAfter: func(workspace *tenancyv1beta1.Workspace, after time.Duration) {
+					requeued = true
+				},
+			}
+
+			now := metav1.Now()
+			workspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "ws",
+					UID:               "ws-uid",
+					Annotations:       map[string]string{logicalcluster.AnnotationKey: "root:org"},
+					DeletionTimestamp: &now,
+					Finalizers:        []string{corev1alpha1.LogicalClusterFinalizer},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{DeletionPolicy: tc.policy},
+				Status: tenancyv1beta1.WorkspaceStatus{
+					Cluster: "cluster-ws",
+					Phase:   corev1alpha1.LogicalClusterPhaseReady,
+				},
+			}
+
+			_, err := r.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantFinalizer, len(workspace.Finalizers) > 0)
+			require.Equal(t, tc.wantDeleted, deleted)
+			require.Equal(t, tc.wantDeletedWS, deletedWorkspaces)
+			require.Equal(t, tc.wantReason != "", requeued)
+			if tc.wantReason != "" {
+				require.Equal(t, tc.wantReason, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceChildrenDeleted))
+			}
+
+			if tc.wantChildOwner != "" {
+				childLogicalCluster := logicalClusters[logicalcluster.NewPath("cluster-child")]
+				require.Equal(t, tc.wantChildOwner, childLogicalCluster.Spec.Owner.UID)
+				require.Equal(t, "root:org", childLogicalCluster.Spec.Owner.Cluster)
+				require.Equal(t, tc.wantChildPath, childLogicalCluster.Annotations[core.LogicalClusterPathAnnotationKey])
+
+				adopted := workspaces["root:org:child"]
+				require.NotNil(t, adopted)
+				require.Equal(t, "cluster-child", adopted.Status.Cluster)
+				require.Equal(t, corev1alpha1.LogicalClusterPhaseReady, adopted.Status.Phase)
+				require.NotContains(t, adopted.Annotations, workspaceAdoptedFromAnnotationKey)
+			}
+		})
+	}
+}
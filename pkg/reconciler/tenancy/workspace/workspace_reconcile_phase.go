@@ -26,6 +26,7 @@ import (
 	"k8s.io/klog/v2"
 
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -33,7 +34,10 @@ import (
 )
 
 type phaseReconciler struct {
-	getLogicalCluster func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error)
+	getLogicalCluster    func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error)
+	updateLogicalCluster func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error
+	getWorkspaceType     func(clusterName logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error)
+	deleteWorkspace      func(ctx context.Context, workspace *tenancyv1beta1.Workspace) error
 
 	requeueAfter func(workspace *tenancyv1beta1.Workspace, after time.Duration)
 }
@@ -61,10 +65,18 @@ func (r *phaseReconciler) reconcile(ctx context.Context, workspace *tenancyv1bet
 		workspace.Status.Initializers = logicalCluster.Status.Initializers
 
 		if initializers := workspace.Status.Initializers; len(initializers) > 0 {
+			timedOut, next := r.initializerTimeouts(logicalCluster, initializers)
+			if len(timedOut) > 0 {
+				return r.initializersTimedOut(ctx, workspace, logicalCluster, timedOut)
+			}
+
 			after := time.Since(logicalCluster.CreationTimestamp.Time) / 5
 			if max := time.Minute * 10; after > max {
 				after = max
 			}
+			if next > 0 && next < after {
+				after = next
+			}
 			logger.V(3).Info("LogicalCluster still has initializers, requeueing", "initializers", initializers, "after", after)
 			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceInitialized, tenancyv1alpha1.WorkspaceInitializedInitializerExists, conditionsv1alpha1.ConditionSeverityInfo, "Initializers still exist: %v", workspace.Status.Initializers)
 			r.requeueAfter(workspace, after)
@@ -111,3 +123,95 @@ func (r *phaseReconciler) reconcile(ctx context.Context, workspace *tenancyv1bet
 
 	return reconcileStatusContinue, nil
 }
+
+// timedOutInitializer is an initializer that has not finished within the timeout of its WorkspaceType.
+type timedOutInitializer struct {
+	initializer corev1alpha1.LogicalClusterInitializer
+	timeout     time.Duration
+	policy      tenancyv1alpha1.InitializerTimeoutPolicy
+}
+
+// initializerTimeouts returns the initializers that have timed out, and the duration until the next
+// timeout of the other initializers, or zero if they have none. The timeouts are counted from the
+// creation of the LogicalCluster, or from the last retry of its initialization.
+func (r *phaseReconciler) initializerTimeouts(logicalCluster *corev1alpha1.LogicalCluster, initializers []corev1alpha1.LogicalClusterInitializer) ([]timedOutInitializer, time.Duration) {
+	start := logicalCluster.CreationTimestamp.Time
+	if value, found := logicalCluster.Annotations[tenancyv1alpha1.ExperimentalInitializationRetriedAtAnnotationKey]; found {
+		if retriedAt, err := time.Parse(time.RFC3339, value); err == nil && retriedAt.After(start) {
+			start = retriedAt
+		}
+	}
+
+	var timedOut []timedOutInitializer
+	var next time.Duration
+	for _, initializer := range initializers {
+		clusterName, name, err := initialization.TypeFrom(initializer)
+		if err != nil {
+			continue
+		}
+		// system initializers have no WorkspaceType, and hence no timeout
+		wt, err := r.getWorkspaceType(clusterName.Path(), name)
+		if err != nil || wt.Spec.InitializerTimeout == nil {
+			continue
+		}
+		timeout := wt.Spec.InitializerTimeout.Duration
+		if remaining := timeout - time.Since(start); remaining <= 0 {
+			timedOut = append(timedOut, timedOutInitializer{initializer: initializer, timeout: timeout, policy: wt.Spec.InitializerTimeoutPolicy})
+		} else if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return timedOut, next
+}
+
+// initializersTimedOut applies the most disruptive timeout policy of the timed out initializers, Delete
+// prevailing over Retry, which prevails over Fail.
+func (r *phaseReconciler) initializersTimedOut(ctx context.Context, workspace *tenancyv1beta1.Workspace, logicalCluster *corev1alpha1.LogicalCluster, timedOut []timedOutInitializer) (reconcileStatus, error) {
+	logger := klog.FromContext(ctx).WithValues("reconciler", "phase", "cluster", workspace.Status.Cluster)
+
+	policy := tenancyv1alpha1.InitializerTimeoutPolicyFail
+	var retryAfter time.Duration
+	initializers := make([]corev1alpha1.LogicalClusterInitializer, 0, len(timedOut))
+	for _, t := range timedOut {
+		initializers = append(initializers, t.initializer)
+		switch t.policy {
+		case tenancyv1alpha1.InitializerTimeoutPolicyDelete:
+			policy = tenancyv1alpha1.InitializerTimeoutPolicyDelete
+		case tenancyv1alpha1.InitializerTimeoutPolicyRetry:
+			if policy != tenancyv1alpha1.InitializerTimeoutPolicyDelete {
+				policy = tenancyv1alpha1.InitializerTimeoutPolicyRetry
+			}
+			if retryAfter == 0 || t.timeout < retryAfter {
+				retryAfter = t.timeout
+			}
+		}
+	}
+
+	switch policy {
+	case tenancyv1alpha1.InitializerTimeoutPolicyDelete:
+		logger.Info("Initializers timed out, deleting workspace", "initializers", initializers)
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceInitialized, tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut, conditionsv1alpha1.ConditionSeverityError, "Initializers timed out, deleting the workspace: %v", initializers)
+		if err := r.deleteWorkspace(ctx, workspace); err != nil && !apierrors.IsNotFound(err) {
+			return reconcileStatusStopAndRequeue, err
+		}
+	case tenancyv1alpha1.InitializerTimeoutPolicyRetry:
+		logger.Info("Initializers timed out, retrying initialization", "initializers", initializers)
+		logicalCluster = logicalCluster.DeepCopy()
+		if logicalCluster.Annotations == nil {
+			logicalCluster.Annotations = map[string]string{}
+		}
+		logicalCluster.Annotations[tenancyv1alpha1.ExperimentalInitializationRetriedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+		if err := r.updateLogicalCluster(ctx, logicalcluster.NewPath(workspace.Status.Cluster), logicalCluster); err != nil {
+			return reconcileStatusStopAndRequeue, err
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceInitialized, tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut, conditionsv1alpha1.ConditionSeverityWarning, "Initializers timed out, retrying the initialization: %v", initializers)
+		r.requeueAfter(workspace, retryAfter)
+	default:
+		logger.Info("Initializers timed out", "initializers", initializers)
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceInitialized, tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut, conditionsv1alpha1.ConditionSeverityError, "Initializers timed out: %v", initializers)
+		// the initializers may still finish their work eventually
+		r.requeueAfter(workspace, time.Minute*10)
+	}
+
+	return reconcileStatusContinue, nil
+}
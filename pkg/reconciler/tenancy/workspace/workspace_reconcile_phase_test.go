@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcilePhaseInitializerTimeout(t *testing.T) {
+	tests := map[string]struct {
+		timeout       *metav1.Duration
+		policy        tenancyv1alpha1.InitializerTimeoutPolicy
+		retriedAt     string
+		wantReason    string
+		wantRetried   bool
+		wantDeleted   bool
+		wantRequeueLE time.Duration
+	}{
+		"no timeout": {
+			wantReason: tenancyv1alpha1.WorkspaceInitializedInitializerExists,
+		},
+		"not timed out": {
+			timeout:       &metav1.Duration{Duration: 2 * time.Hour},
+			wantReason:    tenancyv1alpha1.WorkspaceInitializedInitializerExists,
+			wantRequeueLE: time.Hour,
+		},
+		"timed out": {
+			timeout:    &metav1.Duration{Duration: 30 * time.Minute},
+			wantReason: tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut,
+		},
+		"timed out and retried": {
+			timeout:     &metav1.Duration{Duration: 30 * time.Minute},
+			policy:      tenancyv1alpha1.InitializerTimeoutPolicyRetry,
+			wantReason:  tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut,
+			wantRetried: true,
+		},
+		"recently retried": {
+			timeout:    &metav1.Duration{Duration: 30 * time.Minute},
+			policy:     tenancyv1alpha1.InitializerTimeoutPolicyRetry,
+			retriedAt:  time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+			wantReason: tenancyv1alpha1.WorkspaceInitializedInitializerExists,
+		},
+		"timed out and deleted": {
+			timeout:     &metav1.Duration{Duration: 30 * time.Minute},
+			policy:      tenancyv1alpha1.InitializerTimeoutPolicyDelete,
+			wantReason:  tenancyv1alpha1.WorkspaceInitializedInitializerTimedOut,
+			wantDeleted: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logicalCluster := &corev1alpha1.LogicalCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              corev1alpha1.LogicalClusterName,
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+					Annotations:       map[string]string{},
+				},
+				Status: corev1alpha1.LogicalClusterStatus{
+					Initializers: []corev1alpha1.LogicalClusterInitializer{"root:org:custom", "system:apibindings"},
+				},
+			}
+			if tc.retriedAt != "" {
+				logicalCluster.Annotations[tenancyv1alpha1.ExperimentalInitializationRetriedAtAnnotationKey] = tc.retriedAt
+			}
+
+			var retried, deleted bool
+			var requeue time.Duration
+			r := &phaseReconciler{
+				getLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path) (*corev1alpha1.LogicalCluster, error) {
+					return logicalCluster, nil
+				},
+				updateLogicalCluster: func(ctx context.Context, cluster logicalcluster.Path, logicalCluster *corev1alpha1.LogicalCluster) error {
+					_, retried = logicalCluster.Annotations[tenancyv1alpha1.ExperimentalInitializationRetriedAtAnnotationKey]
+					return nil
+				},
+				getWorkspaceType: func(clusterName logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error) {
+					if clusterName.String() != "root:org" || name != "custom" {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("workspacetypes"), name)
+					}
+					return &tenancyv1alpha1.WorkspaceType{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: tenancyv1alpha1.WorkspaceTypeSpec{
+							Initializer:              true,
+							InitializerTimeout:       tc.timeout,
+							InitializerTimeoutPolicy: tc.policy,
+						},
+					}, nil
+				},
+				deleteWorkspace: func(ctx context.Context, workspace *tenancyv1beta1.Workspace) error {
+					deleted = true
+					return nil
+				},
+				requeueAfter: func(workspace *tenancyv1beta1.Workspace, after time.Duration) {
+					requeue = after
+				},
+			}
+
+			workspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "ws"},
+				Status: tenancyv1beta1.WorkspaceStatus{
+					Cluster: "cluster-ws",
+					Phase:   corev1alpha1.LogicalClusterPhaseInitializing,
+				},
+			}
+			status, err := r.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+			require.Equal(t, reconcileStatusContinue, status)
+
+			require.Equal(t, corev1alpha1.LogicalClusterPhaseInitializing, workspace.Status.Phase)
+			require.Equal(t, tc.wantReason, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceInitialized))
+			require.Equal(t, tc.wantRetried, retried)
+			require.Equal(t, tc.wantDeleted, deleted)
+			if tc.wantRequeueLE > 0 {
+				require.LessOrEqual(t, requeue, tc.wantRequeueLE)
+			}
+		})
+	}
+}
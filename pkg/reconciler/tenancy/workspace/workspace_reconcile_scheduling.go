@@ -81,6 +81,9 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 	switch {
 	case !workspace.DeletionTimestamp.IsZero():
 		return reconcileStatusContinue, nil
+	case workspace.Annotations[workspaceAdoptedFromAnnotationKey] != "":
+		// the logical cluster of the workspace is being moved from a child of a deleted workspace
+		return reconcileStatusContinue, nil
 	case workspace.Status.Phase == corev1alpha1.LogicalClusterPhaseScheduling:
 		shardNameHash, hasShard := workspace.Annotations[workspaceShardAnnotationKey]
 		clusterNameString, hasCluster := workspace.Annotations[workspaceClusterAnnotationKey]
@@ -217,6 +220,15 @@ func (r *schedulingReconciler) chooseShardAndMarkCondition(logger klog.Logger, w
 		reason, message string
 	}{}
 	for _, shard := range shards {
+		if shard.Annotations[corev1alpha1.ShardUnschedulableAnnotationKey] == "true" {
+			invalidShards[shard.Name] = struct {
+				reason, message string
+			}{
+				reason:  "Unschedulable",
+				message: "shard is cordoned",
+			}
+			continue
+		}
 		if valid, reason, message := isValidShard(shard); valid {
 			validShards = append(validShards, shard)
 		} else {
@@ -281,6 +293,13 @@ func (r *schedulingReconciler) createLogicalCluster(ctx context.Context, shard *
 		return err
 	}
 
+	// record the revision of the type, for the migration to later revisions
+	cwt, err := r.getWorkspaceType(logicalcluster.NewPath(workspace.Spec.Type.Path), string(workspace.Spec.Type.Name))
+	if err != nil {
+		return err
+	}
+	logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey] = initialization.TypeRevision(cwt)
+
 	logicalClusterAdminClient, err := r.kcpLogicalClusterAdminClientFor(shard)
 	if err != nil {
 		return err
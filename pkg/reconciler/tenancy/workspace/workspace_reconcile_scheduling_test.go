@@ -43,6 +43,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/workspacetypeexists"
 	"github.com/kcp-dev/kcp/pkg/apis/core"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -228,6 +229,32 @@ func TestReconcileScheduling(t *testing.T) {
 			},
 			expectedStatus: reconcileStatusContinue,
 		},
+		{
+			name: "cordoned shards are not chosen, the ws is unscheduled",
+			initialShards: []*corev1alpha1.Shard{func() *corev1alpha1.Shard {
+				s := shard("root")
+				s.Annotations[corev1alpha1.ShardUnschedulableAnnotationKey] = "true"
+				return s
+			}()},
+			targetWorkspace:      workspace("foo"),
+			targetLogicalCluster: &corev1alpha1.LogicalCluster{},
+			validateWorkspace: func(t *testing.T, initialWS, wsAfterReconciliation *tenancyv1beta1.Workspace) {
+				t.Helper()
+
+				clearLastTransitionTimeOnWsConditions(wsAfterReconciliation)
+				initialWS.Status.Conditions = append(initialWS.Status.Conditions, conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+					Message:  "No available shards to schedule the workspace",
+				})
+				if !equality.Semantic.DeepEqual(wsAfterReconciliation, initialWS) {
+					t.Fatal(fmt.Errorf("unexpected Workspace:\n%s", cmp.Diff(wsAfterReconciliation, initialWS)))
+				}
+			},
+			expectedStatus: reconcileStatusContinue,
+		},
 		{
 			name: "the ws is scheduled onto requested shard (shard name in spec)",
 			targetWorkspace: func() *tenancyv1beta1.Workspace {
@@ -372,12 +399,19 @@ func wellKnownFooWSForPhaseTwo() *tenancyv1beta1.Workspace {
 }
 
 func wellKnownLogicalClusterForFooWS() *corev1alpha1.LogicalCluster {
+	var revision string
+	for _, cwt := range wellKnownWorkspaceTypes() {
+		if cwt.Name == "universal" {
+			revision = initialization.TypeRevision(cwt)
+		}
+	}
 	return &corev1alpha1.LogicalCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: corev1alpha1.LogicalClusterName,
 			Annotations: map[string]string{
 				tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey: `{"username":"kcp-admin"}`,
 				tenancyv1beta1.LogicalClusterTypeAnnotationKey:          "root:universal",
+				tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey:  revision,
 				core.LogicalClusterPathAnnotationKey:                    "root:foo",
 			},
 		},
@@ -45,6 +45,7 @@ import (
 	tenancyv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -57,7 +58,7 @@ func NewController(
 	workspaceTypeInformer tenancyinformers.WorkspaceTypeClusterInformer,
 	shardInformer corev1alpha1informers.ShardClusterInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	shardLister := shardInformer.Lister()
 	workspacetypeLister := workspaceTypeInformer.Lister()
@@ -34,6 +34,8 @@ import (
 )
 
 func (c *controller) reconcile(ctx context.Context, cwt *tenancyv1alpha1.WorkspaceType) {
+	cwt.Status.Revision = initialization.TypeRevision(cwt)
+
 	if err := c.updateVirtualWorkspaceURLs(ctx, cwt); err != nil {
 		conditions.MarkFalse(
 			cwt,
@@ -29,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
@@ -231,6 +232,7 @@ func TestReconcile(t *testing.T) {
 			}
 			c.reconcile(context.TODO(), testCase.cwt)
 			c.reconcile(context.TODO(), testCase.cwt) // relationships require resolved extensions
+			testCase.expected.Status.Revision = initialization.TypeRevision(testCase.cwt)
 			if diff := cmp.Diff(testCase.cwt, testCase.expected, cmpopts.IgnoreTypes(metav1.Time{})); diff != "" {
 				t.Errorf("incorrect WorkspaceType after reconciliation: %v", diff)
 			}
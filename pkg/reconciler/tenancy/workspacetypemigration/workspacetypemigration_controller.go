@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetypemigration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/admission/workspacetypeexists"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	corev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/core/v1alpha1"
+	tenancyv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspace"
+)
+
+const (
+	ControllerName = "kcp-workspacetype-migration"
+)
+
+// NewController returns a new controller migrating the logical clusters of the shard to the
+// current revision of the WorkspaceType of their workspace.
+func NewController(
+	kcpClusterClient kcpclientset.ClusterInterface,
+	logicalClusterInformer corev1alpha1informers.LogicalClusterClusterInformer,
+	workspaceTypeInformer tenancyv1alpha1informers.WorkspaceTypeClusterInformer,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
+
+	getWorkspaceType := func(path logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error) {
+		return indexers.ByPathAndName[*tenancyv1alpha1.WorkspaceType](tenancyv1alpha1.Resource("workspacetypes"), workspaceTypeInformer.Informer().GetIndexer(), path, name)
+	}
+
+	c := &controller{
+		queue: queue,
+
+		getLogicalCluster: func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+			return logicalClusterInformer.Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+		},
+		listLogicalClusters: func() ([]*corev1alpha1.LogicalCluster, error) {
+			return logicalClusterInformer.Lister().List(labels.Everything())
+		},
+		getWorkspaceType: getWorkspaceType,
+		getInitializers: func(path logicalcluster.Path, name string) ([]corev1alpha1.LogicalClusterInitializer, error) {
+			return workspace.LogicalClustersInitializers(workspacetypeexists.NewTransitiveTypeResolver(getWorkspaceType), getWorkspaceType, path, name)
+		},
+		updateLogicalCluster: func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error) {
+			return kcpClusterClient.Cluster(logicalcluster.From(logicalCluster).Path()).CoreV1alpha1().LogicalClusters().Update(ctx, logicalCluster, metav1.UpdateOptions{})
+		},
+		updateLogicalClusterStatus: func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error) {
+			return kcpClusterClient.Cluster(logicalcluster.From(logicalCluster).Path()).CoreV1alpha1().LogicalClusters().UpdateStatus(ctx, logicalCluster, metav1.UpdateOptions{})
+		},
+		requeueAfter: func(logicalCluster *corev1alpha1.LogicalCluster, after time.Duration) {
+			queue.AddAfter(kcpcache.ToClusterAwareKey(logicalcluster.From(logicalCluster).String(), "", corev1alpha1.LogicalClusterName), after)
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(workspaceTypeInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.ByLogicalClusterPathAndName: indexers.IndexByLogicalClusterPathAndName,
+	})
+
+	logicalClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueLogicalCluster(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueLogicalCluster(obj) },
+	})
+
+	workspaceTypeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspaceType(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspaceType(obj) },
+	})
+
+	return c
+}
+
+// controller migrates the logical clusters to the current revision of the WorkspaceType of their
+// workspace, as configured by the migration field of the WorkspaceType. A logical cluster is migrated
+// by re-running the initializers of the type, and the number of logical clusters being migrated at
+// the same time is bounded per shard.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getLogicalCluster   func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error)
+	listLogicalClusters func() ([]*corev1alpha1.LogicalCluster, error)
+	getWorkspaceType    func(path logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error)
+	getInitializers     func(path logicalcluster.Path, name string) ([]corev1alpha1.LogicalClusterInitializer, error)
+
+	updateLogicalCluster       func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error)
+	updateLogicalClusterStatus func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error)
+
+	requeueAfter func(logicalCluster *corev1alpha1.LogicalCluster, after time.Duration)
+}
+
+func (c *controller) enqueueLogicalCluster(obj interface{}) {
+	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(4).Info("queueing LogicalCluster")
+	c.queue.Add(key)
+}
+
+// enqueueWorkspaceType enqueues the logical clusters of the shard whose workspace is of the given type.
+func (c *controller) enqueueWorkspaceType(obj interface{}) {
+	cwt, ok := obj.(*tenancyv1alpha1.WorkspaceType)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a WorkspaceType, but is %T", obj))
+		return
+	}
+	if cwt.Spec.Migration == nil {
+		return
+	}
+
+	logicalClusters, err := c.listLogicalClusters()
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, logicalCluster := range logicalClusters {
+		// the type is only matched by name, the path being resolved when reconciling
+		typeValue, found := logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeAnnotationKey]
+		if !found {
+			continue
+		}
+		if _, name := logicalcluster.NewPath(typeValue).Split(); name != cwt.Name {
+			continue
+		}
+		c.enqueueLogicalCluster(logicalCluster)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
+	logicalCluster, err := c.getLogicalCluster(clusterName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), logicalCluster)
+	ctx = klog.NewContext(ctx, logger)
+
+	return c.reconcile(ctx, logicalCluster)
+}
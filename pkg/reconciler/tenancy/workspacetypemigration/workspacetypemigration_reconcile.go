@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetypemigration
+
+import (
+	"context"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// migrationRequeueAfter is the delay after which a logical cluster waiting for the migrations of
+// other logical clusters to finish is checked again.
+const migrationRequeueAfter = 10 * time.Second
+
+func (c *controller) reconcile(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) error {
+	logger := klog.FromContext(ctx)
+
+	typeValue, found := logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeAnnotationKey]
+	if !found || !logicalCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// finish the migration once the initializers are done
+	if _, migrating := logicalCluster.Annotations[tenancyv1beta1.LogicalClusterMigratingAnnotationKey]; migrating {
+		if logicalCluster.Status.Phase != corev1alpha1.LogicalClusterPhaseReady {
+			return nil
+		}
+		logger.Info("Finished migration", "revision", logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey])
+		logicalCluster = logicalCluster.DeepCopy()
+		delete(logicalCluster.Annotations, tenancyv1beta1.LogicalClusterMigratingAnnotationKey)
+		_, err := c.updateLogicalCluster(ctx, logicalCluster)
+		return err
+	}
+
+	if logicalCluster.Status.Phase != corev1alpha1.LogicalClusterPhaseReady {
+		return nil
+	}
+
+	typePath, typeName := logicalcluster.NewPath(typeValue).Split()
+	cwt, err := c.getWorkspaceType(typePath, typeName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	migration := cwt.Spec.Migration
+	if migration == nil || migration.Paused {
+		return nil
+	}
+	revision := initialization.TypeRevision(cwt)
+	if logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey] == revision {
+		return nil
+	}
+
+	// only the canaries are migrated until the revision is promoted
+	if migration.PromotedRevision != revision {
+		if migration.CanarySelector == nil {
+			return nil
+		}
+		selector, err := metav1.LabelSelectorAsSelector(migration.CanarySelector)
+		if err != nil {
+			logger.Error(err, "invalid canary selector", "workspaceType", typeValue)
+			return nil // nothing we can do until the WorkspaceType is fixed
+		}
+		if !selector.Matches(labels.Set(logicalCluster.Labels)) {
+			return nil
+		}
+	}
+
+	// this is only strict with a single worker
+	maxConcurrent := int(migration.MaxConcurrent)
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	logicalClusters, err := c.listLogicalClusters()
+	if err != nil {
+		return err
+	}
+	migrating := 0
+	for _, other := range logicalClusters {
+		if _, found := other.Annotations[tenancyv1beta1.LogicalClusterMigratingAnnotationKey]; found && other.Annotations[tenancyv1beta1.LogicalClusterTypeAnnotationKey] == typeValue {
+			migrating++
+		}
+	}
+	if migrating >= maxConcurrent {
+		logger.V(4).Info("Waiting for other migrations to finish", "migrating", migrating)
+		c.requeueAfter(logicalCluster, migrationRequeueAfter)
+		return nil
+	}
+
+	initializers, err := c.getInitializers(typePath, typeName)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Migrating to new revision of the workspace type", "revision", revision, "initializers", initializers)
+
+	// re-run the initializers first, so that a failure to record the revision below results in
+	// the initializers being run again rather than not at all
+	if len(initializers) > 0 {
+		logicalCluster = logicalCluster.DeepCopy()
+		logicalCluster.Status.Phase = corev1alpha1.LogicalClusterPhaseInitializing
+		logicalCluster.Status.Initializers = initializers
+		if logicalCluster, err = c.updateLogicalClusterStatus(ctx, logicalCluster); err != nil {
+			return err
+		}
+	}
+
+	logicalCluster = logicalCluster.DeepCopy()
+	logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey] = revision
+	logicalCluster.Annotations[tenancyv1beta1.LogicalClusterMigratingAnnotationKey] = "true"
+	_, err = c.updateLogicalCluster(ctx, logicalCluster)
+	return err
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetypemigration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func TestReconcile(t *testing.T) {
+	cwt := &tenancyv1alpha1.WorkspaceType{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec: tenancyv1alpha1.WorkspaceTypeSpec{
+			Initializer: true,
+		},
+	}
+	revision := initialization.TypeRevision(cwt)
+
+	tests := map[string]struct {
+		migration      *tenancyv1alpha1.WorkspaceTypeMigration
+		revision       string
+		labels         map[string]string
+		phase          corev1alpha1.LogicalClusterPhaseType
+		migrating      bool
+		otherMigrating bool
+		wantMigrated   bool
+		wantFinished   bool
+		wantRequeued   bool
+	}{
+		"no migration": {
+			revision: "old",
+		},
+		"up to date": {
+			migration: &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision},
+			revision:  revision,
+		},
+		"paused": {
+			migration: &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision, Paused: true},
+			revision:  "old",
+		},
+		"promoted": {
+			migration:    &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision},
+			revision:     "old",
+			wantMigrated: true,
+		},
+		"missing revision": {
+			migration:    &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision},
+			wantMigrated: true,
+		},
+		"not promoted": {
+			migration: &tenancyv1alpha1.WorkspaceTypeMigration{
+				CanarySelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}},
+				PromotedRevision: "old",
+			},
+			revision: "old",
+		},
+		"canary": {
+			migration: &tenancyv1alpha1.WorkspaceTypeMigration{
+				CanarySelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}},
+				PromotedRevision: "old",
+			},
+			revision:     "old",
+			labels:       map[string]string{"canary": "true"},
+			wantMigrated: true,
+		},
+		"max concurrent": {
+			migration:      &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision, MaxConcurrent: 1},
+			revision:       "old",
+			otherMigrating: true,
+			wantRequeued:   true,
+		},
+		"still initializing": {
+			migration: &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision},
+			revision:  revision,
+			phase:     corev1alpha1.LogicalClusterPhaseInitializing,
+			migrating: true,
+		},
+		"finished": {
+			migration:    &tenancyv1alpha1.WorkspaceTypeMigration{PromotedRevision: revision},
+			revision:     revision,
+			migrating:    true,
+			wantFinished: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cwt := cwt.DeepCopy()
+			cwt.Spec.Migration = tc.migration
+
+			phase := tc.phase
+			if phase == "" {
+				phase = corev1alpha1.LogicalClusterPhaseReady
+			}
+			logicalCluster := &corev1alpha1.LogicalCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   corev1alpha1.LogicalClusterName,
+					Labels: tc.labels,
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey:                   "cluster-ws",
+						tenancyv1beta1.LogicalClusterTypeAnnotationKey: "root:org:team",
+					},
+				},
+				Status: corev1alpha1.LogicalClusterStatus{Phase: phase},
+			}
+			if tc.revision != "" {
+				logicalCluster.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey] = tc.revision
+			}
+			if tc.migrating {
+				logicalCluster.Annotations[tenancyv1beta1.LogicalClusterMigratingAnnotationKey] = "true"
+			}
+			logicalClusters := []*corev1alpha1.LogicalCluster{logicalCluster}
+			if tc.otherMigrating {
+				other := logicalCluster.DeepCopy()
+				other.Annotations[logicalcluster.AnnotationKey] = "cluster-other"
+				other.Annotations[tenancyv1beta1.LogicalClusterMigratingAnnotationKey] = "true"
+				logicalClusters = append(logicalClusters, other)
+			}
+
+			var updated, updatedStatus *corev1alpha1.LogicalCluster
+			var requeued bool
+			c := &controller{
+				listLogicalClusters: func() ([]*corev1alpha1.LogicalCluster, error) {
+					return logicalClusters, nil
+				},
+				getWorkspaceType: func(path logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error) {
+					require.Equal(t, "root:org", path.String())
+					require.Equal(t, "team", name)
+					return cwt, nil
+				},
+				getInitializers: func(path logicalcluster.Path, name string) ([]corev1alpha1.LogicalClusterInitializer, error) {
+					return []corev1alpha1.LogicalClusterInitializer{"root:org:team", "system:apibindings"}, nil
+				},
+				updateLogicalCluster: func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error) {
+					updated = logicalCluster
+					return logicalCluster, nil
+				},
+				updateLogicalClusterStatus: func(ctx context.Context, logicalCluster *corev1alpha1.LogicalCluster) (*corev1alpha1.LogicalCluster, error) {
+					updatedStatus = logicalCluster
+					return logicalCluster, nil
+				},
+				requeueAfter: func(logicalCluster *corev1alpha1.LogicalCluster, after time.Duration) {
+					requeued = true
+				},
+			}
+
+			require.NoError(t, c.reconcile(context.Background(), logicalCluster))
+
+			require.Equal(t, tc.wantRequeued, requeued)
+			switch {
+			case tc.wantMigrated:
+				require.NotNil(t, updatedStatus)
+				require.Equal(t, corev1alpha1.LogicalClusterPhaseInitializing, updatedStatus.Status.Phase)
+				require.Len(t, updatedStatus.Status.Initializers, 2)
+				require.NotNil(t, updated)
+				require.Equal(t, revision, updated.Annotations[tenancyv1beta1.LogicalClusterTypeRevisionAnnotationKey])
+				require.Contains(t, updated.Annotations, tenancyv1beta1.LogicalClusterMigratingAnnotationKey)
+			case tc.wantFinished:
+				require.Nil(t, updatedStatus)
+				require.NotNil(t, updated)
+				require.NotContains(t, updated.Annotations, tenancyv1beta1.LogicalClusterMigratingAnnotationKey)
+			default:
+				require.Nil(t, updatedStatus)
+				require.Nil(t, updated)
+			}
+		})
+	}
+}
@@ -38,6 +38,7 @@ import (
 	apiresourcev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
 	workloadv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const LocationInLogicalClusterIndexName = "LocationInLogicalCluster"
@@ -67,7 +68,7 @@ func NewClusterReconciler(
 	clusterInformer workloadv1alpha1informers.SyncTargetClusterInformer,
 	apiResourceImportInformer apiresourcev1alpha1informers.APIResourceImportClusterInformer,
 ) (*ClusterReconciler, ClusterQueue, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(name), name)
 
 	c := &ClusterReconciler{
 		name:                     name,
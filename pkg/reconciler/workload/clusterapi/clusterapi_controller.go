@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	corev1listers "github.com/kcp-dev/client-go/listers/core/v1"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	workloadv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+)
+
+const (
+	ControllerName = "kcp-workload-clusterapi"
+
+	// ClusterAnnotationKey is the annotation of the SyncTargets registered for a Cluster API
+	// Cluster, holding the <namespace>/<name> of the Cluster in the workspace of the SyncTarget.
+	ClusterAnnotationKey = "experimental.workload.kcp.io/capi-cluster"
+	// SyncerManifestsHashAnnotationKey is the annotation of the SyncTargets registered for a Cluster
+	// API Cluster, holding the hash of the syncer manifests last applied to the cluster.
+	SyncerManifestsHashAnnotationKey = "experimental.workload.kcp.io/capi-syncer-manifests-hash"
+	// SkipAnnotationKey is the annotation of the Cluster API Clusters that must not be registered
+	// as SyncTargets when set to "true".
+	SkipAnnotationKey = "experimental.workload.kcp.io/capi-skip-sync-target"
+
+	// clusterNameLabelKey is the label of the Cluster API kubeconfig Secrets holding the name of
+	// their Cluster.
+	clusterNameLabelKey = "cluster.x-k8s.io/cluster-name"
+)
+
+// ClusterGVR is the resource of the Cluster API Clusters, which a workspace gets by binding to
+// an APIExport exporting it.
+var ClusterGVR = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+
+// NewController returns a controller that registers the ready Cluster API Clusters as SyncTargets
+// of their workspace, and deploys the syncer to the provisioned clusters with their kubeconfig.
+func NewController(
+	kcpClusterClient kcpclientset.ClusterInterface,
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
+	ddsif *informer.DiscoveringDynamicSharedInformerFactory,
+	syncTargetInformer workloadv1alpha1informers.SyncTargetClusterInformer,
+	secretInformer kcpcorev1informers.SecretClusterInformer,
+	shardExternalURL func() string,
+	options Options,
+) (*Controller, error) {
+	c := &Controller{
+		queue:             workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName),
+		options:           options,
+		shardExternalURL:  shardExternalURL,
+		kcpClusterClient:  kcpClusterClient,
+		kubeClusterClient: kubeClusterClient,
+		ddsif:             ddsif,
+		syncTargetLister:  syncTargetInformer.Lister(),
+		secretLister:      secretInformer.Lister(),
+		newDownstreamClients: func(kubeconfig []byte) (dynamic.Interface, meta.RESTMapper, error) {
+			config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+			if err != nil {
+				return nil, nil, err
+			}
+			config = rest.AddUserAgent(config, ControllerName)
+			dynamicClient, err := dynamic.NewForConfig(config)
+			if err != nil {
+				return nil, nil, err
+			}
+			kubeClient, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return nil, nil, err
+			}
+			return dynamicClient, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kubeClient.Discovery())), nil
+		},
+	}
+
+	ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueCluster(gvr, obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) {
+			c.enqueueCluster(gvr, obj)
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueCluster(gvr, obj) },
+	})
+
+	secretInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			secret, ok := obj.(*corev1.Secret)
+			return ok && secret.Labels[clusterNameLabelKey] != "" && strings.HasSuffix(secret.Name, kubeconfigSecretSuffix)
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueKubeconfigSecret(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueKubeconfigSecret(obj) },
+		},
+	})
+
+	// the syncer permissions on the cluster depend on the synced resources of the SyncTarget
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, obj interface{}) {
+			old, ok := oldObj.(*workloadv1alpha1.SyncTarget)
+			if !ok {
+				return
+			}
+			if syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget); ok && !equality.Semantic.DeepEqual(old.Status.SyncedResources, syncTarget.Status.SyncedResources) {
+				c.enqueueSyncTarget(syncTarget)
+			}
+		},
+	})
+
+	return c, nil
+}
+
+// Controller registers the Cluster API Clusters as SyncTargets.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	options          Options
+	shardExternalURL func() string
+
+	kcpClusterClient     kcpclientset.ClusterInterface
+	kubeClusterClient    kcpkubernetesclientset.ClusterInterface
+	newDownstreamClients func(kubeconfig []byte) (dynamic.Interface, meta.RESTMapper, error)
+
+	ddsif            *informer.DiscoveringDynamicSharedInformerFactory
+	syncTargetLister workloadv1alpha1listers.SyncTargetClusterLister
+	secretLister     corev1listers.SecretClusterLister
+}
+
+func (c *Controller) enqueueCluster(gvr schema.GroupVersionResource, obj interface{}) {
+	if gvr != ClusterGVR {
+		return
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	key, err := kcpcache.MetaClusterNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(2).Info("queueing Cluster")
+	c.queue.Add(key)
+}
+
+func (c *Controller) enqueueKubeconfigSecret(obj interface{}) {
+	secret := obj.(*corev1.Secret)
+	key := kcpcache.ToClusterAwareKey(logicalcluster.From(secret).String(), secret.Namespace, secret.Labels[clusterNameLabelKey])
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(2).Info("queueing Cluster because of kubeconfig Secret", "secret", secret.Name)
+	c.queue.Add(key)
+}
+
+func (c *Controller) enqueueSyncTarget(syncTarget *workloadv1alpha1.SyncTarget) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(syncTarget.Annotations[ClusterAnnotationKey])
+	if err != nil || name == "" {
+		return
+	}
+	key := kcpcache.ToClusterAwareKey(logicalcluster.From(syncTarget).String(), namespace, name)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), key)
+	logger.V(4).Info("queueing Cluster because of SyncTarget", "syncTarget", syncTarget.Name)
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	clusterName, namespace, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return nil
+	}
+
+	inf, known, synced := c.ddsif.Informer(ClusterGVR)
+	if !known {
+		// no workspace of the shard has bound to the Cluster API yet
+		return nil
+	}
+	if !synced {
+		return fmt.Errorf("informer for %q is not synced; re-enqueueing", ClusterGVR)
+	}
+	obj, exists, err := inf.GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	var cluster *unstructured.Unstructured
+	if exists {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("unexpected type %T for Cluster %s", obj, key))
+			return nil
+		}
+		cluster = u
+		ctx = klog.NewContext(ctx, logging.WithObject(klog.FromContext(ctx), cluster))
+	}
+
+	return c.reconcile(ctx, clusterName, namespace, name, cluster)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		APIExports: []string{"root:compute:kubernetes"},
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.SyncerImage, "capi-syncer-image", o.SyncerImage, "Image of the syncer deployed to the clusters provisioned with Cluster API, which are registered as SyncTargets in the workspace of their Cluster object. The Cluster API controller is disabled if empty.")
+	fs.StringSliceVar(&o.APIExports, "capi-sync-target-apiexports", o.APIExports, "APIExports supported by the SyncTargets of the clusters provisioned with Cluster API, as <workspace path>:<apiexport>.")
+	fs.StringSliceVar(&o.ResourcesToSync, "capi-sync-target-resources", o.ResourcesToSync, "Resources synchronized by the syncers of the clusters provisioned with Cluster API, in addition to those of the supported APIExports.")
+	fs.StringVar(&o.ServerURL, "capi-kcp-server-url", o.ServerURL, "URL of kcp used by the syncers of the clusters provisioned with Cluster API. Defaults to the shard external URL.")
+	fs.StringVar(&o.CAFile, "capi-kcp-ca-file", o.CAFile, "Path to the CA bundle used by the syncers of the clusters provisioned with Cluster API to verify the kcp serving certificate.")
+	return o
+}
+
+type Options struct {
+	SyncerImage     string
+	APIExports      []string
+	ResourcesToSync []string
+	ServerURL       string
+	CAFile          string
+}
+
+// Enabled returns whether a syncer image is configured.
+func (o *Options) Enabled() bool {
+	return o.SyncerImage != ""
+}
+
+func (o *Options) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	for _, export := range o.APIExports {
+		path, name := logicalcluster.NewPath(export).Split()
+		if name == "" || !path.IsValid() {
+			return fmt.Errorf("--capi-sync-target-apiexports must be <workspace path>:<apiexport> (%s)", export)
+		}
+	}
+	if o.ServerURL != "" {
+		if u, err := url.Parse(o.ServerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("--capi-kcp-server-url must be an absolute URL (%s)", o.ServerURL)
+		}
+	}
+	if o.CAFile != "" {
+		if _, err := os.Stat(o.CAFile); err != nil {
+			return fmt.Errorf("--capi-kcp-ca-file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadplugin "github.com/kcp-dev/kcp/pkg/cliplugins/workload/plugin"
+)
+
+const (
+	// kubeconfigSecretSuffix is the suffix of the name of the Secret Cluster API writes the
+	// kubeconfig of a provisioned cluster to, in the namespace of its Cluster.
+	kubeconfigSecretSuffix = "-kubeconfig"
+	// kubeconfigSecretKey is the key of the kubeconfig in the Cluster API kubeconfig Secrets.
+	kubeconfigSecretKey = "value"
+)
+
+func (c *Controller) reconcile(ctx context.Context, clusterName logicalcluster.Name, namespace, name string, cluster *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+
+	if cluster == nil || cluster.GetDeletionTimestamp() != nil || cluster.GetAnnotations()[SkipAnnotationKey] == "true" {
+		// the SyncTarget owns the syncer ServiceAccount and its RBAC, which are deleted with it
+		return c.deleteSyncTargets(ctx, clusterName, namespace, name)
+	}
+
+	if !isProvisioned(cluster) {
+		logger.V(4).Info("Cluster is not provisioned yet")
+		return nil
+	}
+
+	syncTarget, err := c.ensureSyncTarget(ctx, clusterName, cluster)
+	if err != nil {
+		return err
+	}
+	if syncTarget == nil {
+		return nil
+	}
+
+	syncerID := workloadplugin.GetSyncerID(syncTarget)
+	token, err := c.ensureSyncerServiceAccount(ctx, clusterName, namespace, syncTarget, syncerID)
+	if err != nil {
+		return err
+	}
+
+	secret, err := c.secretLister.Cluster(clusterName).Secrets(namespace).Get(name + kubeconfigSecretSuffix)
+	if apierrors.IsNotFound(err) {
+		logger.V(2).Info("waiting for the kubeconfig Secret of the Cluster")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	kubeconfig := secret.Data[kubeconfigSecretKey]
+	if len(kubeconfig) == 0 {
+		logger.V(2).Info("waiting for the kubeconfig of the Cluster", "secret", secret.Name)
+		return nil
+	}
+
+	manifests, err := c.renderSyncerManifests(syncTarget, namespace, syncerID, token)
+	if err != nil {
+		return err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(manifests))
+	if syncTarget.Annotations[SyncerManifestsHashAnnotationKey] == hash {
+		return nil
+	}
+
+	dynamicClient, mapper, err := c.newDownstreamClients(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("invalid kubeconfig in Secret %s|%s/%s: %w", clusterName, namespace, secret.Name, err)
+	}
+	if err := applyManifests(ctx, dynamicClient, mapper, manifests); err != nil {
+		return fmt.Errorf("failed to deploy the syncer of SyncTarget %s|%s: %w", clusterName, syncTarget.Name, err)
+	}
+	logger.Info("deployed the syncer to the Cluster", "syncTarget", syncTarget.Name)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{SyncerManifestsHashAnnotationKey: hash},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName.Path()).WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTarget.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// isProvisioned returns whether the infrastructure and the control plane of a Cluster are ready.
+func isProvisioned(cluster *unstructured.Unstructured) bool {
+	infrastructureReady, _, _ := unstructured.NestedBool(cluster.Object, "status", "infrastructureReady")
+	controlPlaneReady, _, _ := unstructured.NestedBool(cluster.Object, "status", "controlPlaneReady")
+	return infrastructureReady && controlPlaneReady
+}
+
+// supportedAPIExports returns the APIExports supported by the SyncTargets, including the local
+// kubernetes APIExport if resources to sync are configured, like kubectl kcp workload sync does.
+func (c *Controller) supportedAPIExports() []tenancyv1alpha1.APIExportReference {
+	exports := make([]tenancyv1alpha1.APIExportReference, 0, len(c.options.APIExports)+1)
+	for _, export := range c.options.APIExports {
+		path, name := logicalcluster.NewPath(export).Split()
+		exports = append(exports, tenancyv1alpha1.APIExportReference{
+			Export: name,
+			Path:   path.String(),
+		})
+	}
+	if len(c.options.ResourcesToSync) > 0 && !sets.NewString(c.options.APIExports...).Has("kubernetes") {
+		exports = append(exports, tenancyv1alpha1.APIExportReference{
+			Export: "kubernetes",
+		})
+	}
+	return exports
+}
+
+// ensureSyncTarget creates or updates the SyncTarget of the Cluster, named after it. It returns
+// nil if a SyncTarget of that name exists that has not been registered for the Cluster.
+func (c *Controller) ensureSyncTarget(ctx context.Context, clusterName logicalcluster.Name, cluster *unstructured.Unstructured) (*workloadv1alpha1.SyncTarget, error) {
+	logger := klog.FromContext(ctx)
+	clusterRef := cluster.GetNamespace() + "/" + cluster.GetName()
+	exports := c.supportedAPIExports()
+
+	syncTarget, err := c.syncTargetLister.Cluster(clusterName).Get(cluster.GetName())
+	if apierrors.IsNotFound(err) {
+		logger.Info("registering the Cluster as SyncTarget")
+		return c.kcpClusterClient.Cluster(clusterName.Path()).WorkloadV1alpha1().SyncTargets().Create(ctx, &workloadv1alpha1.SyncTarget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cluster.GetName(),
+				Annotations: map[string]string{ClusterAnnotationKey: clusterRef},
+			},
+			Spec: workloadv1alpha1.SyncTargetSpec{
+				SupportedAPIExports: exports,
+			},
+		}, metav1.CreateOptions{})
+	} else if err != nil {
+		return nil, err
+	}
+
+	if syncTarget.Annotations[ClusterAnnotationKey] != clusterRef {
+		logger.Info("not registering the Cluster, a SyncTarget of the same name already exists", "syncTarget", syncTarget.Name)
+		return nil, nil
+	}
+	if equality.Semantic.DeepEqual(syncTarget.Spec.SupportedAPIExports, exports) {
+		return syncTarget, nil
+	}
+
+	syncTarget = syncTarget.DeepCopy()
+	syncTarget.Spec.SupportedAPIExports = exports
+	return c.kcpClusterClient.Cluster(clusterName.Path()).WorkloadV1alpha1().SyncTargets().Update(ctx, syncTarget, metav1.UpdateOptions{})
+}
+
+// deleteSyncTargets deletes the SyncTargets registered for the Cluster.
+func (c *Controller) deleteSyncTargets(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error {
+	syncTargets, err := c.syncTargetLister.Cluster(clusterName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, syncTarget := range syncTargets {
+		if syncTarget.Annotations[ClusterAnnotationKey] != namespace+"/"+name || syncTarget.DeletionTimestamp != nil {
+			continue
+		}
+		klog.FromContext(ctx).Info("deleting the SyncTarget of the Cluster", "syncTarget", syncTarget.Name)
+		err := c.kcpClusterClient.Cluster(clusterName.Path()).WorkloadV1alpha1().SyncTargets().Delete(ctx, syncTarget.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureSyncerServiceAccount creates the ServiceAccount of the syncer and grants it its
+// permissions in the workspace, like kubectl kcp workload sync does. It returns the token of
+// the ServiceAccount.
+func (c *Controller) ensureSyncerServiceAccount(ctx context.Context, clusterName logicalcluster.Name, namespace string, syncTarget *workloadv1alpha1.SyncTarget, syncerID string) (string, error) {
+	kubeClient := c.kubeClusterClient.Cluster(clusterName.Path())
+	ownerReferences := []metav1.OwnerReference{{
+		APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "SyncTarget",
+		Name:       syncTarget.Name,
+		UID:        syncTarget.UID,
+	}}
+
+	sa, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, syncerID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		sa, err = kubeClient.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            syncerID,
+				OwnerReferences: ownerReferences,
+			},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rules := workloadplugin.SyncerPolicyRules(syncTarget.Name)
+	cr, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, syncerID, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := kubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            syncerID,
+				OwnerReferences: ownerReferences,
+			},
+			Rules: rules,
+		}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", err
+		}
+	case err != nil:
+		return "", err
+	case !equality.Semantic.DeepEqual(cr.Rules, rules):
+		cr = cr.DeepCopy()
+		cr.Rules = rules
+		if _, err := kubeClient.RbacV1().ClusterRoles().Update(ctx, cr, metav1.UpdateOptions{}); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, syncerID, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		if _, err := kubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            syncerID,
+				OwnerReferences: ownerReferences,
+			},
+			Subjects: []rbacv1.Subject{{
+				Kind:      "ServiceAccount",
+				Name:      syncerID,
+				Namespace: namespace,
+			}},
+			RoleRef: rbacv1.RoleRef{
+				Kind:     "ClusterRole",
+				Name:     syncerID,
+				APIGroup: rbacv1.GroupName,
+			},
+		}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	if len(sa.Secrets) == 0 {
+		// retried with backoff until the token controller has issued the token
+		return "", fmt.Errorf("token of ServiceAccount %s|%s/%s not issued yet", clusterName, namespace, syncerID)
+	}
+	tokenSecret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, sa.Secrets[0].Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	token := tokenSecret.Data["token"]
+	if len(token) == 0 {
+		return "", fmt.Errorf("token Secret %s|%s/%s has no token", clusterName, namespace, tokenSecret.Name)
+	}
+	return string(token), nil
+}
+
+// renderSyncerManifests renders the manifests deploying the syncer of the SyncTarget.
+func (c *Controller) renderSyncerManifests(syncTarget *workloadv1alpha1.SyncTarget, namespace, syncerID, token string) ([]byte, error) {
+	serverURL := c.options.ServerURL
+	if serverURL == "" {
+		serverURL = c.shardExternalURL()
+	}
+	serverURL, err := withPort(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var caData []byte
+	if c.options.CAFile != "" {
+		// read on every reconciliation to pick up rotations
+		if caData, err = os.ReadFile(c.options.CAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	defaults := workloadplugin.NewSyncOptions(genericclioptions.IOStreams{})
+	return workloadplugin.RenderSyncerResources(workloadplugin.TemplateInput{
+		ServerURL:    serverURL,
+		CAData:       base64.StdEncoding.EncodeToString(caData),
+		Token:        token,
+		KCPNamespace: namespace,
+		Namespace:    syncerID,
+
+		SyncTargetPath: logicalcluster.From(syncTarget).Path().String(),
+		SyncTarget:     syncTarget.Name,
+		SyncTargetUID:  string(syncTarget.UID),
+
+		Image:                               c.options.SyncerImage,
+		Replicas:                            defaults.Replicas,
+		ResourcesToSync:                     c.options.ResourcesToSync,
+		QPS:                                 defaults.QPS,
+		Burst:                               defaults.Burst,
+		APIImportPollIntervalString:         defaults.APIImportPollInterval.String(),
+		DownstreamNamespaceCleanDelayString: defaults.DownstreamNamespaceCleanDelay.String(),
+	}, syncerID, resourcesForPermission(c.options.ResourcesToSync, syncTarget).List())
+}
+
+// resourcesForPermission returns the resources the syncer is granted access to on the cluster.
+func resourcesForPermission(resourcesToSync []string, syncTarget *workloadv1alpha1.SyncTarget) sets.String {
+	resources := sets.NewString(resourcesToSync...)
+	// secrets and configmaps are always needed.
+	resources.Insert("secrets", "configmaps")
+	for _, rs := range syncTarget.Status.SyncedResources {
+		resources.Insert(fmt.Sprintf("%s.%s", rs.Resource, rs.Group))
+	}
+	return resources
+}
+
+// withPort returns the scheme and host of the URL, with the port made explicit, as
+// expected by the syncer.
+func withPort(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("kcp server URL %q is not absolute", s)
+	}
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// decodeManifests decodes the YAML documents of the manifests.
+func decodeManifests(manifests []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifests), 4096)
+	var objs []*unstructured.Unstructured
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); errors.Is(err, io.EOF) {
+			return objs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		objs = append(objs, u)
+	}
+}
+
+// applyManifests server-side applies the manifests to the cluster.
+func applyManifests(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, manifests []byte) error {
+	objs, err := decodeManifests(manifests)
+	if err != nil {
+		return err
+	}
+	force := true
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return err
+		}
+		var resource dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			resource = client.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: ControllerName, Force: &force}); err != nil {
+			return fmt.Errorf("failed to apply %s %s/%s: %w", gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
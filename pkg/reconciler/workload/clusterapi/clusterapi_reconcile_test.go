@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestIsProvisioned(t *testing.T) {
+	tests := map[string]struct {
+		status      map[string]interface{}
+		provisioned bool
+	}{
+		"no status":                  {},
+		"infrastructure ready":       {status: map[string]interface{}{"infrastructureReady": true}},
+		"control plane ready":        {status: map[string]interface{}{"controlPlaneReady": true}},
+		"infrastructure and control": {status: map[string]interface{}{"infrastructureReady": true, "controlPlaneReady": true}, provisioned: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cluster := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tc.status != nil {
+				cluster.Object["status"] = tc.status
+			}
+			require.Equal(t, tc.provisioned, isProvisioned(cluster))
+		})
+	}
+}
+
+func TestWithPort(t *testing.T) {
+	tests := map[string]struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		"https":         {url: "https://kcp.example.com", want: "https://kcp.example.com:443"},
+		"http":          {url: "http://kcp.example.com", want: "http://kcp.example.com:80"},
+		"explicit port": {url: "https://kcp.example.com:6443/clusters/root", want: "https://kcp.example.com:6443"},
+		"relative":      {url: "kcp.example.com", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := withPort(tc.url)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResourcesForPermission(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		Status: workloadv1alpha1.SyncTargetStatus{
+			SyncedResources: []workloadv1alpha1.ResourceToSync{
+				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}},
+			},
+		},
+	}
+	require.Equal(t, []string{"configmaps", "deployments.apps", "secrets", "services"}, resourcesForPermission([]string{"services"}, syncTarget).List())
+}
+
+func TestDecodeManifests(t *testing.T) {
+	objs, err := decodeManifests([]byte(`---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: kcp-syncer-a
+---
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kcp-syncer-a
+  namespace: kcp-syncer-a
+`))
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	require.Equal(t, "Namespace", objs[0].GetKind())
+	require.Equal(t, "kcp-syncer-a", objs[1].GetNamespace())
+}
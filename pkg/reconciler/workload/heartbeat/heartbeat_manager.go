@@ -46,18 +46,34 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 		),
 	)
 
+	now := time.Now()
+	inMaintenance := cluster.ActiveMaintenanceWindow(now) != nil
+	if inMaintenance {
+		conditions.MarkTrue(cluster, workloadv1alpha1.InMaintenance)
+	} else {
+		conditions.Delete(cluster, workloadv1alpha1.InMaintenance)
+	}
+	// Enqueue another check when the SyncTarget enters or leaves a maintenance window.
+	if dur := cluster.NextMaintenanceTransition(now); dur > 0 {
+		c.enqueueClusterAfter(cluster, dur)
+	}
+
 	latestHeartbeat := time.Time{}
 	if cluster.Status.LastSyncerHeartbeatTime != nil {
 		latestHeartbeat = cluster.Status.LastSyncerHeartbeatTime.Time
 	}
-	if latestHeartbeat.IsZero() {
+	if inMaintenance && (latestHeartbeat.IsZero() || now.Sub(latestHeartbeat) > c.heartbeatThreshold) {
+		// The missed heartbeats are expected during the maintenance, and are only
+		// accounted for once the maintenance window is over.
+		logger.V(5).Info("ignoring missed heartbeat for SyncTarget in maintenance")
+	} else if latestHeartbeat.IsZero() {
 		logger.V(5).Info("marking HeartbeatHealthy false for SyncTarget due to no heartbeat")
 		conditions.MarkFalse(cluster,
 			workloadv1alpha1.HeartbeatHealthy,
 			workloadv1alpha1.ErrorHeartbeatMissedReason,
 			conditionsv1alpha1.ConditionSeverityWarning,
 			"No heartbeat yet seen")
-	} else if time.Since(latestHeartbeat) > c.heartbeatThreshold {
+	} else if now.Sub(latestHeartbeat) > c.heartbeatThreshold {
 		logger.V(5).Info("marking HeartbeatHealthy false for SyncTarget due to a stale heartbeat")
 		conditions.MarkFalse(cluster,
 			workloadv1alpha1.HeartbeatHealthy,
@@ -30,10 +30,11 @@ import (
 
 func TestManager(t *testing.T) {
 	for _, c := range []struct {
-		desc              string
-		lastHeartbeatTime time.Time
-		wantDur           time.Duration
-		wantReady         bool
+		desc               string
+		lastHeartbeatTime  time.Time
+		maintenanceWindows []workloadv1alpha1.MaintenanceWindow
+		wantDur            time.Duration
+		wantReady          bool
 	}{{
 		desc:      "no last heartbeat",
 		wantReady: false,
@@ -46,6 +47,23 @@ func TestManager(t *testing.T) {
 		desc:              "not recent enough heartbeat",
 		lastHeartbeatTime: time.Now().Add(-90 * time.Second),
 		wantReady:         false,
+	}, {
+		desc:              "not recent enough heartbeat in maintenance",
+		lastHeartbeatTime: time.Now().Add(-90 * time.Second),
+		maintenanceWindows: []workloadv1alpha1.MaintenanceWindow{{
+			Start:    metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+			Duration: metav1.Duration{Duration: time.Hour},
+		}},
+		wantDur:   58 * time.Minute,
+		wantReady: true,
+	}, {
+		desc:              "not recent enough heartbeat after maintenance",
+		lastHeartbeatTime: time.Now().Add(-90 * time.Second),
+		maintenanceWindows: []workloadv1alpha1.MaintenanceWindow{{
+			Start:    metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			Duration: metav1.Duration{Duration: time.Hour},
+		}},
+		wantReady: false,
 	}} {
 		t.Run(c.desc, func(t *testing.T) {
 			var enqueued time.Duration
@@ -59,6 +77,9 @@ func TestManager(t *testing.T) {
 			ctx := context.Background()
 			heartbeat := metav1.NewTime(c.lastHeartbeatTime)
 			cl := &workloadv1alpha1.SyncTarget{
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					MaintenanceWindows: c.maintenanceWindows,
+				},
 				Status: workloadv1alpha1.SyncTargetStatus{
 					Conditions: []conditionsv1alpha1.Condition{{
 						Type:   workloadv1alpha1.HeartbeatHealthy,
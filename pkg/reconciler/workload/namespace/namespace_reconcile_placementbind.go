@@ -25,12 +25,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
 )
 
 // bindNamespaceReconciler updates the existing annotation and creates an empty one if
@@ -111,10 +111,5 @@ func filterValidPlacements(ns *corev1.Namespace, placements []*schedulingv1alpha
 }
 
 func isPlacementValidForNS(ns *corev1.Namespace, placement *schedulingv1alpha1.Placement) bool {
-	selector, err := metav1.LabelSelectorAsSelector(placement.Spec.NamespaceSelector)
-	if err != nil {
-		return false
-	}
-
-	return selector.Matches(labels.Set(ns.Labels))
+	return schedulingplacement.IsNamespaceSelected(ns, placement)
 }
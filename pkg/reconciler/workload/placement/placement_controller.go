@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
@@ -48,6 +49,9 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
 )
 
 const (
@@ -57,18 +61,20 @@ const (
 
 // NewController returns a new controller starting the process of selecting synctarget for a placement.
 func NewController(
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
 	kcpClusterClient kcpclientset.ClusterInterface,
 	locationInformer schedulinginformers.LocationClusterInformer,
 	syncTargetInformer workloadinformers.SyncTargetClusterInformer,
 	placementInformer schedulinginformers.PlacementClusterInformer,
 	apiBindingInformer apisinformers.APIBindingClusterInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName)
 
 	c := &controller{
 		queue: queue,
 
 		kcpClusterClient: kcpClusterClient,
+		recorder:         events.NewRecorder(kubeClusterClient, ControllerName),
 
 		locationLister:  locationInformer.Lister(),
 		locationIndexer: locationInformer.Informer().GetIndexer(),
@@ -164,6 +170,7 @@ type controller struct {
 	queue workqueue.RateLimitingInterface
 
 	kcpClusterClient kcpclientset.ClusterInterface
+	recorder         *events.Recorder
 
 	locationLister  schedulingv1alpha1listers.LocationClusterLister
 	locationIndexer cache.Indexer
@@ -271,6 +278,8 @@ func (c *controller) Start(ctx context.Context, numThreads int) {
 	logger.Info("Starting controller")
 	defer logger.Info("Shutting down controller")
 
+	c.recorder.Start(ctx)
+
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
 	}
@@ -341,6 +350,8 @@ func (c *controller) process(ctx context.Context, key string) (bool, error) {
 	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
 	if err := c.commit(ctx, oldResource, newResource); err != nil {
 		errs = append(errs, err)
+	} else {
+		schedulingplacement.RecordDecisionEvents(c.recorder, obj, old.Status.Decisions, obj.Status.Decisions)
 	}
 
 	return requeue, utilerrors.NewAggregate(errs)
@@ -21,7 +21,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v3"
 
@@ -37,6 +39,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
 )
 
 // placementSchedulingReconciler schedules placments according to the selected locations.
@@ -57,13 +60,19 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 	currentScheduled, foundScheduled := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
 
 	// 2. pick all valid synctargets in this placements
-	validSyncTargets, reason, message, err := r.getAllValidSyncTargetsForPlacement(ctx, placement)
+	validSyncTargets, rejected, reason, message, err := r.getAllValidSyncTargetsForPlacement(ctx, placement)
 	if err != nil {
 		return reconcileStatusStopAndRequeue, placement, err
 	}
 
 	// no valid synctarget, clean the annotation.
 	if len(validSyncTargets) == 0 {
+		schedulingplacement.RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+			Stage:    schedulingv1alpha1.PlacementDecisionStageSyncTarget,
+			Reason:   reason,
+			Message:  message,
+			Rejected: rejected,
+		})
 		if foundScheduled {
 			expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = nil
 			updated, err := r.patchPlacementAnnotation(ctx, clusterName.Path(), placement, expectedAnnotations)
@@ -81,6 +90,12 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 				continue
 			}
 			conditions.MarkTrue(placement, schedulingv1alpha1.PlacementScheduled)
+			schedulingplacement.RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+				Stage:    schedulingv1alpha1.PlacementDecisionStageSyncTarget,
+				Selected: syncTarget.Name,
+				Reason:   schedulingv1alpha1.SyncTargetSelectedReason,
+				Rejected: rejected,
+			})
 			return reconcileStatusContinue, placement, nil
 		}
 	}
@@ -90,14 +105,23 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 	// when the same synctargets are in multiple locations, we need to rethink whether we need a better algorithm or we need location
 	// to be exclusive.
 	scheduledSyncTarget := validSyncTargets[rand.Intn(len(validSyncTargets))]
+	schedulingplacement.RecordDecision(placement, schedulingv1alpha1.PlacementDecision{
+		Stage:    schedulingv1alpha1.PlacementDecisionStageSyncTarget,
+		Selected: scheduledSyncTarget.Name,
+		Reason:   schedulingv1alpha1.SyncTargetSelectedReason,
+		Rejected: rejected,
+	})
 	expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(scheduledSyncTarget), scheduledSyncTarget.Name)
 	updated, err := r.patchPlacementAnnotation(ctx, clusterName.Path(), placement, expectedAnnotations)
 	return reconcileStatusStopAndRequeue, updated, err
 }
 
-func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(ctx context.Context, placement *schedulingv1alpha1.Placement) ([]*workloadv1alpha1.SyncTarget, string, string, error) {
+// getAllValidSyncTargetsForPlacement returns the SyncTargets of the selected location the placement
+// can be scheduled to, and those rejected with the reason why. When there is no valid SyncTarget,
+// it also returns the reason and message of the PlacementScheduled condition.
+func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(ctx context.Context, placement *schedulingv1alpha1.Placement) ([]*workloadv1alpha1.SyncTarget, []schedulingv1alpha1.PlacementRejection, string, string, error) {
 	if placement.Status.Phase == schedulingv1alpha1.PlacementPending || placement.Status.SelectedLocation == nil {
-		return nil, schedulingv1alpha1.ScheduleLocationNotFound, "No selected location is scheduled", nil
+		return nil, nil, schedulingv1alpha1.ScheduleLocationNotFound, "No selected location is scheduled", nil
 	}
 
 	locationWorkspace := logicalcluster.NewPath(placement.Status.SelectedLocation.Path)
@@ -106,48 +130,70 @@ func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(ctx c
 		placement.Status.SelectedLocation.LocationName)
 	switch {
 	case errors.IsNotFound(err):
-		return nil, schedulingv1alpha1.ScheduleLocationNotFound, "Selected location is not found", nil
+		return nil, nil, schedulingv1alpha1.ScheduleLocationNotFound, "Selected location is not found", nil
 	case err != nil:
-		return nil, "", "", err
+		return nil, nil, "", "", err
 	}
 
 	// find all synctargets in the location workspace
 	syncTargets, err := r.listSyncTarget(logicalcluster.From(location))
 	if err != nil {
-		return nil, "", "", err
+		return nil, nil, "", "", err
 	}
 
 	// filter the SyncTargets by location
 	locationSyncTargets, err := locationreconciler.LocationSyncTargets(syncTargets, location)
 	if len(locationSyncTargets) == 0 || err != nil {
-		return nil, schedulingv1alpha1.ScheduleNoValidTargetReason, "No SyncTarget in the selected Location", err
+		return nil, nil, schedulingv1alpha1.ScheduleNoValidTargetReason, "No SyncTarget in the selected Location", err
 	}
+	sort.Slice(locationSyncTargets, func(i, j int) bool {
+		return locationSyncTargets[i].Name < locationSyncTargets[j].Name
+	})
 
 	// filter the SyncTargets by APIs
-	validSyncTargets, message, err := r.filterAPICompatible(ctx, placement, locationSyncTargets)
+	validSyncTargets, rejected, message, err := r.filterAPICompatible(ctx, placement, locationSyncTargets)
 	if len(validSyncTargets) == 0 || err != nil {
-		return nil, schedulingv1alpha1.ScheduleNoValidTargetReason, message, err
+		return nil, rejected, schedulingv1alpha1.ScheduleNoValidTargetReason, message, err
 	}
 
-	// filter the SyncTargets by status.
-	validSyncTargets = locationreconciler.FilterNonEvicting(locationreconciler.FilterReady(validSyncTargets))
-	if len(validSyncTargets) == 0 {
-		return validSyncTargets, schedulingv1alpha1.ScheduleNoValidTargetReason, "No SyncTarget is ready or non evicting", nil
+	// filter the SyncTargets by status. The SyncTarget the placement is scheduled to stays
+	// available during its maintenance windows, so that its workloads are not rescheduled.
+	now := time.Now()
+	currentScheduled := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
+	availableSyncTargets := make([]*workloadv1alpha1.SyncTarget, 0, len(validSyncTargets))
+	for _, syncTarget := range validSyncTargets {
+		if reason, message := locationreconciler.Unavailability(syncTarget, now); reason != "" {
+			if reason == workloadv1alpha1.MaintenanceReason && workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name) == currentScheduled {
+				availableSyncTargets = append(availableSyncTargets, syncTarget)
+				continue
+			}
+			rejected = append(rejected, schedulingv1alpha1.PlacementRejection{
+				Name:    syncTarget.Name,
+				Reason:  reason,
+				Message: message,
+			})
+			continue
+		}
+		availableSyncTargets = append(availableSyncTargets, syncTarget)
+	}
+	if len(availableSyncTargets) == 0 {
+		return availableSyncTargets, rejected, schedulingv1alpha1.ScheduleNoValidTargetReason, "No SyncTarget is ready or non evicting", nil
 	}
 
-	return validSyncTargets, "", "", nil
+	return availableSyncTargets, rejected, "", "", nil
 }
 
-func (r *placementSchedulingReconciler) filterAPICompatible(ctx context.Context, placement *schedulingv1alpha1.Placement, syncTargets []*workloadv1alpha1.SyncTarget) ([]*workloadv1alpha1.SyncTarget, string, error) {
+func (r *placementSchedulingReconciler) filterAPICompatible(ctx context.Context, placement *schedulingv1alpha1.Placement, syncTargets []*workloadv1alpha1.SyncTarget) ([]*workloadv1alpha1.SyncTarget, []schedulingv1alpha1.PlacementRejection, string, error) {
 	logger := klog.FromContext(ctx)
 	var filteredSyncTargets []*workloadv1alpha1.SyncTarget
 
 	apiBindings, err := r.listWorkloadAPIBindings(logicalcluster.From(placement))
 	if err != nil {
-		return filteredSyncTargets, "", err
+		return filteredSyncTargets, nil, "", err
 	}
 
 	var messages []string
+	var rejected []schedulingv1alpha1.PlacementRejection
 	for _, syncTargert := range syncTargets {
 		supportedAPIMap := map[apisv1alpha1.GroupResource]workloadv1alpha1.ResourceToSync{}
 		for _, resource := range syncTargert.Status.SyncedResources {
@@ -164,6 +210,13 @@ func (r *placementSchedulingReconciler) filterAPICompatible(ctx context.Context,
 					Resource: desiredAPI.Resource,
 				}]
 				if !ok || supportedAPI.IdentityHash != desiredAPI.Schema.IdentityHash {
+					if supported {
+						rejected = append(rejected, schedulingv1alpha1.PlacementRejection{
+							Name:    syncTargert.Name,
+							Reason:  schedulingv1alpha1.APIIncompatibleReason,
+							Message: fmt.Sprintf("SyncTarget does not support APIBinding %s", binding.Name),
+						})
+					}
 					supported = false
 					messages = append(messages, fmt.Sprintf("SyncTarget %s does not support APIBinding %s", syncTargert.Name, binding.Name))
 					logger.V(4).Info("Does not support APIBindings", "workspace", logicalcluster.From(placement), "APIBinding", binding.Name, "syncTarget", syncTargert.Name)
@@ -177,7 +230,7 @@ func (r *placementSchedulingReconciler) filterAPICompatible(ctx context.Context,
 		}
 	}
 
-	return filteredSyncTargets, strings.Join(messages, ", "), nil
+	return filteredSyncTargets, rejected, strings.Join(messages, ", "), nil
 }
 
 func (r *placementSchedulingReconciler) patchPlacementAnnotation(ctx context.Context, clusterName logicalcluster.Path, placement *schedulingv1alpha1.Placement, annotations map[string]interface{}) (*schedulingv1alpha1.Placement, error) {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/kcp-dev/logicalcluster/v3"
@@ -96,6 +97,25 @@ func TestSchedulingReconcile(t *testing.T) {
 				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
 			},
 		},
+		{
+			name:        "synctarget scheduled in maintenance",
+			placement:   newPlacement("test", "test-location", "c1"),
+			location:    newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{inMaintenance(newSyncTarget("c1", false)), newSyncTarget("c2", true)},
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
+			},
+		},
+		{
+			name:        "no new workload scheduled to synctarget in maintenance",
+			placement:   newPlacement("test", "test-location", ""),
+			location:    newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{inMaintenance(newSyncTarget("c1", true)), newSyncTarget("c2", true)},
+			wantPatch:   true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+		},
 		{
 			name:      "schedule to syncTarget with compatible APIs",
 			placement: newPlacement("test", "test-location", ""),
@@ -331,6 +351,14 @@ func newSyncTarget(name string, ready bool, resources ...workloadv1alpha1.Resour
 	return syncTarget
 }
 
+func inMaintenance(syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.MaintenanceWindows = []workloadv1alpha1.MaintenanceWindow{{
+		Start:    metav1.NewTime(time.Now().Add(-time.Minute)),
+		Duration: metav1.Duration{Duration: time.Hour},
+	}}
+	return syncTarget
+}
+
 func newAPIBinding(name string, resources ...apisv1alpha1.BoundAPIResource) *apisv1alpha1.APIBinding {
 	return &apisv1alpha1.APIBinding{
 		ObjectMeta: metav1.ObjectMeta{
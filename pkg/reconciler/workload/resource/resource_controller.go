@@ -51,6 +51,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
@@ -67,8 +68,8 @@ func NewController(
 	namespaceInformer kcpcorev1informers.NamespaceClusterInformer,
 	placementInformer schedulingv1alpha1informers.PlacementClusterInformer,
 ) (*Controller, error) {
-	resourceQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-resource")
-	gvrQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-gvr")
+	resourceQueue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For("kcp-namespace-resource"), "kcp-namespace-resource")
+	gvrQueue := workqueue.NewNamedRateLimitingQueue(ratelimiter.For("kcp-namespace-gvr"), "kcp-namespace-gvr")
 
 	c := &Controller{
 		resourceQueue: resourceQueue,
@@ -61,6 +61,7 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 
 	var err error
 	var expectedSyncTargetKeys sets.String
+	var pinningStatus workloadv1alpha1.PinnedSyncTargetStatus
 	expectedDeletedSynctargetKeys := make(map[string]string)
 
 	namespaceName := obj.GetNamespace()
@@ -85,6 +86,16 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 
 		expectedSyncTargetKeys = getLocations(namespace.GetLabels(), false)
 		expectedDeletedSynctargetKeys = getDeletingLocations(namespace.GetAnnotations())
+
+		expectedSyncTargetKeys, pinningStatus, err = c.pinSyncTarget(obj, expectedSyncTargetKeys)
+		if err != nil {
+			return err
+		}
+		for syncTargetKey := range expectedDeletedSynctargetKeys {
+			if !expectedSyncTargetKeys.Has(syncTargetKey) {
+				delete(expectedDeletedSynctargetKeys, syncTargetKey)
+			}
+		}
 	} else {
 		// We only allow some cluster-wide types of resources.
 		if !syncershared.SyncableClusterScopedResources.Has(gvr.String()) {
@@ -104,6 +115,11 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 			return nil
 		}
 
+		expectedSyncTargetKeys, pinningStatus, err = c.pinSyncTarget(obj, expectedSyncTargetKeys)
+		if err != nil {
+			return err
+		}
+
 		deletionTimestamp := time.Now().Format(time.RFC3339)
 		currentLocations := getLocations(obj.GetLabels(), false)
 
@@ -122,6 +138,18 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 		annotationPatch, labelPatch = computePlacement(expectedSyncTargetKeys, expectedDeletedSynctargetKeys, obj)
 	}
 
+	if current, found := obj.GetAnnotations()[workloadv1alpha1.PinnedSyncTargetStatusAnnotationKey]; pinningStatus == "" && found {
+		if annotationPatch == nil {
+			annotationPatch = map[string]interface{}{}
+		}
+		annotationPatch[workloadv1alpha1.PinnedSyncTargetStatusAnnotationKey] = nil
+	} else if pinningStatus != "" && current != string(pinningStatus) {
+		if annotationPatch == nil {
+			annotationPatch = map[string]interface{}{}
+		}
+		annotationPatch[workloadv1alpha1.PinnedSyncTargetStatusAnnotationKey] = string(pinningStatus)
+	}
+
 	// clean finalizers from removed syncers
 	filteredFinalizers := make([]string, 0, len(obj.GetFinalizers()))
 	for _, f := range obj.GetFinalizers() {
@@ -196,6 +224,31 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	return nil
 }
 
+// pinSyncTarget restricts the SyncTargets the resource is expected to be scheduled to, to the one
+// it is pinned to with the workloadv1alpha1.PinnedSyncTargetAnnotationKey annotation, if any, and
+// returns the status of the pinning.
+func (c *Controller) pinSyncTarget(obj metav1.Object, syncTargetKeys sets.String) (sets.String, workloadv1alpha1.PinnedSyncTargetStatus, error) {
+	pinned := strings.TrimSpace(obj.GetAnnotations()[workloadv1alpha1.PinnedSyncTargetAnnotationKey])
+	if pinned == "" {
+		return syncTargetKeys, "", nil
+	}
+
+	for _, syncTargetKey := range syncTargetKeys.List() {
+		if syncTargetKey == pinned {
+			return sets.NewString(syncTargetKey), workloadv1alpha1.PinnedSyncTargetScheduled, nil
+		}
+		syncTarget, found, err := c.getSyncTargetFromKey(syncTargetKey)
+		if err != nil {
+			return nil, "", err
+		}
+		if found && syncTarget.Name == pinned {
+			return sets.NewString(syncTargetKey), workloadv1alpha1.PinnedSyncTargetScheduled, nil
+		}
+	}
+
+	return sets.NewString(), workloadv1alpha1.PinnedSyncTargetNotEligible, nil
+}
+
 func propagateDeletionTimestamp(logger logr.Logger, obj metav1.Object) map[string]interface{} {
 	logger.V(3).Info("resource is being deleted; setting the deletion per locations timestamps")
 	objAnnotations := obj.GetAnnotations()
@@ -25,7 +25,10 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 func namespace(annotations, labels map[string]string) *corev1.Namespace {
@@ -433,3 +436,60 @@ func TestGetDeletingLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestPinSyncTarget(t *testing.T) {
+	syncTargets := map[string]*workloadv1alpha1.SyncTarget{
+		"key-1": {ObjectMeta: metav1.ObjectMeta{Name: "cluster-1"}},
+		"key-2": {ObjectMeta: metav1.ObjectMeta{Name: "cluster-2"}},
+	}
+	c := &Controller{
+		getSyncTargetFromKey: func(syncTargetKey string) (*workloadv1alpha1.SyncTarget, bool, error) {
+			syncTarget, found := syncTargets[syncTargetKey]
+			return syncTarget, found, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		pinned     string
+		wantKeys   sets.String
+		wantStatus workloadv1alpha1.PinnedSyncTargetStatus
+	}{
+		{name: "not pinned",
+			wantKeys: sets.NewString("key-1", "key-2"),
+		},
+		{name: "pinned by name",
+			pinned:     "cluster-2",
+			wantKeys:   sets.NewString("key-2"),
+			wantStatus: workloadv1alpha1.PinnedSyncTargetScheduled,
+		},
+		{name: "pinned by key",
+			pinned:     "key-1",
+			wantKeys:   sets.NewString("key-1"),
+			wantStatus: workloadv1alpha1.PinnedSyncTargetScheduled,
+		},
+		{name: "pinned to a non eligible SyncTarget",
+			pinned:     "cluster-3",
+			wantKeys:   sets.NewString(),
+			wantStatus: workloadv1alpha1.PinnedSyncTargetNotEligible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+			if tt.pinned != "" {
+				annotations = map[string]string{workloadv1alpha1.PinnedSyncTargetAnnotationKey: tt.pinned}
+			}
+			gotKeys, gotStatus, err := c.pinSyncTarget(object(annotations, nil, nil, nil, "ns"), sets.NewString("key-1", "key-2"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !gotKeys.Equal(tt.wantKeys) {
+				t.Errorf("pinSyncTarget() keys = %v, want %v", gotKeys.List(), tt.wantKeys.List())
+			}
+			if gotStatus != tt.wantStatus {
+				t.Errorf("pinSyncTarget() status = %q, want %q", gotStatus, tt.wantStatus)
+			}
+		})
+	}
+}
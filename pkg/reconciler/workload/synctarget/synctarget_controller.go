@@ -43,6 +43,7 @@ import (
 	workloadv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const ControllerName = "kcp-synctarget-controller"
@@ -53,7 +54,7 @@ func NewController(
 	workspaceShardInformer corev1alpha1informers.ShardClusterInformer,
 ) *Controller {
 	c := &Controller{
-		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		queue:                workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName),
 		kcpClusterClient:     kcpClusterClient,
 		syncTargetIndexer:    syncTargetInformer.Informer().GetIndexer(),
 		workspaceShardLister: workspaceShardInformer.Lister(),
@@ -24,6 +24,7 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
 	reconcilerapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 )
 
@@ -50,16 +51,16 @@ func indexSyncTargetsByExports(obj interface{}) ([]string, error) {
 
 	clusterName := logicalcluster.From(synctarget)
 	if len(synctarget.Spec.SupportedAPIExports) == 0 {
-		return []string{clusterName.Path().Join(reconcilerapiexport.TemporaryComputeServiceExportName).String()}, nil
+		return []string{indexkeys.NewPathAndName(clusterName.Path(), reconcilerapiexport.TemporaryComputeServiceExportName).String()}, nil
 	}
 
 	keys := make([]string, 0, len(synctarget.Spec.SupportedAPIExports))
 	for _, export := range synctarget.Spec.SupportedAPIExports {
 		if len(export.Path) == 0 {
-			keys = append(keys, clusterName.Path().Join(export.Export).String())
+			keys = append(keys, indexkeys.NewPathAndName(clusterName.Path(), export.Export).String())
 			continue
 		}
-		keys = append(keys, logicalcluster.NewPath(export.Path).Join(export.Export).String())
+		keys = append(keys, indexkeys.NewPathAndName(logicalcluster.NewPath(export.Path), export.Export).String())
 	}
 
 	return keys, nil
@@ -18,18 +18,16 @@ package synctargetexports
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/go-logr/logr"
 	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -43,6 +41,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/core"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	workloadv1alpha1client "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/workload/v1alpha1"
 	apiresourcev1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
 	apisv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	workloadv1alpha1informers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
@@ -50,6 +49,11 @@ import (
 	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	workloadv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	reconcilermetrics "github.com/kcp-dev/kcp/pkg/reconciler/metrics"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
 )
 
 const (
@@ -57,6 +61,9 @@ const (
 
 	indexSyncTargetsByExport           = ControllerName + "ByExport"
 	indexAPIExportsByAPIResourceSchema = ControllerName + "ByAPIResourceSchema"
+
+	// statusBatchInterval is the interval over which the status updates of a SyncTarget are coalesced.
+	statusBatchInterval = 2 * time.Second
 )
 
 // NewController returns a controller which update syncedResource in status based on supportedExports in spec
@@ -68,9 +75,10 @@ func NewController(
 	apiResourceSchemaInformer apisv1alpha1informers.APIResourceSchemaClusterInformer,
 	apiResourceImportInformer apiresourcev1alpha1informers.APIResourceImportClusterInformer,
 ) (*Controller, error) {
+	logger := logging.WithReconciler(klog.Background(), ControllerName)
+
 	c := &Controller{
-		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
-		kcpClusterClient:     kcpClusterClient,
+		queue:                workqueue.NewNamedRateLimitingQueue(ratelimiter.For(ControllerName), ControllerName),
 		syncTargetIndexer:    syncTargetInformer.Informer().GetIndexer(),
 		syncTargetLister:     syncTargetInformer.Lister(),
 		apiExportsIndexer:    apiExportInformer.Informer().GetIndexer(),
@@ -78,6 +86,11 @@ func NewController(
 		resourceSchemaLister: apiResourceSchemaInformer.Lister(),
 		apiImportLister:      apiResourceImportInformer.Lister(),
 	}
+	c.commit = committer.NewStatusBatcher(
+		committer.NewStatusApplier[*SyncTarget, Patcher, *SyncTargetSpec, *SyncTargetStatus](kcpClusterClient.WorkloadV1alpha1().SyncTargets(), ControllerName, syncTargetStatusApplyConfiguration),
+		statusBatchInterval,
+		func(key string) { c.queue.AddRateLimited(key) },
+	).Commit
 
 	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
 		indexSyncTargetsByExport: indexSyncTargetsByExports,
@@ -92,7 +105,7 @@ func NewController(
 
 	// Watch for events related to SyncTargets
 	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) { c.enqueueSyncTarget(obj, "") },
+		AddFunc: func(obj interface{}) { c.enqueueSyncTarget(obj, logger, "") },
 		UpdateFunc: func(old, obj interface{}) {
 			oldCluster := old.(*workloadv1alpha1.SyncTarget)
 			newCluster := obj.(*workloadv1alpha1.SyncTarget)
@@ -100,33 +113,33 @@ func NewController(
 			// only enqueue when syncedResource or supportedAPIExported are changed.
 			if !equality.Semantic.DeepEqual(oldCluster.Spec.SupportedAPIExports, newCluster.Spec.SupportedAPIExports) ||
 				!equality.Semantic.DeepEqual(oldCluster.Status.SyncedResources, newCluster.Status.SyncedResources) {
-				c.enqueueSyncTarget(obj, "")
+				c.enqueueSyncTarget(obj, logger, "")
 			}
 		},
 		DeleteFunc: func(obj interface{}) {},
 	})
 
 	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj, "") },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj, "") },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, "") },
+		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj, logger, "") },
 	})
 
 	apiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIResourceSchema(obj) },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
+		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIResourceSchema(obj, logger) },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger) },
 	})
 
 	apiResourceImportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: c.enqueueAPIResourceImport,
+		AddFunc: func(obj interface{}) { c.enqueueAPIResourceImport(obj, logger) },
 		UpdateFunc: func(old, obj interface{}) {
 			oldImport := old.(*apiresourcev1alpha1.APIResourceImport)
 			newImport := obj.(*apiresourcev1alpha1.APIResourceImport)
 
 			// only enqueue when spec is changed.
 			if oldImport.Generation != newImport.Generation {
-				c.enqueueAPIResourceImport(obj)
+				c.enqueueAPIResourceImport(obj, logger)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {},
@@ -135,9 +148,44 @@ func NewController(
 	return c, nil
 }
 
+type SyncTarget = workloadv1alpha1.SyncTarget
+type SyncTargetSpec = workloadv1alpha1.SyncTargetSpec
+type SyncTargetStatus = workloadv1alpha1.SyncTargetStatus
+type Patcher = workloadv1alpha1client.SyncTargetInterface
+type Resource = committer.Resource[*SyncTargetSpec, *SyncTargetStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// syncTargetApplyConfiguration is the apply configuration of the SyncTarget status fields owned by
+// the controller.
+type syncTargetApplyConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		SyncedResources []workloadv1alpha1.ResourceToSync `json:"syncedResources"`
+	} `json:"status"`
+}
+
+func syncTargetStatusApplyConfiguration(r *Resource) interface{} {
+	ac := &syncTargetApplyConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "SyncTarget",
+		},
+	}
+	ac.Metadata.Name = r.Name
+	// an empty list, rather than null, so that the synced resources are removed
+	ac.Status.SyncedResources = []workloadv1alpha1.ResourceToSync{}
+	if r.Status != nil && r.Status.SyncedResources != nil {
+		ac.Status.SyncedResources = r.Status.SyncedResources
+	}
+	return ac
+}
+
 type Controller struct {
-	queue            workqueue.RateLimitingInterface
-	kcpClusterClient kcpclientset.ClusterInterface
+	queue  workqueue.RateLimitingInterface
+	commit CommitFunc
 
 	syncTargetIndexer    cache.Indexer
 	syncTargetLister     workloadv1alpha1listers.SyncTargetClusterLister
@@ -147,18 +195,18 @@ type Controller struct {
 	apiImportLister      apiresourcev1alpha1listers.APIResourceImportClusterLister
 }
 
-func (c *Controller) enqueueSyncTarget(obj interface{}, logSuffix string) {
+func (c *Controller) enqueueSyncTarget(obj interface{}, logger logr.Logger, logSuffix string) {
 	key, err := kcpcache.MetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 
-	klog.V(2).Infof("Queueing SyncTarget %q%s", key, logSuffix)
+	logging.WithQueueKey(logger, key).V(2).Info(fmt.Sprintf("queueing SyncTarget%s", logSuffix))
 	c.queue.Add(key)
 }
 
-func (c *Controller) enqueueAPIResourceImport(obj interface{}) {
+func (c *Controller) enqueueAPIResourceImport(obj interface{}, logger logr.Logger) {
 	apiImport, ok := obj.(*apiresourcev1alpha1.APIResourceImport)
 	if !ok {
 		runtime.HandleError(fmt.Errorf("obj is supposed to be a APIResourceImport, but is %T", obj))
@@ -168,11 +216,11 @@ func (c *Controller) enqueueAPIResourceImport(obj interface{}) {
 	lcluster := logicalcluster.From(apiImport)
 	key := kcpcache.ToClusterAwareKey(lcluster.String(), "", apiImport.Spec.Location)
 
-	klog.V(2).Infof("Queueing SyncTarget %q because of APIResourceImport %s", key, apiImport.Name)
+	logging.WithQueueKey(logging.WithObject(logger, apiImport), key).V(2).Info("queueing SyncTarget because of APIResourceImport")
 	c.queue.Add(key)
 }
 
-func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
+func (c *Controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSuffix string) {
 	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
 		obj = d.Obj
 	}
@@ -186,7 +234,7 @@ func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
 	// synctarget keys by full path
 	keys := sets.NewString()
 	if path := export.Annotations[core.LogicalClusterPathAnnotationKey]; path != "" {
-		pathKeys, err := c.syncTargetIndexer.IndexKeys(indexSyncTargetsByExport, logicalcluster.NewPath(path).Join(export.Name).String())
+		pathKeys, err := c.syncTargetIndexer.IndexKeys(indexSyncTargetsByExport, indexkeys.NewPathAndName(logicalcluster.NewPath(path), export.Name).String())
 		if err != nil {
 			runtime.HandleError(err)
 			return
@@ -194,7 +242,7 @@ func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
 		keys.Insert(pathKeys...)
 	}
 
-	clusterKeys, err := c.syncTargetIndexer.IndexKeys(indexSyncTargetsByExport, logicalcluster.From(export).Path().Join(export.Name).String())
+	clusterKeys, err := c.syncTargetIndexer.IndexKeys(indexSyncTargetsByExport, indexkeys.NewPathAndName(logicalcluster.From(export).Path(), export.Name).String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -207,12 +255,12 @@ func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
 			runtime.HandleError(err)
 			continue
 		}
-		c.enqueueSyncTarget(syncTarget, fmt.Sprintf(" because of APIExport %s%s", key, logSuffix))
+		c.enqueueSyncTarget(syncTarget, logging.WithObject(logger, export), fmt.Sprintf(" because of APIExport%s", logSuffix))
 	}
 }
 
 // enqueueAPIResourceSchema maps an APIResourceSchema to APIExports for enqueuing.
-func (c *Controller) enqueueAPIResourceSchema(obj interface{}) {
+func (c *Controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logger) {
 	key, err := kcpcache.DeletionHandlingMetaClusterNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
@@ -226,7 +274,7 @@ func (c *Controller) enqueueAPIResourceSchema(obj interface{}) {
 	}
 
 	for _, obj := range apiExports {
-		c.enqueueAPIExport(obj, fmt.Sprintf(" because of APIResourceSchema %s", key))
+		c.enqueueAPIExport(obj, logging.WithQueueKey(logger, key), " because of APIResourceSchema")
 	}
 }
 
@@ -235,8 +283,10 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.queue.ShutDown()
 
-	klog.InfoS("Starting workers", "controller", ControllerName)
-	defer klog.InfoS("Stopping workers", "controller", ControllerName)
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
 
 	for i := 0; i < numThreads; i++ {
 		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
@@ -258,12 +308,19 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	}
 	key := k.(string)
 
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
 	// No matter what, tell the queue we're done with this key, to unblock
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
-		runtime.HandleError(fmt.Errorf("failed to sync %q: %w", key, err))
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcilermetrics.ObserveReconcile(ControllerName, start, err)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
 	}
@@ -273,6 +330,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 }
 
 func (c *Controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
 	cluster, _, name, err := kcpcache.SplitMetaClusterNamespaceKey(key)
 	if err != nil {
 		runtime.HandleError(err)
@@ -282,11 +340,12 @@ func (c *Controller) process(ctx context.Context, key string) error {
 
 	syncTarget, err := c.syncTargetLister.Cluster(cluster).Get(name)
 	if err != nil {
-		klog.Errorf("Failed to get syncTarget with key %q because: %v", key, err)
+		logger.Error(err, "failed to get SyncTarget from lister")
 		return nil
 	}
 
-	klog.Infof("Processing syncTarget %q", key)
+	logger = logging.WithObject(logger, syncTarget)
+	ctx = klog.NewContext(ctx, logger)
 
 	currentSyncTarget := syncTarget.DeepCopy()
 
@@ -313,46 +372,9 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		return errors.NewAggregate(errs)
 	}
 
-	if equality.Semantic.DeepEqual(syncTarget.Status.SyncedResources, currentSyncTarget.Status.SyncedResources) {
-		return nil
-	}
-
-	oldData, err := json.Marshal(workloadv1alpha1.SyncTarget{
-		Status: workloadv1alpha1.SyncTargetStatus{
-			SyncedResources: syncTarget.Status.SyncedResources,
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to Marshal old data for placement %s: %w", key, err)
-	}
-
-	newData, err := json.Marshal(workloadv1alpha1.SyncTarget{
-		ObjectMeta: metav1.ObjectMeta{
-			UID:             syncTarget.UID,
-			ResourceVersion: syncTarget.ResourceVersion,
-		}, // to ensure they appear in the patch as preconditions
-		Status: workloadv1alpha1.SyncTargetStatus{
-			SyncedResources: currentSyncTarget.Status.SyncedResources,
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to Marshal new data for LocationDomain %s: %w", key, err)
-	}
-
-	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-	if err != nil {
-		klog.Errorf("Failed to create merge patch for syncTarget %q because: %v", key, err)
-		return err
-	}
-
-	clusterName := logicalcluster.From(currentSyncTarget)
-	klog.V(2).Infof("Patching synctarget %s|%s with patch %s", clusterName, currentSyncTarget.Name, string(patchBytes))
-	if _, err := c.kcpClusterClient.Cluster(clusterName.Path()).WorkloadV1alpha1().SyncTargets().Patch(ctx, currentSyncTarget.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
-		klog.Errorf("failed to patch sync target status: %v", err)
-		return err
-	}
-
-	return nil
+	oldResource := &Resource{ObjectMeta: syncTarget.ObjectMeta, Spec: &syncTarget.Spec, Status: &syncTarget.Status}
+	newResource := &Resource{ObjectMeta: currentSyncTarget.ObjectMeta, Spec: &currentSyncTarget.Spec, Status: &currentSyncTarget.Status}
+	return c.commit(ctx, oldResource, newResource)
 }
 
 func (c *Controller) getAPIExport(path logicalcluster.Path, name string) (*apisv1alpha1.APIExport, error) {
@@ -28,6 +28,7 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
 )
 
 // exportReconciler updates syncedResource in SyncTarget status based on supportedAPIExports.
@@ -37,6 +38,7 @@ type exportReconciler struct {
 }
 
 func (e *exportReconciler) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
+	logger := klog.FromContext(ctx)
 	var errs []error
 	var syncedResources []workloadv1alpha1.ResourceToSync
 	for _, exportRef := range syncTarget.Spec.SupportedAPIExports {
@@ -46,7 +48,7 @@ func (e *exportReconciler) reconcile(ctx context.Context, syncTarget *workloadv1
 		}
 		export, err := e.getAPIExport(path, exportRef.Export)
 		if apierrors.IsNotFound(err) {
-			klog.V(4).Infof("APIExport %q not found, skipping", path.Join(exportRef.Export))
+			logger.V(4).Info("APIExport not found, skipping", "apiexport", path.Join(exportRef.Export).String())
 			continue
 		}
 		if err != nil {
@@ -57,7 +59,7 @@ func (e *exportReconciler) reconcile(ctx context.Context, syncTarget *workloadv1
 		for _, schema := range export.Spec.LatestResourceSchemas {
 			syncedResource, err := e.convertSchemaToSyncedResource(logicalcluster.From(export), schema, export.Status.IdentityHash)
 			if err != nil {
-				klog.Warningf("cannot get schema: %v", err)
+				logging.WithObject(logger, export).Error(err, "cannot get APIResourceSchema", "schema", schema)
 				continue
 			}
 			syncedResources = append(syncedResources, syncedResource)
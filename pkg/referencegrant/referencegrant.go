@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referencegrant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+)
+
+// Grantee is a workspace, or the workspaces binding an APIExport, granted the read access to a
+// Secret or ConfigMap with the corev1alpha1.ReferenceGrantsAnnotationKey annotation. Exactly one
+// of the fields must be set.
+type Grantee struct {
+	// Workspace is the path, or the logical cluster name, of the granted workspace.
+	Workspace string `json:"workspace,omitempty"`
+	// Export is the <path>:<name> of the APIExport whose consumers are granted.
+	Export string `json:"export,omitempty"`
+}
+
+// Export is an APIExport, identified by the name of its logical cluster and its name.
+type Export struct {
+	ClusterName logicalcluster.Name
+	Name        string
+}
+
+// Reference is a reference to a Secret or ConfigMap of another workspace, as the value of the
+// corev1alpha1.ReferenceAnnotationKey annotation.
+type Reference struct {
+	Path      logicalcluster.Path
+	Namespace string
+	Name      string
+}
+
+// String returns the reference as <path>/<namespace>/<name>.
+func (r Reference) String() string {
+	return r.Path.String() + "/" + r.Namespace + "/" + r.Name
+}
+
+// ParseReference parses the value of the corev1alpha1.ReferenceAnnotationKey annotation.
+func ParseReference(value string) (Reference, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 {
+		return Reference{}, fmt.Errorf("invalid reference %q, expected <path>/<namespace>/<name>", value)
+	}
+	path, valid := logicalcluster.NewValidatedPath(parts[0])
+	if !valid || path.Empty() {
+		return Reference{}, fmt.Errorf("invalid workspace path %q in reference %q", parts[0], value)
+	}
+	if errs := validation.IsDNS1123Label(parts[1]); len(errs) > 0 {
+		return Reference{}, fmt.Errorf("invalid namespace %q in reference %q: %s", parts[1], value, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1123Subdomain(parts[2]); len(errs) > 0 {
+		return Reference{}, fmt.Errorf("invalid name %q in reference %q: %s", parts[2], value, strings.Join(errs, ", "))
+	}
+	return Reference{Path: path, Namespace: parts[1], Name: parts[2]}, nil
+}
+
+// ParseGrantees parses the value of the corev1alpha1.ReferenceGrantsAnnotationKey annotation.
+func ParseGrantees(value string) ([]Grantee, error) {
+	var grantees []Grantee
+	decoder := json.NewDecoder(bytes.NewBufferString(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&grantees); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", corev1alpha1.ReferenceGrantsAnnotationKey, err)
+	}
+	for i, grantee := range grantees {
+		switch {
+		case grantee.Workspace != "" && grantee.Export != "", grantee.Workspace == "" && grantee.Export == "":
+			return nil, fmt.Errorf("invalid %s annotation: exactly one of workspace or export must be set in grantee %d", corev1alpha1.ReferenceGrantsAnnotationKey, i)
+		case grantee.Workspace != "":
+			if _, valid := logicalcluster.NewValidatedPath(grantee.Workspace); !valid {
+				return nil, fmt.Errorf("invalid %s annotation: invalid workspace %q", corev1alpha1.ReferenceGrantsAnnotationKey, grantee.Workspace)
+			}
+		default:
+			path, valid := logicalcluster.NewValidatedPath(grantee.Export)
+			if parent, _ := path.Split(); !valid || parent.Empty() {
+				return nil, fmt.Errorf("invalid %s annotation: invalid export %q, expected <path>:<name>", corev1alpha1.ReferenceGrantsAnnotationKey, grantee.Export)
+			}
+		}
+	}
+	return grantees, nil
+}
+
+// Referrer is a workspace reading a Secret or ConfigMap of another workspace.
+type Referrer struct {
+	// ClusterName is the logical cluster name of the workspace.
+	ClusterName logicalcluster.Name
+	// Path is the canonical path of the workspace, if known.
+	Path logicalcluster.Path
+	// Exports are the APIExports bound in the workspace.
+	Exports []Export
+}
+
+// Granted returns whether the object grants the read access to the referrer. The paths of the
+// grantees are resolved to logical cluster names with resolve, which returns an empty name for
+// the paths that cannot be resolved.
+func Granted(obj metav1.Object, referrer Referrer, resolve func(path logicalcluster.Path) logicalcluster.Name) (bool, error) {
+	value, found := obj.GetAnnotations()[corev1alpha1.ReferenceGrantsAnnotationKey]
+	if !found {
+		return false, nil
+	}
+	grantees, err := ParseGrantees(value)
+	if err != nil {
+		return false, err
+	}
+
+	matches := func(path logicalcluster.Path, clusterName logicalcluster.Name) bool {
+		if path == clusterName.Path() {
+			return true
+		}
+		resolved := resolve(path)
+		return resolved != "" && resolved == clusterName
+	}
+
+	for _, grantee := range grantees {
+		if grantee.Workspace != "" {
+			if path := logicalcluster.NewPath(grantee.Workspace); path == referrer.Path || matches(path, referrer.ClusterName) {
+				return true, nil
+			}
+			continue
+		}
+		path, name := logicalcluster.NewPath(grantee.Export).Split()
+		for _, export := range referrer.Exports {
+			if export.Name == name && matches(path, export.ClusterName) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,509 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiservices serves the APIServices of the workspaces: the requests for a group/version
+// delegated by an APIService are proxied to its extension API server, and the delegated
+// group/versions are merged into the discovery of the workspace.
+package apiservices
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kcpkubernetesinformers "github.com/kcp-dev/client-go/informers"
+	kcpcorev1informers "github.com/kcp-dev/client-go/informers/core/v1"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/kcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
+
+	apiserviceadmission "github.com/kcp-dev/kcp/pkg/admission/apiservice"
+)
+
+const (
+	defaultServicePort = 443
+
+	// maxTransports bounds the number of cached transports, one per APIService and TLS configuration.
+	maxTransports = 1024
+)
+
+// SchemeGroupVersionResource is the resource of the APIServices.
+var SchemeGroupVersionResource = apiregistrationv1.SchemeGroupVersion.WithResource("apiservices")
+
+var (
+	errorScheme = runtime.NewScheme()
+	errorCodecs = serializer.NewCodecFactory(errorScheme)
+)
+
+func init() {
+	errorScheme.AddUnversionedTypes(metav1.Unversioned,
+		&metav1.Status{},
+	)
+}
+
+type transportKey struct {
+	clusterName logicalcluster.Name
+	name        string
+	serverName  string
+	caBundle    string
+}
+
+type transportEntry struct {
+	key transportKey
+	rt  http.RoundTripper
+	// refresh is the time the transport is renewed at, before its client certificate expires.
+	refresh time.Time
+}
+
+type handler struct {
+	delegate http.Handler
+
+	getAPIServices func(clusterName logicalcluster.Name) ([]*apiregistrationv1.APIService, error)
+	getService     func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error)
+	getLocalGroups func(ctx context.Context, clusterName logicalcluster.Name) (sets.String, error)
+
+	signer *ClientCertificateSigner
+	proxy  func(*http.Request) (*url.URL, error)
+	now    func() time.Time
+
+	lock            sync.Mutex
+	transports      *list.List
+	transportsByKey map[transportKey]*list.Element
+}
+
+// WithAPIServices proxies the requests for the group/versions delegated by the APIServices of the
+// logical cluster of the request to their extension API servers, and merges these group/versions
+// into the discovery of the logical cluster. It must run after the request is authorized.
+//
+// The extension API server of an APIService is the Service it references in the logical cluster of
+// the APIService, which must be of type ExternalName or have an external IP, as kcp does not run
+// Service endpoints. The serving certificate is verified with the caBundle of the APIService. The
+// APIServices of a reserved group, or of a group served locally, e.g., by a CRD or an APIBinding,
+// are ignored.
+//
+// The requests are authenticated with a client certificate issued by signer for the APIService,
+// and carry the user in the X-Remote-* headers, as with kube-aggregator. They are routed through
+// proxy, unless it is nil. Upgrade requests are not supported.
+func WithAPIServices(
+	delegate http.Handler,
+	apiServiceInformer kcpkubernetesinformers.GenericClusterInformer,
+	serviceInformer kcpcorev1informers.ServiceClusterInformer,
+	crdLister kcp.ClusterAwareCRDClusterLister,
+	signer *ClientCertificateSigner,
+	proxy func(*http.Request) (*url.URL, error),
+) http.Handler {
+	return &handler{
+		delegate: delegate,
+		getAPIServices: func(clusterName logicalcluster.Name) ([]*apiregistrationv1.APIService, error) {
+			if !apiServiceInformer.Informer().HasSynced() {
+				return nil, nil
+			}
+			objs, err := apiServiceInformer.Lister().ByCluster(clusterName).List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			apiServices := make([]*apiregistrationv1.APIService, 0, len(objs))
+			for _, obj := range objs {
+				u, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					return nil, fmt.Errorf("unexpected type %T", obj)
+				}
+				apiService := &apiregistrationv1.APIService{}
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), apiService); err != nil {
+					return nil, err
+				}
+				apiServices = append(apiServices, apiService)
+			}
+			return apiServices, nil
+		},
+		getService: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error) {
+			return serviceInformer.Lister().Cluster(clusterName).Services(namespace).Get(name)
+		},
+		getLocalGroups: func(ctx context.Context, clusterName logicalcluster.Name) (sets.String, error) {
+			crds, err := crdLister.Cluster(clusterName).List(ctx, labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			groups := sets.NewString()
+			for _, crd := range crds {
+				groups.Insert(crd.Spec.Group)
+			}
+			return groups, nil
+		},
+		signer:          signer,
+		proxy:           proxy,
+		now:             time.Now,
+		transports:      list.New(),
+		transportsByKey: map[transportKey]*list.Element{},
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	cluster := request.ClusterFrom(req.Context())
+	if cluster == nil || cluster.Wildcard || cluster.Name.Empty() || !strings.HasPrefix(req.URL.Path, "/apis") {
+		h.delegate.ServeHTTP(w, req)
+		return
+	}
+
+	var group, version string
+	switch segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/"); {
+	case segments[0] != "apis":
+		h.delegate.ServeHTTP(w, req)
+		return
+	case len(segments) >= 3:
+		version = segments[2]
+		fallthrough
+	case len(segments) == 2:
+		group = segments[1]
+	}
+
+	apiServices, err := h.getAPIServices(cluster.Name)
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	apiServices = delegated(apiServices)
+	if len(apiServices) > 0 {
+		localGroups, err := h.getLocalGroups(req.Context(), cluster.Name)
+		if err != nil {
+			responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+			return
+		}
+		apiServices = notLocal(apiServices, localGroups)
+	}
+	if len(apiServices) == 0 {
+		h.delegate.ServeHTTP(w, req)
+		return
+	}
+
+	switch {
+	case group == "":
+		h.serveGroupList(w, req, apiServices)
+	case version == "":
+		h.serveGroup(w, req, group, apiServices)
+	default:
+		for _, apiService := range apiServices {
+			if apiService.Spec.Group == group && apiService.Spec.Version == version {
+				h.serveProxy(w, req, cluster.Name, apiService)
+				return
+			}
+		}
+		h.delegate.ServeHTTP(w, req)
+	}
+}
+
+// delegated returns the APIServices delegating their group/version to an extension API server.
+func delegated(apiServices []*apiregistrationv1.APIService) []*apiregistrationv1.APIService {
+	var ret []*apiregistrationv1.APIService
+	for _, apiService := range apiServices {
+		if apiService.Spec.Service != nil {
+			ret = append(ret, apiService)
+		}
+	}
+	return ret
+}
+
+// notLocal returns the APIServices whose group is neither reserved nor served locally. The
+// admission rejects the APIServices of a reserved group, but a group can be served locally after
+// the APIService is created.
+func notLocal(apiServices []*apiregistrationv1.APIService, localGroups sets.String) []*apiregistrationv1.APIService {
+	var ret []*apiregistrationv1.APIService
+	for _, apiService := range apiServices {
+		if !apiserviceadmission.ReservedGroup(apiService.Spec.Group) && !localGroups.Has(apiService.Spec.Group) {
+			ret = append(ret, apiService)
+		}
+	}
+	return ret
+}
+
+func (h *handler) serveGroupList(w http.ResponseWriter, req *http.Request, apiServices []*apiregistrationv1.APIService) {
+	groupList := &metav1.APIGroupList{}
+	if !h.delegateDiscovery(w, req, groupList) {
+		return
+	}
+
+	local := sets.NewString()
+	for _, group := range groupList.Groups {
+		local.Insert(group.Name)
+	}
+	for _, group := range apiGroups(apiServices) {
+		if !local.Has(group.Name) {
+			groupList.Groups = append(groupList.Groups, group)
+		}
+	}
+
+	responsewriters.WriteObjectNegotiated(aggregator.DiscoveryCodecs, negotiation.DefaultEndpointRestrictions, schema.GroupVersion{}, w, req, http.StatusOK, groupList)
+}
+
+func (h *handler) serveGroup(w http.ResponseWriter, req *http.Request, name string, apiServices []*apiregistrationv1.APIService) {
+	for _, group := range apiGroups(apiServices) {
+		if group.Name == name {
+			responsewriters.WriteObjectNegotiated(aggregator.DiscoveryCodecs, negotiation.DefaultEndpointRestrictions, schema.GroupVersion{}, w, req, http.StatusOK, &group)
+			return
+		}
+	}
+	h.delegate.ServeHTTP(w, req)
+}
+
+// delegateDiscovery decodes the discovery response of the delegate into into. It returns false if
+// the response could not be decoded, in which case it has been written back to the client.
+func (h *handler) delegateDiscovery(w http.ResponseWriter, req *http.Request, into runtime.Object) bool {
+	writer := newInMemoryResponseWriter()
+	h.delegate.ServeHTTP(writer, utilnet.CloneRequest(req))
+	if writer.respCode != http.StatusOK {
+		for k, v := range writer.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(writer.respCode)
+		w.Write(writer.data) //nolint:errcheck
+		return false
+	}
+
+	if _, _, err := aggregator.DiscoveryCodecs.UniversalDeserializer().Decode(writer.data, nil, into); err != nil {
+		err = apierrors.NewInternalError(fmt.Errorf("unable to serve %s discovery: error decoding the local discovery: %w", req.URL.Path, err))
+		responsewriters.ErrorNegotiated(err, errorCodecs, schema.GroupVersion{}, w, req)
+		return false
+	}
+	return true
+}
+
+// apiGroups returns the discovery groups of the APIServices, ordered by group priority, with
+// their versions ordered by version priority, as with kube-aggregator.
+func apiGroups(apiServices []*apiregistrationv1.APIService) []metav1.APIGroup {
+	byGroup := map[string][]*apiregistrationv1.APIService{}
+	groupPriority := map[string]int32{}
+	for _, apiService := range apiServices {
+		group := apiService.Spec.Group
+		byGroup[group] = append(byGroup[group], apiService)
+		if apiService.Spec.GroupPriorityMinimum > groupPriority[group] {
+			groupPriority[group] = apiService.Spec.GroupPriorityMinimum
+		}
+	}
+
+	names := make([]string, 0, len(byGroup))
+	for name := range byGroup {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if groupPriority[names[i]] != groupPriority[names[j]] {
+			return groupPriority[names[i]] > groupPriority[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	groups := make([]metav1.APIGroup, 0, len(names))
+	for _, name := range names {
+		versions := byGroup[name]
+		sort.Slice(versions, func(i, j int) bool {
+			if versions[i].Spec.VersionPriority != versions[j].Spec.VersionPriority {
+				return versions[i].Spec.VersionPriority > versions[j].Spec.VersionPriority
+			}
+			return version.CompareKubeAwareVersionStrings(versions[i].Spec.Version, versions[j].Spec.Version) > 0
+		})
+
+		group := metav1.APIGroup{Name: name}
+		for _, apiService := range versions {
+			group.Versions = append(group.Versions, metav1.GroupVersionForDiscovery{
+				GroupVersion: apiService.Spec.Group + "/" + apiService.Spec.Version,
+				Version:      apiService.Spec.Version,
+			})
+		}
+		group.PreferredVersion = group.Versions[0]
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func (h *handler) serveProxy(w http.ResponseWriter, req *http.Request, clusterName logicalcluster.Name, apiService *apiregistrationv1.APIService) {
+	logger := klog.FromContext(req.Context()).WithValues("cluster", clusterName, "apiService", apiService.Name)
+
+	user, ok := request.UserFrom(req.Context())
+	if !ok {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("no user found in the context")), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	location, rt, err := h.backend(clusterName, apiService)
+	if err != nil {
+		logger.V(2).Info("failed to resolve the extension API server", "err", err)
+		responsewriters.ErrorNegotiated(apierrors.NewServiceUnavailable(fmt.Sprintf("service unavailable for APIService %s", apiService.Name)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = location.Scheme
+			r.URL.Host = location.Host
+			r.Host = location.Host
+			// the user is authenticated by kcp, and passed in the X-Remote-* headers
+			r.Header.Del("Authorization")
+		},
+		Transport:     transport.NewAuthProxyRoundTripper(user.GetName(), user.GetGroups(), user.GetExtra(), rt),
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.V(2).Info("failed to proxy the request to the extension API server", "err", err)
+			responsewriters.ErrorNegotiated(apierrors.NewServiceUnavailable(fmt.Sprintf("service unavailable for APIService %s", apiService.Name)), errorCodecs, schema.GroupVersion{}, w, r)
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// backend returns the location of the extension API server of the APIService, and the transport
+// to reach it.
+func (h *handler) backend(clusterName logicalcluster.Name, apiService *apiregistrationv1.APIService) (*url.URL, http.RoundTripper, error) {
+	ref := apiService.Spec.Service
+	port := int32(defaultServicePort)
+	if ref.Port != nil {
+		port = *ref.Port
+	}
+	service, err := h.getService(clusterName, ref.Namespace, ref.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get Service %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	key := transportKey{
+		clusterName: clusterName,
+		name:        apiService.Name,
+		caBundle:    string(apiService.Spec.CABundle),
+	}
+	var host string
+	switch {
+	case service.Spec.Type == corev1.ServiceTypeExternalName:
+		host = service.Spec.ExternalName
+		key.serverName = service.Spec.ExternalName
+	case len(service.Spec.ExternalIPs) > 0:
+		host = service.Spec.ExternalIPs[0]
+		key.serverName = service.Name + "." + service.Namespace + ".svc"
+	default:
+		return nil, nil, fmt.Errorf("service %s/%s must be of type ExternalName or have an external IP", service.Namespace, service.Name)
+	}
+
+	rt, err := h.transportFor(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &url.URL{Scheme: "https", Host: net.JoinHostPort(host, strconv.Itoa(int(port)))}, rt, nil
+}
+
+// transportFor returns the transport of the given APIService and TLS configuration, authenticated
+// with a client certificate issued for the APIService. The transports are cached, up to
+// maxTransports, and renewed before their client certificate expires.
+func (h *handler) transportFor(key transportKey) (http.RoundTripper, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if e, ok := h.transportsByKey[key]; ok {
+		entry := e.Value.(*transportEntry)
+		if h.now().Before(entry.refresh) {
+			h.transports.MoveToFront(e)
+			return entry.rt, nil
+		}
+		h.removeTransport(e)
+	}
+
+	cert, err := h.signer.issue(key.clusterName, key.name)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := transport.TLSConfigFor(&transport.Config{
+		TLS: transport.TLSConfig{
+			CAData:     []byte(key.caBundle),
+			CertData:   cert.certPEM,
+			KeyData:    cert.keyPEM,
+			ServerName: key.serverName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// not built with client-go, which caches the transports of each TLS configuration forever
+	rt := utilnet.SetTransportDefaults(&http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           h.proxy,
+	})
+
+	h.transportsByKey[key] = h.transports.PushFront(&transportEntry{
+		key:     key,
+		rt:      rt,
+		refresh: cert.notAfter.Add(-certificateLifetime / 2),
+	})
+	for h.transports.Len() > maxTransports {
+		h.removeTransport(h.transports.Back())
+	}
+	return rt, nil
+}
+
+func (h *handler) removeTransport(e *list.Element) {
+	entry := h.transports.Remove(e).(*transportEntry)
+	delete(h.transportsByKey, entry.key)
+	utilnet.CloseIdleConnectionsFor(entry.rt)
+}
+
+// inMemoryResponseWriter is a http.Writer that keeps the response in memory.
+type inMemoryResponseWriter struct {
+	writeHeaderCalled bool
+	header            http.Header
+	respCode          int
+	data              []byte
+}
+
+func newInMemoryResponseWriter() *inMemoryResponseWriter {
+	return &inMemoryResponseWriter{header: http.Header{}}
+}
+
+func (r *inMemoryResponseWriter) Header() http.Header {
+	return r.header
+}
+
+func (r *inMemoryResponseWriter) WriteHeader(code int) {
+	r.writeHeaderCalled = true
+	r.respCode = code
+}
+
+func (r *inMemoryResponseWriter) Write(in []byte) (int, error) {
+	if !r.writeHeaderCalled {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.data = append(r.data, in...)
+	return len(in), nil
+}
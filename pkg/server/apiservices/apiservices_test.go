@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiservices
+
+import (
+	"container/list"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"k8s.io/utils/pointer"
+)
+
+// newSigner returns a ClientCertificateSigner of a new CA, and the pool of the CA.
+func newSigner(t *testing.T) (*ClientCertificateSigner, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "apiservices-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	signer, err := NewClientCertificateSigner(certFile, keyFile)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	return signer, pool
+}
+
+func apiService(group, version string, groupPriority, versionPriority int32) *apiregistrationv1.APIService {
+	return &apiregistrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: version + "." + group},
+		Spec: apiregistrationv1.APIServiceSpec{
+			Group:                group,
+			Version:              version,
+			Service:              &apiregistrationv1.ServiceReference{Namespace: "default", Name: "api"},
+			GroupPriorityMinimum: groupPriority,
+			VersionPriority:      versionPriority,
+		},
+	}
+}
+
+func TestAPIGroups(t *testing.T) {
+	groups := apiGroups([]*apiregistrationv1.APIService{
+		apiService("metrics.example.com", "v1beta1", 100, 10),
+		apiService("metrics.example.com", "v1", 100, 20),
+		apiService("custom.example.com", "v1", 1000, 10),
+		apiService("custom.example.com", "v2", 1000, 10),
+	})
+
+	require.Equal(t, []metav1.APIGroup{
+		{
+			Name: "custom.example.com",
+			Versions: []metav1.GroupVersionForDiscovery{
+				{GroupVersion: "custom.example.com/v2", Version: "v2"},
+				{GroupVersion: "custom.example.com/v1", Version: "v1"},
+			},
+			PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "custom.example.com/v2", Version: "v2"},
+		},
+		{
+			Name: "metrics.example.com",
+			Versions: []metav1.GroupVersionForDiscovery{
+				{GroupVersion: "metrics.example.com/v1", Version: "v1"},
+				{GroupVersion: "metrics.example.com/v1beta1", Version: "v1beta1"},
+			},
+			PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "metrics.example.com/v1", Version: "v1"},
+		},
+	}, groups)
+}
+
+func TestWithAPIServices(t *testing.T) {
+	signer, clientCAs := newSigner(t)
+
+	var proxied *http.Request
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = req
+		w.Write([]byte("from the extension API server")) //nolint:errcheck
+	}))
+	backend.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	backend.StartTLS()
+	defer backend.Close()
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(backendURL.Port())
+	require.NoError(t, err)
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw})
+
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/apis":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&metav1.APIGroupList{ //nolint:errcheck
+				TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+				Groups: []metav1.APIGroup{{
+					Name:             "apis.kcp.io",
+					Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "apis.kcp.io/v1alpha1", Version: "v1alpha1"}},
+					PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "apis.kcp.io/v1alpha1", Version: "v1alpha1"},
+				}},
+			})
+		default:
+			w.Write([]byte("from kcp")) //nolint:errcheck
+		}
+	})
+
+	delegating := func(group string) *apiregistrationv1.APIService {
+		apiService := apiService(group, "v1", 100, 10)
+		apiService.Spec.Service.Port = pointer.Int32(int32(port))
+		apiService.Spec.CABundle = caBundle
+		return apiService
+	}
+	h := &handler{
+		delegate: delegate,
+		getAPIServices: func(clusterName logicalcluster.Name) ([]*apiregistrationv1.APIService, error) {
+			if clusterName != "root" {
+				return nil, nil
+			}
+			return []*apiregistrationv1.APIService{
+				delegating("metrics.example.com"),
+				// not delegated
+				{Spec: apiregistrationv1.APIServiceSpec{Group: "local.example.com", Version: "v1"}},
+				// reserved group
+				delegating("apis.kcp.io"),
+				// group served by a CRD
+				delegating("widgets.example.com"),
+			}, nil
+		},
+		getService: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Service, error) {
+			return &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: backendURL.Hostname()},
+			}, nil
+		},
+		getLocalGroups: func(_ context.Context, _ logicalcluster.Name) (sets.String, error) {
+			return sets.NewString("widgets.example.com"), nil
+		},
+		signer:          signer,
+		now:             time.Now,
+		transports:      list.New(),
+		transportsByKey: map[transportKey]*list.Element{},
+	}
+
+	serve := func(clusterName logicalcluster.Name, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Accept", "application/json")
+		ctx := request.WithCluster(req.Context(), request.Cluster{Name: clusterName})
+		ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "alice", Groups: []string{"team"}})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req.WithContext(ctx))
+		return w
+	}
+
+	t.Log("The delegated group/versions are merged into the discovery")
+	w := serve("root", "/apis")
+	require.Equal(t, http.StatusOK, w.Code)
+	groupList := &metav1.APIGroupList{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), groupList))
+	require.Len(t, groupList.Groups, 2)
+	require.Equal(t, "apis.kcp.io", groupList.Groups[0].Name)
+	require.Len(t, groupList.Groups[0].Versions, 1, "the reserved group must not be merged")
+	require.Equal(t, "metrics.example.com", groupList.Groups[1].Name)
+
+	t.Log("The requests for a delegated group/version are proxied with the identity of the APIService and the user in the headers")
+	w = serve("root", "/apis/metrics.example.com/v1/namespaces/default/pods")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "from the extension API server", w.Body.String())
+	require.Equal(t, "/apis/metrics.example.com/v1/namespaces/default/pods", proxied.URL.Path)
+	require.Equal(t, "system:kcp:apiservice:root:v1.metrics.example.com", proxied.TLS.PeerCertificates[0].Subject.CommonName)
+	require.Equal(t, "alice", proxied.Header.Get("X-Remote-User"))
+	require.Equal(t, []string{"team"}, proxied.Header.Values("X-Remote-Group"))
+	require.Empty(t, proxied.Header.Get("Authorization"))
+
+	t.Log("The requests for other group/versions, reserved or local groups and other logical clusters are served locally")
+	for _, tc := range []struct {
+		clusterName logicalcluster.Name
+		path        string
+	}{
+		{"root", "/apis/metrics.example.com/v2"},
+		{"root", "/apis/local.example.com/v1"},
+		{"root", "/apis/apis.kcp.io/v1"},
+		{"root", "/apis/widgets.example.com/v1"},
+		{"other", "/apis/metrics.example.com/v1"},
+		{"root", "/api/v1"},
+	} {
+		w = serve(tc.clusterName, tc.path)
+		require.Equal(t, "from kcp", strings.TrimSpace(w.Body.String()), "%s|%s", tc.clusterName, tc.path)
+	}
+}
+
+func TestTransportFor(t *testing.T) {
+	signer, _ := newSigner(t)
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+	h := &handler{
+		signer:          signer,
+		now:             func() time.Time { return now },
+		transports:      list.New(),
+		transportsByKey: map[transportKey]*list.Element{},
+	}
+
+	key := transportKey{clusterName: "root", name: "v1.metrics.example.com"}
+	rt, err := h.transportFor(key)
+	require.NoError(t, err)
+	cached, err := h.transportFor(key)
+	require.NoError(t, err)
+	require.Same(t, rt, cached, "the transport must be cached")
+
+	t.Log("The transports are renewed before their client certificate expires")
+	now = now.Add(certificateLifetime / 2)
+	renewed, err := h.transportFor(key)
+	require.NoError(t, err)
+	require.NotSame(t, rt, renewed)
+	require.Equal(t, 1, h.transports.Len())
+
+	t.Log("The number of transports is bounded")
+	for i := 0; i < maxTransports+10; i++ {
+		_, err := h.transportFor(transportKey{clusterName: "root", name: fmt.Sprintf("v%d.metrics.example.com", i)})
+		require.NoError(t, err)
+	}
+	require.Equal(t, maxTransports, h.transports.Len())
+	require.Len(t, h.transportsByKey, maxTransports)
+	require.NotContains(t, h.transportsByKey, key, "the least recently used transport must be evicted")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiservices
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+const (
+	// UserPrefix prefixes the common name of the client certificates of the APIServices. The common
+	// name of the client certificate of an APIService is <UserPrefix><logical cluster>:<name>.
+	UserPrefix = "system:kcp:apiservice:"
+	// Organization is the organization of the client certificates of the APIServices.
+	Organization = "system:kcp:apiservices"
+
+	certificateLifetime = 24 * time.Hour
+)
+
+// ClientCertificateSigner issues the client certificates kcp authenticates with to the extension
+// API servers of the APIServices. Each APIService gets its own identity, so that an extension API
+// server only trusts the requests proxied for its own APIServices.
+type ClientCertificateSigner struct {
+	caCert *x509.Certificate
+	caKey  crypto.Signer
+	now    func() time.Time
+}
+
+// NewClientCertificateSigner returns a ClientCertificateSigner issuing the client certificates with
+// the CA of the given PEM encoded certificate and key files.
+func NewClientCertificateSigner(caCertFile, caKeyFile string) (*ClientCertificateSigner, error) {
+	certPEM, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", caCertFile, err)
+	}
+
+	keyPEM, err := os.ReadFile(caKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", caKeyFile, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the key of %s cannot sign certificates", caKeyFile)
+	}
+
+	return &ClientCertificateSigner{caCert: certs[0], caKey: signer, now: time.Now}, nil
+}
+
+// clientCertificate is an issued client certificate, with its PEM encoded certificate and key.
+type clientCertificate struct {
+	certPEM, keyPEM []byte
+	notAfter        time.Time
+}
+
+// issue returns a new client certificate for the APIService of the given logical cluster and name.
+func (s *ClientCertificateSigner) issue(clusterName logicalcluster.Name, name string) (*clientCertificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   UserPrefix + clusterName.String() + ":" + name,
+			Organization: []string{Organization},
+		},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(certificateLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.caCert, &key.PublicKey, s.caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientCertificate{
+		certPEM:  pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der}),
+		keyPEM:   pem.EncodeToMemory(&pem.Block{Type: keyutil.ECPrivateKeyBlockType, Bytes: keyDER}),
+		notAfter: template.NotAfter,
+	}, nil
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	kcpapiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/kcp/clientset/versioned"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+
+	confighelpers "github.com/kcp-dev/kcp/config/helpers"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+)
+
+// ManifestsPhase is the phase of the manifests of a workspace in a bootstrap directory.
+type ManifestsPhase string
+
+const (
+	// ManifestsPending means the manifests have not been applied yet.
+	ManifestsPending ManifestsPhase = "Pending"
+	// ManifestsApplying means the manifests are being applied, and have failed at least once.
+	ManifestsApplying ManifestsPhase = "Applying"
+	// ManifestsApplied means all the manifests have been applied.
+	ManifestsApplied ManifestsPhase = "Applied"
+)
+
+// ManifestsStatus is the status of the manifests of one workspace of a bootstrap directory.
+type ManifestsStatus struct {
+	// Path is the logical cluster path of the workspace the manifests are applied to.
+	Path string `json:"path"`
+	// Files are the manifest files, in the order they are applied.
+	Files []string `json:"files"`
+	// Phase is the phase of the manifests.
+	Phase ManifestsPhase `json:"phase"`
+	// Attempts is the number of times the manifests have been applied.
+	Attempts int `json:"attempts"`
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"lastError,omitempty"`
+	// LastTransitionTime is the last time the phase changed.
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// Manifests applies the declarative manifests of a bootstrap directory. The
+// manifests at the top of the directory are applied to the root workspace, and
+// the ones in a sub-directory to the workspace whose logical cluster path is the
+// name of that sub-directory, e.g. root:org:team. The workspaces are processed
+// parents first, and the files of a workspace in lexical order, so that a
+// workspace can be created by the manifests of its parent and populated by its
+// own.
+//
+// Manifests are created, or updated when they exist, which makes them
+// idempotent across restarts. They go through the same templating as the
+// embedded kcp manifests, and honour the bootstrap.kcp.io/create-only and
+// bootstrap.kcp.io/battery annotations.
+//
+// Manifests serves the status of every workspace as JSON, and is a readyz
+// check failing until all the manifests have been applied.
+type Manifests struct {
+	dir string
+
+	lock     sync.RWMutex
+	statuses []ManifestsStatus
+}
+
+// NewManifests reads the layout of the bootstrap directory dir.
+func NewManifests(dir string) (*Manifests, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rootFiles, err := manifestFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifests{dir: dir}
+	if len(rootFiles) > 0 {
+		m.statuses = append(m.statuses, ManifestsStatus{Path: core.RootCluster.Path().String(), Files: rootFiles, Phase: ManifestsPending})
+	}
+
+	var children []ManifestsStatus
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := logicalcluster.NewPath(e.Name())
+		if !path.IsValid() || !path.HasPrefix(core.RootCluster.Path()) {
+			return nil, fmt.Errorf("directory %q of %s is not a workspace path under root", e.Name(), dir)
+		}
+		if path == core.RootCluster.Path() {
+			return nil, fmt.Errorf("manifests of the root workspace must be at the top of %s", dir)
+		}
+		files, err := manifestFiles(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		children = append(children, ManifestsStatus{Path: path.String(), Files: files, Phase: ManifestsPending})
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return strings.Count(children[i].Path, ":") < strings.Count(children[j].Path, ":")
+	})
+	m.statuses = append(m.statuses, children...)
+
+	return m, nil
+}
+
+// manifestFiles returns the YAML files of dir, in lexical order.
+func manifestFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(e.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		files = append(files, e.Name())
+	}
+	return files, nil
+}
+
+// Apply applies the manifests of every workspace in order, retrying each
+// workspace until it succeeds. It blocks until all the manifests have been
+// applied, or the context is done.
+func (m *Manifests) Apply(ctx context.Context, apiExtensionClusterClient kcpapiextensionsclientset.ClusterInterface, dynamicClusterClient kcpdynamic.ClusterInterface, batteriesIncluded sets.String) error {
+	logger := klog.FromContext(ctx)
+
+	for i := range m.statuses {
+		m.lock.RLock()
+		status := m.statuses[i]
+		m.lock.RUnlock()
+
+		path := logicalcluster.NewPath(status.Path)
+		logger := logger.WithValues("path", path, "dir", m.dir)
+		dir := m.dir
+		if path != core.RootCluster.Path() {
+			dir = filepath.Join(m.dir, status.Path)
+		}
+		fsys := os.DirFS(dir)
+
+		cache := memory.NewMemCacheClient(apiExtensionClusterClient.Cluster(path).Discovery())
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(cache)
+		dynamicClient := dynamicClusterClient.Cluster(path)
+
+		logger.Info("applying bootstrap manifests", "files", status.Files)
+		if err := wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+			for _, file := range status.Files {
+				if err := confighelpers.CreateResourceFromFS(ctx, dynamicClient, mapper, batteriesIncluded, file, fsys); err != nil {
+					logger.V(2).Info("failed to apply bootstrap manifests, retrying", "file", file, "err", err)
+					// the workspace or the resources might not exist yet
+					cache.Invalidate()
+					m.setStatus(i, ManifestsApplying, fmt.Errorf("%s: %w", file, err))
+					return false, nil
+				}
+			}
+			m.setStatus(i, ManifestsApplied, nil)
+			return true, nil
+		}); err != nil {
+			return err
+		}
+		logger.Info("applied bootstrap manifests")
+	}
+
+	return nil
+}
+
+func (m *Manifests) setStatus(i int, phase ManifestsPhase, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	status := &m.statuses[i]
+	status.Attempts++
+	if status.Phase != phase {
+		status.Phase = phase
+		status.LastTransitionTime = time.Now()
+	}
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+}
+
+// Statuses returns the statuses of the workspaces, in the order they are applied.
+func (m *Manifests) Statuses() []ManifestsStatus {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	statuses := make([]ManifestsStatus, len(m.statuses))
+	copy(statuses, m.statuses)
+	return statuses
+}
+
+// ServeHTTP serves the statuses as JSON.
+func (m *Manifests) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Statuses()); err != nil {
+		klog.FromContext(req.Context()).Error(err, "failed to encode bootstrap manifests statuses")
+	}
+}
+
+// Name implements healthz.HealthChecker.
+func (m *Manifests) Name() string {
+	return "bootstrap-manifests"
+}
+
+// Check implements healthz.HealthChecker. It fails with the first workspace
+// whose manifests have not been applied.
+func (m *Manifests) Check(_ *http.Request) error {
+	for _, status := range m.Statuses() {
+		if status.Phase == ManifestsApplied {
+			continue
+		}
+		if status.LastError != "" {
+			return fmt.Errorf("bootstrap manifests of %s not applied: %s", status.Path, status.LastError)
+		}
+		return fmt.Errorf("bootstrap manifests of %s not applied", status.Path)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewManifests(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"20-workspace-org.yaml",
+		"10-workspacetype.yaml",
+		"README.md",
+		"root:org:team/01-rbac.yml",
+		"root:org/01-workspace-team.yaml",
+		"root:other/01-apiexport.yaml",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, f), nil, 0644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "root:empty"), 0755))
+
+	m, err := NewManifests(dir)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, status := range m.Statuses() {
+		require.Equal(t, ManifestsPending, status.Phase)
+		paths = append(paths, status.Path)
+	}
+	require.Equal(t, []string{"root", "root:org", "root:other", "root:org:team"}, paths)
+	require.Equal(t, []string{"10-workspacetype.yaml", "20-workspace-org.yaml"}, m.Statuses()[0].Files)
+	require.Equal(t, []string{"01-rbac.yml"}, m.Statuses()[3].Files)
+
+	require.EqualError(t, m.Check(nil), "bootstrap manifests of root not applied")
+	m.setStatus(0, ManifestsApplied, nil)
+	m.setStatus(1, ManifestsApplying, errors.New("01-workspace-team.yaml: not found"))
+	require.EqualError(t, m.Check(nil), "bootstrap manifests of root:org not applied: 01-workspace-team.yaml: not found")
+	for i := 1; i < len(m.Statuses()); i++ {
+		m.setStatus(i, ManifestsApplied, nil)
+	}
+	require.NoError(t, m.Check(nil))
+}
+
+func TestNewManifestsInvalidDirectory(t *testing.T) {
+	for _, name := range []string{"root", "org", "root:Org"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+			_, err := NewManifests(dir)
+			require.Error(t, err)
+		})
+	}
+}
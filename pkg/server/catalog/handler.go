@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	schedulingv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	tenancyv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	// Path is the path of the catalog endpoint of a workspace, relative to its /clusters/<path>
+	// prefix.
+	Path = "/catalog"
+
+	// DefaultLimit is the number of entries of a page when the limit query parameter is not set.
+	DefaultLimit = 100
+
+	// MaxLimit is the maximum number of entries of a page.
+	MaxLimit = 500
+)
+
+// NewHandler returns a handler serving, as an EntryList, the APIExports, WorkspaceTypes and
+// Locations of the request's workspace, for UIs to browse them. The objects of a kind are only
+// listed when the user is allowed to list them in the workspace.
+//
+// The handler supports the following query parameters:
+//
+//   - kind: only lists the entries of the given kinds, comma-separated.
+//   - search: only lists the entries whose name or description contains the given string,
+//     case-insensitively.
+//   - limit: the maximum number of entries of the page, DefaultLimit by default.
+//   - continue: the metadata.continue value of the previous page.
+func NewHandler(
+	authz authorizer.Authorizer,
+	apiExportLister apisv1alpha1listers.APIExportClusterLister,
+	workspaceTypeLister tenancyv1alpha1listers.WorkspaceTypeClusterLister,
+	locationLister schedulingv1alpha1listers.LocationClusterLister,
+) http.Handler {
+	return &handler{
+		authz: authz,
+		sources: []source{
+			{kind: EntryKindAPIExport, resource: apisv1alpha1.SchemeGroupVersion.WithResource("apiexports"), list: func(clusterName logicalcluster.Name) ([]Entry, error) {
+				exports, err := apiExportLister.Cluster(clusterName).List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				entries := make([]Entry, 0, len(exports))
+				for _, export := range exports {
+					entry := newEntry(EntryKindAPIExport, export, export.Spec.Description, export.Spec.Icon)
+					for _, schemaName := range export.Spec.LatestResourceSchemas {
+						// schema names are <prefix>.<resource>.<group>
+						if _, resource, found := strings.Cut(schemaName, "."); found {
+							entry.Resources = append(entry.Resources, resource)
+						}
+					}
+					entries = append(entries, entry)
+				}
+				return entries, nil
+			}},
+			{kind: EntryKindWorkspaceType, resource: tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacetypes"), list: func(clusterName logicalcluster.Name) ([]Entry, error) {
+				wts, err := workspaceTypeLister.Cluster(clusterName).List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				entries := make([]Entry, 0, len(wts))
+				for _, wt := range wts {
+					entries = append(entries, newEntry(EntryKindWorkspaceType, wt, wt.Spec.Description, wt.Spec.Icon))
+				}
+				return entries, nil
+			}},
+			{kind: EntryKindLocation, resource: schedulingv1alpha1.SchemeGroupVersion.WithResource("locations"), list: func(clusterName logicalcluster.Name) ([]Entry, error) {
+				locations, err := locationLister.Cluster(clusterName).List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				entries := make([]Entry, 0, len(locations))
+				for _, location := range locations {
+					entry := newEntry(EntryKindLocation, location, location.Spec.Description, location.Spec.Icon)
+					gvr := location.Spec.Resource
+					entry.LocationResource = strings.TrimSuffix(fmt.Sprintf("%s.%s.%s", gvr.Resource, gvr.Version, gvr.Group), ".")
+					entries = append(entries, entry)
+				}
+				return entries, nil
+			}},
+		},
+	}
+}
+
+type source struct {
+	kind     EntryKind
+	resource schema.GroupVersionResource
+	list     func(clusterName logicalcluster.Name) ([]Entry, error)
+}
+
+type handler struct {
+	authz   authorizer.Authorizer
+	sources []source
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := klog.FromContext(ctx)
+
+	cluster := request.ClusterFrom(ctx)
+	if cluster == nil || cluster.Wildcard || cluster.Name.Empty() {
+		http.Error(w, "the catalog of a workspace must be requested under its /clusters/<path> prefix", http.StatusBadRequest)
+		return
+	}
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := request.UserFrom(ctx)
+	if !ok {
+		http.Error(w, "no user in the request", http.StatusUnauthorized)
+		return
+	}
+
+	query, err := parseQuery(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var entries []Entry
+	for _, s := range h.sources {
+		if len(query.kinds) > 0 && !query.kinds[s.kind] {
+			continue
+		}
+		decision, _, err := h.authz.Authorize(ctx, authorizer.AttributesRecord{
+			User:            user,
+			Verb:            "list",
+			APIGroup:        s.resource.Group,
+			APIVersion:      s.resource.Version,
+			Resource:        s.resource.Resource,
+			ResourceRequest: true,
+		})
+		if err != nil {
+			logger.Error(err, "failed to authorize the catalog entries", "resource", s.resource)
+		}
+		if decision != authorizer.DecisionAllow {
+			continue
+		}
+		listed, err := s.list(cluster.Name)
+		if err != nil {
+			logger.Error(err, "failed to list the catalog entries", "resource", s.resource)
+			http.Error(w, "failed to list the catalog entries", http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, listed...)
+	}
+
+	list := page(entries, query)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.Error(err, "failed to write the catalog entries")
+	}
+}
+
+type catalogQuery struct {
+	kinds  map[EntryKind]bool
+	search string
+	limit  int
+	after  string
+}
+
+func parseQuery(req *http.Request) (*catalogQuery, error) {
+	values := req.URL.Query()
+	q := &catalogQuery{
+		search: strings.ToLower(values.Get("search")),
+		limit:  DefaultLimit,
+	}
+
+	if kinds := values.Get("kind"); kinds != "" {
+		q.kinds = map[EntryKind]bool{}
+		for _, kind := range strings.Split(kinds, ",") {
+			switch k := EntryKind(strings.TrimSpace(kind)); k {
+			case EntryKindAPIExport, EntryKindWorkspaceType, EntryKindLocation:
+				q.kinds[k] = true
+			default:
+				return nil, fmt.Errorf("invalid kind %q, must be one of %s, %s or %s", kind, EntryKindAPIExport, EntryKindWorkspaceType, EntryKindLocation)
+			}
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 {
+			return nil, fmt.Errorf("invalid limit %q, must be a positive integer", limit)
+		}
+		if l > MaxLimit {
+			l = MaxLimit
+		}
+		q.limit = l
+	}
+
+	if token := values.Get("continue"); token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(after) == 0 {
+			return nil, fmt.Errorf("invalid continue token %q", token)
+		}
+		q.after = string(after)
+	}
+
+	return q, nil
+}
+
+// page returns the page of the entries matching the query, sorted by kind and name.
+func page(entries []Entry, q *catalogQuery) *EntryList {
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(&entries[i]) < sortKey(&entries[j])
+	})
+
+	list := &EntryList{
+		TypeMeta: metav1.TypeMeta{APIVersion: APIVersion, Kind: EntryListKind},
+		Items:    []Entry{},
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if q.after != "" && sortKey(entry) <= q.after {
+			continue
+		}
+		if q.search != "" && !strings.Contains(strings.ToLower(entry.Name), q.search) && !strings.Contains(strings.ToLower(entry.Description), q.search) {
+			continue
+		}
+		if len(list.Items) == q.limit {
+			list.Continue = base64.RawURLEncoding.EncodeToString([]byte(sortKey(&list.Items[len(list.Items)-1])))
+			break
+		}
+		list.Items = append(list.Items, *entry)
+	}
+	return list
+}
+
+func sortKey(entry *Entry) string {
+	return string(entry.Kind) + "/" + entry.Name
+}
+
+func newEntry(kind EntryKind, obj metav1.Object, description, icon string) Entry {
+	path := obj.GetAnnotations()[core.LogicalClusterPathAnnotationKey]
+	if path == "" {
+		path = logicalcluster.From(obj).String()
+	}
+	return Entry{
+		Kind:        kind,
+		Name:        obj.GetName(),
+		Path:        path,
+		Description: description,
+		Icon:        icon,
+	}
+}
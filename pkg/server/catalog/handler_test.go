@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	schedulingv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	tenancyv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func objectMeta(cluster, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name: name,
+		Annotations: map[string]string{
+			logicalcluster.AnnotationKey:         cluster,
+			core.LogicalClusterPathAnnotationKey: "root:" + cluster,
+		},
+	}
+}
+
+func newTestHandler(t *testing.T, allowed ...string) http.Handler {
+	newIndexer := func(objs ...interface{}) cache.Indexer {
+		indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+		for _, obj := range objs {
+			require.NoError(t, indexer.Add(obj))
+		}
+		return indexer
+	}
+
+	exports := newIndexer(
+		&apisv1alpha1.APIExport{ObjectMeta: objectMeta("provider", "widgets"), Spec: apisv1alpha1.APIExportSpec{
+			Description:           "Widgets for everyone",
+			Icon:                  "https://example.com/widgets.svg",
+			LatestResourceSchemas: []string{"v1.widgets.example.io", "v1.gadgets.example.io"},
+		}},
+		&apisv1alpha1.APIExport{ObjectMeta: objectMeta("provider", "databases"), Spec: apisv1alpha1.APIExportSpec{Description: "Managed databases"}},
+		&apisv1alpha1.APIExport{ObjectMeta: objectMeta("other", "hidden")},
+	)
+	types := newIndexer(
+		&tenancyv1alpha1.WorkspaceType{ObjectMeta: objectMeta("provider", "team"), Spec: tenancyv1alpha1.WorkspaceTypeSpec{Description: "A workspace for a team"}},
+	)
+	locations := newIndexer(
+		&schedulingv1alpha1.Location{ObjectMeta: objectMeta("provider", "europe"), Spec: schedulingv1alpha1.LocationSpec{
+			Description: "European data centers",
+			Resource:    schedulingv1alpha1.GroupVersionResource{Group: "workload.kcp.io", Version: "v1alpha1", Resource: "synctargets"},
+		}},
+	)
+
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		for _, resource := range allowed {
+			if a.GetVerb() == "list" && a.GetResource() == resource {
+				return authorizer.DecisionAllow, "", nil
+			}
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+
+	return NewHandler(
+		authz,
+		apisv1alpha1listers.NewAPIExportClusterLister(exports),
+		tenancyv1alpha1listers.NewWorkspaceTypeClusterLister(types),
+		schedulingv1alpha1listers.NewLocationClusterLister(locations),
+	)
+}
+
+func get(t *testing.T, handler http.Handler, cluster *request.Cluster, query string) (int, *EntryList) {
+	req := httptest.NewRequest(http.MethodGet, Path+query, nil)
+	ctx := request.WithUser(req.Context(), &user.DefaultInfo{Name: "user"})
+	if cluster != nil {
+		ctx = request.WithCluster(ctx, *cluster)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var list EntryList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	return rec.Code, &list
+}
+
+func names(list *EntryList) []string {
+	ret := []string{}
+	for _, entry := range list.Items {
+		ret = append(ret, string(entry.Kind)+"/"+entry.Name)
+	}
+	return ret
+}
+
+func TestHandler(t *testing.T) {
+	handler := newTestHandler(t, "apiexports", "workspacetypes", "locations")
+	provider := &request.Cluster{Name: "provider"}
+
+	code, list := get(t, handler, provider, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, APIVersion, list.APIVersion)
+	require.Equal(t, EntryListKind, list.Kind)
+	require.Empty(t, list.Continue)
+	require.Equal(t, []string{"APIExport/databases", "APIExport/widgets", "Location/europe", "WorkspaceType/team"}, names(list))
+	require.Equal(t, Entry{
+		Kind:        EntryKindAPIExport,
+		Name:        "widgets",
+		Path:        "root:provider",
+		Description: "Widgets for everyone",
+		Icon:        "https://example.com/widgets.svg",
+		Resources:   []string{"widgets.example.io", "gadgets.example.io"},
+	}, list.Items[1])
+	require.Equal(t, "synctargets.v1alpha1.workload.kcp.io", list.Items[2].LocationResource)
+
+	_, list = get(t, handler, provider, "?kind=APIExport,WorkspaceType")
+	require.Equal(t, []string{"APIExport/databases", "APIExport/widgets", "WorkspaceType/team"}, names(list))
+
+	_, list = get(t, handler, provider, "?search=EUROPE")
+	require.Equal(t, []string{"Location/europe"}, names(list))
+
+	_, list = get(t, handler, provider, "?search=managed")
+	require.Equal(t, []string{"APIExport/databases"}, names(list))
+
+	_, list = get(t, handler, &request.Cluster{Name: "other"}, "")
+	require.Equal(t, []string{"APIExport/hidden"}, names(list))
+
+	for _, query := range []string{"?kind=Secret", "?limit=0", "?limit=x", "?continue=!"} {
+		code, _ = get(t, handler, provider, query)
+		require.Equal(t, http.StatusBadRequest, code, query)
+	}
+
+	code, _ = get(t, handler, &request.Cluster{Wildcard: true}, "")
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(t, handler, nil, "")
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHandlerPagination(t *testing.T) {
+	handler := newTestHandler(t, "apiexports", "workspacetypes", "locations")
+	provider := &request.Cluster{Name: "provider"}
+
+	var got []string
+	query := "?limit=3"
+	for {
+		code, list := get(t, handler, provider, query)
+		require.Equal(t, http.StatusOK, code)
+		require.LessOrEqual(t, len(list.Items), 3)
+		got = append(got, names(list)...)
+		if list.Continue == "" {
+			break
+		}
+		query = "?limit=3&continue=" + list.Continue
+	}
+	require.Equal(t, []string{"APIExport/databases", "APIExport/widgets", "Location/europe", "WorkspaceType/team"}, got)
+}
+
+func TestHandlerAuthorization(t *testing.T) {
+	handler := newTestHandler(t, "workspacetypes")
+
+	_, list := get(t, handler, &request.Cluster{Name: "provider"}, "")
+	require.Equal(t, []string{"WorkspaceType/team"}, names(list))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// APIVersion is the version of the catalog responses. Fields are only added to it, never
+	// removed or changed.
+	APIVersion = "catalog.kcp.io/v1alpha1"
+
+	// EntryListKind is the kind of the catalog responses.
+	EntryListKind = "CatalogEntryList"
+)
+
+// EntryKind is the kind of the object an Entry describes.
+type EntryKind string
+
+const (
+	EntryKindAPIExport     EntryKind = "APIExport"
+	EntryKindWorkspaceType EntryKind = "WorkspaceType"
+	EntryKindLocation      EntryKind = "Location"
+)
+
+// EntryList is a page of the catalog of a workspace.
+type EntryList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata.continue is set when there are more entries. It is passed as the continue
+	// query parameter to get the next page.
+	metav1.ListMeta `json:"metadata"`
+
+	// items are the entries of the page, sorted by kind and name.
+	Items []Entry `json:"items"`
+}
+
+// Entry describes an APIExport, a WorkspaceType or a Location of the catalog.
+type Entry struct {
+	// kind is the kind of the object: APIExport, WorkspaceType or Location.
+	Kind EntryKind `json:"kind"`
+
+	// name is the name of the object.
+	Name string `json:"name"`
+
+	// path is the path of the workspace of the object, to be used in references to it,
+	// e.g. in APIBindings or in the type of Workspaces.
+	Path string `json:"path"`
+
+	// description is the human-readable description in the spec of the object.
+	//
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// icon is the https URL or the data URI of the image in the spec of the object.
+	//
+	// +optional
+	Icon string `json:"icon,omitempty"`
+
+	// resources are the resources of an APIExport, as <resource>.<group>.
+	//
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+
+	// locationResource is the resource of the instances of a Location, as
+	// <resource>.<version>.<group>.
+	//
+	// +optional
+	LocationResource string `json:"locationResource,omitempty"`
+}
@@ -22,7 +22,9 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	kcpdynamicinformer "github.com/kcp-dev/client-go/dynamic/dynamicinformer"
 	kcpkubernetesinformers "github.com/kcp-dev/client-go/informers"
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/kcp-dev/logicalcluster/v3"
@@ -47,7 +49,9 @@ import (
 	quotainstall "k8s.io/kubernetes/pkg/quota/v1/install"
 
 	kcpadmissioninitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	webhookresolver "github.com/kcp-dev/kcp/pkg/admission/webhook/resolver"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/authorization"
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
@@ -58,11 +62,17 @@ import (
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingusage"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	"github.com/kcp-dev/kcp/pkg/server/apiservices"
 	"github.com/kcp-dev/kcp/pkg/server/bootstrap"
 	kcpfilters "github.com/kcp-dev/kcp/pkg/server/filters"
+	"github.com/kcp-dev/kcp/pkg/server/inventory"
 	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
+	"github.com/kcp-dev/kcp/pkg/spiffe"
 	"github.com/kcp-dev/kcp/pkg/tunneler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/fairness"
 )
 
 type Config struct {
@@ -88,6 +98,7 @@ type ExtraConfig struct {
 	// authentication
 	kcpAdminToken, shardAdminToken, userToken string
 	shardAdminTokenHash                       []byte
+	spiffeSource                              *spiffe.X509Source
 
 	// clients
 	DynamicClusterClient                kcpdynamic.ClusterInterface
@@ -105,8 +116,12 @@ type ExtraConfig struct {
 	LogicalClusterAdminConfig *rest.Config
 
 	// misc
-	preHandlerChainMux   *handlerChainMuxes
-	quotaAdmissionStopCh chan struct{}
+	preHandlerChainMux      *handlerChainMuxes
+	quotaAdmissionStopCh    chan struct{}
+	apiBindingUsageRequests *apibindingusage.RequestCounter
+	logicalClusterAccesses  *inventory.AccessTracker
+	longRunningRequests     *kcpfilters.LongRunningRequests
+	apiServiceSigner        *apiservices.ClientCertificateSigner
 
 	// URL getters depending on genericspiserver.ExternalAddress which is initialized on server run
 	ShardBaseURL             func() string
@@ -119,6 +134,7 @@ type ExtraConfig struct {
 	ApiExtensionsSharedInformerFactory      kcpapiextensionsinformers.SharedInformerFactory
 	DiscoveringDynamicSharedInformerFactory *informer.DiscoveringDynamicSharedInformerFactory
 	CacheKcpSharedInformerFactory           kcpinformers.SharedInformerFactory
+	APIServiceInformer                      kcpkubernetesinformers.GenericClusterInformer
 	// TODO(p0lyn0mial):  get rid of TemporaryRootShardKcpSharedInformerFactory, in the future
 	//                    we should have multi-shard aware informers
 	//
@@ -173,6 +189,9 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		go http.ListenAndServe(opts.Extra.ProfilerAddress, nil)
 	}
 
+	// the workqueues of the controllers are created with their rate limiters while the server is built
+	ratelimiter.SetLimits(opts.Controllers.Limits())
+
 	if opts.EmbeddedEtcd.Enabled {
 		var err error
 		c.EmbeddedEtcd, err = embeddedetcd.NewConfig(opts.EmbeddedEtcd, opts.GenericControlPlane.Etcd.EnableWatchCache)
@@ -188,12 +207,21 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		return nil, err
 	}
 
+	c.spiffeSource, err = opts.SPIFFE.ApplyTo(c.GenericConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	var cacheClientConfig *rest.Config
 	if len(c.Options.Cache.KubeconfigFile) > 0 {
 		cacheClientConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.Options.Cache.KubeconfigFile}, nil).ClientConfig()
 		if err != nil {
 			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for a cache client, err: %w", c.Options.Cache.KubeconfigFile, err)
 		}
+		if c.spiffeSource != nil && c.spiffeSource.SVID() != nil {
+			cacheClientConfig = c.spiffeSource.WrapConfig(cacheClientConfig)
+		}
+		cacheClientConfig = opts.Egress.WrapConfig(kcpserveroptions.EgressCache, cacheClientConfig)
 	} else {
 		cacheClientConfig = rest.CopyConfig(c.GenericConfig.LoopbackClientConfig)
 	}
@@ -224,6 +252,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for the root shard, err: %w", c.Options.Extra.RootShardKubeconfigFile, err)
 		}
+		nonIdentityRootKcpShardSystemAdminConfig = opts.Egress.WrapConfig(kcpserveroptions.EgressShard, nonIdentityRootKcpShardSystemAdminConfig)
 
 		var kcpShardIdentityRoundTripper func(rt http.RoundTripper) http.RoundTripper
 		kcpShardIdentityRoundTripper, c.resolveIdentities = bootstrap.NewWildcardIdentitiesWrappingRoundTripper(bootstrap.KcpRootGroupExportNames, bootstrap.KcpRootGroupResourceExportNames, nonIdentityRootKcpShardSystemAdminConfig, c.KubeClusterClient)
@@ -279,6 +308,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for a logical cluster client, err: %w", c.Options.Extra.LogicalClusterAdminKubeconfig, err)
 		}
+		c.LogicalClusterAdminConfig = opts.Egress.WrapConfig(kcpserveroptions.EgressShard, c.LogicalClusterAdminConfig)
 	}
 
 	// Setup apiextensions * informers
@@ -296,6 +326,20 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	// the APIServices are only served with a CA to issue their client certificates
+	if opts.Extra.APIServiceClientCAFile != "" {
+		c.apiServiceSigner, err = apiservices.NewClientCertificateSigner(opts.Extra.APIServiceClientCAFile, opts.Extra.APIServiceClientCAKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the APIService client CA: %w", err)
+		}
+		c.APIServiceInformer = kcpdynamicinformer.NewFilteredDynamicInformer(
+			c.DynamicClusterClient,
+			apiservices.SchemeGroupVersionResource,
+			resyncPeriod,
+			cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc},
+			nil,
+		)
+	}
 
 	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory); err != nil {
 		return nil, err
@@ -332,7 +376,27 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	// is called multiple times, but only one of the handler chain will actually be used. Hence, we wrap it
 	// to give handlers below one mux.Handle func to call.
 	c.preHandlerChainMux = &handlerChainMuxes{}
+	if opts.Controllers.APIBindingUsage.Enabled() {
+		c.apiBindingUsageRequests = apibindingusage.NewRequestCounter()
+	}
+	c.logicalClusterAccesses = inventory.NewAccessTracker()
+	c.longRunningRequests = kcpfilters.NewLongRunningRequests()
 	c.GenericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
+		if c.APIServiceInformer != nil {
+			apiHandler = apiservices.WithAPIServices(
+				apiHandler,
+				c.APIServiceInformer,
+				c.KubeSharedInformerFactory.Core().V1().Services(),
+				c.ApiExtensions.ExtraConfig.ClusterAwareCRDLister,
+				c.apiServiceSigner,
+				opts.Egress.ProxyFor(kcpserveroptions.EgressAPIService),
+			)
+		}
+		apiHandler = kcpfilters.WithLogicalClusterAccessRecording(apiHandler, c.logicalClusterAccesses)
+		if c.apiBindingUsageRequests != nil {
+			// run after the resource identity has been stripped from the request info
+			apiHandler = kcpfilters.WithResourceRequestAccounting(apiHandler, c.apiBindingUsageRequests)
+		}
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
 		apiHandler = WithRequestIdentity(apiHandler)
 		apiHandler = authorization.WithDeepSubjectAccessReview(apiHandler)
@@ -340,6 +404,10 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		apiHandler = genericapiserver.DefaultBuildHandlerChainFromAuthz(apiHandler, genericConfig)
 
 		if opts.HomeWorkspaces.Enabled {
+			homeWorkspaceTypes, err := opts.HomeWorkspaces.HomeWorkspaceTypes()
+			if err != nil {
+				panic(err) // shouldn't happen due to flag validation
+			}
 			apiHandler, err = WithHomeWorkspaces(
 				apiHandler,
 				genericConfig.Authorization.Authorizer,
@@ -352,12 +420,28 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 				logicalcluster.NewPath(opts.HomeWorkspaces.HomeRootPrefix),
 				opts.HomeWorkspaces.BucketLevels,
 				opts.HomeWorkspaces.BucketSize,
+				logicalcluster.NewPath(opts.HomeWorkspaces.Type),
+				homeWorkspaceTypes,
+				opts.HomeWorkspaces.OptOutGroups,
+				opts.HomeWorkspaces.Quota,
 			)
 			if err != nil {
 				panic(err) // shouldn't happen due to flag validation
 			}
 		}
 
+		// run after the logical cluster, the user and the request info have been resolved, before authorization
+		apiHandler = kcpfilters.WithLogicalClusterRequestMetrics(apiHandler, opts.LogicalClusterMetrics.MaxClusters, opts.LogicalClusterMetrics.Window)
+		apiHandler = kcpfilters.WithLongRunningRequestTracking(apiHandler, genericConfig.LongRunningFunc, c.longRunningRequests)
+		apiHandler = kcpfilters.WithSlowRequestLogging(apiHandler, genericConfig.LongRunningFunc, opts.SlowRequests.ReadThreshold, opts.SlowRequests.WriteThreshold)
+		apiHandler = kcpfilters.WithWatchCacheBypassMetrics(apiHandler, opts.GenericControlPlane.Etcd.EnableWatchCache)
+
+		// bound the requests forwarded by the virtual workspaces per priority level, rejecting instead
+		// of queueing, so that they cannot take all the max-in-flight seats from direct workspace requests.
+		forwardedRequestLimits := *opts.Virtual.VirtualWorkspaces.Fairness
+		forwardedRequestLimits.QueueTimeout = 0
+		apiHandler = fairness.NewLimiter(fairness.Shard, forwardedRequestLimits).WithForwardedRequestLimits(apiHandler)
+
 		authorizerWithoutAudit := genericConfig.Authorization.Authorizer
 		genericConfig.Authorization.Authorizer = authorization.EnableAuditLogging(genericConfig.Authorization.Authorizer)
 		apiHandler = genericapiserver.DefaultBuildHandlerChainBeforeAuthz(apiHandler, genericConfig)
@@ -381,6 +465,9 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 
 		apiHandler = WithClusterWorkspaceProjection(apiHandler)
 		apiHandler = kcpfilters.WithAuditEventClusterAnnotation(apiHandler)
+		apiHandler = kcpfilters.WithAuditEventShardAnnotations(apiHandler, opts.Extra.ShardName, func(clusterName logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+			return c.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters().Lister().Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+		})
 		apiHandler = WithAuditAnnotation(apiHandler) // Must run before any audit annotation is made
 		apiHandler = WithLocalProxy(apiHandler, opts.Extra.ShardName, opts.Extra.ShardBaseURL, c.KcpSharedInformerFactory.Tenancy().V1beta1().Workspaces(), c.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters())
 		apiHandler = WithInClusterServiceAccountRequestRewrite(apiHandler)
@@ -397,6 +484,25 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 
 	c.ExtraConfig.quotaAdmissionStopCh = make(chan struct{})
 
+	var webhookFrontProxyClient kcpkubernetesclientset.ClusterInterface
+	if opts.Extra.WebhookFrontProxyKubeconfig != "" {
+		frontProxyConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: opts.Extra.WebhookFrontProxyKubeconfig}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for the webhook front-proxy client, err: %w", opts.Extra.WebhookFrontProxyKubeconfig, err)
+		}
+		frontProxyConfig = opts.Egress.WrapConfig(kcpserveroptions.EgressShard, frontProxyConfig)
+		webhookFrontProxyClient, err = kcpkubernetesclientset.NewForConfig(rest.AddUserAgent(frontProxyConfig, "kcp-webhook-resolver"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	webhookResolver := webhookresolver.NewResolver(
+		c.KubeSharedInformerFactory.Core().V1().Services(),
+		c.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		webhookFrontProxyClient,
+		opts.Egress.ProxyFor(kcpserveroptions.EgressWebhook),
+	)
+
 	admissionPluginInitializers := []admission.PluginInitializer{
 		kcpadmissioninitializers.NewKcpInformersInitializer(c.KcpSharedInformerFactory),
 		kcpadmissioninitializers.NewKubeClusterClientInitializer(c.KubeClusterClient),
@@ -406,6 +512,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		// with the default secure port, when the config is later completed.
 		kcpadmissioninitializers.NewKubeQuotaConfigurationInitializer(quotaConfiguration),
 		kcpadmissioninitializers.NewServerShutdownInitializer(c.quotaAdmissionStopCh),
+		kcpadmissioninitializers.NewWebhookResolverInitializer(webhookResolver),
 	}
 
 	c.ShardBaseURL = func() string {
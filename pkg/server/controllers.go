@@ -47,14 +47,16 @@ import (
 	"k8s.io/kubernetes/pkg/serviceaccount"
 
 	configuniversal "github.com/kcp-dev/kcp/config/universal"
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
-	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/informer"
+	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingdeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingusage"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexportendpointslice"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
@@ -62,21 +64,29 @@ import (
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/extraannotationsync"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/identitycache"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/permissionclaimlabel"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/schemalint"
 	"github.com/kcp-dev/kcp/pkg/reconciler/cache/replication"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/dnsendpoints"
 	logicalclusterctrl "github.com/kcp-dev/kcp/pkg/reconciler/core/logicalcluster"
 	"github.com/kcp-dev/kcp/pkg/reconciler/core/logicalclusterdeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/notifications"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/referencegrant"
 	"github.com/kcp-dev/kcp/pkg/reconciler/core/shard"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/trustbundle"
 	"github.com/kcp-dev/kcp/pkg/reconciler/garbagecollector"
 	"github.com/kcp-dev/kcp/pkg/reconciler/kubequota"
 	schedulinglocationstatus "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
 	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bootstrap"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/gitops"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/initialization"
 	tenancylogicalcluster "github.com/kcp-dev/kcp/pkg/reconciler/tenancy/logicalcluster"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspace"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacetype"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacetypemigration"
 	workloadsapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	workloadsapiexportcreate "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexportcreate"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/clusterapi"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	workloadplacement "github.com/kcp-dev/kcp/pkg/reconciler/workload/placement"
@@ -102,7 +112,7 @@ func (s *Server) installClusterRoleAggregationController(ctx context.Context, co
 		kubeClient.RbacV1())
 
 	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
-		go c.Run(ctx, 5)
+		go c.Run(ctx, s.Options.Controllers.WorkersFor(controllerName, 5))
 		return nil
 	})
 }
@@ -149,7 +159,7 @@ func (s *Server) installKubeNamespaceController(ctx context.Context, config *res
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Run(10, ctx.Done())
+		go c.Run(s.Options.Controllers.WorkersFor(controllerName, 10), ctx.Done())
 		return nil
 	})
 }
@@ -179,7 +189,7 @@ func (s *Server) installKubeServiceAccountController(ctx context.Context, config
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Run(ctx, 1)
+		go c.Run(ctx, s.Options.Controllers.WorkersFor(controllerName, 1))
 		return nil
 	})
 }
@@ -278,7 +288,7 @@ func (s *Server) installRootCAConfigMapController(ctx context.Context, config *r
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Run(ctx, 2)
+		go c.Run(ctx, s.Options.Controllers.WorkersFor(controllerName, 2))
 		return nil
 	})
 }
@@ -316,7 +326,34 @@ func (s *Server) installTenancyLogicalClusterController(ctx context.Context, con
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go controller.Start(ctx, 10)
+		go controller.Start(ctx, s.Options.Controllers.WorkersFor(tenancylogicalcluster.ControllerName, 10))
+		return nil
+	})
+}
+
+func (s *Server) installWorkspaceTypeMigrationController(ctx context.Context, config *rest.Config) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, workspacetypemigration.ControllerName)
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	controller := workspacetypemigration.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceTypes(),
+	)
+
+	return s.AddPostStartHook(postStartHookName(workspacetypemigration.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(workspacetypemigration.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		// a single worker by default, for the bound on concurrent migrations to be strict
+		go controller.Start(ctx, s.Options.Controllers.WorkersFor(workspacetypemigration.ControllerName, 1))
 		return nil
 	})
 }
@@ -363,7 +400,7 @@ func (s *Server) installLogicalClusterDeletionController(ctx context.Context, co
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go logicalClusterDeletionController.Start(ctx, 10)
+		go logicalClusterDeletionController.Start(ctx, s.Options.Controllers.WorkersFor(logicalclusterdeletion.ControllerName, 10))
 		return nil
 	})
 }
@@ -394,7 +431,7 @@ func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *r
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go resourceScheduler.Start(ctx, 2)
+		go resourceScheduler.Start(ctx, s.Options.Controllers.WorkersFor(workloadresource.ControllerName, 2))
 		return nil
 	})
 }
@@ -436,7 +473,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 			logger.Error(err, "failed to finish post-start-hook")
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
-		go workspaceController.Start(ctx, 2)
+		go workspaceController.Start(ctx, s.Options.Controllers.WorkersFor(workspace.ControllerName, 2))
 		return nil
 	}); err != nil {
 		return err
@@ -466,7 +503,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 				logger.Error(err, "failed to finish post-start-hook")
 				return nil // don't klog.Fatal. This only happens when context is cancelled.
 			}
-			go workspaceShardController.Start(ctx, 2)
+			go workspaceShardController.Start(ctx, s.Options.Controllers.WorkersFor(shard.ControllerName, 2))
 			return nil
 		}); err != nil {
 			return err
@@ -495,7 +532,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 			logger.Error(err, "failed to finish post-start-hook")
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
-		go workspaceTypeController.Start(ctx, 2)
+		go workspaceTypeController.Start(ctx, s.Options.Controllers.WorkersFor(workspacetype.ControllerName, 2))
 		return nil
 	}); err != nil {
 		return err
@@ -534,7 +571,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 			logger.Error(err, "failed to finish post-start-hook")
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
-		go universalController.Start(ctx, 2)
+		go universalController.Start(ctx, s.Options.Controllers.WorkersFor(universalControllerName, 2))
 		return nil
 	})
 }
@@ -562,7 +599,7 @@ func (s *Server) installLogicalCluster(ctx context.Context, config *rest.Config)
 			logger.Error(err, "failed to finish post-start-hook")
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
-		go logicalClusterController.Start(ctx, 2)
+		go logicalClusterController.Start(ctx, s.Options.Controllers.WorkersFor(logicalclusterctrl.ControllerName, 2))
 		return nil
 	}); err != nil {
 		return err
@@ -587,10 +624,12 @@ func (s *Server) installApiResourceController(ctx context.Context, config *rest.
 	c, err := apiresource.NewController(
 		crdClusterClient,
 		kcpClusterClient,
-		s.Options.Controllers.ApiResource.AutoPublishAPIs,
+		apiresourcev1alpha1.NegotiationStrategyType(s.Options.Controllers.ApiResource.NegotiationStrategy),
+		s.Options.Controllers.ApiResource.RequirePublicationApproval,
 		s.KcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
 		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
 	)
 	if err != nil {
 		return err
@@ -603,7 +642,7 @@ func (s *Server) installApiResourceController(ctx context.Context, config *rest.
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(ctx, s.Options.Controllers.ApiResource.NumThreads)
+		go c.Start(ctx, s.Options.Controllers.WorkersFor(apiresource.ControllerName, s.Options.Controllers.ApiResource.NumThreads))
 
 		return nil
 	})
@@ -658,6 +697,10 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 	if err != nil {
 		return err
 	}
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(apiBindingConfig)
+	if err != nil {
+		return err
+	}
 
 	c, err := apibinding.NewController(
 		crdClusterClient,
@@ -670,6 +713,8 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
 		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
 		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		kubeClusterClient,
+		*s.GenericConfig.TracerProvider,
 	)
 	if err != nil {
 		return err
@@ -690,7 +735,7 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(apibinding.ControllerName, 2))
 
 		return nil
 	}); err != nil {
@@ -708,10 +753,15 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 	if err != nil {
 		return err
 	}
+	kubeClusterClient, err = kcpkubernetesclientset.NewForConfig(permissionClaimLabelConfig)
+	if err != nil {
+		return err
+	}
 
 	permissionClaimLabelController, err := permissionclaimlabel.NewController(
 		kcpClusterClient,
 		dynamicClusterClient,
+		kubeClusterClient,
 		ddsif,
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
@@ -727,7 +777,7 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go permissionClaimLabelController.Start(goContext(hookContext), 5)
+		go permissionClaimLabelController.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(permissionclaimlabel.ControllerName, 5))
 
 		return nil
 	}); err != nil {
@@ -762,7 +812,7 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 			logger.Error(err, "failed to finish post-start-hook")
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
-		go permissionClaimLabelResourceController.Start(goContext(hookContext), 2)
+		go permissionClaimLabelResourceController.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(permissionclaimlabel.ResourceControllerName, 2))
 
 		return nil
 	}); err != nil {
@@ -793,7 +843,7 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go apibindingDeletionController.Start(goContext(hookContext), 10)
+		go apibindingDeletionController.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(apibindingdeletion.ControllerName, 10))
 
 		return nil
 	})
@@ -809,21 +859,10 @@ func (s *Server) installAPIBinderController(ctx context.Context, config *rest.Co
 	if err != nil {
 		return err
 	}
-	informerClient, err := kcpclientset.NewForConfig(config)
-	if err != nil {
-		return err
-	}
-
-	// This informer factory is created here because it is specifically against the initializing workspaces virtual
-	// workspace.
-	initializingWorkspacesKcpInformers := kcpinformers.NewSharedInformerFactoryWithOptions(
-		informerClient,
-		resyncPeriod,
-	)
 
 	c, err := initialization.NewAPIBinder(
 		initializingWorkspacesKcpClusterClient,
-		initializingWorkspacesKcpInformers.Core().V1alpha1().LogicalClusters(),
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceTypes(),
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
@@ -840,10 +879,7 @@ func (s *Server) installAPIBinderController(ctx context.Context, config *rest.Co
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		initializingWorkspacesKcpInformers.Start(hookContext.StopCh)
-		initializingWorkspacesKcpInformers.WaitForCacheSync(hookContext.StopCh)
-
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(initialization.ControllerName, 2))
 		return nil
 	})
 }
@@ -873,7 +909,7 @@ func (s *Server) installCRDCleanupController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(crdcleanup.ControllerName, 2))
 
 		return nil
 	})
@@ -920,7 +956,7 @@ func (s *Server) installAPIExportController(ctx context.Context, config *rest.Co
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(apiexport.ControllerName, 2))
 
 		return nil
 	})
@@ -957,7 +993,7 @@ func (s *Server) installAPIExportEndpointSliceController(ctx context.Context, co
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(apiexportendpointslice.ControllerName, 2))
 
 		return nil
 	})
@@ -989,7 +1025,7 @@ func (s *Server) installSchedulingLocationStatusController(ctx context.Context,
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(controllerName, 2))
 
 		return nil
 	})
@@ -1019,7 +1055,7 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(workloadnamespace.ControllerName, 2))
 
 		return nil
 	}); err != nil {
@@ -1032,12 +1068,17 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 func (s *Server) installWorkloadPlacementScheduler(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(config, workloadplacement.ControllerName)
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	kcpClusterClient, err := kcpclientset.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
 	c, err := workloadplacement.NewController(
+		kubeClusterClient,
 		kcpClusterClient,
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Locations(),
 		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
@@ -1055,7 +1096,7 @@ func (s *Server) installWorkloadPlacementScheduler(ctx context.Context, config *
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(workloadplacement.ControllerName, 2))
 
 		return nil
 	})
@@ -1064,12 +1105,17 @@ func (s *Server) installWorkloadPlacementScheduler(ctx context.Context, config *
 func (s *Server) installSchedulingPlacementController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(config, schedulingplacement.ControllerName)
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	kcpClusterClient, err := kcpclientset.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
 	c, err := schedulingplacement.NewController(
+		kubeClusterClient,
 		kcpClusterClient,
 		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Locations(),
@@ -1086,7 +1132,7 @@ func (s *Server) installSchedulingPlacementController(ctx context.Context, confi
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(schedulingplacement.ControllerName, 2))
 
 		return nil
 	})
@@ -1118,7 +1164,7 @@ func (s *Server) installWorkloadsAPIExportController(ctx context.Context, config
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(workloadsapiexport.ControllerName, 2))
 
 		return nil
 	})
@@ -1147,7 +1193,71 @@ func (s *Server) installExtraAnnotationSyncController(ctx context.Context, confi
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(extraannotationsync.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installTrustBundleController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, trustbundle.ControllerName)
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := trustbundle.NewController(
+		kubeClusterClient,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
+		s.KubeSharedInformerFactory.Core().V1().ConfigMaps(),
+	)
+
+	return server.AddPostStartHook(postStartHookName(trustbundle.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(trustbundle.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(trustbundle.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installReferenceGrantController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, referencegrant.ControllerName)
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := referencegrant.NewController(
+		kubeClusterClient,
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		s.KubeSharedInformerFactory.Core().V1().ConfigMaps(),
+	)
+
+	return server.AddPostStartHook(postStartHookName(referencegrant.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(referencegrant.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(referencegrant.ControllerName, 2))
 
 		return nil
 	})
@@ -1179,7 +1289,7 @@ func (s *Server) installWorkloadsAPIExportCreateController(ctx context.Context,
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(workloadsapiexportcreate.ControllerName, 2))
 
 		return nil
 	})
@@ -1211,7 +1321,7 @@ func (s *Server) installWorkloadsSyncTargetExportController(ctx context.Context,
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(synctargetexports.ControllerName, 2))
 
 		return nil
 	})
@@ -1241,7 +1351,7 @@ func (s *Server) installSyncTargetController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(synctargetcontroller.ControllerName, 2))
 
 		return nil
 	})
@@ -1259,6 +1369,10 @@ func (s *Server) installKubeQuotaController(
 	if err != nil {
 		return err
 	}
+	metadataClusterClient, err := metadataclient.NewDynamicMetadataClusterClientForConfig(config)
+	if err != nil {
+		return err
+	}
 
 	// TODO(ncdc): should we make these configurable?
 	const (
@@ -1269,7 +1383,9 @@ func (s *Server) installKubeQuotaController(
 
 	c, err := kubequota.NewController(
 		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
 		kubeClusterClient,
+		metadataClusterClient,
 		s.KubeSharedInformerFactory,
 		s.DiscoveringDynamicSharedInformerFactory,
 		quotaResyncPeriod,
@@ -1288,7 +1404,7 @@ func (s *Server) installKubeQuotaController(
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(kubequota.ControllerName, 2))
 
 		return nil
 	}); err != nil {
@@ -1322,7 +1438,7 @@ func (s *Server) installApiExportIdentityController(ctx context.Context, config
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 1)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(identitycache.ControllerName, 1))
 		return nil
 	})
 }
@@ -1349,7 +1465,7 @@ func (s *Server) installReplicationController(ctx context.Context, config *rest.
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go controller.Start(goContext(hookContext), 2)
+		go controller.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(replication.ControllerName, 2))
 		return nil
 	})
 }
@@ -1393,7 +1509,205 @@ func (s *Server) installGarbageCollectorController(ctx context.Context, config *
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(goContext(hookContext), 2)
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(garbagecollector.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installGitOpsController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, gitops.ControllerName)
+
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := gitops.NewController(
+		kubeClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1beta1().Workspaces(),
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		s.Options.Controllers.GitOps,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(gitops.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(gitops.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(gitops.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installDNSEndpointsController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, dnsendpoints.ControllerName)
+
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := dnsendpoints.NewController(
+		dynamicClusterClient,
+		s.KcpSharedInformerFactory.Core().V1alpha1().Shards(),
+		s.Options.Controllers.DNSEndpoints,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(dnsendpoints.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(dnsendpoints.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(dnsendpoints.ControllerName, 1))
+
+		return nil
+	})
+}
+
+func (s *Server) installClusterAPIController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, clusterapi.ControllerName)
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := clusterapi.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.DiscoveringDynamicSharedInformerFactory,
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		s.CompletedConfig.ShardExternalURL,
+		s.Options.Controllers.ClusterAPI,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(clusterapi.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(clusterapi.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(clusterapi.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installNotificationsController(ctx context.Context, server *genericapiserver.GenericAPIServer) error {
+	c, err := notifications.NewController(
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Tenancy().V1beta1().Workspaces(),
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters(),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		s.Options.Controllers.Notifications,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(notifications.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(notifications.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(notifications.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installSchemaLintController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, schemalint.ControllerName)
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := schemalint.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(schemalint.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(schemalint.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(schemalint.ControllerName, 2))
+
+		return nil
+	})
+}
+
+func (s *Server) installAPIBindingUsageController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, apibindingusage.ControllerName)
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := apibindingusage.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.DiscoveringDynamicSharedInformerFactory,
+		s.apiBindingUsageRequests,
+		s.Options.Controllers.APIBindingUsage.Interval,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(apibindingusage.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(apibindingusage.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), s.Options.Controllers.WorkersFor(apibindingusage.ControllerName, 2))
 
 		return nil
 	})
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// ResourceRequestRecorder records the requests to the resources of logical clusters.
+type ResourceRequestRecorder interface {
+	RecordRequest(cluster logicalcluster.Name, gr schema.GroupResource)
+}
+
+// WithResourceRequestAccounting records the resource requests to a logical cluster, so that the
+// API usage of the workspaces can be accounted per APIBinding. Requests to subresources are
+// recorded as requests to their resource. Wildcard requests, e.g., from controllers, are not
+// recorded, as they are not made by the consumers of the APIs.
+func WithResourceRequestAccounting(handler http.Handler, recorder ResourceRequestRecorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		cluster := request.ClusterFrom(ctx)
+		info, ok := request.RequestInfoFrom(ctx)
+		if cluster != nil && !cluster.Wildcard && !cluster.Name.Empty() && ok && info.IsResourceRequest {
+			// the identity of the resource is not relevant within a logical cluster
+			resource, _, _ := strings.Cut(info.Resource, ":")
+			recorder.RecordRequest(cluster.Name, schema.GroupResource{Group: info.APIGroup, Resource: resource})
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
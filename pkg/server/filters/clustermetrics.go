@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// otherClustersLabel is the logical cluster label value aggregating the requests of the logical
+	// clusters that are not among the busiest ones.
+	otherClustersLabel = "other"
+	// wildcardClusterLabel is the logical cluster label value of wildcard requests, e.g., from controllers.
+	wildcardClusterLabel = "*"
+)
+
+// The logical cluster label is bounded to the busiest logical clusters, see WithLogicalClusterRequestMetrics.
+var (
+	logicalClusterRequests = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "logical_cluster_requests_total",
+			Help:           "Number of requests served per logical cluster, verb and HTTP response code. Only the busiest logical clusters are labeled, the others are aggregated as \"other\".",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{logicalClusterLabel, "verb", "code"},
+	)
+
+	logicalClusterRequestLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Name: "logical_cluster_request_duration_seconds",
+			Help: "Response latency distribution in seconds of non-watch requests per logical cluster and verb. Only the busiest logical clusters are labeled, the others are aggregated as \"other\".",
+			Buckets: []float64{0.005, 0.025, 0.05, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.25, 1.5, 2, 3,
+				4, 5, 6, 8, 10, 15, 20, 30, 45, 60},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{logicalClusterLabel, "verb"},
+	)
+)
+
+var registerLogicalClusterMetrics sync.Once
+
+// RegisterLogicalClusterMetrics registers the per-logical-cluster request metrics.
+func RegisterLogicalClusterMetrics() {
+	registerLogicalClusterMetrics.Do(func() {
+		legacyregistry.MustRegister(logicalClusterRequests)
+		legacyregistry.MustRegister(logicalClusterRequestLatencies)
+	})
+}
+
+// WithLogicalClusterRequestMetrics records the number and the latency of the requests per logical
+// cluster, so that the workspaces driving the load of the shard can be identified. To bound the
+// cardinality of the metrics, at most maxClusters logical clusters are labeled: the ones with the
+// most requests over the last window. The requests of the other logical clusters are aggregated
+// under the "other" label. Metrics are not recorded if maxClusters is not positive.
+func WithLogicalClusterRequestMetrics(handler http.Handler, maxClusters int, window time.Duration) http.Handler {
+	if maxClusters <= 0 {
+		return handler
+	}
+	RegisterLogicalClusterMetrics()
+	metrics := newClusterRequestMetrics(maxClusters, window, clock.RealClock{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		cluster := request.ClusterFrom(ctx)
+		if cluster == nil || (!cluster.Wildcard && cluster.Name.Empty()) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		verb := "unknown"
+		if info, ok := request.RequestInfoFrom(ctx); ok {
+			verb = info.Verb
+		}
+
+		start := metrics.clock.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		handler.ServeHTTP(responsewriter.WrapForHTTP1Or2(recorder), req)
+
+		metrics.observe(cluster, verb, strconv.Itoa(recorder.code), metrics.clock.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+var _ responsewriter.UserProvidedDecorator = &statusRecorder{}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// clusterRequestMetrics selects the logical clusters with their own label. Between the ends of
+// windows, logical clusters are labeled on their first request while there is room. At the end of
+// a window, the labeled clusters are re-selected as the ones with the most requests during the
+// window, and the series of the clusters not selected anymore are deleted.
+type clusterRequestMetrics struct {
+	maxClusters int
+	window      time.Duration
+	clock       clock.Clock
+
+	lock        sync.Mutex
+	windowStart time.Time
+	// counts are the numbers of requests per logical cluster during the current window.
+	counts map[logicalcluster.Name]int
+	// labeled are the logical clusters with their own label, and the label values of their series.
+	labeled map[logicalcluster.Name]*clusterSeries
+}
+
+type clusterSeries struct {
+	requests  map[[2]string]bool // verb, code
+	latencies map[string]bool    // verb
+}
+
+func newClusterRequestMetrics(maxClusters int, window time.Duration, clock clock.Clock) *clusterRequestMetrics {
+	return &clusterRequestMetrics{
+		maxClusters: maxClusters,
+		window:      window,
+		clock:       clock,
+		windowStart: clock.Now(),
+		counts:      map[logicalcluster.Name]int{},
+		labeled:     map[logicalcluster.Name]*clusterSeries{},
+	}
+}
+
+// observe records a request. Metrics are updated under the lock, so that the series of a
+// logical cluster are not re-created by a concurrent request after they have been deleted.
+func (m *clusterRequestMetrics) observe(cluster *request.Cluster, verb, code string, latency time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	label := wildcardClusterLabel
+	if !cluster.Wildcard {
+		label = m.labelLocked(cluster.Name)
+	}
+
+	logicalClusterRequests.WithLabelValues(label, verb, code).Inc()
+	if verb != "watch" {
+		logicalClusterRequestLatencies.WithLabelValues(label, verb).Observe(latency.Seconds())
+	}
+
+	// remember the series of the labeled cluster, to delete them when it is not labeled anymore
+	if series, ok := m.labeled[logicalcluster.Name(label)]; ok {
+		series.requests[[2]string{verb, code}] = true
+		if verb != "watch" {
+			series.latencies[verb] = true
+		}
+	}
+}
+
+func (m *clusterRequestMetrics) labelLocked(name logicalcluster.Name) string {
+	if now := m.clock.Now(); now.Sub(m.windowStart) >= m.window {
+		m.selectLocked()
+		m.windowStart = now
+	}
+
+	m.counts[name]++
+	if _, ok := m.labeled[name]; !ok {
+		if len(m.labeled) >= m.maxClusters {
+			return otherClustersLabel
+		}
+		m.labeled[name] = newClusterSeries()
+	}
+	return name.String()
+}
+
+// selectLocked labels the logical clusters with the most requests during the window that ended,
+// and resets the counts for the next window.
+func (m *clusterRequestMetrics) selectLocked() {
+	candidates := make([]logicalcluster.Name, 0, len(m.counts))
+	for name := range m.counts {
+		candidates = append(candidates, name)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := m.counts[candidates[i]], m.counts[candidates[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		// on ties, favour the clusters already labeled, to avoid churning series
+		_, li := m.labeled[candidates[i]]
+		_, lj := m.labeled[candidates[j]]
+		if li != lj {
+			return li
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > m.maxClusters {
+		candidates = candidates[:m.maxClusters]
+	}
+
+	selected := make(map[logicalcluster.Name]*clusterSeries, len(candidates))
+	for _, name := range candidates {
+		series, ok := m.labeled[name]
+		if !ok {
+			series = newClusterSeries()
+		}
+		selected[name] = series
+	}
+	for name, series := range m.labeled {
+		if _, ok := selected[name]; ok {
+			continue
+		}
+		for labels := range series.requests {
+			logicalClusterRequests.DeleteLabelValues(name.String(), labels[0], labels[1])
+		}
+		for verb := range series.latencies {
+			logicalClusterRequestLatencies.DeleteLabelValues(name.String(), verb)
+		}
+	}
+
+	m.labeled = selected
+	m.counts = map[logicalcluster.Name]int{}
+}
+
+func newClusterSeries() *clusterSeries {
+	return &clusterSeries{
+		requests:  map[[2]string]bool{},
+		latencies: map[string]bool{},
+	}
+}
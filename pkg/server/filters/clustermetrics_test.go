@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestClusterRequestMetricsLabels(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	m := newClusterRequestMetrics(2, time.Minute, clock)
+
+	label := func(name string) string {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		return m.labelLocked(logicalcluster.Name(name))
+	}
+	labeled := func() []logicalcluster.Name {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		var names []logicalcluster.Name
+		for name := range m.labeled {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	t.Log("Clusters are labeled on their first request while there is room")
+	require.Equal(t, "a", label("a"))
+	require.Equal(t, "b", label("b"))
+	require.Equal(t, otherClustersLabel, label("c"))
+	require.Equal(t, "a", label("a"))
+
+	t.Log("The busiest clusters of the window are labeled at its end")
+	for i := 0; i < 5; i++ {
+		require.Equal(t, otherClustersLabel, label("c"))
+	}
+	clock.Step(time.Minute)
+	require.Equal(t, "c", label("c"))
+	require.ElementsMatch(t, []logicalcluster.Name{"a", "c"}, labeled())
+
+	t.Log("Clusters idle during a window make room for new ones")
+	clock.Step(time.Minute)
+	require.Equal(t, "d", label("d"))
+	require.ElementsMatch(t, []logicalcluster.Name{"c", "d"}, labeled())
+}
+
+func TestClusterRequestMetricsWildcard(t *testing.T) {
+	m := newClusterRequestMetrics(1, time.Minute, clocktesting.NewFakeClock(time.Now()))
+
+	m.observe(&request.Cluster{Wildcard: true}, "list", "200", time.Second)
+	m.observe(&request.Cluster{Name: "a"}, "get", "200", time.Second)
+	m.observe(&request.Cluster{Name: "a"}, "watch", "200", time.Second)
+
+	require.Len(t, m.labeled, 1)
+	require.Equal(t, map[[2]string]bool{{"get", "200"}: true, {"watch", "200"}: true}, m.labeled["a"].requests)
+	require.Equal(t, map[string]bool{"get": true}, m.labeled["a"].latencies)
+}
@@ -35,6 +35,9 @@ import (
 	kaudit "k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 )
 
 type (
@@ -42,7 +45,9 @@ type (
 )
 
 const (
-	workspaceAnnotation = "tenancy.kcp.io/workspace"
+	workspaceAnnotation     = "tenancy.kcp.io/workspace"
+	workspacePathAnnotation = "tenancy.kcp.io/path"
+	shardAnnotation         = "tenancy.kcp.io/shard"
 
 	// clusterKey is the context key for the request namespace.
 	acceptHeaderContextKey acceptHeaderContextKeyType = iota
@@ -78,6 +83,26 @@ func WithAuditEventClusterAnnotation(handler http.Handler) http.HandlerFunc {
 	})
 }
 
+// WithAuditEventShardAnnotations adds the shard name, and the canonical path of the
+// logical cluster of the request when it is hosted on the shard, into the annotations
+// of an audit event. Needs initialized annotations.
+func WithAuditEventShardAnnotations(handler http.Handler, shardName string, getLogicalCluster func(logicalcluster.Name) (*corev1alpha1.LogicalCluster, error)) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		kaudit.AddAuditAnnotation(req.Context(), shardAnnotation, shardName)
+
+		cluster := request.ClusterFrom(req.Context())
+		if cluster != nil && !cluster.Name.Empty() && !cluster.Wildcard {
+			if logicalCluster, err := getLogicalCluster(cluster.Name); err == nil {
+				if path, ok := logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]; ok {
+					kaudit.AddAuditAnnotation(req.Context(), workspacePathAnnotation, path)
+				}
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
 // WithClusterScope reads a cluster name from the URL path and puts it into the context.
 // It also trims "/clusters/" prefix from the URL.
 func WithClusterScope(apiHandler http.Handler) http.HandlerFunc {
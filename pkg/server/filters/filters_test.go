@@ -17,17 +17,29 @@ limitations under the License.
 package filters
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/kcp-dev/logicalcluster/v3"
 	"github.com/stretchr/testify/require"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditapis "k8s.io/apiserver/pkg/apis/audit"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 )
 
 func Test_isPartialMetadataHeader(t *testing.T) {
@@ -115,3 +127,51 @@ func TestReCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestWithAuditEventShardAnnotations(t *testing.T) {
+	getLogicalCluster := func(name logicalcluster.Name) (*corev1alpha1.LogicalCluster, error) {
+		if name != "abc123" {
+			return nil, apierrors.NewNotFound(corev1alpha1.Resource("logicalclusters"), corev1alpha1.LogicalClusterName)
+		}
+		return &corev1alpha1.LogicalCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        corev1alpha1.LogicalClusterName,
+				Annotations: map[string]string{core.LogicalClusterPathAnnotationKey: "root:org:ws"},
+			},
+		}, nil
+	}
+
+	tests := map[string]struct {
+		cluster *request.Cluster
+		want    map[string]string
+	}{
+		"no cluster": {
+			want: map[string]string{shardAnnotation: "alpha"},
+		},
+		"wildcard": {
+			cluster: &request.Cluster{Wildcard: true},
+			want:    map[string]string{shardAnnotation: "alpha"},
+		},
+		"cluster on the shard": {
+			cluster: &request.Cluster{Name: "abc123"},
+			want:    map[string]string{shardAnnotation: "alpha", workspacePathAnnotation: "root:org:ws"},
+		},
+		"unknown cluster": {
+			cluster: &request.Cluster{Name: "def456"},
+			want:    map[string]string{shardAnnotation: "alpha"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := kaudit.WithAuditContext(context.Background(), &kaudit.AuditContext{
+				Event: &auditapis.Event{Level: auditapis.LevelMetadata},
+			})
+			if tc.cluster != nil {
+				ctx = request.WithCluster(ctx, *tc.cluster)
+			}
+			handler := WithAuditEventShardAnnotations(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), "alpha", getLogicalCluster)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			require.Equal(t, tc.want, kaudit.AuditEventFrom(ctx).Annotations)
+		})
+	}
+}
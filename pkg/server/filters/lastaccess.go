@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// LogicalClusterAccessRecorder records the requests to logical clusters.
+type LogicalClusterAccessRecorder interface {
+	RecordAccess(cluster logicalcluster.Name)
+}
+
+// WithLogicalClusterAccessRecording records the requests to a logical cluster, so that the time
+// of its last access can be reported in the logical cluster inventory of the shard. Wildcard
+// requests are not recorded, as they do not access a particular logical cluster.
+func WithLogicalClusterAccessRecording(handler http.Handler, recorder LogicalClusterAccessRecorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if cluster := request.ClusterFrom(req.Context()); cluster != nil && !cluster.Wildcard && !cluster.Name.Empty() {
+			recorder.RecordAccess(cluster.Name)
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CauseTypeShardShuttingDown is the cause of the rejection of the long-running requests while
+	// the shard is shutting down, e.g., for a planned restart. The clients are expected to retry
+	// after the Retry-After delay.
+	CauseTypeShardShuttingDown metav1.CauseType = "ShardShuttingDown"
+
+	// CauseTypeWorkspaceNotOnShard is the cause of the rejection of the requests to a workspace that
+	// is not on the shard, e.g., because it has moved to another shard. The clients are expected to
+	// retry after the Retry-After delay, against the current location of the workspace.
+	CauseTypeWorkspaceNotOnShard metav1.CauseType = "WorkspaceNotOnShard"
+)
+
+var (
+	longRunningRequests = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           "long_running_requests",
+			Help:           "Number of long-running requests currently served, per kind, i.e., watch or stream.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"kind"},
+	)
+	terminatedLongRunningRequests = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "long_running_requests_terminated_total",
+			Help:           "Number of long-running requests terminated by the shard, for their clients to re-establish them, per reason, i.e., shutdown or moved.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+)
+
+var registerLongRunningRequestMetrics sync.Once
+
+// RegisterLongRunningRequestMetrics registers the long-running request metrics.
+func RegisterLongRunningRequestMetrics() {
+	registerLongRunningRequestMetrics.Do(func() {
+		legacyregistry.MustRegister(longRunningRequests)
+		legacyregistry.MustRegister(terminatedLongRunningRequests)
+	})
+}
+
+// LongRunningRequests tracks the long-running requests, i.e., the watches and the streams, served
+// by the shard, per logical cluster, so that they can be terminated when they cannot be served
+// anymore, for their clients to re-establish them quickly instead of waiting for their timeout.
+type LongRunningRequests struct {
+	lock     sync.Mutex
+	requests map[logicalcluster.Name]map[*trackedRequest]struct{}
+	// shutdownRetryAfter is the delay after which the clients are told to retry, once the shard is shutting down.
+	shutdownRetryAfter time.Duration
+	shuttingDown       bool
+}
+
+type trackedRequest struct {
+	cancel context.CancelFunc
+}
+
+// NewLongRunningRequests returns an empty LongRunningRequests.
+func NewLongRunningRequests() *LongRunningRequests {
+	return &LongRunningRequests{
+		requests: map[logicalcluster.Name]map[*trackedRequest]struct{}{},
+	}
+}
+
+// Len returns the number of the long-running requests currently served.
+func (t *LongRunningRequests) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	n := 0
+	for _, requests := range t.requests {
+		n += len(requests)
+	}
+	return n
+}
+
+func (t *LongRunningRequests) add(clusterName logicalcluster.Name, cancel context.CancelFunc) (*trackedRequest, time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.shuttingDown {
+		return nil, t.shutdownRetryAfter, false
+	}
+	r := &trackedRequest{cancel: cancel}
+	if t.requests[clusterName] == nil {
+		t.requests[clusterName] = map[*trackedRequest]struct{}{}
+	}
+	t.requests[clusterName][r] = struct{}{}
+	return r, 0, true
+}
+
+func (t *LongRunningRequests) remove(clusterName logicalcluster.Name, r *trackedRequest) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.requests[clusterName], r)
+	if len(t.requests[clusterName]) == 0 {
+		delete(t.requests, clusterName)
+	}
+}
+
+// Terminate terminates the long-running requests to the logical cluster, e.g., because the
+// logical cluster has been deleted or moved to another shard. The watches end as if they had
+// timed out, and the clients re-establish them against the current location of the logical cluster.
+func (t *LongRunningRequests) Terminate(clusterName logicalcluster.Name) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	n := len(t.requests[clusterName])
+	for r := range t.requests[clusterName] {
+		r.cancel()
+	}
+	delete(t.requests, clusterName)
+	terminatedLongRunningRequests.WithLabelValues("moved").Add(float64(n))
+	return n
+}
+
+// Shutdown terminates all the long-running requests, and rejects the new ones with the given
+// Retry-After delay and the ShardShuttingDown cause, so that the clients re-establish them once
+// the shard is back, e.g., after a planned restart, rather than timing out.
+func (t *LongRunningRequests) Shutdown(retryAfter time.Duration) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.shuttingDown = true
+	t.shutdownRetryAfter = retryAfter
+	n := 0
+	for _, requests := range t.requests {
+		for r := range requests {
+			r.cancel()
+			n++
+		}
+	}
+	t.requests = map[logicalcluster.Name]map[*trackedRequest]struct{}{}
+	terminatedLongRunningRequests.WithLabelValues("shutdown").Add(float64(n))
+	return n
+}
+
+// WithLongRunningRequestTracking tracks the long-running requests in tracker, so that they can be
+// terminated, and rejects them once the shard is shutting down. The requests of the loopback
+// clients of the shard are neither tracked nor rejected, as the shard stops them itself.
+func WithLongRunningRequestTracking(handler http.Handler, longRunning request.LongRunningRequestCheck, tracker *LongRunningRequests) http.Handler {
+	RegisterLongRunningRequestMetrics()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		info, ok := request.RequestInfoFrom(ctx)
+		if !ok || !longRunning(req, info) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if u, ok := request.UserFrom(ctx); ok && u.GetName() == user.APIServerUser {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		var clusterName logicalcluster.Name
+		if cluster := request.ClusterFrom(ctx); cluster != nil {
+			if cluster.Wildcard {
+				clusterName = "*"
+			} else {
+				clusterName = cluster.Name
+			}
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		r, retryAfter, ok := tracker.add(clusterName, cancel)
+		if !ok {
+			err := apierrors.NewTooManyRequests("The shard is shutting down, please try again later.", int(retryAfter.Seconds()))
+			err.ErrStatus.Details.Causes = []metav1.StatusCause{{
+				Type:    CauseTypeShardShuttingDown,
+				Message: fmt.Sprintf("the shard is restarting, the %s can be re-established after %s", kind(req), retryAfter),
+			}}
+			w.Header().Set("Connection", "close")
+			responsewriters.ErrorNegotiated(err, errorCodecs, schema.GroupVersion{}, w, req)
+			return
+		}
+		defer tracker.remove(clusterName, r)
+
+		gauge := longRunningRequests.WithLabelValues(kind(req))
+		gauge.Inc()
+		defer gauge.Dec()
+
+		klog.FromContext(ctx).V(6).Info("tracking long-running request", "cluster", clusterName, "verb", info.Verb)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+func kind(req *http.Request) string {
+	if httpstream.IsUpgradeRequest(req) {
+		return "stream"
+	}
+	return "watch"
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	genericfilters "k8s.io/apiserver/pkg/server/filters"
+)
+
+func TestLongRunningRequestTracking(t *testing.T) {
+	tracker := NewLongRunningRequests()
+	started := make(chan struct{})
+	handler := WithLongRunningRequestTracking(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-req.Context().Done()
+	}), genericfilters.BasicLongRunningRequestCheck(sets.NewString("watch"), sets.NewString("exec")), tracker)
+
+	ctx, cancel := context.WithCancel(request.NewContext())
+	defer cancel()
+	serve := func(clusterName logicalcluster.Name, userName string) (*httptest.ResponseRecorder, <-chan struct{}) {
+		ctx := request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: "watch", Resource: "configmaps"})
+		ctx = request.WithCluster(ctx, request.Cluster{Name: clusterName})
+		ctx = request.WithUser(ctx, &user.DefaultInfo{Name: userName})
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps?watch=true", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler.ServeHTTP(w, req)
+		}()
+		return w, done
+	}
+
+	_, doneA := serve("a", "alice")
+	<-started
+	_, doneB := serve("b", "bob")
+	<-started
+	require.Equal(t, 2, tracker.Len())
+
+	t.Log("The watches of a logical cluster are terminated")
+	require.Equal(t, 1, tracker.Terminate("a"))
+	<-doneA
+	require.Eventually(t, func() bool { return tracker.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	t.Log("The watches are terminated on shutdown")
+	require.Equal(t, 1, tracker.Shutdown(5*time.Second))
+	<-doneB
+	require.Equal(t, 0, tracker.Len())
+
+	t.Log("The new watches are rejected once shutting down")
+	w, done := serve("a", "alice")
+	<-done
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "5", w.Header().Get("Retry-After"))
+	var status metav1.Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.NotNil(t, status.Details)
+	require.Len(t, status.Details.Causes, 1)
+	require.Equal(t, CauseTypeShardShuttingDown, status.Details.Causes[0].Type)
+
+	t.Log("The watches of the loopback clients are not rejected")
+	_, done = serve("a", user.APIServerUser)
+	<-started
+	require.Equal(t, 0, tracker.Len())
+	cancel()
+	<-done
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+var slowRequests = compbasemetrics.NewCounterVec(
+	&compbasemetrics.CounterOpts{
+		Name:           "slow_requests_total",
+		Help:           "Number of requests served slower than the slow request threshold of their verb, per verb, resource and HTTP response code.",
+		StabilityLevel: compbasemetrics.ALPHA,
+	},
+	[]string{"verb", "resource", "code"},
+)
+
+var registerSlowRequestMetrics sync.Once
+
+// RegisterSlowRequestMetrics registers the slow request metrics.
+func RegisterSlowRequestMetrics() {
+	registerSlowRequestMetrics.Do(func() {
+		legacyregistry.MustRegister(slowRequests)
+	})
+}
+
+// WithSlowRequestLogging logs and counts the requests served slower than a threshold, with the
+// user, the logical cluster and the resource of the request, so that the workspaces and the
+// queries loading the shard can be identified. Reads, i.e. get and list requests, are compared to
+// readThreshold, and the other requests to writeThreshold. Long-running requests like watches are
+// ignored, as well as the requests of a kind whose threshold is not positive.
+//
+// The latency is broken down into the time to the response headers, covering authorization,
+// admission and storage, and the time to write the response body, covering serialization and
+// the transfer to the client.
+func WithSlowRequestLogging(handler http.Handler, longRunning request.LongRunningRequestCheck, readThreshold, writeThreshold time.Duration) http.Handler {
+	return withSlowRequestLogging(handler, longRunning, readThreshold, writeThreshold, clock.RealClock{})
+}
+
+func withSlowRequestLogging(handler http.Handler, longRunning request.LongRunningRequestCheck, readThreshold, writeThreshold time.Duration, clock clock.PassiveClock) http.Handler {
+	if readThreshold <= 0 && writeThreshold <= 0 {
+		return handler
+	}
+	RegisterSlowRequestMetrics()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		info, ok := request.RequestInfoFrom(ctx)
+		if !ok || !info.IsResourceRequest || longRunning(req, info) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		threshold := writeThreshold
+		if info.Verb == "get" || info.Verb == "list" {
+			threshold = readThreshold
+		}
+		if threshold <= 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		start := clock.Now()
+		recorder := &timingRecorder{ResponseWriter: w, clock: clock, code: http.StatusOK}
+		handler.ServeHTTP(responsewriter.WrapForHTTP1Or2(recorder), req)
+		latency := clock.Since(start)
+		if latency <= threshold {
+			return
+		}
+
+		resource := info.Resource
+		if info.Subresource != "" {
+			resource += "/" + info.Subresource
+		}
+		code := strconv.Itoa(recorder.code)
+		slowRequests.WithLabelValues(info.Verb, resource, code).Inc()
+
+		headersLatency := latency
+		if !recorder.headersWritten.IsZero() {
+			headersLatency = recorder.headersWritten.Sub(start)
+		}
+
+		logger := klog.FromContext(ctx)
+		if cluster := request.ClusterFrom(ctx); cluster != nil {
+			if cluster.Wildcard {
+				logger = logger.WithValues(logging.LogicalClusterKey, "*")
+			} else if !cluster.Name.Empty() {
+				logger = logger.WithValues(logging.LogicalClusterKey, cluster.Name.String())
+			}
+		}
+		if user, ok := request.UserFrom(ctx); ok {
+			logger = logger.WithValues("user", user.GetName())
+		}
+		logger.Info("slow request",
+			"verb", info.Verb,
+			"apiGroup", info.APIGroup,
+			"resource", resource,
+			"namespace", info.Namespace,
+			"name", info.Name,
+			"code", code,
+			"latency", latency,
+			"threshold", threshold,
+			"headersLatency", headersLatency,
+			"responseWriteLatency", latency-headersLatency,
+		)
+	})
+}
+
+// timingRecorder records the response code, and the time the response headers are written.
+type timingRecorder struct {
+	http.ResponseWriter
+	clock clock.PassiveClock
+
+	code           int
+	headersWritten time.Time
+}
+
+var _ responsewriter.UserProvidedDecorator = &timingRecorder{}
+
+func (r *timingRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *timingRecorder) WriteHeader(code int) {
+	if r.headersWritten.IsZero() {
+		r.code = code
+		r.headersWritten = r.clock.Now()
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *timingRecorder) Write(b []byte) (int, error) {
+	if r.headersWritten.IsZero() {
+		r.headersWritten = r.clock.Now()
+	}
+	return r.ResponseWriter.Write(b)
+}
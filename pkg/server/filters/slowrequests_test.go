@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/klog/v2"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestSlowRequestLogging(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	var delay time.Duration
+	handler := withSlowRequestLogging(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		clock.Step(delay)
+		w.WriteHeader(http.StatusOK)
+		clock.Step(time.Second)
+	}), func(_ *http.Request, info *request.RequestInfo) bool {
+		return info.Verb == "watch"
+	}, 5*time.Second, 10*time.Second, clock)
+
+	serve := func(verb string) string {
+		var logged string
+		logger := funcr.New(func(_, args string) { logged = args }, funcr.Options{})
+		ctx := request.WithRequestInfo(request.NewContext(), &request.RequestInfo{IsResourceRequest: true, Verb: verb, Resource: "configmaps", Namespace: "default"})
+		ctx = request.WithCluster(ctx, request.Cluster{Name: "abc123"})
+		ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "alice"})
+		ctx = klog.NewContext(ctx, logger)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		return logged
+	}
+
+	t.Log("Requests faster than the threshold of their verb are not logged")
+	delay = 3 * time.Second
+	require.Empty(t, serve("list"))
+	delay = 8 * time.Second
+	require.Empty(t, serve("create"))
+
+	t.Log("Requests slower than the threshold of their verb are logged and counted")
+	logged := serve("list")
+	require.Contains(t, logged, `"logicalcluster"="abc123"`)
+	require.Contains(t, logged, `"user"="alice"`)
+	require.Contains(t, logged, `"verb"="list"`)
+	require.Contains(t, logged, `"resource"="configmaps"`)
+	require.Contains(t, logged, `"headersLatency"="8s"`)
+	require.Contains(t, logged, `"responseWriteLatency"="1s"`)
+	count, err := testutil.GetCounterMetricValue(slowRequests.WithLabelValues("list", "configmaps", "200"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), count)
+
+	t.Log("Long-running requests are not logged")
+	delay = time.Hour
+	require.Empty(t, serve("watch"))
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// watchCacheBypassConsistent is the reason of the requests without resource version, which
+	// must be served consistently from etcd.
+	watchCacheBypassConsistent = "consistent"
+	// watchCacheBypassContinue is the reason of the list requests continuing a paginated list.
+	watchCacheBypassContinue = "continue"
+	// watchCacheBypassLimit is the reason of the paginated list requests at a resource version
+	// other than "0".
+	watchCacheBypassLimit = "limit"
+	// watchCacheBypassExact is the reason of the list requests at an exact resource version.
+	watchCacheBypassExact = "exact"
+)
+
+var watchCacheBypasses = compbasemetrics.NewCounterVec(
+	&compbasemetrics.CounterOpts{
+		Name:           "watch_cache_bypass_requests_total",
+		Help:           "Number of list and watch requests served from etcd rather than from the watch cache, per verb, group, resource, scope (cluster or wildcard) and reason.",
+		StabilityLevel: compbasemetrics.ALPHA,
+	},
+	[]string{"verb", "group", "resource", "scope", "reason"},
+)
+
+var registerWatchCacheMetrics sync.Once
+
+// RegisterWatchCacheMetrics registers the watch cache bypass metrics.
+func RegisterWatchCacheMetrics() {
+	registerWatchCacheMetrics.Do(func() {
+		legacyregistry.MustRegister(watchCacheBypasses)
+	})
+}
+
+// WithWatchCacheBypassMetrics counts the list and watch requests that the watch cache delegates
+// to etcd, following the rules of the cacher: requests without resource version, continuations
+// of paginated lists, paginated lists at a resource version other than "0", and lists at an
+// exact resource version. Wildcard requests, e.g., from controllers, are counted apart from the
+// requests to a logical cluster, as a wildcard list from etcd reads the objects of all the
+// logical clusters of the shard.
+//
+// The watch caches are not sized per logical cluster: there is one watch cache per resource, shared
+// by all the logical clusters of the shard, and the cacher sizes it dynamically. Sizing it per
+// logical cluster requires changes to the cacher and is out of scope, see the watch cache concepts
+// documentation. The metric shows the resources and the clients whose requests end up in etcd, so
+// that the clients can be tuned instead.
+func WithWatchCacheBypassMetrics(handler http.Handler, watchCacheEnabled bool) http.Handler {
+	if !watchCacheEnabled {
+		return handler
+	}
+	RegisterWatchCacheMetrics()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		info, ok := request.RequestInfoFrom(ctx)
+		if !ok || !info.IsResourceRequest || info.Subresource != "" || (info.Verb != "list" && info.Verb != "watch") {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		if reason := watchCacheBypassReason(req, info.Verb); reason != "" {
+			scope := "cluster"
+			if cluster := request.ClusterFrom(ctx); cluster != nil && cluster.Wildcard {
+				scope = "wildcard"
+			}
+			watchCacheBypasses.WithLabelValues(info.Verb, info.APIGroup, info.Resource, scope, reason).Inc()
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// watchCacheBypassReason returns why the watch cache delegates the request to etcd, or an empty
+// string if the watch cache serves it.
+func watchCacheBypassReason(req *http.Request, verb string) string {
+	query := req.URL.Query()
+	resourceVersion := query.Get("resourceVersion")
+	if resourceVersion == "" {
+		return watchCacheBypassConsistent
+	}
+	if verb == "watch" {
+		return ""
+	}
+	if query.Get("continue") != "" {
+		return watchCacheBypassContinue
+	}
+	if limit, err := strconv.ParseInt(query.Get("limit"), 10, 64); err == nil && limit > 0 && resourceVersion != "0" {
+		return watchCacheBypassLimit
+	}
+	if query.Get("resourceVersionMatch") == string(metav1.ResourceVersionMatchExact) {
+		return watchCacheBypassExact
+	}
+	return ""
+}
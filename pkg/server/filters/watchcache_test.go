@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchCacheBypassReason(t *testing.T) {
+	tests := map[string]struct {
+		verb  string
+		query string
+		want  string
+	}{
+		"list without resource version":               {verb: "list", query: "", want: watchCacheBypassConsistent},
+		"list at resource version 0":                  {verb: "list", query: "resourceVersion=0", want: ""},
+		"list at a resource version":                  {verb: "list", query: "resourceVersion=42", want: ""},
+		"paginated list at resource version 0":        {verb: "list", query: "resourceVersion=0&limit=500", want: ""},
+		"paginated list at a resource version":        {verb: "list", query: "resourceVersion=42&limit=500", want: watchCacheBypassLimit},
+		"list continuation":                           {verb: "list", query: "resourceVersion=42&continue=abc", want: watchCacheBypassContinue},
+		"list at an exact resource version":           {verb: "list", query: "resourceVersion=42&resourceVersionMatch=Exact", want: watchCacheBypassExact},
+		"list at a minimum resource version":          {verb: "list", query: "resourceVersion=42&resourceVersionMatch=NotOlderThan", want: ""},
+		"watch without resource version":              {verb: "watch", query: "", want: watchCacheBypassConsistent},
+		"watch at a resource version":                 {verb: "watch", query: "resourceVersion=42", want: ""},
+		"watch at a resource version with pagination": {verb: "watch", query: "resourceVersion=42&limit=500", want: ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps?"+tc.query, nil)
+			require.Equal(t, tc.want, watchCacheBypassReason(req, tc.verb))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// WorkspaceMetricsPath is the path of the metrics endpoint of a workspace, relative to its
+	// /clusters/<path> prefix.
+	WorkspaceMetricsPath = "/metrics/workspace"
+
+	// logicalClusterLabel is the label of the metrics broken down by logical cluster.
+	logicalClusterLabel = "logical_cluster"
+)
+
+// NewWorkspaceMetricsHandler returns a handler serving, in the Prometheus text format, the series
+// of the request's logical cluster among the metrics broken down by logical cluster, without the
+// logical cluster label. It lets tenants scrape their own usage with the permission to get the
+// /metrics/workspace non-resource URL in their workspace, without access to the metrics of the
+// shard.
+//
+// Only the logical clusters labeled in the metrics have series. For the request metrics, these are
+// the busiest ones, see WithLogicalClusterRequestMetrics.
+func NewWorkspaceMetricsHandler(gatherer compbasemetrics.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		if cluster == nil || cluster.Wildcard || cluster.Name.Empty() {
+			http.Error(w, "the metrics of a workspace must be requested under its /clusters/<path> prefix", http.StatusBadRequest)
+			return
+		}
+
+		families, err := gatherer.Gather()
+		if err != nil {
+			klog.FromContext(req.Context()).Error(err, "failed to gather metrics")
+			http.Error(w, "failed to gather metrics", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", string(expfmt.FmtText))
+		for _, family := range clusterMetricFamilies(families, cluster.Name.String()) {
+			if _, err := expfmt.MetricFamilyToText(w, family); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// clusterMetricFamilies returns the families with the series of the given logical cluster,
+// without the logical cluster label.
+func clusterMetricFamilies(families []*dto.MetricFamily, clusterName string) []*dto.MetricFamily {
+	var ret []*dto.MetricFamily
+	for _, family := range families {
+		var metrics []*dto.Metric
+		for _, metric := range family.GetMetric() {
+			labels := make([]*dto.LabelPair, 0, len(metric.GetLabel()))
+			matches := false
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == logicalClusterLabel {
+					matches = label.GetValue() == clusterName
+					continue
+				}
+				labels = append(labels, label)
+			}
+			if !matches {
+				continue
+			}
+			metrics = append(metrics, &dto.Metric{
+				Label:       labels,
+				Gauge:       metric.Gauge,
+				Counter:     metric.Counter,
+				Summary:     metric.Summary,
+				Untyped:     metric.Untyped,
+				Histogram:   metric.Histogram,
+				TimestampMs: metric.TimestampMs,
+			})
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		ret = append(ret, &dto.MetricFamily{
+			Name:   family.Name,
+			Help:   family.Help,
+			Type:   family.Type,
+			Metric: metrics,
+		})
+	}
+	return ret
+}
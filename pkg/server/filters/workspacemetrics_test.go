@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	compbasemetrics "k8s.io/component-base/metrics"
+)
+
+func TestWorkspaceMetricsHandler(t *testing.T) {
+	registry := compbasemetrics.NewKubeRegistry()
+	requests := compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{Name: "test_requests_total", Help: "Test requests."},
+		[]string{"logical_cluster", "verb"},
+	)
+	shard := compbasemetrics.NewCounter(&compbasemetrics.CounterOpts{Name: "test_shard_total", Help: "Test shard."})
+	registry.MustRegister(requests, shard)
+	requests.WithLabelValues("a", "get").Add(2)
+	requests.WithLabelValues("a", "list").Inc()
+	requests.WithLabelValues("b", "get").Inc()
+	shard.Inc()
+
+	handler := NewWorkspaceMetricsHandler(registry)
+
+	tests := map[string]struct {
+		cluster  *request.Cluster
+		wantCode int
+		wantBody string
+	}{
+		"workspace": {
+			cluster:  &request.Cluster{Name: "a"},
+			wantCode: http.StatusOK,
+			wantBody: `# HELP test_requests_total [ALPHA] Test requests.
+# TYPE test_requests_total counter
+test_requests_total{verb="get"} 2
+test_requests_total{verb="list"} 1
+`,
+		},
+		"workspace without series": {
+			cluster:  &request.Cluster{Name: "c"},
+			wantCode: http.StatusOK,
+		},
+		"wildcard": {
+			cluster:  &request.Cluster{Wildcard: true},
+			wantCode: http.StatusBadRequest,
+		},
+		"no cluster": {
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, WorkspaceMetricsPath, nil)
+			if tc.cluster != nil {
+				req = req.WithContext(request.WithCluster(req.Context(), *tc.cluster))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantCode == http.StatusOK {
+				require.Equal(t, tc.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
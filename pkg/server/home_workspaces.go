@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	kcpkubernetesinformers "github.com/kcp-dev/client-go/informers"
@@ -31,7 +32,9 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
@@ -54,8 +57,12 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	reconcilerworkspace "github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspace"
+	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
 )
 
+// homeRootPath is the path prefix of the home workspaces.
+var homeRootPath = logicalcluster.NewPath("user")
+
 var (
 	homeWorkspaceScheme = runtime.NewScheme()
 	homeWorkspaceCodecs = serializer.NewCodecFactory(homeWorkspaceScheme)
@@ -81,6 +88,11 @@ func init() {
 // - bucketSize is the number of chars comprising each bucket.
 //
 // Bucket workspace names are calculated based on the user name hash.
+//
+// The home workspaces are of the homeType WorkspaceType, or of the type of the first of the
+// groupHomeTypes the user is a member of. The members of the optOutGroups do not get a home
+// workspace, and no home workspace is created once quota home workspaces exist on the shard,
+// unless quota is 0.
 func WithHomeWorkspaces(
 	apiHandler http.Handler,
 	a authorizer.Authorizer,
@@ -93,6 +105,10 @@ func WithHomeWorkspaces(
 	homePrefix logicalcluster.Path,
 	bucketLevels,
 	bucketSize int,
+	homeType logicalcluster.Path,
+	groupHomeTypes []kcpserveroptions.GroupWorkspaceType,
+	optOutGroups []string,
+	quota int,
 ) (http.Handler, error) {
 	if bucketLevels > 5 || bucketSize > 4 {
 		return nil, fmt.Errorf("bucketLevels and bucketSize must be <= 5 and <= 4")
@@ -108,6 +124,10 @@ func WithHomeWorkspaces(
 		creationDelaySeconds: creationDelaySeconds,
 		creationTimeout:      time.Minute,
 		externalHost:         externalHost,
+		homeType:             homeType,
+		groupHomeTypes:       groupHomeTypes,
+		optOutGroups:         sets.NewString(optOutGroups...),
+		quota:                quota,
 
 		kcpClusterClient:  kcpClusterClient,
 		kubeClusterClient: kubeClusterClient,
@@ -139,6 +159,10 @@ type homeWorkspaceHandler struct {
 	creationDelaySeconds     int
 	creationTimeout          time.Duration
 	externalHost             string
+	homeType                 logicalcluster.Path
+	groupHomeTypes           []kcpserveroptions.GroupWorkspaceType
+	optOutGroups             sets.String
+	quota                    int
 
 	transitiveTypeResolver workspacetypeexists.TransitiveTypeResolver
 
@@ -209,6 +233,14 @@ func (h *homeWorkspaceHandler) ServeHTTP(rw http.ResponseWriter, req *http.Reque
 			responsewriters.InternalError(rw, req, err)
 			return
 		}
+		if groups := effectiveUser.GetGroups(); h.optOutGroups.HasAny(groups...) {
+			responsewriters.ErrorNegotiated(
+				kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), "~"),
+				homeWorkspaceCodecs, schema.GroupVersion{}, rw, req,
+			)
+			return
+		}
+
 		// check permissions first
 		attr := authorizer.AttributesRecord{
 			User:            effectiveUser,
@@ -229,23 +261,36 @@ func (h *homeWorkspaceHandler) ServeHTTP(rw http.ResponseWriter, req *http.Reque
 			return
 		}
 
+		if h.quota > 0 {
+			if count := h.homeWorkspaceCount(); count >= h.quota {
+				logger.Info("Home workspace quota reached", "user", effectiveUser.GetName(), "quota", h.quota)
+				responsewriters.ErrorNegotiated(
+					kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), "~", fmt.Errorf("the quota of %d home workspaces is reached", h.quota)),
+					homeWorkspaceCodecs, schema.GroupVersion{}, rw, req,
+				)
+				return
+			}
+		}
+
 		userInfo, err := workspace.WorkspaceOwnerAnnotationValue(effectiveUser)
 		if err != nil {
 			responsewriters.InternalError(rw, req, err)
 			return
 		}
 
+		homeType := h.homeTypeFor(effectiveUser.GetGroups())
+		typePath, typeName := homeType.Split()
 		logicalCluster = &corev1alpha1.LogicalCluster{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: corev1alpha1.LogicalClusterName,
 				Annotations: map[string]string{
 					tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey: userInfo,
-					tenancyv1beta1.LogicalClusterTypeAnnotationKey:          "root:home",
-					core.LogicalClusterPathAnnotationKey:                    fmt.Sprintf("user:%s", effectiveUser.GetName()),
+					tenancyv1beta1.LogicalClusterTypeAnnotationKey:          homeType.String(),
+					core.LogicalClusterPathAnnotationKey:                    homeRootPath.Join(effectiveUser.GetName()).String(),
 				},
 			},
 		}
-		logicalCluster.Spec.Initializers, err = reconcilerworkspace.LogicalClustersInitializers(h.transitiveTypeResolver, h.getWorkspaceType, core.RootCluster.Path(), "home")
+		logicalCluster.Spec.Initializers, err = reconcilerworkspace.LogicalClustersInitializers(h.transitiveTypeResolver, h.getWorkspaceType, typePath, typeName)
 		if err != nil {
 			responsewriters.InternalError(rw, req, err)
 			return
@@ -303,12 +348,12 @@ func (h *homeWorkspaceHandler) ServeHTTP(rw http.ResponseWriter, req *http.Reque
 
 	if logicalCluster.Status.Phase == corev1alpha1.LogicalClusterPhaseInitializing {
 		if time.Since(logicalCluster.CreationTimestamp.Time) > h.creationTimeout {
-			responsewriters.InternalError(rw, req, fmt.Errorf("home workspace creation timeout"))
+			responsewriters.InternalError(rw, req, fmt.Errorf("home workspace creation timeout: %s", provisioningStatus(logicalCluster)))
 			return
 		}
 
 		rw.Header().Set("Retry-After", fmt.Sprintf("%d", h.creationDelaySeconds))
-		http.Error(rw, "Creating the home workspace", http.StatusTooManyRequests)
+		http.Error(rw, provisioningStatus(logicalCluster), http.StatusTooManyRequests)
 		return
 	}
 
@@ -331,6 +376,45 @@ func (h *homeWorkspaceHandler) ServeHTTP(rw http.ResponseWriter, req *http.Reque
 	responsewriters.WriteObjectNegotiated(homeWorkspaceCodecs, negotiation.DefaultEndpointRestrictions, tenancyv1beta1.SchemeGroupVersion, rw, req, http.StatusOK, homeWorkspace)
 }
 
+// homeTypeFor returns the WorkspaceType of the home workspace of a member of the given groups.
+func (h *homeWorkspaceHandler) homeTypeFor(groups []string) logicalcluster.Path {
+	userGroups := sets.NewString(groups...)
+	for _, groupType := range h.groupHomeTypes {
+		if userGroups.Has(groupType.Group) {
+			return groupType.Type
+		}
+	}
+	return h.homeType
+}
+
+// homeWorkspaceCount returns the number of home workspaces on the shard.
+func (h *homeWorkspaceHandler) homeWorkspaceCount() int {
+	count := 0
+	for _, obj := range h.logicalClusterIndexer.List() {
+		logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster)
+		if !ok {
+			continue
+		}
+		if path := logicalcluster.NewPath(logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]); path.HasPrefix(homeRootPath) {
+			count++
+		}
+	}
+	return count
+}
+
+// provisioningStatus describes the provisioning of a home workspace to its user.
+func provisioningStatus(logicalCluster *corev1alpha1.LogicalCluster) string {
+	status := fmt.Sprintf("Creating the home workspace: phase %s", logicalCluster.Status.Phase)
+	if len(logicalCluster.Status.Initializers) > 0 {
+		initializers := make([]string, 0, len(logicalCluster.Status.Initializers))
+		for _, initializer := range logicalCluster.Status.Initializers {
+			initializers = append(initializers, string(initializer))
+		}
+		status += fmt.Sprintf(", waiting for the initializers %s", strings.Join(initializers, ", "))
+	}
+	return status
+}
+
 func (h *homeWorkspaceHandler) getWorkspaceType(path logicalcluster.Path, name string) (*tenancyv1alpha1.WorkspaceType, error) {
 	return indexers.ByPathAndName[*tenancyv1alpha1.WorkspaceType](tenancyv1alpha1.Resource("workspacetypes"), h.workspaceTypeIndexer, path, name)
 }
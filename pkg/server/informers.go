@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"reflect"
+
+	"github.com/go-logr/logr"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+// shardInformerScope is the scope of the informers of the wildcard objects of the shard.
+const shardInformerScope = "shard"
+
+// reportDuplicateInformers logs the resources of the shard informed by more than one shared
+// informer factory. It must be called once the informers of the factories are started.
+// The discovering dynamic factory is not part of the report: it caches partial object metadata,
+// for the controllers to work with any resource, and not the typed objects.
+func (s *Server) reportDuplicateInformers(logger logr.Logger, stopCh <-chan struct{}) {
+	apiExtensionsScheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(apiExtensionsScheme); err != nil {
+		logger.Error(err, "failed to build the apiextensions scheme")
+		return
+	}
+
+	registry := informer.NewRegistry()
+	for _, factory := range []struct {
+		name    string
+		scheme  *runtime.Scheme
+		started map[reflect.Type]bool
+	}{
+		{"kube", clientgoscheme.Scheme, s.KubeSharedInformerFactory.WaitForCacheSync(stopCh)},
+		{"apiextensions", apiExtensionsScheme, s.ApiExtensionsSharedInformerFactory.WaitForCacheSync(stopCh)},
+		{"kcp", kcpscheme.Scheme, s.KcpSharedInformerFactory.WaitForCacheSync(stopCh)},
+	} {
+		if err := registry.AddTyped(shardInformerScope, factory.name, factory.scheme, factory.started); err != nil {
+			logger.Error(err, "failed to record the informers of the factory", "factory", factory.name)
+		}
+	}
+
+	duplicates := registry.Duplicates()
+	for _, d := range duplicates {
+		logger.Error(nil, "resource informed by more than one informer factory", "scope", d.Scope, "resource", d.Resource.String(), "factories", d.Factories)
+	}
+	if len(duplicates) == 0 {
+		logger.V(2).Info("no resource informed by more than one informer factory")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+)
+
+const (
+	// accessGranularity is the precision of the recorded access times, so that the busy logical
+	// clusters do not contend on the lock of the tracker.
+	accessGranularity = time.Second
+
+	// accessRetention is the duration the accesses of the logical clusters without LogicalCluster,
+	// e.g., the system ones or those requested without existing, are kept after their last access.
+	accessRetention = time.Hour
+
+	// pruneInterval is the interval the accesses of the logical clusters are pruned at.
+	pruneInterval = 10 * time.Minute
+)
+
+// AccessTracker records the time of the last request to the logical clusters of a shard.
+type AccessTracker struct {
+	clock clock.Clock
+
+	lock       sync.RWMutex
+	lastAccess map[logicalcluster.Name]time.Time
+}
+
+// NewAccessTracker returns an AccessTracker without any recorded access.
+func NewAccessTracker() *AccessTracker {
+	return newAccessTracker(clock.RealClock{})
+}
+
+func newAccessTracker(clock clock.Clock) *AccessTracker {
+	return &AccessTracker{
+		clock:      clock,
+		lastAccess: map[logicalcluster.Name]time.Time{},
+	}
+}
+
+// RecordAccess records a request to the given logical cluster.
+func (t *AccessTracker) RecordAccess(cluster logicalcluster.Name) {
+	now := t.clock.Now()
+
+	t.lock.RLock()
+	last, found := t.lastAccess[cluster]
+	t.lock.RUnlock()
+	if found && now.Sub(last) < accessGranularity {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if last, found := t.lastAccess[cluster]; !found || now.After(last) {
+		t.lastAccess[cluster] = now
+	}
+}
+
+// LastAccess returns the time of the last request to the given logical cluster, if any.
+func (t *AccessTracker) LastAccess(cluster logicalcluster.Name) (time.Time, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	last, found := t.lastAccess[cluster]
+	return last, found
+}
+
+// Forget removes the access of the given logical cluster, e.g. once its LogicalCluster is deleted.
+func (t *AccessTracker) Forget(cluster logicalcluster.Name) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.lastAccess, cluster)
+}
+
+// Run prunes the accesses of the logical clusters without LogicalCluster in the given lister
+// periodically, until the context is done.
+func (t *AccessTracker) Run(ctx context.Context, logicalClusterLister corev1alpha1listers.LogicalClusterClusterLister) {
+	logger := klog.FromContext(ctx)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if n := t.prune(func(cluster logicalcluster.Name) bool {
+			_, err := logicalClusterLister.Cluster(cluster).Get(corev1alpha1.LogicalClusterName)
+			return !apierrors.IsNotFound(err)
+		}); n > 0 {
+			logger.V(4).Info("pruned the accesses of logical clusters", "count", n)
+		}
+	}, pruneInterval)
+}
+
+// prune removes the accesses older than accessRetention of the logical clusters that do not
+// exist, and returns the number of removed accesses.
+func (t *AccessTracker) prune(exists func(cluster logicalcluster.Name) bool) int {
+	cutoff := t.clock.Now().Add(-accessRetention)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	n := 0
+	for cluster, last := range t.lastAccess {
+		if last.Before(cutoff) && !exists(cluster) {
+			delete(t.lastAccess, cluster)
+			n++
+		}
+	}
+	return n
+}
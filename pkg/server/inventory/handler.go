@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+)
+
+// Path is the path of the logical cluster inventory endpoint of a shard.
+const Path = "/debug/logicalclusters"
+
+// systemClusterPrefix is the prefix of the names of the system logical clusters.
+const systemClusterPrefix = "system:"
+
+// NewHandler returns a handler serving, as a LogicalClusterList, all the logical clusters
+// physically hosted on the shard, including the system ones that are not represented as
+// workspaces, with the number of their objects and the time of their last access, for
+// capacity audits and leak detection. It is only served to the kcp admins.
+//
+// The objects are counted in the given indexers, i.e. those of the resources informed by the
+// shard, keyed by resource.
+//
+// The handler supports the following query parameters:
+//
+//   - hidden=true: only lists the logical clusters without LogicalCluster.
+//   - orphaned=true: only lists the hidden logical clusters that are not system ones.
+func NewHandler(
+	shardName string,
+	indexers func() map[schema.GroupVersionResource]cache.Indexer,
+	logicalClusterLister corev1alpha1listers.LogicalClusterClusterLister,
+	tracker *AccessTracker,
+) http.Handler {
+	return &handler{
+		shardName:            shardName,
+		indexers:             indexers,
+		logicalClusterLister: logicalClusterLister,
+		tracker:              tracker,
+	}
+}
+
+type handler struct {
+	shardName            string
+	indexers             func() map[schema.GroupVersionResource]cache.Indexer
+	logicalClusterLister corev1alpha1listers.LogicalClusterClusterLister
+	tracker              *AccessTracker
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := klog.FromContext(ctx)
+
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := request.UserFrom(ctx)
+	if !ok {
+		http.Error(w, "no user in the request", http.StatusUnauthorized)
+		return
+	}
+	if groups := sets.NewString(user.GetGroups()...); !groups.Has(kuser.SystemPrivilegedGroup) && !groups.Has(bootstrap.SystemKcpAdminGroup) {
+		http.Error(w, "the logical cluster inventory is only available to the kcp admins", http.StatusForbidden)
+		return
+	}
+
+	values := req.URL.Query()
+	hiddenOnly := values.Get("hidden") == "true"
+	orphanedOnly := values.Get("orphaned") == "true"
+
+	clusters, err := h.inventory()
+	if err != nil {
+		logger.Error(err, "failed to list the logical clusters")
+		http.Error(w, "failed to list the logical clusters", http.StatusInternalServerError)
+		return
+	}
+
+	list := &LogicalClusterList{
+		TypeMeta: metav1.TypeMeta{APIVersion: APIVersion, Kind: LogicalClusterListKind},
+		Shard:    h.shardName,
+		Items:    []LogicalCluster{},
+	}
+	for _, cluster := range clusters {
+		if hiddenOnly && !cluster.Hidden {
+			continue
+		}
+		if orphanedOnly && !cluster.Orphaned {
+			continue
+		}
+		list.Items = append(list.Items, cluster)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger.Error(err, "failed to write the logical cluster inventory")
+	}
+}
+
+// inventory returns the logical clusters with objects on the shard, sorted by name.
+func (h *handler) inventory() ([]LogicalCluster, error) {
+	resources := map[logicalcluster.Name]map[string]int{}
+	for gvr, indexer := range h.indexers() {
+		for _, name := range indexer.ListIndexFuncValues(kcpcache.ClusterIndexName) {
+			keys, err := indexer.IndexKeys(kcpcache.ClusterIndexName, name)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) == 0 {
+				continue
+			}
+			clusterName := logicalcluster.Name(name)
+			if resources[clusterName] == nil {
+				resources[clusterName] = map[string]int{}
+			}
+			resources[clusterName][strings.TrimSuffix(gvr.Resource+"."+gvr.Version+"."+gvr.Group, ".")] = len(keys)
+		}
+	}
+
+	clusters := make([]LogicalCluster, 0, len(resources))
+	for clusterName, counts := range resources {
+		cluster := LogicalCluster{
+			Name:      clusterName.String(),
+			System:    strings.HasPrefix(clusterName.String(), systemClusterPrefix),
+			Resources: counts,
+		}
+		for _, count := range counts {
+			cluster.Objects += count
+		}
+
+		logicalCluster, err := h.logicalClusterLister.Cluster(clusterName).Get(corev1alpha1.LogicalClusterName)
+		if apierrors.IsNotFound(err) {
+			cluster.Hidden = true
+			cluster.Orphaned = !cluster.System
+		} else if err != nil {
+			return nil, err
+		} else {
+			cluster.Path = logicalCluster.Annotations[core.LogicalClusterPathAnnotationKey]
+			cluster.Phase = string(logicalCluster.Status.Phase)
+		}
+
+		if last, found := h.tracker.LastAccess(clusterName); found {
+			t := metav1.NewTime(last)
+			cluster.LastAccess = &t
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
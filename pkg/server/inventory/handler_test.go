@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
+	corev1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/core/v1alpha1"
+)
+
+func objectMeta(cluster, namespace, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:   namespace,
+		Name:        name,
+		Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+	}
+}
+
+func newIndexer(t *testing.T, objs ...interface{}) cache.Indexer {
+	indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+	for _, obj := range objs {
+		require.NoError(t, indexer.Add(obj))
+	}
+	return indexer
+}
+
+func newTestHandler(t *testing.T, tracker *AccessTracker) http.Handler {
+	logicalClusters := newIndexer(t,
+		&corev1alpha1.LogicalCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: corev1alpha1.LogicalClusterName,
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey:         "abc",
+					core.LogicalClusterPathAnnotationKey: "root:org",
+				},
+			},
+			Status: corev1alpha1.LogicalClusterStatus{Phase: corev1alpha1.LogicalClusterPhaseReady},
+		},
+	)
+	configMaps := newIndexer(t,
+		&corev1.ConfigMap{ObjectMeta: objectMeta("abc", "default", "a")},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("abc", "default", "b")},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("system:shard", "default", "a")},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("leaked", "default", "a")},
+	)
+	return NewHandler("alpha",
+		func() map[schema.GroupVersionResource]cache.Indexer {
+			return map[schema.GroupVersionResource]cache.Indexer{
+				corev1alpha1.SchemeGroupVersion.WithResource("logicalclusters"): logicalClusters,
+				corev1.SchemeGroupVersion.WithResource("configmaps"):            configMaps,
+			}
+		},
+		corev1alpha1listers.NewLogicalClusterClusterLister(logicalClusters),
+		tracker,
+	)
+}
+
+func get(t *testing.T, h http.Handler, groups []string, query string) (int, *LogicalClusterList) {
+	req := httptest.NewRequest(http.MethodGet, Path+query, nil)
+	req = req.WithContext(request.WithUser(context.Background(), &user.DefaultInfo{Name: "someone", Groups: groups}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	list := &LogicalClusterList{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), list))
+	return w.Code, list
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newAccessTracker(clocktesting.NewFakeClock(now))
+	tracker.RecordAccess("abc")
+	tracker.RecordAccess("gone")
+
+	h := newTestHandler(t, tracker)
+
+	code, list := get(t, h, []string{bootstrap.SystemKcpAdminGroup}, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "alpha", list.Shard)
+	require.Len(t, list.Items, 3)
+	require.NotNil(t, list.Items[0].LastAccess)
+	require.True(t, now.Equal(list.Items[0].LastAccess.Time))
+	list.Items[0].LastAccess = nil
+	require.Equal(t, []LogicalCluster{
+		{
+			Name:      "abc",
+			Path:      "root:org",
+			Phase:     string(corev1alpha1.LogicalClusterPhaseReady),
+			Objects:   3,
+			Resources: map[string]int{"logicalclusters.v1alpha1.core.kcp.io": 1, "configmaps.v1": 2},
+		},
+		{
+			Name:      "leaked",
+			Hidden:    true,
+			Orphaned:  true,
+			Objects:   1,
+			Resources: map[string]int{"configmaps.v1": 1},
+		},
+		{
+			Name:      "system:shard",
+			Hidden:    true,
+			System:    true,
+			Objects:   1,
+			Resources: map[string]int{"configmaps.v1": 1},
+		},
+	}, list.Items)
+
+	_, found := tracker.LastAccess("gone")
+	require.True(t, found, "the inventory must not change the recorded accesses")
+
+	code, list = get(t, h, []string{user.SystemPrivilegedGroup}, "?orphaned=true")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, "leaked", list.Items[0].Name)
+
+	code, list = get(t, h, []string{user.SystemPrivilegedGroup}, "?hidden=true")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, list.Items, 2)
+
+	code, _ = get(t, h, []string{user.AllAuthenticated}, "")
+	require.Equal(t, http.StatusForbidden, code)
+}
+
+func TestAccessTracker(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC))
+	tracker := newAccessTracker(clock)
+
+	tracker.RecordAccess("abc")
+	first, found := tracker.LastAccess("abc")
+	require.True(t, found)
+
+	clock.Step(accessGranularity / 2)
+	tracker.RecordAccess("abc")
+	last, _ := tracker.LastAccess("abc")
+	require.Equal(t, first, last, "accesses within the granularity must not be recorded")
+
+	clock.Step(accessGranularity)
+	tracker.RecordAccess("abc")
+	last, _ = tracker.LastAccess("abc")
+	require.Equal(t, clock.Now(), last)
+}
+
+func TestAccessTrackerForget(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC))
+	tracker := newAccessTracker(clock)
+
+	tracker.RecordAccess("abc")
+	tracker.RecordAccess("def")
+	tracker.Forget("abc")
+	_, found := tracker.LastAccess("abc")
+	require.False(t, found)
+	_, found = tracker.LastAccess("def")
+	require.True(t, found)
+}
+
+func TestAccessTrackerPrune(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC))
+	tracker := newAccessTracker(clock)
+	exists := func(cluster logicalcluster.Name) bool {
+		return cluster == "abc"
+	}
+
+	tracker.RecordAccess("abc")
+	tracker.RecordAccess("unknown")
+	clock.Step(accessRetention * 2 / 3)
+	tracker.RecordAccess("recent")
+	require.Equal(t, 0, tracker.prune(exists), "accesses within the retention must be kept")
+
+	clock.Step(accessRetention * 2 / 3)
+	require.Equal(t, 1, tracker.prune(exists))
+	_, found := tracker.LastAccess("unknown")
+	require.False(t, found, "the expired access of an unknown logical cluster must be removed")
+	_, found = tracker.LastAccess("abc")
+	require.True(t, found, "the access of an existing logical cluster must be kept")
+	_, found = tracker.LastAccess("recent")
+	require.True(t, found, "accesses within the retention must be kept")
+}
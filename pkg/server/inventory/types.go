@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// APIVersion is the version of the inventory responses. Fields are only added to it, never
+	// removed or changed.
+	APIVersion = "inventory.kcp.io/v1alpha1"
+
+	// LogicalClusterListKind is the kind of the inventory responses.
+	LogicalClusterListKind = "LogicalClusterInventoryList"
+)
+
+// LogicalClusterList is the inventory of the logical clusters hosted on a shard.
+type LogicalClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// shard is the name of the shard hosting the logical clusters.
+	Shard string `json:"shard"`
+
+	// items are the logical clusters, sorted by name.
+	Items []LogicalCluster `json:"items"`
+}
+
+// LogicalCluster describes a logical cluster hosted on a shard.
+type LogicalCluster struct {
+	// name is the name of the logical cluster.
+	Name string `json:"name"`
+
+	// path is the canonical path of the logical cluster, when it is set on its LogicalCluster.
+	//
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// phase is the phase of the LogicalCluster of the logical cluster.
+	//
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// hidden is true when the logical cluster has no LogicalCluster, i.e. it is not
+	// represented as a workspace.
+	Hidden bool `json:"hidden"`
+
+	// system is true for the system logical clusters, e.g. system:shard.
+	System bool `json:"system"`
+
+	// orphaned is true when the logical cluster is hidden and not a system one, i.e. its
+	// objects are likely leaked, e.g. by a workspace whose deletion did not complete.
+	Orphaned bool `json:"orphaned"`
+
+	// objects is the number of objects stored in the logical cluster.
+	Objects int `json:"objects"`
+
+	// resources is the number of objects stored in the logical cluster per resource,
+	// as <resource>.<version>.<group>.
+	//
+	// +optional
+	Resources map[string]int `json:"resources,omitempty"`
+
+	// lastAccess is the time of the last request to the logical cluster since the shard
+	// started, if any.
+	//
+	// +optional
+	LastAccess *metav1.Time `json:"lastAccess,omitempty"`
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
@@ -136,8 +137,12 @@ func WithLocalProxy(
 		if foundInIndex && requestShardName != shardName {
 			klog.Infof("Cluster %q is not on this shard, but on %q", cluster.Name, requestShardName)
 
-			w.Header().Set("Retry-After", fmt.Sprintf("%d", 1))
-			http.Error(w, "Not found on this shard", http.StatusTooManyRequests)
+			err := apierrors.NewTooManyRequests("Not found on this shard", 1)
+			err.ErrStatus.Details.Causes = []metav1.StatusCause{{
+				Type:    filters.CauseTypeWorkspaceNotOnShard,
+				Message: fmt.Sprintf("workspace %q is on shard %q", path, requestShardName),
+			}}
+			responsewriters.ErrorNegotiated(err, errorCodecs, schema.GroupVersion{}, w, req)
 			return
 		}
 
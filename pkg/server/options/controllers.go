@@ -29,20 +29,44 @@ import (
 	"k8s.io/klog/v2"
 	kcmoptions "k8s.io/kubernetes/cmd/kube-controller-manager/app/options"
 
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingusage"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/schemalint"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/dnsendpoints"
+	"github.com/kcp-dev/kcp/pkg/reconciler/core/notifications"
+	"github.com/kcp-dev/kcp/pkg/reconciler/ratelimiter"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/gitops"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/clusterapi"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 )
 
 type Controllers struct {
 	EnableAll           bool
 	IndividuallyEnabled []string
+	// Workers are the numbers of workers of the controllers, by controller name, overriding their defaults.
+	Workers map[string]int
+	// RateLimits are the overall rate limits of the workqueues of the controllers, by controller name,
+	// as <qps>:<burst>, overriding the default of 10 qps with a burst of 100.
+	RateLimits          map[string]string
 	ApiResource         ApiResourceController
 	SyncTargetHeartbeat SyncTargetHeartbeatController
+	GitOps              GitOpsController
+	DNSEndpoints        DNSEndpointsController
+	Notifications       NotificationsController
+	ClusterAPI          ClusterAPIController
+	SchemaLint          SchemaLintController
+	APIBindingUsage     APIBindingUsageController
 	SAController        kcmoptions.SAControllerOptions
 }
 
 type ApiResourceController = apiresource.Options
 type SyncTargetHeartbeatController = heartbeat.Options
+type GitOpsController = gitops.Options
+type DNSEndpointsController = dnsendpoints.Options
+type NotificationsController = notifications.Options
+type ClusterAPIController = clusterapi.Options
+type SchemaLintController = schemalint.Options
+type APIBindingUsageController = apibindingusage.Options
 
 var kcmDefaults *kcmoptions.KubeControllerManagerOptions
 
@@ -61,6 +85,12 @@ func NewControllers() *Controllers {
 
 		ApiResource:         *apiresource.DefaultOptions(),
 		SyncTargetHeartbeat: *heartbeat.DefaultOptions(),
+		GitOps:              *gitops.DefaultOptions(),
+		DNSEndpoints:        *dnsendpoints.DefaultOptions(),
+		Notifications:       *notifications.DefaultOptions(),
+		ClusterAPI:          *clusterapi.DefaultOptions(),
+		SchemaLint:          *schemalint.DefaultOptions(),
+		APIBindingUsage:     *apibindingusage.DefaultOptions(),
 		SAController:        *kcmDefaults.SAController,
 	}
 }
@@ -71,8 +101,17 @@ func (c *Controllers) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&c.IndividuallyEnabled, "unsupported-run-individual-controllers", c.IndividuallyEnabled, "Run individual controllers in-process. The controller names can change at any time.")
 	fs.MarkHidden("unsupported-run-individual-controllers") //nolint:errcheck
 
+	fs.StringToIntVar(&c.Workers, "controller-workers", c.Workers, "Number of workers per controller, overriding the default of the controller, e.g. kcp-apibinding=10,kcp-apiexport=5.")
+	fs.StringToStringVar(&c.RateLimits, "controller-rate-limits", c.RateLimits, "Overall rate limit of the workqueue per controller, as <qps>:<burst>, overriding the default of 10 qps with a burst of 100, e.g. kcp-apibinding=50:500. The per-key backoff of failed reconciliations is not affected.")
+
 	apiresource.BindOptions(&c.ApiResource, fs)
 	heartbeat.BindOptions(&c.SyncTargetHeartbeat, fs)
+	gitops.BindOptions(&c.GitOps, fs)
+	dnsendpoints.BindOptions(&c.DNSEndpoints, fs)
+	notifications.BindOptions(&c.Notifications, fs)
+	clusterapi.BindOptions(&c.ClusterAPI, fs)
+	schemalint.BindOptions(&c.SchemaLint, fs)
+	apibindingusage.BindOptions(&c.APIBindingUsage, fs)
 
 	c.SAController.AddFlags(fs)
 }
@@ -112,9 +151,57 @@ func (c *Controllers) Validate() []error {
 	if err := c.SyncTargetHeartbeat.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.GitOps.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.DNSEndpoints.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Notifications.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.ClusterAPI.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.SchemaLint.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.APIBindingUsage.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 	if saErrs := c.SAController.Validate(); saErrs != nil {
 		errs = append(errs, saErrs...)
 	}
+	for name, workers := range c.Workers {
+		if workers < 1 {
+			errs = append(errs, fmt.Errorf("--controller-workers for %s must be positive", name))
+		}
+	}
+	for name, limit := range c.RateLimits {
+		if _, err := ratelimiter.ParseLimit(limit); err != nil {
+			errs = append(errs, fmt.Errorf("--controller-rate-limits for %s: %w", name, err))
+		}
+	}
 
 	return errs
 }
+
+// WorkersFor returns the number of workers of the named controller, defaultWorkers unless overridden.
+func (c *Controllers) WorkersFor(controllerName string, defaultWorkers int) int {
+	if workers, ok := c.Workers[controllerName]; ok {
+		return workers
+	}
+	return defaultWorkers
+}
+
+// Limits returns the overall rate limits of the workqueues of the controllers, by controller name.
+// The rate limits are expected to be valid.
+func (c *Controllers) Limits() map[string]ratelimiter.Limit {
+	limits := make(map[string]ratelimiter.Limit, len(c.RateLimits))
+	for name, s := range c.RateLimits {
+		if limit, err := ratelimiter.ParseLimit(s); err == nil {
+			limits[name] = limit
+		}
+	}
+	return limits
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/net/http/httpproxy"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+)
+
+// EgressClass is a class of the traffic initiated by the shard.
+type EgressClass string
+
+const (
+	// EgressWebhook is the traffic of the calls to the admission webhooks.
+	EgressWebhook EgressClass = "webhook"
+	// EgressCache is the traffic to the cache server.
+	EgressCache EgressClass = "cache"
+	// EgressShard is the traffic to the root shard, to the other shards and to the front-proxy.
+	EgressShard EgressClass = "shard"
+	// EgressAPIService is the traffic of the requests proxied to the extension API servers of APIServices.
+	EgressAPIService EgressClass = "apiservice"
+)
+
+var egressClasses = sets.NewString(string(EgressWebhook), string(EgressCache), string(EgressShard), string(EgressAPIService))
+
+// Egress configures the proxies the connections initiated by the shard are routed through, per
+// traffic class, for the shard to be run in segmented networks. The traffic of a class without
+// proxy is routed according to the proxy environment variables, as by default.
+type Egress struct {
+	// Proxies are the URLs of the HTTP(S) or SOCKS5 proxies, by traffic class.
+	Proxies map[string]string
+	// NoProxy are the hosts, domains and CIDRs that are reached without proxy, in the format of NO_PROXY.
+	NoProxy []string
+}
+
+func NewEgress() *Egress {
+	return &Egress{
+		Proxies: map[string]string{},
+	}
+}
+
+func (s *Egress) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.StringToStringVar(&s.Proxies, "egress-proxies", s.Proxies, fmt.Sprintf(
+		"Proxies the connections initiated by the shard are routed through, as <traffic class>=<proxy URL> pairs, e.g. webhook=http://proxy.example.com:3128. "+
+			"The proxy URL scheme is one of http, https or socks5. The traffic classes are: %s.", strings.Join(egressClasses.List(), ", ")))
+	fs.StringSliceVar(&s.NoProxy, "egress-no-proxy", s.NoProxy,
+		"Hosts, domains and CIDRs reached without proxy, whatever their traffic class, e.g. 10.0.0.0/8,.cluster.local.")
+}
+
+func (s *Egress) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	var errs []error
+	classes := make([]string, 0, len(s.Proxies))
+	for class := range s.Proxies {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		if !egressClasses.Has(class) {
+			errs = append(errs, fmt.Errorf("--egress-proxies: unknown traffic class %q, must be one of %s", class, strings.Join(egressClasses.List(), ", ")))
+			continue
+		}
+		u, err := url.Parse(s.Proxies[class])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("--egress-proxies: invalid proxy URL for %s: %w", class, err))
+			continue
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			errs = append(errs, fmt.Errorf("--egress-proxies: invalid proxy URL scheme %q for %s, must be one of http, https or socks5", u.Scheme, class))
+		}
+		if u.Host == "" {
+			errs = append(errs, fmt.Errorf("--egress-proxies: missing host in proxy URL for %s", class))
+		}
+	}
+	return errs
+}
+
+// ProxyFor returns the proxy function of the traffic class, for the Proxy field of a
+// rest.Config or of an http.Transport, or nil if no proxy is configured for the class.
+func (s *Egress) ProxyFor(class EgressClass) func(*http.Request) (*url.URL, error) {
+	proxy := s.Proxies[string(class)]
+	if proxy == "" {
+		return nil
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxy,
+		HTTPSProxy: proxy,
+		NoProxy:    strings.Join(s.NoProxy, ","),
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// WrapConfig returns the config routing its connections through the proxy of the traffic class,
+// if any, in which case the config is copied.
+func (s *Egress) WrapConfig(class EgressClass, config *rest.Config) *rest.Config {
+	proxy := s.ProxyFor(class)
+	if proxy == nil {
+		return config
+	}
+	config = rest.CopyConfig(config)
+	config.Proxy = proxy
+	return config
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestEgressValidate(t *testing.T) {
+	tests := map[string]struct {
+		proxies  map[string]string
+		wantErrs int
+	}{
+		"none":           {},
+		"valid":          {proxies: map[string]string{"webhook": "http://proxy:3128", "cache": "socks5://proxy:1080", "shard": "https://proxy"}},
+		"unknown class":  {proxies: map[string]string{"authorization": "http://proxy:3128"}, wantErrs: 1},
+		"invalid scheme": {proxies: map[string]string{"webhook": "ftp://proxy"}, wantErrs: 1},
+		"missing host":   {proxies: map[string]string{"webhook": "proxy:3128"}, wantErrs: 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewEgress()
+			for class, proxy := range tc.proxies {
+				s.Proxies[class] = proxy
+			}
+			require.Len(t, s.Validate(), tc.wantErrs)
+		})
+	}
+}
+
+func TestEgressProxyFor(t *testing.T) {
+	s := NewEgress()
+	s.Proxies["webhook"] = "http://proxy.example.com:3128"
+	s.NoProxy = []string{".internal", "10.0.0.0/8"}
+
+	require.Nil(t, s.ProxyFor(EgressCache))
+	config := &rest.Config{Host: "https://cache:6443"}
+	require.Same(t, config, s.WrapConfig(EgressCache, config))
+
+	proxy := s.ProxyFor(EgressWebhook)
+	require.NotNil(t, proxy)
+	for host, expected := range map[string]string{
+		"https://webhook.example.com": "http://proxy.example.com:3128",
+		"https://webhook.internal":    "",
+		"https://10.1.2.3:443":        "",
+	} {
+		req, err := http.NewRequest(http.MethodPost, host, nil)
+		require.NoError(t, err)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		if expected == "" {
+			require.Nil(t, u, host)
+		} else {
+			require.Equal(t, expected, u.String(), host)
+		}
+	}
+
+	wrapped := s.WrapConfig(EgressWebhook, config)
+	require.NotSame(t, config, wrapped)
+	require.NotNil(t, wrapped.Proxy)
+	require.Nil(t, config.Proxy)
+}
@@ -39,6 +39,7 @@ var (
 		"KCP Controllers",
 		"KCP Home Workspaces",
 		"KCP Cache Server",
+		"KCP Egress",
 		"KCP",
 	}
 
@@ -105,6 +106,16 @@ var (
 		"authentication-admin-token-path", // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
 		"kubeconfig-path",                 // Path to which the administrative kubeconfig should be written at startup.
 
+		// KCP SPIFFE Authentication flags
+		"spiffe-trust-domain",      // SPIFFE trust domain whose X.509 SVIDs are authenticated. SVID authentication is disabled if empty.
+		"spiffe-trust-bundle-file", // File holding the PEM trust bundle of the SPIFFE trust domain, reloaded when rotated.
+		"spiffe-svid-cert-file",    // File holding the PEM X.509 SVID of the shard, reloaded when rotated, to authenticate to the cache server.
+		"spiffe-svid-key-file",     // File holding the PEM private key of --spiffe-svid-cert-file.
+
+		// KCP Egress flags
+		"egress-proxies",  // Proxies the connections initiated by the shard are routed through, as <traffic class>=<proxy URL> pairs. The traffic classes are: cache, shard, webhook.
+		"egress-no-proxy", // Hosts, domains and CIDRs reached without proxy, whatever their traffic class.
+
 		// Kubernetes ServiceAccount Token Controller
 		"concurrent-serviceaccount-token-syncs", // The number of service account token objects that are allowed to sync concurrently. Larger number = more responsive token generation, but more CPU (and network) load
 		"service-account-private-key-file",      // Filename containing a PEM-encoded private RSA or ECDSA key used to sign service account tokens.
@@ -140,6 +151,11 @@ var (
 		"experimental-bind-free-port",      // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
 		"batteries-included",               // A list of batteries included (= default objects that might be unwanted in production, but very helpful in trying out kcp or development).
 		"logical-cluster-admin-kubeconfig", // Kubeconfig holding admin(!) credentials to other shards. Defaults to the loopback client.
+		"bootstrap-dir",                    // Directory of declarative manifests applied idempotently at startup. Top-level files are applied to the root workspace, and the files of a sub-directory to the workspace of the path it is named after, e.g. root:org. Workspaces are processed parents first, and files in lexical order.
+		"webhook-front-proxy-kubeconfig",   // Kubeconfig of the front-proxy, used to resolve the webhook Services delegated to workspaces of other shards. If empty, only the workspaces of this shard are resolved.
+		"apiservice-client-ca-file",        // CA certificate issuing the client certificates of the APIServices, which the requests proxied to their extension API servers are authenticated with. Each APIService gets its own client certificate, with the system:kcp:apiservice:<logical cluster>:<name> common name. If empty, the APIServices are not served.
+		"apiservice-client-ca-key-file",    // Private key of the --apiservice-client-ca-file CA.
+		"additional-hierarchy-roots",       // Names of additional top-level hierarchy roots, next to the root workspace, created on the root shard. They host isolated workspace hierarchies.
 
 		// secure serving flags
 		"bind-address",                     // The IP address on which to listen for the --secure-port port. The associated interface(s) must be reachable by the rest of the cluster, and by CLI/web clients. If blank or an unspecified address (0.0.0.0 or ::), all interfaces will be used.
@@ -170,14 +186,40 @@ var (
 		"home-workspaces-bucket-size",            // Number of characters of bucket workspace names used when bucketing home workspaces
 		"home-workspaces-home-creator-groups",    // Groups of users who can have their home workspace created automatically create when first accessing it.
 		"home-workspaces-root-prefix",            // Logical cluster name of the workspace that will contains home workspaces for all workspaces.
+		"home-workspaces-type",                   // WorkspaceType of the home workspaces, as <path>:<name>.
+		"home-workspaces-type-for-groups",        // WorkspaceTypes of the home workspaces of the members of groups, as <group>=<path>:<name>. The first group the user is a member of applies.
+		"home-workspaces-quota",                  // Maximum number of home workspaces created on the shard. 0 means unlimited.
+		"home-workspaces-opt-out-groups",         // Groups of users who do not get a home workspace.
 
 		// KCP Controllers flags
-		"auto-publish-apis",                      // If true, the APIs imported from physical clusters will be published automatically as CRDs
-		"apiresource-controller-threads",         // Number of threads to use for the apiresource controller.
-		"run-controllers",                        // Run the controllers in-process
-		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
-		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
-		"sync-target-heartbeat-threshold",        // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"auto-publish-apis",                        // If true, the APIs imported from physical clusters will be published automatically as CRDs
+		"apibinding-usage-accounting-interval",     // The interval at which the numbers of objects and requests of the bound resources are recorded in the status of the APIBindings, readable by the API service providers through the APIExport virtual workspace. The accounting is disabled if zero.
+		"apiexport-schema-linter",                  // If true, the APIResourceSchemas referenced by the APIExports are linted against best practices, and the findings are reported with the SchemasLinted condition and events on the APIExports.
+		"apiresource-controller-threads",           // Number of threads to use for the apiresource controller.
+		"apiresource-negotiation-strategy",         // Default strategy negotiating the APIs imported from physical clusters, one of Strict, LCD or Manual. It is overridden per workspace and per resource with the experimental.apiresource.kcp.io/negotiation-strategy annotation of the LogicalCluster.
+		"apiresource-require-publication-approval", // If true, the NegotiatedAPIResources are only published as CRDs once their spec is approved with the experimental.apiresource.kcp.io/approved-spec annotation, or by the experimental.apiresource.kcp.io/auto-approve annotation of the LogicalCluster. Workspaces can require the approval with the experimental.apiresource.kcp.io/require-approval annotation of their LogicalCluster.
+		"capi-kcp-ca-file",                         // Path to the CA bundle used by the syncers of the clusters provisioned with Cluster API to verify the kcp serving certificate.
+		"capi-kcp-server-url",                      // URL of kcp used by the syncers of the clusters provisioned with Cluster API. Defaults to the shard external URL.
+		"capi-sync-target-apiexports",              // APIExports supported by the SyncTargets of the clusters provisioned with Cluster API, as <workspace path>:<apiexport>.
+		"capi-sync-target-resources",               // Resources synchronized by the syncers of the clusters provisioned with Cluster API, in addition to those of the supported APIExports.
+		"capi-syncer-image",                        // Image of the syncer deployed to the clusters provisioned with Cluster API, which are registered as SyncTargets in the workspace of their Cluster object. The Cluster API controller is disabled if empty.
+		"controller-rate-limits",                   // Overall rate limit of the workqueue per controller, as <qps>:<burst>, overriding the default of 10 qps with a burst of 100, e.g. kcp-apibinding=50:500. The per-key backoff of failed reconciliations is not affected.
+		"controller-workers",                       // Number of workers per controller, overriding the default of the controller, e.g. kcp-apibinding=10,kcp-apiexport=5.
+		"dns-endpoints-namespace",                  // Namespace of the DNS endpoints workspace the DNSEndpoint is published into.
+		"dns-endpoints-ttl",                        // TTL in seconds of the published DNS records.
+		"dns-endpoints-workspace",                  // Path of the workspace the DNSEndpoint of the shard hostnames is published into, for the CRD source of external-dns. The DNSEndpoint CRD must be installed in the workspace. The DNS endpoints controller is disabled if empty.
+		"gitops-credentials-secret",                // Name of the Secret in the GitOps namespace holding the token, and optionally the ca.crt, to access the workspaces with.
+		"gitops-format",                            // Format of the GitOps cluster Secrets, either argocd or flux.
+		"gitops-namespace",                         // Namespace of the GitOps workspace the cluster Secrets are rendered into, e.g. flux-system for Flux.
+		"gitops-workspace",                         // Path of the workspace the GitOps cluster Secrets of the workspaces of this shard are rendered into. The GitOps controller is disabled if empty.
+		"notification-max-retries",                 // Number of times the delivery of a notification is retried with an exponential backoff before it is dropped.
+		"notification-sinks-namespace",             // Namespace of the notification sinks workspace holding the sink Secrets.
+		"notification-sinks-workspace",             // Path of the workspace holding the notification sinks, as Secrets labelled notifications.kcp.io/sink=true. The notifications controller is disabled if empty.
+		"notification-timeout",                     // Timeout of the delivery of a notification to a sink.
+		"run-controllers",                          // Run the controllers in-process
+		"run-virtual-workspaces",                   // Run the virtual workspaces apiservers in-process
+		"unsupported-run-individual-controllers",   // Run individual controllers in-process. The controller names can change at any time.
+		"sync-target-heartbeat-threshold",          // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
 
 		// KCP Cache Server flags
 		"cache-server-kubeconfig-file", // Kubeconfig for the cache server this instance connects to (defaults to loopback configuration).
@@ -186,6 +228,13 @@ var (
 		"virtual-workspaces-workspaces.authorization-cache.jitter-factor", // Jitter factor for cache re-sync. Leave unset to use a default factor.
 		"virtual-workspaces-workspaces.authorization-cache.resync-period", // Period for cache re-sync.
 		"virtual-workspaces-workspaces.authorization-cache.sliding",       // Whether or not to take into account sync duration in period calculations.
+		"virtual-workspaces-initializingworkspaces.max-list-limit",        // Maximum page size of paginated LIST requests for LogicalClusters across workspaces. Set to 0 to disable.
+		"virtual-workspaces-syncer.impersonated-users",                    // Users that syncers are allowed to impersonate when accessing the syncer virtual workspaces, so that the requests replayed upstream are attributed to them.
+		"virtual-workspaces-syncer.impersonated-groups",                   // Groups that syncers are allowed to impersonate when accessing the syncer virtual workspaces, so that the requests replayed upstream are attributed to them.
+		"virtual-workspaces-consumer-qps",                                 // Maximum sustained rate of requests per second per consumer, i.e., per API domain, logical cluster and user. Set to 0 to disable throttling.
+		"virtual-workspaces-consumer-burst",                               // Maximum burst of requests per consumer.
+		"virtual-workspaces-max-requests-in-flight",                       // Maximum number of non-long-running requests in flight per priority level of the virtual workspaces.
+		"virtual-workspaces-queue-timeout",                                // How long a virtual workspace request waits for a seat in its priority level before being rejected.
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
@@ -218,18 +267,24 @@ var (
 		"profiling",            // Enable profiling via web interface host:port/debug/pprof/
 
 		// metrics flags
-		"allow-metric-labels",             // The map from metric-label to value allow-list of this label. The key's format is <MetricName>,<LabelName>. The value's format is <allowed_value>,<allowed_value>...e.g. metric1,label1='v1,v2,v3', metric1,label2='v1,v2,v3' metric2,label1='v1,v2,v3'.
-		"disabled-metrics",                // This flag provides an escape hatch for misbehaving metrics. You must provide the fully qualified metric name in order to disable it. Disclaimer: disabling metrics is higher in precedence than showing hidden metrics.
-		"show-hidden-metrics-for-version", // The previous version for which you want to show hidden metrics. Only the previous minor version is meaningful, other values will not be allowed. The format is <major>.<minor>, e.g.: '1.16'. The purpose of this format is make sure you have the opportunity to notice if the next release hides additional metrics, rather than being surprised when they are permanently removed in the release after that.
+		"allow-metric-labels",                  // The map from metric-label to value allow-list of this label. The key's format is <MetricName>,<LabelName>. The value's format is <allowed_value>,<allowed_value>...e.g. metric1,label1='v1,v2,v3', metric1,label2='v1,v2,v3' metric2,label1='v1,v2,v3'.
+		"disabled-metrics",                     // This flag provides an escape hatch for misbehaving metrics. You must provide the fully qualified metric name in order to disable it. Disclaimer: disabling metrics is higher in precedence than showing hidden metrics.
+		"logical-cluster-metrics-max-clusters", // Maximum number of logical clusters labeled in the request metrics, picked as the ones with the most requests. The requests of the other logical clusters are aggregated under the "other" label. Set to 0 to disable the per-logical-cluster request metrics.
+		"logical-cluster-metrics-window",       // Period over which the requests are counted to pick the logical clusters labeled in the request metrics.
+		"show-hidden-metrics-for-version",      // The previous version for which you want to show hidden metrics. Only the previous minor version is meaningful, other values will not be allowed. The format is <major>.<minor>, e.g.: '1.16'. The purpose of this format is make sure you have the opportunity to notice if the next release hides additional metrics, rather than being surprised when they are permanently removed in the release after that.
 
 		// misc flags
-		"enable-logs-handler",                   // If true, install a /logs handler for the apiserver logs.
-		"event-ttl",                             // Amount of time to retain events.
-		"identity-lease-duration-seconds",       // The duration of kube-apiserver lease in seconds, must be a positive number. (In use when the APIServerIdentity feature gate is enabled.)
-		"identity-lease-renew-interval-seconds", // The interval of kube-apiserver renewing its lease in seconds, must be a positive number. (In use when the APIServerIdentity feature gate is enabled.)
-		"max-connection-bytes-per-sec",          // If non-zero, throttle each user connection to this number of bytes/sec. Currently only applies to long-running requests.
-		"proxy-client-cert-file",                // Client certificate used to prove the identity of the aggregator or kube-apiserver when it must call out during a request. This includes proxying requests to a user api-server and calling out to webhook admission plugins. It is expected that this cert includes a signature from the CA in the --requestheader-client-ca-file flag. That CA is published in the 'extension-apiserver-authentication' configmap in the kube-system namespace. Components receiving calls from kube-aggregator should use that CA to perform their half of the mutual TLS verification.
-		"proxy-client-key-file",                 // Private key for the client certificate used to prove the identity of the aggregator or kube-apiserver when it must call out during a request. This includes proxying requests to a user api-server and calling out to webhook admission plugins.
+		"enable-logs-handler",                         // If true, install a /logs handler for the apiserver logs.
+		"event-ttl",                                   // Amount of time to retain events.
+		"identity-lease-duration-seconds",             // The duration of kube-apiserver lease in seconds, must be a positive number. (In use when the APIServerIdentity feature gate is enabled.)
+		"identity-lease-renew-interval-seconds",       // The interval of kube-apiserver renewing its lease in seconds, must be a positive number. (In use when the APIServerIdentity feature gate is enabled.)
+		"long-running-requests-shutdown-retry-after",  // The delay after which the clients of the watches and streams rejected while the shard is shutting down are told to retry, e.g., the expected duration of a restart.
+		"long-running-requests-terminate-on-shutdown", // If true, the watches and streams are terminated as soon as the shard is shutting down, and the new ones are rejected with a Retry-After response header, so that the clients re-establish them once the shard is back instead of timing out.
+		"max-connection-bytes-per-sec",                // If non-zero, throttle each user connection to this number of bytes/sec. Currently only applies to long-running requests.
+		"proxy-client-cert-file",                      // Client certificate used to prove the identity of the aggregator or kube-apiserver when it must call out during a request. This includes proxying requests to a user api-server and calling out to webhook admission plugins. It is expected that this cert includes a signature from the CA in the --requestheader-client-ca-file flag. That CA is published in the 'extension-apiserver-authentication' configmap in the kube-system namespace. Components receiving calls from kube-aggregator should use that CA to perform their half of the mutual TLS verification.
+		"proxy-client-key-file",                       // Private key for the client certificate used to prove the identity of the aggregator or kube-apiserver when it must call out during a request. This includes proxying requests to a user api-server and calling out to webhook admission plugins.
+		"slow-request-read-threshold",                 // Latency above which get and list requests are logged, with their user, logical cluster and resource, and counted in the slow_requests_total metric. Set to 0 to disable.
+		"slow-request-write-threshold",                // Latency above which the requests other than get, list and long-running ones are logged, with their user, logical cluster and resource, and counted in the slow_requests_total metric. Set to 0 to disable.
 	)
 
 	disallowedFlags = sets.NewString(
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAdditionalHierarchyRoots(t *testing.T) {
+	tests := map[string]struct {
+		names   []string
+		wantErr string
+	}{
+		"no roots": {},
+		"roots": {
+			names: []string{"acme", "globex"},
+		},
+		"root": {
+			names:   []string{"root"},
+			wantErr: `name "root" is reserved`,
+		},
+		"system": {
+			names:   []string{"acme", "system"},
+			wantErr: `name "system" is reserved`,
+		},
+		"duplicate": {
+			names:   []string{"acme", "acme"},
+			wantErr: `duplicate name "acme"`,
+		},
+		"path": {
+			names:   []string{"acme:team"},
+			wantErr: `invalid name "acme:team"`,
+		},
+		"upper case": {
+			names:   []string{"Acme"},
+			wantErr: `invalid name "Acme"`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := validateAdditionalHierarchyRoots(tc.names)
+			if tc.wantErr == "" {
+				require.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			require.Contains(t, errs[0].Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestAdditionalHierarchyRootsDefault(t *testing.T) {
+	o := NewOptions(t.TempDir())
+	require.Empty(t, o.Extra.AdditionalHierarchyRoots)
+	require.Empty(t, validateAdditionalHierarchyRoots(o.Extra.AdditionalHierarchyRoots))
+}
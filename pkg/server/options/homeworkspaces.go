@@ -18,6 +18,7 @@ package options
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v3"
 	"github.com/spf13/pflag"
@@ -36,6 +37,48 @@ type HomeWorkspaces struct {
 
 	HomeCreatorGroups []string
 	HomeRootPrefix    string
+
+	// Type is the WorkspaceType of the home workspaces, as <path>:<name>.
+	Type string
+	// TypesForGroups are the WorkspaceTypes of the home workspaces of the members of groups,
+	// as <group>=<path>:<name>. The first group the user is a member of applies.
+	TypesForGroups []string
+	// Quota is the maximum number of home workspaces created on the shard, 0 meaning unlimited.
+	Quota int
+	// OptOutGroups are the groups of users who do not get a home workspace.
+	OptOutGroups []string
+}
+
+// GroupWorkspaceType is the WorkspaceType of the home workspaces of the members of a group.
+type GroupWorkspaceType struct {
+	Group string
+	Type  logicalcluster.Path
+}
+
+// HomeWorkspaceTypes returns the WorkspaceTypes of the home workspaces per group, in order of
+// precedence.
+func (hw *HomeWorkspaces) HomeWorkspaceTypes() ([]GroupWorkspaceType, error) {
+	types := make([]GroupWorkspaceType, 0, len(hw.TypesForGroups))
+	for _, entry := range hw.TypesForGroups {
+		group, typ, found := strings.Cut(entry, "=")
+		if !found || group == "" {
+			return nil, fmt.Errorf("invalid home workspace type %q, must be <group>=<path>:<name>", entry)
+		}
+		path, err := parseWorkspaceType(typ)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, GroupWorkspaceType{Group: group, Type: path})
+	}
+	return types, nil
+}
+
+func parseWorkspaceType(value string) (logicalcluster.Path, error) {
+	path := logicalcluster.NewPath(value)
+	if _, hasParent := path.Parent(); !path.IsValid() || path == logicalcluster.Wildcard || !hasParent {
+		return logicalcluster.Path{}, fmt.Errorf("invalid workspace type %q, must be <path>:<name>", value)
+	}
+	return path, nil
 }
 
 func NewHomeWorkspaces() *HomeWorkspaces {
@@ -46,6 +89,7 @@ func NewHomeWorkspaces() *HomeWorkspaces {
 		BucketSize:           2,
 		HomeCreatorGroups:    []string{user.AllAuthenticated},
 		HomeRootPrefix:       "root:users",
+		Type:                 "root:home",
 	}
 }
 
@@ -53,9 +97,13 @@ func (hw *HomeWorkspaces) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&hw.Enabled, "enable-home-workspaces", hw.Enabled, "Enable the Home Workspaces feature. Home workspaces allow a personal home workspace to provisioned on first access per-user. A user is cluster-admin inside his personal Home workspace.")
 	fs.IntVar(&hw.CreationDelaySeconds, "home-workspaces-creation-delay-seconds", hw.CreationDelaySeconds, "Delay, in seconds, before retrying accessing the Home workspace after its automatic creation. This value is used when sending 'retry-after' responses to the Kubernetes client.")
 	fs.IntVar(&hw.BucketLevels, "home-workspaces-bucket-levels", hw.BucketLevels, "Number of levels of bucket workspaces when bucketing home workspaces")
-	fs.IntVar(&hw.BucketLevels, "home-workspaces-bucket-size", hw.BucketSize, "Number of characters of bucket workspace names used when bucketing home workspaces")
+	fs.IntVar(&hw.BucketSize, "home-workspaces-bucket-size", hw.BucketSize, "Number of characters of bucket workspace names used when bucketing home workspaces")
 	fs.StringSliceVar(&hw.HomeCreatorGroups, "home-workspaces-home-creator-groups", hw.HomeCreatorGroups, "Groups of users who can have their home workspace created automatically create when first accessing it.")
 	fs.StringVar(&hw.HomeRootPrefix, "home-workspaces-root-prefix", hw.HomeRootPrefix, "Logical cluster name of the workspace that will contains home workspaces for all workspaces.")
+	fs.StringVar(&hw.Type, "home-workspaces-type", hw.Type, "WorkspaceType of the home workspaces, as <path>:<name>.")
+	fs.StringSliceVar(&hw.TypesForGroups, "home-workspaces-type-for-groups", hw.TypesForGroups, "WorkspaceTypes of the home workspaces of the members of groups, as <group>=<path>:<name>. The first group the user is a member of applies, and --home-workspaces-type otherwise.")
+	fs.IntVar(&hw.Quota, "home-workspaces-quota", hw.Quota, "Maximum number of home workspaces created on the shard. Users without a home workspace are denied its creation when it is reached. 0 means unlimited.")
+	fs.StringSliceVar(&hw.OptOutGroups, "home-workspaces-opt-out-groups", hw.OptOutGroups, "Groups of users who do not get a home workspace.")
 
 	fs.MarkDeprecated("home-workspaces-home-creator-groups", "This flag is deprecated and will be removed in a future release.")    //nolint:errcheck
 	fs.MarkDeprecated("home-workspaces-root-prefix", "This flag is deprecated and will be removed in a future release.")            //nolint:errcheck
@@ -71,7 +119,7 @@ func (hw *HomeWorkspaces) Validate() []error {
 		if hw.BucketLevels < 1 || hw.BucketLevels > 5 {
 			errs = append(errs, fmt.Errorf("--home-workspaces-bucket-levels should be between 1 and 5"))
 		}
-		if hw.BucketSize < 1 || hw.BucketLevels > 4 {
+		if hw.BucketSize < 1 || hw.BucketSize > 4 {
 			errs = append(errs, fmt.Errorf("--home-workspaces-bucket-size should be between 1 and 4"))
 		}
 		if hw.CreationDelaySeconds < 1 {
@@ -84,6 +132,15 @@ func (hw *HomeWorkspaces) Validate() []error {
 		} else if parent, ok := homePrefix.Parent(); !ok || parent != core.RootCluster.Path() {
 			errs = append(errs, fmt.Errorf("--home-workspaces-root-prefix should be a direct child of the root logical cluster"))
 		}
+		if _, err := parseWorkspaceType(hw.Type); err != nil {
+			errs = append(errs, fmt.Errorf("--home-workspaces-type: %w", err))
+		}
+		if _, err := hw.HomeWorkspaceTypes(); err != nil {
+			errs = append(errs, fmt.Errorf("--home-workspaces-type-for-groups: %w", err))
+		}
+		if hw.Quota < 0 {
+			errs = append(errs, fmt.Errorf("--home-workspaces-quota should be positive, or 0 for unlimited"))
+		}
 	}
 
 	return errs
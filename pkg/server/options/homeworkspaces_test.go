@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHomeWorkspacesValidate(t *testing.T) {
+	tests := map[string]struct {
+		modify  func(hw *HomeWorkspaces)
+		wantErr bool
+	}{
+		"defaults": {
+			modify: func(hw *HomeWorkspaces) {},
+		},
+		"group types": {
+			modify: func(hw *HomeWorkspaces) {
+				hw.TypesForGroups = []string{"premium=root:premium-home", "trial=root:org:trial-home"}
+			},
+		},
+		"group type without group": {
+			modify: func(hw *HomeWorkspaces) {
+				hw.TypesForGroups = []string{"root:premium-home"}
+			},
+			wantErr: true,
+		},
+		"type without path": {
+			modify: func(hw *HomeWorkspaces) {
+				hw.Type = "home"
+			},
+			wantErr: true,
+		},
+		"negative quota": {
+			modify: func(hw *HomeWorkspaces) {
+				hw.Quota = -1
+			},
+			wantErr: true,
+		},
+		"bucket size too large": {
+			modify: func(hw *HomeWorkspaces) {
+				hw.BucketSize = 5
+			},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			hw := NewHomeWorkspaces()
+			tc.modify(hw)
+			if errs := hw.Validate(); tc.wantErr {
+				require.NotEmpty(t, errs)
+			} else {
+				require.Empty(t, errs)
+			}
+		})
+	}
+}
+
+func TestHomeWorkspaceTypes(t *testing.T) {
+	hw := NewHomeWorkspaces()
+	hw.TypesForGroups = []string{"premium=root:premium-home", "trial=root:org:trial-home"}
+	types, err := hw.HomeWorkspaceTypes()
+	require.NoError(t, err)
+	require.Equal(t, []GroupWorkspaceType{
+		{Group: "premium", Type: logicalcluster.NewPath("root:premium-home")},
+		{Group: "trial", Type: logicalcluster.NewPath("root:org:trial-home")},
+	}, types)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// LongRunningRequests are the options of the termination of the long-running requests, i.e., the
+// watches and the streams, when the shard is shutting down.
+type LongRunningRequests struct {
+	TerminateOnShutdown bool
+	ShutdownRetryAfter  time.Duration
+}
+
+func NewLongRunningRequests() *LongRunningRequests {
+	return &LongRunningRequests{
+		TerminateOnShutdown: true,
+		ShutdownRetryAfter:  5 * time.Second,
+	}
+}
+
+func (s *LongRunningRequests) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&s.TerminateOnShutdown, "long-running-requests-terminate-on-shutdown", s.TerminateOnShutdown, "If true, the watches and streams are terminated as soon as the shard is shutting down, and the new ones are rejected with a Retry-After response header, so that the clients re-establish them once the shard is back instead of timing out.")
+	fs.DurationVar(&s.ShutdownRetryAfter, "long-running-requests-shutdown-retry-after", s.ShutdownRetryAfter, "The delay after which the clients of the watches and streams rejected while the shard is shutting down are told to retry, e.g., the expected duration of a restart.")
+}
+
+func (s *LongRunningRequests) Validate() []error {
+	var errs []error
+
+	if s.ShutdownRetryAfter < time.Second {
+		errs = append(errs, fmt.Errorf("--long-running-requests-shutdown-retry-after must be at least 1s"))
+	}
+
+	return errs
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// LogicalClusterMetrics are the options of the request metrics labeled by logical cluster.
+type LogicalClusterMetrics struct {
+	MaxClusters int
+	Window      time.Duration
+}
+
+func NewLogicalClusterMetrics() *LogicalClusterMetrics {
+	return &LogicalClusterMetrics{
+		MaxClusters: 10,
+		Window:      time.Minute,
+	}
+}
+
+func (m *LogicalClusterMetrics) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&m.MaxClusters, "logical-cluster-metrics-max-clusters", m.MaxClusters, "Maximum number of logical clusters labeled in the request metrics, picked as the ones with the most requests. The requests of the other logical clusters are aggregated under the \"other\" label. Set to 0 to disable the per-logical-cluster request metrics.")
+	fs.DurationVar(&m.Window, "logical-cluster-metrics-window", m.Window, "Period over which the requests are counted to pick the logical clusters labeled in the request metrics.")
+}
+
+func (m *LogicalClusterMetrics) Validate() []error {
+	var errs []error
+
+	if m.MaxClusters < 0 {
+		errs = append(errs, fmt.Errorf("--logical-cluster-metrics-max-clusters cannot be negative"))
+	}
+	if m.MaxClusters > 0 && m.Window <= 0 {
+		errs = append(errs, fmt.Errorf("--logical-cluster-metrics-window must be positive"))
+	}
+
+	return errs
+}
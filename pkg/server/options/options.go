@@ -28,6 +28,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
@@ -35,20 +36,26 @@ import (
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	kcpadmission "github.com/kcp-dev/kcp/pkg/admission"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
 )
 
 type Options struct {
-	GenericControlPlane ServerRunOptions
-	EmbeddedEtcd        etcdoptions.Options
-	Controllers         Controllers
-	Authorization       Authorization
-	AdminAuthentication AdminAuthentication
-	Virtual             Virtual
-	HomeWorkspaces      HomeWorkspaces
-	Cache               Cache
+	GenericControlPlane   ServerRunOptions
+	EmbeddedEtcd          etcdoptions.Options
+	Controllers           Controllers
+	Authorization         Authorization
+	AdminAuthentication   AdminAuthentication
+	SPIFFE                SPIFFE
+	Egress                Egress
+	Virtual               Virtual
+	HomeWorkspaces        HomeWorkspaces
+	Cache                 Cache
+	LogicalClusterMetrics LogicalClusterMetrics
+	SlowRequests          SlowRequests
+	LongRunningRequests   LongRunningRequests
 
 	Extra ExtraOptions
 }
@@ -65,19 +72,29 @@ type ExtraOptions struct {
 	DiscoveryPollInterval         time.Duration
 	ExperimentalBindFreePort      bool
 	LogicalClusterAdminKubeconfig string
+	BootstrapDirectory            string
+	WebhookFrontProxyKubeconfig   string
+	APIServiceClientCAFile        string
+	APIServiceClientCAKeyFile     string
 
-	BatteriesIncluded []string
+	BatteriesIncluded        []string
+	AdditionalHierarchyRoots []string
 }
 
 type completedOptions struct {
-	GenericControlPlane options.CompletedServerRunOptions
-	EmbeddedEtcd        etcdoptions.CompletedOptions
-	Controllers         Controllers
-	Authorization       Authorization
-	AdminAuthentication AdminAuthentication
-	Virtual             Virtual
-	HomeWorkspaces      HomeWorkspaces
-	Cache               cacheCompleted
+	GenericControlPlane   options.CompletedServerRunOptions
+	EmbeddedEtcd          etcdoptions.CompletedOptions
+	Controllers           Controllers
+	Authorization         Authorization
+	AdminAuthentication   AdminAuthentication
+	SPIFFE                SPIFFE
+	Egress                Egress
+	Virtual               Virtual
+	HomeWorkspaces        HomeWorkspaces
+	Cache                 cacheCompleted
+	LogicalClusterMetrics LogicalClusterMetrics
+	SlowRequests          SlowRequests
+	LongRunningRequests   LongRunningRequests
 
 	Extra ExtraOptions
 }
@@ -92,13 +109,18 @@ func NewOptions(rootDir string) *Options {
 		GenericControlPlane: ServerRunOptions{
 			*options.NewServerRunOptions(),
 		},
-		EmbeddedEtcd:        *etcdoptions.NewOptions(rootDir),
-		Controllers:         *NewControllers(),
-		Authorization:       *NewAuthorization(),
-		AdminAuthentication: *NewAdminAuthentication(rootDir),
-		Virtual:             *NewVirtual(),
-		HomeWorkspaces:      *NewHomeWorkspaces(),
-		Cache:               *NewCache(rootDir),
+		EmbeddedEtcd:          *etcdoptions.NewOptions(rootDir),
+		Controllers:           *NewControllers(),
+		Authorization:         *NewAuthorization(),
+		AdminAuthentication:   *NewAdminAuthentication(rootDir),
+		SPIFFE:                *NewSPIFFE(),
+		Egress:                *NewEgress(),
+		Virtual:               *NewVirtual(),
+		HomeWorkspaces:        *NewHomeWorkspaces(),
+		Cache:                 *NewCache(rootDir),
+		LogicalClusterMetrics: *NewLogicalClusterMetrics(),
+		SlowRequests:          *NewSlowRequests(),
+		LongRunningRequests:   *NewLongRunningRequests(),
 
 		Extra: ExtraOptions{
 			RootDirectory:            rootDir,
@@ -162,9 +184,14 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	o.Controllers.AddFlags(fss.FlagSet("KCP Controllers"))
 	o.Authorization.AddFlags(fss.FlagSet("KCP Authorization"))
 	o.AdminAuthentication.AddFlags(fss.FlagSet("KCP Authentication"))
+	o.SPIFFE.AddFlags(fss.FlagSet("KCP Authentication"))
+	o.Egress.AddFlags(fss.FlagSet("KCP Egress"))
 	o.Virtual.AddFlags(fss.FlagSet("KCP Virtual Workspaces"))
 	o.HomeWorkspaces.AddFlags(fss.FlagSet("KCP Home Workspaces"))
 	o.Cache.AddFlags(fss.FlagSet("KCP Cache Server"))
+	o.LogicalClusterMetrics.AddFlags(fss.FlagSet("metrics"))
+	o.SlowRequests.AddFlags(fss.FlagSet("misc"))
+	o.LongRunningRequests.AddFlags(fss.FlagSet("misc"))
 
 	fs := fss.FlagSet("KCP")
 	fs.StringVar(&o.Extra.ProfilerAddress, "profiler-address", o.Extra.ProfilerAddress, "[Address]:port to bind the profiler to")
@@ -176,6 +203,18 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.Extra.ShardVirtualWorkspaceURL, "shard-virtual-workspace-url", o.Extra.ShardVirtualWorkspaceURL, "An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.")
 	fs.StringVar(&o.Extra.RootDirectory, "root-directory", o.Extra.RootDirectory, "Root directory.")
 	fs.StringVar(&o.Extra.LogicalClusterAdminKubeconfig, "logical-cluster-admin-kubeconfig", o.Extra.LogicalClusterAdminKubeconfig, "Kubeconfig holding admin(!) credentials to other shards. Defaults to the loopback client")
+	fs.StringVar(&o.Extra.BootstrapDirectory, "bootstrap-dir", o.Extra.BootstrapDirectory, "Directory of declarative manifests applied idempotently at startup, e.g. the workspace hierarchy, WorkspaceTypes, APIExports and RBAC. "+
+		"Top-level files are applied to the root workspace, and the files of a sub-directory to the workspace of the path it is named after, e.g. root:org. "+
+		"Workspaces are processed parents first, and files in lexical order. Progress is reported by the bootstrap-manifests readyz check and on /debug/bootstrap.")
+
+	fs.StringVar(&o.Extra.WebhookFrontProxyKubeconfig, "webhook-front-proxy-kubeconfig", o.Extra.WebhookFrontProxyKubeconfig, "Kubeconfig of the front-proxy, used to resolve the webhook Services delegated to workspaces of other shards. If empty, only the workspaces of this shard are resolved.")
+
+	fs.StringVar(&o.Extra.APIServiceClientCAFile, "apiservice-client-ca-file", o.Extra.APIServiceClientCAFile, "CA certificate issuing the client certificates of the APIServices, which the requests proxied to their extension API servers are authenticated with. "+
+		"Each APIService gets its own client certificate, with the system:kcp:apiservice:<logical cluster>:<name> common name. If empty, the APIServices are not served.")
+	fs.StringVar(&o.Extra.APIServiceClientCAKeyFile, "apiservice-client-ca-key-file", o.Extra.APIServiceClientCAKeyFile, "Private key of the --apiservice-client-ca-file CA.")
+
+	fs.StringSliceVar(&o.Extra.AdditionalHierarchyRoots, "additional-hierarchy-roots", o.Extra.AdditionalHierarchyRoots, "Names of additional top-level hierarchy roots, next to the root workspace, created on the root shard. "+
+		"They host isolated workspace hierarchies: none of the RBAC of the root workspace applies to them, and their WorkspaceTypes are only resolved within their hierarchy.")
 
 	fs.BoolVar(&o.Extra.ExperimentalBindFreePort, "experimental-bind-free-port", o.Extra.ExperimentalBindFreePort, "Bind to a free port. --secure-port must be 0. Use the admin.kubeconfig to extract the chosen port.")
 	fs.MarkHidden("experimental-bind-free-port") //nolint:errcheck
@@ -208,9 +247,20 @@ func (o *CompletedOptions) Validate() []error {
 	errs = append(errs, o.EmbeddedEtcd.Validate()...)
 	errs = append(errs, o.Authorization.Validate()...)
 	errs = append(errs, o.AdminAuthentication.Validate()...)
+	errs = append(errs, o.SPIFFE.Validate()...)
+	errs = append(errs, o.Egress.Validate()...)
 	errs = append(errs, o.Virtual.Validate()...)
+	if total, unlimited := o.Virtual.VirtualWorkspaces.Fairness.TotalMaxRequestsInFlight(); !unlimited {
+		generic := o.GenericControlPlane.GenericServerRunOptions
+		if shard := generic.MaxRequestsInFlight + generic.MaxMutatingRequestsInFlight; generic.MaxRequestsInFlight > 0 && generic.MaxMutatingRequestsInFlight > 0 && total >= shard {
+			errs = append(errs, fmt.Errorf("the sum of --virtual-workspaces-max-requests-in-flight (%d) must be lower than --max-requests-inflight plus --max-mutating-requests-inflight (%d), to keep seats for direct workspace requests", total, shard))
+		}
+	}
 	errs = append(errs, o.HomeWorkspaces.Validate()...)
 	errs = append(errs, o.Cache.Validate()...)
+	errs = append(errs, o.LogicalClusterMetrics.Validate()...)
+	errs = append(errs, o.SlowRequests.Validate()...)
+	errs = append(errs, o.LongRunningRequests.Validate()...)
 
 	differential := false
 	for i, b := range o.Extra.BatteriesIncluded {
@@ -234,6 +284,44 @@ func (o *CompletedOptions) Validate() []error {
 		errs = append(errs, fmt.Errorf("--shard-external-url is required if --logical-cluster-admin-kubeconfig is set"))
 	}
 
+	if o.Extra.BootstrapDirectory != "" {
+		if info, err := os.Stat(o.Extra.BootstrapDirectory); err != nil {
+			errs = append(errs, fmt.Errorf("--bootstrap-dir: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("--bootstrap-dir %q is not a directory", o.Extra.BootstrapDirectory))
+		}
+	}
+
+	if (o.Extra.APIServiceClientCAFile == "") != (o.Extra.APIServiceClientCAKeyFile == "") {
+		errs = append(errs, fmt.Errorf("--apiservice-client-ca-file and --apiservice-client-ca-key-file must be set together"))
+	}
+	if o.Extra.APIServiceClientCAFile != "" && o.Extra.APIServiceClientCAFile == o.GenericControlPlane.Authentication.RequestHeader.ClientCAFile {
+		errs = append(errs, fmt.Errorf("--apiservice-client-ca-file must not be the --requestheader-client-ca-file CA"))
+	}
+
+	errs = append(errs, validateAdditionalHierarchyRoots(o.Extra.AdditionalHierarchyRoots)...)
+
+	return errs
+}
+
+// reservedHierarchyRoots are the top-level path segments that cannot name an additional hierarchy root.
+var reservedHierarchyRoots = sets.NewString(core.RootCluster.String(), "user", "system")
+
+// validateAdditionalHierarchyRoots validates the names of the additional hierarchy roots, which
+// become the first segment of the paths of their hierarchy.
+func validateAdditionalHierarchyRoots(names []string) []error {
+	var errs []error
+	seen := sets.NewString()
+	for _, name := range names {
+		if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("--additional-hierarchy-roots: invalid name %q: %s", name, strings.Join(msgs, ", ")))
+		} else if reservedHierarchyRoots.Has(name) {
+			errs = append(errs, fmt.Errorf("--additional-hierarchy-roots: name %q is reserved", name))
+		} else if seen.Has(name) {
+			errs = append(errs, fmt.Errorf("--additional-hierarchy-roots: duplicate name %q", name))
+		}
+		seen.Insert(name)
+	}
 	return errs
 }
 
@@ -286,6 +374,12 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 			return nil, err
 		}
 	}
+	if len(o.Extra.BootstrapDirectory) > 0 && !filepath.IsAbs(o.Extra.BootstrapDirectory) {
+		o.Extra.BootstrapDirectory, err = filepath.Abs(o.Extra.BootstrapDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if o.Extra.ExperimentalBindFreePort {
 		listener, _, err := genericapiserveroptions.CreateListener("tcp", fmt.Sprintf("%s:0", o.GenericControlPlane.SecureServing.BindAddress), net.ListenConfig{})
@@ -359,15 +453,20 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 	return &CompletedOptions{
 		completedOptions: &completedOptions{
 			// TODO: GenericControlPlane here should be completed. But the k/k repo does not expose the CompleteOptions type, but should.
-			GenericControlPlane: completedGenericServerRunOptions,
-			EmbeddedEtcd:        completedEmbeddedEtcd,
-			Controllers:         o.Controllers,
-			Authorization:       o.Authorization,
-			AdminAuthentication: o.AdminAuthentication,
-			Virtual:             o.Virtual,
-			HomeWorkspaces:      o.HomeWorkspaces,
-			Cache:               cacheCompletedOptions,
-			Extra:               o.Extra,
+			GenericControlPlane:   completedGenericServerRunOptions,
+			EmbeddedEtcd:          completedEmbeddedEtcd,
+			Controllers:           o.Controllers,
+			Authorization:         o.Authorization,
+			AdminAuthentication:   o.AdminAuthentication,
+			SPIFFE:                o.SPIFFE,
+			Egress:                o.Egress,
+			Virtual:               o.Virtual,
+			HomeWorkspaces:        o.HomeWorkspaces,
+			Cache:                 cacheCompletedOptions,
+			LogicalClusterMetrics: o.LogicalClusterMetrics,
+			SlowRequests:          o.SlowRequests,
+			LongRunningRequests:   o.LongRunningRequests,
+			Extra:                 o.Extra,
 		},
 	}, nil
 }
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// SlowRequests are the options of the logging of the requests slower than a threshold.
+type SlowRequests struct {
+	ReadThreshold  time.Duration
+	WriteThreshold time.Duration
+}
+
+func NewSlowRequests() *SlowRequests {
+	return &SlowRequests{
+		ReadThreshold:  0,
+		WriteThreshold: 0,
+	}
+}
+
+func (s *SlowRequests) AddFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&s.ReadThreshold, "slow-request-read-threshold", s.ReadThreshold, "Latency above which get and list requests are logged, with their user, logical cluster and resource, and counted in the slow_requests_total metric. Set to 0 to disable.")
+	fs.DurationVar(&s.WriteThreshold, "slow-request-write-threshold", s.WriteThreshold, "Latency above which the requests other than get, list and long-running ones are logged, with their user, logical cluster and resource, and counted in the slow_requests_total metric. Set to 0 to disable.")
+}
+
+func (s *SlowRequests) Validate() []error {
+	var errs []error
+
+	if s.ReadThreshold < 0 {
+		errs = append(errs, fmt.Errorf("--slow-request-read-threshold cannot be negative"))
+	}
+	if s.WriteThreshold < 0 {
+		errs = append(errs, fmt.Errorf("--slow-request-write-threshold cannot be negative"))
+	}
+
+	return errs
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apiserver/pkg/authentication/group"
+	authenticatorunion "k8s.io/apiserver/pkg/authentication/request/union"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/spiffe"
+)
+
+// SPIFFE configures the authentication of clients with SPIFFE X.509 SVIDs, and
+// the SVID of the shard for its connections to the cache server.
+type SPIFFE struct {
+	TrustDomain     string
+	TrustBundleFile string
+	SVIDCertFile    string
+	SVIDKeyFile     string
+}
+
+func NewSPIFFE() *SPIFFE {
+	return &SPIFFE{}
+}
+
+func (s *SPIFFE) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.StringVar(&s.TrustDomain, "spiffe-trust-domain", s.TrustDomain,
+		"SPIFFE trust domain whose X.509 SVIDs are authenticated. Users are named after the SPIFFE ID, and are members of the system:spiffe:<trust domain> group. SVID authentication is disabled if empty.")
+	fs.StringVar(&s.TrustBundleFile, "spiffe-trust-bundle-file", s.TrustBundleFile,
+		"File holding the PEM trust bundle of the SPIFFE trust domain, reloaded when rotated. Also verifies the cache server when --spiffe-svid-cert-file is set.")
+	fs.StringVar(&s.SVIDCertFile, "spiffe-svid-cert-file", s.SVIDCertFile,
+		"File holding the PEM X.509 SVID of the shard, reloaded when rotated. If set, it authenticates the shard to the cache server instead of the TLS options of --cache-server-kubeconfig-file.")
+	fs.StringVar(&s.SVIDKeyFile, "spiffe-svid-key-file", s.SVIDKeyFile,
+		"File holding the PEM private key of --spiffe-svid-cert-file.")
+}
+
+func (s *SPIFFE) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	var errs []error
+	if s.TrustDomain != "" && s.TrustBundleFile == "" {
+		errs = append(errs, fmt.Errorf("--spiffe-trust-bundle-file is required if --spiffe-trust-domain is set"))
+	}
+	if (s.SVIDCertFile == "") != (s.SVIDKeyFile == "") {
+		errs = append(errs, fmt.Errorf("--spiffe-svid-cert-file and --spiffe-svid-key-file must be set together"))
+	}
+	if s.SVIDCertFile != "" && s.TrustBundleFile == "" {
+		errs = append(errs, fmt.Errorf("--spiffe-trust-bundle-file is required if --spiffe-svid-cert-file is set"))
+	}
+	return errs
+}
+
+// ApplyTo adds the SVID authenticator to the config if enabled. The returned
+// source must be run for the SVID and the trust bundle to be reloaded, and is
+// nil if neither SVID authentication nor the shard SVID are enabled.
+func (s *SPIFFE) ApplyTo(config *genericapiserver.Config) (*spiffe.X509Source, error) {
+	if s.TrustDomain == "" && s.SVIDCertFile == "" {
+		return nil, nil
+	}
+
+	source, err := spiffe.NewX509Source(s.SVIDCertFile, s.SVIDKeyFile, s.TrustBundleFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.TrustDomain != "" {
+		svidAuthenticator := group.NewAuthenticatedGroupAdder(spiffe.NewAuthenticator(source, s.TrustDomain))
+		config.Authentication.Authenticator = authenticatorunion.New(config.Authentication.Authenticator, svidAuthenticator)
+	}
+
+	return source, nil
+}
@@ -26,14 +26,17 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 
+	confighierarchyroot "github.com/kcp-dev/kcp/config/hierarchyroot"
 	configroot "github.com/kcp-dev/kcp/config/root"
 	configrootphase0 "github.com/kcp-dev/kcp/config/root-phase0"
 	configrootcompute "github.com/kcp-dev/kcp/config/rootcompute"
@@ -46,6 +49,12 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
+	"github.com/kcp-dev/kcp/pkg/reconciler/controllerstatus"
+	"github.com/kcp-dev/kcp/pkg/server/bootstrap"
+	"github.com/kcp-dev/kcp/pkg/server/catalog"
+	kcpfilters "github.com/kcp-dev/kcp/pkg/server/filters"
+	"github.com/kcp-dev/kcp/pkg/server/inventory"
+	"github.com/kcp-dev/kcp/pkg/spiffe"
 )
 
 const resyncPeriod = 10 * time.Hour
@@ -125,6 +134,15 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	if s.spiffeSource != nil {
+		if err := s.AddPostStartHook("kcp-spiffe-reloader", func(hookContext genericapiserver.PostStartHookContext) error {
+			go s.spiffeSource.Run(goContext(hookContext), spiffe.DefaultReloadInterval)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
 	hookName := "kcp-start-informers"
 	if err := s.AddPostStartHook(hookName, func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := logger.WithValues("postStartHook", hookName)
@@ -163,6 +181,11 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 		logger.Info("finished bootstrapping system CRDs")
 
+		// the APIServices are served once their system CRD is established
+		if s.APIServiceInformer != nil {
+			go s.APIServiceInformer.Informer().Run(hookContext.StopCh)
+		}
+
 		logger.Info("bootstrapping the shard workspace")
 		if err := wait.PollInfiniteWithContext(goContext(hookContext), time.Second, func(ctx context.Context) (bool, error) {
 			if err := configshard.Bootstrap(ctx,
@@ -340,6 +363,21 @@ func (s *Server) Run(ctx context.Context) error {
 			}
 			logger.Info("finished bootstrapping root workspace phase 1")
 			close(s.rootPhase1FinishedCh)
+
+			for _, name := range s.Options.Extra.AdditionalHierarchyRoots {
+				hierarchyRoot := logicalcluster.Name(name)
+				logger.Info("bootstrapping hierarchy root", "hierarchyRoot", hierarchyRoot)
+				if err := confighierarchyroot.Bootstrap(goContext(hookContext),
+					s.KcpClusterClient.Cluster(hierarchyRoot.Path()),
+					s.BootstrapApiExtensionsClusterClient.Cluster(hierarchyRoot.Path()).Discovery(),
+					s.BootstrapDynamicClusterClient.Cluster(hierarchyRoot.Path()),
+					sets.NewString(s.Options.Extra.BatteriesIncluded...),
+				); err != nil {
+					logger.Error(err, "failed to bootstrap hierarchy root", "hierarchyRoot", hierarchyRoot)
+					return nil // don't klog.Fatal. This only happens when context is cancelled.
+				}
+				logger.Info("finished bootstrapping hierarchy root", "hierarchyRoot", hierarchyRoot)
+			}
 		}
 
 		return nil
@@ -347,6 +385,19 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.AddPostStartHook("kcp-informer-registry", func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := logger.WithValues("postStartHook", "kcp-informer-registry")
+		go func() {
+			if err := s.waitForSync(hookContext.StopCh); err != nil {
+				return
+			}
+			s.reportDuplicateInformers(logger, hookContext.StopCh)
+		}()
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	if err := s.AddPostStartHook("kcp-start-optional-informers", func(hookContext genericapiserver.PostStartHookContext) error {
 		// TODO(p0lyn0mial): failing the optional hook should not render the main server unhealthy
 		logger := logger.WithValues("postStartHook", "kcp-start-optional-informers")
@@ -451,6 +502,9 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installTenancyLogicalClusterController(ctx, controllerConfig); err != nil {
 			return err
 		}
+		if err := s.installWorkspaceTypeMigrationController(ctx, controllerConfig); err != nil {
+			return err
+		}
 		if err := s.installLogicalClusterDeletionController(ctx, controllerConfig, s.LogicalClusterAdminConfig, s.CompletedConfig.ShardExternalURL); err != nil {
 			return err
 		}
@@ -475,6 +529,12 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installExtraAnnotationSyncController(ctx, controllerConfig, delegationChainHead); err != nil {
 			return err
 		}
+		if err := s.installTrustBundleController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+		if err := s.installReferenceGrantController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
 	}
 
 	if s.Options.Controllers.EnableAll || enabled.Has("apiexport") {
@@ -530,6 +590,43 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.Options.Controllers.GitOps.Enabled() && (s.Options.Controllers.EnableAll || enabled.Has("gitops")) {
+		if err := s.installGitOpsController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.ClusterAPI.Enabled() && (s.Options.Controllers.EnableAll || enabled.Has("clusterapi")) {
+		if err := s.installClusterAPIController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.SchemaLint.Enabled() && (s.Options.Controllers.EnableAll || enabled.Has("schemalint")) {
+		if err := s.installSchemaLintController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.APIBindingUsage.Enabled() && (s.Options.Controllers.EnableAll || enabled.Has("apibindingusage")) {
+		if err := s.installAPIBindingUsageController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.Notifications.Enabled() && (s.Options.Controllers.EnableAll || enabled.Has("notifications")) {
+		if err := s.installNotificationsController(ctx, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	// the shards are only all known on the root shard
+	if s.Options.Controllers.DNSEndpoints.Enabled() && s.Options.Extra.ShardName == corev1alpha1.RootShard && (s.Options.Controllers.EnableAll || enabled.Has("dnsendpoints")) {
+		if err := s.installDNSEndpointsController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
 	if s.Options.Virtual.Enabled {
 		virtualWorkspacesConfig := rest.CopyConfig(s.GenericConfig.LoopbackClientConfig)
 		virtualWorkspacesConfig = rest.AddUserAgent(virtualWorkspacesConfig, "virtual-workspaces")
@@ -544,6 +641,122 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	controllerMonitor := controllerstatus.NewMonitor(legacyregistry.DefaultGatherer, controllerstatus.DefaultStallTimeout)
+	delegationChainHead.Handler.NonGoRestfulMux.Handle("/debug/controllers", controllerMonitor)
+	delegationChainHead.Handler.NonGoRestfulMux.Handle(kcpfilters.WorkspaceMetricsPath, kcpfilters.NewWorkspaceMetricsHandler(legacyregistry.DefaultGatherer))
+	delegationChainHead.Handler.NonGoRestfulMux.Handle(catalog.Path, catalog.NewHandler(
+		s.GenericConfig.Authorization.Authorizer,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports().Lister(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceTypes().Lister(),
+		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Locations().Lister(),
+	))
+	delegationChainHead.Handler.NonGoRestfulMux.Handle(inventory.Path, inventory.NewHandler(
+		s.Options.Extra.ShardName,
+		func() map[schema.GroupVersionResource]cache.Indexer {
+			listers, _ := s.DiscoveringDynamicSharedInformerFactory.Listers()
+			indexers := make(map[schema.GroupVersionResource]cache.Indexer, len(listers))
+			for gvr := range listers {
+				if informer, known, synced := s.DiscoveringDynamicSharedInformerFactory.Informer(gvr); known && synced {
+					indexers[gvr] = informer.GetIndexer()
+				}
+			}
+			return indexers
+		},
+		s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters().Lister(),
+		s.logicalClusterAccesses,
+	))
+	// the accesses of the deleted logical clusters are forgotten, and those of the logical clusters
+	// requested without existing are pruned periodically.
+	s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = final.Obj
+			}
+			if logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster); ok {
+				s.logicalClusterAccesses.Forget(logicalcluster.From(logicalCluster))
+			}
+		},
+	})
+	if err := s.AddPostStartHook("kcp-prune-logical-cluster-accesses", func(hookContext genericapiserver.PostStartHookContext) error {
+		go s.logicalClusterAccesses.Run(goContext(hookContext), s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters().Lister())
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := delegationChainHead.AddReadyzChecks(controllerMonitor); err != nil {
+		return err
+	}
+	if err := s.AddPostStartHook("kcp-controller-status-monitor", func(hookContext genericapiserver.PostStartHookContext) error {
+		go controllerMonitor.Start(goContext(hookContext), 10*time.Second)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// the watches of the logical clusters leaving the shard are terminated, for their clients to
+	// re-establish them against the current location of the logical clusters.
+	s.KcpSharedInformerFactory.Core().V1alpha1().LogicalClusters().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = final.Obj
+			}
+			logicalCluster, ok := obj.(*corev1alpha1.LogicalCluster)
+			if !ok {
+				return
+			}
+			if n := s.longRunningRequests.Terminate(logicalcluster.From(logicalCluster)); n > 0 {
+				logger.V(2).Info("terminated the long-running requests of the logical cluster", "cluster", logicalcluster.From(logicalCluster), "count", n)
+			}
+		},
+	})
+	if s.Options.LongRunningRequests.TerminateOnShutdown {
+		if err := delegationChainHead.AddPreShutdownHook("kcp-terminate-long-running-requests", func() error {
+			n := s.longRunningRequests.Shutdown(s.Options.LongRunningRequests.ShutdownRetryAfter)
+			logger.Info("terminated the long-running requests", "count", n)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Extra.BootstrapDirectory != "" {
+		manifests, err := bootstrap.NewManifests(s.Options.Extra.BootstrapDirectory)
+		if err != nil {
+			return err
+		}
+		delegationChainHead.Handler.NonGoRestfulMux.Handle("/debug/bootstrap", manifests)
+		if err := delegationChainHead.AddReadyzChecks(manifests); err != nil {
+			return err
+		}
+		if err := s.AddPostStartHook("kcp-bootstrap-manifests", func(hookContext genericapiserver.PostStartHookContext) error {
+			logger := logger.WithValues("postStartHook", "kcp-bootstrap-manifests")
+			go func() {
+				if err := s.waitForSync(hookContext.StopCh); err != nil {
+					return
+				}
+				if s.Options.Extra.ShardName == corev1alpha1.RootShard {
+					// the manifests may rely on the WorkspaceTypes and APIExports of the root workspace
+					select {
+					case <-hookContext.StopCh:
+						return
+					case <-s.rootPhase1FinishedCh:
+					}
+				}
+				ctx := klog.NewContext(goContext(hookContext), logger)
+				if err := manifests.Apply(ctx,
+					s.BootstrapApiExtensionsClusterClient,
+					s.BootstrapDynamicClusterClient,
+					sets.NewString(s.Options.Extra.BatteriesIncluded...),
+				); err != nil {
+					logger.Error(err, "failed to apply bootstrap manifests")
+				}
+			}()
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
 	if err := s.Options.AdminAuthentication.WriteKubeConfig(s.GenericConfig, s.kcpAdminToken, s.shardAdminToken, s.userToken, s.shardAdminTokenHash); err != nil {
 		return err
 	}
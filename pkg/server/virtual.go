@@ -74,6 +74,9 @@ func (s *Server) installVirtualWorkspaces(
 	recommendedConfig.ReadyzChecks = []healthz.HealthChecker{}
 	recommendedConfig.LivezChecks = []healthz.HealthChecker{}
 	recommendedConfig.Authentication = auth
+	// share the tracer provider of the server, so that the virtual workspace requests are traced
+	// as children of the spans propagated by the front-proxy.
+	recommendedConfig.TracerProvider = s.GenericConfig.TracerProvider
 
 	authorizationOptions := virtualoptions.NewAuthorization()
 	authorizationOptions.AlwaysAllowGroups = s.Options.Authorization.AlwaysAllowGroups
@@ -82,7 +85,7 @@ func (s *Server) installVirtualWorkspaces(
 		return err
 	}
 
-	rootAPIServerConfig, err := virtualrootapiserver.NewRootAPIConfig(recommendedConfig, nil, virtualWorkspaces)
+	rootAPIServerConfig, err := virtualrootapiserver.NewRootAPIConfig(recommendedConfig, virtualcommandoptions.DefaultRootPathPrefix, nil, virtualWorkspaces)
 	if err != nil {
 		return err
 	}
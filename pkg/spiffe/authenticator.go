@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	x509request "k8s.io/apiserver/pkg/authentication/request/x509"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	// Scheme is the URI scheme of SPIFFE IDs.
+	Scheme = "spiffe"
+
+	// TrustDomainGroupPrefix prefixes the trust domain of an SVID to form the
+	// group its user is a member of.
+	TrustDomainGroupPrefix = "system:spiffe:"
+)
+
+// IDFromCertificate returns the SPIFFE ID of an X.509 SVID, i.e. its single
+// URI SAN with the spiffe scheme.
+func IDFromCertificate(cert *x509.Certificate) (*url.URL, error) {
+	if len(cert.URIs) != 1 {
+		return nil, fmt.Errorf("expected exactly one URI SAN, got %d", len(cert.URIs))
+	}
+	id := cert.URIs[0]
+	if id.Scheme != Scheme || id.Host == "" || id.User != nil || id.Port() != "" || id.RawQuery != "" || id.Fragment != "" {
+		return nil, fmt.Errorf("%q is not a SPIFFE ID", id.String())
+	}
+	return id, nil
+}
+
+// NewAuthenticator returns an authenticator for the client X.509 SVIDs of
+// trustDomain, verified against the trust bundle of source. The user is named
+// after the SPIFFE ID, e.g. spiffe://example.org/ns/kcp-syncer/sa/syncer, and is
+// a member of the system:spiffe:<trust domain> group. Client certificates
+// without a SPIFFE ID are left to the other authenticators.
+func NewAuthenticator(source *X509Source, trustDomain string) authenticator.Request {
+	return x509request.NewDynamic(source.VerifyOptions, x509request.UserConversionFunc(func(chain []*x509.Certificate) (*authenticator.Response, bool, error) {
+		if len(chain) == 0 {
+			return nil, false, nil
+		}
+		id, err := IDFromCertificate(chain[0])
+		if err != nil {
+			return nil, false, nil
+		}
+		if id.Host != trustDomain {
+			return nil, false, errors.New("SVID of a foreign trust domain")
+		}
+		return &authenticator.Response{
+			User: &user.DefaultInfo{
+				Name:   id.String(),
+				Groups: []string{TrustDomainGroupPrefix + trustDomain},
+			},
+		}, true, nil
+	}))
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spiffe supports SPIFFE X.509 SVIDs to authenticate the connections to
+// kcp. The SVIDs and the trust bundle are read from files, as written by the
+// SPIRE agent or the spiffe-helper, and reloaded when they are rotated.
+package spiffe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+// DefaultReloadInterval is the interval the SVID and trust bundle files are checked for rotation.
+const DefaultReloadInterval = 10 * time.Second
+
+// X509Source holds an X.509 SVID and a trust bundle read from files, and
+// reloads them when the files change.
+type X509Source struct {
+	svidCertFile string
+	svidKeyFile  string
+	bundleFile   string
+
+	lock     sync.RWMutex
+	contents [][]byte
+	svid     *tls.Certificate
+	bundle   *x509.CertPool
+}
+
+// NewX509Source loads the SVID from svidCertFile and svidKeyFile, and the
+// trust bundle from bundleFile. The SVID files are optional, for sources only
+// verifying peers.
+func NewX509Source(svidCertFile, svidKeyFile, bundleFile string) (*X509Source, error) {
+	if (svidCertFile == "") != (svidKeyFile == "") {
+		return nil, errors.New("both the SVID certificate and key files must be set")
+	}
+	if bundleFile == "" {
+		return nil, errors.New("the trust bundle file must be set")
+	}
+
+	s := &X509Source{
+		svidCertFile: svidCertFile,
+		svidKeyFile:  svidKeyFile,
+		bundleFile:   bundleFile,
+	}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload reads the files, and swaps the SVID and the trust bundle if any
+// changed. It returns whether they did.
+func (s *X509Source) reload() (bool, error) {
+	var contents [][]byte
+	for _, file := range []string{s.bundleFile, s.svidCertFile, s.svidKeyFile} {
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		contents = append(contents, content)
+	}
+
+	s.lock.RLock()
+	unchanged := len(contents) == len(s.contents)
+	for i := 0; unchanged && i < len(contents); i++ {
+		unchanged = bytes.Equal(contents[i], s.contents[i])
+	}
+	s.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	roots, err := certutil.ParseCertsPEM(contents[0])
+	if err != nil {
+		return false, fmt.Errorf("failed to parse the trust bundle %s: %w", s.bundleFile, err)
+	}
+	bundle := x509.NewCertPool()
+	for _, root := range roots {
+		bundle.AddCert(root)
+	}
+
+	var svid *tls.Certificate
+	if s.svidCertFile != "" {
+		cert, err := tls.X509KeyPair(contents[1], contents[2])
+		if err != nil {
+			return false, fmt.Errorf("failed to load the SVID from %s and %s: %w", s.svidCertFile, s.svidKeyFile, err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return false, err
+		}
+		if _, err := IDFromCertificate(leaf); err != nil {
+			return false, fmt.Errorf("%s is not an X.509 SVID: %w", s.svidCertFile, err)
+		}
+		cert.Leaf = leaf
+		svid = &cert
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.contents = contents
+	s.svid = svid
+	s.bundle = bundle
+	return true, nil
+}
+
+// Run reloads the files every interval until the context is done. The
+// previous SVID and trust bundle are kept when the files cannot be loaded, e.g.
+// while they are being rotated.
+func (s *X509Source) Run(ctx context.Context, interval time.Duration) {
+	logger := klog.FromContext(ctx).WithValues("bundle", s.bundleFile, "svid", s.svidCertFile)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		changed, err := s.reload()
+		if err != nil {
+			logger.Error(err, "failed to reload the SPIFFE SVID and trust bundle")
+			return
+		}
+		if changed {
+			logger.V(2).Info("reloaded the SPIFFE SVID and trust bundle")
+		}
+	}, interval)
+}
+
+// Bundle returns the current trust bundle.
+func (s *X509Source) Bundle() *x509.CertPool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.bundle
+}
+
+// SVID returns the current SVID, or nil if the source has none.
+func (s *X509Source) SVID() *tls.Certificate {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.svid
+}
+
+// VerifyOptions returns the options to verify client SVIDs against the current
+// trust bundle.
+func (s *X509Source) VerifyOptions() (x509.VerifyOptions, bool) {
+	return x509.VerifyOptions{
+		Roots:     s.Bundle(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, true
+}
+
+// TLSClientConfig returns a TLS configuration presenting the current SVID, and
+// verifying the server certificate and hostname against the current trust
+// bundle.
+func (s *X509Source) TLSClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if svid := s.SVID(); svid != nil {
+				return svid, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// the server certificate is verified in VerifyConnection, against the
+		// trust bundle at the time of the handshake.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no server certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         s.Bundle(),
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
+
+// WrapConfig returns a copy of config whose connections are authenticated
+// with the SVID of the source, and whose server is verified against its trust
+// bundle. The TLS options of config are replaced, other credentials like
+// tokens are kept.
+func (s *X509Source) WrapConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	tlsConfig := s.TLSClientConfig(config.TLSClientConfig.ServerName)
+	config.TLSClientConfig = rest.TLSClientConfig{}
+	config.Transport = utilnet.SetTransportDefaults(&http.Transport{
+		Proxy:               config.Proxy,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConnsPerHost: 25,
+	})
+	config.Proxy = nil
+	return config
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, id string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if id != "" {
+		u, err := url.Parse(id)
+		require.NoError(t, err)
+		template.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) bundle() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0600))
+	}
+}
+
+func TestIDFromCertificate(t *testing.T) {
+	tests := map[string]struct {
+		uris    []string
+		wantErr bool
+	}{
+		"spiffe id":       {uris: []string{"spiffe://example.org/ns/kcp/sa/syncer"}},
+		"no uri":          {wantErr: true},
+		"several uris":    {uris: []string{"spiffe://example.org/a", "spiffe://example.org/b"}, wantErr: true},
+		"other scheme":    {uris: []string{"https://example.org/a"}, wantErr: true},
+		"with port":       {uris: []string{"spiffe://example.org:8443/a"}, wantErr: true},
+		"with query":      {uris: []string{"spiffe://example.org/a?b=c"}, wantErr: true},
+		"no trust domain": {uris: []string{"spiffe:///a"}, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cert := &x509.Certificate{}
+			for _, s := range tc.uris {
+				u, err := url.Parse(s)
+				require.NoError(t, err)
+				cert.URIs = append(cert.URIs, u)
+			}
+			id, err := IDFromCertificate(cert)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.uris[0], id.String())
+		})
+	}
+}
+
+func TestX509SourceReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, "spiffe://example.org/syncer")
+	writeFiles(t, dir, map[string][]byte{"svid.pem": cert, "svid_key.pem": key, "bundle.pem": ca.bundle()})
+
+	source, err := NewX509Source(filepath.Join(dir, "svid.pem"), filepath.Join(dir, "svid_key.pem"), filepath.Join(dir, "bundle.pem"))
+	require.NoError(t, err)
+	first := source.SVID()
+	require.NotNil(t, first)
+
+	changed, err := source.reload()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// rotate the trust domain CA and the SVID
+	rotated := newTestCA(t)
+	cert, key = rotated.issue(t, "spiffe://example.org/syncer")
+	writeFiles(t, dir, map[string][]byte{"svid.pem": cert, "svid_key.pem": key, "bundle.pem": rotated.bundle()})
+	changed, err = source.reload()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NotEqual(t, first.Leaf.Raw, source.SVID().Leaf.Raw)
+
+	_, err = source.SVID().Leaf.Verify(x509.VerifyOptions{Roots: source.Bundle(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err)
+
+	// a certificate without SPIFFE ID is rejected, and the previous SVID kept
+	cert, key = rotated.issue(t, "")
+	writeFiles(t, dir, map[string][]byte{"svid.pem": cert, "svid_key.pem": key})
+	_, err = source.reload()
+	require.Error(t, err)
+	require.NotNil(t, source.SVID())
+}
+
+func TestAuthenticator(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	writeFiles(t, dir, map[string][]byte{"bundle.pem": ca.bundle()})
+	source, err := NewX509Source("", "", filepath.Join(dir, "bundle.pem"))
+	require.NoError(t, err)
+	auth := NewAuthenticator(source, "example.org")
+
+	request := func(t *testing.T, ca *testCA, id string) *http.Request {
+		certPEM, keyPEM := ca.issue(t, id)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		req := &http.Request{TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}}
+		return req
+	}
+
+	resp, ok, err := auth.AuthenticateRequest(request(t, ca, "spiffe://example.org/ns/kcp/sa/syncer"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "spiffe://example.org/ns/kcp/sa/syncer", resp.User.GetName())
+	require.Equal(t, []string{"system:spiffe:example.org"}, resp.User.GetGroups())
+
+	_, ok, err = auth.AuthenticateRequest(request(t, ca, "spiffe://other.org/syncer"))
+	require.Error(t, err)
+	require.False(t, ok)
+
+	_, ok, _ = auth.AuthenticateRequest(request(t, ca, ""))
+	require.False(t, ok)
+
+	_, ok, err = auth.AuthenticateRequest(request(t, newTestCA(t), "spiffe://example.org/syncer"))
+	require.Error(t, err)
+	require.False(t, ok)
+}
@@ -33,6 +33,8 @@ import (
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -437,6 +439,13 @@ func getAllGVRs(synctarget *workloadv1alpha1.SyncTarget) map[schema.GroupVersion
 		}: true,
 	}
 
+	// The ServiceAccounts and their RBAC are only synced when enabled on the SyncTarget.
+	if synctarget.Spec.WorkloadRBAC != nil {
+		gvrs[corev1.SchemeGroupVersion.WithResource("serviceaccounts")] = true
+		gvrs[rbacv1.SchemeGroupVersion.WithResource("roles")] = true
+		gvrs[rbacv1.SchemeGroupVersion.WithResource("rolebindings")] = true
+	}
+
 	// TODO(qiujian16) We currently checks the API compaibility on the server side. When we change to check the
 	// compatibility on the syncer side, this part needs to be changed.
 	for _, r := range synctarget.Status.SyncedResources {
@@ -45,6 +45,7 @@ type DeploymentMutator struct {
 	syncTargetUID         types.UID
 	syncTargetName        string
 	dnsNamespace          string
+	getWorkloadRBAC       WorkloadRBACPolicyFunc
 }
 
 func (dm *DeploymentMutator) GVR() schema.GroupVersionResource {
@@ -57,7 +58,7 @@ func (dm *DeploymentMutator) GVR() schema.GroupVersionResource {
 
 func NewDeploymentMutator(upstreamURL *url.URL, secretLister ListSecretFunc, serviceLister listerscorev1.ServiceLister,
 	syncTargetClusterName logicalcluster.Name,
-	syncTargetUID types.UID, syncTargetName, dnsNamespace string) *DeploymentMutator {
+	syncTargetUID types.UID, syncTargetName, dnsNamespace string, getWorkloadRBAC WorkloadRBACPolicyFunc) *DeploymentMutator {
 	return &DeploymentMutator{
 		upstreamURL:           upstreamURL,
 		listSecrets:           secretLister,
@@ -66,6 +67,7 @@ func NewDeploymentMutator(upstreamURL *url.URL, secretLister ListSecretFunc, ser
 		syncTargetUID:         syncTargetUID,
 		syncTargetName:        syncTargetName,
 		dnsNamespace:          dnsNamespace,
+		getWorkloadRBAC:       getWorkloadRBAC,
 	}
 }
 
@@ -126,8 +128,11 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 	// Setting AutomountServiceAccountToken to false allow us to control the ServiceAccount
 	// VolumeMount and Volume definitions.
 	templateSpec.AutomountServiceAccountToken = utilspointer.BoolPtr(false)
-	// Set to empty the serviceAccountName on podTemplate as we are not syncing the serviceAccount down to the workload cluster.
-	templateSpec.ServiceAccountName = ""
+	// Set to empty the serviceAccountName on podTemplate, unless the serviceAccount is synced down to the workload cluster
+	// along with its RBAC.
+	if desiredServiceAccountName == "default" || dm.getWorkloadRBAC() == nil {
+		templateSpec.ServiceAccountName = ""
+	}
 
 	kcpExternalHost := dm.upstreamURL.Hostname()
 	kcpExternalPort := dm.upstreamURL.Port()
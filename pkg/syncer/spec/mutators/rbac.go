@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// WorkloadRBACPolicyFunc returns the policy of the syncing of the RBAC of the workloads of the SyncTarget,
+// or nil if it is not enabled.
+type WorkloadRBACPolicyFunc func() *workloadv1alpha1.WorkloadRBACPolicy
+
+var (
+	defaultAllowedAPIGroups = []string{""}
+	defaultAllowedVerbs     = []string{"get", "list", "watch"}
+)
+
+type RoleMutator struct {
+	getPolicy WorkloadRBACPolicyFunc
+}
+
+func (rm *RoleMutator) GVR() schema.GroupVersionResource {
+	return rbacv1.SchemeGroupVersion.WithResource("roles")
+}
+
+func NewRoleMutator(getPolicy WorkloadRBACPolicyFunc) *RoleMutator {
+	return &RoleMutator{
+		getPolicy: getPolicy,
+	}
+}
+
+// Mutate applies the mutator changes to the object.
+func (rm *RoleMutator) Mutate(obj *unstructured.Unstructured) error {
+	var role rbacv1.Role
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &role); err != nil {
+		return err
+	}
+
+	allowedAPIGroups, allowedVerbs := sets.NewString(defaultAllowedAPIGroups...), sets.NewString(defaultAllowedVerbs...)
+	if policy := rm.getPolicy(); policy != nil {
+		if len(policy.AllowedAPIGroups) > 0 {
+			allowedAPIGroups = sets.NewString(policy.AllowedAPIGroups...)
+		}
+		if len(policy.AllowedVerbs) > 0 {
+			allowedVerbs = sets.NewString(policy.AllowedVerbs...)
+		}
+	}
+
+	// Drop the rules that refer to non-allowed API groups, or that grant no allowed verbs, so that
+	// the synced Role never grants more than the policy of the SyncTarget allows.
+	rules := make([]rbacv1.PolicyRule, 0, len(role.Rules))
+	for _, rule := range role.Rules {
+		if len(rule.NonResourceURLs) > 0 || !allowed(allowedAPIGroups, rule.APIGroups) {
+			continue
+		}
+		if !allowedVerbs.Has(rbacv1.VerbAll) {
+			var verbs []string
+			for _, verb := range rule.Verbs {
+				if allowedVerbs.Has(verb) {
+					verbs = append(verbs, verb)
+				}
+			}
+			if len(verbs) == 0 {
+				continue
+			}
+			rule.Verbs = verbs
+		}
+		rules = append(rules, rule)
+	}
+	role.Rules = rules
+
+	unstructuredRole, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&role)
+	if err != nil {
+		return err
+	}
+	obj.SetUnstructuredContent(unstructuredRole)
+	return nil
+}
+
+// allowed returns whether all the values are allowed.
+func allowed(allowedValues sets.String, values []string) bool {
+	if allowedValues.Has(rbacv1.APIGroupAll) {
+		return true
+	}
+	return len(values) > 0 && allowedValues.HasAll(values...)
+}
+
+type RoleBindingMutator struct {
+	syncTargetClusterName logicalcluster.Name
+	syncTargetUID         types.UID
+	syncTargetName        string
+}
+
+func (rbm *RoleBindingMutator) GVR() schema.GroupVersionResource {
+	return rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+}
+
+func NewRoleBindingMutator(syncTargetClusterName logicalcluster.Name, syncTargetUID types.UID, syncTargetName string) *RoleBindingMutator {
+	return &RoleBindingMutator{
+		syncTargetClusterName: syncTargetClusterName,
+		syncTargetUID:         syncTargetUID,
+		syncTargetName:        syncTargetName,
+	}
+}
+
+// Mutate applies the mutator changes to the object.
+func (rbm *RoleBindingMutator) Mutate(obj *unstructured.Unstructured) error {
+	var roleBinding rbacv1.RoleBinding
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &roleBinding); err != nil {
+		return err
+	}
+
+	downstreamNamespace, err := shared.PhysicalClusterNamespaceName(shared.NewNamespaceLocator(logicalcluster.From(obj), rbm.syncTargetClusterName, rbm.syncTargetUID, rbm.syncTargetName, roleBinding.Namespace))
+	if err != nil {
+		return err
+	}
+
+	// Only keep the ServiceAccounts of the namespace of the RoleBinding, as they are the only subjects
+	// synced downstream, and never bind ClusterRoles, as they are not synced. The remaining RoleBinding
+	// grants nothing, but is still synced so that its deletion is propagated.
+	subjects := []rbacv1.Subject{}
+	if roleBinding.RoleRef.Kind == "Role" {
+		for _, subject := range roleBinding.Subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind || (subject.Namespace != "" && subject.Namespace != roleBinding.Namespace) {
+				continue
+			}
+			subject.Namespace = downstreamNamespace
+			subjects = append(subjects, subject)
+		}
+	}
+	roleBinding.Subjects = subjects
+
+	unstructuredRoleBinding, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&roleBinding)
+	if err != nil {
+		return err
+	}
+	obj.SetUnstructuredContent(unstructuredRoleBinding)
+	return nil
+}
+
+type ServiceAccountMutator struct {
+}
+
+func (sam *ServiceAccountMutator) GVR() schema.GroupVersionResource {
+	return corev1.SchemeGroupVersion.WithResource("serviceaccounts")
+}
+
+func NewServiceAccountMutator() *ServiceAccountMutator {
+	return &ServiceAccountMutator{}
+}
+
+// Mutate applies the mutator changes to the object.
+func (sam *ServiceAccountMutator) Mutate(obj *unstructured.Unstructured) error {
+	// The token secrets of the ServiceAccount are the ones of kcp, and the downstream cluster
+	// manages its own, so only the image pull secrets are kept.
+	unstructured.RemoveNestedField(obj.Object, "secrets")
+	return nil
+}
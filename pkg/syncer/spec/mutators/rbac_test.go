@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestRoleMutate(t *testing.T) {
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list", "update"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+	}
+
+	for _, c := range []struct {
+		desc          string
+		policy        *workloadv1alpha1.WorkloadRBACPolicy
+		expectedRules []rbacv1.PolicyRule
+	}{{
+		desc: "Without policy, only the read-only rules of the core API group are kept",
+		expectedRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+		},
+	}, {
+		desc:   "The rules are restricted to the allowed API groups and verbs",
+		policy: &workloadv1alpha1.WorkloadRBACPolicy{AllowedAPIGroups: []string{"", "apps"}, AllowedVerbs: []string{"get", "delete"}},
+		expectedRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		},
+	}, {
+		desc:          "All the rules are kept when everything is allowed",
+		policy:        &workloadv1alpha1.WorkloadRBACPolicy{AllowedAPIGroups: []string{"*"}, AllowedVerbs: []string{"*"}},
+		expectedRules: rules,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			rm := NewRoleMutator(func() *workloadv1alpha1.WorkloadRBACPolicy { return c.policy })
+			unstrRole, err := toUnstructured(&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{Kind: "Role", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: "ns"},
+				Rules:      rules,
+			})
+			require.NoError(t, err)
+			require.NoError(t, rm.Mutate(unstrRole))
+
+			var role rbacv1.Role
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(unstrRole.Object, &role))
+			require.Equal(t, c.expectedRules, role.Rules)
+		})
+	}
+}
+
+func TestRoleBindingMutate(t *testing.T) {
+	clusterName := logicalcluster.Name("root:default:testing")
+	downstreamNamespace, err := shared.PhysicalClusterNamespaceName(shared.NewNamespaceLocator(clusterName, "root:org:ws", "syncTargetUID", "syncTargetName", "ns"))
+	require.NoError(t, err)
+
+	subjects := []rbacv1.Subject{
+		{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: "ns"},
+		{Kind: rbacv1.ServiceAccountKind, Name: "other", Namespace: "other-ns"},
+		{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "user"},
+	}
+
+	for _, c := range []struct {
+		desc             string
+		roleRef          rbacv1.RoleRef
+		expectedSubjects []rbacv1.Subject
+	}{{
+		desc:    "Only the ServiceAccounts of the namespace are kept, in the downstream namespace",
+		roleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "role"},
+		expectedSubjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: downstreamNamespace},
+		},
+	}, {
+		desc:    "A binding to a ClusterRole grants nothing",
+		roleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			rbm := NewRoleBindingMutator("root:org:ws", "syncTargetUID", "syncTargetName")
+			unstrRoleBinding, err := toUnstructured(&rbacv1.RoleBinding{
+				TypeMeta: metav1.TypeMeta{Kind: "RoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "binding",
+					Namespace:   "ns",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName.String()},
+				},
+				RoleRef:  c.roleRef,
+				Subjects: subjects,
+			})
+			require.NoError(t, err)
+			require.NoError(t, rbm.Mutate(unstrRoleBinding))
+
+			var roleBinding rbacv1.RoleBinding
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(unstrRoleBinding.Object, &roleBinding))
+			require.Equal(t, c.expectedSubjects, roleBinding.Subjects)
+			require.Equal(t, c.roleRef, roleBinding.RoleRef)
+		})
+	}
+}
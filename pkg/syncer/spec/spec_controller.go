@@ -49,6 +49,8 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
@@ -86,6 +88,7 @@ func NewSpecSyncer(syncerLogger logr.Logger, syncTargetClusterName logicalcluste
 	upstreamInformers kcpdynamicinformer.DynamicSharedInformerFactory, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, downstreamNSCleaner shared.Cleaner,
 	syncerInformers resourcesync.SyncerInformerFactory,
 	syncTargetUID types.UID,
+	syncTargetLister workloadv1alpha1listers.SyncTargetLister,
 	serviceAccountLister listerscorev1.ServiceAccountLister,
 	roleLister listersrbacv1.RoleLister,
 	roleBindingLister listersrbacv1.RoleBindingLister,
@@ -215,17 +218,31 @@ func NewSpecSyncer(syncerLogger logr.Logger, syncTargetClusterName logicalcluste
 			}
 		})
 
+	getWorkloadRBAC := func() *workloadv1alpha1.WorkloadRBACPolicy {
+		syncTarget, err := syncTargetLister.Get(syncTargetName)
+		if err != nil {
+			return nil
+		}
+		return syncTarget.Spec.WorkloadRBAC
+	}
+
 	secretMutator := specmutators.NewSecretMutator()
+	serviceAccountMutator := specmutators.NewServiceAccountMutator()
+	roleMutator := specmutators.NewRoleMutator(getWorkloadRBAC)
+	roleBindingMutator := specmutators.NewRoleBindingMutator(syncTargetClusterName, syncTargetUID, syncTargetName)
 
 	// make sure the secrets informer gets started
 	_ = upstreamInformers.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}).Informer()
 	deploymentMutator := specmutators.NewDeploymentMutator(upstreamURL, func(clusterName logicalcluster.Name, namespace string) ([]runtime.Object, error) {
 		return upstreamInformers.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}).Lister().ByCluster(clusterName).ByNamespace(namespace).List(labels.Everything())
-	}, serviceLister, syncTargetClusterName, syncTargetUID, syncTargetName, dnsNamespace)
+	}, serviceLister, syncTargetClusterName, syncTargetUID, syncTargetName, dnsNamespace, getWorkloadRBAC)
 
 	c.mutators = mutatorGvrMap{
-		deploymentMutator.GVR(): deploymentMutator.Mutate,
-		secretMutator.GVR():     secretMutator.Mutate,
+		deploymentMutator.GVR():     deploymentMutator.Mutate,
+		secretMutator.GVR():         secretMutator.Mutate,
+		serviceAccountMutator.GVR(): serviceAccountMutator.Mutate,
+		roleMutator.GVR():           roleMutator.Mutate,
+		roleBindingMutator.GVR():    roleBindingMutator.Mutate,
 	}
 
 	c.dnsProcessor = dns.NewDNSProcessor(downstreamKubeClient, serviceAccountLister, roleLister, roleBindingLister, deploymentLister,
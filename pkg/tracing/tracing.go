@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing supplies helpers to trace the reconciliation of objects by controllers as part of
+// the trace of the request that changed them.
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TraceParentAnnotationKey is the annotation holding the W3C trace context of the request that
+	// last changed the spec of an object. The reconciliation of the object joins that trace.
+	TraceParentAnnotationKey = "internal.kcp.io/traceparent"
+	// TraceParentGenerationAnnotationKey is the annotation holding the generation of the object
+	// the trace context applies to. Reconciliations of other generations do not join the trace.
+	TraceParentGenerationAnnotationKey = "internal.kcp.io/traceparent-generation"
+
+	// ReconcilerAttribute is the span attribute identifying a reconciler.
+	ReconcilerAttribute = "kcp.reconciler"
+	// QueueKeyAttribute is the span attribute exposing the workqueue key being processed.
+	QueueKeyAttribute = "kcp.queue.key"
+	// ClusterAttribute is the span attribute identifying the logical cluster of an object.
+	ClusterAttribute = "kcp.cluster"
+
+	// instrumentationName is the name of the tracer of the reconcilers.
+	instrumentationName = "github.com/kcp-dev/kcp/pkg/reconciler"
+
+	traceParentHeader = "traceparent"
+)
+
+var propagator = propagation.TraceContext{}
+
+// Tracer returns the tracer of the reconcilers.
+func Tracer(tracerProvider trace.TracerProvider) trace.Tracer {
+	if tracerProvider == nil {
+		tracerProvider = trace.NewNoopTracerProvider()
+	}
+	return tracerProvider.Tracer(instrumentationName)
+}
+
+// InjectTraceContext records the trace context of the request, if it is sampled, in the annotations
+// of the object, for the given generation of the object. Otherwise, any recorded trace context is
+// removed, so that the reconciliation does not join the trace of an earlier request. It is meant to
+// be called by admission, on changes of the spec of the object to be reconciled.
+func InjectTraceContext(ctx context.Context, obj metav1.Object, generation int64) {
+	annotations := obj.GetAnnotations()
+
+	var traceParent string
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && sc.IsSampled() {
+		carrier := annotationCarrier{}
+		propagator.Inject(ctx, carrier)
+		traceParent = carrier[traceParentHeader]
+	}
+	if traceParent == "" {
+		if _, found := annotations[TraceParentAnnotationKey]; found {
+			delete(annotations, TraceParentAnnotationKey)
+			delete(annotations, TraceParentGenerationAnnotationKey)
+			obj.SetAnnotations(annotations)
+		}
+		return
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TraceParentAnnotationKey] = traceParent
+	annotations[TraceParentGenerationAnnotationKey] = strconv.FormatInt(generation, 10)
+	obj.SetAnnotations(annotations)
+}
+
+// StartReconcileSpan starts the span of the reconciliation of the object with the given key. The span
+// is a child of the trace context recorded in the annotations of the object, if any, so that requests
+// issued by the reconciler with the returned context are part of the trace of the request that last
+// changed the object. obj may be nil, e.g., when the object has been deleted.
+func StartReconcileSpan(ctx context.Context, tracer trace.Tracer, reconciler, key string, obj metav1.Object) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{
+		attribute.String(ReconcilerAttribute, reconciler),
+		attribute.String(QueueKeyAttribute, key),
+	}
+	if clusterName, _, _, err := kcpcache.SplitMetaClusterNamespaceKey(key); err == nil && !clusterName.Empty() {
+		attributes = append(attributes, attribute.String(ClusterAttribute, clusterName.String()))
+	}
+
+	if obj != nil {
+		ctx = extractTraceContext(ctx, obj)
+	}
+	return tracer.Start(ctx, reconciler+".reconcile", trace.WithAttributes(attributes...))
+}
+
+// extractTraceContext returns the context with the trace context recorded in the annotations
+// of the object as remote parent, if any, and if it was recorded for the current generation.
+func extractTraceContext(ctx context.Context, obj metav1.Object) context.Context {
+	traceParent, ok := obj.GetAnnotations()[TraceParentAnnotationKey]
+	if !ok {
+		return ctx
+	}
+	if generation := obj.GetAnnotations()[TraceParentGenerationAnnotationKey]; generation != strconv.FormatInt(obj.GetGeneration(), 10) {
+		return ctx
+	}
+	return propagator.Extract(ctx, annotationCarrier{traceParentHeader: traceParent})
+}
+
+// annotationCarrier carries the trace context between the propagator and an annotation.
+type annotationCarrier map[string]string
+
+var _ propagation.TextMapCarrier = annotationCarrier{}
+
+func (c annotationCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c annotationCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c annotationCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
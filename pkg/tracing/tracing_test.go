@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	spanID := trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	tests := map[string]struct {
+		flags          trace.TraceFlags
+		wantAnnotation string
+	}{
+		"sampled": {
+			flags:          trace.FlagsSampled,
+			wantAnnotation: "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+		},
+		"not sampled": {
+			flags: 0,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: tc.flags})
+			ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+			obj := &metav1.ObjectMeta{Name: "binding", Generation: 1}
+			InjectTraceContext(ctx, obj, 1)
+			require.Equal(t, tc.wantAnnotation, obj.Annotations[TraceParentAnnotationKey])
+			if tc.wantAnnotation == "" {
+				return
+			}
+
+			extracted := trace.SpanContextFromContext(extractTraceContext(context.Background(), obj))
+			require.Equal(t, traceID, extracted.TraceID())
+			require.Equal(t, spanID, extracted.SpanID())
+			require.True(t, extracted.IsRemote())
+
+			// the trace context does not apply to later generations
+			obj.Generation = 2
+			require.False(t, trace.SpanContextFromContext(extractTraceContext(context.Background(), obj)).IsValid())
+		})
+	}
+}
+
+func TestUnsampledWriteRemovesTraceContext(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Name:       "binding",
+		Generation: 1,
+		Annotations: map[string]string{
+			TraceParentAnnotationKey:           "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+			TraceParentGenerationAnnotationKey: "1",
+			"other":                            "value",
+		},
+	}
+	InjectTraceContext(context.Background(), obj, 2)
+	require.Equal(t, map[string]string{"other": "value"}, obj.Annotations)
+}
+
+func TestNoTraceContext(t *testing.T) {
+	obj := &metav1.ObjectMeta{Name: "binding"}
+	InjectTraceContext(context.Background(), obj, 1)
+	require.Nil(t, obj.Annotations)
+
+	ctx := extractTraceContext(context.Background(), obj)
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
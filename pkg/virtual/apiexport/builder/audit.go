@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	kaudit "k8s.io/apiserver/pkg/audit"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+const (
+	apiExportAnnotation         = "apis.kcp.io/apiexport"
+	apiExportIdentityAnnotation = "apis.kcp.io/apiexport-identity"
+)
+
+// withAuditAnnotations adds the APIExport served by the virtual workspace, and its identity
+// hash when the APIExport is known, into the annotations of the audit event of the request.
+// The annotations are only recorded when the virtual workspace runs in-process on a shard.
+func withAuditAnnotations(ctx context.Context, apiDomain dynamiccontext.APIDomainKey, getAPIExport func(logicalcluster.Name, string) (*apisv1alpha1.APIExport, error)) {
+	kaudit.AddAuditAnnotation(ctx, apiExportAnnotation, string(apiDomain))
+
+	clusterName, name, ok := strings.Cut(string(apiDomain), "/")
+	if !ok {
+		return
+	}
+	if export, err := getAPIExport(logicalcluster.Name(clusterName), name); err == nil && export.Status.IdentityHash != "" {
+		kaudit.AddAuditAnnotation(ctx, apiExportIdentityAnnotation, export.Status.IdentityHash)
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
@@ -34,9 +36,11 @@ import (
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/authorization"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	virtualapiexportauth "github.com/kcp-dev/kcp/pkg/virtual/apiexport/authorizer"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/controllers/apireconciler"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/schemas"
@@ -46,6 +50,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 )
 
@@ -64,6 +69,14 @@ func BuildVirtualWorkspace(
 
 	readyCh := make(chan struct{})
 
+	getAPIExport := func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+		return wildcardKcpInformers.Apis().V1alpha1().APIExports().Lister().Cluster(clusterName).Get(name)
+	}
+
+	// apiSetGetter is set when the apiexport virtual workspace is registered, and shared with the claims one.
+	var apiSetGetterLock sync.RWMutex
+	var apiSetGetter apidefinition.APIDefinitionSetGetter
+
 	boundOrClaimedWorkspaceContent := &virtualdynamic.DynamicVirtualWorkspace{
 		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, ctx context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
 			cluster, apiDomain, prefixToStrip, ok := digestUrl(urlPath, rootPathPrefix)
@@ -73,6 +86,7 @@ func BuildVirtualWorkspace(
 
 			completedContext = genericapirequest.WithCluster(ctx, cluster)
 			completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomain)
+			withAuditAnnotations(completedContext, apiDomain, getAPIExport)
 			return true, prefixToStrip, completedContext
 		}),
 
@@ -124,6 +138,19 @@ func BuildVirtualWorkspace(
 						restProvider,
 					)
 				},
+				func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error) {
+					restProvider, err := provideTenantRestStorage(ctx, dynamicClusterClient, wildcardKcpInformers.Apis().V1alpha1().APIBindings().Lister(), clusterName, apiExportName)
+					if err != nil {
+						return nil, err
+					}
+
+					return apiserver.CreateServingInfoFor(
+						mainConfig,
+						schemas.LogicalClusterSchema,
+						corev1alpha1.SchemeGroupVersion.Version,
+						restProvider,
+					)
+				},
 			)
 			if err != nil {
 				return nil, err
@@ -135,6 +162,7 @@ func BuildVirtualWorkspace(
 				for name, informer := range map[string]cache.SharedIndexInformer{
 					"apiresourceschemas": wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas().Informer(),
 					"apiexports":         wildcardKcpInformers.Apis().V1alpha1().APIExports().Informer(),
+					"apibindings":        wildcardKcpInformers.Apis().V1alpha1().APIBindings().Informer(),
 				} {
 					if !cache.WaitForNamedCacheSync(name, hookContext.StopCh, informer.HasSynced) {
 						klog.Errorf("informer not synced")
@@ -148,13 +176,105 @@ func BuildVirtualWorkspace(
 				return nil, err
 			}
 
+			apiSetGetterLock.Lock()
+			apiSetGetter = apiReconciler
+			apiSetGetterLock.Unlock()
+
 			return apiReconciler, nil
 		},
 		Authorizer: newAuthorizer(kubeClusterClient, deepSARClient, wildcardKcpInformers),
 	}
 
+	indexers.AddIfNotPresentOrDie(wildcardKcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), cache.Indexers{
+		indexers.APIBindingsByAPIExport: indexers.IndexAPIBindingByAPIExport,
+	})
+
+	writeThroughReadyCh := make(chan struct{})
+	writeThrough := &handler.VirtualWorkspace{
+		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, ctx context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			apiDomain, prefixToStrip, ok := digestWriteThroughUrl(urlPath, rootPathPrefix)
+			if !ok {
+				return false, "", ctx
+			}
+
+			completedContext = dynamiccontext.WithAPIDomainKey(ctx, apiDomain)
+			withAuditAnnotations(completedContext, apiDomain, getAPIExport)
+			return true, prefixToStrip, completedContext
+		}),
+		ReadyChecker: framework.ReadyFunc(func() error {
+			select {
+			case <-writeThroughReadyCh:
+				return nil
+			default:
+				return fmt.Errorf("%s virtual workspace informers are not synced", WriteThroughVirtualWorkspaceName)
+			}
+		}),
+		HandlerFactory: handler.HandlerFactory(func(rootAPIServerConfig genericapiserver.CompletedConfig) (http.Handler, error) {
+			if err := rootAPIServerConfig.AddPostStartHook(WriteThroughVirtualWorkspaceName, func(hookContext genericapiserver.PostStartHookContext) error {
+				defer close(writeThroughReadyCh)
+
+				for name, informer := range map[string]cache.SharedIndexInformer{
+					"apiexports":  wildcardKcpInformers.Apis().V1alpha1().APIExports().Informer(),
+					"apibindings": wildcardKcpInformers.Apis().V1alpha1().APIBindings().Informer(),
+				} {
+					if !cache.WaitForNamedCacheSync(name, hookContext.StopCh, informer.HasSynced) {
+						klog.Errorf("informer not synced")
+						return nil
+					}
+				}
+
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			return newWriteThroughHandler(
+				dynamicClusterClient,
+				wildcardKcpInformers.Apis().V1alpha1().APIExports().Lister(),
+				wildcardKcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(),
+			), nil
+		}),
+		Authorizer: newAuthorizer(kubeClusterClient, deepSARClient, wildcardKcpInformers),
+	}
+
+	claimsAuthorizer := newAuthorizer(kubeClusterClient, deepSARClient, wildcardKcpInformers)
+	claims := &handler.VirtualWorkspace{
+		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, ctx context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			apiDomain, prefixToStrip, ok := digestClaimsUrl(urlPath, rootPathPrefix)
+			if !ok {
+				return false, "", ctx
+			}
+
+			completedContext = dynamiccontext.WithAPIDomainKey(ctx, apiDomain)
+			withAuditAnnotations(completedContext, apiDomain, getAPIExport)
+			return true, prefixToStrip, completedContext
+		}),
+		ReadyChecker: framework.ReadyFunc(func() error {
+			select {
+			case <-readyCh:
+				return nil
+			default:
+				return fmt.Errorf("%s virtual workspace controllers are not started", ClaimsVirtualWorkspaceName)
+			}
+		}),
+		HandlerFactory: handler.HandlerFactory(func(rootAPIServerConfig genericapiserver.CompletedConfig) (http.Handler, error) {
+			return newClaimsHandler(
+				wildcardKcpInformers.Apis().V1alpha1().APIExports().Lister(),
+				func() apidefinition.APIDefinitionSetGetter {
+					apiSetGetterLock.RLock()
+					defer apiSetGetterLock.RUnlock()
+					return apiSetGetter
+				},
+				claimsAuthorizer,
+			), nil
+		}),
+		Authorizer: claimsAuthorizer,
+	}
+
 	return []rootapiserver.NamedVirtualWorkspace{
 		{Name: VirtualWorkspaceName, VirtualWorkspace: boundOrClaimedWorkspaceContent},
+		{Name: WriteThroughVirtualWorkspaceName, VirtualWorkspace: writeThrough},
+		{Name: ClaimsVirtualWorkspaceName, VirtualWorkspace: claims},
 	}, nil
 }
 
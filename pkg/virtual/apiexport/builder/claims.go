@@ -0,0 +1,577 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+const (
+	// ClaimsVirtualWorkspaceName is the name of the virtual workspace that serves a single watch
+	// stream over all the resources claimed by an APIExport, across all the consumer workspaces.
+	ClaimsVirtualWorkspaceName string = "apiexport-claims"
+
+	// claimsPathSegment follows the APIExport name in the URLs served by the claims virtual workspace, e.g.:
+	//  /services/apiexport/root:org:ws/<apiexport-name>/claims?watch=true
+	claimsPathSegment = "claims"
+
+	// claimsListPageSize is the number of objects listed per page for the initial list of a
+	// claimed resource.
+	claimsListPageSize = 500
+)
+
+// ClaimWatchEvent is an event of the claims watch stream. As the stream mixes events for
+// different resources, each event carries the resource of its object.
+//
+// Without a resourceVersion, the stream starts with ADDED events for the existing objects of each
+// resource, followed by a BOOKMARK event for that resource. The existing objects are listed in
+// pages, and the stream ends with an ERROR event if a page cannot be listed.
+type ClaimWatchEvent struct {
+	// Type is the type of the watch event.
+	Type watch.EventType `json:"type"`
+	// Resource is the resource of the object.
+	Resource metav1.GroupVersionResource `json:"resource"`
+	// ResourceVersion is the resource version of the stream after this event. Passing it as the
+	// resourceVersion of a new claims watch resumes the stream without missing events.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// Object is the object of the event. It is a Status for ERROR events, and empty for
+	// BOOKMARK events.
+	Object json.RawMessage `json:"object"`
+}
+
+// digestClaimsUrl parses URLs of the form:
+//
+//	/services/apiexport/<apiexport-cluster>/<apiexport-name>/claims
+func digestClaimsUrl(urlPath, rootPathPrefix string) (
+	domainKey dynamiccontext.APIDomainKey,
+	logicalPath string,
+	accepted bool,
+) {
+	if !strings.HasPrefix(urlPath, rootPathPrefix) {
+		return "", "", false
+	}
+	withoutRootPathPrefix := strings.TrimSuffix(strings.TrimPrefix(urlPath, rootPathPrefix), "/")
+
+	parts := strings.Split(withoutRootPathPrefix, "/")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+
+	apiExportClusterName, apiExportName := parts[0], parts[1]
+	if apiExportClusterName == "" || apiExportName == "" || parts[2] != claimsPathSegment {
+		return "", "", false
+	}
+
+	key := fmt.Sprintf("%s/%s", apiExportClusterName, apiExportName)
+	return dynamiccontext.APIDomainKey(key), rootPathPrefix + withoutRootPathPrefix, true
+}
+
+type claimsHandler struct {
+	getAPIExport        func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error)
+	getAPIDefinitionSet func(ctx context.Context, key dynamiccontext.APIDomainKey) (apidefinition.APIDefinitionSet, bool, error)
+	authorizer          authorizer.Authorizer
+}
+
+func newClaimsHandler(apiExportLister apisv1alpha1listers.APIExportClusterLister, apiSetGetter func() apidefinition.APIDefinitionSetGetter, authz authorizer.Authorizer) *claimsHandler {
+	return &claimsHandler{
+		getAPIExport: func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error) {
+			return apiExportLister.Cluster(logicalcluster.Name(clusterName)).Get(apiExportName)
+		},
+		getAPIDefinitionSet: func(ctx context.Context, key dynamiccontext.APIDomainKey) (apidefinition.APIDefinitionSet, bool, error) {
+			getter := apiSetGetter()
+			if getter == nil {
+				return nil, false, fmt.Errorf("%s virtual workspace is not started", VirtualWorkspaceName)
+			}
+			return getter.GetAPIDefinitionSet(ctx, key)
+		},
+		authorizer: authz,
+	}
+}
+
+// claimedStorage is the storage serving a claimed resource in the apiexport virtual workspace.
+type claimedStorage struct {
+	gvr     schema.GroupVersionResource
+	watcher rest.Watcher
+	// lister is nil if the storage does not support listing.
+	lister rest.Lister
+}
+
+func (h *claimsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := klog.FromContext(ctx)
+
+	query := req.URL.Query()
+	if req.Method != http.MethodGet || query.Get("watch") != "true" {
+		responsewriters.ErrorNegotiated(apierrors.NewMethodNotSupported(schema.GroupResource{Resource: claimsPathSegment}, req.Method), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	resourceVersions, err := decodeClaimsResourceVersion(query.Get("resourceVersion"))
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(err.Error()), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	selector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	var timeoutSeconds *int64
+	if s := query.Get("timeoutSeconds"); s != "" {
+		t, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || t < 0 {
+			responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("invalid timeoutSeconds %q", s)), errorCodecs, schema.GroupVersion{}, w, req)
+			return
+		}
+		timeoutSeconds = &t
+	}
+
+	apiDomainKey := dynamiccontext.APIDomainKeyFrom(ctx)
+	parts := strings.SplitN(string(apiDomainKey), "/", 2)
+	if len(parts) < 2 {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("invalid API domain key")), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	export, err := h.getAPIExport(parts[0], parts[1])
+	if apierrors.IsNotFound(err) {
+		responsewriters.ErrorNegotiated(apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), parts[0]+"|"+parts[1]), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	apis, found, err := h.getAPIDefinitionSet(ctx, apiDomainKey)
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewServiceUnavailable(err.Error()), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if !found {
+		responsewriters.ErrorNegotiated(apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), parts[0]+"|"+parts[1]), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	storages := claimedStorages(export, apis)
+	if len(storages) == 0 {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("APIExport %s|%s does not claim any resource", parts[0], parts[1])), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	u, ok := genericapirequest.UserFrom(ctx)
+	if !ok {
+		responsewriters.ErrorNegotiated(apierrors.NewUnauthorized("no user found in request"), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	for _, s := range storages {
+		for _, verb := range []string{"list", "watch"} {
+			decision, reason, err := h.authorizer.Authorize(ctx, authorizer.AttributesRecord{
+				User:            u,
+				Verb:            verb,
+				APIGroup:        s.gvr.Group,
+				APIVersion:      s.gvr.Version,
+				Resource:        s.gvr.Resource,
+				ResourceRequest: true,
+			})
+			if err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+				return
+			}
+			if decision != authorizer.DecisionAllow {
+				responsewriters.ErrorNegotiated(apierrors.NewForbidden(s.gvr.GroupResource(), "", fmt.Errorf("%s is not allowed: %s", verb, reason)), errorCodecs, schema.GroupVersion{}, w, req)
+				return
+			}
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("streaming is not supported")), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	if timeoutSeconds != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watchCtx := genericapirequest.WithCluster(ctx, genericapirequest.Cluster{Wildcard: true})
+	watchCtx = genericapirequest.WithNamespace(watchCtx, "")
+
+	// Start all the streams before answering, so that errors are returned with the right status code.
+	streams := make([]*claimStream, 0, len(storages))
+	defer func() {
+		for _, stream := range streams {
+			stream.stop()
+		}
+	}()
+	for _, s := range storages {
+		stream := &claimStream{storage: s, selector: selector, resourceVersion: resourceVersions[s.gvr.GroupResource().String()]}
+		if err := stream.start(watchCtx); err != nil {
+			if _, ok := err.(apierrors.APIStatus); !ok {
+				err = apierrors.NewInternalError(err)
+			}
+			responsewriters.ErrorNegotiated(err, errorCodecs, schema.GroupVersion{}, w, req)
+			return
+		}
+		streams = append(streams, stream)
+	}
+
+	events := make(chan claimEvent)
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream *claimStream) {
+			defer wg.Done()
+			// a stream only ends on its own when it cannot be resumed, in which case
+			// clients have to re-establish the whole claims watch.
+			defer cancel()
+			stream.run(watchCtx, events)
+		}(stream)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	current := make(map[string]string, len(streams))
+	for _, stream := range streams {
+		if stream.resourceVersion != "" {
+			current[stream.storage.gvr.GroupResource().String()] = stream.resourceVersion
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	for event := range events {
+		if event.resourceVersion != "" {
+			current[event.gvr.GroupResource().String()] = event.resourceVersion
+		}
+		raw, err := json.Marshal(event.object)
+		if err != nil {
+			logger.Error(err, "failed to encode watch event", "gvr", event.gvr)
+			cancel()
+			break
+		}
+		if err := encoder.Encode(&ClaimWatchEvent{
+			Type:            event.eventType,
+			Resource:        metav1.GroupVersionResource(event.gvr),
+			ResourceVersion: encodeClaimsResourceVersion(current),
+			Object:          raw,
+		}); err != nil {
+			logger.V(4).Info("claims watch client went away", "err", err)
+			cancel()
+			break
+		}
+		flusher.Flush()
+	}
+	// drain so that the streams can terminate
+	for range events {
+	}
+}
+
+// claimEvent is an event of a single claimed resource, before it is merged into the claims watch stream.
+type claimEvent struct {
+	gvr             schema.GroupVersionResource
+	eventType       watch.EventType
+	object          runtime.Object
+	resourceVersion string
+}
+
+// claimStream lists and watches a single claimed resource. It resumes its watch from the last
+// seen resource version whenever the underlying watch ends.
+type claimStream struct {
+	storage  claimedStorage
+	selector labels.Selector
+
+	// resourceVersion is the resource version to resume from.
+	resourceVersion string
+	// initial holds the objects of the current page of the initial list.
+	initial []runtime.Object
+	// continueToken is the continue token of the next page of the initial list, if any.
+	continueToken string
+	watcher       watch.Interface
+}
+
+// start lists the first page of the resource if there is no resource version to resume from, and
+// starts watching from the resource version of the list. The other pages are listed by run.
+func (s *claimStream) start(ctx context.Context) error {
+	if s.resourceVersion == "" && s.storage.lister != nil {
+		if err := s.list(ctx, ""); err != nil {
+			return err
+		}
+	}
+
+	watcher, err := s.watch(ctx)
+	if err != nil {
+		return err
+	}
+	s.watcher = watcher
+	return nil
+}
+
+// list lists the page of the resource of the given continue token. The resource version of the
+// stream is the one of the first page, which is the same for all the pages.
+func (s *claimStream) list(ctx context.Context, continueToken string) error {
+	list, err := s.storage.lister.List(ctx, &metainternalversion.ListOptions{
+		LabelSelector: s.selector,
+		Limit:         claimsListPageSize,
+		Continue:      continueToken,
+	})
+	if err != nil {
+		return err
+	}
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	s.initial, s.continueToken = items, listAccessor.GetContinue()
+	if continueToken == "" {
+		s.resourceVersion = listAccessor.GetResourceVersion()
+	}
+	return nil
+}
+
+func (s *claimStream) watch(ctx context.Context) (watch.Interface, error) {
+	return s.storage.watcher.Watch(ctx, &metainternalversion.ListOptions{
+		LabelSelector:       s.selector,
+		ResourceVersion:     s.resourceVersion,
+		Watch:               true,
+		AllowWatchBookmarks: true,
+	})
+}
+
+func (s *claimStream) stop() {
+	if s.watcher != nil {
+		s.watcher.Stop()
+	}
+}
+
+// run sends the listed objects, a bookmark marking the end of the initial list, and then the watch
+// events to the given channel, until the context is done or the watch cannot be resumed.
+func (s *claimStream) run(ctx context.Context, events chan<- claimEvent) {
+	logger := klog.FromContext(ctx).WithValues("gvr", s.storage.gvr)
+
+	send := func(event claimEvent) bool {
+		event.gvr = s.storage.gvr
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if s.initial != nil || s.resourceVersion != "" {
+		for {
+			for _, obj := range s.initial {
+				if !send(claimEvent{eventType: watch.Added, object: obj}) {
+					return
+				}
+			}
+			s.initial = nil
+			if s.continueToken == "" {
+				break
+			}
+			if err := s.list(ctx, s.continueToken); err != nil {
+				// e.g. the continue token expired. The stream cannot be completed without missing objects.
+				status, ok := err.(apierrors.APIStatus)
+				if !ok {
+					status = apierrors.NewInternalError(err)
+				}
+				st := status.Status()
+				send(claimEvent{eventType: watch.Error, object: &st})
+				return
+			}
+		}
+		if !send(claimEvent{eventType: watch.Bookmark, resourceVersion: s.resourceVersion}) {
+			return
+		}
+	}
+
+	for {
+		for event := range s.watcher.ResultChan() {
+			if event.Type == watch.Error {
+				// e.g. the resource version is too old. The stream cannot be resumed without missing events.
+				send(claimEvent{eventType: watch.Error, object: event.Object})
+				return
+			}
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				logger.Error(err, "unexpected watch event object")
+				return
+			}
+			s.resourceVersion = accessor.GetResourceVersion()
+			if !send(claimEvent{eventType: event.Type, object: event.Object, resourceVersion: s.resourceVersion}) {
+				return
+			}
+		}
+
+		// the underlying watch ended, resume it from the last seen resource version.
+		s.watcher.Stop()
+		backoff := wait.Backoff{Duration: 100 * time.Millisecond, Factor: 2, Jitter: 0.1, Steps: 10, Cap: 10 * time.Second}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff.Step()):
+			}
+			watcher, err := s.watch(ctx)
+			if err == nil {
+				s.watcher = watcher
+				break
+			}
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				if status, ok := err.(apierrors.APIStatus); ok {
+					st := status.Status()
+					send(claimEvent{eventType: watch.Error, object: &st})
+				}
+				return
+			}
+			logger.V(4).Info("failed to resume watch, retrying", "err", err)
+		}
+	}
+}
+
+// encodeClaimsResourceVersion encodes the resource versions of the claimed resources, keyed by
+// group resource, into the opaque resource version of the claims watch stream.
+func encodeClaimsResourceVersion(resourceVersions map[string]string) string {
+	if len(resourceVersions) == 0 {
+		return ""
+	}
+	raw, err := json.Marshal(resourceVersions)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeClaimsResourceVersion decodes a resource version of the claims watch stream. Resources
+// without a resource version are listed before they are watched.
+func decodeClaimsResourceVersion(resourceVersion string) (map[string]string, error) {
+	if resourceVersion == "" || resourceVersion == "0" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(resourceVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resourceVersion %q", resourceVersion)
+	}
+	var resourceVersions map[string]string
+	if err := json.Unmarshal(raw, &resourceVersions); err != nil {
+		return nil, fmt.Errorf("invalid resourceVersion %q", resourceVersion)
+	}
+	return resourceVersions, nil
+}
+
+// claimedStorages returns a storage per resource claimed by the APIExport, served at the storage
+// version of its schema.
+func claimedStorages(export *apisv1alpha1.APIExport, apis apidefinition.APIDefinitionSet) []claimedStorage {
+	claimed := map[schema.GroupResource]bool{}
+	for _, claim := range export.Spec.PermissionClaims {
+		claimed[schema.GroupResource{Group: claim.Group, Resource: claim.Resource}] = true
+	}
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(apis))
+	for gvr := range apis {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].String() < gvrs[j].String()
+	})
+
+	selected := map[schema.GroupResource]claimedStorage{}
+	for _, gvr := range gvrs {
+		if !claimed[gvr.GroupResource()] {
+			continue
+		}
+		def := apis[gvr]
+		if def == nil {
+			continue
+		}
+		watcher, ok := def.GetStorage().(rest.Watcher)
+		if !ok {
+			continue
+		}
+		if _, found := selected[gvr.GroupResource()]; found && !isStorageVersion(def, gvr.Version) {
+			continue
+		}
+		lister, _ := def.GetStorage().(rest.Lister)
+		selected[gvr.GroupResource()] = claimedStorage{gvr: gvr, watcher: watcher, lister: lister}
+	}
+
+	storages := make([]claimedStorage, 0, len(selected))
+	for _, gvr := range gvrs {
+		if s, ok := selected[gvr.GroupResource()]; ok && s.gvr == gvr {
+			storages = append(storages, s)
+		}
+	}
+	return storages
+}
+
+func isStorageVersion(def apidefinition.APIDefinition, version string) bool {
+	apiResourceSchema := def.GetAPIResourceSchema()
+	if apiResourceSchema == nil {
+		return false
+	}
+	for _, v := range apiResourceSchema.Spec.Versions {
+		if v.Name == version {
+			return v.Storage
+		}
+	}
+	return false
+}
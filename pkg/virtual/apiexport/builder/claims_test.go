@@ -0,0 +1,301 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+func TestDigestClaimsUrl(t *testing.T) {
+	tests := map[string]struct {
+		urlPath        string
+		expectedKey    dynamiccontext.APIDomainKey
+		expectedPrefix string
+		accepted       bool
+	}{
+		"claims": {
+			urlPath:        "/services/apiexport/root:org/my-export/claims",
+			expectedKey:    "root:org/my-export",
+			expectedPrefix: "/services/apiexport/root:org/my-export/claims",
+			accepted:       true,
+		},
+		"trailing slash": {
+			urlPath:        "/services/apiexport/root:org/my-export/claims/",
+			expectedKey:    "root:org/my-export",
+			expectedPrefix: "/services/apiexport/root:org/my-export/claims",
+			accepted:       true,
+		},
+		"resource path": {
+			urlPath: "/services/apiexport/root:org/my-export/claims/api/v1/configmaps",
+		},
+		"apiexport virtual workspace request": {
+			urlPath: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps",
+		},
+		"missing apiexport name": {
+			urlPath: "/services/apiexport/root:org//claims",
+		},
+		"other virtual workspace": {
+			urlPath: "/services/syncer/root:org/my-export/claims",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, prefix, accepted := digestClaimsUrl(tc.urlPath, "/services/apiexport/")
+			require.Equal(t, tc.accepted, accepted)
+			if !tc.accepted {
+				return
+			}
+			require.Equal(t, tc.expectedKey, key)
+			require.Equal(t, tc.expectedPrefix, prefix)
+		})
+	}
+}
+
+func TestClaimsResourceVersion(t *testing.T) {
+	rvs, err := decodeClaimsResourceVersion("")
+	require.NoError(t, err)
+	require.Empty(t, rvs)
+
+	rvs, err = decodeClaimsResourceVersion("0")
+	require.NoError(t, err)
+	require.Empty(t, rvs)
+
+	_, err = decodeClaimsResourceVersion("42")
+	require.Error(t, err)
+
+	expected := map[string]string{"configmaps": "42", "widgets.example.io": "43"}
+	rvs, err = decodeClaimsResourceVersion(encodeClaimsResourceVersion(expected))
+	require.NoError(t, err)
+	require.Equal(t, expected, rvs)
+}
+
+type fakeClaimedStorage struct {
+	rest.TableConvertor
+
+	// lists are the pages of the list, by continue token.
+	lists    map[string]*metav1.PartialObjectMetadataList
+	listErrs map[string]error
+	watchers []watch.Interface
+	errs     []error
+
+	watchedResourceVersions []string
+}
+
+func (s *fakeClaimedStorage) NewList() runtime.Object {
+	return &metav1.PartialObjectMetadataList{}
+}
+
+func (s *fakeClaimedStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	if err := s.listErrs[options.Continue]; err != nil {
+		return nil, err
+	}
+	if options.Limit != claimsListPageSize {
+		return nil, fmt.Errorf("unexpected limit %d", options.Limit)
+	}
+	return s.lists[options.Continue], nil
+}
+
+func (s *fakeClaimedStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	s.watchedResourceVersions = append(s.watchedResourceVersions, options.ResourceVersion)
+	watcher, err := s.watchers[0], s.errs[0]
+	s.watchers, s.errs = s.watchers[1:], s.errs[1:]
+	return watcher, err
+}
+
+func TestClaimStream(t *testing.T) {
+	object := func(name, rv string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: rv}}
+	}
+	watcherWith := func(events ...watch.Event) watch.Interface {
+		w := watch.NewFakeWithChanSize(len(events), false)
+		for _, e := range events {
+			w.Action(e.Type, e.Object)
+		}
+		w.Stop()
+		return w
+	}
+
+	firstPage := &metav1.PartialObjectMetadataList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "10"},
+		Items:    []metav1.PartialObjectMetadata{*object("a", "5")},
+	}
+	paginated := map[string]*metav1.PartialObjectMetadataList{
+		"": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "10", Continue: "next"},
+			Items:    []metav1.PartialObjectMetadata{*object("a", "5")},
+		},
+		"next": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "10"},
+			Items:    []metav1.PartialObjectMetadata{*object("b", "6"), *object("c", "7")},
+		},
+	}
+
+	tests := map[string]struct {
+		resourceVersion string
+		lists           map[string]*metav1.PartialObjectMetadataList
+		listErrs        map[string]error
+		watchers        []watch.Interface
+		errs            []error
+
+		expectedTypes                   []watch.EventType
+		expectedWatchedResourceVersions []string
+	}{
+		"list, watch and resume": {
+			watchers: []watch.Interface{
+				watcherWith(watch.Event{Type: watch.Modified, Object: object("a", "11")}),
+				watcherWith(watch.Event{Type: watch.Deleted, Object: object("a", "12")}),
+				nil,
+			},
+			errs:                            []error{nil, nil, apierrors.NewResourceExpired("too old")},
+			expectedTypes:                   []watch.EventType{watch.Added, watch.Bookmark, watch.Modified, watch.Deleted, watch.Error},
+			expectedWatchedResourceVersions: []string{"10", "11", "12"},
+		},
+		"resume from resource version": {
+			resourceVersion: "20",
+			watchers: []watch.Interface{
+				watcherWith(watch.Event{Type: watch.Added, Object: object("b", "21")}),
+				nil,
+			},
+			errs:                            []error{nil, apierrors.NewResourceExpired("too old")},
+			expectedTypes:                   []watch.EventType{watch.Bookmark, watch.Added, watch.Error},
+			expectedWatchedResourceVersions: []string{"20", "21"},
+		},
+		"paginated list": {
+			lists: paginated,
+			watchers: []watch.Interface{
+				watcherWith(watch.Event{Type: watch.Error, Object: &metav1.Status{Code: 410}}),
+			},
+			errs:                            []error{nil},
+			expectedTypes:                   []watch.EventType{watch.Added, watch.Added, watch.Added, watch.Bookmark, watch.Error},
+			expectedWatchedResourceVersions: []string{"10"},
+		},
+		"expired continue token": {
+			lists:    paginated,
+			listErrs: map[string]error{"next": apierrors.NewResourceExpired("continue token expired")},
+			watchers: []watch.Interface{
+				watcherWith(),
+			},
+			errs:                            []error{nil},
+			expectedTypes:                   []watch.EventType{watch.Added, watch.Error},
+			expectedWatchedResourceVersions: []string{"10"},
+		},
+		"watch error": {
+			watchers: []watch.Interface{
+				watcherWith(watch.Event{Type: watch.Error, Object: &metav1.Status{Code: 410}}),
+			},
+			errs:                            []error{nil},
+			expectedTypes:                   []watch.EventType{watch.Added, watch.Bookmark, watch.Error},
+			expectedWatchedResourceVersions: []string{"10"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.lists == nil {
+				tc.lists = map[string]*metav1.PartialObjectMetadataList{"": firstPage}
+			}
+			storage := &fakeClaimedStorage{
+				lists:    tc.lists,
+				listErrs: tc.listErrs,
+				watchers: tc.watchers,
+				errs:     tc.errs,
+			}
+			stream := &claimStream{
+				storage:         claimedStorage{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, watcher: storage, lister: storage},
+				selector:        labels.Everything(),
+				resourceVersion: tc.resourceVersion,
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), wait.ForeverTestTimeout)
+			defer cancel()
+			require.NoError(t, stream.start(ctx))
+
+			events := make(chan claimEvent, 10)
+			stream.run(ctx, events)
+			close(events)
+
+			var types []watch.EventType
+			for event := range events {
+				types = append(types, event.eventType)
+			}
+			require.Equal(t, tc.expectedTypes, types)
+			require.Equal(t, tc.expectedWatchedResourceVersions, storage.watchedResourceVersions)
+		})
+	}
+}
+
+func TestClaimsErrors(t *testing.T) {
+	h := &claimsHandler{
+		getAPIExport: func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error) {
+			return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), apiExportName)
+		},
+	}
+
+	tests := map[string]struct {
+		method, query  string
+		expectedCode   int
+		expectedReason metav1.StatusReason
+	}{
+		"not a watch": {
+			method: http.MethodGet, query: "",
+			expectedCode: http.StatusMethodNotAllowed, expectedReason: metav1.StatusReasonMethodNotAllowed,
+		},
+		"invalid resource version": {
+			method: http.MethodGet, query: "watch=true&resourceVersion=42",
+			expectedCode: http.StatusBadRequest, expectedReason: metav1.StatusReasonBadRequest,
+		},
+		"invalid timeout": {
+			method: http.MethodGet, query: "watch=true&timeoutSeconds=-1",
+			expectedCode: http.StatusBadRequest, expectedReason: metav1.StatusReasonBadRequest,
+		},
+		"unknown APIExport": {
+			method: http.MethodGet, query: "watch=true",
+			expectedCode: http.StatusNotFound, expectedReason: metav1.StatusReasonNotFound,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/services/apiexport/root:org/my-export/claims?"+tc.query, nil)
+			req = req.WithContext(dynamiccontext.WithAPIDomainKey(req.Context(), "root:org/my-export"))
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			require.Equal(t, tc.expectedCode, rw.Code, rw.Body.String())
+			var status metav1.Status
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+			require.Equal(t, tc.expectedReason, status.Reason)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/permissionclaims"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+// provideTenantRestStorage returns a read-only storage of the LogicalClusters of the workspaces binding the
+// given APIExport, projected to the metadata the consumers share with the API provider.
+func provideTenantRestStorage(ctx context.Context, clusterClient kcpdynamic.ClusterInterface, apiBindingLister apisv1alpha1listers.APIBindingClusterLister, clusterName logicalcluster.Name, exportName string) (apiserver.RestProviderFunc, error) {
+	return registry.ProvideReadOnlyRestStorage(ctx, clusterClient, withTenantProjection(apiBindingLister, clusterName, exportName), nil)
+}
+
+// withTenantProjection filters out the LogicalClusters of the workspaces not binding the given APIExport,
+// and strips the others down to their name, path, owner and shared labels.
+func withTenantProjection(apiBindingLister apisv1alpha1listers.APIBindingClusterLister, exportClusterName logicalcluster.Name, exportName string) registry.StorageWrapper {
+	selector := labels.SelectorFromSet(labels.Set{
+		apisv1alpha1.InternalAPIBindingExportLabelKey: permissionclaims.ToAPIBindingExportLabelValue(exportClusterName, exportName),
+	})
+	isBound := func(obj *unstructured.Unstructured) (bool, error) {
+		apiBindings, err := apiBindingLister.Cluster(logicalcluster.From(obj)).List(selector)
+		if err != nil {
+			return false, err
+		}
+		return len(apiBindings) > 0, nil
+	}
+
+	return registry.StorageWrapperFunc(func(resource schema.GroupResource, storage *registry.StoreFuncs) {
+		delegateLister := storage.ListerFunc
+		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			obj, err := delegateLister.List(ctx, options)
+			if err != nil {
+				return obj, err
+			}
+			list, ok := obj.(*unstructured.UnstructuredList)
+			if !ok {
+				return nil, fmt.Errorf("expected an UnstructuredList, got %T", obj)
+			}
+			projected := &unstructured.UnstructuredList{Object: list.Object, Items: make([]unstructured.Unstructured, 0, len(list.Items))}
+			for i := range list.Items {
+				bound, err := isBound(&list.Items[i])
+				if err != nil {
+					return nil, err
+				}
+				if bound {
+					projected.Items = append(projected.Items, *projectTenant(&list.Items[i]))
+				}
+			}
+			return projected, nil
+		}
+
+		delegateGetter := storage.GetterFunc
+		storage.GetterFunc = func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			obj, err := delegateGetter.Get(ctx, name, options)
+			if err != nil {
+				return obj, err
+			}
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("expected an Unstructured, got %T", obj)
+			}
+			bound, err := isBound(u)
+			if err != nil {
+				return nil, err
+			}
+			if !bound {
+				return nil, errors.NewNotFound(resource, name)
+			}
+			return projectTenant(u), nil
+		}
+
+		delegateWatcher := storage.WatcherFunc
+		storage.WatcherFunc = func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+			w, err := delegateWatcher.Watch(ctx, options)
+			if err != nil {
+				return w, err
+			}
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				u, ok := in.Object.(*unstructured.Unstructured)
+				if !ok || in.Type == watch.Bookmark {
+					return in, true
+				}
+				// the deletion of a workspace must be propagated even if its bindings are already gone
+				if in.Type != watch.Deleted {
+					if bound, err := isBound(u); err != nil || !bound {
+						return in, false
+					}
+				}
+				in.Object = projectTenant(u)
+				return in, true
+			}), nil
+		}
+	})
+}
+
+// projectTenant returns the metadata of the given LogicalCluster that is shared with the API providers:
+// its name, logical cluster and path, the username of its owner, and the labels listed in the
+// apisv1alpha1.SharedLabelsAnnotationKey annotation.
+func projectTenant(logicalCluster *unstructured.Unstructured) *unstructured.Unstructured {
+	projected := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	projected.SetAPIVersion(logicalCluster.GetAPIVersion())
+	projected.SetKind(logicalCluster.GetKind())
+	projected.SetName(logicalCluster.GetName())
+	projected.SetUID(logicalCluster.GetUID())
+	projected.SetResourceVersion(logicalCluster.GetResourceVersion())
+	projected.SetCreationTimestamp(logicalCluster.GetCreationTimestamp())
+	projected.SetDeletionTimestamp(logicalCluster.GetDeletionTimestamp())
+
+	annotations := logicalCluster.GetAnnotations()
+	sharedAnnotations := map[string]string{}
+	for _, key := range []string{logicalcluster.AnnotationKey, core.LogicalClusterPathAnnotationKey} {
+		if value, found := annotations[key]; found {
+			sharedAnnotations[key] = value
+		}
+	}
+	if value, found := annotations[tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey]; found {
+		// only the username of the owner is shared, not its groups and extra attributes
+		var owner authenticationv1.UserInfo
+		if err := json.Unmarshal([]byte(value), &owner); err == nil && owner.Username != "" {
+			if bs, err := json.Marshal(authenticationv1.UserInfo{Username: owner.Username}); err == nil {
+				sharedAnnotations[tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey] = string(bs)
+			}
+		}
+	}
+	projected.SetAnnotations(sharedAnnotations)
+
+	sharedLabels := map[string]string{}
+	for _, key := range strings.Split(annotations[apisv1alpha1.SharedLabelsAnnotationKey], ",") {
+		key = strings.TrimSpace(key)
+		if value, found := logicalCluster.GetLabels()[key]; found && key != "" {
+			sharedLabels[key] = value
+		}
+	}
+	if len(sharedLabels) > 0 {
+		projected.SetLabels(sharedLabels)
+	}
+
+	return projected
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	kcpcache "github.com/kcp-dev/apimachinery/v2/pkg/cache"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/permissionclaims"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+func newTenant(clusterName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": corev1alpha1.SchemeGroupVersion.String(),
+		"kind":       "LogicalCluster",
+		"metadata": map[string]interface{}{
+			"name":            corev1alpha1.LogicalClusterName,
+			"resourceVersion": "42",
+			"annotations": map[string]interface{}{
+				logicalcluster.AnnotationKey:                            clusterName,
+				core.LogicalClusterPathAnnotationKey:                    "root:org:" + clusterName,
+				tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey: `{"username":"alice","groups":["team-a"]}`,
+				apisv1alpha1.SharedLabelsAnnotationKey:                  "tier, region",
+				"internal.example.com/secret":                           "value",
+			},
+			"labels": map[string]interface{}{
+				"tier":     "gold",
+				"internal": "true",
+			},
+		},
+		"spec": map[string]interface{}{
+			"owner": map[string]interface{}{"name": "ws"},
+		},
+	}}
+}
+
+func TestTenantProjection(t *testing.T) {
+	indexer := cache.NewIndexer(kcpcache.MetaClusterNamespaceKeyFunc, cache.Indexers{kcpcache.ClusterIndexName: kcpcache.ClusterIndexFunc})
+	require.NoError(t, indexer.Add(&apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "binding",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: "bound",
+			},
+			Labels: map[string]string{
+				apisv1alpha1.InternalAPIBindingExportLabelKey: permissionclaims.ToAPIBindingExportLabelValue("provider", "export"),
+			},
+		},
+	}))
+	require.NoError(t, indexer.Add(&apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "binding",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: "other",
+			},
+			Labels: map[string]string{
+				apisv1alpha1.InternalAPIBindingExportLabelKey: permissionclaims.ToAPIBindingExportLabelValue("provider", "other-export"),
+			},
+		},
+	}))
+
+	tenants := map[string]*unstructured.Unstructured{
+		"bound":   newTenant("bound"),
+		"other":   newTenant("other"),
+		"unbound": newTenant("unbound"),
+	}
+	storage := &registry.StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			return tenants[name].DeepCopy(), nil
+		},
+		ListerFunc: func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			list := &unstructured.UnstructuredList{}
+			for _, name := range []string{"bound", "other", "unbound"} {
+				list.Items = append(list.Items, *tenants[name].DeepCopy())
+			}
+			return list, nil
+		},
+	}
+	withTenantProjection(apisv1alpha1listers.NewAPIBindingClusterLister(indexer), "provider", "export").Decorate(corev1alpha1.Resource("logicalclusters"), storage)
+
+	obj, err := storage.List(context.Background(), &internalversion.ListOptions{})
+	require.NoError(t, err)
+	list := obj.(*unstructured.UnstructuredList)
+	require.Len(t, list.Items, 1)
+
+	projected := list.Items[0]
+	require.Equal(t, corev1alpha1.LogicalClusterName, projected.GetName())
+	require.Equal(t, "42", projected.GetResourceVersion())
+	require.Equal(t, map[string]string{
+		logicalcluster.AnnotationKey:                            "bound",
+		core.LogicalClusterPathAnnotationKey:                    "root:org:bound",
+		tenancyv1alpha1.ExperimentalWorkspaceOwnerAnnotationKey: `{"username":"alice"}`,
+	}, projected.GetAnnotations())
+	require.Equal(t, map[string]string{"tier": "gold"}, projected.GetLabels())
+	_, found := projected.Object["spec"]
+	require.False(t, found, "spec must not be projected")
+
+	_, err = storage.Get(context.Background(), "bound", &metav1.GetOptions{})
+	require.NoError(t, err)
+	_, err = storage.Get(context.Background(), "other", &metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+	_, err = storage.Get(context.Background(), "unbound", &metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "expected NotFound, got %v", err)
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	apisv1alpha1listers "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/indexers/indexkeys"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+const (
+	// WriteThroughVirtualWorkspaceName is the name of the virtual workspace that lets APIExport owners
+	// write claimed objects into all the consumer workspaces that are bound to their APIExport.
+	WriteThroughVirtualWorkspaceName string = "apiexport-writethrough"
+
+	// writeThroughPathSegment follows the APIExport name in the URLs served by the write-through virtual workspace, e.g.:
+	//  /services/apiexport/root:org:ws/<apiexport-name>/writethrough/api/v1/namespaces/default/configmaps
+	writeThroughPathSegment = "writethrough"
+
+	writeThroughFieldManagerPrefix = "apiexport-writethrough:"
+
+	writeThroughParallelism = 10
+
+	// maxWriteThroughBodySize limits the size of the objects that are written through, in line with
+	// the default maximum request body size of the kube-apiserver.
+	maxWriteThroughBodySize = 3 * 1024 * 1024
+)
+
+var (
+	errorScheme = runtime.NewScheme()
+	errorCodecs = serializer.NewCodecFactory(errorScheme)
+)
+
+func init() {
+	errorScheme.AddUnversionedTypes(metav1.Unversioned,
+		&metav1.Status{},
+	)
+}
+
+// WriteThroughResult is the outcome of writing an object through to one consumer workspace.
+type WriteThroughResult struct {
+	// Cluster is the logical cluster of the consumer workspace.
+	Cluster string `json:"cluster"`
+	// Status is the result of the write in the consumer workspace.
+	Status metav1.Status `json:"status"`
+}
+
+// WriteThroughResultList is the response returned by the write-through virtual workspace,
+// with one result per consumer workspace.
+type WriteThroughResultList struct {
+	Items []WriteThroughResult `json:"items"`
+}
+
+// digestWriteThroughUrl parses URLs of the form:
+//
+//	/services/apiexport/<apiexport-cluster>/<apiexport-name>/writethrough/api/v1/namespaces/default/configmaps
+func digestWriteThroughUrl(urlPath, rootPathPrefix string) (
+	domainKey dynamiccontext.APIDomainKey,
+	logicalPath string,
+	accepted bool,
+) {
+	if !strings.HasPrefix(urlPath, rootPathPrefix) {
+		return "", "", false
+	}
+	withoutRootPathPrefix := strings.TrimPrefix(urlPath, rootPathPrefix)
+
+	parts := strings.SplitN(withoutRootPathPrefix, "/", 4)
+	if len(parts) < 4 {
+		return "", "", false
+	}
+
+	apiExportClusterName, apiExportName := parts[0], parts[1]
+	if apiExportClusterName == "" || apiExportName == "" || parts[2] != writeThroughPathSegment {
+		return "", "", false
+	}
+
+	realPath := "/" + parts[3]
+	key := fmt.Sprintf("%s/%s", apiExportClusterName, apiExportName)
+	return dynamiccontext.APIDomainKey(key), strings.TrimSuffix(urlPath, realPath), true
+}
+
+type writeThroughHandler struct {
+	dynamicClusterClient kcpdynamic.ClusterInterface
+
+	getAPIExport   func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error)
+	getAPIBindings func(export *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error)
+}
+
+func newWriteThroughHandler(dynamicClusterClient kcpdynamic.ClusterInterface, apiExportLister apisv1alpha1listers.APIExportClusterLister, apiBindingIndexer cache.Indexer) *writeThroughHandler {
+	return &writeThroughHandler{
+		dynamicClusterClient: dynamicClusterClient,
+		getAPIExport: func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error) {
+			return apiExportLister.Cluster(logicalcluster.Name(clusterName)).Get(apiExportName)
+		},
+		getAPIBindings: func(export *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+			// bindings can reference the APIExport either by its canonical path or by its logical cluster.
+			values := []string{indexkeys.NewPathAndName(logicalcluster.From(export).Path(), export.Name).String()}
+			if path := logicalcluster.NewPath(export.Annotations[core.LogicalClusterPathAnnotationKey]); !path.Empty() {
+				values = append(values, indexkeys.NewPathAndName(path, export.Name).String())
+			}
+
+			seen := sets.NewString()
+			var bindings []*apisv1alpha1.APIBinding
+			for _, value := range values {
+				found, err := indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingIndexer, indexers.APIBindingsByAPIExport, value)
+				if err != nil {
+					return nil, err
+				}
+				for _, binding := range found {
+					key := logicalcluster.From(binding).Path().Join(binding.Name).String()
+					if seen.Has(key) {
+						continue
+					}
+					seen.Insert(key)
+					bindings = append(bindings, binding)
+				}
+			}
+			return bindings, nil
+		},
+	}
+}
+
+func (h *writeThroughHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	requestInfo, ok := genericapirequest.RequestInfoFrom(ctx)
+	if !ok {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("no RequestInfo found in the context")), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	gr := schema.GroupResource{Group: requestInfo.APIGroup, Resource: requestInfo.Resource}
+	if !requestInfo.IsResourceRequest || requestInfo.Subresource != "" {
+		responsewriters.ErrorNegotiated(apierrors.NewNotFound(gr, requestInfo.Name), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if requestInfo.Verb != "create" && requestInfo.Verb != "update" {
+		responsewriters.ErrorNegotiated(apierrors.NewMethodNotSupported(gr, requestInfo.Verb), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	parts := strings.SplitN(string(dynamiccontext.APIDomainKeyFrom(ctx)), "/", 2)
+	if len(parts) < 2 {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(fmt.Errorf("invalid API domain key")), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	export, err := h.getAPIExport(parts[0], parts[1])
+	if apierrors.IsNotFound(err) {
+		responsewriters.ErrorNegotiated(apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), parts[0]+"|"+parts[1]), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWriteThroughBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		responsewriters.ErrorNegotiated(apierrors.NewRequestEntityTooLargeError(fmt.Sprintf("limit is %d", maxBytesErr.Limit)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("error reading request body: %v", err)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(body); err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("error decoding request body: %v", err)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if requestInfo.Name != "" && obj.GetName() != requestInfo.Name {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("object name %q does not match the request name %q", obj.GetName(), requestInfo.Name)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	if obj.GetName() == "" {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest("object name must be set"), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	obj.SetNamespace(requestInfo.Namespace)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+
+	claim, found := permissionClaimFor(export, gr, obj.GetNamespace(), obj.GetName())
+	if !found {
+		responsewriters.ErrorNegotiated(apierrors.NewForbidden(gr, obj.GetName(), fmt.Errorf("object is not claimed by APIExport %s|%s", parts[0], export.Name)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	bindings, err := h.getAPIBindings(export)
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	gvr := schema.GroupVersionResource{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion, Resource: requestInfo.Resource}
+	if claim.IdentityHash != "" {
+		gvr.Resource += ":" + claim.IdentityHash
+	}
+	// Field ownership is only taken away from consumers when explicitly asked for. Otherwise, conflicts
+	// are reported in the result of the respective consumer workspace.
+	force, err := strconv.ParseBool(req.URL.Query().Get("force"))
+	if err != nil && req.URL.Query().Get("force") != "" {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("invalid force parameter: %v", err)), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	options := metav1.PatchOptions{
+		FieldManager: writeThroughFieldManagerPrefix + export.Name,
+		Force:        &force,
+		DryRun:       req.URL.Query()["dryRun"],
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	results := make([]WriteThroughResult, len(bindings))
+	workqueue.ParallelizeUntil(ctx, writeThroughParallelism, len(bindings), func(i int) {
+		clusterName := logicalcluster.From(bindings[i])
+		results[i] = WriteThroughResult{
+			Cluster: clusterName.String(),
+			Status:  h.writeThrough(ctx, clusterName, bindings[i], claim, gvr, obj, data, options),
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Cluster < results[j].Cluster
+	})
+
+	response, err := json.Marshal(&WriteThroughResultList{Items: results})
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewInternalError(err), errorCodecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response) //nolint:errcheck
+}
+
+func (h *writeThroughHandler) writeThrough(ctx context.Context, clusterName logicalcluster.Name, binding *apisv1alpha1.APIBinding, claim apisv1alpha1.PermissionClaim, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, data []byte, options metav1.PatchOptions) metav1.Status {
+	gr := schema.GroupResource{Group: claim.Group, Resource: claim.Resource}
+	if !claimAccepted(binding, claim, obj.GetNamespace(), obj.GetName()) {
+		return apierrors.NewForbidden(gr, obj.GetName(), fmt.Errorf("permission claim not accepted by APIBinding %s|%s", clusterName, binding.Name)).Status()
+	}
+
+	var err error
+	if obj.GetNamespace() != "" {
+		_, err = h.dynamicClusterClient.Cluster(clusterName.Path()).Resource(gvr).Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options)
+	} else {
+		_, err = h.dynamicClusterClient.Cluster(clusterName.Path()).Resource(gvr).Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options)
+	}
+	if err != nil {
+		if status, ok := err.(apierrors.APIStatus); ok {
+			return status.Status()
+		}
+		return apierrors.NewInternalError(err).Status()
+	}
+
+	return metav1.Status{Status: metav1.StatusSuccess, Code: http.StatusOK}
+}
+
+// permissionClaimFor returns the permission claim of the APIExport selecting the object of the given
+// resource, namespace and name.
+func permissionClaimFor(export *apisv1alpha1.APIExport, gr schema.GroupResource, namespace, name string) (apisv1alpha1.PermissionClaim, bool) {
+	for _, claim := range export.Spec.PermissionClaims {
+		if claim.Group == gr.Group && claim.Resource == gr.Resource && claimSelects(claim, namespace, name) {
+			return claim, true
+		}
+	}
+	return apisv1alpha1.PermissionClaim{}, false
+}
+
+// claimAccepted returns whether the binding accepted the given claim for the object of the given
+// namespace and name. The identity hash is part of the comparison, so that accepting a claim for the
+// resource of one APIExport does not authorize writes to the resource of another APIExport with the
+// same group and resource. The resource selectors of the accepted claim must select the object, as
+// a binding can accept a claim for fewer objects than the APIExport claims.
+func claimAccepted(binding *apisv1alpha1.APIBinding, claim apisv1alpha1.PermissionClaim, namespace, name string) bool {
+	for _, accepted := range binding.Spec.PermissionClaims {
+		if accepted.PermissionClaim.Equal(claim) {
+			return accepted.State == apisv1alpha1.ClaimAccepted && claimSelects(accepted.PermissionClaim, namespace, name)
+		}
+	}
+	return false
+}
+
+// claimSelects returns whether the claim selects the object of the given namespace and name, either
+// by claiming all the objects of its resource, or with one of its resource selectors.
+func claimSelects(claim apisv1alpha1.PermissionClaim, namespace, name string) bool {
+	if claim.All {
+		return true
+	}
+	for _, selector := range claim.ResourceSelector {
+		if (selector.Name == "" || selector.Name == name) && (selector.Namespace == "" || selector.Namespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+func TestDigestWriteThroughUrl(t *testing.T) {
+	tests := map[string]struct {
+		urlPath        string
+		expectedKey    dynamiccontext.APIDomainKey
+		expectedPrefix string
+		accepted       bool
+	}{
+		"namespaced resource": {
+			urlPath:        "/services/apiexport/root:org/my-export/writethrough/api/v1/namespaces/default/configmaps",
+			expectedKey:    "root:org/my-export",
+			expectedPrefix: "/services/apiexport/root:org/my-export/writethrough",
+			accepted:       true,
+		},
+		"cluster-scoped resource": {
+			urlPath:        "/services/apiexport/root:org/my-export/writethrough/apis/rbac.authorization.k8s.io/v1/clusterroles/admin",
+			expectedKey:    "root:org/my-export",
+			expectedPrefix: "/services/apiexport/root:org/my-export/writethrough",
+			accepted:       true,
+		},
+		"apiexport virtual workspace request": {
+			urlPath: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps",
+		},
+		"missing apiexport name": {
+			urlPath: "/services/apiexport/root:org//writethrough/api/v1/configmaps",
+		},
+		"other virtual workspace": {
+			urlPath: "/services/syncer/root:org/my-export/writethrough/api/v1/configmaps",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, prefix, accepted := digestWriteThroughUrl(tc.urlPath, "/services/apiexport/")
+			require.Equal(t, tc.accepted, accepted)
+			if !tc.accepted {
+				return
+			}
+			require.Equal(t, tc.expectedKey, key)
+			require.Equal(t, tc.expectedPrefix, prefix)
+		})
+	}
+}
+
+func TestClaimAccepted(t *testing.T) {
+	claim := apisv1alpha1.PermissionClaim{
+		GroupResource: apisv1alpha1.GroupResource{Group: "example.io", Resource: "widgets"},
+		All:           true,
+		IdentityHash:  "abc",
+	}
+	selected := func(selectors ...apisv1alpha1.ResourceSelector) apisv1alpha1.PermissionClaim {
+		return apisv1alpha1.PermissionClaim{GroupResource: claim.GroupResource, ResourceSelector: selectors, IdentityHash: claim.IdentityHash}
+	}
+	binding := func(claims ...apisv1alpha1.AcceptablePermissionClaim) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{Spec: apisv1alpha1.APIBindingSpec{PermissionClaims: claims}}
+	}
+
+	tests := map[string]struct {
+		binding  *apisv1alpha1.APIBinding
+		accepted bool
+	}{
+		"accepted": {
+			binding:  binding(apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: claim, State: apisv1alpha1.ClaimAccepted}),
+			accepted: true,
+		},
+		"rejected": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: claim, State: apisv1alpha1.ClaimRejected}),
+		},
+		"not claimed": {
+			binding: binding(),
+		},
+		"accepted for another identity": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{
+				PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: claim.GroupResource, All: true, IdentityHash: "other"},
+				State:           apisv1alpha1.ClaimAccepted,
+			}),
+		},
+		"accepted for the name": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{
+				PermissionClaim: selected(apisv1alpha1.ResourceSelector{Name: "widget"}),
+				State:           apisv1alpha1.ClaimAccepted,
+			}),
+			accepted: true,
+		},
+		"accepted for the namespace": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{
+				PermissionClaim: selected(apisv1alpha1.ResourceSelector{Namespace: "other"}, apisv1alpha1.ResourceSelector{Namespace: "default"}),
+				State:           apisv1alpha1.ClaimAccepted,
+			}),
+			accepted: true,
+		},
+		"accepted for another name": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{
+				PermissionClaim: selected(apisv1alpha1.ResourceSelector{Name: "other"}),
+				State:           apisv1alpha1.ClaimAccepted,
+			}),
+		},
+		"accepted for the name in another namespace": {
+			binding: binding(apisv1alpha1.AcceptablePermissionClaim{
+				PermissionClaim: selected(apisv1alpha1.ResourceSelector{Name: "widget", Namespace: "other"}),
+				State:           apisv1alpha1.ClaimAccepted,
+			}),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.accepted, claimAccepted(tc.binding, claim, "default", "widget"))
+		})
+	}
+}
+
+func TestWriteThroughErrors(t *testing.T) {
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+		Spec: apisv1alpha1.APIExportSpec{
+			PermissionClaims: []apisv1alpha1.PermissionClaim{{
+				GroupResource:    apisv1alpha1.GroupResource{Resource: "configmaps"},
+				ResourceSelector: []apisv1alpha1.ResourceSelector{{Namespace: "default"}},
+			}},
+		},
+	}
+	h := &writeThroughHandler{
+		getAPIExport: func(clusterName, apiExportName string) (*apisv1alpha1.APIExport, error) {
+			if apiExportName != export.Name {
+				return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), apiExportName)
+			}
+			return export, nil
+		},
+		getAPIBindings: func(export *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+			return nil, nil
+		},
+	}
+
+	tests := map[string]struct {
+		verb, namespace, resource, domainKey, body string
+		expectedCode                               int
+		expectedReason                             metav1.StatusReason
+	}{
+		"unsupported verb": {
+			verb: "delete", namespace: "default", resource: "configmaps", domainKey: "root:org/my-export",
+			expectedCode: http.StatusMethodNotAllowed, expectedReason: metav1.StatusReasonMethodNotAllowed,
+		},
+		"unknown APIExport": {
+			verb: "create", namespace: "default", resource: "configmaps", domainKey: "root:org/other",
+			expectedCode: http.StatusNotFound, expectedReason: metav1.StatusReasonNotFound,
+		},
+		"invalid body": {
+			verb: "create", namespace: "default", resource: "configmaps", domainKey: "root:org/my-export", body: "{",
+			expectedCode: http.StatusBadRequest, expectedReason: metav1.StatusReasonBadRequest,
+		},
+		"unclaimed resource": {
+			verb: "create", namespace: "default", resource: "secrets", domainKey: "root:org/my-export",
+			body:         `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s"}}`,
+			expectedCode: http.StatusForbidden, expectedReason: metav1.StatusReasonForbidden,
+		},
+		"object not selected by the claim": {
+			verb: "create", namespace: "other", resource: "configmaps", domainKey: "root:org/my-export",
+			body:         `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}}`,
+			expectedCode: http.StatusForbidden, expectedReason: metav1.StatusReasonForbidden,
+		},
+		"object selected by the claim": {
+			verb: "create", namespace: "default", resource: "configmaps", domainKey: "root:org/my-export",
+			body:         `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}}`,
+			expectedCode: http.StatusOK,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/"+tc.namespace+"/"+tc.resource, strings.NewReader(tc.body))
+			ctx := genericapirequest.WithRequestInfo(req.Context(), &genericapirequest.RequestInfo{
+				IsResourceRequest: true,
+				Verb:              tc.verb,
+				APIVersion:        "v1",
+				Namespace:         tc.namespace,
+				Resource:          tc.resource,
+			})
+			ctx = dynamiccontext.WithAPIDomainKey(ctx, dynamiccontext.APIDomainKey(tc.domainKey))
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req.WithContext(ctx))
+
+			require.Equal(t, tc.expectedCode, rw.Code, rw.Body.String())
+			if tc.expectedCode == http.StatusOK {
+				return
+			}
+			var status metav1.Status
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+			require.Equal(t, tc.expectedReason, status.Reason)
+		})
+	}
+}
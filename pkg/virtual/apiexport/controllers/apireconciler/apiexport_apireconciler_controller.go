@@ -58,6 +58,7 @@ func NewAPIReconciler(
 	apiExportInformer apisv1alpha1informers.APIExportClusterInformer,
 	createAPIDefinition CreateAPIDefinitionFunc,
 	createAPIBindingAPIDefinition func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error),
+	createLogicalClusterAPIDefinition func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error),
 ) (*APIReconciler, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
 
@@ -72,8 +73,9 @@ func NewAPIReconciler(
 
 		queue: queue,
 
-		createAPIDefinition:           createAPIDefinition,
-		createAPIBindingAPIDefinition: createAPIBindingAPIDefinition,
+		createAPIDefinition:               createAPIDefinition,
+		createAPIBindingAPIDefinition:     createAPIBindingAPIDefinition,
+		createLogicalClusterAPIDefinition: createLogicalClusterAPIDefinition,
 
 		apiSets: map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet{},
 	}
@@ -124,8 +126,9 @@ type APIReconciler struct {
 
 	queue workqueue.RateLimitingInterface
 
-	createAPIDefinition           CreateAPIDefinitionFunc
-	createAPIBindingAPIDefinition func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error)
+	createAPIDefinition               CreateAPIDefinitionFunc
+	createAPIBindingAPIDefinition     func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error)
+	createLogicalClusterAPIDefinition func(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) (apidefinition.APIDefinition, error)
 
 	mutex   sync.RWMutex // protects the map, not the values!
 	apiSets map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet
@@ -33,6 +33,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/apis"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/permissionclaims"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/schemas"
 	apiexportbuiltin "github.com/kcp-dev/kcp/pkg/virtual/apiexport/schemas/builtin"
@@ -239,6 +240,20 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 		newGVRs = append(newGVRs, gvrString(gvr))
 	}
 
+	// the metadata of the consumer workspaces is exposed to the provider, unless shadowed by an exported resource
+	if _, found := apiResourceSchemas[corev1alpha1.Resource("logicalclusters")]; !found {
+		d, err := c.createLogicalClusterAPIDefinition(ctx, clusterName, apiExport.Name)
+		if err != nil {
+			logger.Error(err, "error creating api definition for logicalclusters")
+		}
+
+		gvr := corev1alpha1.SchemeGroupVersion.WithResource("logicalclusters")
+		newSet[gvr] = apiResourceSchemaApiDefinition{
+			APIDefinition: d,
+		}
+		newGVRs = append(newGVRs, gvrString(gvr))
+	}
+
 	// cleanup old definitions
 	removedGVRs := []string{}
 	for gvr, oldDef := range oldSet {
@@ -29,29 +29,36 @@ import (
 
 var ApisKcpDevSchemas = map[string]*apisv1alpha1.APIResourceSchema{}
 
+// LogicalClusterSchema is the schema of the logicalclusters resource, projecting the metadata of the
+// consumer workspaces to the API providers.
+var LogicalClusterSchema *apisv1alpha1.APIResourceSchema
+
 func init() {
 	for _, resource := range []string{"apibindings", "apiresourceschemas", "apiexports"} {
-		// get APIBindings resource schema
-		crd := apiextensionsv1.CustomResourceDefinition{}
-		if err := configcrds.Unmarshal(fmt.Sprintf("apis.kcp.io_%s.yaml", resource), &crd); err != nil {
-			panic(fmt.Sprintf("failed to unmarshal apibindings resource: %v", err))
-		}
-		schema, err := apisv1alpha1.CRDToAPIResourceSchema(&crd, "crd")
-		if err != nil {
-			panic(fmt.Sprintf("failed to convert CRD %s.%s to APIResourceSchema: %v", crd.Spec.Names.Plural, crd.Spec.Group, err))
-		}
-		bs, err := json.Marshal(&apiextensionsv1.JSONSchemaProps{
-			Type:                   "object",
-			XPreserveUnknownFields: pointer.BoolPtr(true),
-		})
-		if err != nil {
-			panic(fmt.Sprintf("failed to marshal JSONSchemaProps: %v", err))
-		}
-		for i := range schema.Spec.Versions {
-			v := &schema.Spec.Versions[i]
-			v.Schema.Raw = bs // wipe schemas. We don't want validation here.
-		}
-
-		ApisKcpDevSchemas[resource] = schema
+		ApisKcpDevSchemas[resource] = loadSchema(fmt.Sprintf("apis.kcp.io_%s.yaml", resource))
+	}
+	LogicalClusterSchema = loadSchema("core.kcp.io_logicalclusters.yaml")
+}
+
+func loadSchema(fileName string) *apisv1alpha1.APIResourceSchema {
+	crd := apiextensionsv1.CustomResourceDefinition{}
+	if err := configcrds.Unmarshal(fileName, &crd); err != nil {
+		panic(fmt.Sprintf("failed to unmarshal %s: %v", fileName, err))
+	}
+	schema, err := apisv1alpha1.CRDToAPIResourceSchema(&crd, "crd")
+	if err != nil {
+		panic(fmt.Sprintf("failed to convert CRD %s.%s to APIResourceSchema: %v", crd.Spec.Names.Plural, crd.Spec.Group, err))
+	}
+	bs, err := json.Marshal(&apiextensionsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: pointer.BoolPtr(true),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal JSONSchemaProps: %v", err))
+	}
+	for i := range schema.Spec.Versions {
+		v := &schema.Spec.Versions[i]
+		v.Schema.Raw = bs // wipe schemas. We don't want validation here.
 	}
+	return schema
 }
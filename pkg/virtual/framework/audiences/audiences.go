@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audiences scopes the tokens to virtual workspaces, by their audiences.
+//
+// A token bound to the audience of a virtual workspace, i.e., the path of its URL, e.g.,
+// /services/apiexport/root:org/my-export for an APIExport virtual workspace, is only accepted
+// by that virtual workspace, and rejected by the workspace API of the shards as well as by
+// the other virtual workspaces. Such a token can be requested with a TokenRequest, e.g.:
+//
+//	kubectl create token my-controller --audience /services/apiexport/root:org/my-export
+//
+// The tokens bound to the API audiences of the server are accepted everywhere, as before.
+package audiences
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+// rootSegments are the numbers of the path segments following the name of a virtual workspace
+// which identify one of its roots, e.g., <path>/<export> for the APIExport virtual workspace.
+// The virtual workspaces without entry have no audience, i.e., they only accept the tokens bound
+// to the API audiences. TestVirtualWorkspaceAudiences in pkg/virtual/options checks that every
+// registered virtual workspace has an entry.
+var rootSegments = map[string]int{
+	"apiexport":              2,
+	"clusterworkspaces":      1,
+	"initializingworkspaces": 1,
+	"syncer":                 3,
+	"upsyncer":               3,
+}
+
+// ForPath returns the audience of the tokens accepted for the requests to the given path of the
+// virtual workspaces served under rootPathPrefix, i.e., the root of the virtual workspace serving
+// the path. For instance, the audience for the path /services/apiexport/root:org/my-export/clusters/*/api
+// is /services/apiexport/root:org/my-export. The parents of the root, e.g., /services/apiexport/root:org,
+// are not accepted. The path is cleaned first, so that it cannot escape the root with dot segments.
+func ForPath(rootPathPrefix, urlPath string) authenticator.Audiences {
+	rootPathPrefix = path.Clean("/" + rootPathPrefix)
+	urlPath = path.Clean("/" + urlPath)
+	if !strings.HasPrefix(urlPath, rootPathPrefix+"/") {
+		return nil
+	}
+
+	segments := strings.Split(strings.TrimPrefix(urlPath, rootPathPrefix+"/"), "/")
+	n, found := rootSegments[segments[0]]
+	if !found || len(segments) < 1+n {
+		return nil
+	}
+	return authenticator.Audiences{path.Join(append([]string{rootPathPrefix}, segments[:1+n]...)...)}
+}
+
+// WithAudiences returns an authenticator accepting the tokens bound to one of the API audiences,
+// or to one of the audiences returned by audiencesFor for the request. It returns the delegate
+// authenticator as is if there are no API audiences, i.e., if the tokens are not audience-scoped.
+//
+// As the authentication filter of the generic API server rejects the tokens that are not bound
+// to one of its API audiences, the API audiences must be removed from its AuthenticationInfo.
+func WithAudiences(delegate authenticator.Request, apiAudiences authenticator.Audiences, audiencesFor func(req *http.Request) authenticator.Audiences) authenticator.Request {
+	if delegate == nil || len(apiAudiences) == 0 {
+		return delegate
+	}
+
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		audiences := apiAudiences
+		if extra := audiencesFor(req); len(extra) > 0 {
+			audiences = make(authenticator.Audiences, 0, len(apiAudiences)+len(extra))
+			audiences = append(audiences, apiAudiences...)
+			audiences = append(audiences, extra...)
+		}
+		req = req.WithContext(authenticator.WithAudiences(req.Context(), audiences))
+
+		resp, ok, err := delegate.AuthenticateRequest(req)
+		if err != nil || !ok {
+			return resp, ok, err
+		}
+		if len(resp.Audiences) > 0 && len(audiences.Intersect(resp.Audiences)) == 0 {
+			return nil, false, fmt.Errorf("token audiences %q are invalid for the target audiences %q", resp.Audiences, audiences)
+		}
+		return resp, ok, err
+	})
+}
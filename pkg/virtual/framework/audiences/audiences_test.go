@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audiences
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestForPath(t *testing.T) {
+	for _, tc := range []struct {
+		rootPathPrefix, path string
+		want                 authenticator.Audiences
+	}{
+		{"/services", "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps", authenticator.Audiences{"/services/apiexport/root:org/my-export"}},
+		{"/services/", "/services/apiexport/root:org/my-export/", authenticator.Audiences{"/services/apiexport/root:org/my-export"}},
+		{"/services", "/services/syncer/root:org/my-target/uid/clusters/*/api", authenticator.Audiences{"/services/syncer/root:org/my-target/uid"}},
+		{"/services", "/services/initializingworkspaces/root:org:my-initializer/clusters/*/apis", authenticator.Audiences{"/services/initializingworkspaces/root:org:my-initializer"}},
+		{"/services", "/services/apiexport/root:org/other-export/../my-export/clusters/*/api", authenticator.Audiences{"/services/apiexport/root:org/my-export"}},
+		{"/services", "/services/apiexport/root:org", nil},
+		{"/services", "/services/apiexport", nil},
+		{"/services", "/services/syncer/root:org/my-target", nil},
+		{"/services", "/services/unknown/root:org/foo", nil},
+		{"/services", "/services/../clusters/root/api", nil},
+		{"/services", "/clusters/root/api", nil},
+		{"/services", "/servicesfoo/bar", nil},
+	} {
+		require.Equal(t, tc.want, ForPath(tc.rootPathPrefix, tc.path), tc.path)
+	}
+}
+
+// tokenAuthenticator mimics the service account token authenticator: the tokens are accepted if
+// their audiences intersect with the audiences of the request.
+type tokenAuthenticator map[string]authenticator.Audiences
+
+func (a tokenAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	tokenAudiences, found := a[req.Header.Get("Authorization")]
+	if !found {
+		return nil, false, nil
+	}
+	audiences, _ := authenticator.AudiencesFrom(req.Context())
+	if len(audiences.Intersect(tokenAudiences)) == 0 {
+		return nil, false, nil
+	}
+	return &authenticator.Response{User: &user.DefaultInfo{Name: "controller"}, Audiences: audiences.Intersect(tokenAudiences)}, true, nil
+}
+
+func TestWithAudiences(t *testing.T) {
+	delegate := tokenAuthenticator{
+		"api":    {"https://kcp.default.svc"},
+		"export": {"/services/apiexport/root:org/my-export"},
+		"other":  {"/services/apiexport/root:org/other-export"},
+		"parent": {"/services/apiexport/root:org"},
+	}
+	auth := WithAudiences(delegate, authenticator.Audiences{"https://kcp.default.svc"}, func(req *http.Request) authenticator.Audiences {
+		return ForPath("/services", req.URL.Path)
+	})
+
+	for _, tc := range []struct {
+		token, path string
+		want        bool
+	}{
+		{token: "api", path: "/clusters/root:org/api/v1/configmaps", want: true},
+		{token: "api", path: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps", want: true},
+		{token: "export", path: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps", want: true},
+		{token: "export", path: "/clusters/root:org/api/v1/configmaps", want: false},
+		{token: "export", path: "/services/apiexport/root:org/other-export/clusters/*/api/v1/configmaps", want: false},
+		{token: "other", path: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps", want: false},
+		{token: "parent", path: "/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps", want: false},
+		{token: "export", path: "/services/apiexport/root:org/my-export/../other-export/clusters/*/api/v1/configmaps", want: false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Authorization", tc.token)
+		_, ok, _ := auth.AuthenticateRequest(req)
+		require.Equal(t, tc.want, ok, "token %q for path %q", tc.token, tc.path)
+	}
+
+	t.Log("The authenticator is not wrapped without API audiences")
+	require.Equal(t, authenticator.Request(delegate), WithAudiences(delegate, nil, nil))
+}
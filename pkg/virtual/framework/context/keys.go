@@ -36,3 +36,20 @@ func VirtualWorkspaceNameFrom(ctx context.Context) (string, bool) {
 	wcn, hasVirtualWorkspaceName := ctx.Value(virtualWorkspaceNameKey).(string)
 	return wcn, hasVirtualWorkspaceName
 }
+
+type virtualWorkspaceAudiencesKeyType string
+
+// virtualWorkspaceAudiencesKey is a context key that contains the audiences of the tokens
+// accepted by the virtual workspace serving a given request, in addition to the API audiences.
+const virtualWorkspaceAudiencesKey virtualWorkspaceAudiencesKeyType = "VirtualWorkspaceAudiences"
+
+// WithVirtualWorkspaceAudiences adds the audiences of the VirtualWorkspace to the context.
+func WithVirtualWorkspaceAudiences(ctx context.Context, audiences []string) context.Context {
+	return context.WithValue(ctx, virtualWorkspaceAudiencesKey, audiences)
+}
+
+// VirtualWorkspaceAudiencesFrom retrieves the audiences of the VirtualWorkspace from the context, if any.
+func VirtualWorkspaceAudiencesFrom(ctx context.Context) []string {
+	audiences, _ := ctx.Value(virtualWorkspaceAudiencesKey).([]string)
+	return audiences
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// withBuiltInProtobuf wraps the negotiated serializer of a resource, so that the protobuf
+// media type is supported when the resource is a built-in type of the client-go scheme,
+// e.g., claimed configmaps or secrets. Objects served by the virtual workspaces are
+// unstructured, so they are converted to their typed counterparts before being encoded,
+// and back to unstructured after being decoded.
+// Resources of other groups are left unchanged, as CRDs do not support protobuf.
+func withBuiltInProtobuf(delegate runtime.NegotiatedSerializer, gk schema.GroupKind) runtime.NegotiatedSerializer {
+	if !kubernetesscheme.Scheme.IsGroupRegistered(gk.Group) {
+		return delegate
+	}
+	return &builtInProtobufNegotiatedSerializer{NegotiatedSerializer: delegate}
+}
+
+type builtInProtobufNegotiatedSerializer struct {
+	runtime.NegotiatedSerializer
+}
+
+func (s *builtInProtobufNegotiatedSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	supported := s.NegotiatedSerializer.SupportedMediaTypes()
+	mediaTypes := make([]runtime.SerializerInfo, 0, len(supported)+1)
+	for _, info := range supported {
+		if info.MediaType == runtime.ContentTypeProtobuf {
+			continue
+		}
+		mediaTypes = append(mediaTypes, info)
+	}
+
+	serializer := &typedProtobufSerializer{
+		Serializer: protobuf.NewSerializer(kubernetesscheme.Scheme, kubernetesscheme.Scheme),
+	}
+	raw := &typedProtobufSerializer{
+		Serializer: protobuf.NewRawSerializer(kubernetesscheme.Scheme, kubernetesscheme.Scheme),
+	}
+	return append(mediaTypes, runtime.SerializerInfo{
+		MediaType:        runtime.ContentTypeProtobuf,
+		MediaTypeType:    "application",
+		MediaTypeSubType: "vnd.kubernetes.protobuf",
+		Serializer:       serializer,
+		StreamSerializer: &runtime.StreamSerializerInfo{
+			Serializer: raw,
+			Framer:     protobuf.LengthDelimitedFramer,
+		},
+	})
+}
+
+// typedProtobufSerializer encodes unstructured objects of the client-go scheme types into protobuf,
+// by converting them to their typed counterparts first, and decodes protobuf into unstructured
+// objects, by converting the decoded typed objects.
+type typedProtobufSerializer struct {
+	runtime.Serializer
+}
+
+func (s *typedProtobufSerializer) Encode(obj runtime.Object, w io.Writer) error {
+	typed, err := toTyped(obj)
+	if err != nil {
+		return err
+	}
+	return s.Serializer.Encode(typed, w)
+}
+
+func (s *typedProtobufSerializer) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	u, ok := into.(runtime.Unstructured)
+	if !ok {
+		return s.Serializer.Decode(data, defaults, into)
+	}
+
+	typed, gvk, err := s.Serializer.Decode(data, defaults, nil)
+	if err != nil {
+		return nil, gvk, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return nil, gvk, fmt.Errorf("unable to convert %s to its unstructured form: %w", gvk, err)
+	}
+	u.SetUnstructuredContent(content)
+	u.GetObjectKind().SetGroupVersionKind(*gvk)
+	return u, gvk, nil
+}
+
+func (s *typedProtobufSerializer) Identifier() runtime.Identifier {
+	return runtime.Identifier("typed-" + string(s.Serializer.Identifier()))
+}
+
+func toTyped(obj runtime.Object) (runtime.Object, error) {
+	var content map[string]interface{}
+	switch u := obj.(type) {
+	case *unstructured.Unstructured:
+		content = u.UnstructuredContent()
+	case *unstructured.UnstructuredList:
+		content = u.UnstructuredContent()
+	default:
+		return obj, nil
+	}
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	typed, err := kubernetesscheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode %s to protobuf: %w", gvk, err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+		return nil, fmt.Errorf("unable to convert %s to its typed form: %w", gvk, err)
+	}
+	return typed, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestWithBuiltInProtobuf(t *testing.T) {
+	delegate := serializer.NewCodecFactory(runtime.NewScheme()).WithoutConversion()
+
+	t.Run("custom resources are left unchanged", func(t *testing.T) {
+		require.Equal(t, delegate, withBuiltInProtobuf(delegate, schema.GroupKind{Group: "custom", Kind: "Custom"}))
+	})
+
+	t.Run("built-in resources are encoded into protobuf", func(t *testing.T) {
+		negotiated := withBuiltInProtobuf(delegate, schema.GroupKind{Kind: "ConfigMap"})
+
+		info, ok := runtime.SerializerInfoForMediaType(negotiated.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+		require.True(t, ok, "protobuf should be supported")
+		require.NotNil(t, info.StreamSerializer)
+
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "cm",
+				"namespace": "default",
+			},
+			"data": map[string]interface{}{
+				"foo": "bar",
+			},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, info.Serializer.Encode(obj, &buf))
+
+		decoded := &corev1.ConfigMap{}
+		_, _, err := protobuf.NewSerializer(kubernetesscheme.Scheme, kubernetesscheme.Scheme).Decode(buf.Bytes(), nil, decoded)
+		require.NoError(t, err)
+		require.Equal(t, "cm", decoded.Name)
+		require.Equal(t, map[string]string{"foo": "bar"}, decoded.Data)
+	})
+
+	t.Run("built-in resources are decoded from protobuf into unstructured", func(t *testing.T) {
+		negotiated := withBuiltInProtobuf(delegate, schema.GroupKind{Kind: "ConfigMap"})
+
+		info, ok := runtime.SerializerInfoForMediaType(negotiated.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+		require.True(t, ok, "protobuf should be supported")
+
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "cm",
+				"namespace": "default",
+			},
+			"data": map[string]interface{}{
+				"foo": "bar",
+			},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, info.Serializer.Encode(obj, &buf))
+
+		decoded, gvk, err := info.Serializer.Decode(buf.Bytes(), nil, &unstructured.Unstructured{})
+		require.NoError(t, err)
+		require.Equal(t, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, *gvk)
+
+		u, ok := decoded.(*unstructured.Unstructured)
+		require.True(t, ok, "expected an unstructured object, got %T", decoded)
+		require.Equal(t, "v1", u.GetAPIVersion())
+		require.Equal(t, "ConfigMap", u.GetKind())
+		require.Equal(t, "cm", u.GetName())
+		require.Equal(t, "default", u.GetNamespace())
+		data, _, err := unstructured.NestedStringMap(u.Object, "data")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"foo": "bar"}, data)
+	})
+}
@@ -199,15 +199,16 @@ func CreateServingInfoFor(genericConfig genericapiserver.CompletedConfig, apiRes
 
 	clusterScoped := apiResourceSchema.Spec.Scope == apiextensionsv1.ClusterScoped
 
-	// CRDs explicitly do not support protobuf, but some objects returned by the API server do
-	negotiatedSerializer := apiextensionsapiserver.NewUnstructuredNegotiatedSerializer(
+	// CRDs explicitly do not support protobuf, but some objects returned by the API server do,
+	// as well as the built-in types served by virtual workspaces, e.g., claimed configmaps.
+	negotiatedSerializer := withBuiltInProtobuf(apiextensionsapiserver.NewUnstructuredNegotiatedSerializer(
 		typer,
 		creator,
 		safeConverter,
 		map[string]*structuralschema.Structural{gvk.Version: structuralSchema},
 		gvk.GroupKind(),
 		false,
-	)
+	), gvk.GroupKind())
 	supportedMediaTypes := negotiatedSerializer.SupportedMediaTypes()
 	standardSerializers := make([]runtime.SerializerInfo, 0, len(supportedMediaTypes))
 	for _, s := range supportedMediaTypes {
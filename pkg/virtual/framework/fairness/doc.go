@@ -0,0 +1,26 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fairness provides a virtual workspace decorator that limits the number of
+// in-flight requests per priority level, e.g., syncers, API providers and interactive
+// users, so that background virtual workspace traffic cannot starve the other levels.
+//
+// The priority level is propagated to the shard with the requests the virtual workspaces
+// forward, where they are limited again before being served. Together with a shard
+// max-in-flight limit above the sum of the priority levels, this keeps seats of the shard
+// for direct workspace API access. Direct workspace requests are not classified by these
+// priority levels, as kcp does not serve the flow control API of the kube API server.
+package fairness
@@ -0,0 +1,327 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/sets"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericfilters "k8s.io/apiserver/pkg/server/filters"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+// PriorityLevel classifies the virtual workspace traffic.
+type PriorityLevel string
+
+const (
+	// Syncers is the priority level of the syncer and upsyncer virtual workspaces.
+	Syncers PriorityLevel = "syncers"
+	// Providers is the priority level of the virtual workspaces used by API provider
+	// controllers, e.g., the apiexport and initializingworkspaces ones.
+	Providers PriorityLevel = "providers"
+	// Interactive is the priority level of the virtual workspaces used by end users,
+	// e.g., the workspaces one.
+	Interactive PriorityLevel = "interactive"
+)
+
+// Scope is where a limiter accounts requests.
+type Scope string
+
+const (
+	// VirtualWorkspaces accounts the requests served by the virtual workspaces.
+	VirtualWorkspaces Scope = "virtual-workspaces"
+	// Shard accounts the requests that the virtual workspaces forward to the shard, so that
+	// they cannot take all the max-in-flight seats of the shard from direct workspace requests.
+	Shard Scope = "shard"
+)
+
+// PriorityLevelHeader carries the priority level of a virtual workspace request to the shard
+// the virtual workspace forwards it to. A client setting it on its own requests can only
+// subject itself to the limits of that priority level.
+const PriorityLevelHeader = "X-Kcp-Virtual-Workspace-Priority-Level"
+
+type priorityLevelContextKeyType int
+
+const priorityLevelContextKey priorityLevelContextKeyType = iota
+
+// WithPriorityLevelContext returns a context with the priority level of the virtual workspace request.
+func WithPriorityLevelContext(ctx context.Context, level PriorityLevel) context.Context {
+	return context.WithValue(ctx, priorityLevelContextKey, level)
+}
+
+// PriorityLevelFrom returns the priority level of the virtual workspace request, if any.
+func PriorityLevelFrom(ctx context.Context) (PriorityLevel, bool) {
+	level, ok := ctx.Value(priorityLevelContextKey).(PriorityLevel)
+	return level, ok
+}
+
+// longRunningRequestCheck mirrors the default long-running request check of the kube API server.
+var longRunningRequestCheck = genericfilters.BasicLongRunningRequestCheck(
+	sets.NewString("watch", "proxy"),
+	sets.NewString("attach", "exec", "proxy", "log", "portforward"),
+)
+
+// Options are the in-flight request limits per priority level of the virtual workspaces.
+type Options struct {
+	// MaxRequestsInFlight is the maximum number of non-long-running requests in flight per priority level.
+	// Zero disables the limit for the priority level.
+	MaxRequestsInFlight map[string]int
+	// QueueTimeout is how long a request waits for a seat in its priority level before being rejected.
+	QueueTimeout time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		MaxRequestsInFlight: map[string]int{
+			string(Syncers):     200,
+			string(Providers):   200,
+			string(Interactive): 100,
+		},
+		QueueTimeout: time.Second,
+	}
+}
+
+func (o *Options) AddFlags(flags *pflag.FlagSet, prefix string) {
+	if o == nil {
+		return
+	}
+	flags.StringToIntVar(&o.MaxRequestsInFlight, prefix+"max-requests-in-flight", o.MaxRequestsInFlight,
+		fmt.Sprintf("Maximum number of non-long-running requests in flight per priority level of the virtual workspaces. Priority levels are %s. Set a level to 0 to disable its limit.", priorityLevels()))
+	flags.DurationVar(&o.QueueTimeout, prefix+"queue-timeout", o.QueueTimeout, "How long a virtual workspace request waits for a seat in its priority level before being rejected.")
+}
+
+func (o *Options) Validate(flagPrefix string) []error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	for level, limit := range o.MaxRequestsInFlight {
+		switch PriorityLevel(level) {
+		case Syncers, Providers, Interactive:
+		default:
+			errs = append(errs, fmt.Errorf("--%smax-requests-in-flight has unknown priority level %q, must be one of %s", flagPrefix, level, priorityLevels()))
+		}
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("--%smax-requests-in-flight for priority level %q cannot be negative", flagPrefix, level))
+		}
+	}
+	if o.QueueTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--%squeue-timeout cannot be negative", flagPrefix))
+	}
+	return errs
+}
+
+// TotalMaxRequestsInFlight returns the number of seats of all the priority levels, and whether
+// one of the priority levels is unlimited.
+func (o *Options) TotalMaxRequestsInFlight() (total int, unlimited bool) {
+	for _, level := range []PriorityLevel{Syncers, Providers, Interactive} {
+		limit := o.MaxRequestsInFlight[string(level)]
+		if limit == 0 {
+			unlimited = true
+		}
+		total += limit
+	}
+	return total, unlimited
+}
+
+func priorityLevels() string {
+	return fmt.Sprintf("%q", []string{string(Syncers), string(Providers), string(Interactive)})
+}
+
+// Limiter limits the requests in flight of the virtual workspaces, per priority level.
+type Limiter struct {
+	scope        Scope
+	queueTimeout time.Duration
+	seats        map[PriorityLevel]chan struct{}
+}
+
+// NewLimiter returns a limiter with the in-flight request limits of the options.
+func NewLimiter(scope Scope, opts Options) *Limiter {
+	l := &Limiter{
+		scope:        scope,
+		queueTimeout: opts.QueueTimeout,
+		seats:        map[PriorityLevel]chan struct{}{},
+	}
+	for level, limit := range opts.MaxRequestsInFlight {
+		if limit > 0 {
+			l.seats[PriorityLevel(level)] = make(chan struct{}, limit)
+		}
+	}
+	return l
+}
+
+// WithPriorityLevel decorates the virtual workspace so that its non-long-running requests
+// are accounted against the given priority level of the limiter.
+func (l *Limiter) WithPriorityLevel(vw framework.VirtualWorkspace, level PriorityLevel) framework.VirtualWorkspace {
+	return &prioritizedVirtualWorkspace{
+		VirtualWorkspace: vw,
+		limiter:          l,
+		level:            level,
+	}
+}
+
+type prioritizedVirtualWorkspace struct {
+	framework.VirtualWorkspace
+	limiter *Limiter
+	level   PriorityLevel
+}
+
+func (vw *prioritizedVirtualWorkspace) Register(name string, rootAPIServerConfig genericapiserver.CompletedConfig, delegateAPIServer genericapiserver.DelegationTarget) (genericapiserver.DelegationTarget, error) {
+	target, err := vw.VirtualWorkspace.Register(name, rootAPIServerConfig, delegateAPIServer)
+	if err != nil {
+		return nil, err
+	}
+	return &prioritizedDelegationTarget{DelegationTarget: target, name: name, limiter: vw.limiter, level: vw.level}, nil
+}
+
+type prioritizedDelegationTarget struct {
+	genericapiserver.DelegationTarget
+	name    string
+	limiter *Limiter
+	level   PriorityLevel
+}
+
+func (t *prioritizedDelegationTarget) UnprotectedHandler() http.Handler {
+	delegate := t.DelegationTarget.UnprotectedHandler()
+	if delegate == nil {
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if name, found := virtualcontext.VirtualWorkspaceNameFrom(req.Context()); !found || name != t.name {
+			// requests for other virtual workspaces are passed through the delegation chain
+			delegate.ServeHTTP(w, req)
+			return
+		}
+		// the priority level is propagated to the requests forwarded to the shard
+		req = req.WithContext(WithPriorityLevelContext(req.Context(), t.level))
+		if isLongRunning(req) {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		t.limiter.serve(w, req, t.level, delegate)
+	})
+}
+
+func (l *Limiter) serve(w http.ResponseWriter, req *http.Request, level PriorityLevel, delegate http.Handler) {
+	release, ok := l.acquire(req, level)
+	if !ok {
+		rejectedRequests.WithLabelValues(string(l.scope), string(level)).Inc()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, fmt.Sprintf("Too many requests in flight for the %s priority level, please try again later.", level), http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	delegate.ServeHTTP(w, req)
+}
+
+// WithPriorityLevelRoundTripper sets the priority level of the virtual workspace request being
+// served on the requests the virtual workspace forwards to the shard.
+func WithPriorityLevelRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if level, ok := PriorityLevelFrom(req.Context()); ok {
+			req = utilnet.CloneRequest(req)
+			req.Header.Set(PriorityLevelHeader, string(level))
+		}
+		return rt.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithForwardedRequestLimits accounts the non-long-running requests that the virtual workspaces
+// forward to the shard against their priority level. It runs after authentication, and hence
+// after the max-in-flight filter of the shard. The limiter should therefore not queue, so that
+// forwarded requests do not hold seats of the shard while waiting. Bounding the forwarded requests
+// leaves the remaining max-in-flight seats of the shard to direct workspace requests.
+func (l *Limiter) WithForwardedRequestLimits(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		level := PriorityLevel(req.Header.Get(PriorityLevelHeader))
+		switch level {
+		case Syncers, Providers, Interactive:
+		default:
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if isLongRunning(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		l.serve(w, req, level, handler)
+	})
+}
+
+func isLongRunning(req *http.Request) bool {
+	if info, ok := genericapirequest.RequestInfoFrom(req.Context()); ok && longRunningRequestCheck(req, info) {
+		return true
+	}
+	// non-resource watch endpoints, like the apiexport claims one
+	return req.URL.Query().Get("watch") == "true"
+}
+
+// acquire waits for a seat in the priority level, until the queue timeout or the request is done.
+func (l *Limiter) acquire(req *http.Request, level PriorityLevel) (release func(), ok bool) {
+	seats, limited := l.seats[level]
+	if !limited {
+		return func() {}, true
+	}
+
+	release = func() {
+		<-seats
+		inflightRequests.WithLabelValues(string(l.scope), string(level)).Dec()
+	}
+
+	select {
+	case seats <- struct{}{}:
+		inflightRequests.WithLabelValues(string(l.scope), string(level)).Inc()
+		return release, true
+	default:
+	}
+
+	if l.queueTimeout == 0 {
+		return nil, false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case seats <- struct{}{}:
+		inflightRequests.WithLabelValues(string(l.scope), string(level)).Inc()
+		return release, true
+	case <-timer.C:
+		return nil, false
+	case <-req.Context().Done():
+		return nil, false
+	}
+}
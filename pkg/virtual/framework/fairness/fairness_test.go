@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterAcquire(t *testing.T) {
+	limiter := NewLimiter(VirtualWorkspaces, Options{
+		MaxRequestsInFlight: map[string]int{
+			string(Syncers):   1,
+			string(Providers): 0,
+		},
+		QueueTimeout: 10 * time.Millisecond,
+	})
+	req := httptest.NewRequest("GET", "/api/v1/configmaps", nil)
+
+	release, ok := limiter.acquire(req, Syncers)
+	require.True(t, ok)
+
+	_, ok = limiter.acquire(req, Syncers)
+	require.False(t, ok, "the syncers priority level should be saturated")
+
+	for i := 0; i < 10; i++ {
+		_, ok := limiter.acquire(req, Providers)
+		require.True(t, ok, "the providers priority level should not be limited")
+	}
+
+	release()
+	release, ok = limiter.acquire(req, Syncers)
+	require.True(t, ok, "a seat should have been released")
+	release()
+}
+
+func TestOptionsValidate(t *testing.T) {
+	require.Empty(t, NewOptions().Validate("virtual-workspaces-"))
+
+	opts := NewOptions()
+	opts.MaxRequestsInFlight["batch"] = 10
+	opts.MaxRequestsInFlight[string(Syncers)] = -1
+	require.Len(t, opts.Validate("virtual-workspaces-"), 2)
+}
+
+func TestWithForwardedRequestLimits(t *testing.T) {
+	limiter := NewLimiter(Shard, Options{
+		MaxRequestsInFlight: map[string]int{string(Syncers): 1},
+	})
+	release, ok := limiter.acquire(httptest.NewRequest("GET", "/api/v1/configmaps", nil), Syncers)
+	require.True(t, ok)
+	defer release()
+
+	handler := limiter.WithForwardedRequestLimits(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/configmaps", nil)
+	req.Header.Set(PriorityLevelHeader, string(Syncers))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, "forwarded syncer requests should be rejected without queueing")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/configmaps", nil))
+	require.Equal(t, http.StatusOK, rec.Code, "direct requests should not be limited")
+}
+
+func TestWithPriorityLevelRoundTripper(t *testing.T) {
+	var header string
+	rt := WithPriorityLevelRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header.Get(PriorityLevelHeader)
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/configmaps", nil)
+	_, err := rt.RoundTrip(req.WithContext(WithPriorityLevelContext(req.Context(), Providers)))
+	require.NoError(t, err)
+	require.Equal(t, string(Providers), header)
+	require.Empty(t, req.Header.Get(PriorityLevelHeader), "the original request should not be modified")
+
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Empty(t, header)
+}
+
+func TestOptionsTotalMaxRequestsInFlight(t *testing.T) {
+	total, unlimited := NewOptions().TotalMaxRequestsInFlight()
+	require.Equal(t, 500, total)
+	require.False(t, unlimited)
+
+	opts := NewOptions()
+	opts.MaxRequestsInFlight[string(Interactive)] = 0
+	_, unlimited = opts.TotalMaxRequestsInFlight()
+	require.True(t, unlimited)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"sync"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	inflightRequests = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           "virtual_workspace_inflight_requests",
+			Help:           "Number of non-long-running virtual workspace requests in flight, per scope and priority level. The shard scope counts the requests forwarded by the virtual workspaces to the shard.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"scope", "priority_level"},
+	)
+
+	rejectedRequests = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "virtual_workspace_rejected_requests_total",
+			Help:           "Number of virtual workspace requests rejected because their priority level had no seat available, per scope and priority level.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"scope", "priority_level"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(inflightRequests)
+		legacyregistry.MustRegister(rejectedRequests)
+	})
+}
+
+func init() {
+	Register()
+}
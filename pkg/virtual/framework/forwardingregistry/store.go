@@ -22,6 +22,7 @@ import (
 	"net/http"
 
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
@@ -141,23 +143,42 @@ func DefaultDynamicDelegatedStoreFuncs(
 		return obj, deletedImmediately, nil
 	}
 	s.CollectionDeleterFunc = func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *metainternalversion.ListOptions) (runtime.Object, error) {
-		delegate, err := client(ctx)
+		var v1ListOptions metav1.ListOptions
+		err := metainternalversion.Convert_internalversion_ListOptions_To_v1_ListOptions(listOptions, &v1ListOptions, nil)
 		if err != nil {
 			return nil, err
 		}
 
-		deleter, err := dynamicextension.NewDeleterWithResults(delegate)
+		cluster, err := genericapirequest.ValidClusterFrom(ctx)
 		if err != nil {
-			return nil, err
+			return nil, apiErrorBadRequest(err)
+		}
+		namespace, _ := genericapirequest.NamespaceFrom(ctx)
+		if !cluster.Wildcard && (!strategy.NamespaceScoped() || namespace != metav1.NamespaceAll) {
+			return deleteCollection(ctx, client, options, v1ListOptions)
 		}
 
-		var v1ListOptions metav1.ListOptions
-		err = metainternalversion.Convert_internalversion_ListOptions_To_v1_ListOptions(listOptions, &v1ListOptions, nil)
+		// Collections of namespaced resources can only be deleted per namespace, and per logical cluster,
+		// so cross-namespace and cross-cluster requests are fanned out to one DeleteCollection request for
+		// each logical cluster and namespace that holds matching objects, rather than to one DELETE request
+		// per object.
+		delegate, err := listerWatcher(ctx)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := listCollectionTargets(ctx, delegate, cluster, v1ListOptions)
 		if err != nil {
 			return nil, err
 		}
 
-		return deleter.DeleteCollectionWithResult(ctx, *options, v1ListOptions)
+		list := listFactory()
+		result, ok := list.(*unstructured.UnstructuredList)
+		if !ok {
+			return nil, fmt.Errorf("not an UnstructuredList: %T", list)
+		}
+		return result, deleteCollectionTargets(ctx, targets, result, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+			return deleteCollection(ctx, client, options, v1ListOptions)
+		})
 	}
 	s.ListerFunc = func(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
 		var v1ListOptions metav1.ListOptions
@@ -287,6 +308,87 @@ func clientGetter(dynamicClusterClient kcpdynamic.ClusterInterface, namespaceSco
 	}
 }
 
+func deleteCollection(ctx context.Context, client func(ctx context.Context) (dynamic.ResourceInterface, error), options *metav1.DeleteOptions, listOptions metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	delegate, err := client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deleter, err := dynamicextension.NewDeleterWithResults(delegate)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleter.DeleteCollectionWithResult(ctx, *options, listOptions)
+}
+
+// deleteCollectionTargets deletes the collection in each of the targets, and appends the deleted objects
+// to result. A failure for a target does not prevent the deletion in the other ones, so that the result
+// holds all the deleted objects, and the errors are aggregated. A single error is returned as is, to keep
+// its status.
+func deleteCollectionTargets(ctx context.Context, targets []collectionTarget, result *unstructured.UnstructuredList, deleteCollection func(ctx context.Context) (*unstructured.UnstructuredList, error)) error {
+	var errs []error
+	for _, target := range targets {
+		targetCtx := genericapirequest.WithCluster(ctx, genericapirequest.Cluster{Name: target.cluster})
+		targetCtx = genericapirequest.WithNamespace(targetCtx, target.namespace)
+		deleted, err := deleteCollection(targetCtx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Items = append(result.Items, deleted.Items...)
+	}
+	return utilerrors.Reduce(utilerrors.NewAggregate(errs))
+}
+
+// deleteCollectionBatchSize is the page size of the LIST requests issued to find out the logical clusters
+// and namespaces a cross-namespace or cross-cluster DeleteCollection request is fanned out to.
+const deleteCollectionBatchSize = 500
+
+type collectionTarget struct {
+	cluster   logicalcluster.Name
+	namespace string
+}
+
+// listCollectionTargets pages through the objects matching the list options, and returns the distinct
+// logical clusters and namespaces they live in, in the order they are first listed.
+func listCollectionTargets(ctx context.Context, delegate listerWatcher, cluster *genericapirequest.Cluster, listOptions metav1.ListOptions) ([]collectionTarget, error) {
+	listOptions.Limit = deleteCollectionBatchSize
+	listOptions.Continue = ""
+	// the objects are deleted at their latest version anyway, and a resource version
+	// cannot be combined with a continue token.
+	listOptions.ResourceVersion = ""
+	listOptions.ResourceVersionMatch = ""
+
+	var targets []collectionTarget
+	seen := map[collectionTarget]bool{}
+	for {
+		list, err := delegate.List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range list.Items {
+			target := collectionTarget{cluster: cluster.Name, namespace: list.Items[i].GetNamespace()}
+			if cluster.Wildcard {
+				target.cluster = logicalcluster.From(&list.Items[i])
+				if target.cluster.Empty() {
+					return nil, apierrors.NewInternalError(fmt.Errorf("object %s/%s has no logical cluster", target.namespace, list.Items[i].GetName()))
+				}
+			}
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+
+		if list.GetContinue() == "" {
+			return targets, nil
+		}
+		listOptions.Continue = list.GetContinue()
+	}
+}
+
 type listerWatcher interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
 	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
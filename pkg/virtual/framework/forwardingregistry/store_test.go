@@ -17,12 +17,20 @@ limitations under the License.
 package forwardingregistry
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/kcp-dev/logicalcluster/v3"
 	"github.com/stretchr/testify/require"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 )
 
 func TestUpdateToCreateOptions(t *testing.T) {
@@ -67,3 +75,136 @@ func TestUpdateToCreateOptions(t *testing.T) {
 	}
 	require.Equalf(t, expectedCreateOptions, co, "CreateOptions should have the same fields as the UpdateOptions")
 }
+
+type pagingLister struct {
+	pages    [][]unstructured.Unstructured
+	requests []metav1.ListOptions
+}
+
+func (l *pagingLister) List(_ context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	l.requests = append(l.requests, opts)
+	page := 0
+	if opts.Continue != "" {
+		if _, err := fmt.Sscanf(opts.Continue, "page-%d", &page); err != nil {
+			return nil, err
+		}
+	}
+	list := &unstructured.UnstructuredList{Items: l.pages[page]}
+	if page+1 < len(l.pages) {
+		list.SetContinue(fmt.Sprintf("page-%d", page+1))
+	}
+	return list, nil
+}
+
+func (l *pagingLister) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func collectionObject(cluster, namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if cluster != "" {
+		obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: cluster})
+	}
+	return obj
+}
+
+func TestListCollectionTargets(t *testing.T) {
+	tests := map[string]struct {
+		cluster         genericapirequest.Cluster
+		pages           [][]unstructured.Unstructured
+		expectedTargets []collectionTarget
+		expectedErr     bool
+	}{
+		"cross-namespace in a single cluster": {
+			cluster: genericapirequest.Cluster{Name: "root:org"},
+			pages: [][]unstructured.Unstructured{
+				{collectionObject("root:org", "a", "x"), collectionObject("root:org", "b", "y")},
+				{collectionObject("root:org", "a", "z")},
+			},
+			expectedTargets: []collectionTarget{
+				{cluster: "root:org", namespace: "a"},
+				{cluster: "root:org", namespace: "b"},
+			},
+		},
+		"cross-cluster": {
+			cluster: genericapirequest.Cluster{Wildcard: true},
+			pages: [][]unstructured.Unstructured{
+				{collectionObject("one", "a", "x"), collectionObject("two", "a", "x")},
+				{collectionObject("one", "a", "y"), collectionObject("one", "b", "x")},
+			},
+			expectedTargets: []collectionTarget{
+				{cluster: "one", namespace: "a"},
+				{cluster: "two", namespace: "a"},
+				{cluster: "one", namespace: "b"},
+			},
+		},
+		"cross-cluster object without logical cluster": {
+			cluster: genericapirequest.Cluster{Wildcard: true},
+			pages: [][]unstructured.Unstructured{
+				{collectionObject("", "a", "x")},
+			},
+			expectedErr: true,
+		},
+		"no matching objects": {
+			cluster: genericapirequest.Cluster{Wildcard: true},
+			pages:   [][]unstructured.Unstructured{{}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			lister := &pagingLister{pages: tc.pages}
+			cluster := tc.cluster
+			targets, err := listCollectionTargets(context.Background(), lister, &cluster, metav1.ListOptions{
+				LabelSelector:   "team=a",
+				ResourceVersion: "42",
+			})
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedTargets, targets)
+
+			require.Len(t, lister.requests, len(tc.pages))
+			for _, req := range lister.requests {
+				require.Equal(t, "team=a", req.LabelSelector)
+				require.Equal(t, int64(deleteCollectionBatchSize), req.Limit)
+				require.Empty(t, req.ResourceVersion)
+			}
+		})
+	}
+}
+
+func TestDeleteCollectionTargets(t *testing.T) {
+	targets := []collectionTarget{
+		{cluster: "one", namespace: "a"},
+		{cluster: "two", namespace: "a"},
+		{cluster: "three", namespace: "b"},
+	}
+	result := &unstructured.UnstructuredList{}
+	err := deleteCollectionTargets(context.Background(), targets, result, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		cluster := genericapirequest.ClusterFrom(ctx)
+		namespace, _ := genericapirequest.NamespaceFrom(ctx)
+		if cluster.Name == "two" {
+			return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "noxus"}, "", fmt.Errorf("denied"))
+		}
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{collectionObject(cluster.Name.String(), namespace, "x")}}, nil
+	})
+
+	require.True(t, apierrors.IsForbidden(err), "the error of the failed target is returned: %v", err)
+	require.Len(t, result.Items, 2, "the objects deleted in the other targets are returned")
+	require.Equal(t, "one", logicalcluster.From(&result.Items[0]).String())
+	require.Equal(t, "three", logicalcluster.From(&result.Items[1]).String())
+
+	result = &unstructured.UnstructuredList{}
+	err = deleteCollectionTargets(context.Background(), targets, result, func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		if cluster := genericapirequest.ClusterFrom(ctx); cluster.Name != "one" {
+			return nil, fmt.Errorf("failed in %s", cluster.Name)
+		}
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{collectionObject("one", "a", "x")}}, nil
+	})
+	require.EqualError(t, err, "[failed in two, failed in three]")
+	require.Len(t, result.Items, 1)
+}
@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
 )
 
 func WithStaticLabelSelector(labelSelector labels.Requirements) StorageWrapper {
@@ -39,6 +40,9 @@ func WithLabelSelector(labelSelectorFrom func(ctx context.Context) labels.Requir
 	return StorageWrapperFunc(func(resource schema.GroupResource, storage *StoreFuncs) {
 		delegateLister := storage.ListerFunc
 		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			// copy the options so that the limit and continue token the client paginates
+			// with are forwarded untouched, along with the augmented selector.
+			options = options.DeepCopy()
 			selector := options.LabelSelector
 			if selector == nil {
 				selector = labels.Everything()
@@ -67,6 +71,7 @@ func WithLabelSelector(labelSelectorFrom func(ctx context.Context) labels.Requir
 
 		delegateWatcher := storage.WatcherFunc
 		storage.WatcherFunc = func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+			options = options.DeepCopy()
 			selector := options.LabelSelector
 			if selector == nil {
 				selector = labels.Everything()
@@ -74,5 +79,36 @@ func WithLabelSelector(labelSelectorFrom func(ctx context.Context) labels.Requir
 			options.LabelSelector = selector.Add(labelSelectorFrom(ctx)...)
 			return delegateWatcher.Watch(ctx, options)
 		}
+
+		delegateCollectionDeleter := storage.CollectionDeleterFunc
+		storage.CollectionDeleterFunc = func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+			listOptions = listOptions.DeepCopy()
+			selector := listOptions.LabelSelector
+			if selector == nil {
+				selector = labels.Everything()
+			}
+			listOptions.LabelSelector = selector.Add(labelSelectorFrom(ctx)...)
+			return delegateCollectionDeleter.DeleteCollection(ctx, deleteValidation, options, listOptions)
+		}
+	})
+}
+
+// WithMaxListLimit caps the page size of paginated LIST requests to maxLimit, so that
+// a single request can never force the whole result set to be loaded in memory.
+// Requests without a limit are not paginated by the client, and are left untouched.
+func WithMaxListLimit(maxLimit int64) StorageWrapper {
+	return StorageWrapperFunc(func(resource schema.GroupResource, storage *StoreFuncs) {
+		if maxLimit <= 0 {
+			return
+		}
+
+		delegateLister := storage.ListerFunc
+		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			if options.Limit > maxLimit {
+				options = options.DeepCopy()
+				options.Limit = maxLimit
+			}
+			return delegateLister.List(ctx, options)
+		}
 	})
 }
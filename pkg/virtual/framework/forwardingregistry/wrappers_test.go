@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+func recordingStore(recorded **internalversion.ListOptions) *StoreFuncs {
+	return &StoreFuncs{
+		ListerFunc: func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			*recorded = options
+			return &unstructured.UnstructuredList{}, nil
+		},
+	}
+}
+
+func TestWithLabelSelectorPagination(t *testing.T) {
+	var recorded *internalversion.ListOptions
+	store := recordingStore(&recorded)
+
+	requirements, _ := labels.SelectorFromSet(labels.Set{"phase": "Initializing"}).Requirements()
+	WithStaticLabelSelector(requirements).Decorate(schema.GroupResource{}, store)
+
+	userSelector := labels.SelectorFromSet(labels.Set{"team": "a"})
+	options := &internalversion.ListOptions{
+		LabelSelector: userSelector,
+		Limit:         10,
+		Continue:      "token",
+	}
+	_, err := store.List(context.Background(), options)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(10), recorded.Limit)
+	require.Equal(t, "token", recorded.Continue)
+	require.Equal(t, "phase=Initializing,team=a", recorded.LabelSelector.String())
+	require.Equal(t, userSelector, options.LabelSelector, "client options should not be mutated")
+}
+
+func TestWithLabelSelectorDeleteCollection(t *testing.T) {
+	var recorded *internalversion.ListOptions
+	store := &StoreFuncs{
+		CollectionDeleterFunc: func(ctx context.Context, _ rest.ValidateObjectFunc, _ *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+			recorded = listOptions
+			return &unstructured.UnstructuredList{}, nil
+		},
+	}
+
+	requirements, _ := labels.SelectorFromSet(labels.Set{"phase": "Initializing"}).Requirements()
+	WithStaticLabelSelector(requirements).Decorate(schema.GroupResource{}, store)
+
+	_, err := store.DeleteCollection(context.Background(), nil, &metav1.DeleteOptions{}, &internalversion.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "phase=Initializing", recorded.LabelSelector.String(), "unselected deletion should be restricted to the selector")
+
+	userSelector := labels.SelectorFromSet(labels.Set{"team": "a"})
+	options := &internalversion.ListOptions{LabelSelector: userSelector}
+	_, err = store.DeleteCollection(context.Background(), nil, &metav1.DeleteOptions{}, options)
+	require.NoError(t, err)
+	require.Equal(t, "phase=Initializing,team=a", recorded.LabelSelector.String())
+	require.Equal(t, userSelector, options.LabelSelector, "client options should not be mutated")
+}
+
+func TestWithMaxListLimit(t *testing.T) {
+	tests := map[string]struct {
+		maxLimit      int64
+		limit         int64
+		expectedLimit int64
+	}{
+		"unpaginated request is left untouched": {maxLimit: 100, limit: 0, expectedLimit: 0},
+		"limit below max is forwarded":          {maxLimit: 100, limit: 50, expectedLimit: 50},
+		"limit above max is capped":             {maxLimit: 100, limit: 5000, expectedLimit: 100},
+		"disabled cap":                          {maxLimit: 0, limit: 5000, expectedLimit: 5000},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var recorded *internalversion.ListOptions
+			store := recordingStore(&recorded)
+			WithMaxListLimit(tc.maxLimit).Decorate(schema.GroupResource{}, store)
+
+			_, err := store.List(context.Background(), &internalversion.ListOptions{Limit: tc.limit, Continue: "token"})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedLimit, recorded.Limit)
+			require.Equal(t, "token", recorded.Continue)
+		})
+	}
+}
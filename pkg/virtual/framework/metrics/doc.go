@@ -0,0 +1,21 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides a virtual workspace decorator that instruments the
+// requests served by the virtual workspace, per API domain, e.g., per APIExport
+// or SyncTarget, so that virtual workspace traffic is visible on the metrics
+// endpoint of the server the virtual workspaces run in.
+package metrics
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+// WithRequestMetrics decorates the virtual workspace so that the count, latency, errors and
+// in-flight number of its requests are recorded.
+func WithRequestMetrics(vw framework.VirtualWorkspace) framework.VirtualWorkspace {
+	return &instrumentedVirtualWorkspace{VirtualWorkspace: vw}
+}
+
+type instrumentedVirtualWorkspace struct {
+	framework.VirtualWorkspace
+}
+
+func (vw *instrumentedVirtualWorkspace) Register(name string, rootAPIServerConfig genericapiserver.CompletedConfig, delegateAPIServer genericapiserver.DelegationTarget) (genericapiserver.DelegationTarget, error) {
+	target, err := vw.VirtualWorkspace.Register(name, rootAPIServerConfig, delegateAPIServer)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedDelegationTarget{DelegationTarget: target, name: name}, nil
+}
+
+type instrumentedDelegationTarget struct {
+	genericapiserver.DelegationTarget
+	name string
+}
+
+func (t *instrumentedDelegationTarget) UnprotectedHandler() http.Handler {
+	delegate := t.DelegationTarget.UnprotectedHandler()
+	if delegate == nil {
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if name, found := virtualcontext.VirtualWorkspaceNameFrom(ctx); !found || name != t.name {
+			// requests for other virtual workspaces are passed through the delegation chain
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		apiDomain := string(dynamiccontext.APIDomainKeyFrom(ctx))
+		verb := "unknown"
+		if info, ok := genericapirequest.RequestInfoFrom(ctx); ok {
+			verb = info.Verb
+		}
+		requestKind := "request"
+		if verb == "watch" {
+			requestKind = "watch"
+		} else if httpstream.IsUpgradeRequest(req) {
+			// exec, attach and port-forward streams last as long as the sessions
+			requestKind = "stream"
+		}
+
+		inflight := currentInflightRequests.WithLabelValues(t.name, apiDomain, requestKind)
+		inflight.Inc()
+		defer inflight.Dec()
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		delegate.ServeHTTP(responsewriter.WrapForHTTP1Or2(recorder), req)
+
+		code := strconv.Itoa(recorder.code)
+		requests.WithLabelValues(t.name, apiDomain, verb, code).Inc()
+		if isError(recorder.code) {
+			requestErrors.WithLabelValues(t.name, apiDomain, verb, code).Inc()
+		}
+		if requestKind == "request" {
+			requestLatencies.WithLabelValues(t.name, apiDomain, verb).Observe(time.Since(start).Seconds())
+		}
+	})
+}
+
+// isError returns whether the response code is a server error, or the request was rejected
+// because the virtual workspace or one of its consumers is overloaded. Other client errors,
+// e.g. not found or forbidden, are part of the normal operation of the clients.
+func isError(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+var _ responsewriter.UserProvidedDecorator = &statusRecorder{}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
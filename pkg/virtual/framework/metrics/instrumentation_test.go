@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/component-base/metrics/testutil"
+
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+type fakeDelegationTarget struct {
+	genericapiserver.DelegationTarget
+	handler http.Handler
+}
+
+func (t fakeDelegationTarget) UnprotectedHandler() http.Handler {
+	return t.handler
+}
+
+func TestRequestMetrics(t *testing.T) {
+	var inflight float64
+	code := http.StatusOK
+	target := &instrumentedDelegationTarget{
+		name: "apiexport",
+		DelegationTarget: fakeDelegationTarget{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var err error
+			inflight, err = testutil.GetGaugeMetricValue(currentInflightRequests.WithLabelValues("apiexport", "root:org/export", "request"))
+			require.NoError(t, err)
+			w.WriteHeader(code)
+		})},
+	}
+	handler := target.UnprotectedHandler()
+
+	serve := func(vwName string) {
+		ctx := virtualcontext.WithVirtualWorkspaceName(dynamiccontext.WithAPIDomainKey(
+			genericapirequest.WithRequestInfo(genericapirequest.NewContext(), &genericapirequest.RequestInfo{Verb: "list"}),
+			"root:org/export",
+		), vwName)
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve("apiexport")
+	require.Equal(t, float64(1), inflight, "the request should be in flight while it is served")
+	count, err := testutil.GetCounterMetricValue(requests.WithLabelValues("apiexport", "root:org/export", "list", "200"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), count)
+	current, err := testutil.GetGaugeMetricValue(currentInflightRequests.WithLabelValues("apiexport", "root:org/export", "request"))
+	require.NoError(t, err)
+	require.Equal(t, float64(0), current)
+
+	t.Log("Not found responses are not errors")
+	code = http.StatusNotFound
+	serve("apiexport")
+	errs, err := testutil.GetCounterMetricValue(requestErrors.WithLabelValues("apiexport", "root:org/export", "list", "404"))
+	require.NoError(t, err)
+	require.Equal(t, float64(0), errs)
+
+	t.Log("Rejected requests are errors")
+	code = http.StatusTooManyRequests
+	serve("apiexport")
+	errs, err = testutil.GetCounterMetricValue(requestErrors.WithLabelValues("apiexport", "root:org/export", "list", "429"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), errs)
+
+	t.Log("Requests for other virtual workspaces are not recorded")
+	code = http.StatusOK
+	serve("syncer")
+	count, err = testutil.GetCounterMetricValue(requests.WithLabelValues("syncer", "root:org/export", "list", "200"))
+	require.NoError(t, err)
+	require.Equal(t, float64(0), count)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// The API domain label identifies e.g. the APIExport or the SyncTarget the requests are served for.
+// The logical cluster and user are deliberately not used as labels, to bound the metrics cardinality.
+var (
+	requests = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "virtual_workspace_requests_total",
+			Help:           "Number of requests served by virtual workspaces, per virtual workspace, API domain, verb and HTTP response code.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "api_domain", "verb", "code"},
+	)
+
+	requestLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Name: "virtual_workspace_request_duration_seconds",
+			Help: "Response latency distribution in seconds of the requests, but watches and streams, served by virtual workspaces, per virtual workspace, API domain and verb.",
+			Buckets: []float64{0.005, 0.025, 0.05, 0.1, 0.2, 0.4, 0.6, 0.8, 1.0, 1.25, 1.5, 2, 3,
+				4, 5, 6, 8, 10, 15, 20, 30, 45, 60},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "api_domain", "verb"},
+	)
+
+	currentInflightRequests = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           "virtual_workspace_current_inflight_requests",
+			Help:           "Number of requests currently served by virtual workspaces, per virtual workspace, API domain and request kind, i.e., watch, stream or request.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "api_domain", "request_kind"},
+	)
+
+	requestErrors = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "virtual_workspace_request_errors_total",
+			Help:           "Number of requests served by virtual workspaces with a server error or a too many requests response, per virtual workspace, API domain, verb and HTTP response code.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "api_domain", "verb", "code"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(requests)
+		legacyregistry.MustRegister(requestLatencies)
+		legacyregistry.MustRegister(currentInflightRequests)
+		legacyregistry.MustRegister(requestErrors)
+	})
+}
+
+func init() {
+	Register()
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/apiserver/pkg/server/healthz"
@@ -36,6 +37,7 @@ import (
 	componentbaseversion "k8s.io/component-base/version"
 
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/audiences"
 	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
 )
 
@@ -56,6 +58,9 @@ type RootAPIExtraConfig struct {
 	// we phrase it like this so we can build the post-start-hook, but no one can take more indirect dependencies on informers
 	informerStart func(stopCh <-chan struct{})
 
+	// RootPathPrefix is the path prefix the virtual workspaces are served under.
+	RootPathPrefix string
+
 	VirtualWorkspaces []NamedVirtualWorkspace
 }
 
@@ -163,7 +168,8 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 	return func(apiHandler http.Handler, genericConfig *genericapiserver.Config) http.Handler {
 		delegateAfterDefaultHandlerChain := genericapiserver.DefaultBuildHandlerChain(
 			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				if _, virtualWorkspaceNameExists := virtualcontext.VirtualWorkspaceNameFrom(req.Context()); virtualWorkspaceNameExists {
+				if virtualWorkspaceName, virtualWorkspaceNameExists := virtualcontext.VirtualWorkspaceNameFrom(req.Context()); virtualWorkspaceNameExists {
+					addSpanAttributes(req.Context(), virtualWorkspaceName)
 					delegatedHandler := delegateAPIServer.UnprotectedHandler()
 					if delegatedHandler != nil {
 						delegatedHandler.ServeHTTP(w, req)
@@ -193,6 +199,7 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 						return
 					}
 					req.URL = newURL
+					completedContext = virtualcontext.WithVirtualWorkspaceAudiences(completedContext, audiences.ForPath(c.ExtraConfig.RootPathPrefix, prefixToStrip))
 					req = req.WithContext(virtualcontext.WithVirtualWorkspaceName(completedContext, vw.Name))
 					break
 				}
@@ -202,7 +209,7 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 	}
 }
 
-func NewRootAPIConfig(recommendedConfig *genericapiserver.RecommendedConfig, informerStarts []InformerStart, virtualWorkspaces []NamedVirtualWorkspace) (*RootAPIConfig, error) {
+func NewRootAPIConfig(recommendedConfig *genericapiserver.RecommendedConfig, rootPathPrefix string, informerStarts []InformerStart, virtualWorkspaces []NamedVirtualWorkspace) (*RootAPIConfig, error) {
 	// TODO: genericConfig.ExternalAddress = ... allow a command line flag or it to be overridden by a top-level multiroot apiServer
 
 	// Loopback is not wired for now, since virtual workspaces are expected to delegate to
@@ -214,9 +221,23 @@ func NewRootAPIConfig(recommendedConfig *genericapiserver.RecommendedConfig, inf
 		Host: "loopback-config-not-wired-for-now",
 	}
 
+	// the tokens bound to the audiences of a virtual workspace are accepted, in addition to the ones
+	// bound to the API audiences.
+	recommendedConfig.Authentication.Authenticator = audiences.WithAudiences(
+		recommendedConfig.Authentication.Authenticator,
+		recommendedConfig.Authentication.APIAudiences,
+		func(req *http.Request) authenticator.Audiences {
+			return virtualcontext.VirtualWorkspaceAudiencesFrom(req.Context())
+		},
+	)
+	if recommendedConfig.Authentication.Authenticator != nil {
+		recommendedConfig.Authentication.APIAudiences = nil
+	}
+
 	ret := &RootAPIConfig{
 		GenericConfig: recommendedConfig,
 		ExtraConfig: RootAPIExtraConfig{
+			RootPathPrefix: rootPathPrefix,
 			informerStart: func(stopCh <-chan struct{}) {
 				for _, informerStart := range informerStarts {
 					informerStart(stopCh)
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rootapiserver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+const (
+	virtualWorkspaceNameAttribute = "kcp.virtualworkspace.name"
+	apiDomainAttribute            = "kcp.virtualworkspace.apidomain"
+	clusterAttribute              = "kcp.cluster"
+)
+
+// addSpanAttributes annotates the span of the request, started by the tracing filter of
+// the handler chain, with the virtual workspace, API domain and logical cluster it targets,
+// e.g., the APIExport and the consumer workspace for the apiexport virtual workspace.
+func addSpanAttributes(ctx context.Context, virtualWorkspaceName string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.String(virtualWorkspaceNameAttribute, virtualWorkspaceName),
+	}
+	if key := dynamiccontext.APIDomainKeyFrom(ctx); key != "" {
+		attributes = append(attributes, attribute.String(apiDomainAttribute, string(key)))
+	}
+	if cluster := genericapirequest.ClusterFrom(ctx); cluster != nil {
+		if cluster.Wildcard {
+			attributes = append(attributes, attribute.String(clusterAttribute, "*"))
+		} else if !cluster.Name.Empty() {
+			attributes = append(attributes, attribute.String(clusterAttribute, cluster.Name.String()))
+		}
+	}
+	span.SetAttributes(attributes...)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	"sync"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// The API domain label identifies e.g. the APIExport or the SyncTarget the requests are served for.
+// The logical cluster and user are deliberately not used as labels, to bound the metrics cardinality.
+var (
+	throttledRequests = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "virtual_workspace_throttled_requests_total",
+			Help:           "Number of requests rejected by virtual workspaces because a consumer exceeded its rate limit, per virtual workspace and API domain.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "api_domain"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register metrics.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(throttledRequests)
+	})
+}
+
+func init() {
+	Register()
+}
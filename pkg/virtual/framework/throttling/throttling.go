@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+// limiterIdleTimeout is the duration after which the rate limiter of an idle consumer is released.
+const limiterIdleTimeout = 10 * time.Minute
+
+// Options are the per-consumer throttling options of a virtual workspace.
+type Options struct {
+	// QPS is the maximum sustained rate of requests per second per consumer. Zero disables throttling.
+	QPS float32
+	// Burst is the maximum burst of requests per consumer.
+	Burst int
+}
+
+func NewOptions() *Options {
+	return &Options{
+		QPS:   0,
+		Burst: 100,
+	}
+}
+
+func (o *Options) AddFlags(flags *pflag.FlagSet, prefix string) {
+	if o == nil {
+		return
+	}
+	flags.Float32Var(&o.QPS, prefix+"consumer-qps", o.QPS, "Maximum sustained rate of requests per second per consumer, i.e., per API domain, logical cluster and user. Set to 0 to disable throttling.")
+	flags.IntVar(&o.Burst, prefix+"consumer-burst", o.Burst, "Maximum burst of requests per consumer.")
+}
+
+func (o *Options) Validate(flagPrefix string) []error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	if o.QPS < 0 {
+		errs = append(errs, fmt.Errorf("--%sconsumer-qps cannot be negative", flagPrefix))
+	}
+	if o.QPS > 0 && o.Burst < 1 {
+		errs = append(errs, fmt.Errorf("--%sconsumer-burst must be positive when --%sconsumer-qps is set", flagPrefix, flagPrefix))
+	}
+	return errs
+}
+
+// WithConsumerThrottling decorates the virtual workspace so that its requests are throttled
+// per consumer when opts.QPS is positive.
+func WithConsumerThrottling(vw framework.VirtualWorkspace, opts Options) framework.VirtualWorkspace {
+	return &throttledVirtualWorkspace{
+		VirtualWorkspace: vw,
+		limiters:         newConsumerLimiters(opts, clock.RealClock{}),
+	}
+}
+
+type throttledVirtualWorkspace struct {
+	framework.VirtualWorkspace
+	limiters *consumerLimiters
+}
+
+func (vw *throttledVirtualWorkspace) Register(name string, rootAPIServerConfig genericapiserver.CompletedConfig, delegateAPIServer genericapiserver.DelegationTarget) (genericapiserver.DelegationTarget, error) {
+	target, err := vw.VirtualWorkspace.Register(name, rootAPIServerConfig, delegateAPIServer)
+	if err != nil {
+		return nil, err
+	}
+	return &throttledDelegationTarget{DelegationTarget: target, name: name, limiters: vw.limiters}, nil
+}
+
+type throttledDelegationTarget struct {
+	genericapiserver.DelegationTarget
+	name     string
+	limiters *consumerLimiters
+}
+
+func (t *throttledDelegationTarget) UnprotectedHandler() http.Handler {
+	delegate := t.DelegationTarget.UnprotectedHandler()
+	if delegate == nil {
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if name, found := virtualcontext.VirtualWorkspaceNameFrom(ctx); !found || name != t.name {
+			// requests for other virtual workspaces are passed through the delegation chain
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		apiDomain := string(dynamiccontext.APIDomainKeyFrom(ctx))
+		if !t.limiters.tryAccept(consumerKey(ctx, apiDomain)) {
+			throttledRequests.WithLabelValues(t.name, apiDomain).Inc()
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many requests for this consumer, please try again later.", http.StatusTooManyRequests)
+			return
+		}
+
+		delegate.ServeHTTP(w, req)
+	})
+}
+
+// consumerKey identifies the consumer of a request by its API domain, logical cluster and user.
+func consumerKey(ctx context.Context, apiDomain string) string {
+	cluster := ""
+	if c := genericapirequest.ClusterFrom(ctx); c != nil {
+		if c.Wildcard {
+			cluster = "*"
+		} else {
+			cluster = c.Name.String()
+		}
+	}
+	userName := ""
+	if u, ok := genericapirequest.UserFrom(ctx); ok {
+		userName = u.GetName()
+	}
+	return apiDomain + "|" + cluster + "|" + userName
+}
+
+type limiterEntry struct {
+	limiter  flowcontrol.RateLimiter
+	lastSeen time.Time
+}
+
+// consumerLimiters holds a token bucket rate limiter per consumer, releasing
+// the ones of the consumers that have been idle for some time.
+type consumerLimiters struct {
+	opts  Options
+	clock clock.Clock
+
+	lock      sync.Mutex
+	limiters  map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+func newConsumerLimiters(opts Options, clock clock.Clock) *consumerLimiters {
+	return &consumerLimiters{
+		opts:      opts,
+		clock:     clock,
+		limiters:  map[string]*limiterEntry{},
+		lastSweep: clock.Now(),
+	}
+}
+
+func (l *consumerLimiters) tryAccept(key string) bool {
+	if l.opts.QPS <= 0 {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	if now.Sub(l.lastSweep) > limiterIdleTimeout {
+		for k, e := range l.limiters {
+			if now.Sub(e.lastSeen) > limiterIdleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &limiterEntry{limiter: flowcontrol.NewTokenBucketRateLimiterWithClock(l.opts.QPS, l.opts.Burst, l.clock)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	return entry.limiter.TryAccept()
+}
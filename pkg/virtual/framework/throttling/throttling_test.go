@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestConsumerLimiters(t *testing.T) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	limiters := newConsumerLimiters(Options{QPS: 1, Burst: 2}, clock)
+
+	require.True(t, limiters.tryAccept("a"))
+	require.True(t, limiters.tryAccept("a"))
+	require.False(t, limiters.tryAccept("a"), "burst should be exhausted")
+	require.True(t, limiters.tryAccept("b"), "consumers should be throttled independently")
+
+	clock.Step(time.Second)
+	require.True(t, limiters.tryAccept("a"), "a token should have been refilled")
+	require.False(t, limiters.tryAccept("a"))
+
+	clock.Step(limiterIdleTimeout + time.Second)
+	require.True(t, limiters.tryAccept("a"))
+	require.Len(t, limiters.limiters, 1, "idle consumer limiters should have been released")
+}
+
+func TestConsumerLimitersDisabled(t *testing.T) {
+	limiters := newConsumerLimiters(Options{QPS: 0, Burst: 1}, clocktesting.NewFakeClock(time.Now()))
+	for i := 0; i < 10; i++ {
+		require.True(t, limiters.tryAccept("a"))
+	}
+	require.Empty(t, limiters.limiters)
+}
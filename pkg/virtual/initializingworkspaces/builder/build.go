@@ -68,6 +68,7 @@ func BuildVirtualWorkspace(
 	dynamicClusterClient kcpdynamic.ClusterInterface,
 	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
 	wildcardKcpInformers kcpinformers.SharedInformerFactory,
+	maxListLimit int64,
 ) ([]rootapiserver.NamedVirtualWorkspace, error) {
 	if !strings.HasSuffix(rootPathPrefix, "/") {
 		rootPathPrefix += "/"
@@ -127,7 +128,7 @@ func BuildVirtualWorkspace(
 				dynamicClusterClient: dynamicClusterClient,
 				exposeSubresources:   false,
 				resource:             &logicalClusterResource,
-				storageProvider:      provideFilteredClusterWorkspacesReadOnlyRestStorage(getTenancyIdentity),
+				storageProvider:      provideFilteredClusterWorkspacesReadOnlyRestStorage(getTenancyIdentity, maxListLimit),
 			}, nil
 		},
 	}
@@ -60,7 +60,7 @@ func initializingWorkspaceRequirements(initializer corev1alpha1.LogicalClusterIn
 	return requirements, nil
 }
 
-func provideFilteredClusterWorkspacesReadOnlyRestStorage(getTenancyIdentity func() (string, error)) func(
+func provideFilteredClusterWorkspacesReadOnlyRestStorage(getTenancyIdentity func() (string, error), maxListLimit int64) func(
 	ctx context.Context,
 	clusterClient kcpdynamic.ClusterInterface,
 	initializer corev1alpha1.LogicalClusterInitializer,
@@ -83,7 +83,10 @@ func provideFilteredClusterWorkspacesReadOnlyRestStorage(getTenancyIdentity func
 		return registry.ProvideReadOnlyRestStorage(
 			ctx,
 			clusterClient,
-			registry.WithStaticLabelSelector(requirements),
+			&registry.StorageWrappers{
+				registry.WithStaticLabelSelector(requirements),
+				registry.WithMaxListLimit(maxListLimit),
+			},
 			identities,
 		)
 	}
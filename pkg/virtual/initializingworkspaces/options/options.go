@@ -17,6 +17,7 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
 	"path"
 
 	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
@@ -31,16 +32,25 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/initializingworkspaces/builder"
 )
 
-type InitializingWorkspaces struct{}
+type InitializingWorkspaces struct {
+	// MaxListLimit caps the page size of paginated LIST requests for LogicalClusters
+	// across all workspaces. Zero means no cap.
+	MaxListLimit int64
+}
 
 func New() *InitializingWorkspaces {
-	return &InitializingWorkspaces{}
+	return &InitializingWorkspaces{
+		MaxListLimit: 500,
+	}
 }
 
+const initializingWorkspacesPrefix = "initializingworkspaces."
+
 func (o *InitializingWorkspaces) AddFlags(flags *pflag.FlagSet, prefix string) {
 	if o == nil {
 		return
 	}
+	flags.Int64Var(&o.MaxListLimit, prefix+initializingWorkspacesPrefix+"max-list-limit", o.MaxListLimit, "Maximum page size of paginated LIST requests for LogicalClusters across workspaces. Set to 0 to disable.")
 }
 
 func (o *InitializingWorkspaces) Validate(flagPrefix string) []error {
@@ -48,6 +58,9 @@ func (o *InitializingWorkspaces) Validate(flagPrefix string) []error {
 		return nil
 	}
 	errs := []error{}
+	if o.MaxListLimit < 0 {
+		errs = append(errs, fmt.Errorf("--%s%smax-list-limit cannot be negative", flagPrefix, initializingWorkspacesPrefix))
+	}
 
 	return errs
 }
@@ -67,5 +80,5 @@ func (o *InitializingWorkspaces) NewVirtualWorkspaces(
 		return nil, err
 	}
 
-	return builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, initializingworkspaces.VirtualWorkspaceName), dynamicClusterClient, kubeClusterClient, wildcardKcpInformers)
+	return builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, initializingworkspaces.VirtualWorkspaceName), dynamicClusterClient, kubeClusterClient, wildcardKcpInformers, o.MaxListLimit)
 }
@@ -26,7 +26,10 @@ import (
 
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	apiexportoptions "github.com/kcp-dev/kcp/pkg/virtual/apiexport/options"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/fairness"
+	virtualmetrics "github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/throttling"
 	initializingworkspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/initializingworkspaces/options"
 	synceroptions "github.com/kcp-dev/kcp/pkg/virtual/syncer/options"
 	workspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/workspaces/options"
@@ -39,6 +42,13 @@ type Options struct {
 	Syncer                 *synceroptions.Syncer
 	APIExport              *apiexportoptions.APIExport
 	InitializingWorkspaces *initializingworkspacesoptions.InitializingWorkspaces
+
+	// ConsumerThrottling applies to the virtual workspaces that serve API consumers,
+	// i.e., the apiexport and syncer ones.
+	ConsumerThrottling *throttling.Options
+
+	// Fairness limits the requests in flight per priority level, i.e., syncers, providers and interactive users.
+	Fairness *fairness.Options
 }
 
 func NewOptions() *Options {
@@ -47,6 +57,8 @@ func NewOptions() *Options {
 		Syncer:                 synceroptions.New(),
 		APIExport:              apiexportoptions.New(),
 		InitializingWorkspaces: initializingworkspacesoptions.New(),
+		ConsumerThrottling:     throttling.NewOptions(),
+		Fairness:               fairness.NewOptions(),
 	}
 }
 
@@ -57,13 +69,18 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.Syncer.Validate(virtualWorkspacesFlagPrefix)...)
 	errs = append(errs, o.APIExport.Validate(virtualWorkspacesFlagPrefix)...)
 	errs = append(errs, o.InitializingWorkspaces.Validate(virtualWorkspacesFlagPrefix)...)
+	errs = append(errs, o.ConsumerThrottling.Validate(virtualWorkspacesFlagPrefix)...)
+	errs = append(errs, o.Fairness.Validate(virtualWorkspacesFlagPrefix)...)
 
 	return errs
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.Workspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	o.Syncer.AddFlags(fs, virtualWorkspacesFlagPrefix)
 	o.InitializingWorkspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	o.ConsumerThrottling.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	o.Fairness.AddFlags(fs, virtualWorkspacesFlagPrefix)
 }
 
 func (o *Options) NewVirtualWorkspaces(
@@ -72,6 +89,10 @@ func (o *Options) NewVirtualWorkspaces(
 	wildcardKubeInformers kcpkubernetesinformers.SharedInformerFactory,
 	wildcardKcpInformers kcpinformers.SharedInformerFactory,
 ) ([]rootapiserver.NamedVirtualWorkspace, error) {
+	// requests forwarded to the shard carry the priority level of the virtual workspace request
+	config = rest.CopyConfig(config)
+	config.Wrap(fairness.WithPriorityLevelRoundTripper)
+
 	workspaces, err := o.Workspaces.NewVirtualWorkspaces(rootPathPrefix, config)
 	if err != nil {
 		return nil, err
@@ -92,13 +113,61 @@ func (o *Options) NewVirtualWorkspaces(
 		return nil, err
 	}
 
-	all, err := merge(workspaces, syncer, apiexports, initializingworkspaces)
+	syncer = o.withConsumerThrottling(syncer)
+	apiexports = o.withConsumerThrottling(apiexports)
+
+	limiter := fairness.NewLimiter(fairness.VirtualWorkspaces, *o.Fairness)
+	workspaces = withPriorityLevel(limiter, fairness.Interactive, workspaces)
+	syncer = withPriorityLevel(limiter, fairness.Syncers, syncer)
+	apiexports = withPriorityLevel(limiter, fairness.Providers, apiexports)
+	initializingworkspaces = withPriorityLevel(limiter, fairness.Providers, initializingworkspaces)
+
+	// instrument last, so that requests rejected by the throttling and fairness limits are counted
+	all, err := merge(
+		withRequestMetrics(workspaces),
+		withRequestMetrics(syncer),
+		withRequestMetrics(apiexports),
+		withRequestMetrics(initializingworkspaces),
+	)
 	if err != nil {
 		return nil, err
 	}
 	return all, nil
 }
 
+func (o *Options) withConsumerThrottling(vws []rootapiserver.NamedVirtualWorkspace) []rootapiserver.NamedVirtualWorkspace {
+	throttled := make([]rootapiserver.NamedVirtualWorkspace, 0, len(vws))
+	for _, vw := range vws {
+		throttled = append(throttled, rootapiserver.NamedVirtualWorkspace{
+			Name:             vw.Name,
+			VirtualWorkspace: throttling.WithConsumerThrottling(vw.VirtualWorkspace, *o.ConsumerThrottling),
+		})
+	}
+	return throttled
+}
+
+func withRequestMetrics(vws []rootapiserver.NamedVirtualWorkspace) []rootapiserver.NamedVirtualWorkspace {
+	instrumented := make([]rootapiserver.NamedVirtualWorkspace, 0, len(vws))
+	for _, vw := range vws {
+		instrumented = append(instrumented, rootapiserver.NamedVirtualWorkspace{
+			Name:             vw.Name,
+			VirtualWorkspace: virtualmetrics.WithRequestMetrics(vw.VirtualWorkspace),
+		})
+	}
+	return instrumented
+}
+
+func withPriorityLevel(limiter *fairness.Limiter, level fairness.PriorityLevel, vws []rootapiserver.NamedVirtualWorkspace) []rootapiserver.NamedVirtualWorkspace {
+	prioritized := make([]rootapiserver.NamedVirtualWorkspace, 0, len(vws))
+	for _, vw := range vws {
+		prioritized = append(prioritized, rootapiserver.NamedVirtualWorkspace{
+			Name:             vw.Name,
+			VirtualWorkspace: limiter.WithPriorityLevel(vw.VirtualWorkspace, level),
+		})
+	}
+	return prioritized
+}
+
 func merge(sets ...[]rootapiserver.NamedVirtualWorkspace) ([]rootapiserver.NamedVirtualWorkspace, error) {
 	var workspaces []rootapiserver.NamedVirtualWorkspace
 	seen := map[string]bool{}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	kcpkubernetesinformers "github.com/kcp-dev/client-go/informers"
+	kcpfakekubeclient "github.com/kcp-dev/client-go/kubernetes/fake"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/client-go/rest"
+
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/audiences"
+)
+
+// TestVirtualWorkspaceAudiences checks that the roots of every registered virtual workspace are
+// scoped by the audiences package, so that the tokens bound to the audience of a virtual workspace
+// are accepted by it, and not by the others. A new virtual workspace must be added here, and to
+// the root segments of the audiences package.
+func TestVirtualWorkspaceAudiences(t *testing.T) {
+	kubeInformers := kcpkubernetesinformers.NewSharedInformerFactory(kcpfakekubeclient.NewSimpleClientset(), 0)
+	kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfakeclient.NewSimpleClientset(), 0)
+	vws, err := NewOptions().NewVirtualWorkspaces(&rest.Config{Host: "https://kcp.example.com"}, "/services", kubeInformers, kcpInformers)
+	require.NoError(t, err)
+
+	// a path served by each virtual workspace, and the audience of its root
+	roots := map[string]struct{ path, audience string }{
+		"clusterworkspaces": {
+			"/services/clusterworkspaces/root:org/apis/tenancy.kcp.io/v1beta1/workspaces",
+			"/services/clusterworkspaces/root:org",
+		},
+		"apiexport": {
+			"/services/apiexport/root:org/my-export/clusters/*/api/v1/configmaps",
+			"/services/apiexport/root:org/my-export",
+		},
+		"apiexport-writethrough": {
+			"/services/apiexport/root:org/my-export/writethrough/clusters/my-cluster/api/v1/configmaps",
+			"/services/apiexport/root:org/my-export",
+		},
+		"apiexport-claims": {
+			"/services/apiexport/root:org/my-export/claims",
+			"/services/apiexport/root:org/my-export",
+		},
+		"syncer": {
+			"/services/syncer/root:org/my-target/uid/clusters/*/api/v1/configmaps",
+			"/services/syncer/root:org/my-target/uid",
+		},
+		"upsyncer": {
+			"/services/upsyncer/root:org/my-target/uid/clusters/*/api/v1/persistentvolumes",
+			"/services/upsyncer/root:org/my-target/uid",
+		},
+		"initializingworkspaces-wildcard-logicalclusters": {
+			"/services/initializingworkspaces/root:org:my-initializer/clusters/*/apis/core.kcp.io/v1alpha1/logicalclusters",
+			"/services/initializingworkspaces/root:org:my-initializer",
+		},
+		"initializingworkspaces-logicalclusters": {
+			"/services/initializingworkspaces/root:org:my-initializer/clusters/my-cluster/apis/core.kcp.io/v1alpha1/logicalclusters/cluster",
+			"/services/initializingworkspaces/root:org:my-initializer",
+		},
+		"initializingworkspaces-workspace-content": {
+			"/services/initializingworkspaces/root:org:my-initializer/clusters/my-cluster/api/v1/configmaps",
+			"/services/initializingworkspaces/root:org:my-initializer",
+		},
+	}
+
+	// the syncer virtual workspaces only resolve their paths once started, for existing SyncTargets
+	notStarted := sets.NewString("syncer", "upsyncer")
+
+	for _, vw := range vws {
+		root, found := roots[vw.Name]
+		require.True(t, found, "virtual workspace %q has no root", vw.Name)
+		require.Equal(t, authenticator.Audiences{root.audience}, audiences.ForPath("/services", root.path), "virtual workspace %q", vw.Name)
+		if notStarted.Has(vw.Name) {
+			continue
+		}
+
+		accepted, prefixToStrip, _ := vw.ResolveRootPath(root.path, context.Background())
+		require.True(t, accepted, "virtual workspace %q does not serve %s", vw.Name, root.path)
+		require.True(t, strings.HasPrefix(prefixToStrip+"/", root.audience+"/"), "the root of virtual workspace %q is not %s", vw.Name, root.audience)
+		require.Equal(t, authenticator.Audiences{root.audience}, audiences.ForPath("/services", prefixToStrip), "virtual workspace %q", vw.Name)
+	}
+}
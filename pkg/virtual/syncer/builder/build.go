@@ -50,6 +50,7 @@ func BuildVirtualWorkspace(
 	dynamicClusterClient kcpdynamic.ClusterInterface,
 	kcpClusterClient kcpclientset.ClusterInterface,
 	wildcardKcpInformers kcpinformers.SharedInformerFactory,
+	impersonation ImpersonationAllowlist,
 ) []rootapiserver.NamedVirtualWorkspace {
 	if !strings.HasSuffix(rootPathPrefix, "/") {
 		rootPathPrefix += "/"
@@ -74,6 +75,7 @@ func BuildVirtualWorkspace(
 		kcpClusterClient:     kcpClusterClient,
 		wildcardKcpInformers: wildcardKcpInformers,
 		rootPathPrefix:       rootPathPrefix,
+		impersonation:        impersonation,
 	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/transport"
+)
+
+// SyncTargetImpersonationExtraKey is the user extra key a syncer must impersonate, with the key of its
+// SyncTarget (as returned by workloadv1alpha1.ToSyncTargetKey) as value, alongside the impersonated user. It binds the impersonated identity to the
+// SyncTarget the syncer is allowed to sync, so that the impersonated identity is granted access to the
+// syncer virtual workspaces for that SyncTarget only.
+const SyncTargetImpersonationExtraKey = "workload.kcp.io/sync-target"
+
+// ImpersonationAllowlist declares the identities a syncer is allowed to impersonate through the
+// syncer virtual workspaces. An empty allowlist disables impersonation.
+type ImpersonationAllowlist struct {
+	Users  sets.String
+	Groups sets.String
+}
+
+// authorizeImpersonation decides whether the requesting syncer can impersonate the identity
+// described by the impersonate attributes. canSync must only be called once the impersonated
+// identity has been checked against the allowlist, as it issues a delegated authorization request.
+func (l ImpersonationAllowlist) authorizeImpersonation(a authorizer.Attributes, syncTargetKey string, canSync func() (authorizer.Decision, string, error)) (authorizer.Decision, string, error) {
+	switch a.GetResource() {
+	case "users":
+		if !l.Users.Has(a.GetName()) {
+			return authorizer.DecisionDeny, "user is not declared as impersonable by syncers", nil
+		}
+	case "groups":
+		if !l.Groups.Has(a.GetName()) {
+			return authorizer.DecisionDeny, "group is not declared as impersonable by syncers", nil
+		}
+	case "userextras":
+		if a.GetSubresource() != SyncTargetImpersonationExtraKey || a.GetName() != syncTargetKey {
+			return authorizer.DecisionDeny, "only the sync target user extra can be impersonated by syncers", nil
+		}
+	default:
+		return authorizer.DecisionDeny, "impersonation of " + a.GetResource() + " is not supported by syncers", nil
+	}
+
+	return canSync()
+}
+
+// isImpersonatedBySyncerOf returns whether the user has been impersonated by a syncer
+// of the SyncTarget with the given key.
+func isImpersonatedBySyncerOf(u user.Info, syncTargetKey string) bool {
+	if u == nil {
+		return false
+	}
+	return sets.NewString(u.GetExtra()[SyncTargetImpersonationExtraKey]...).Has(syncTargetKey)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+var _ http.RoundTripper = roundTripperFunc(nil)
+
+func (rt roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return rt(r)
+}
+
+// WithSyncerImpersonation replays the identity impersonated by a syncer onto the upstream requests,
+// so that these requests are attributed to that identity rather than to the virtual workspace itself.
+// Requests that have not been impersonated by a syncer are forwarded unchanged.
+func WithSyncerImpersonation(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(origReq *http.Request) (*http.Response, error) {
+		u, ok := genericapirequest.UserFrom(origReq.Context())
+		if !ok || len(u.GetExtra()[SyncTargetImpersonationExtraKey]) == 0 {
+			return rt.RoundTrip(origReq)
+		}
+
+		req := origReq.Clone(origReq.Context())
+		req.Header.Set(transport.ImpersonateUserHeader, u.GetName())
+		req.Header.Del(transport.ImpersonateGroupHeader)
+		for _, group := range u.GetGroups() {
+			if group == user.AllAuthenticated {
+				continue
+			}
+			req.Header.Add(transport.ImpersonateGroupHeader, group)
+		}
+
+		return rt.RoundTrip(req)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/transport"
+)
+
+func TestAuthorizeImpersonation(t *testing.T) {
+	allowlist := ImpersonationAllowlist{
+		Users:  sets.NewString("alice"),
+		Groups: sets.NewString("team-a"),
+	}
+	canSync := func() (authorizer.Decision, string, error) {
+		return authorizer.DecisionAllow, "", nil
+	}
+
+	tests := map[string]struct {
+		attributes authorizer.AttributesRecord
+		expected   authorizer.Decision
+	}{
+		"declared user": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "users", Name: "alice"},
+			expected:   authorizer.DecisionAllow,
+		},
+		"undeclared user": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "users", Name: "bob"},
+			expected:   authorizer.DecisionDeny,
+		},
+		"declared group": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "groups", Name: "team-a"},
+			expected:   authorizer.DecisionAllow,
+		},
+		"undeclared group": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "groups", Name: "system:masters"},
+			expected:   authorizer.DecisionDeny,
+		},
+		"own sync target extra": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "userextras", Subresource: SyncTargetImpersonationExtraKey, Name: "key"},
+			expected:   authorizer.DecisionAllow,
+		},
+		"other sync target extra": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "userextras", Subresource: SyncTargetImpersonationExtraKey, Name: "other"},
+			expected:   authorizer.DecisionDeny,
+		},
+		"arbitrary extra": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "userextras", Subresource: "scopes", Name: "key"},
+			expected:   authorizer.DecisionDeny,
+		},
+		"uid": {
+			attributes: authorizer.AttributesRecord{Verb: "impersonate", Resource: "uids", Name: "1234"},
+			expected:   authorizer.DecisionDeny,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			decision, _, err := allowlist.authorizeImpersonation(tc.attributes, "key", canSync)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, decision)
+		})
+	}
+}
+
+func TestWithSyncerImpersonation(t *testing.T) {
+	var received http.Header
+	rt := WithSyncerImpersonation(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		received = req.Header
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/clusters/*/api/v1/configmaps", nil)
+	_, err := rt.RoundTrip(req.WithContext(genericapirequest.WithUser(req.Context(), &user.DefaultInfo{Name: "syncer"})))
+	require.NoError(t, err)
+	require.Empty(t, received.Get(transport.ImpersonateUserHeader))
+
+	impersonated := &user.DefaultInfo{
+		Name:   "alice",
+		Groups: []string{"team-a", user.AllAuthenticated},
+		Extra:  map[string][]string{SyncTargetImpersonationExtraKey: {"key"}},
+	}
+	_, err = rt.RoundTrip(req.WithContext(genericapirequest.WithUser(req.Context(), impersonated)))
+	require.NoError(t, err)
+	require.Equal(t, "alice", received.Get(transport.ImpersonateUserHeader))
+	require.Equal(t, []string{"team-a"}, received.Values(transport.ImpersonateGroupHeader))
+	require.Empty(t, req.Header.Get(transport.ImpersonateUserHeader), "original request should not be mutated")
+}
@@ -57,6 +57,7 @@ type templateProvider struct {
 	kcpClusterClient     kcpclientset.ClusterInterface
 	wildcardKcpInformers kcpinformers.SharedInformerFactory
 	rootPathPrefix       string
+	impersonation        ImpersonationAllowlist
 }
 
 type templateParameters struct {
@@ -186,20 +187,35 @@ func (t *template) authorize(ctx context.Context, a authorizer.Attributes) (auth
 		return authorizer.DecisionNoOpinion, "", err
 	}
 
-	authz, err := delegated.NewDelegatedAuthorizer(negotiationWorkspaceName, t.kubeClusterClient)
-	if err != nil {
-		return authorizer.DecisionNoOpinion, "Error", err
+	// A user impersonated by a syncer has been authorized at impersonation time,
+	// for the SyncTarget the syncer is allowed to sync.
+	hashedSyncTargetKey := workloadv1alpha1.ToSyncTargetKey(negotiationWorkspaceName, syncTargetName)
+	if isImpersonatedBySyncerOf(a.GetUser(), hashedSyncTargetKey) {
+		return authorizer.DecisionAllow, "impersonated by a syncer of the sync target", nil
 	}
-	SARAttributes := authorizer.AttributesRecord{
-		User:            a.GetUser(),
-		Verb:            "sync",
-		Name:            syncTargetName,
-		APIGroup:        workloadv1alpha1.SchemeGroupVersion.Group,
-		APIVersion:      workloadv1alpha1.SchemeGroupVersion.Version,
-		Resource:        "synctargets",
-		ResourceRequest: true,
+
+	canSync := func() (authorizer.Decision, string, error) {
+		authz, err := delegated.NewDelegatedAuthorizer(negotiationWorkspaceName, t.kubeClusterClient)
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "Error", err
+		}
+		SARAttributes := authorizer.AttributesRecord{
+			User:            a.GetUser(),
+			Verb:            "sync",
+			Name:            syncTargetName,
+			APIGroup:        workloadv1alpha1.SchemeGroupVersion.Group,
+			APIVersion:      workloadv1alpha1.SchemeGroupVersion.Version,
+			Resource:        "synctargets",
+			ResourceRequest: true,
+		}
+		return authz.Authorize(ctx, SARAttributes)
 	}
-	return authz.Authorize(ctx, SARAttributes)
+
+	if a.GetVerb() == "impersonate" {
+		return t.impersonation.authorizeImpersonation(a, hashedSyncTargetKey, canSync)
+	}
+
+	return canSync()
 }
 
 func (t *template) bootstrapManagement(mainConfig genericapiserver.CompletedConfig) (apidefinition.APIDefinitionSetGetter, error) {
@@ -21,6 +21,7 @@ import (
 	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/rest"
 
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
@@ -29,16 +30,25 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/syncer/builder"
 )
 
-type Syncer struct{}
+type Syncer struct {
+	// ImpersonatedUsers are the users syncers are allowed to impersonate.
+	ImpersonatedUsers []string
+	// ImpersonatedGroups are the groups syncers are allowed to impersonate.
+	ImpersonatedGroups []string
+}
 
 func New() *Syncer {
 	return &Syncer{}
 }
 
+const syncerPrefix = "syncer."
+
 func (o *Syncer) AddFlags(flags *pflag.FlagSet, prefix string) {
 	if o == nil {
 		return
 	}
+	flags.StringSliceVar(&o.ImpersonatedUsers, prefix+syncerPrefix+"impersonated-users", o.ImpersonatedUsers, "Users that syncers are allowed to impersonate when accessing the syncer virtual workspaces, so that the requests replayed upstream are attributed to them.")
+	flags.StringSliceVar(&o.ImpersonatedGroups, prefix+syncerPrefix+"impersonated-groups", o.ImpersonatedGroups, "Groups that syncers are allowed to impersonate when accessing the syncer virtual workspaces, so that the requests replayed upstream are attributed to them.")
 }
 
 func (o *Syncer) Validate(flagPrefix string) []error {
@@ -64,10 +74,19 @@ func (o *Syncer) NewVirtualWorkspaces(
 	if err != nil {
 		return nil, err
 	}
-	dynamicClusterClient, err := kcpdynamic.NewForConfig(config)
+	// only the forwarding client replays the identities impersonated by syncers,
+	// the other clients are used for the virtual workspace own purposes, e.g., authorization.
+	dynamicConfig := rest.CopyConfig(config)
+	dynamicConfig.Wrap(builder.WithSyncerImpersonation)
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(dynamicConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	return builder.BuildVirtualWorkspace(rootPathPrefix, kubeClusterClient, dynamicClusterClient, kcpClusterClient, wildcardKcpInformers), nil
+	impersonation := builder.ImpersonationAllowlist{
+		Users:  sets.NewString(o.ImpersonatedUsers...),
+		Groups: sets.NewString(o.ImpersonatedGroups...),
+	}
+
+	return builder.BuildVirtualWorkspace(rootPathPrefix, kubeClusterClient, dynamicClusterClient, kcpClusterClient, wildcardKcpInformers, impersonation), nil
 }
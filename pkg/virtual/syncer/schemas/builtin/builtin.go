@@ -18,10 +18,12 @@ package builtin
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/kube-openapi/pkg/common"
+	"k8s.io/kubernetes/pkg/api/genericcontrolplanescheme"
 	"k8s.io/kubernetes/pkg/api/legacyscheme"
 	"k8s.io/kubernetes/pkg/apis/core/install/genericcontrolplane"
 	generatedopenapi "k8s.io/kubernetes/pkg/generated/openapi"
@@ -36,7 +38,7 @@ var SyncerSchemas map[apisv1alpha1.GroupResource]*apisv1alpha1.APIResourceSchema
 
 func init() {
 	genericcontrolplane.Install(legacyscheme.Scheme)
-	schemes := []*runtime.Scheme{legacyscheme.Scheme}
+	schemes := []*runtime.Scheme{legacyscheme.Scheme, genericcontrolplanescheme.Scheme}
 	openAPIDefinitionsGetters := []common.GetOpenAPIDefinitions{generatedopenapi.GetOpenAPIDefinitions}
 
 	apis, err := internalapis.CreateAPIResourceSchemas(schemes, openAPIDefinitionsGetters, syncerInternalAPIs...)
@@ -97,4 +99,24 @@ var syncerInternalAPIs = []internalapis.InternalAPI{
 		Instance:      &corev1.ServiceAccount{},
 		ResourceScope: apiextensionsv1.NamespaceScoped,
 	},
+	{
+		Names: apiextensionsv1.CustomResourceDefinitionNames{
+			Plural:   "roles",
+			Singular: "role",
+			Kind:     "Role",
+		},
+		GroupVersion:  schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"},
+		Instance:      &rbacv1.Role{},
+		ResourceScope: apiextensionsv1.NamespaceScoped,
+	},
+	{
+		Names: apiextensionsv1.CustomResourceDefinitionNames{
+			Plural:   "rolebindings",
+			Singular: "rolebinding",
+			Kind:     "RoleBinding",
+		},
+		GroupVersion:  schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"},
+		Instance:      &rbacv1.RoleBinding{},
+		ResourceScope: apiextensionsv1.NamespaceScoped,
+	},
 }
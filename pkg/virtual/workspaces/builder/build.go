@@ -41,7 +41,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
 )
 
-func BuildVirtualWorkspace(cfg *clientrest.Config, rootPathPrefix string, kcpClusterClient kcpclientset.ClusterInterface) framework.VirtualWorkspace {
+func BuildVirtualWorkspace(cfg *clientrest.Config, rootPathPrefix string, kcpClusterClient kcpclientset.ClusterInterface, kubeClusterClient kcpkubernetesclientset.ClusterInterface) framework.VirtualWorkspace {
 	if !strings.HasSuffix(rootPathPrefix, "/") {
 		rootPathPrefix += "/"
 	}
@@ -73,7 +73,7 @@ func BuildVirtualWorkspace(cfg *clientrest.Config, rootPathPrefix string, kcpClu
 				AddToScheme:        tenancyv1alpha1.AddToScheme,
 				OpenAPIDefinitions: kcpopenapi.GetOpenAPIDefinitions,
 				BootstrapRestResources: func(mainConfig genericapiserver.CompletedConfig) (map[string]fixedgvs.RestStorageBuilder, error) {
-					workspacesRest := registry.NewREST(kcpClusterClient)
+					workspacesRest := registry.NewREST(kcpClusterClient, kubeClusterClient)
 					return map[string]fixedgvs.RestStorageBuilder{
 						"clusterworkspaces": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
 							return workspacesRest, nil
@@ -21,6 +21,7 @@ import (
 	"path"
 	"time"
 
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/spf13/pflag"
 
 	"k8s.io/client-go/rest"
@@ -100,8 +101,12 @@ func (o *Workspaces) NewVirtualWorkspaces(
 	if err != nil {
 		return nil, err
 	}
+	kubeClusterClient, err := kcpkubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
-		{Name: "clusterworkspaces", VirtualWorkspace: builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, "clusterworkspaces"), kcpClusterClient)},
+		{Name: "clusterworkspaces", VirtualWorkspace: builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, "clusterworkspaces"), kcpClusterClient, kubeClusterClient)},
 	}, nil
 }
@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/klog/v2"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// Field selectors supported by the workspaces virtual workspace, on top of the
+// metadata ones that are supported by the kcp API server.
+const (
+	// TypeNameField selects workspaces by the name of their type.
+	TypeNameField = "spec.type.name"
+	// TypePathField selects workspaces by the path of their type.
+	TypePathField = "spec.type.path"
+	// PhaseField selects workspaces by their phase.
+	PhaseField = "status.phase"
+	// SubtreeField, when set to "true", lists the workspaces of the whole subtree
+	// rooted at the requested workspace, trimmed to the workspaces the requesting
+	// user is allowed to list workspaces in.
+	SubtreeField = "subtree"
+)
+
+const (
+	// maxSubtreeDepth bounds the depth of subtree listing.
+	maxSubtreeDepth = 10
+	// defaultMaxSubtreeWorkspaces bounds the number of workspaces visited by subtree listing.
+	defaultMaxSubtreeWorkspaces = 1000
+
+	// subtreeAuthorizationCacheSize and subtreeAuthorizationCacheTTL size the cache of the decisions
+	// whether a user can list the workspaces of a traversed workspace.
+	subtreeAuthorizationCacheSize = 10000
+	subtreeAuthorizationCacheTTL  = 10 * time.Second
+)
+
+// workspaceFilter holds the field selectors evaluated by the virtual workspace itself.
+type workspaceFilter struct {
+	selector fields.Selector
+	subtree  bool
+}
+
+// splitFieldSelector separates the field selectors evaluated by the virtual workspace from
+// the ones that are forwarded to the kcp API server.
+func splitFieldSelector(selector fields.Selector) (forwarded fields.Selector, filter workspaceFilter, err error) {
+	filter.selector = fields.Everything()
+	if selector == nil || selector.Empty() {
+		return selector, filter, nil
+	}
+
+	var forwardedSelectors, filterSelectors []fields.Selector
+	for _, r := range selector.Requirements() {
+		var term fields.Selector
+		switch r.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			term = fields.OneTermEqualSelector(r.Field, r.Value)
+		case selection.NotEquals:
+			term = fields.OneTermNotEqualSelector(r.Field, r.Value)
+		default:
+			return nil, filter, fmt.Errorf("unsupported operator %q for field %q", r.Operator, r.Field)
+		}
+
+		switch r.Field {
+		case SubtreeField:
+			if r.Operator == selection.NotEquals {
+				return nil, filter, fmt.Errorf("field %q only supports equality", SubtreeField)
+			}
+			subtree, err := strconv.ParseBool(r.Value)
+			if err != nil {
+				return nil, filter, fmt.Errorf("invalid value %q for field %q: %w", r.Value, SubtreeField, err)
+			}
+			filter.subtree = subtree
+		case TypeNameField, TypePathField, PhaseField:
+			filterSelectors = append(filterSelectors, term)
+		default:
+			forwardedSelectors = append(forwardedSelectors, term)
+		}
+	}
+
+	if len(filterSelectors) > 0 {
+		filter.selector = fields.AndSelectors(filterSelectors...)
+	}
+	if len(forwardedSelectors) == 0 {
+		return fields.Everything(), filter, nil
+	}
+	return fields.AndSelectors(forwardedSelectors...), filter, nil
+}
+
+func (f workspaceFilter) matches(ws *tenancyv1beta1.Workspace) bool {
+	return f.selector.Matches(fields.Set{
+		TypeNameField: string(ws.Spec.Type.Name),
+		TypePathField: ws.Spec.Type.Path,
+		PhaseField:    string(ws.Status.Phase),
+	})
+}
+
+// listSubtree lists the workspaces of the subtree rooted at the given logical cluster, breadth-first.
+// Only the children of the workspaces the user is allowed to list workspaces in are traversed. The
+// request fails when the subtree has more workspaces than the virtual workspace is willing to visit.
+func (s *REST) listSubtree(ctx context.Context, clusterName logicalcluster.Name, u user.Info, opts metav1.ListOptions) ([]tenancyv1beta1.Workspace, error) {
+	logger := klog.FromContext(ctx)
+
+	var result []tenancyv1beta1.Workspace
+	current := []logicalcluster.Name{clusterName}
+	for depth := 0; depth < maxSubtreeDepth && len(current) > 0; depth++ {
+		var next []logicalcluster.Name
+		for _, cluster := range current {
+			ws, err := s.kcpClusterClient.Cluster(cluster.Path()).TenancyV1beta1().Workspaces().List(ctx, opts)
+			if err != nil {
+				if depth > 0 && (kerrors.IsNotFound(err) || kerrors.IsForbidden(err)) {
+					// the workspace might have been deleted in the meantime
+					continue
+				}
+				return nil, err
+			}
+
+			for i := range ws.Items {
+				w := ws.Items[i]
+				result = append(result, w)
+				if len(result) > s.maxSubtreeWorkspaces {
+					return nil, kerrors.NewBadRequest(fmt.Sprintf("the subtree of workspace %s has more than %d workspaces, list the workspaces of a smaller subtree", clusterName, s.maxSubtreeWorkspaces))
+				}
+
+				if w.Status.Phase != corev1alpha1.LogicalClusterPhaseReady || w.Status.Cluster == "" {
+					continue
+				}
+				child := logicalcluster.Name(w.Status.Cluster)
+				if allowed, err := s.canListWorkspaces(ctx, child, u); err != nil {
+					logger.Error(err, "failed to authorize listing workspaces", "cluster", child)
+				} else if allowed {
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+
+	return result, nil
+}
+
+// canListWorkspaces returns whether the user can list the workspaces of the given logical cluster.
+// Decisions are cached for a short time, so that repeated subtree listings do not cause a
+// SubjectAccessReview for every traversed workspace.
+func (s *REST) canListWorkspaces(ctx context.Context, clusterName logicalcluster.Name, u user.Info) (bool, error) {
+	if s.kubeClusterClient == nil {
+		return false, nil
+	}
+
+	key := authorizationCacheKey(clusterName, u)
+	if allowed, ok := s.authorizationCache.Get(key); ok {
+		return allowed.(bool), nil
+	}
+
+	authz, err := s.delegatedAuthz(clusterName, s.kubeClusterClient)
+	if err != nil {
+		return false, err
+	}
+	decision, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+		User:            u,
+		Verb:            "list",
+		APIGroup:        tenancyv1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      tenancyv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "clusterworkspaces",
+		ResourceRequest: true,
+	})
+	if err != nil {
+		return false, err
+	}
+	allowed := decision == authorizer.DecisionAllow
+	s.authorizationCache.Add(key, allowed, subtreeAuthorizationCacheTTL)
+	return allowed, nil
+}
+
+func authorizationCacheKey(clusterName logicalcluster.Name, u user.Info) string {
+	groups := append([]string(nil), u.GetGroups()...)
+	sort.Strings(groups)
+	return strings.Join([]string{clusterName.String(), u.GetName(), u.GetUID(), strings.Join(groups, ",")}, "/")
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
+	kcpfakekubeclient "github.com/kcp-dev/client-go/kubernetes/fake"
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster/fake"
+)
+
+func TestSplitFieldSelector(t *testing.T) {
+	ready := &tenancyv1beta1.Workspace{
+		Spec:   tenancyv1beta1.WorkspaceSpec{Type: tenancyv1beta1.WorkspaceTypeReference{Name: "team", Path: "root"}},
+		Status: tenancyv1beta1.WorkspaceStatus{Phase: corev1alpha1.LogicalClusterPhaseReady},
+	}
+	initializing := &tenancyv1beta1.Workspace{
+		Spec:   tenancyv1beta1.WorkspaceSpec{Type: tenancyv1beta1.WorkspaceTypeReference{Name: "universal", Path: "root"}},
+		Status: tenancyv1beta1.WorkspaceStatus{Phase: corev1alpha1.LogicalClusterPhaseInitializing},
+	}
+
+	tests := map[string]struct {
+		selector          string
+		expectedForwarded string
+		expectedSubtree   bool
+		matches           []*tenancyv1beta1.Workspace
+		doesNotMatch      []*tenancyv1beta1.Workspace
+		wantErr           bool
+	}{
+		"no selector": {
+			selector:     "",
+			matches:      []*tenancyv1beta1.Workspace{ready, initializing},
+			doesNotMatch: nil,
+		},
+		"metadata selector is forwarded": {
+			selector:          "metadata.name=foo",
+			expectedForwarded: "metadata.name=foo",
+			matches:           []*tenancyv1beta1.Workspace{ready, initializing},
+		},
+		"phase and type are filtered": {
+			selector:          "metadata.name=foo,status.phase=Ready,spec.type.name=team",
+			expectedForwarded: "metadata.name=foo",
+			matches:           []*tenancyv1beta1.Workspace{ready},
+			doesNotMatch:      []*tenancyv1beta1.Workspace{initializing},
+		},
+		"negated phase": {
+			selector:     "status.phase!=Ready",
+			matches:      []*tenancyv1beta1.Workspace{initializing},
+			doesNotMatch: []*tenancyv1beta1.Workspace{ready},
+		},
+		"subtree": {
+			selector:        "subtree=true,spec.type.path=root",
+			expectedSubtree: true,
+			matches:         []*tenancyv1beta1.Workspace{ready, initializing},
+		},
+		"invalid subtree": {
+			selector: "subtree=maybe",
+			wantErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			selector, err := fields.ParseSelector(tc.selector)
+			require.NoError(t, err)
+
+			forwarded, filter, err := splitFieldSelector(selector)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedForwarded, forwarded.String())
+			require.Equal(t, tc.expectedSubtree, filter.subtree)
+			for _, ws := range tc.matches {
+				require.True(t, filter.matches(ws), "expected %v to match %q", ws.Spec, tc.selector)
+			}
+			for _, ws := range tc.doesNotMatch {
+				require.False(t, filter.matches(ws), "expected %v not to match %q", ws.Spec, tc.selector)
+			}
+		})
+	}
+}
+
+func TestListSubtree(t *testing.T) {
+	workspace := func(parent, name, cluster string) runtime.Object {
+		return &tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: parent},
+			},
+			Status: tenancyv1beta1.WorkspaceStatus{
+				Phase:   corev1alpha1.LogicalClusterPhaseReady,
+				Cluster: cluster,
+			},
+		}
+	}
+	objects := []runtime.Object{
+		workspace("root", "a", "c-a"),
+		workspace("root", "b", "c-b"),
+		workspace("root", "c", "c-c"),
+		workspace("c-a", "a1", "c-a1"),
+		workspace("c-b", "b1", "c-b1"),
+		workspace("c-c", "c1", "c-c1"),
+	}
+
+	tests := map[string]struct {
+		maxWorkspaces int
+		expected      int
+		wantErr       bool
+	}{
+		"within limit":   {maxWorkspaces: 10, expected: 6},
+		"at limit":       {maxWorkspaces: 6, expected: 6},
+		"exceeded limit": {maxWorkspaces: 4, wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var reviews int
+			s := &REST{
+				kcpClusterClient:  kcpfakeclient.NewSimpleClientset(objects...),
+				kubeClusterClient: kcpfakekubeclient.NewSimpleClientset(),
+				delegatedAuthz: func(clusterName logicalcluster.Name, client kcpkubernetesclientset.ClusterInterface) (authorizer.Authorizer, error) {
+					return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+						reviews++
+						return authorizer.DecisionAllow, "", nil
+					}), nil
+				},
+				maxSubtreeWorkspaces: tc.maxWorkspaces,
+				authorizationCache:   utilcache.NewLRUExpireCache(100),
+			}
+			u := &user.DefaultInfo{Name: "user", Groups: []string{"group"}}
+
+			items, err := s.listSubtree(context.Background(), "root", u, metav1.ListOptions{})
+			if tc.wantErr {
+				require.True(t, kerrors.IsBadRequest(err), "expected a bad request, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, items, tc.expected)
+			require.Equal(t, 6, reviews)
+
+			// authorization decisions are cached across listings
+			_, err = s.listSubtree(context.Background(), "root", u, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Equal(t, 6, reviews)
+		})
+	}
+}
@@ -20,12 +20,14 @@ import (
 	"context"
 	"fmt"
 
+	kcpkubernetesclientset "github.com/kcp-dev/client-go/kubernetes"
 	"github.com/kcp-dev/logicalcluster/v3"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/watch"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
@@ -50,9 +52,15 @@ const (
 )
 
 type REST struct {
-	kcpClusterClient kcpclientset.ClusterInterface
-	delegatedAuthz   delegated.DelegatedAuthorizerFactory
+	kcpClusterClient  kcpclientset.ClusterInterface
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface
+	delegatedAuthz    delegated.DelegatedAuthorizerFactory
 	rest.TableConvertor
+
+	// maxSubtreeWorkspaces is the maximum number of workspaces visited by a subtree listing.
+	maxSubtreeWorkspaces int
+	// authorizationCache caches whether users can list the workspaces of a logical cluster.
+	authorizationCache *utilcache.LRUExpireCache
 }
 
 var _ rest.Getter = &REST{}
@@ -66,10 +74,15 @@ var _ rest.GracefulDeleter = &REST{}
 // projecting them to the Workspace type.
 func NewREST(
 	kcpClusterClient kcpclientset.ClusterInterface,
+	kubeClusterClient kcpkubernetesclientset.ClusterInterface,
 ) *REST {
 	mainRest := &REST{
-		kcpClusterClient: kcpClusterClient,
-		delegatedAuthz:   delegated.NewDelegatedAuthorizer,
+		kcpClusterClient:  kcpClusterClient,
+		kubeClusterClient: kubeClusterClient,
+		delegatedAuthz:    delegated.NewDelegatedAuthorizer,
+
+		maxSubtreeWorkspaces: defaultMaxSubtreeWorkspaces,
+		authorizationCache:   utilcache.NewLRUExpireCache(subtreeAuthorizationCacheSize),
 
 		TableConvertor: printerstorage.TableConvertor{TableGenerator: printers.NewTableGenerator().With(workspaceprinters.AddWorkspacePrintHandlers)},
 	}
@@ -96,27 +109,56 @@ func (s *REST) NamespaceScoped() bool {
 	return false
 }
 
-// List retrieves a list of Workspaces that match label.
+// List retrieves a list of Workspaces that match label, and field selectors, optionally
+// across the whole subtree of the requested workspace.
 func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (runtime.Object, error) {
 	clusterName := ctx.Value(ClusterKey).(logicalcluster.Name)
 
+	options, filter, err := withoutFilterFieldSelectors(options)
+	if err != nil {
+		return nil, err
+	}
+
 	v1Opts := metav1.ListOptions{}
 	if err := metainternal.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1Opts, nil); err != nil {
 		return nil, err
 	}
-	ws, err := s.kcpClusterClient.Cluster(clusterName.Path()).TenancyV1beta1().Workspaces().List(ctx, v1Opts)
-	if err != nil {
-		return nil, err
+
+	var items []tenancyv1beta1.Workspace
+	var listMeta metav1.ListMeta
+	if filter.subtree {
+		if v1Opts.Limit > 0 || v1Opts.Continue != "" {
+			return nil, kerrors.NewBadRequest(fmt.Sprintf("pagination is not supported with the %q field selector", SubtreeField))
+		}
+		userInfo, ok := apirequest.UserFrom(ctx)
+		if !ok {
+			return nil, kerrors.NewForbidden(tenancyv1alpha1.Resource("clusterworkspaces"), "", fmt.Errorf("unable to list a workspace subtree without a user on the context"))
+		}
+		items, err = s.listSubtree(ctx, clusterName, userInfo, v1Opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ws, err := s.kcpClusterClient.Cluster(clusterName.Path()).TenancyV1beta1().Workspaces().List(ctx, v1Opts)
+		if err != nil {
+			return nil, err
+		}
+		items, listMeta = ws.Items, ws.ListMeta
 	}
 
 	cws := &tenancyv1alpha1.ClusterWorkspaceList{
-		ListMeta: ws.ListMeta,
-		Items:    make([]tenancyv1alpha1.ClusterWorkspace, len(ws.Items)),
+		ListMeta: listMeta,
+		Items:    make([]tenancyv1alpha1.ClusterWorkspace, 0, len(items)),
 	}
 
-	for i := range ws.Items {
-		w := &ws.Items[i]
-		projection.ProjectWorkspaceToClusterWorkspace(w, &cws.Items[i])
+	for i := range items {
+		w := &items[i]
+		if !filter.matches(w) {
+			continue
+		}
+		var projected tenancyv1alpha1.ClusterWorkspace
+		projection.ProjectWorkspaceToClusterWorkspace(w, &projected)
+		cws.Items = append(cws.Items, projected)
 	}
 
 	return cws, nil
@@ -125,6 +167,14 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (watch.Interface, error) {
 	clusterName := ctx.Value(ClusterKey).(logicalcluster.Name)
 
+	options, filter, err := withoutFilterFieldSelectors(options)
+	if err != nil {
+		return nil, err
+	}
+	if filter.subtree {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("watching is not supported with the %q field selector", SubtreeField))
+	}
+
 	v1Opts := metav1.ListOptions{}
 	if err := metainternal.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1Opts, nil); err != nil {
 		return nil, err
@@ -133,7 +183,22 @@ func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (wa
 	if err != nil {
 		return nil, err
 	}
-	return withProjection{delegate: w, ch: make(chan watch.Event)}, nil
+	return withProjection{delegate: w, ch: make(chan watch.Event), filter: filter}, nil
+}
+
+// withoutFilterFieldSelectors returns a copy of the list options, with the field selectors
+// evaluated by the virtual workspace removed.
+func withoutFilterFieldSelectors(options *metainternal.ListOptions) (*metainternal.ListOptions, workspaceFilter, error) {
+	if options == nil {
+		options = &metainternal.ListOptions{}
+	}
+	options = options.DeepCopy()
+	forwarded, filter, err := splitFieldSelector(options.FieldSelector)
+	if err != nil {
+		return nil, filter, kerrors.NewBadRequest(err.Error())
+	}
+	options.FieldSelector = forwarded
+	return options, filter, nil
 }
 
 // Get retrieves a Workspace by name.
@@ -230,6 +295,7 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 type withProjection struct {
 	delegate watch.Interface
 	ch       chan watch.Event
+	filter   workspaceFilter
 }
 
 func (w withProjection) ResultChan() <-chan watch.Event {
@@ -243,6 +309,9 @@ func (w withProjection) ResultChan() <-chan watch.Event {
 				continue
 			}
 			if ws, ok := ev.Object.(*tenancyv1beta1.Workspace); ok {
+				if w.filter.selector != nil && !w.filter.matches(ws) {
+					continue
+				}
 				cws := &tenancyv1alpha1.ClusterWorkspace{}
 				projection.ProjectWorkspaceToClusterWorkspace(ws, cws)
 				ev.Object = cws
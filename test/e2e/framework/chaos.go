@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// FaultProxy is a TCP proxy injecting faults in the connections to a target address, e.g.
+// a shard, the cache server or the syncer tunnels. Clients are pointed at the proxy with
+// ProxiedConfig, or by passing Addr() to the component under test.
+type FaultProxy struct {
+	target   string
+	listener net.Listener
+
+	lock        sync.Mutex
+	latency     time.Duration
+	partitioned bool
+	conns       map[net.Conn]struct{}
+}
+
+// NewFaultProxy starts a proxy to the given host:port. It is closed when the test ends.
+func NewFaultProxy(t *testing.T, target string) *FaultProxy {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &FaultProxy{
+		target:   target,
+		listener: listener,
+		conns:    map[net.Conn]struct{}{},
+	}
+	go p.serve(t)
+	t.Cleanup(func() {
+		listener.Close()
+		p.DropConnections()
+	})
+
+	return p
+}
+
+// Addr returns the host:port the proxy listens on.
+func (p *FaultProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// SetLatency delays the data forwarded in both directions by the given duration. Zero disables
+// the latency.
+func (p *FaultProxy) SetLatency(latency time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.latency = latency
+}
+
+// Partition drops the established connections and refuses the new ones until Heal is called.
+func (p *FaultProxy) Partition() {
+	p.lock.Lock()
+	p.partitioned = true
+	p.lock.Unlock()
+
+	p.DropConnections()
+}
+
+// Heal accepts connections again after Partition.
+func (p *FaultProxy) Heal() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.partitioned = false
+}
+
+// DropConnections closes the established connections, e.g. to simulate the loss of the syncer
+// tunnels. New connections are accepted.
+func (p *FaultProxy) DropConnections() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+		delete(p.conns, conn)
+	}
+}
+
+func (p *FaultProxy) serve(t *testing.T) {
+	for {
+		conn, err := p.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			t.Logf("fault proxy to %s failed to accept a connection: %v", p.target, err)
+			return
+		}
+
+		p.lock.Lock()
+		partitioned := p.partitioned
+		p.lock.Unlock()
+		if partitioned {
+			conn.Close()
+			continue
+		}
+
+		upstream, err := net.Dial("tcp", p.target)
+		if err != nil {
+			t.Logf("fault proxy failed to connect to %s: %v", p.target, err)
+			conn.Close()
+			continue
+		}
+
+		p.lock.Lock()
+		p.conns[conn] = struct{}{}
+		p.conns[upstream] = struct{}{}
+		p.lock.Unlock()
+
+		go p.forward(upstream, conn)
+		go p.forward(conn, upstream)
+	}
+}
+
+func (p *FaultProxy) forward(dst, src net.Conn) {
+	defer func() {
+		p.lock.Lock()
+		delete(p.conns, dst)
+		delete(p.conns, src)
+		p.lock.Unlock()
+		dst.Close()
+		src.Close()
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.lock.Lock()
+			latency := p.latency
+			p.lock.Unlock()
+			if latency > 0 {
+				time.Sleep(latency)
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			// io.EOF, or the connection was dropped
+			return
+		}
+	}
+}
+
+// ProxiedConfig returns a copy of the config sending the requests through the proxy. The TLS
+// server name is kept, so that the serving certificate of the target is still verified.
+func ProxiedConfig(t *testing.T, cfg *rest.Config, proxy *FaultProxy) *rest.Config {
+	t.Helper()
+
+	u, err := url.Parse(cfg.Host)
+	require.NoError(t, err)
+
+	proxied := rest.CopyConfig(cfg)
+	if proxied.TLSClientConfig.ServerName == "" {
+		proxied.TLSClientConfig.ServerName = u.Hostname()
+	}
+	u.Host = proxy.Addr()
+	proxied.Host = u.String()
+	return proxied
+}
+
+// EventuallyServerReady asserts that the server behind the config recovers, i.e. that its
+// /readyz endpoint eventually succeeds.
+func EventuallyServerReady(t *testing.T, cfg *rest.Config) {
+	t.Helper()
+
+	cfg = rest.CopyConfig(cfg)
+	if cfg.NegotiatedSerializer == nil {
+		cfg.NegotiatedSerializer = kubernetesscheme.Codecs.WithoutConversion()
+	}
+	client, err := rest.UnversionedRESTClientFor(cfg)
+	require.NoError(t, err)
+
+	Eventually(t, func() (bool, string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := rest.NewRequest(client).RequestURI("/readyz").Do(ctx).Raw(); err != nil {
+			return false, unreadyComponentsFromError(err)
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, 100*time.Millisecond, "server %s never became ready again", cfg.Host)
+}
@@ -50,8 +50,13 @@ type testConfig struct {
 	kcpTestImage                       string
 	pclusterKubeconfig                 string
 	kcpKubeconfig, rootShardKubeconfig string
+	kcpAuditLog                        string
 	useDefaultKCPServer                bool
 	suites                             string
+	perfScale, perfParallelism         int
+	perfResultsDir                     string
+	serverPoolSize                     int
+	workspaceSnapshots                 bool
 }
 
 var TestConfig *testConfig
@@ -68,6 +73,12 @@ func (c *testConfig) PClusterKubeconfig() string {
 	return c.pclusterKubeconfig
 }
 
+// HasPCluster returns whether syncers are deployed to a real cluster, either given by
+// --pcluster-kubeconfig or managed by the syncer fixture, instead of a fake one.
+func (c *testConfig) HasPCluster() bool {
+	return len(c.PClusterKubeconfig()) > 0 || ManagedKindClusterEnvSet()
+}
+
 func (c *testConfig) KCPKubeconfig() string {
 	// TODO(marun) How to validate before use given that the testing package is calling flags.Parse()?
 	if c.useDefaultKCPServer && len(c.kcpKubeconfig) > 0 {
@@ -87,6 +98,30 @@ func (c *testConfig) RootShardKubeconfig() string {
 	return c.rootShardKubeconfig
 }
 
+func (c *testConfig) KCPAuditLog() string {
+	return c.kcpAuditLog
+}
+
+func (c *testConfig) PerfScale() int {
+	return c.perfScale
+}
+
+func (c *testConfig) PerfParallelism() int {
+	return c.perfParallelism
+}
+
+func (c *testConfig) PerfResultsDir() string {
+	return c.perfResultsDir
+}
+
+func (c *testConfig) ServerPoolSize() int {
+	return c.serverPoolSize
+}
+
+func (c *testConfig) WorkspaceSnapshots() bool {
+	return c.workspaceSnapshots
+}
+
 func (c *testConfig) Suites() []string {
 	return strings.Split(c.suites, ",")
 }
@@ -100,10 +135,16 @@ func init() {
 func registerFlags(c *testConfig) {
 	flag.StringVar(&c.kcpKubeconfig, "kcp-kubeconfig", "", "Path to the kubeconfig for a kcp server.")
 	flag.StringVar(&c.rootShardKubeconfig, "root-shard-kubeconfig", "", "Path to the kubeconfig for a kcp shard server. If unset, kcp-kubeconfig is used.")
+	flag.StringVar(&c.kcpAuditLog, "kcp-audit-log", "", "Path to the JSON audit log of the persistent kcp server. The events received during a failed test are copied to its artifacts.")
 	flag.StringVar(&c.pclusterKubeconfig, "pcluster-kubeconfig", "", "Path to the kubeconfig for a kubernetes cluster to sync to. Requires --syncer-image.")
 	flag.StringVar(&c.syncerImage, "syncer-image", "", "The syncer image to use with the pcluster. Requires --pcluster-kubeconfig")
 	flag.StringVar(&c.kcpTestImage, "kcp-test-image", "", "The test image to use with the pcluster. Requires --pcluster-kubeconfig")
 	flag.BoolVar(&c.useDefaultKCPServer, "use-default-kcp-server", false, "Whether to use server configuration from .kcp/admin.kubeconfig.")
+	flag.IntVar(&c.perfScale, "perf-scale", 10, "The number of samples taken by each measurement of the perf suite.")
+	flag.IntVar(&c.perfParallelism, "perf-parallelism", 1, "The number of operations run concurrently by each measurement of the perf suite.")
+	flag.StringVar(&c.perfResultsDir, "perf-results-dir", "", "Path to the directory where the JSON results of the perf suite are written. If empty, results are only logged.")
+	flag.IntVar(&c.serverPoolSize, "server-pool-size", 4, "The maximum number of dedicated kcp servers running at once in a test package using the server pool.")
+	flag.BoolVar(&c.workspaceSnapshots, "workspace-snapshots", true, "Whether to log and save the bindings, exports and placements of the workspaces of a test when one of its Eventually assertions fails.")
 	flag.StringVar(&c.suites, "suites", "control-plane,transparent-multi-cluster,transparent-multi-cluster:requires-kind", "A comma-delimited list of suites to run.")
 }
 
@@ -0,0 +1,218 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kcpdynamic "github.com/kcp-dev/client-go/dynamic"
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+)
+
+// failureArtifactsTimeout bounds the time spent capturing the artifacts of a failed test.
+const failureArtifactsTimeout = 30 * time.Second
+
+// captureServerArtifactsOnFailure registers the capture, if the test fails, of a /metrics snapshot
+// of each shard of the server, and of the audit events received during the test when the server
+// audit log is given. It must be called after the server is started, so that the capture runs
+// before the server is stopped.
+func captureServerArtifactsOnFailure(t *testing.T, server RunningServer, auditLogPath string) {
+	t.Helper()
+
+	start := time.Now()
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+
+		artifactDir, err := CreateTempDirForTest(t, filepath.Join("artifacts", "kcp", server.Name()))
+		if err != nil {
+			t.Logf("failed to create the artifact dir: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), failureArtifactsTimeout)
+		defer cancel()
+
+		captureShardMetrics(ctx, t, server, filepath.Join(artifactDir, "metrics"))
+		if auditLogPath != "" {
+			if err := sliceAuditLog(auditLogPath, filepath.Join(artifactDir, "audit.log"), start, time.Now()); err != nil {
+				t.Logf("failed to capture the audit log of the test: %v", err)
+			}
+		}
+	})
+}
+
+// captureShardMetrics writes the /metrics of each shard to <dir>/<shard>.txt.
+func captureShardMetrics(ctx context.Context, t *testing.T, server RunningServer, dir string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Logf("failed to create the metrics artifact dir: %v", err)
+		return
+	}
+
+	rootShardConfig := server.RootShardSystemMasterBaseConfig(t)
+	shardConfigs := map[string]*rest.Config{"root": rootShardConfig}
+	if kcpClusterClient, err := kcpclientset.NewForConfig(rootShardConfig); err != nil {
+		t.Logf("failed to create a client for the root shard: %v", err)
+	} else if shards, err := kcpClusterClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().List(ctx, metav1.ListOptions{}); err != nil {
+		t.Logf("failed to list the shards, only capturing the metrics of the root shard: %v", err)
+	} else {
+		for _, shard := range shards.Items {
+			cfg := rest.CopyConfig(rootShardConfig)
+			cfg.Host = shard.Spec.BaseURL
+			shardConfigs[shard.Name] = cfg
+		}
+	}
+
+	for name, cfg := range shardConfigs {
+		if cfg.NegotiatedSerializer == nil {
+			cfg.NegotiatedSerializer = kubernetesscheme.Codecs.WithoutConversion()
+		}
+		client, err := rest.UnversionedRESTClientFor(cfg)
+		if err != nil {
+			t.Logf("failed to create a client for shard %s: %v", name, err)
+			continue
+		}
+		metrics, err := rest.NewRequest(client).RequestURI("/metrics").Do(ctx).Raw()
+		if err != nil {
+			t.Logf("failed to get the metrics of shard %s: %v", name, err)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".txt"), metrics, 0644); err != nil {
+			t.Logf("failed to write the metrics of shard %s: %v", name, err)
+		}
+	}
+}
+
+// sliceAuditLog copies the audit events of the JSON audit log received in the given time window.
+func sliceAuditLog(auditLogPath, output string, start, end time.Time) error {
+	in, err := os.Open(auditLogPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var slice bytes.Buffer
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var event struct {
+			RequestReceivedTimestamp metav1.MicroTime `json:"requestReceivedTimestamp"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if received := event.RequestReceivedTimestamp.Time; received.Before(start) || received.After(end) {
+			continue
+		}
+		slice.Write(scanner.Bytes())
+		slice.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, slice.Bytes(), 0644)
+}
+
+// dumpWorkspaceOnFailure registers the dump, if the test fails, of the objects of the workspace
+// to a YAML file. It must be called after the workspace deletion is registered, so that the dump
+// runs before the workspace is deleted.
+func dumpWorkspaceOnFailure(t *testing.T, server RunningServer, path logicalcluster.Path) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if !t.Failed() || preserveTestResources() {
+			return
+		}
+
+		artifactDir, err := CreateTempDirForTest(t, filepath.Join("artifacts", "workspaces"))
+		if err != nil {
+			t.Logf("failed to create the artifact dir: %v", err)
+			return
+		}
+		file := filepath.Join(artifactDir, strings.ReplaceAll(path.String(), ":", "_")+".yaml")
+
+		ctx, cancel := context.WithTimeout(context.Background(), failureArtifactsTimeout)
+		defer cancel()
+
+		if err := dumpWorkspace(ctx, server.BaseConfig(t), path, file); err != nil {
+			t.Logf("failed to dump workspace %s: %v", path, err)
+		}
+	})
+}
+
+func dumpWorkspace(ctx context.Context, cfg *rest.Config, path logicalcluster.Path, file string) error {
+	kcpClusterClient, err := kcpclientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := kcpdynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	// discovery may partially fail, e.g. for an unavailable APIBinding, in which case the
+	// discovered resources are still dumped.
+	resources, err := discovery.ServerPreferredResources(kcpClusterClient.Cluster(path).Discovery())
+	if len(resources) == 0 && err != nil {
+		return err
+	}
+	gvrs, err := discovery.GroupVersionResources(discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, resources))
+	if err != nil {
+		return err
+	}
+
+	var dump bytes.Buffer
+	for gvr := range gvrs {
+		list, err := dynamicClusterClient.Cluster(path).Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			fmt.Fprintf(&dump, "# failed to list %s: %v\n---\n", gvr.GroupResource(), err)
+			continue
+		}
+		for i := range list.Items {
+			data, err := yaml.Marshal(list.Items[i].Object)
+			if err != nil {
+				return err
+			}
+			dump.Write(data)
+			dump.WriteString("---\n")
+		}
+	}
+
+	return os.WriteFile(file, dump.Bytes(), 0644)
+}
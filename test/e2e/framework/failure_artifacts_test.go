@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestSliceAuditLog(t *testing.T) {
+	start := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+
+	event := func(id string, received time.Time) string {
+		return fmt.Sprintf(`{"auditID":%q,"requestReceivedTimestamp":%q}`, id, received.Format("2006-01-02T15:04:05.000000Z07:00"))
+	}
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(auditLog, []byte(strings.Join([]string{
+		event("before", start.Add(-time.Second)),
+		event("start", start),
+		"not an event",
+		event("during", start.Add(30*time.Second)),
+		event("end", end),
+		event("after", end.Add(time.Second)),
+	}, "\n")+"\n"), 0644))
+
+	output := filepath.Join(t.TempDir(), "slice.log")
+	require.NoError(t, sliceAuditLog(auditLog, output, start, end))
+
+	slice, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		event("start", start),
+		event("during", start.Add(30*time.Second)),
+		event("end", end),
+	}, "\n")+"\n", string(slice))
+}
+
+func TestSliceAuditLogMissing(t *testing.T) {
+	err := sliceAuditLog(filepath.Join(t.TempDir(), "missing.log"), filepath.Join(t.TempDir(), "slice.log"), time.Now(), time.Now())
+	require.Error(t, err)
+}
+
+// newFakeWorkspaceServer serves the discovery and the lists of a workspace from the given
+// responses, by request path relative to the workspace.
+func newFakeWorkspaceServer(t *testing.T, path logicalcluster.Path, responses map[string]string) *rest.Config {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, ok := responses[strings.TrimPrefix(req.URL.Path, path.RequestPath())]
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return &rest.Config{Host: server.URL}
+}
+
+func TestDumpWorkspace(t *testing.T) {
+	path := logicalcluster.NewPath("root:org")
+	cfg := newFakeWorkspaceServer(t, path, map[string]string{
+		"/api":  `{"kind":"APIVersions","versions":["v1"]}`,
+		"/apis": `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`,
+		"/api/v1": `{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"v1","resources":[
+			{"name":"configmaps","namespaced":true,"kind":"ConfigMap","verbs":["get","list"]},
+			{"name":"secrets","namespaced":true,"kind":"Secret","verbs":["get","list"]},
+			{"name":"bindings","namespaced":true,"kind":"Binding","verbs":["create"]}]}`,
+		"/api/v1/configmaps": `{"kind":"ConfigMapList","apiVersion":"v1","metadata":{},"items":[
+			{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"first","namespace":"default"},"data":{"key":"value"}},
+			{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"second","namespace":"default"}}]}`,
+	})
+
+	file := filepath.Join(t.TempDir(), "root_org.yaml")
+	require.NoError(t, dumpWorkspace(context.Background(), cfg, path, file))
+
+	dump, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(dump), "name: first")
+	require.Contains(t, string(dump), "key: value")
+	require.Contains(t, string(dump), "name: second")
+	require.Contains(t, string(dump), "# failed to list secrets")
+	require.NotContains(t, string(dump), "bindings")
+	require.Equal(t, 3, strings.Count(string(dump), "---\n"), "expected one document per object and per failed list")
+}
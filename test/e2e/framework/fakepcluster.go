@@ -0,0 +1,336 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// fakeWorkloadControllersResyncPeriod is how often the fake workload controllers reconcile
+// all the objects of the fake pcluster.
+const fakeWorkloadControllersResyncPeriod = 200 * time.Millisecond
+
+// runFakeWorkloadControllers approximates the kube workload controllers in a fake pcluster,
+// where no pod is ever run:
+//   - a deployment immediately rolls out a ReplicaSet of its pod template, and reports all its
+//     replicas as available,
+//   - a ReplicaSet reports all its replicas as ready,
+//   - the Endpoints of a service list one fake address per ready replica of the ReplicaSets
+//     selected by the service,
+//   - an ingress is exposed on 127.0.0.1.
+//
+// The controllers are stopped when the test ends.
+func runFakeWorkloadControllers(t *testing.T, client kubernetes.Interface) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	c := &fakeWorkloadControllers{client: client, now: time.Now}
+	go func() {
+		defer close(done)
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			for name, reconcile := range map[string]func(context.Context) error{
+				"deployments": c.reconcileDeployments,
+				"replicasets": c.reconcileReplicaSets,
+				"endpoints":   c.reconcileEndpoints,
+				"ingresses":   c.reconcileIngresses,
+			} {
+				// conflicts are expected with the syncer, and resolved at the next resync.
+				if err := reconcile(ctx); err != nil && ctx.Err() == nil && !apierrors.IsConflict(err) {
+					t.Logf("fake %s controller failed: %v", name, err)
+				}
+			}
+		}, fakeWorkloadControllersResyncPeriod)
+	}()
+}
+
+type fakeWorkloadControllers struct {
+	client kubernetes.Interface
+	now    func() time.Time
+}
+
+func (c *fakeWorkloadControllers) reconcileDeployments(ctx context.Context) error {
+	deployments, err := c.client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		if err := c.reconcileDeployment(ctx, &deployments.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fakeWorkloadControllers) reconcileDeployment(ctx context.Context, deployment *appsv1.Deployment) error {
+	if deployment.DeletionTimestamp != nil {
+		return nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	// roll out the ReplicaSet of the current pod template, and delete the previous ones
+	hash, err := podTemplateHash(&deployment.Spec.Template)
+	if err != nil {
+		return err
+	}
+	rsName := deployment.Name + "-" + hash
+	replicaSets, err := c.client.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var current *appsv1.ReplicaSet
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		if rs.Name == rsName {
+			current = rs
+			continue
+		}
+		if err := c.client.AppsV1().ReplicaSets(rs.Namespace).Delete(ctx, rs.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	switch {
+	case current == nil:
+		podLabels := labels.Merge(deployment.Spec.Template.Labels, labels.Set{appsv1.DefaultDeploymentUniqueLabelKey: hash})
+		rs := &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            rsName,
+				Namespace:       deployment.Namespace,
+				Labels:          podLabels,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas: &replicas,
+				Selector: deployment.Spec.Selector,
+				Template: *deployment.Spec.Template.DeepCopy(),
+			},
+		}
+		rs.Spec.Template.Labels = podLabels
+		if _, err := c.client.AppsV1().ReplicaSets(rs.Namespace).Create(ctx, rs, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	case current.Spec.Replicas == nil || *current.Spec.Replicas != replicas:
+		current.Spec.Replicas = &replicas
+		if _, err := c.client.AppsV1().ReplicaSets(current.Namespace).Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	status := appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.Generation,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		ReadyReplicas:      replicas,
+		AvailableReplicas:  replicas,
+		Conditions: []appsv1.DeploymentCondition{
+			{
+				Type:    appsv1.DeploymentAvailable,
+				Status:  corev1.ConditionTrue,
+				Reason:  "MinimumReplicasAvailable",
+				Message: "Deployment has minimum availability.",
+			},
+			{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  corev1.ConditionTrue,
+				Reason:  "NewReplicaSetAvailable",
+				Message: fmt.Sprintf("ReplicaSet %q has successfully progressed.", rsName),
+			},
+		},
+	}
+	for i := range status.Conditions {
+		condition := &status.Conditions[i]
+		condition.LastUpdateTime = metav1.NewTime(c.now())
+		condition.LastTransitionTime = condition.LastUpdateTime
+		for _, existing := range deployment.Status.Conditions {
+			if existing.Type == condition.Type && existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+				condition.LastUpdateTime = existing.LastUpdateTime
+				condition.LastTransitionTime = existing.LastTransitionTime
+			}
+		}
+	}
+	if equality.Semantic.DeepEqual(deployment.Status, status) {
+		return nil
+	}
+	deployment = deployment.DeepCopy()
+	deployment.Status = status
+	_, err = c.client.AppsV1().Deployments(deployment.Namespace).UpdateStatus(ctx, deployment, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *fakeWorkloadControllers) reconcileReplicaSets(ctx context.Context) error {
+	replicaSets, err := c.client.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		replicas := int32(1)
+		if rs.Spec.Replicas != nil {
+			replicas = *rs.Spec.Replicas
+		}
+		status := appsv1.ReplicaSetStatus{
+			ObservedGeneration:   rs.Generation,
+			Replicas:             replicas,
+			FullyLabeledReplicas: replicas,
+			ReadyReplicas:        replicas,
+			AvailableReplicas:    replicas,
+		}
+		if equality.Semantic.DeepEqual(rs.Status, status) {
+			continue
+		}
+		rs.Status = status
+		if _, err := c.client.AppsV1().ReplicaSets(rs.Namespace).UpdateStatus(ctx, rs, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fakeWorkloadControllers) reconcileEndpoints(ctx context.Context) error {
+	services, err := c.client.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if len(service.Spec.Selector) == 0 || service.DeletionTimestamp != nil {
+			continue
+		}
+
+		replicaSets, err := c.client.AppsV1().ReplicaSets(service.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		var addresses []corev1.EndpointAddress
+		for _, rs := range replicaSets.Items {
+			if !selector.Matches(labels.Set(rs.Spec.Template.Labels)) {
+				continue
+			}
+			addresses = append(addresses, fakeEndpointAddresses(&rs)...)
+		}
+		var subsets []corev1.EndpointSubset
+		if len(addresses) > 0 {
+			subset := corev1.EndpointSubset{Addresses: addresses}
+			for _, port := range service.Spec.Ports {
+				targetPort := port.Port
+				if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal > 0 {
+					targetPort = port.TargetPort.IntVal
+				}
+				subset.Ports = append(subset.Ports, corev1.EndpointPort{Name: port.Name, Port: targetPort, Protocol: port.Protocol})
+			}
+			subsets = []corev1.EndpointSubset{subset}
+		}
+
+		endpoints, err := c.client.CoreV1().Endpoints(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			endpoints = &corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: service.Namespace, Labels: service.Labels},
+				Subsets:    subsets,
+			}
+			if _, err := c.client.CoreV1().Endpoints(service.Namespace).Create(ctx, endpoints, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(endpoints.Subsets, subsets) {
+			continue
+		}
+		endpoints.Subsets = subsets
+		if _, err := c.client.CoreV1().Endpoints(service.Namespace).Update(ctx, endpoints, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fakeWorkloadControllers) reconcileIngresses(ctx context.Context) error {
+	ingresses, err := c.client.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range ingresses.Items {
+		ingress := &ingresses.Items[i]
+		if len(ingress.Status.LoadBalancer.Ingress) > 0 {
+			continue
+		}
+		ingress.Status = networkingv1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "127.0.0.1"}}},
+		}
+		if _, err := c.client.NetworkingV1().Ingresses(ingress.Namespace).UpdateStatus(ctx, ingress, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeEndpointAddresses returns a stable fake address for each ready replica of the ReplicaSet.
+func fakeEndpointAddresses(rs *appsv1.ReplicaSet) []corev1.EndpointAddress {
+	h := fnv.New32a()
+	h.Write([]byte(rs.Namespace + "/" + rs.Name)) //nolint:errcheck
+	sum := h.Sum32()
+
+	var addresses []corev1.EndpointAddress
+	for i := int32(0); i < rs.Status.ReadyReplicas && i < 254; i++ {
+		addresses = append(addresses, corev1.EndpointAddress{
+			IP: fmt.Sprintf("10.%d.%d.%d", (sum>>8)&0xff, sum&0xff, i+1),
+		})
+	}
+	return addresses
+}
+
+// podTemplateHash returns a hash of the pod template, like the pod-template-hash label of the
+// ReplicaSets of a deployment.
+func podTemplateHash(template *corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	h.Write(data) //nolint:errcheck
+	return fmt.Sprintf("%x", h.Sum32()), nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+func newFakeDeployment(image string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+			},
+		},
+	}
+}
+
+func TestFakeWorkloadControllersDeployment(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(newFakeDeployment("nginx:1", 3))
+	c := &fakeWorkloadControllers{client: client, now: func() time.Time { return now }}
+
+	require.NoError(t, c.reconcileDeployments(ctx))
+	require.NoError(t, c.reconcileReplicaSets(ctx))
+
+	replicaSets, err := client.AppsV1().ReplicaSets("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, replicaSets.Items, 1)
+	first := replicaSets.Items[0]
+	hash := first.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	require.NotEmpty(t, hash)
+	require.Equal(t, "web-"+hash, first.Name)
+	require.Equal(t, "web", first.Spec.Template.Labels["app"])
+	require.Equal(t, hash, first.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey])
+	require.Equal(t, "web-uid", string(metav1.GetControllerOf(&first).UID))
+	require.Equal(t, int32(3), first.Status.ReadyReplicas)
+
+	deployment, err := client.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), deployment.Status.AvailableReplicas)
+	require.Equal(t, int32(3), deployment.Status.UpdatedReplicas)
+	require.Len(t, deployment.Status.Conditions, 2)
+	for _, condition := range deployment.Status.Conditions {
+		require.Equal(t, corev1.ConditionTrue, condition.Status)
+	}
+
+	// a new pod template rolls out a new ReplicaSet, and deletes the previous one
+	now = now.Add(time.Minute)
+	deployment.Spec.Template.Spec.Containers[0].Image = "nginx:2"
+	deployment.Spec.Replicas = pointer.Int32(2)
+	_, err = client.AppsV1().Deployments("default").Update(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, c.reconcileDeployments(ctx))
+
+	replicaSets, err = client.AppsV1().ReplicaSets("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, replicaSets.Items, 1)
+	require.NotEqual(t, first.Name, replicaSets.Items[0].Name)
+	require.Equal(t, int32(2), *replicaSets.Items[0].Spec.Replicas)
+
+	// conditions keep their transition time while they don't change
+	updated, err := client.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), updated.Status.AvailableReplicas)
+	for _, condition := range updated.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			require.True(t, condition.LastTransitionTime.Equal(&deployment.Status.Conditions[0].LastTransitionTime))
+		}
+	}
+}
+
+func TestFakeWorkloadControllersDeploymentScale(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(newFakeDeployment("nginx:1", 1))
+	c := &fakeWorkloadControllers{client: client, now: time.Now}
+	require.NoError(t, c.reconcileDeployments(ctx))
+
+	deployment, err := client.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	deployment.Spec.Replicas = pointer.Int32(4)
+	_, err = client.AppsV1().Deployments("default").Update(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, c.reconcileDeployments(ctx))
+
+	// scaling keeps the ReplicaSet of the pod template
+	replicaSets, err := client.AppsV1().ReplicaSets("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, replicaSets.Items, 1)
+	require.Equal(t, int32(4), *replicaSets.Items[0].Spec.Replicas)
+}
+
+func TestFakeWorkloadControllersEndpoints(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		newFakeDeployment("nginx:1", 2),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"team": "a"}},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+					{Name: "metrics", Port: 9090, Protocol: corev1.ProtocolTCP},
+				},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"},
+		},
+	)
+	c := &fakeWorkloadControllers{client: client, now: time.Now}
+
+	// no ReplicaSet is ready yet
+	require.NoError(t, c.reconcileEndpoints(ctx))
+	endpoints, err := client.CoreV1().Endpoints("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, endpoints.Subsets)
+	require.Equal(t, "a", endpoints.Labels["team"])
+
+	require.NoError(t, c.reconcileDeployments(ctx))
+	require.NoError(t, c.reconcileReplicaSets(ctx))
+	require.NoError(t, c.reconcileEndpoints(ctx))
+
+	endpoints, err = client.CoreV1().Endpoints("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, endpoints.Subsets, 1)
+	require.Len(t, endpoints.Subsets[0].Addresses, 2)
+	require.NotEqual(t, endpoints.Subsets[0].Addresses[0].IP, endpoints.Subsets[0].Addresses[1].IP)
+	require.Equal(t, []corev1.EndpointPort{
+		{Name: "http", Port: 8080, Protocol: corev1.ProtocolTCP},
+		{Name: "metrics", Port: 9090, Protocol: corev1.ProtocolTCP},
+	}, endpoints.Subsets[0].Ports)
+
+	// the addresses are stable
+	require.NoError(t, c.reconcileEndpoints(ctx))
+	again, err := client.CoreV1().Endpoints("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, endpoints.Subsets, again.Subsets)
+
+	// services without selector are left alone
+	_, err = client.CoreV1().Endpoints("default").Get(ctx, "external", metav1.GetOptions{})
+	require.Error(t, err)
+}
+
+func TestFakeWorkloadControllersIngress(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	})
+	c := &fakeWorkloadControllers{client: client, now: time.Now}
+	require.NoError(t, c.reconcileIngresses(ctx))
+
+	ingress, err := client.NetworkingV1().Ingresses("default").Get(ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []corev1.LoadBalancerIngress{{IP: "127.0.0.1"}}, ingress.Status.LoadBalancer.Ingress)
+}
@@ -94,6 +94,8 @@ type kcpFixture struct {
 
 // PrivateKcpServer returns a new kcp server fixture managing a new
 // server process that is not intended to be shared between tests.
+// Within RunWithServerPool, the server is allocated by the pool and
+// counts towards its size.
 func PrivateKcpServer(t *testing.T, options ...KcpConfigOption) RunningServer {
 	t.Helper()
 
@@ -104,6 +106,10 @@ func PrivateKcpServer(t *testing.T, options ...KcpConfigOption) RunningServer {
 		cfg = opt(cfg)
 	}
 
+	if pool := currentServerPool(); pool != nil && len(cfg.ArtifactDir) == 0 && len(cfg.DataDir) == 0 {
+		return pool.lease(t, *cfg, false)
+	}
+
 	if len(cfg.ArtifactDir) == 0 || len(cfg.DataDir) == 0 {
 		artifactDir, dataDir, err := ScratchDirs(t)
 		require.NoError(t, err, "failed to create scratch dirs: %v", err)
@@ -112,7 +118,10 @@ func PrivateKcpServer(t *testing.T, options ...KcpConfigOption) RunningServer {
 	}
 
 	f := newKcpFixture(t, *cfg)
-	return f.Servers[serverName]
+	server := f.Servers[serverName]
+	// the audit log of a test-managed server is already written to the artifact dir
+	captureServerArtifactsOnFailure(t, server, "")
+	return server
 }
 
 // SharedKcpServer returns a kcp server fixture intended to be shared
@@ -131,6 +140,7 @@ func SharedKcpServer(t *testing.T) RunningServer {
 		t.Logf("shared kcp server will target configuration %q", kubeconfig)
 		server, err := newPersistentKCPServer(serverName, kubeconfig, TestConfig.RootShardKubeconfig())
 		require.NoError(t, err, "failed to create persistent server fixture")
+		captureServerArtifactsOnFailure(t, server, TestConfig.KCPAuditLog())
 		return server
 	}
 
@@ -153,7 +163,9 @@ func SharedKcpServer(t *testing.T) RunningServer {
 		ArtifactDir: artifactDir,
 		DataDir:     dataDir,
 	})
-	return f.Servers[serverName]
+	server := f.Servers[serverName]
+	captureServerArtifactsOnFailure(t, server, "")
+	return server
 }
 
 // Deprecated for use outside this package. Prefer PrivateKcpServer().
@@ -285,10 +297,25 @@ type kcpServer struct {
 	cfg            clientcmd.ClientConfig
 	kubeconfigPath string
 
-	t *testing.T
+	// stop terminates the server process with the given signal, or cancels the server when
+	// running in-process, and waits for it to exit, before the end of the test.
+	stop func(sig os.Signal)
+
+	t lifecycleT
+}
+
+// lifecycleT is the subset of testing.T that scopes the lifecycle of a server, its process and
+// its ports. It is the test that starts the server, unless the server is pooled and outlives it.
+type lifecycleT interface {
+	Helper()
+	Cleanup(func())
+	Log(args ...interface{})
+	Logf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
 }
 
-func newKcpServer(t *testing.T, cfg kcpConfig, artifactDir, dataDir string) (*kcpServer, error) {
+func newKcpServer(t lifecycleT, cfg kcpConfig, artifactDir, dataDir string) (*kcpServer, error) {
 	t.Helper()
 
 	kcpListenPort, err := GetFreePort(t)
@@ -487,6 +514,10 @@ func (c *kcpServer) Run(opts ...RunOption) error {
 				c.t.Errorf("`kcp` failed: %v", err)
 			}
 		}()
+		c.stop = func(os.Signal) {
+			cancel()
+			<-shutdownComplete
+		}
 
 		return nil
 	}
@@ -494,7 +525,8 @@ func (c *kcpServer) Run(opts ...RunOption) error {
 	// NOTE: do not use exec.CommandContext here. That method issues a SIGKILL when the context is done, and we
 	// want to issue SIGTERM instead, to give the server a chance to shut down cleanly.
 	cmd := exec.Command(commandLine[0], commandLine[1:]...)
-	logFile, err := os.Create(filepath.Join(c.artifactDir, "kcp.log"))
+	// the log file is appended to, as the server may be restarted by the test
+	logFile, err := os.OpenFile(filepath.Join(c.artifactDir, "kcp.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		cleanup()
 		return fmt.Errorf("could not create log file: %w", err)
@@ -525,13 +557,25 @@ func (c *kcpServer) Run(opts ...RunOption) error {
 		return err
 	}
 
+	var terminateOnce sync.Once
+	terminate := func(sig os.Signal) {
+		terminateOnce.Do(func() {
+			err := cmd.Process.Signal(sig)
+			if err != nil {
+				c.t.Errorf("Saw an error trying to kill `kcp`: %v", err)
+			}
+		})
+	}
+	// Ensure child process is killed on cleanup
 	c.t.Cleanup(func() {
-		// Ensure child process is killed on cleanup
-		err := cmd.Process.Signal(syscall.SIGTERM)
-		if err != nil {
-			c.t.Errorf("Saw an error trying to kill `kcp`: %v", err)
-		}
+		terminate(syscall.SIGTERM)
 	})
+	c.stop = func(sig os.Signal) {
+		// cancel first, so that the process exiting is not reported as a failure
+		cancel()
+		terminate(sig)
+		<-shutdownComplete
+	}
 
 	go func() {
 		defer cleanup()
@@ -737,10 +781,13 @@ func (c *kcpServer) monitorEndpoint(client *rest.RESTClient, endpoint string) {
 	// we need a shorter deadline than the server, or else:
 	// timeout.go:135] post-timeout activity - time-elapsed: 23.784917ms, GET "/livez" result: Header called after Handler finished
 	ctx := c.ctx
-	if deadline, ok := c.t.Deadline(); ok {
-		deadlinedCtx, deadlinedCancel := context.WithDeadline(c.ctx, deadline.Add(-20*time.Second))
-		ctx = deadlinedCtx
-		c.t.Cleanup(deadlinedCancel) // this does not really matter but govet is upset
+	// pooled servers are not bound to the deadline of a test
+	if tt, ok := c.t.(*testing.T); ok {
+		if deadline, ok := tt.Deadline(); ok {
+			deadlinedCtx, deadlinedCancel := context.WithDeadline(c.ctx, deadline.Add(-20*time.Second))
+			ctx = deadlinedCtx
+			c.t.Cleanup(deadlinedCancel) // this does not really matter but govet is upset
+		}
 	}
 	var errCount int
 	errs := sets.NewString()
@@ -835,7 +882,10 @@ func newPersistentKCPServer(name, kubeconfigPath, rootShardKubeconfigPath string
 }
 
 // NewFakeWorkloadServer creates a workspace in the provided server and org
-// and creates a server fixture for the logical cluster that results.
+// and creates a server fixture for the logical cluster that results. Minimal
+// deployment, replicaset, endpoints and ingress controllers run against the
+// logical cluster until the end of the test, so that synced workloads get a
+// realistic status without running any pod.
 func NewFakeWorkloadServer(t *testing.T, server RunningServer, org logicalcluster.Path, syncTargetName string) RunningServer {
 	t.Helper()
 
@@ -856,6 +906,7 @@ func NewFakeWorkloadServer(t *testing.T, server RunningServer, org logicalcluste
 	require.NoError(t, err)
 	kubefixtures.Create(t, crdClient.ApiextensionsV1().CustomResourceDefinitions(),
 		metav1.GroupResource{Group: "apps.k8s.io", Resource: "deployments"},
+		metav1.GroupResource{Group: "apps.k8s.io", Resource: "replicasets"},
 		metav1.GroupResource{Group: "core.k8s.io", Resource: "services"},
 		metav1.GroupResource{Group: "core.k8s.io", Resource: "endpoints"},
 		metav1.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
@@ -872,6 +923,11 @@ func NewFakeWorkloadServer(t *testing.T, server RunningServer, org logicalcluste
 			t.Logf("error seen waiting for deployment crd to become active: %v", err)
 			return false
 		}
+		_, err = kubeClient.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Logf("error seen waiting for replicaset crd to become active: %v", err)
+			return false
+		}
 		_, err = kubeClient.CoreV1().Services("").List(ctx, metav1.ListOptions{})
 		if err != nil {
 			t.Logf("error seen waiting for service crd to become active: %v", err)
@@ -885,6 +941,8 @@ func NewFakeWorkloadServer(t *testing.T, server RunningServer, org logicalcluste
 		return true
 	}, wait.ForeverTestTimeout, time.Millisecond*100)
 
+	runFakeWorkloadControllers(t, kubeClient)
+
 	return fakeServer
 }
 
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/rest"
+)
+
+// ManagedKindClusterEnvSet returns whether syncer fixtures provision their own kind cluster as
+// pcluster, when --pcluster-kubeconfig is not given. It requires the kind binary in the PATH, and
+// --syncer-image to be available in the local container image store.
+func ManagedKindClusterEnvSet() bool {
+	managed, _ := strconv.ParseBool(os.Getenv("MANAGED_KIND_CLUSTER"))
+	return managed
+}
+
+// startKindCluster creates a kind cluster for the test, loads the given images into it, and
+// returns the path of its kubeconfig. The cluster is deleted at the end of the test, unless
+// PRESERVE is set. The upstream server must be reachable from the pods of the cluster.
+func startKindCluster(t *testing.T, upstreamConfig *rest.Config, images ...string) string {
+	t.Helper()
+
+	upstreamURL, err := url.Parse(upstreamConfig.Host)
+	require.NoError(t, err)
+	if host := upstreamURL.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+		t.Fatalf("kcp server %s is not reachable from the pods of a kind cluster", upstreamConfig.Host)
+	}
+
+	artifactDir, _, err := ScratchDirs(t)
+	require.NoError(t, err)
+	name := "kcp-e2e-" + rand.String(8)
+	kubeconfigPath := filepath.Join(artifactDir, name+".kubeconfig")
+
+	start := time.Now()
+	t.Logf("Creating kind cluster %s", name)
+	runKind(t, "create", "cluster", "--name", name, "--kubeconfig", kubeconfigPath, "--wait", "5m")
+	t.Cleanup(func() {
+		if preserveTestResources() {
+			t.Logf("Preserving kind cluster %s, with kubeconfig %s", name, kubeconfigPath)
+			return
+		}
+		t.Logf("Deleting kind cluster %s", name)
+		if output, err := exec.Command("kind", "delete", "cluster", "--name", name).CombinedOutput(); err != nil {
+			t.Errorf("failed to delete kind cluster %s: %v: %s", name, err, output)
+		}
+	})
+
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		t.Logf("Loading image %s into kind cluster %s", image, name)
+		runKind(t, "load", "docker-image", image, "--name", name)
+	}
+	t.Logf("Created kind cluster %s after %s", name, time.Since(start))
+
+	return kubeconfigPath
+}
+
+func runKind(t *testing.T, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("kind", args...)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "failed to run kind %s: %s", strings.Join(args, " "), output)
+}
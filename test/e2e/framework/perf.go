@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// PerfPollInterval is the interval at which perf measurements poll for convergence. It bounds
+// the resolution of the reported latencies.
+const PerfPollInterval = 50 * time.Millisecond
+
+// PerfResult is the summary of the latency samples of a measured operation, as written
+// to the perf results directory.
+type PerfResult struct {
+	Name        string    `json:"name"`
+	Timestamp   time.Time `json:"timestamp"`
+	Scale       int       `json:"scale"`
+	Parallelism int       `json:"parallelism"`
+	P50Millis   float64   `json:"p50Millis"`
+	P95Millis   float64   `json:"p95Millis"`
+	MaxMillis   float64   `json:"maxMillis"`
+}
+
+// MeasureLatency runs op --perf-scale times, with at most --perf-parallelism operations in
+// flight, and summarizes the latencies they return. op is called with the index of the sample
+// and must not call t.Fatal, as it may run outside the test goroutine.
+func MeasureLatency(t *testing.T, name string, op func(i int) (time.Duration, error)) *PerfResult {
+	t.Helper()
+
+	scale, parallelism := TestConfig.PerfScale(), TestConfig.PerfParallelism()
+	require.Greater(t, scale, 0, "--perf-scale must be positive")
+	require.Greater(t, parallelism, 0, "--perf-parallelism must be positive")
+
+	var (
+		lock    sync.Mutex
+		samples []time.Duration
+		errs    []error
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, parallelism)
+	for i := 0; i < scale; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			d, err := op(i)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("sample %d: %w", i, err))
+				return
+			}
+			samples = append(samples, d)
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, utilerrors.NewAggregate(errs), "failed to measure %s", name)
+
+	result := summarizeLatencies(name, samples)
+	result.Scale, result.Parallelism = scale, parallelism
+	t.Logf("Measured %s over %d samples: p50=%.0fms p95=%.0fms max=%.0fms", name, len(samples), result.P50Millis, result.P95Millis, result.MaxMillis)
+	return result
+}
+
+// WritePerfResults writes the results as JSON to --perf-results-dir, in a file named after
+// the test. Nothing is written if the flag is not set.
+func WritePerfResults(t *testing.T, results ...*PerfResult) {
+	t.Helper()
+
+	dir := TestConfig.PerfResultsDir()
+	if dir == "" {
+		return
+	}
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	require.NoError(t, err)
+	path := filepath.Join(dir, strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(t.Name())+".json")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	t.Logf("Wrote perf results to %s", path)
+}
+
+func summarizeLatencies(name string, samples []time.Duration) *PerfResult {
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	result := &PerfResult{
+		Name:      name,
+		Timestamp: time.Now().UTC(),
+	}
+	if len(sorted) == 0 {
+		return result
+	}
+	result.P50Millis = millis(percentile(sorted, 50))
+	result.P95Millis = millis(percentile(sorted, 95))
+	result.MaxMillis = millis(sorted[len(sorted)-1])
+	return result
+}
+
+// percentile returns the nearest-rank percentile p of the sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	activeServerPool     *serverPool
+	activeServerPoolLock sync.Mutex
+)
+
+// RunWithServerPool runs the tests of a package with a pool of dedicated kcp servers, used by
+// PrivateKcpServer and PooledKcpServer. The pool bounds the number of servers running at once
+// to --server-pool-size, and stops the servers left once the tests have completed. It is meant
+// to be called from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(framework.RunWithServerPool(m))
+//	}
+func RunWithServerPool(m *testing.M) int {
+	pool := &serverPool{idle: map[string][]*pooledServer{}}
+	pool.cond = sync.NewCond(&pool.lock)
+
+	activeServerPoolLock.Lock()
+	activeServerPool = pool
+	activeServerPoolLock.Unlock()
+
+	defer pool.shutdown()
+	return m.Run()
+}
+
+func currentServerPool() *serverPool {
+	activeServerPoolLock.Lock()
+	defer activeServerPoolLock.Unlock()
+	return activeServerPool
+}
+
+// PooledKcpServer returns a kcp server dedicated to the test until it completes. The server is
+// then handed over to the next test asking for one with the same arguments, instead of being
+// stopped, so tests must leave it as they found it, as with SharedKcpServer. It is discarded if
+// the test fails. Outside of RunWithServerPool, it is equivalent to PrivateKcpServer.
+//
+// Tests covering a server configuration, e.g., its authentication, or destructive tests must
+// use a PrivateKcpServer instead.
+func PooledKcpServer(t *testing.T, options ...KcpConfigOption) RunningServer {
+	t.Helper()
+
+	pool := currentServerPool()
+	if pool == nil {
+		return PrivateKcpServer(t, options...)
+	}
+
+	cfg := &kcpConfig{Name: "main"}
+	for _, opt := range options {
+		cfg = opt(cfg)
+	}
+	if len(cfg.ArtifactDir) > 0 || len(cfg.DataDir) > 0 {
+		// the directories of a test do not outlive it
+		return PrivateKcpServer(t, options...)
+	}
+	return pool.lease(t, *cfg, true)
+}
+
+// serverPool allocates the ports and directories of dedicated kcp servers, and keeps the
+// reusable ones running, idle, between the tests leasing them.
+type serverPool struct {
+	lock sync.Mutex
+	cond *sync.Cond
+	// running is the number of servers started, leased or idle.
+	running int
+	// idle are the servers available for a lease, by configuration key.
+	idle map[string][]*pooledServer
+
+	dirOnce sync.Once
+	dir     string
+	dirErr  error
+	tempDir bool
+}
+
+type pooledServer struct {
+	*kcpServer
+	owner *poolOwner
+	// key identifies the configuration of a reusable server. It is empty for a server that is
+	// stopped at the end of its lease.
+	key string
+}
+
+func (p *serverPool) lease(t *testing.T, cfg kcpConfig, reusable bool) RunningServer {
+	t.Helper()
+
+	dir, err := p.ensureDir()
+	require.NoError(t, err, "failed to create the server pool dir")
+
+	var key string
+	if reusable {
+		if len(cfg.Args) == 0 {
+			cfg.Args = append(
+				TestServerArgsWithTokenAuthFile(filepath.Join(dir, "auth-tokens.csv")),
+				TestServerWithAuditPolicyFile(filepath.Join(dir, "audit-policy.yaml"))...,
+			)
+		}
+		key = strings.Join(append([]string{cfg.Name, fmt.Sprintf("%t/%t", cfg.RunInProcess, cfg.LogToConsole)}, cfg.Args...), "\x00")
+	}
+
+	server, evicted := p.reserve(key)
+	if evicted != nil {
+		t.Logf("Stopping idle pooled kcp server %s to make room", evicted.artifactDir)
+		evicted.owner.shutdown()
+	}
+	if server == nil {
+		artifactDir, dataDir := "", ""
+		if reusable {
+			artifactDir, err = os.MkdirTemp(dir, "server-")
+			dataDir = artifactDir
+		} else {
+			// the logs of a private server belong with the artifacts of its test
+			artifactDir, dataDir, err = ScratchDirs(t)
+		}
+		if err != nil {
+			p.free()
+		}
+		require.NoError(t, err, "failed to create the server dirs")
+
+		start := time.Now()
+		server, err = p.start(t, cfg, artifactDir, dataDir, key)
+		if err != nil {
+			p.free()
+		}
+		require.NoError(t, err, "failed to start pooled kcp server")
+		t.Logf("Started pooled kcp server %s after %s", server.artifactDir, time.Since(start))
+	} else {
+		server.owner.lease(t)
+		t.Logf("Leased idle pooled kcp server %s", server.artifactDir)
+	}
+
+	t.Cleanup(func() {
+		p.release(t, server)
+	})
+	captureServerArtifactsOnFailure(t, server, filepath.Join(server.artifactDir, "kcp.audit"))
+	return server.kcpServer
+}
+
+// reserve returns an idle server for the key, or reserves room to start one. Room is made by
+// evicting an idle server, which must be stopped by the caller, when the pool is full.
+func (p *serverPool) reserve(key string) (idle, evicted *pooledServer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for {
+		if servers := p.idle[key]; key != "" && len(servers) > 0 {
+			server := servers[len(servers)-1]
+			p.idle[key] = servers[:len(servers)-1]
+			if server.owner.hasFailed() {
+				// the server became unhealthy while idle, so it is replaced
+				return nil, server
+			}
+			return server, nil
+		}
+		if size := TestConfig.ServerPoolSize(); p.running < size || size <= 0 {
+			p.running++
+			return nil, nil
+		}
+		for k, servers := range p.idle {
+			if len(servers) > 0 {
+				p.idle[k] = servers[:len(servers)-1]
+				return nil, servers[len(servers)-1]
+			}
+		}
+		p.cond.Wait()
+	}
+}
+
+// free gives back the room reserved for a server that is not running anymore.
+func (p *serverPool) free() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.running--
+	p.cond.Broadcast()
+}
+
+func (p *serverPool) start(t *testing.T, cfg kcpConfig, artifactDir, dataDir, key string) (*pooledServer, error) {
+	owner := &poolOwner{}
+	owner.lease(t)
+	s, err := newKcpServer(owner, cfg, artifactDir, dataDir)
+	if err != nil {
+		owner.shutdown()
+		return nil, err
+	}
+
+	var opts []RunOption
+	if LogToConsoleEnvSet() || cfg.LogToConsole {
+		opts = append(opts, WithLogStreaming)
+	}
+	if InProcessEnvSet() || cfg.RunInProcess {
+		opts = append(opts, RunInProcess)
+	}
+	if err := s.Run(opts...); err != nil {
+		owner.shutdown()
+		return nil, err
+	}
+	if err := s.Ready(!cfg.RunInProcess); err != nil {
+		owner.shutdown()
+		return nil, fmt.Errorf("kcp server %s never became ready: %w", s.name, err)
+	}
+
+	return &pooledServer{kcpServer: s, owner: owner, key: key}, nil
+}
+
+func (p *serverPool) release(t *testing.T, server *pooledServer) {
+	if server.key == "" || t.Failed() || server.owner.hasFailed() {
+		t.Logf("Stopping pooled kcp server %s", server.artifactDir)
+		server.owner.shutdown()
+		server.owner.release()
+		p.free()
+		return
+	}
+
+	server.owner.release()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.idle[server.key] = append(p.idle[server.key], server)
+	p.cond.Broadcast()
+}
+
+func (p *serverPool) shutdown() {
+	p.lock.Lock()
+	var servers []*pooledServer
+	for _, idle := range p.idle {
+		servers = append(servers, idle...)
+	}
+	p.idle = map[string][]*pooledServer{}
+	p.lock.Unlock()
+
+	for _, server := range servers {
+		server.owner.shutdown()
+	}
+	if p.tempDir {
+		if err := os.RemoveAll(p.dir); err != nil {
+			klog.Errorf("failed to remove the server pool dir: %v", err)
+		}
+	}
+}
+
+// ensureDir creates the directory holding the servers of the pool and the files passed to them,
+// under the artifact dir if set.
+func (p *serverPool) ensureDir() (string, error) {
+	p.dirOnce.Do(func() {
+		if dir, set := os.LookupEnv("ARTIFACT_DIR"); set {
+			p.dir = filepath.Join(dir, "server-pool")
+			p.dirErr = os.MkdirAll(p.dir, 0755)
+		} else {
+			p.dir, p.dirErr = os.MkdirTemp("", "kcp-server-pool-")
+			p.tempDir = true
+		}
+		if p.dirErr != nil {
+			return
+		}
+		for _, source := range []string{"auth-tokens.csv", "audit-policy.yaml"} {
+			data, err := fs.ReadFile(source)
+			if err != nil {
+				p.dirErr = fmt.Errorf("error reading embed file %q: %w", source, err)
+				return
+			}
+			if err := os.WriteFile(filepath.Join(p.dir, source), data, 0644); err != nil {
+				p.dirErr = fmt.Errorf("failed to write %q: %w", source, err)
+				return
+			}
+		}
+	})
+	return p.dir, p.dirErr
+}
+
+// poolOwner scopes the lifecycle of a pooled server to the pool. It forwards logs and errors
+// to the test leasing the server, if any, and runs the cleanups when the server is stopped.
+type poolOwner struct {
+	lock     sync.Mutex
+	t        *testing.T
+	failed   bool
+	cleanups []func()
+}
+
+var _ lifecycleT = &poolOwner{}
+
+func (o *poolOwner) lease(t *testing.T) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.t = t
+}
+
+func (o *poolOwner) release() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.t = nil
+}
+
+func (o *poolOwner) hasFailed() bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.failed
+}
+
+// shutdown runs the cleanups in the reverse order of their registration, like testing.T.
+func (o *poolOwner) shutdown() {
+	o.lock.Lock()
+	cleanups := o.cleanups
+	o.cleanups = nil
+	o.lock.Unlock()
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+func (o *poolOwner) Helper() {}
+
+func (o *poolOwner) Cleanup(f func()) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.cleanups = append(o.cleanups, f)
+}
+
+func (o *poolOwner) Log(args ...interface{}) {
+	o.Logf("%s", fmt.Sprint(args...))
+}
+
+func (o *poolOwner) Logf(format string, args ...interface{}) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.t != nil {
+		o.t.Logf(format, args...)
+		return
+	}
+	klog.Infof(format, args...)
+}
+
+func (o *poolOwner) Error(args ...interface{}) {
+	o.Errorf("%s", fmt.Sprint(args...))
+}
+
+func (o *poolOwner) Errorf(format string, args ...interface{}) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.failed = true
+	if o.t != nil {
+		o.t.Errorf(format, args...)
+		return
+	}
+	klog.Errorf(format, args...)
+}
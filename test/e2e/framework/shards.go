@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+)
+
+// AdditionalShard is a kcp shard started by a test and joined to the root shard of a
+// running server. The front-proxy of the server, if any, routes requests to the shard
+// as soon as it is registered.
+type AdditionalShard struct {
+	*kcpServer
+
+	rootShardClient kcpclientset.ClusterInterface
+	runOpts         []RunOption
+	stopOnce        sync.Once
+}
+
+// StartAdditionalShard starts a new shard with the given name, registers it with the root
+// shard of the given server, and waits for it to be ready. The shard is stopped and
+// unregistered when the test ends, or earlier when Stop is called.
+func StartAdditionalShard(t *testing.T, server RunningServer, shardName string, args ...string) *AdditionalShard {
+	t.Helper()
+
+	artifactDir, dataDir, err := ScratchDirs(t)
+	require.NoError(t, err, "failed to create scratch dirs: %v", err)
+
+	rootShardConfig := server.RootShardSystemMasterBaseConfig(t)
+	rootShardKubeconfigPath := filepath.Join(dataDir, shardName+"-root-shard.kubeconfig")
+	require.NoError(t, writeSystemAdminKubeconfig(rootShardConfig, rootShardKubeconfigPath), "failed to write the root shard kubeconfig")
+
+	rootShardClient, err := kcpclientset.NewForConfig(rootShardConfig)
+	require.NoError(t, err)
+
+	s, err := newKcpServer(t, kcpConfig{
+		Name: shardName,
+		Args: append([]string{
+			"--shard-name=" + shardName,
+			"--root-shard-kubeconfig-file=" + rootShardKubeconfigPath,
+		}, args...),
+	}, artifactDir, dataDir)
+	require.NoError(t, err)
+
+	var opts []RunOption
+	if LogToConsoleEnvSet() {
+		opts = append(opts, WithLogStreaming)
+	}
+	if InProcessEnvSet() {
+		opts = append(opts, RunInProcess)
+	}
+	start := time.Now()
+	t.Logf("Starting additional shard %s...", shardName)
+	require.NoError(t, s.Run(opts...))
+	require.NoError(t, s.Ready(!InProcessEnvSet()), "shard %s never became ready", shardName)
+
+	shard := &AdditionalShard{kcpServer: s, rootShardClient: rootShardClient, runOpts: opts}
+	t.Cleanup(shard.Stop)
+
+	Eventually(t, func() (bool, string) {
+		_, err := rootShardClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().Get(context.Background(), shardName, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, 100*time.Millisecond, "shard %s was never registered with the root shard", shardName)
+
+	t.Logf("Started additional shard %s after %s", shardName, time.Since(start))
+
+	return shard
+}
+
+// Stop stops the shard and removes its Shard object from the root shard, such that it is no
+// longer a scheduling target nor routed to. The workspaces scheduled onto the shard are not
+// moved. It is a no-op if the shard is already stopped.
+func (s *AdditionalShard) Stop() {
+	s.stopOnce.Do(func() {
+		s.t.Logf("Stopping additional shard %s", s.name)
+		s.stop(syscall.SIGTERM)
+
+		err := s.rootShardClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().Delete(context.Background(), s.name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			s.t.Errorf("failed to delete the Shard object of %s: %v", s.name, err)
+		}
+	})
+}
+
+// writeSystemAdminKubeconfig writes a kubeconfig for the given config, with a "system:admin"
+// context as expected by --root-shard-kubeconfig-file.
+func writeSystemAdminKubeconfig(cfg *rest.Config, path string) error {
+	const name = "system:admin"
+	return clientcmd.WriteToFile(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			name: {
+				Server:                   cfg.Host,
+				CertificateAuthority:     cfg.CAFile,
+				CertificateAuthorityData: cfg.CAData,
+				InsecureSkipTLSVerify:    cfg.Insecure,
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			name: {
+				ClientCertificate:     cfg.CertFile,
+				ClientCertificateData: cfg.CertData,
+				ClientKey:             cfg.KeyFile,
+				ClientKeyData:         cfg.KeyData,
+				Token:                 cfg.BearerToken,
+				TokenFile:             cfg.BearerTokenFile,
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			name: {Cluster: name, AuthInfo: name},
+		},
+		CurrentContext: name,
+	}, path)
+}
+
+// Restart terminates the shard process with the given signal, e.g. syscall.SIGKILL to simulate
+// a crash, and starts it again with the same data directory. It waits for the shard to be ready.
+// When running in-process, the shard is always stopped gracefully.
+func (s *AdditionalShard) Restart(t *testing.T, sig os.Signal) {
+	t.Helper()
+
+	start := time.Now()
+	t.Logf("Restarting additional shard %s with signal %v", s.name, sig)
+	s.stop(sig)
+	require.NoError(t, s.Run(s.runOpts...))
+	require.NoError(t, s.Ready(!InProcessEnvSet()), "shard %s never became ready again", s.name)
+	t.Logf("Restarted additional shard %s after %s", s.name, time.Since(start))
+}
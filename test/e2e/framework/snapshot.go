@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+)
+
+type trackedWorkspace struct {
+	server RunningServer
+	path   logicalcluster.Path
+}
+
+type workspaceSnapshots struct {
+	workspaces []trackedWorkspace
+	// taken is the number of snapshots taken, used to name their artifacts.
+	taken int
+}
+
+var (
+	trackedWorkspacesLock sync.Mutex
+	// trackedWorkspaces are the workspaces to snapshot, by name of the test tracking them.
+	trackedWorkspaces = map[string]*workspaceSnapshots{}
+)
+
+// TrackWorkspace adds the workspace to the snapshot taken when an Eventually assertion of the
+// test, or of one of its subtests, fails, or when the test ends up failing otherwise. The snapshot
+// logs the phase and the conditions of the logical cluster, APIBindings, APIExports and Placements
+// of the workspace, and writes them to the artifacts of the test. Workspaces created with
+// NewWorkspaceFixture are tracked. Snapshots are disabled with --workspace-snapshots=false.
+func TrackWorkspace(t *testing.T, server RunningServer, path logicalcluster.Path) {
+	t.Helper()
+
+	trackedWorkspacesLock.Lock()
+	snapshots, ok := trackedWorkspaces[t.Name()]
+	if !ok {
+		snapshots = &workspaceSnapshots{}
+		trackedWorkspaces[t.Name()] = snapshots
+		name := t.Name()
+		t.Cleanup(func() {
+			trackedWorkspacesLock.Lock()
+			taken := snapshots.taken
+			trackedWorkspacesLock.Unlock()
+			if t.Failed() && taken == 0 {
+				snapshotWorkspaces(t, "test failed")
+			}
+
+			trackedWorkspacesLock.Lock()
+			defer trackedWorkspacesLock.Unlock()
+			delete(trackedWorkspaces, name)
+		})
+	}
+	snapshots.workspaces = append(snapshots.workspaces, trackedWorkspace{server: server, path: path})
+	trackedWorkspacesLock.Unlock()
+}
+
+// snapshotWorkspaces logs the state of the workspaces tracked by the test and its parents, and
+// writes their objects to the artifacts of the test.
+func snapshotWorkspaces(t *testing.T, reason string) {
+	t.Helper()
+
+	if !TestConfig.WorkspaceSnapshots() {
+		return
+	}
+
+	trackedWorkspacesLock.Lock()
+	var workspaces []trackedWorkspace
+	var seq int
+	for name, snapshots := range trackedWorkspaces {
+		if name == t.Name() || strings.HasPrefix(t.Name(), name+"/") {
+			workspaces = append(workspaces, snapshots.workspaces...)
+			snapshots.taken++
+			if snapshots.taken > seq {
+				seq = snapshots.taken
+			}
+		}
+	}
+	trackedWorkspacesLock.Unlock()
+	if len(workspaces) == 0 {
+		return
+	}
+
+	artifactDir, err := CreateTempDirForTest(t, filepath.Join("artifacts", "snapshots"))
+	if err != nil {
+		t.Logf("failed to create the snapshot artifact dir: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), failureArtifactsTimeout)
+	defer cancel()
+
+	for _, ws := range workspaces {
+		file := filepath.Join(artifactDir, fmt.Sprintf("%d_%s.yaml", seq, strings.ReplaceAll(ws.path.String(), ":", "_")))
+		summary, err := snapshotWorkspace(ctx, ws.server.BaseConfig(t), ws.path, file)
+		if err != nil {
+			t.Logf("failed to snapshot workspace %s: %v", ws.path, err)
+			continue
+		}
+		t.Logf("Snapshot of workspace %s (%s), written to %s:\n%s", ws.path, reason, file, summary)
+	}
+}
+
+// snapshotWorkspace writes the logical cluster, APIBindings, APIExports and Placements of the
+// workspace to the file, and returns a summary of their phases and conditions.
+func snapshotWorkspace(ctx context.Context, cfg *rest.Config, path logicalcluster.Path, file string) (string, error) {
+	kcpClusterClient, err := kcpclientset.NewForConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	client := kcpClusterClient.Cluster(path)
+
+	var summary, dump bytes.Buffer
+	add := func(kind, name, phase string, obj interface{}, conditions conditionsv1alpha1.Conditions) {
+		fmt.Fprintf(&summary, "  %s %s", kind, name)
+		if phase != "" {
+			fmt.Fprintf(&summary, " phase=%s", phase)
+		}
+		for _, c := range conditions {
+			fmt.Fprintf(&summary, " %s=%s", c.Type, c.Status)
+			if c.Status != corev1.ConditionTrue && (c.Reason != "" || c.Message != "") {
+				fmt.Fprintf(&summary, " (%s: %s)", c.Reason, c.Message)
+			}
+		}
+		summary.WriteString("\n")
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			fmt.Fprintf(&dump, "# failed to marshal %s %s: %v\n---\n", kind, name, err)
+			return
+		}
+		fmt.Fprintf(&dump, "# %s %s\n", kind, name)
+		dump.Write(data)
+		dump.WriteString("---\n")
+	}
+	failed := func(resource string, err error) {
+		fmt.Fprintf(&summary, "  failed to list %s: %v\n", resource, err)
+	}
+
+	if logicalClusters, err := client.CoreV1alpha1().LogicalClusters().List(ctx, metav1.ListOptions{}); err != nil {
+		failed("logicalclusters", err)
+	} else {
+		for i := range logicalClusters.Items {
+			lc := &logicalClusters.Items[i]
+			add("LogicalCluster", lc.Name, string(lc.Status.Phase), lc, lc.Status.Conditions)
+		}
+	}
+	if bindings, err := client.ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{}); err != nil {
+		failed("apibindings", err)
+	} else {
+		for i := range bindings.Items {
+			binding := &bindings.Items[i]
+			add("APIBinding", binding.Name, string(binding.Status.Phase), binding, binding.Status.Conditions)
+		}
+	}
+	if exports, err := client.ApisV1alpha1().APIExports().List(ctx, metav1.ListOptions{}); err != nil {
+		failed("apiexports", err)
+	} else {
+		for i := range exports.Items {
+			export := &exports.Items[i]
+			add("APIExport", export.Name, "", export, export.Status.Conditions)
+		}
+	}
+	if placements, err := client.SchedulingV1alpha1().Placements().List(ctx, metav1.ListOptions{}); err != nil {
+		failed("placements", err)
+	} else {
+		for i := range placements.Items {
+			placement := &placements.Items[i]
+			add("Placement", placement.Name, string(placement.Status.Phase), placement, placement.Status.Conditions)
+		}
+	}
+
+	if err := os.WriteFile(file, dump.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return summary.String(), nil
+}
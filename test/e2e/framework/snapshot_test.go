@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+)
+
+var snapshotResponses = map[string]string{
+	"/apis/core.kcp.io/v1alpha1/logicalclusters": `{"kind":"LogicalClusterList","apiVersion":"core.kcp.io/v1alpha1","metadata":{},"items":[
+		{"metadata":{"name":"cluster"},"status":{"phase":"Ready"}}]}`,
+	"/apis/apis.kcp.io/v1alpha1/apibindings": `{"kind":"APIBindingList","apiVersion":"apis.kcp.io/v1alpha1","metadata":{},"items":[
+		{"metadata":{"name":"tenancy"},"status":{"phase":"Bound","conditions":[{"type":"Ready","status":"True"}]}},
+		{"metadata":{"name":"widgets"},"status":{"phase":"Binding","conditions":[
+			{"type":"Ready","status":"False","reason":"APIExportNotFound","message":"APIExport root:org:widgets not found"}]}}]}`,
+	"/apis/apis.kcp.io/v1alpha1/apiexports": `{"kind":"APIExportList","apiVersion":"apis.kcp.io/v1alpha1","metadata":{},"items":[]}`,
+}
+
+func TestSnapshotWorkspace(t *testing.T) {
+	path := logicalcluster.NewPath("root:org")
+	cfg := newFakeWorkspaceServer(t, path, snapshotResponses)
+
+	file := filepath.Join(t.TempDir(), "snapshot.yaml")
+	summary, err := snapshotWorkspace(context.Background(), cfg, path, file)
+	require.NoError(t, err)
+
+	require.Contains(t, summary, "  LogicalCluster cluster phase=Ready\n")
+	require.Contains(t, summary, "  APIBinding tenancy phase=Bound Ready=True\n")
+	require.Contains(t, summary, "  APIBinding widgets phase=Binding Ready=False (APIExportNotFound: APIExport root:org:widgets not found)\n")
+	require.Contains(t, summary, "  failed to list placements: ")
+	require.NotContains(t, summary, "  APIExport ")
+
+	dump, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(dump), "# LogicalCluster cluster\n")
+	require.Contains(t, string(dump), "# APIBinding tenancy\n")
+	require.Contains(t, string(dump), "# APIBinding widgets\n")
+	require.Contains(t, string(dump), "reason: APIExportNotFound")
+}
+
+// fakeRunningServer is a RunningServer whose base config points to a fake server.
+type fakeRunningServer struct {
+	RunningServer
+	cfg *rest.Config
+}
+
+func (s *fakeRunningServer) BaseConfig(t *testing.T) *rest.Config {
+	return rest.CopyConfig(s.cfg)
+}
+
+// snapshotFiles returns the names of the snapshot files written under the artifact dir.
+func snapshotFiles(t *testing.T, artifactDir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.Walk(artifactDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Base(filepath.Dir(path)) == "snapshots" {
+			files = append(files, info.Name())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(files)
+	return files
+}
+
+func TestSnapshotTrackedWorkspaces(t *testing.T) {
+	artifactDir := t.TempDir()
+	t.Setenv("ARTIFACT_DIR", artifactDir)
+
+	org := logicalcluster.NewPath("root:org")
+	team := logicalcluster.NewPath("root:org:team")
+	other := logicalcluster.NewPath("root:other")
+	server := &fakeRunningServer{cfg: newFakeWorkspaceServer(t, org, snapshotResponses)}
+	TrackWorkspace(t, server, org)
+
+	t.Run("subtest", func(t *testing.T) {
+		TrackWorkspace(t, &fakeRunningServer{cfg: newFakeWorkspaceServer(t, team, snapshotResponses)}, team)
+
+		// the snapshot of a subtest includes the workspaces of its parents
+		snapshotWorkspaces(t, "test")
+		snapshotWorkspaces(t, "test")
+		require.Equal(t, []string{"1_root_org.yaml", "1_root_org_team.yaml", "2_root_org.yaml", "2_root_org_team.yaml"}, snapshotFiles(t, artifactDir))
+	})
+
+	t.Run("sibling", func(t *testing.T) {
+		TrackWorkspace(t, &fakeRunningServer{cfg: newFakeWorkspaceServer(t, other, snapshotResponses)}, other)
+
+		// the workspaces of a sibling are not included
+		snapshotWorkspaces(t, "test")
+		require.Contains(t, snapshotFiles(t, artifactDir), "3_root_other.yaml")
+		require.NotContains(t, snapshotFiles(t, artifactDir), "3_root_org_team.yaml")
+	})
+
+	trackedWorkspacesLock.Lock()
+	_, subtestTracked := trackedWorkspaces[t.Name()+"/subtest"]
+	trackedWorkspacesLock.Unlock()
+	require.False(t, subtestTracked, "the workspaces of a subtest must be untracked when it ends")
+}
+
+func TestSnapshotWorkspacesDisabled(t *testing.T) {
+	artifactDir := t.TempDir()
+	t.Setenv("ARTIFACT_DIR", artifactDir)
+
+	enabled := TestConfig.workspaceSnapshots
+	TestConfig.workspaceSnapshots = false
+	t.Cleanup(func() { TestConfig.workspaceSnapshots = enabled })
+
+	path := logicalcluster.NewPath("root:org")
+	TrackWorkspace(t, &fakeRunningServer{cfg: newFakeWorkspaceServer(t, path, snapshotResponses)}, path)
+	snapshotWorkspaces(t, "test")
+	require.Empty(t, snapshotFiles(t, artifactDir))
+}
@@ -126,7 +126,8 @@ func WithDownstreamPreparation(prepare func(config *rest.Config, isFakePCluster
 
 // Start starts a new syncer against the given upstream kcp workspace. Whether the syncer run
 // in-process or deployed on a pcluster will depend whether --pcluster-kubeconfig and
-// --syncer-image are supplied to the test invocation.
+// --syncer-image are supplied to the test invocation. With MANAGED_KIND_CLUSTER set, the
+// pcluster is a kind cluster created for the test.
 func (sf *syncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 	t.Helper()
 
@@ -135,7 +136,7 @@ func (sf *syncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 	require.NoError(t, err)
 	_, kubeconfigPath := WriteLogicalClusterConfig(t, upstreamRawConfig, "base", sf.syncTargetClusterName.Path())
 
-	useDeployedSyncer := len(TestConfig.PClusterKubeconfig()) > 0
+	useDeployedSyncer := TestConfig.HasPCluster()
 
 	syncerImage := TestConfig.SyncerImage()
 	if useDeployedSyncer {
@@ -169,8 +170,12 @@ func (sf *syncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 	var downstreamConfig *rest.Config
 	var downstreamKubeconfigPath string
 	if useDeployedSyncer {
-		// The syncer will target the pcluster identified by `--pcluster-kubeconfig`.
+		// The syncer will target the pcluster identified by `--pcluster-kubeconfig`, or
+		// a kind cluster managed by the fixture.
 		downstreamKubeconfigPath = TestConfig.PClusterKubeconfig()
+		if downstreamKubeconfigPath == "" {
+			downstreamKubeconfigPath = startKindCluster(t, sf.upstreamServer.BaseConfig(t), syncerImage, TestConfig.KCPTestImage())
+		}
 		fs, err := os.Stat(downstreamKubeconfigPath)
 		require.NoError(t, err)
 		require.NotZero(t, fs.Size(), "%s points to an empty file", downstreamKubeconfigPath)
@@ -504,6 +509,13 @@ func (sf *syncerFixture) Start(t *testing.T) *StartedSyncerFixture {
 	// The sync target becoming ready indicates the syncer is healthy and has
 	// successfully sent a heartbeat to kcp.
 	startedSyncer.WaitForClusterReady(ctx, t)
+	if useDeployedSyncer {
+		Eventually(t, func() (bool, string) {
+			syncTarget, err := kcpClusterClient.Cluster(syncerConfig.SyncTargetPath).WorkloadV1alpha1().SyncTargets().Get(ctx, syncerConfig.SyncTargetName, metav1.GetOptions{})
+			require.NoError(t, err)
+			return syncTarget.Status.LastSyncerHeartbeatTime != nil, "no heartbeat received from the deployed syncer"
+		}, wait.ForeverTestTimeout, 100*time.Millisecond, "deployed syncer for %s never sent a heartbeat", syncerConfig.SyncTargetName)
+	}
 
 	return startedSyncer
 }
@@ -33,6 +33,7 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v3"
 	"github.com/martinlindhe/base36"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -212,7 +213,7 @@ func artifact(t *testing.T, server RunningServer, producer func() (runtime.Objec
 }
 
 // GetFreePort asks the kernel for a free open port that is ready to use.
-func GetFreePort(t *testing.T) (string, error) {
+func GetFreePort(t lifecycleT) (string, error) {
 	t.Helper()
 
 	for {
@@ -302,13 +303,14 @@ func LogicalClusterRawConfig(rawConfig clientcmdapi.Config, logicalClusterName l
 
 // Eventually asserts that given condition will be met in waitFor time, periodically checking target function
 // each tick. In addition to require.Eventually, this function t.Logs the raason string value returned by the condition
-// function (eventually after 20% of the wait time) to aid in debugging.
+// function (eventually after 20% of the wait time) to aid in debugging. On failure, the workspaces tracked by
+// the test are snapshotted, see TrackWorkspace.
 func Eventually(t *testing.T, condition func() (success bool, reason string), waitFor time.Duration, tick time.Duration, msgAndArgs ...interface{}) {
 	t.Helper()
 
 	var last string
 	start := time.Now()
-	require.Eventually(t, func() bool {
+	if !assert.Eventually(t, func() bool {
 		t.Helper()
 
 		ok, msg := condition()
@@ -321,7 +323,10 @@ func Eventually(t *testing.T, condition func() (success bool, reason string), wa
 			}
 		}
 		return ok
-	}, waitFor, tick, msgAndArgs...)
+	}, waitFor, tick, msgAndArgs...) {
+		snapshotWorkspaces(t, fmt.Sprintf("condition not met after %s: %s", waitFor, last))
+		t.FailNow()
+	}
 }
 
 // EventuallyReady asserts that the object returned by getter() eventually has a ready condition.
@@ -123,6 +123,8 @@ func NewWorkspaceFixtureObject(t *testing.T, server RunningServer, parent logica
 		}
 		require.NoErrorf(t, err, "failed to delete workspace %s", ws.Name)
 	})
+	dumpWorkspaceOnFailure(t, server, parent.Join(ws.Name))
+	TrackWorkspace(t, server, parent.Join(ws.Name))
 
 	Eventually(t, func() (bool, string) {
 		ws, err = clusterClient.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, ws.Name, metav1.GetOptions{})
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package perf
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	corev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/core/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestWorkspaceReadinessLatency(t *testing.T) {
+	t.Parallel()
+	framework.Suite(t, "perf")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	server := framework.SharedKcpServer(t)
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(server.BaseConfig(t))
+	require.NoError(t, err)
+	client := kcpClusterClient.Cluster(orgClusterName.Path()).TenancyV1alpha1().ClusterWorkspaces()
+
+	result := framework.MeasureLatency(t, "workspace-readiness", func(i int) (time.Duration, error) {
+		start := time.Now()
+		ws, err := client.Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("perf-%d", i)},
+			Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+				Type: tenancyv1alpha1.WorkspaceTypeReference{Name: "universal", Path: "root"},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return 0, err
+		}
+		err = wait.PollImmediateWithContext(ctx, framework.PerfPollInterval, wait.ForeverTestTimeout, func(ctx context.Context) (bool, error) {
+			ws, err := client.Get(ctx, ws.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			return ws.Status.Phase == corev1alpha1.LogicalClusterPhaseReady, nil
+		})
+		return time.Since(start), err
+	})
+	framework.WritePerfResults(t, result)
+}
+
+func TestAPIBindingReadinessLatency(t *testing.T) {
+	t.Parallel()
+	framework.Suite(t, "perf")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	server := framework.SharedKcpServer(t)
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+	providerClusterName := framework.NewWorkspaceFixture(t, server, orgClusterName.Path())
+	consumerClusterName := framework.NewWorkspaceFixture(t, server, orgClusterName.Path())
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(server.BaseConfig(t))
+	require.NoError(t, err)
+
+	t.Logf("Create %d APIExports in %s", framework.TestConfig.PerfScale(), providerClusterName)
+	for i := 0; i < framework.TestConfig.PerfScale(); i++ {
+		_, err := kcpClusterClient.Cluster(providerClusterName.Path()).ApisV1alpha1().APIExports().Create(ctx, &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("perf-%d", i)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	client := kcpClusterClient.Cluster(consumerClusterName.Path()).ApisV1alpha1().APIBindings()
+	result := framework.MeasureLatency(t, "apibinding-readiness", func(i int) (time.Duration, error) {
+		start := time.Now()
+		binding, err := client.Create(ctx, &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("perf-%d", i)},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.BindingReference{
+					Export: &apisv1alpha1.ExportBindingReference{
+						Path: providerClusterName.Path().String(),
+						Name: fmt.Sprintf("perf-%d", i),
+					},
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return 0, err
+		}
+		err = wait.PollImmediateWithContext(ctx, framework.PerfPollInterval, wait.ForeverTestTimeout, func(ctx context.Context) (bool, error) {
+			binding, err := client.Get(ctx, binding.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			return binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound, nil
+		})
+		return time.Since(start), err
+	})
+	framework.WritePerfResults(t, result)
+}
+
+func TestPlacementConvergenceLatency(t *testing.T) {
+	t.Parallel()
+	framework.Suite(t, "perf")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	server := framework.SharedKcpServer(t)
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+	locationClusterName := framework.NewWorkspaceFixture(t, server, orgClusterName.Path())
+	userClusterName := framework.NewWorkspaceFixture(t, server, orgClusterName.Path())
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(server.BaseConfig(t))
+	require.NoError(t, err)
+
+	synctargets := schedulingv1alpha1.GroupVersionResource{
+		Group:    "workload.kcp.io",
+		Version:  "v1alpha1",
+		Resource: "synctargets",
+	}
+
+	t.Logf("Create a Location in %s", locationClusterName)
+	framework.Eventually(t, func() (bool, string) {
+		_, err := kcpClusterClient.Cluster(locationClusterName.Path()).SchedulingV1alpha1().Locations().Create(ctx, &schedulingv1alpha1.Location{
+			ObjectMeta: metav1.ObjectMeta{Name: "perf"},
+			Spec: schedulingv1alpha1.LocationSpec{
+				Resource:         synctargets,
+				InstanceSelector: &metav1.LabelSelector{},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("failed to create location: %v", err)
+		}
+		return true, ""
+	}, wait.ForeverTestTimeout, time.Millisecond*100)
+
+	client := kcpClusterClient.Cluster(userClusterName.Path()).SchedulingV1alpha1().Placements()
+	result := framework.MeasureLatency(t, "placement-convergence", func(i int) (time.Duration, error) {
+		start := time.Now()
+		placement, err := client.Create(ctx, &schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("perf-%d", i)},
+			Spec: schedulingv1alpha1.PlacementSpec{
+				LocationSelectors: []metav1.LabelSelector{{}},
+				NamespaceSelector: &metav1.LabelSelector{},
+				LocationResource:  synctargets,
+				LocationWorkspace: locationClusterName.String(),
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return 0, err
+		}
+		err = wait.PollImmediateWithContext(ctx, framework.PerfPollInterval, wait.ForeverTestTimeout, func(ctx context.Context) (bool, error) {
+			placement, err := client.Get(ctx, placement.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			return conditions.IsTrue(placement, schedulingv1alpha1.PlacementReady) && placement.Status.SelectedLocation != nil, nil
+		})
+		return time.Since(start), err
+	})
+	framework.WritePerfResults(t, result)
+}
@@ -372,6 +372,7 @@ func TestCacheServerInProcess(t *testing.T) {
 	framework.Suite(t, "control-plane")
 
 	// TODO(p0lyn0mial): switch to framework.SharedKcpServer when caching is turned on by default
+	// The server is private, not pooled, as the test covers the token authentication of the server.
 	tokenAuthFile := framework.WriteTokenAuthFile(t)
 	server := framework.PrivateKcpServer(t,
 		framework.WithCustomArguments(framework.TestServerArgsWithTokenAuthFile(tokenAuthFile)...))
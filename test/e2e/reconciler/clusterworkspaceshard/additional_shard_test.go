@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"context"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+
+	"github.com/kcp-dev/kcp/pkg/apis/core"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/cluster"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestAdditionalShard(t *testing.T) {
+	t.Parallel()
+	framework.Suite(t, "control-plane")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	server := framework.SharedKcpServer(t)
+	rootShardClient, err := kcpclientset.NewForConfig(server.RootShardSystemMasterBaseConfig(t))
+	require.NoError(t, err)
+
+	shard := framework.StartAdditionalShard(t, server, "additional-shard")
+
+	t.Logf("Checking the shard is registered with its base URL")
+	registered, err := rootShardClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().Get(ctx, "additional-shard", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, registered.Spec.BaseURL)
+
+	t.Logf("Checking the shard serves requests")
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(shard.BaseConfig(t))
+	require.NoError(t, err)
+	_, err = discoveryClient.ServerVersion()
+	require.NoError(t, err)
+
+	t.Logf("Stopping the shard and checking it is unregistered")
+	shard.Stop()
+	require.Eventually(t, func() bool {
+		_, err := rootShardClient.Cluster(core.RootCluster.Path()).CoreV1alpha1().Shards().Get(ctx, "additional-shard", metav1.GetOptions{})
+		return apierrors.IsNotFound(err)
+	}, wait.ForeverTestTimeout, 100*time.Millisecond)
+}
+
+func TestAdditionalShardFaults(t *testing.T) {
+	t.Parallel()
+	framework.Suite(t, "control-plane")
+
+	server := framework.SharedKcpServer(t)
+	shard := framework.StartAdditionalShard(t, server, "faulty-shard")
+
+	shardConfig := shard.BaseConfig(t)
+	u, err := url.Parse(shardConfig.Host)
+	require.NoError(t, err)
+	proxy := framework.NewFaultProxy(t, u.Host)
+	proxiedConfig := framework.ProxiedConfig(t, shardConfig, proxy)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(proxiedConfig)
+	require.NoError(t, err)
+
+	t.Logf("Checking requests are slowed down by the injected latency")
+	proxy.SetLatency(500 * time.Millisecond)
+	start := time.Now()
+	_, err = discoveryClient.ServerVersion()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Second, "expected the latency to apply to both directions")
+	proxy.SetLatency(0)
+
+	t.Logf("Checking requests fail while the shard is partitioned, and succeed once healed")
+	proxy.Partition()
+	_, err = discoveryClient.ServerVersion()
+	require.Error(t, err)
+	proxy.Heal()
+	framework.EventuallyServerReady(t, proxiedConfig)
+
+	t.Logf("Checking the shard recovers from a crash")
+	shard.Restart(t, syscall.SIGKILL)
+	framework.EventuallyServerReady(t, shard.BaseConfig(t))
+}
@@ -71,7 +71,7 @@ func TestDeploymentCoordinator(t *testing.T) {
 	t.Parallel()
 	framework.Suite(t, "transparent-multi-cluster:requires-kind")
 
-	if len(framework.TestConfig.PClusterKubeconfig()) == 0 {
+	if !framework.TestConfig.HasPCluster() {
 		t.Skip("Test requires a pcluster")
 	}
 
@@ -219,7 +219,7 @@ func TestSyncerLifecycle(t *testing.T) {
 		return true
 	}, wait.ForeverTestTimeout, time.Millisecond*100, "downstream deployment %s/%s was not synced", downstreamNamespaceName, upstreamDeployment.Name)
 
-	if len(framework.TestConfig.PClusterKubeconfig()) > 0 {
+	if framework.TestConfig.HasPCluster() {
 		t.Logf("Check for available replicas if downstream is capable of actually running the deployment")
 		expectedAvailableReplicas := int32(1)
 		var lastEvents time.Time
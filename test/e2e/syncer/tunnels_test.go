@@ -50,7 +50,7 @@ func TestSyncerTunnel(t *testing.T) {
 	t.Parallel()
 	framework.Suite(t, "transparent-multi-cluster:requires-kind")
 
-	if len(framework.TestConfig.PClusterKubeconfig()) == 0 {
+	if !framework.TestConfig.HasPCluster() {
 		t.Skip("Test requires a pcluster")
 	}
 